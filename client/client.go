@@ -0,0 +1,275 @@
+// Package client is a Go client for the healthmon REST and WebSocket API.
+// Its types are the ones the server encodes its responses with, so they
+// never drift from the API.
+//
+//	c := client.New("https://healthmon.example.com", os.Getenv("HM_TOKEN"))
+//	containers, err := c.ListContainers(ctx, client.ContainerFilter{Groups: []string{"media"}})
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// maxUpdateBytes bounds a WebSocket update, which can carry the logs of a
+// restart loop.
+const maxUpdateBytes = 4 << 20
+
+// Client calls one healthmon instance.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New returns a client for the instance at baseURL, e.g.
+// http://localhost:8080. The token is sent as a bearer token when it is
+// not empty; read tokens can do everything but AckAlert.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// WithHTTPClient replaces the HTTP client, e.g. to trust a private CA. Its
+// timeout does not apply to StreamUpdates, which runs until it is stopped.
+func (c *Client) WithHTTPClient(hc *http.Client) {
+	c.http = hc
+}
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("healthmon: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("healthmon: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// ContainerFilter narrows ListContainers. The zero value lists the present
+// containers.
+type ContainerFilter struct {
+	// Present is "true" (the default), "false" for the removed containers
+	// or "all".
+	Present string
+	Groups  []string
+	// Labels are selectors: key, key=value or key!=value.
+	Labels []string
+}
+
+// ListFilter narrows ListEvents and ListAlerts like the parameters of the
+// listings.
+type ListFilter struct {
+	Containers []string
+	Groups     []string
+	Types      []string
+	Severities []string
+	// Since and Until take an RFC3339 time or a duration back from now,
+	// e.g. "36h" or "7d".
+	Since string
+	Until string
+	// Query searches the messages, reasons and details of events.
+	Query string
+	// Unacknowledged hides acknowledged alerts.
+	Unacknowledged bool
+	// Ascending lists oldest first.
+	Ascending bool
+	// Limit is the page size, 50 by default.
+	Limit int
+	// Cursor is the NextCursor or PrevCursor of an earlier page.
+	Cursor string
+}
+
+func (f ListFilter) query() url.Values {
+	q := url.Values{}
+	setList(q, "container", f.Containers)
+	setList(q, "group", f.Groups)
+	setList(q, "type", f.Types)
+	setList(q, "severity", f.Severities)
+	setIf(q, "since", f.Since)
+	setIf(q, "until", f.Until)
+	setIf(q, "q", f.Query)
+	setIf(q, "cursor", f.Cursor)
+	if f.Unacknowledged {
+		q.Set("unacknowledged", "true")
+	}
+	if f.Ascending {
+		q.Set("order", "asc")
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	return q
+}
+
+// ListContainers lists containers with their alert counts and notes.
+func (c *Client) ListContainers(ctx context.Context, filter ContainerFilter) ([]Container, error) {
+	q := url.Values{}
+	setIf(q, "present", filter.Present)
+	setList(q, "group", filter.Groups)
+	for _, label := range filter.Labels {
+		q.Add("label", label)
+	}
+	var out []Container
+	if err := c.do(ctx, http.MethodGet, "/api/containers", q, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListEvents returns a page of events, newest first unless Ascending.
+func (c *Client) ListEvents(ctx context.Context, filter ListFilter) (EventList, error) {
+	var out EventList
+	err := c.do(ctx, http.MethodGet, "/api/events", filter.query(), &out)
+	return out, err
+}
+
+// ListAlerts returns a page of alerts with their comments and hook runs.
+func (c *Client) ListAlerts(ctx context.Context, filter ListFilter) (AlertList, error) {
+	var out AlertList
+	err := c.do(ctx, http.MethodGet, "/api/alerts", filter.query(), &out)
+	return out, err
+}
+
+// GetAlert returns an alert with its comments and hook runs.
+func (c *Client) GetAlert(ctx context.Context, id int64) (Alert, error) {
+	var out Alert
+	err := c.do(ctx, http.MethodGet, "/api/alerts/"+strconv.FormatInt(id, 10), nil, &out)
+	return out, err
+}
+
+// AckAlert acknowledges an alert. It needs an admin token.
+func (c *Client) AckAlert(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodPost, "/api/alerts/"+strconv.FormatInt(id, 10)+"/ack", nil, nil)
+}
+
+// StreamOptions configures StreamUpdates.
+type StreamOptions struct {
+	// ClientName applies the severity filter saved for this dashboard
+	// client with PUT /api/clients/{client}/filter.
+	ClientName string
+}
+
+// StreamUpdates calls fn with every update of the WebSocket stream until
+// ctx is done, fn returns an error or the connection fails, and returns
+// why it stopped. Updates of a container can be coalesced, with the
+// earlier events in Events; a client too slow to take them is
+// disconnected and has to call StreamUpdates again.
+func (c *Client) StreamUpdates(ctx context.Context, opts StreamOptions, fn func(Update) error) error {
+	q := url.Values{}
+	setIf(q, "client", opts.ClientName)
+	target := c.baseURL + "/api/events/stream"
+	if len(q) > 0 {
+		target += "?" + q.Encode()
+	}
+	header := http.Header{}
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+	hc := *c.http
+	hc.Timeout = 0
+	conn, resp, err := websocket.Dial(ctx, target, &websocket.DialOptions{HTTPClient: &hc, HTTPHeader: header})
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			return responseError(resp)
+		}
+		return err
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(maxUpdateBytes)
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		var update Update
+		if err := json.Unmarshal(data, &update); err != nil {
+			return fmt.Errorf("decode update: %w", err)
+		}
+		if err := fn(update); err != nil {
+			conn.Close(websocket.StatusNormalClosure, "")
+			return err
+		}
+	}
+}
+
+// do sends a request and decodes the JSON response into out, unless out is
+// nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// responseError reads the {"error": ...} body of a failed request.
+func responseError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	if resp.Body != nil {
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: body.Error}
+}
+
+func setIf(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func setList(q url.Values, key string, values []string) {
+	if len(values) > 0 {
+		q.Set(key, strings.Join(values, ","))
+	}
+}
+
+// IsNotFound reports whether err is a 404 of the API.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
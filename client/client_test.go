@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/client"
+	"healthmon/internal/api"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestClientAgainstServer(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", StartedAt: now, Labels: map[string]string{"tier": "front"}}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "db", ContainerID: "c-db", Status: "running", StartedAt: now}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "oom", Severity: "red", Message: "oom", Timestamp: now})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+
+	server := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	server.WithAuth(api.AuthOptions{Tokens: map[string]api.TokenScope{"ops": api.ScopeAdmin, "wiki": api.ScopeRead}})
+	ts := httptest.NewServer(server.Routes())
+	defer ts.Close()
+
+	c := client.New(ts.URL+"/", "ops")
+	containers, err := c.ListContainers(ctx, client.ContainerFilter{Labels: []string{"tier=front"}})
+	if err != nil {
+		t.Fatalf("list containers: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Name != "web" || containers[0].AlertCount != 1 {
+		t.Fatalf("unexpected containers %+v", containers)
+	}
+
+	alerts, err := c.ListAlerts(ctx, client.ListFilter{Unacknowledged: true})
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts.Items) != 1 || alerts.Items[0].ID != alertID {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+
+	var apiErr *client.Error
+	if err := client.New(ts.URL, "wiki").AckAlert(ctx, alertID); !errors.As(err, &apiErr) || apiErr.StatusCode != 403 {
+		t.Fatalf("expected a read token to be refused, got %v", err)
+	}
+	if alert, err := c.GetAlert(ctx, alertID); err != nil || alert.ID != alertID || alert.Type != "oom" {
+		t.Fatalf("unexpected alert %+v: %v", alert, err)
+	}
+	if err := c.AckAlert(ctx, alertID+100); !client.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := c.AckAlert(ctx, alertID); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	alerts, err = c.ListAlerts(ctx, client.ListFilter{Unacknowledged: true})
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts.Items) != 0 {
+		t.Fatalf("expected the alert to be acknowledged, got %+v", alerts.Items)
+	}
+
+	if err := client.New(ts.URL, "").StreamUpdates(ctx, client.StreamOptions{}, func(client.Update) error { return nil }); !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("expected the stream to need a token, got %v", err)
+	}
+
+	// The stream is only registered once the WebSocket is accepted, so keep
+	// broadcasting until the first update comes through.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			server.Broadcast(ctx, api.EventUpdate{
+				Container: client.Container{Name: "web"},
+				Event:     &client.Event{ID: 7, Container: "web", Severity: "red"},
+			})
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	stop := errors.New("stop")
+	var got client.Update
+	err = c.StreamUpdates(ctx, client.StreamOptions{}, func(update client.Update) error {
+		got = update
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the stream to end with the callback error, got %v", err)
+	}
+	if got.Container.Name != "web" || got.Event == nil || got.Event.ID != 7 {
+		t.Fatalf("unexpected update %+v", got)
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import "time"
+
+// Container is a container as listed by GET /api/containers and sent in
+// WebSocket updates. Times are RFC3339 in UTC, or relative ("5m ago") when
+// asked for with time=relative.
+type Container struct {
+	ID                   int64             `json:"id"`
+	Name                 string            `json:"name"`
+	DisplayName          string            `json:"display_name,omitempty"`
+	Group                string            `json:"group,omitempty"`
+	ContainerID          string            `json:"container_id"`
+	CurrentContainerName string            `json:"current_container_name"`
+	Image                string            `json:"image"`
+	ImageTag             string            `json:"image_tag"`
+	ImageDigest          string            `json:"image_digest,omitempty"`
+	ImageID              string            `json:"image_id"`
+	CreatedAt            string            `json:"created_at"`
+	RegisteredAt         string            `json:"registered_at"`
+	StartedAt            string            `json:"started_at"`
+	FinishedAt           string            `json:"finished_at"`
+	ExitCode             *int              `json:"exit_code"`
+	Status               string            `json:"status"`
+	Role                 string            `json:"role"`
+	Caps                 []string          `json:"caps"`
+	ReadOnly             bool              `json:"read_only"`
+	NoNewPrivileges      bool              `json:"no_new_privileges"`
+	MemoryReservation    int64             `json:"memory_reservation"`
+	MemoryLimit          int64             `json:"memory_limit"`
+	User                 string            `json:"user"`
+	Present              bool              `json:"present"`
+	RemovedAt            string            `json:"removed_at,omitempty"`
+	HealthStatus         string            `json:"health_status"`
+	HealthFailingStreak  int               `json:"health_failing_streak"`
+	UnhealthySince       string            `json:"unhealthy_since"`
+	UnhealthyGrace       string            `json:"unhealthy_grace,omitempty"`
+	HealthPending        bool              `json:"health_pending"`
+	LastSuccessAt        string            `json:"last_success_at,omitempty"`
+	TaskMaxAge           string            `json:"task_max_age,omitempty"`
+	Platform             string            `json:"platform,omitempty"`
+	DependsOn            []string          `json:"depends_on,omitempty"`
+	Networks             []string          `json:"networks,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty"`
+	RestartLoop          bool              `json:"restart_loop"`
+	RestartStreak        int               `json:"restart_streak"`
+	RestartLoopSince     string            `json:"restart_loop_since"`
+	Healthcheck          *Healthcheck      `json:"healthcheck"`
+	LastHealthProbe      *HealthProbe      `json:"last_health_probe,omitempty"`
+	AlertCount           int64             `json:"alert_count"`
+	Notes                *Notes            `json:"notes,omitempty"`
+}
+
+// Healthcheck is the healthcheck a container was configured with.
+type Healthcheck struct {
+	Test          []string `json:"test"`
+	Interval      string   `json:"interval"`
+	Timeout       string   `json:"timeout"`
+	StartPeriod   string   `json:"start_period"`
+	StartInterval string   `json:"start_interval"`
+	Retries       int      `json:"retries"`
+}
+
+// HealthProbe is the result of the latest healthcheck run.
+type HealthProbe struct {
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output"`
+	At       time.Time `json:"at"`
+}
+
+// Notes is what is known about a service beyond Docker: free-text notes,
+// its owner and its runbook.
+type Notes struct {
+	Container  string `json:"container"`
+	Notes      string `json:"notes"`
+	Owner      string `json:"owner"`
+	RunbookURL string `json:"runbook_url"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+	UpdatedBy  string `json:"updated_by,omitempty"`
+}
+
+// Event is something that happened to a container.
+type Event struct {
+	ID                  int64  `json:"id"`
+	ContainerPK         int64  `json:"container_pk"`
+	Container           string `json:"container"`
+	ContainerID         string `json:"container_id"`
+	ParsedContainerName string `json:"parsed_container_name"`
+	Type                string `json:"type"`
+	Severity            string `json:"severity"`
+	Message             string `json:"message"`
+	Timestamp           string `json:"timestamp"`
+	OldImage            string `json:"old_image"`
+	NewImage            string `json:"new_image"`
+	OldImageID          string `json:"old_image_id"`
+	NewImageID          string `json:"new_image_id"`
+	Reason              string `json:"reason"`
+	DetailsJSON         string `json:"details"`
+	ExitCode            *int   `json:"exit_code"`
+}
+
+// EventList is a page of GET /api/events or /api/containers/{name}/events.
+type EventList struct {
+	Items []Event `json:"items"`
+	Total int64   `json:"total"`
+	PageInfo
+}
+
+// Alert is an event that healthmon notified about.
+type Alert struct {
+	ID                  int64  `json:"id"`
+	ContainerPK         int64  `json:"container_pk"`
+	Container           string `json:"container"`
+	ContainerID         string `json:"container_id"`
+	ParsedContainerName string `json:"parsed_container_name"`
+	Type                string `json:"type"`
+	Severity            string `json:"severity"`
+	Message             string `json:"message"`
+	Timestamp           string `json:"timestamp"`
+	OldImage            string `json:"old_image"`
+	NewImage            string `json:"new_image"`
+	OldImageID          string `json:"old_image_id"`
+	NewImageID          string `json:"new_image_id"`
+	Reason              string `json:"reason"`
+	DetailsJSON         string `json:"details"`
+	ExitCode            *int   `json:"exit_code"`
+	IncidentID          int64  `json:"incident_id,omitempty"`
+	AcknowledgedAt      string `json:"acknowledged_at,omitempty"`
+	// Comments and hook results are only filled in alert listings.
+	Comments []Comment `json:"comments,omitempty"`
+	Hooks    []HookRun `json:"hooks,omitempty"`
+}
+
+// AlertList is a page of GET /api/alerts or /api/containers/{name}/alerts.
+type AlertList struct {
+	Items []Alert `json:"items"`
+	Total int64   `json:"total"`
+	PageInfo
+}
+
+// PageInfo is embedded in paged listings. NextCursor continues past the last
+// item in the listing order, PrevCursor goes back before the first one; an
+// empty page returns the cursor it was requested with, so it can be polled
+// again. With the default newest first order PrevCursor leads to newer rows:
+// a client polls it for rows that arrived since it loaded the page, and they
+// never shift the pages it scrolls through. HasMore tells whether there are
+// more rows in the direction the page was requested in.
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Comment is a comment left on an alert.
+type Comment struct {
+	ID        int64  `json:"id"`
+	AlertID   int64  `json:"alert_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+// HookRun is the result of a command HM_ALERT_HOOKS ran for an alert.
+type HookRun struct {
+	ID         int64  `json:"id"`
+	AlertID    int64  `json:"alert_id"`
+	Target     string `json:"target"`
+	Command    string `json:"command"`
+	ExitCode   int    `json:"exit_code"`
+	Output     string `json:"output"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// AlertLogs is the tail of the container's logs taken for a restart_loop
+// alert, served by GET /api/alerts/{id}/logs to admins only.
+type AlertLogs struct {
+	AlertID int64  `json:"alert_id"`
+	Logs    string `json:"logs"`
+}
+
+// Update is a message of the WebSocket stream: the state of a container,
+// with the event, alert, comment or hook run that changed it.
+type Update struct {
+	Container Container `json:"container"`
+	Event     *Event    `json:"event,omitempty"`
+	// Events are earlier events coalesced into this update by the debounce,
+	// oldest first. Event is the latest.
+	Events              []*Event `json:"events,omitempty"`
+	Alert               *Alert   `json:"alert,omitempty"`
+	Comment             *Comment `json:"comment,omitempty"`
+	HookRun             *HookRun `json:"hook_run,omitempty"`
+	ContainerEventTotal *int64   `json:"container_event_total,omitempty"`
+	EventTotal          *int64   `json:"event_total,omitempty"`
+	AlertTotal          *int64   `json:"alert_total,omitempty"`
+}
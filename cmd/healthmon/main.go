@@ -2,22 +2,54 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
+	"slices"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"healthmon/internal/api"
+	"healthmon/internal/cli"
 	"healthmon/internal/config"
 	"healthmon/internal/db"
+	"healthmon/internal/importer"
 	"healthmon/internal/monitor"
+	"healthmon/internal/notify"
 	"healthmon/internal/store"
 )
 
+// Set at build time with -ldflags "-X main.version=... -X main.commit=...".
+var (
+	version = "dev"
+	commit  = ""
+)
+
 func main() {
 	cfg := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		runRestore(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && slices.Contains(cli.Commands, os.Args[1]) {
+		runCLI(cfg, os.Args[1], os.Args[2:])
+		return
+	}
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -26,8 +58,17 @@ func main() {
 			log.Fatalf("telegram enabled but HM_TG_TOKEN or HM_TG_CHAT_ID missing")
 		}
 	}
+	if _, err := notify.ParseURLs(cfg.NotifyURLs); err != nil {
+		log.Fatalf("HM_NOTIFY_URLS: %v", err)
+	}
 
-	database, err := db.Open(cfg.DBPath)
+	var database *db.DB
+	var err error
+	if cfg.DBDSN != "" {
+		database, err = db.OpenPostgres(cfg.DBDSN)
+	} else {
+		database, err = db.Open(cfg.DBPath)
+	}
 	if err != nil {
 		log.Fatalf("open db: %v", err)
 	}
@@ -37,7 +78,17 @@ func main() {
 		log.Fatalf("migrate db: %v", err)
 	}
 
-	st := store.New(database.SQL)
+	st := store.New(database.Querier())
+	defer st.Close()
+	report, err := st.CheckIntegrity(ctx)
+	if err != nil {
+		log.Printf("db integrity check failed: %v", err)
+	}
+	for _, check := range report.Checks {
+		if check.Rows > 0 {
+			log.Printf("integrity check %s: %d rows (repaired: %v)", check.Name, check.Rows, check.Repaired)
+		}
+	}
 	if err := st.Load(ctx); err != nil {
 		log.Fatalf("load store: %v", err)
 	}
@@ -46,7 +97,18 @@ func main() {
 	server := api.NewServer(st, broadcaster, api.WSOptions{
 		OriginPatterns:     cfg.WSOriginPatterns,
 		InsecureSkipVerify: cfg.WSInsecureSkipVerify,
+		Debounce:           time.Duration(cfg.WSDebounceMillis) * time.Millisecond,
 	})
+	auth, err := authOptions(cfg)
+	if err != nil {
+		log.Fatalf("auth: %v", err)
+	}
+	server.WithAuth(auth)
+	if cfg.DBDSN == "" {
+		server.WithBackup(func(ctx context.Context) (string, error) {
+			return database.Backup(ctx, cfg.BackupDir, cfg.BackupKeep)
+		})
+	}
 	if hasWebDist {
 		staticFS, err := fs.Sub(webDist, "web/dist")
 		if err != nil {
@@ -54,12 +116,37 @@ func main() {
 		}
 		server.WithStatic(http.FS(staticFS))
 	}
+	mqtt, err := notify.NewMQTT(cfg.MQTTURL, cfg.MQTTClientID, cfg.MQTTTopicPrefix)
+	if err != nil {
+		log.Fatalf("mqtt: %v", err)
+	}
+	if mqtt != nil {
+		server.OnUpdate(mqtt.PublishUpdate)
+		go mqtt.Run(ctx)
+	}
 	mon := monitor.New(cfg, st, server)
+	server.WithCrashReporter(mon.CrashReporter())
+	server.WithInspector(mon)
+	server.WithImages(mon, cfg.ImagePrune)
+	server.WithStatus(api.BuildInfo{Version: version, Commit: commit}, mon.Status)
+	mon.WithMaintenance(database.Maintain)
+	server.WithMaintenance(mon.RequestMaintenance)
+	server.WithLimits(api.LimitOptions{
+		RequestsPerSecond: float64(cfg.APIRateLimit),
+		Burst:             cfg.APIRateBurst,
+		MaxBodyBytes:      int64(cfg.APIMaxBodyKB) << 10,
+	})
+	trustedProxies, err := api.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	server.WithTrustedProxies(trustedProxies)
 
 	httpServer := &http.Server{
 		Addr:              cfg.HTTPAddr,
 		Handler:           server.Routes(),
 		ReadHeaderTimeout: 5 * time.Second,
+		MaxHeaderBytes:    cfg.APIMaxHeaderKB << 10,
 	}
 
 	serverErrCh := make(chan error, 1)
@@ -67,8 +154,25 @@ func main() {
 		serverErrCh <- httpServer.ListenAndServe()
 	}()
 
+	if cfg.GRPCAddr != "" {
+		listener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			log.Fatalf("grpc listen: %v", err)
+		}
+		grpcServer := server.GRPC()
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("grpc server stopped: %v", err)
+			}
+		}()
+		// Watch streams only end with their clients, so they are cut
+		// rather than waited for.
+		defer grpcServer.Stop()
+		log.Printf("grpc api listening on %s", cfg.GRPCAddr)
+	}
+
 	go func() {
-		if err := mon.Start(ctx); err != nil && err != context.Canceled {
+		if err := mon.Run(ctx); err != nil && err != context.Canceled {
 			log.Printf("monitor stopped: %v", err)
 			stop()
 		}
@@ -97,3 +201,170 @@ func main() {
 		log.Printf("http server stopped: %v", serverErr)
 	}
 }
+
+// runRestore implements `healthmon restore <file>`, which replaces the
+// database at HM_DB_PATH with a snapshot taken by POST /api/admin/backup.
+func runRestore(cfg config.Config, args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: healthmon restore <file>")
+	}
+	if cfg.DBDSN != "" {
+		log.Fatalf("restore is only supported for SQLite; use pg_restore for PostgreSQL")
+	}
+	if err := db.Restore(context.Background(), args[0], cfg.DBPath); err != nil {
+		log.Fatalf("restore: %v", err)
+	}
+	log.Printf("restored %s from %s", cfg.DBPath, args[0])
+}
+
+// runImport implements `healthmon import <source> <file>`, which loads
+// history exported from another monitor into the configured database. Run it
+// while healthmon is stopped so the running instance's cache does not miss
+// the imported containers.
+func runImport(cfg config.Config, args []string) {
+	sources := make([]string, 0, len(importer.Sources))
+	for _, source := range importer.Sources {
+		sources = append(sources, string(source))
+	}
+	if len(args) != 2 {
+		log.Fatalf("usage: healthmon import <%s> <file|->", strings.Join(sources, "|"))
+	}
+	ctx := context.Background()
+
+	// "-" reads stdin, for piping journalctl into a backfill.
+	in := os.Stdin
+	if args[1] != "-" {
+		f, err := os.Open(args[1])
+		if err != nil {
+			log.Fatalf("import: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	history, err := importer.Parse(importer.Source(args[0]), in)
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+
+	var database *db.DB
+	if cfg.DBDSN != "" {
+		database, err = db.OpenPostgres(cfg.DBDSN)
+	} else {
+		database, err = db.Open(cfg.DBPath)
+	}
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := database.Migrate(ctx); err != nil {
+		log.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(database.Querier())
+	defer st.Close()
+	report, err := st.CheckIntegrity(ctx)
+	if err != nil {
+		log.Printf("db integrity check failed: %v", err)
+	}
+	for _, check := range report.Checks {
+		if check.Rows > 0 {
+			log.Printf("integrity check %s: %d rows (repaired: %v)", check.Name, check.Rows, check.Repaired)
+		}
+	}
+	if err := st.Load(ctx); err != nil {
+		log.Fatalf("load store: %v", err)
+	}
+
+	res, err := importer.Import(ctx, st, history)
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+	log.Printf("imported %s: containers=%d events=%d alerts=%d skipped=%d", args[0], res.Containers, res.Events, res.Alerts, res.Skipped)
+}
+
+// runCLI implements the query subcommands such as `healthmon alerts --since
+// 1h`. They talk to the running instance over HTTP, by default the one this
+// environment configures: HM_HTTP_ADDR on localhost with one of
+// HM_API_TOKENS, so `docker exec healthmon healthmon containers` needs no
+// flags.
+func runCLI(cfg config.Config, command string, args []string) {
+	opts := cli.Options{URL: cfg.CLIURL, Token: cfg.CLIToken, Out: os.Stdout}
+	if opts.URL == "" {
+		host, port, err := net.SplitHostPort(cfg.HTTPAddr)
+		if err == nil {
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "localhost"
+			}
+			opts.URL = "http://" + net.JoinHostPort(host, port)
+		}
+	}
+	if opts.Token == "" && len(cfg.APITokens) > 0 {
+		tokens := make([]string, 0, len(cfg.APITokens))
+		for token := range cfg.APITokens {
+			tokens = append(tokens, token)
+		}
+		sort.Strings(tokens)
+		opts.Token = tokens[0]
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if err := cli.Run(ctx, opts, command, args); err != nil {
+		if err != cli.ErrUsage {
+			fmt.Fprintf(os.Stderr, "healthmon %s: %v\n", command, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// authOptions builds the API authentication from the token, reverse proxy
+// and OIDC settings.
+func authOptions(cfg config.Config) (api.AuthOptions, error) {
+	scopes := func(in map[string]string) map[string]api.TokenScope {
+		out := make(map[string]api.TokenScope, len(in))
+		for key, scope := range in {
+			out[key] = api.TokenScope(scope)
+		}
+		return out
+	}
+	opts := api.AuthOptions{
+		Tokens: scopes(cfg.APITokens),
+		Roles: api.RoleMapping{
+			Users:  scopes(cfg.AuthUsers),
+			Groups: scopes(cfg.AuthGroups),
+		},
+	}
+	switch role := strings.ToLower(cfg.AuthDefaultRole); role {
+	case "", "none":
+	case "read", "admin":
+		opts.Roles.Default = api.TokenScope(role)
+	default:
+		return api.AuthOptions{}, fmt.Errorf("invalid HM_AUTH_DEFAULT_ROLE %q, expected none, read or admin", cfg.AuthDefaultRole)
+	}
+	if cfg.AuthProxyHeader != "" {
+		trusted, err := api.ParseTrustedProxies(cfg.TrustedProxies)
+		if err != nil {
+			return api.AuthOptions{}, err
+		}
+		if len(trusted) == 0 {
+			return api.AuthOptions{}, errors.New("HM_AUTH_PROXY_HEADER needs HM_TRUSTED_PROXIES")
+		}
+		opts.Proxy = api.ProxyAuth{UserHeader: cfg.AuthProxyHeader, GroupsHeader: cfg.AuthProxyGroupsHeader, Trusted: trusted}
+	}
+	if cfg.OIDCIssuer != "" {
+		oidc, err := api.NewOIDC(api.OIDCOptions{
+			Issuer:        cfg.OIDCIssuer,
+			ClientID:      cfg.OIDCClientID,
+			ClientSecret:  cfg.OIDCClientSecret,
+			RedirectURL:   cfg.OIDCRedirectURL,
+			Scopes:        cfg.OIDCScopes,
+			UserClaim:     cfg.OIDCUserClaim,
+			GroupsClaim:   cfg.OIDCGroupsClaim,
+			SessionSecret: []byte(cfg.SessionSecret),
+			SessionTTL:    time.Duration(cfg.SessionHours) * time.Hour,
+		})
+		if err != nil {
+			return api.AuthOptions{}, err
+		}
+		opts.OIDC = oidc
+	}
+	return opts, nil
+}
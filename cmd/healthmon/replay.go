@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/dockerdump"
+	"healthmon/internal/monitor"
+	"healthmon/internal/store"
+)
+
+// runReplay implements `healthmon replay --events x.jsonl --inspects
+// y.jsonl`, which runs the monitor against dumps recorded with
+// HM_RECORD_DIR in recorded time, as fast as it can, and prints the alerts
+// it raised. Rules and thresholds come from the environment as usual, so a
+// change can be checked against a recording before it is deployed.
+// Notifications are never sent.
+func runReplay(cfg config.Config, args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	eventsPath := fs.String("events", "", "events dump (record-*.events.jsonl)")
+	inspectsPath := fs.String("inspects", "", "inspects dump (record-*.inspects.jsonl); defaults to the one next to --events")
+	dbPath := fs.String("db", "", "SQLite database to replay into, kept for a look in the UI; a temporary one by default")
+	verbose := fs.Bool("v", false, "log every event while replaying")
+	_ = fs.Parse(args)
+	if *eventsPath == "" || fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: healthmon replay --events x.events.jsonl [--inspects x.inspects.jsonl] [--db out.db] [-v]")
+		os.Exit(2)
+	}
+	if *inspectsPath == "" {
+		*inspectsPath = strings.TrimSuffix(*eventsPath, ".events.jsonl") + ".inspects.jsonl"
+	}
+
+	messages, err := dockerdump.LoadEvents(*eventsPath)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	inspects, err := dockerdump.LoadInspects(*inspectsPath)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	docker := dockerdump.NewServer(inspects)
+	host, err := docker.Start()
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	defer docker.Close()
+
+	if *dbPath == "" {
+		dir, err := os.MkdirTemp("", "healthmon-replay-")
+		if err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		*dbPath = filepath.Join(dir, "replay.db")
+	}
+	ctx := context.Background()
+	database, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer database.Close()
+	if err := database.Migrate(ctx); err != nil {
+		log.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Now())
+	st := store.New(database.Querier())
+	st.WithClock(fake)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		log.Fatalf("load store: %v", err)
+	}
+
+	// Only the rules apply; nothing leaves the machine.
+	cfg.DockerHost = host
+	cfg.TelegramEnabled = false
+	cfg.GrafanaURL = ""
+	cfg.AppriseURL = ""
+	cfg.ErrorReportURL = ""
+	cfg.HAInstance = ""
+	cfg.SystemdUnits = nil
+	cfg.RecordDir = ""
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	mon := monitor.New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	err = mon.Replay(ctx, fake, messages, docker.SetEventIndex)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10000)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tSEVERITY\tCONTAINER\tTYPE\tMESSAGE")
+	for _, a := range alerts {
+		message, _, _ := strings.Cut(a.Message, "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", a.Timestamp.UTC().Format(time.RFC3339), a.Severity, a.Container, a.Type, message)
+	}
+	_ = tw.Flush()
+	fmt.Printf("replayed %d events, %d alerts\n", len(messages), len(alerts))
+}
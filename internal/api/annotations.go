@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// maxAnnotationMessage bounds the message of a posted event.
+const maxAnnotationMessage = 1000
+
+var annotationTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// AnnotationRequest is the body of POST /api/events.
+type AnnotationRequest struct {
+	Container string `json:"container"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Severity  string `json:"severity"`
+	Timestamp string `json:"timestamp"`
+	Source    string `json:"source"`
+	URL       string `json:"url"`
+}
+
+// handleAnnotation serves POST /api/events, which lets deployment tooling
+// and scripts add their own events, such as "deployed release v1.2.3", to a
+// container's timeline. They are stored and broadcast like the events
+// derived from Docker, with reason "annotation", and never raise alerts.
+func (s *Server) handleAnnotation(w http.ResponseWriter, r *http.Request) {
+	var req AnnotationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || len(req.Message) > maxAnnotationMessage {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("message is required and at most %d bytes", maxAnnotationMessage))
+		return
+	}
+	if req.Type == "" {
+		req.Type = "annotation"
+	}
+	if !annotationTypePattern.MatchString(req.Type) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid type %q, expected lowercase letters, digits and underscores", req.Type))
+		return
+	}
+	if req.Severity == "" {
+		req.Severity = "blue"
+	}
+	if err := checkSeverities([]string{req.Severity}); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	at := time.Now().UTC()
+	if req.Timestamp != "" {
+		parsed, err := time.Parse(time.RFC3339, req.Timestamp)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid timestamp %q, expected RFC3339", req.Timestamp))
+			return
+		}
+		at = parsed.UTC()
+	}
+	c, ok := s.store.GetContainer(req.Container)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown container %q", req.Container))
+		return
+	}
+
+	e := store.Event{
+		ContainerPK: c.ID,
+		Container:   c.Name,
+		ContainerID: c.ContainerID,
+		Type:        req.Type,
+		Severity:    req.Severity,
+		Message:     req.Message,
+		Timestamp:   at,
+		Reason:      "annotation",
+	}
+	if req.Source != "" || req.URL != "" {
+		e.DetailsJSON = store.EncodeDetails(store.AnnotationDetails{Source: req.Source, URL: req.URL})
+	}
+	id, err := s.store.AddEvent(r.Context(), e)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	e.ID = id
+	resp := toEventResponse(e)
+	if updated, ok := s.store.GetContainer(c.Name); ok {
+		c = updated
+	}
+	s.Broadcast(r.Context(), EventUpdate{Container: toContainerResponse(c), Event: resp})
+	writeJSON(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestPostedEventJoinsTheContainerTimeline(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{
+		"wiki":   ScopeRead,
+		"deploy": ScopeAdmin,
+	}})
+	handler := srv.Routes()
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("deploy", `{"container":"web","message":"deployed release v1.2.3","source":"ci","url":"https://ci.example/builds/42","timestamp":"2026-01-02T03:04:05Z"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created EventResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == 0 || created.Type != "annotation" || created.Severity != "blue" || created.Reason != "annotation" || created.Timestamp != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected event %+v", created)
+	}
+	if !strings.Contains(created.DetailsJSON, `"source":"ci"`) {
+		t.Fatalf("expected source in details, got %q", created.DetailsJSON)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/containers/web/events", nil)
+	req.Header.Set("Authorization", "Bearer wiki")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "deployed release v1.2.3") {
+		t.Fatalf("expected the posted event in the timeline, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	cases := []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{name: "read token", token: "wiki", body: `{"container":"web","message":"x"}`, want: http.StatusForbidden},
+		{name: "unknown container", token: "deploy", body: `{"container":"db","message":"x"}`, want: http.StatusNotFound},
+		{name: "missing message", token: "deploy", body: `{"container":"web"}`, want: http.StatusBadRequest},
+		{name: "bad type", token: "deploy", body: `{"container":"web","type":"Deploy!","message":"x"}`, want: http.StatusBadRequest},
+		{name: "bad severity", token: "deploy", body: `{"container":"web","severity":"purple","message":"x"}`, want: http.StatusBadRequest},
+		{name: "bad timestamp", token: "deploy", body: `{"container":"web","message":"x","timestamp":"yesterday"}`, want: http.StatusBadRequest},
+		{name: "bad json", token: "deploy", body: `{`, want: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if rec := post(tc.token, tc.body); rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.name, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// auditBodyBytes is how much of a request body the audit log keeps.
+const auditBodyBytes = 2 << 10
+
+type AuditEntryResponse struct {
+	ID        int64  `json:"id"`
+	Timestamp string `json:"ts"`
+	Actor     string `json:"actor"`
+	IP        string `json:"ip"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Params    string `json:"params,omitempty"`
+	Status    int    `json:"status"`
+}
+
+type AuditListResponse struct {
+	Items []AuditEntryResponse `json:"items"`
+}
+
+// auditMiddleware records every mutating API call once it has been
+// answered, including the ones the handler refused; calls without a valid
+// admin token are turned away before they get here. Heartbeat pings are
+// left out: jobs send them all the time and they change nothing but a
+// timestamp. So are GraphQL queries, which only read.
+func (s *Server) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.store == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions ||
+			!strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/api/heartbeat/") || r.URL.Path == "/api/graphql" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(r.Body, auditBodyBytes))
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		entry := store.AuditEntry{
+			Timestamp: time.Now(),
+			Actor:     requestActor(r),
+			IP:        s.clientIP(r),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Params:    auditParams(r, body),
+			Status:    rec.status,
+		}
+		if err := s.store.AddAuditEntry(context.WithoutCancel(r.Context()), entry); err != nil {
+			log.Printf("audit log write failed: %v", err)
+		}
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// auditParams joins the query, without a token passed in it, and the start
+// of the body.
+func auditParams(r *http.Request, body []byte) string {
+	q := r.URL.Query()
+	q.Del("token")
+	parts := []string{}
+	if encoded := q.Encode(); encoded != "" {
+		parts = append(parts, encoded)
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// handleAudit serves GET /api/audit, the mutating API calls newest first.
+// It filters by actor and path (a prefix) and pages with before_id and limit.
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	beforeID, _ := strconv.ParseInt(q.Get("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	filter := store.AuditFilter{Actor: q.Get("actor"), PathPrefix: q.Get("path")}
+	items, err := s.store.ListAudit(r.Context(), filter, beforeID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := AuditListResponse{Items: make([]AuditEntryResponse, 0, len(items))}
+	for _, e := range items {
+		resp.Items = append(resp.Items, AuditEntryResponse{
+			ID:        e.ID,
+			Timestamp: formatMaybeTime(e.Timestamp),
+			Actor:     e.Actor,
+			IP:        e.IP,
+			Method:    e.Method,
+			Path:      e.Path,
+			Params:    e.Params,
+			Status:    e.Status,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
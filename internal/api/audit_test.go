@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestAuditLogRecordsMutatingCalls(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", Type: "restart_loop", Severity: "red", Message: "restarting", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"ops-secret": ScopeAdmin, "wiki": ScopeRead}})
+	srv.WithTrustedProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	handler := srv.Routes()
+	// The ack comes through the trusted proxy, everything else straight
+	// from 192.0.2.1 with a made-up X-Forwarded-For.
+	remote := "10.0.0.2:80"
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = remote
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, fmt.Sprintf("/api/alerts/%d/ack", alertID), "ops-secret", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("ack: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	remote = "192.0.2.1:1234"
+	if rec := do(http.MethodPost, "/api/events", "ops-secret", `{"container":"web","message":"deployed"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("annotate: expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	do(http.MethodPost, "/api/heartbeat/nightly", "ops-secret", "")
+	if rec := do(http.MethodPost, "/api/events", "wiki", `{}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a read token to be refused, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodGet, "/api/audit", "wiki", ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected the audit log to need an admin token, got %d", rec.Code)
+	}
+	rec := do(http.MethodGet, "/api/audit", "ops-secret", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("audit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "ops-secret") {
+		t.Fatalf("the audit log must not contain tokens: %s", rec.Body.String())
+	}
+	var resp AuditListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Items) != 2 {
+		t.Fatalf("expected the ack and the annotation, got %+v", resp.Items)
+	}
+	sum := sha256.Sum256([]byte("ops-secret"))
+	actor := "token:" + hex.EncodeToString(sum[:4])
+	annotation, ack := resp.Items[0], resp.Items[1]
+	if ack.Actor != actor || ack.IP != "198.51.100.7" || ack.Method != http.MethodPost || ack.Path != fmt.Sprintf("/api/alerts/%d/ack", alertID) || ack.Status != http.StatusNoContent {
+		t.Fatalf("unexpected ack entry %+v", ack)
+	}
+	if annotation.IP != "192.0.2.1" || annotation.Status != http.StatusCreated || !strings.Contains(annotation.Params, `"message":"deployed"`) {
+		t.Fatalf("unexpected annotation entry %+v", annotation)
+	}
+
+	rec = do(http.MethodGet, "/api/audit?path=/api/alerts/", "ops-secret", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Path != ack.Path {
+		t.Fatalf("expected only the ack for the path filter, got %+v", resp.Items)
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type TokenScope string
+
+const (
+	// ScopeRead limits a token to GET requests: the REST read endpoints, the
+	// WebSocket stream and the status page itself, but not the audit log or
+	// incident bundles.
+	ScopeRead TokenScope = "read"
+	// ScopeAdmin grants every endpoint, including mutating ones.
+	ScopeAdmin TokenScope = "admin"
+)
+
+const authCookieName = "hm_token"
+
+type AuthOptions struct {
+	// Tokens maps API tokens to their scope.
+	Tokens map[string]TokenScope
+	// Proxy trusts the user name an authenticating reverse proxy puts in a
+	// header.
+	Proxy ProxyAuth
+	// OIDC logs users in with an OpenID Connect provider; nil disables it.
+	OIDC *OIDC
+	// Roles gives the users of Proxy and OIDC their scope.
+	Roles RoleMapping
+}
+
+// ProxyAuth reads the user, and optionally their groups, from headers set
+// by a reverse proxy such as Authelia or Authentik. The headers are only
+// believed on requests from one of the Trusted addresses; anyone else could
+// set them too.
+type ProxyAuth struct {
+	UserHeader   string
+	GroupsHeader string
+	Trusted      []netip.Prefix
+}
+
+// RoleMapping maps users, by name or by group, to a scope. A user gets the
+// widest scope any of these grant. Users it grants nothing are refused.
+type RoleMapping struct {
+	Users   map[string]TokenScope
+	Groups  map[string]TokenScope
+	Default TokenScope
+}
+
+// identity is who a request was authenticated as.
+type identity struct {
+	actor string
+	scope TokenScope
+}
+
+type identityKey struct{}
+
+func (s *Server) WithAuth(opts AuthOptions) {
+	s.auth = opts
+}
+
+// enabled reports whether requests have to authenticate at all.
+func (o AuthOptions) enabled() bool {
+	return len(o.Tokens) > 0 || o.Proxy.UserHeader != "" || o.OIDC != nil
+}
+
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Probes carry no token and reveal nothing beyond up or down.
+		if !s.auth.enabled() || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.auth.OIDC != nil && strings.HasPrefix(r.URL.Path, oidcPathPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, ok := s.auth.identify(r)
+		if !ok {
+			if s.auth.OIDC != nil && r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/api/") {
+				s.auth.OIDC.redirectToLogin(w, r)
+				return
+			}
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if !id.scope.allows(r) {
+			writeError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+		if token, fromQuery := requestToken(r); fromQuery && strings.HasPrefix(id.actor, "token:") {
+			// Lets the status page opened via ?token= keep authenticating its
+			// own fetch and WebSocket calls.
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	})
+}
+
+// identify authenticates a request by API token, by the headers of a
+// trusted proxy or by an OIDC session, in this order.
+func (o AuthOptions) identify(r *http.Request) (identity, bool) {
+	if token, _ := requestToken(r); token != "" {
+		if scope, ok := o.lookup(token); ok {
+			return identity{actor: tokenActor(token), scope: scope}, true
+		}
+	}
+	if user, groups, ok := o.Proxy.user(r); ok {
+		return identity{actor: "user:" + user, scope: o.Roles.scope(user, groups)}, true
+	}
+	if o.OIDC != nil {
+		if user, groups, ok := o.OIDC.session(r); ok {
+			return identity{actor: "user:" + user, scope: o.Roles.scope(user, groups)}, true
+		}
+	}
+	return identity{}, false
+}
+
+// requestActor names the caller of an authenticated request, or returns
+// "anonymous" when authentication is disabled.
+func requestActor(r *http.Request) string {
+	if id, ok := r.Context().Value(identityKey{}).(identity); ok {
+		return id.actor
+	}
+	return "anonymous"
+}
+
+// tokenActor names a token caller by a fingerprint, the first 8 hex digits
+// of the token's SHA-256, so what it is written to does not hand out
+// credentials: `printf %s "$TOKEN" | sha256sum` tells which token it was.
+func tokenActor(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:4])
+}
+
+func (p ProxyAuth) user(r *http.Request) (string, []string, bool) {
+	if p.UserHeader == "" {
+		return "", nil, false
+	}
+	user := strings.TrimSpace(r.Header.Get(p.UserHeader))
+	if user == "" || !p.trusts(r.RemoteAddr) {
+		return "", nil, false
+	}
+	var groups []string
+	if p.GroupsHeader != "" {
+		// Authelia separates groups with commas, Authentik with pipes.
+		for _, group := range strings.FieldsFunc(r.Header.Get(p.GroupsHeader), func(c rune) bool { return c == ',' || c == '|' }) {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return user, groups, true
+}
+
+func (p ProxyAuth) trusts(remoteAddr string) bool {
+	return trustsAddr(p.Trusted, remoteHost(remoteAddr))
+}
+
+// trustsAddr reports whether the address host lies in one of prefixes.
+func trustsAddr(prefixes []netip.Prefix, host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses addresses and CIDR ranges.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			out = append(out, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, expected an address or CIDR range", value)
+		}
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+func (m RoleMapping) scope(user string, groups []string) TokenScope {
+	best := m.Default
+	grant := func(scope TokenScope) {
+		if scope == ScopeAdmin || (scope == ScopeRead && best == "") {
+			best = scope
+		}
+	}
+	grant(m.Users[user])
+	for _, group := range groups {
+		grant(m.Groups[group])
+	}
+	return best
+}
+
+func (o AuthOptions) lookup(token string) (TokenScope, bool) {
+	if token == "" {
+		return "", false
+	}
+	var match TokenScope
+	found := false
+	for candidate, scope := range o.Tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			match = scope
+			found = true
+		}
+	}
+	return match, found
+}
+
+func (scope TokenScope) allows(r *http.Request) bool {
+	switch scope {
+	case ScopeAdmin:
+		return true
+	case ScopeRead:
+		// The audit log names callers and their addresses, and incident
+		// bundles and alert log tails carry container logs. GraphQL queries
+		// only read, whichever method they come with.
+		if r.URL.Path == "/api/graphql" {
+			return true
+		}
+		if r.URL.Path == "/api/audit" || isBundlePath(r.URL.Path) || isAlertLogsPath(r.URL.Path) {
+			return false
+		}
+		return r.Method == http.MethodGet || r.Method == http.MethodHead
+	default:
+		return false
+	}
+}
+
+func requestToken(r *http.Request) (string, bool) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token), false
+		}
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, true
+	}
+	if cookie, err := r.Cookie(authCookieName); err == nil {
+		return cookie.Value, false
+	}
+	return "", false
+}
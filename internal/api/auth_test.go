@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadTokenIsLimitedToGetRequests(t *testing.T) {
+	srv := NewServer(nil, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{
+		"wiki":   ScopeRead,
+		"secret": ScopeAdmin,
+	}})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := srv.authMiddleware(ok)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		header string
+		want   int
+	}{
+		{name: "missing token", method: http.MethodGet, target: "/api/containers", want: http.StatusUnauthorized},
+		{name: "unknown token", method: http.MethodGet, target: "/api/containers", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "read get", method: http.MethodGet, target: "/api/containers", header: "Bearer wiki", want: http.StatusNoContent},
+		{name: "read query token", method: http.MethodGet, target: "/?token=wiki", want: http.StatusNoContent},
+		{name: "read post", method: http.MethodPost, target: "/api/containers", header: "Bearer wiki", want: http.StatusForbidden},
+		{name: "admin post", method: http.MethodPost, target: "/api/containers", header: "Bearer secret", want: http.StatusNoContent},
+		{name: "read graphql query", method: http.MethodPost, target: "/api/graphql", header: "Bearer wiki", want: http.StatusNoContent},
+		{name: "read alert logs", method: http.MethodGet, target: "/api/alerts/7/logs", header: "Bearer wiki", want: http.StatusForbidden},
+		{name: "admin alert logs", method: http.MethodGet, target: "/api/alerts/7/logs", header: "Bearer secret", want: http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.want, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?token=wiki", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != authCookieName || cookies[0].Value != "wiki" {
+		t.Fatalf("expected auth cookie to be set, got %#v", cookies)
+	}
+}
+
+func TestProxyUserGetsMappedRole(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	srv := NewServer(nil, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{
+		Proxy: ProxyAuth{UserHeader: "Remote-User", GroupsHeader: "Remote-Groups", Trusted: trusted},
+		Roles: RoleMapping{
+			Users:  map[string]TokenScope{"alice": ScopeAdmin},
+			Groups: map[string]TokenScope{"family": ScopeRead, "ops": ScopeAdmin},
+		},
+	})
+	var actor string
+	handler := srv.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = requestActor(r)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		name   string
+		method string
+		remote string
+		user   string
+		groups string
+		want   int
+	}{
+		{name: "mapped user", method: http.MethodPost, remote: "10.1.2.3:5555", user: "alice", want: http.StatusNoContent},
+		{name: "read group", method: http.MethodGet, remote: "10.1.2.3:5555", user: "bob", groups: "family", want: http.StatusNoContent},
+		{name: "read group post", method: http.MethodPost, remote: "10.1.2.3:5555", user: "bob", groups: "family", want: http.StatusForbidden},
+		{name: "admin group with pipes", method: http.MethodPost, remote: "192.0.2.1:5555", user: "carol", groups: "family|ops", want: http.StatusNoContent},
+		{name: "no role", method: http.MethodGet, remote: "10.1.2.3:5555", user: "mallory", want: http.StatusForbidden},
+		{name: "untrusted peer", method: http.MethodGet, remote: "203.0.113.9:5555", user: "alice", want: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/api/containers", nil)
+		req.RemoteAddr = tc.remote
+		req.Header.Set("Remote-User", tc.user)
+		if tc.groups != "" {
+			req.Header.Set("Remote-Groups", tc.groups)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, rec.Code)
+		}
+		if tc.want == http.StatusNoContent && actor != "user:"+tc.user {
+			t.Fatalf("%s: expected actor user:%s, got %q", tc.name, tc.user, actor)
+		}
+	}
+}
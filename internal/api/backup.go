@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// BackupFunc snapshots the database and returns the path of the snapshot.
+type BackupFunc func(ctx context.Context) (string, error)
+
+type BackupResponse struct {
+	Path      string `json:"path"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (s *Server) WithBackup(fn BackupFunc) {
+	s.backup = fn
+}
+
+func (s *Server) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.backup == nil {
+		writeError(w, http.StatusNotImplemented, "backups are not configured")
+		return
+	}
+
+	path, err := s.backup(r.Context())
+	if err != nil {
+		log.Printf("backup failed: %v", err)
+		if path == "" {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	log.Printf("backup written: %s", path)
+	writeJSON(w, http.StatusOK, BackupResponse{
+		Path:      path,
+		CreatedAt: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	})
+}
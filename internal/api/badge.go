@@ -0,0 +1,146 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"healthmon/internal/store"
+)
+
+// stateLevel buckets a container status for compact renderings such as
+// badges and widgets.
+type stateLevel int
+
+const (
+	levelUnknown stateLevel = iota
+	levelOK
+	levelWarn
+	levelBad
+)
+
+var badgeColors = map[stateLevel]string{
+	levelUnknown: "#9f9f9f",
+	levelOK:      "#4c1",
+	levelWarn:    "#fe7d37",
+	levelBad:     "#e05d44",
+}
+
+// handleBadge serves /api/badge/{name}.svg for a container and
+// /api/badge/group/{group}.svg for a healthmon.group.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/badge/"), ".svg")
+	group, isGroup := strings.CutPrefix(name, "group/")
+	if isGroup {
+		name = group
+	}
+	if !ok || name == "" || strings.Contains(name, "/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	status, level := "not found", levelUnknown
+	code := http.StatusNotFound
+	if isGroup {
+		if groupStatus, groupLevel, found := s.groupState(name); found {
+			status, level = groupStatus, groupLevel
+			code = http.StatusOK
+		}
+	} else if c, found := s.store.GetContainer(name); found {
+		status, level = containerState(c)
+		code = http.StatusOK
+	}
+
+	// Badges are embedded through caching image proxies (GitHub camo and the
+	// like); ask them not to hold on to a stale status.
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate, max-age=0")
+	w.WriteHeader(code)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(renderBadge(name, status, badgeColors[level]))
+}
+
+// groupState reports the state of the worst present container in a group,
+// the first by name among equally bad ones.
+func (s *Server) groupState(group string) (string, stateLevel, bool) {
+	items := s.store.ListContainers()
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	status, level, found := "", levelUnknown, false
+	for _, c := range items {
+		if c.Group() != group {
+			continue
+		}
+		memberStatus, memberLevel := containerState(c)
+		if !found || memberLevel > level {
+			status, level = memberStatus, memberLevel
+		}
+		found = true
+	}
+	return status, level, found
+}
+
+func containerState(c store.Container) (string, stateLevel) {
+	switch {
+	case !c.Present:
+		return "removed", levelUnknown
+	case c.RestartLoop:
+		return "looping", levelBad
+	case c.HealthPending(time.Now()):
+		return "pending", levelWarn
+	case strings.EqualFold(c.HealthStatus, "unhealthy"):
+		return "unhealthy", levelBad
+	case strings.EqualFold(c.HealthStatus, "starting"):
+		return "starting", levelWarn
+	case c.Status == "running" && strings.EqualFold(c.HealthStatus, "healthy"):
+		return "healthy", levelOK
+	case c.Status == "running":
+		return "running", levelOK
+	case c.Role == "task" && c.ExitCode != nil && *c.ExitCode == 0:
+		return "completed", levelOK
+	case c.Status == "":
+		return "unknown", levelUnknown
+	default:
+		return c.Status, levelWarn
+	}
+}
+
+// renderBadge draws a flat shields.io-style badge. Text widths are estimated
+// from the character count, which is close enough for Verdana at 11px.
+func renderBadge(label, status, color string) []byte {
+	labelWidth := badgeTextWidth(label)
+	statusWidth := badgeTextWidth(status)
+	width := labelWidth + statusWidth
+	label = html.EscapeString(label)
+	status = html.EscapeString(status)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, status)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, status)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	b.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="#555"/>`, labelWidth)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, labelWidth, statusWidth, color)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="url(#s)"/>`, width)
+	b.WriteString(`</g>`)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, labelWidth/2, label, labelWidth/2, label)
+	fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>`, labelWidth+statusWidth/2, status, labelWidth+statusWidth/2, status)
+	b.WriteString(`</g></svg>`)
+	return []byte(b.String())
+}
+
+func badgeTextWidth(text string) int {
+	return utf8.RuneCountInString(text)*7 + 10
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBadgeColorsAndStatuses(t *testing.T) {
+	handler := newStatusTestServer(t).Routes()
+	for _, tc := range []struct {
+		path   string
+		code   int
+		status string
+		color  string
+	}{
+		{"/api/badge/web.svg", http.StatusOK, "healthy", badgeColors[levelOK]},
+		{"/api/badge/jellyfin.svg", http.StatusOK, "starting", badgeColors[levelWarn]},
+		{"/api/badge/sonarr.svg", http.StatusOK, "unhealthy", badgeColors[levelBad]},
+		{"/api/badge/worker.svg", http.StatusOK, "looping", badgeColors[levelBad]},
+		{"/api/badge/old.svg", http.StatusOK, "removed", badgeColors[levelUnknown]},
+		{"/api/badge/nope.svg", http.StatusNotFound, "not found", badgeColors[levelUnknown]},
+		{"/api/badge/group/media.svg", http.StatusOK, "unhealthy", badgeColors[levelBad]},
+		{"/api/badge/group/mail.svg", http.StatusOK, "running", badgeColors[levelOK]},
+		{"/api/badge/group/nope.svg", http.StatusNotFound, "not found", badgeColors[levelUnknown]},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		body := rec.Body.String()
+		if rec.Code != tc.code || !strings.Contains(body, "<title>") || !strings.Contains(body, ": "+tc.status+"</title>") || !strings.Contains(body, `fill="`+tc.color+`"`) {
+			t.Errorf("%s: expected %d %q in %s, got %d %s", tc.path, tc.code, tc.status, tc.color, rec.Code, body)
+		}
+	}
+
+	for _, path := range []string{"/api/badge/web", "/api/badge/.svg", "/api/badge/a/b.svg"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") == "image/svg+xml; charset=utf-8" {
+			t.Errorf("%s: expected a plain 404, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestBadgeHeadersAndAuth(t *testing.T) {
+	srv := newStatusTestServer(t)
+	handler := srv.Routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/api/badge/web.svg", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("HEAD: expected 200 without a body, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "image/svg+xml; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); !strings.Contains(got, "no-store") || !strings.Contains(got, "max-age=0") {
+		t.Fatalf("expected image proxies to be told not to cache, got %q", got)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/badge/web.svg", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST: expected 405, got %d", rec.Code)
+	}
+
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"wiki": ScopeRead}})
+	handler = srv.Routes()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/badge/web.svg", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a badge without token to be refused, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/badge/group/media.svg?token=wiki", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a read token to get the badge, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	// bundleMargin widens an incident's window so the bundle shows what led
+	// up to it and how it settled.
+	bundleMargin = 30 * time.Minute
+	// bundleLimit caps the events and the alerts in a bundle.
+	bundleLimit = 1000
+	// bundleLogTail is how many log lines a bundle includes.
+	bundleLogTail = 500
+)
+
+// ContainerInspector reads live container state from Docker for incident
+// bundles.
+type ContainerInspector interface {
+	// InspectContainer returns Docker's inspect output for a container.
+	InspectContainer(ctx context.Context, name string) (json.RawMessage, error)
+	// ContainerLogs returns up to tail log lines a container wrote between
+	// since and until, with timestamps.
+	ContainerLogs(ctx context.Context, name string, since, until time.Time, tail int) ([]byte, error)
+}
+
+// secretLabel matches label names whose values are likely credentials.
+var secretLabel = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_.-]?key|private[_.-]?key|credential)`)
+
+func (s *Server) WithInspector(i ContainerInspector) {
+	s.inspector = i
+}
+
+type bundleFile struct {
+	name  string
+	value interface{}
+}
+
+// TimelineEntry is one event or alert in an incident bundle's timeline.
+type TimelineEntry struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	ID        int64  `json:"id"`
+	Type      string `json:"type"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	// InIncident marks the alerts linked to the incident.
+	InIncident bool `json:"in_incident,omitempty"`
+}
+
+// isBundlePath reports whether path is /api/incidents/{id}/bundle.
+func isBundlePath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/incidents/")
+	return ok && strings.HasSuffix(rest, "/bundle")
+}
+
+// handleIncidentBundle serves GET /api/incidents/{id}/bundle, a zip with
+// everything known about an incident, ready to attach to a postmortem.
+func (s *Server) handleIncidentBundle(w http.ResponseWriter, r *http.Request) {
+	idPart, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/incidents/"), "/bundle")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if !ok || err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	inc, found, err := s.store.GetIncident(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "incident not found")
+		return
+	}
+	until := time.Now().UTC()
+	if !inc.ResolvedAt.IsZero() && inc.ResolvedAt.Add(bundleMargin).Before(until) {
+		until = inc.ResolvedAt.Add(bundleMargin)
+	}
+	since := inc.OpenedAt.Add(-bundleMargin)
+	filter := store.Filter{Containers: []string{inc.Container}, Since: since, Until: until, Ascending: true}
+	events, err := s.store.ListAllEvents(ctx, filter, 0, bundleLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	alerts, err := s.store.ListAllAlerts(ctx, filter, 0, bundleLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []bundleFile{
+		{"incident.json", toIncidentResponse(inc)},
+		{"timeline.json", bundleTimeline(events, alerts, inc.ID)},
+		{"events.json", toEventResponses(events)},
+		{"alerts.json", toAlertResponses(alerts)},
+	}
+	if c, ok := s.store.GetContainer(inc.Container); ok {
+		container := toContainerResponse(c)
+		container.Notes = s.containerNotes(c.Name)
+		files = append(files, bundleFile{"container.json", container})
+	}
+	for _, f := range files {
+		if err := writeZipJSON(zw, f.name, f.value); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if s.inspector != nil {
+		s.addLiveState(ctx, zw, inc.Container, since, until)
+	}
+	if err := zw.Close(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="incident-%d.zip"`, inc.ID))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// addLiveState adds the container's inspect output and logs from around the
+// incident. Either may be gone, e.g. after the container was removed; the
+// bundle then notes why instead of failing.
+func (s *Server) addLiveState(ctx context.Context, zw *zip.Writer, name string, since, until time.Time) {
+	entries := []struct {
+		name  string
+		fetch func() ([]byte, error)
+	}{
+		{"inspect.json", func() ([]byte, error) {
+			raw, err := s.inspector.InspectContainer(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			return redactInspect(raw)
+		}},
+		{"logs.txt", func() ([]byte, error) {
+			return s.inspector.ContainerLogs(ctx, name, since, until, bundleLogTail)
+		}},
+	}
+	for _, entry := range entries {
+		data, err := entry.fetch()
+		fileName := entry.name
+		if err != nil {
+			log.Printf("incident bundle %s for %s: %v", entry.name, name, err)
+			fileName += ".error"
+			data = []byte(err.Error() + "\n")
+		}
+		fw, err := zw.Create(fileName)
+		if err != nil {
+			return
+		}
+		_, _ = fw.Write(data)
+	}
+}
+
+// redactInspect drops the values of the environment and of secret-looking
+// labels from Docker's inspect output, which commonly hold passwords and
+// API keys. Variable and label names are kept.
+func redactInspect(raw json.RawMessage) ([]byte, error) {
+	var inspect map[string]any
+	if err := json.Unmarshal(raw, &inspect); err != nil {
+		return nil, fmt.Errorf("decode inspect output: %w", err)
+	}
+	if cfg, ok := inspect["Config"].(map[string]any); ok {
+		if env, ok := cfg["Env"].([]any); ok {
+			for i, entry := range env {
+				name, _, _ := strings.Cut(fmt.Sprint(entry), "=")
+				env[i] = name + "=<redacted>"
+			}
+		}
+		if labels, ok := cfg["Labels"].(map[string]any); ok {
+			for name := range labels {
+				if secretLabel.MatchString(name) {
+					labels[name] = "<redacted>"
+				}
+			}
+		}
+	}
+	return json.MarshalIndent(inspect, "", "  ")
+}
+
+func bundleTimeline(events []store.Event, alerts []store.Alert, incidentID int64) []TimelineEntry {
+	entries := make([]TimelineEntry, 0, len(events)+len(alerts))
+	for _, e := range events {
+		entries = append(entries, TimelineEntry{
+			Timestamp: e.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			Kind:      "event",
+			ID:        e.ID,
+			Type:      e.Type,
+			Severity:  e.Severity,
+			Message:   e.Message,
+		})
+	}
+	for _, a := range alerts {
+		entries = append(entries, TimelineEntry{
+			Timestamp:  a.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			Kind:       "alert",
+			ID:         a.ID,
+			Type:       a.Type,
+			Severity:   a.Severity,
+			Message:    a.Message,
+			InIncident: a.IncidentID == incidentID,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+	return entries
+}
+
+func toEventResponses(events []store.Event) []*EventResponse {
+	out := make([]*EventResponse, 0, len(events))
+	for _, e := range events {
+		out = append(out, toEventResponse(e))
+	}
+	return out
+}
+
+func toAlertResponses(alerts []store.Alert) []*AlertResponse {
+	out := make([]*AlertResponse, 0, len(alerts))
+	for _, a := range alerts {
+		out = append(out, toAlertResponse(a))
+	}
+	return out
+}
+
+func writeZipJSON(zw *zip.Writer, name string, value interface{}) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(fw)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+type fakeInspector struct{}
+
+func (fakeInspector) InspectContainer(ctx context.Context, name string) (json.RawMessage, error) {
+	return json.RawMessage(`{"Id":"c-worker","Config":{"Env":["DB_PASSWORD=hunter2","TZ=UTC"],"Labels":{"app.api-key":"abc123","com.docker.compose.service":"worker"}}}`), nil
+}
+
+func (fakeInspector) ContainerLogs(ctx context.Context, name string, since, until time.Time, tail int) ([]byte, error) {
+	return nil, errors.New("container removed")
+}
+
+func TestIncidentBundleContainsTimelineAndLiveState(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := st.UpsertContainer(ctx, store.Container{Name: "worker", ContainerID: "c-worker", Status: "restarting", StartedAt: now}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	worker, _ := st.GetContainer("worker")
+	incidentID, err := st.AddIncident(ctx, store.Incident{ContainerPK: worker.ID, Container: "worker", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", OpenedAt: now.Add(-10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("add incident: %v", err)
+	}
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: worker.ID, Container: "worker", ContainerID: "c-worker", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", Timestamp: now.Add(-10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+	if err := st.LinkAlertToIncident(ctx, alertID, incidentID); err != nil {
+		t.Fatalf("link alert: %v", err)
+	}
+	for _, at := range []time.Duration{-12 * time.Minute, -2 * time.Hour} {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: worker.ID, Container: "worker", ContainerID: "c-worker", Type: "restart", Severity: "blue", Message: "Restart event: die", Timestamp: now.Add(at)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithInspector(fakeInspector{})
+	rec := httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/incidents/"+strconv.FormatInt(incidentID, 10)+"/bundle", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	for _, name := range []string{"incident.json", "timeline.json", "events.json", "alerts.json", "container.json", "inspect.json", "logs.txt.error"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("expected %s in bundle, got %v", name, zr.File)
+		}
+	}
+
+	for _, secret := range []string{"hunter2", "UTC", "abc123"} {
+		if strings.Contains(string(files["inspect.json"]), secret) {
+			t.Fatalf("inspect.json leaks %q: %s", secret, files["inspect.json"])
+		}
+	}
+	var inspect struct {
+		Config struct {
+			Env    []string
+			Labels map[string]string
+		}
+	}
+	if err := json.Unmarshal(files["inspect.json"], &inspect); err != nil {
+		t.Fatalf("decode inspect: %v", err)
+	}
+	if len(inspect.Config.Env) != 2 || inspect.Config.Env[0] != "DB_PASSWORD=<redacted>" || inspect.Config.Labels["com.docker.compose.service"] != "worker" {
+		t.Fatalf("unexpected redacted inspect: %+v", inspect.Config)
+	}
+
+	var timeline []TimelineEntry
+	if err := json.Unmarshal(files["timeline.json"], &timeline); err != nil {
+		t.Fatalf("decode timeline: %v", err)
+	}
+	// The event two hours earlier falls outside the incident's margin.
+	if len(timeline) != 2 || timeline[0].Kind != "event" || timeline[1].Kind != "alert" || !timeline[1].InIncident {
+		t.Fatalf("unexpected timeline: %+v", timeline)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/incidents/999/bundle", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown incident: expected 404, got %d", rec.Code)
+	}
+
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"wiki": ScopeRead, "ops": ScopeAdmin}})
+	for token, want := range map[string]int{"wiki": http.StatusForbidden, "ops": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/incidents/"+strconv.FormatInt(incidentID, 10)+"/bundle", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec = httptest.NewRecorder()
+		srv.Routes().ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("token %s: expected %d, got %d", token, want, rec.Code)
+		}
+	}
+}
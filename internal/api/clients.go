@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+const clientHeader = "X-Healthmon-Client"
+
+var clientIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// ClientFilterResponse is the severity filter saved for a dashboard client.
+type ClientFilterResponse struct {
+	Client     string   `json:"client"`
+	Severities []string `json:"severities"`
+}
+
+// clientID returns the dashboard client a request identifies as, via
+// ?client= or the X-Healthmon-Client header. Clients pick their own ids, e.g.
+// "wall" for a wall-mounted screen.
+func clientID(r *http.Request) string {
+	id := r.URL.Query().Get("client")
+	if id == "" {
+		id = r.Header.Get(clientHeader)
+	}
+	if !clientIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// handleClientFilter serves /api/clients/{client}/filter.
+func (s *Server) handleClientFilter(w http.ResponseWriter, r *http.Request) {
+	client, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/clients/"), "/filter")
+	if !ok || !clientIDPattern.MatchString(client) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		severities, _, err := s.store.ClientSeverities(r.Context(), client)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if severities == nil {
+			severities = []string{}
+		}
+		writeJSON(w, http.StatusOK, ClientFilterResponse{Client: client, Severities: severities})
+	case http.MethodPut:
+		var req ClientFilterResponse
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		severities := make([]string, 0, len(req.Severities))
+		for _, severity := range req.Severities {
+			if severity = strings.TrimSpace(severity); severity != "" {
+				severities = append(severities, severity)
+			}
+		}
+		if err := checkSeverities(severities); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.store.SetClientSeverities(r.Context(), client, severities); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.broadcaster.SetFilter(client, severities)
+		writeJSON(w, http.StatusOK, ClientFilterResponse{Client: client, Severities: severities})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// applyClientDefaults fills in the requesting client's saved severities when
+// the request does not choose any itself.
+func (s *Server) applyClientDefaults(r *http.Request, f *store.Filter) error {
+	if len(f.Severities) > 0 {
+		return nil
+	}
+	client := clientID(r)
+	if client == "" {
+		return nil
+	}
+	severities, _, err := s.store.ClientSeverities(r.Context(), client)
+	if err != nil {
+		return err
+	}
+	f.Severities = severities
+	return nil
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestClientSeverityFilterAppliesToListings(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", StartedAt: now}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	for _, severity := range []string{"blue", "red", "yellow", "blue"} {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "test", Severity: severity, Message: severity, Timestamp: now}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+	total := func(target string) int64 {
+		rec := do(http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var resp EventListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.Total
+	}
+
+	if rec := do(http.MethodPut, "/api/clients/wall/filter", `{"severities":["red","yellow"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("put filter: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := total("/api/events?client=wall"); got != 2 {
+		t.Fatalf("wall client: expected 2 events, got %d", got)
+	}
+	if got := total("/api/events?client=wall&severity=blue"); got != 2 {
+		t.Fatalf("explicit severity should win: expected 2 blue events, got %d", got)
+	}
+	if got := total("/api/events"); got != 4 {
+		t.Fatalf("no client: expected all 4 events, got %d", got)
+	}
+
+	if rec := do(http.MethodPut, "/api/clients/wall/filter", `{"severities":[]}`); rec.Code != http.StatusOK {
+		t.Fatalf("clear filter: status %d", rec.Code)
+	}
+	if got := total("/api/events?client=wall"); got != 4 {
+		t.Fatalf("cleared filter: expected 4 events, got %d", got)
+	}
+
+	if rec := do(http.MethodPut, "/api/clients/wall/filter", `{"severities":["red","critical"]}`); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `\"critical\"`) {
+		t.Fatalf("expected an unknown severity to be refused, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/events/timeline?severity=red,purple", ""); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `\"purple\"`) {
+		t.Fatalf("expected the timeline to name the unknown severity, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/events/timeline?severity=red", ""); rec.Code != http.StatusOK {
+		t.Fatalf("timeline: status %d: %s", rec.Code, rec.Body.String())
+	}
+}
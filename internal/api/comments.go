@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+// maxCommentLength bounds the body of an alert comment.
+const maxCommentLength = 2000
+
+// CommentRequest is the body of POST /api/alerts/{id}/comments.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+func toCommentResponses(items []store.AlertComment) []CommentResponse {
+	resp := make([]CommentResponse, 0, len(items))
+	for _, c := range items {
+		resp = append(resp, toCommentResponse(c))
+	}
+	return resp
+}
+
+func toCommentResponse(c store.AlertComment) CommentResponse {
+	return CommentResponse{
+		ID:        c.ID,
+		AlertID:   c.AlertID,
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// attachComments fills in the comments of a page of alerts.
+func (s *Server) attachComments(ctx context.Context, alerts []AlertResponse) error {
+	ids := make([]int64, 0, len(alerts))
+	index := make(map[int64]int, len(alerts))
+	for i, a := range alerts {
+		ids = append(ids, a.ID)
+		index[a.ID] = i
+	}
+	comments, err := s.store.ListAlertComments(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		i := index[c.AlertID]
+		alerts[i].Comments = append(alerts[i].Comments, toCommentResponse(c))
+	}
+	return nil
+}
+
+// handleAlert routes /api/alerts/{id}, /api/alerts/{id}/ack,
+// /api/alerts/{id}/comments and /api/alerts/{id}/hooks.
+func (s *Server) handleAlert(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/") {
+		s.handleAlertByID(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/comments") {
+		s.handleAlertComments(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/hooks") {
+		s.handleAlertHooks(w, r)
+		return
+	}
+	if isAlertLogsPath(r.URL.Path) {
+		s.handleAlertLogs(w, r)
+		return
+	}
+	s.handleAlertAck(w, r)
+}
+
+// handleAlertComments serves GET and POST /api/alerts/{id}/comments. New
+// comments are signed with the caller's identity and broadcast, so every
+// open dashboard shows them right away.
+func (s *Server) handleAlertComments(w http.ResponseWriter, r *http.Request) {
+	idPart, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/comments")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	ctx := r.Context()
+	alert, found, err := s.store.GetAlert(ctx, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		items, err := s.store.ListAlertComments(ctx, id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, toCommentResponses(items))
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" || len(req.Body) > maxCommentLength {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("body is required and at most %d bytes", maxCommentLength))
+		return
+	}
+	comment, err := s.store.AddAlertComment(ctx, store.AlertComment{AlertID: id, Author: requestActor(r), Body: req.Body})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := toCommentResponse(comment)
+	update := EventUpdate{Container: ContainerResponse{Name: alert.Container}, Comment: &resp}
+	if c, ok := s.store.GetContainer(alert.Container); ok {
+		update.Container = toContainerResponse(c)
+	}
+	s.Broadcast(ctx, update)
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// handleIncidentComments serves GET /api/incidents/{id}/comments, the
+// comments on all alerts of an incident.
+func (s *Server) handleIncidentComments(w http.ResponseWriter, r *http.Request, inc store.Incident) {
+	items, err := s.store.ListAlertComments(r.Context(), inc.AlertIDs...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toCommentResponses(items))
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestAlertCommentsAreStoredAndBroadcast(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", Present: true}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", Type: "unhealthy", Severity: "red", Message: "Container became unhealthy", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+	incidentID, err := st.AddIncident(ctx, store.Incident{ContainerPK: web.ID, Container: "web", Type: "unhealthy", Severity: "red", Message: "Container became unhealthy", OpenedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("add incident: %v", err)
+	}
+	if err := st.LinkAlertToIncident(ctx, alertID, incidentID); err != nil {
+		t.Fatalf("link alert: %v", err)
+	}
+
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"viewer": ScopeRead, "oncall": ScopeAdmin}})
+	var updates []EventUpdate
+	srv.OnUpdate(func(_ context.Context, u EventUpdate) { updates = append(updates, u) })
+	routes := srv.Routes()
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+	path := "/api/alerts/" + strconv.FormatInt(alertID, 10) + "/comments"
+
+	if rec := serve(http.MethodPost, path, "viewer", `{"body":"looking"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a read token to be refused, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, path, "oncall", `{"body":"  "}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected an empty comment to be refused, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/api/alerts/999/comments", "oncall", `{"body":"hm"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown alert, got %d", rec.Code)
+	}
+	rec := serve(http.MethodPost, path, "oncall", `{"body":"known issue, upstream outage"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(updates) != 1 || updates[0].Comment == nil || updates[0].Container.Name != "web" || updates[0].Comment.Body != "known issue, upstream outage" {
+		t.Fatalf("expected the comment to be broadcast, got %+v", updates)
+	}
+
+	for _, path := range []string{path, "/api/incidents/" + strconv.FormatInt(incidentID, 10) + "/comments"} {
+		rec = serve(http.MethodGet, path, "viewer", "")
+		var comments []CommentResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &comments); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		if len(comments) != 1 || comments[0].AlertID != alertID || comments[0].Author != tokenActor("oncall") {
+			t.Fatalf("unexpected comments at %s: %+v", path, comments)
+		}
+	}
+}
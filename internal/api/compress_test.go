@@ -0,0 +1,88 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestListsAreCompressedAndConditional(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	addEvent := func() {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "restart", Severity: "blue", Message: "Restart event", Timestamp: time.Now().UTC()}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	addEvent()
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	get := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("/api/events", http.Header{"Accept-Encoding": {"br, gzip"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzipped 200, got %d with %q", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	body, _ := io.ReadAll(zr)
+	var list EventListResponse
+	if err := json.Unmarshal(body, &list); err != nil || len(list.Items) != 1 {
+		t.Fatalf("expected one event, got %s (%v)", body, err)
+	}
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatalf("expected an ETag")
+	}
+
+	if rec := get("/api/events", nil); rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("ETag") != tag {
+		t.Fatalf("expected an uncompressed response with the same tag, got %q and %q", rec.Header().Get("Content-Encoding"), rec.Header().Get("ETag"))
+	}
+	if rec := get("/api/events", http.Header{"If-None-Match": {tag}}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("expected 304 without a body, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := get("/api/events?since=1h", http.Header{"If-None-Match": {tag}}); rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Fatalf("expected a relative window not to be tagged, got %d", rec.Code)
+	}
+
+	addEvent()
+	rec = get("/api/events", http.Header{"If-None-Match": {tag}})
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == tag {
+		t.Fatalf("expected a new event to change the tag, got %d with %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
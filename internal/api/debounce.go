@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// debouncer limits WebSocket updates to one per container per window, so
+// bursts such as a host reboot do not make the UI re-render for every event.
+// The first update of a burst is sent right away; later ones within the
+// window are held and sent as one update carrying the latest container
+// snapshot and all held events. Alerts, comments and removals are never
+// delayed.
+type debouncer struct {
+	window time.Duration
+	send   func(context.Context, EventUpdate)
+
+	mu      sync.Mutex
+	pending map[string]*heldUpdate
+}
+
+type heldUpdate struct {
+	update *EventUpdate
+	events []*EventResponse
+	timer  *time.Timer
+}
+
+func newDebouncer(window time.Duration, send func(context.Context, EventUpdate)) *debouncer {
+	return &debouncer{window: window, send: send, pending: make(map[string]*heldUpdate)}
+}
+
+func (d *debouncer) push(ctx context.Context, update EventUpdate) {
+	if d.window <= 0 {
+		d.send(ctx, update)
+		return
+	}
+	name := update.Container.Name
+	urgent := update.Alert != nil || update.Comment != nil || update.HookRun != nil || !update.Container.Present
+
+	d.mu.Lock()
+	held, ok := d.pending[name]
+	if !ok {
+		d.pending[name] = &heldUpdate{timer: time.AfterFunc(d.window, func() { d.flush(name) })}
+		d.mu.Unlock()
+		d.send(ctx, update)
+		return
+	}
+	// Held events are only merged with events of the same severity, so
+	// clients filtering by severity can still tell them apart.
+	var earlier *EventUpdate
+	if held.update != nil && (urgent || severityOf(held.update) != severityOf(&update)) {
+		earlier = held.take()
+	}
+	if urgent {
+		d.mu.Unlock()
+		if earlier != nil {
+			d.send(ctx, *earlier)
+		}
+		d.send(ctx, update)
+		return
+	}
+	if held.update != nil {
+		held.events = append(held.events, held.update.Events...)
+		if held.update.Event != nil {
+			held.events = append(held.events, held.update.Event)
+		}
+	}
+	held.update = &update
+	d.mu.Unlock()
+	if earlier != nil {
+		d.send(ctx, *earlier)
+	}
+}
+
+// take returns the held update merged with the events held before it and
+// clears it. d.mu is held.
+func (h *heldUpdate) take() *EventUpdate {
+	merged := *h.update
+	if len(h.events) > 0 {
+		merged.Events = append(h.events, merged.Events...)
+	}
+	h.update = nil
+	h.events = nil
+	return &merged
+}
+
+func (d *debouncer) flush(name string) {
+	d.mu.Lock()
+	held, ok := d.pending[name]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, name)
+	var update *EventUpdate
+	if held.update != nil {
+		update = held.take()
+	}
+	d.mu.Unlock()
+	if update != nil {
+		d.send(context.Background(), *update)
+	}
+}
+
+func severityOf(update *EventUpdate) string {
+	if update.Event != nil {
+		return update.Event.Severity
+	}
+	return ""
+}
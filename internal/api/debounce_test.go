@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebouncerCoalescesEventsAndFlushesAlerts(t *testing.T) {
+	var mu sync.Mutex
+	var sent []EventUpdate
+	flushed := make(chan struct{}, 10)
+	d := newDebouncer(50*time.Millisecond, func(ctx context.Context, update EventUpdate) {
+		mu.Lock()
+		sent = append(sent, update)
+		mu.Unlock()
+		flushed <- struct{}{}
+	})
+	ctx := context.Background()
+	event := func(id int64, severity string) EventUpdate {
+		return EventUpdate{
+			Container: ContainerResponse{Name: "web", Present: true, RestartStreak: int(id)},
+			Event:     &EventResponse{ID: id, Severity: severity},
+		}
+	}
+
+	d.push(ctx, event(1, "blue"))
+	d.push(ctx, event(2, "blue"))
+	d.push(ctx, event(3, "blue"))
+	d.push(ctx, EventUpdate{Container: ContainerResponse{Name: "db", Present: true}, Event: &EventResponse{ID: 4, Severity: "blue"}})
+	for i := 0; i < 3; i++ {
+		select {
+		case <-flushed:
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d updates", i)
+		}
+	}
+
+	mu.Lock()
+	if len(sent) != 3 {
+		t.Fatalf("expected the first update of each container and one coalesced update, got %d", len(sent))
+	}
+	last := sent[2]
+	mu.Unlock()
+	if last.Container.RestartStreak != 3 || last.Event.ID != 3 || len(last.Events) != 1 || last.Events[0].ID != 2 {
+		t.Fatalf("expected latest snapshot with event 3 and earlier event 2, got %+v", last)
+	}
+
+	mu.Lock()
+	sent = nil
+	mu.Unlock()
+	d.push(ctx, event(5, "blue"))
+	d.push(ctx, event(6, "blue"))
+	d.push(ctx, EventUpdate{Container: ContainerResponse{Name: "web", Present: true}, Alert: &AlertResponse{ID: 1, Severity: "red"}})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 3 || sent[1].Event.ID != 6 || sent[2].Alert == nil {
+		t.Fatalf("expected an alert to flush the held event and go out right away, got %+v", sent)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// wsSnapshotInterval is how often a delta connection gets the full state of
+// a container again, so a client that missed something catches up.
+const wsSnapshotInterval = time.Minute
+
+// sentContainer is the container state last written to a delta connection.
+type sentContainer struct {
+	fields map[string]json.RawMessage
+	fullAt time.Time
+}
+
+// deltaPayload rewrites an update for a connection that asked for deltas:
+// its container only carries the fields that changed since the last update
+// written to this connection, plus id and name, with "delta": true. Fields
+// that went away are sent as null. The first update of a container, and one
+// every snapshot interval, is sent in full. Deltas are computed when the
+// message is written, so updates dropped from the queue never leave the
+// client with a stale field. Only the connection's writer calls it.
+func (s *subscriber) deltaPayload(msg []byte, now time.Time, snapshotEvery time.Duration) []byte {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &top); err != nil {
+		return msg
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(top["container"], &fields); err != nil || fields["id"] == nil {
+		return msg
+	}
+	key := string(fields["id"])
+	if s.sent == nil {
+		s.sent = make(map[string]*sentContainer)
+	}
+	last, ok := s.sent[key]
+	if !ok || now.Sub(last.fullAt) >= snapshotEvery {
+		s.sent[key] = &sentContainer{fields: fields, fullAt: now}
+		return msg
+	}
+
+	delta := map[string]json.RawMessage{"id": fields["id"], "name": fields["name"]}
+	for field, value := range fields {
+		if !bytes.Equal(last.fields[field], value) {
+			delta[field] = value
+		}
+	}
+	for field := range last.fields {
+		if _, ok := fields[field]; !ok {
+			delta[field] = json.RawMessage("null")
+		}
+	}
+	last.fields = fields
+	encoded, err := json.Marshal(delta)
+	if err != nil {
+		return msg
+	}
+	top["container"] = encoded
+	top["delta"] = json.RawMessage("true")
+	out, err := json.Marshal(top)
+	if err != nil {
+		return msg
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeltaPayloadSendsChangedFieldsBetweenSnapshots(t *testing.T) {
+	sub := &subscriber{delta: true}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	encode := func(update EventUpdate, at time.Time) map[string]any {
+		payload, err := json.Marshal(update)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var out map[string]any
+		if err := json.Unmarshal(sub.deltaPayload(payload, at, time.Minute), &out); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return out
+	}
+
+	web := ContainerResponse{ID: 1, Name: "web", Image: "nginx", Status: "running", Group: "front"}
+	first := encode(EventUpdate{Container: web}, now)
+	if first["delta"] != nil || first["container"].(map[string]any)["image"] != "nginx" {
+		t.Fatalf("expected the first update in full, got %v", first)
+	}
+
+	web.Status = "exited"
+	web.Group = ""
+	second := encode(EventUpdate{Container: web, Event: &EventResponse{ID: 5, Type: "die"}}, now.Add(time.Second))
+	container := second["container"].(map[string]any)
+	if second["delta"] != true || second["event"] == nil {
+		t.Fatalf("expected a delta with the event, got %v", second)
+	}
+	if container["status"] != "exited" || container["name"] != "web" || container["id"] != float64(1) {
+		t.Fatalf("expected the changed status with id and name, got %v", container)
+	}
+	if group, ok := container["group"]; !ok || group != nil {
+		t.Fatalf("expected the removed group as null, got %v", container)
+	}
+	if _, ok := container["image"]; ok {
+		t.Fatalf("expected unchanged fields left out, got %v", container)
+	}
+
+	other := encode(EventUpdate{Container: ContainerResponse{ID: 2, Name: "db"}}, now.Add(2*time.Second))
+	if other["delta"] != nil {
+		t.Fatalf("expected another container's first update in full, got %v", other)
+	}
+	snapshot := encode(EventUpdate{Container: web}, now.Add(time.Minute))
+	if snapshot["delta"] != nil || snapshot["container"].(map[string]any)["image"] != "nginx" {
+		t.Fatalf("expected a full snapshot after a minute, got %v", snapshot)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// withETag answers GET requests of a list endpoint with 304 Not Modified
+// when the client's If-None-Match still matches the stored data, and tags
+// successful responses with an ETag otherwise. The tag is derived from the
+// newest event and alert ids and the writes committed since startup, so it
+// changes with anything the listing could show. Responses that change with
+// the time alone, with a relative since window or relative timestamps, are
+// not tagged.
+func (s *Server) withETag(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if s.store == nil || r.Method != http.MethodGet || q.Has("since") || q.Get("time") == "relative" {
+			next(w, r)
+			return
+		}
+		version, err := s.store.DataVersion(r.Context())
+		if err != nil {
+			next(w, r)
+			return
+		}
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%d/%d/%d/%d", s.startedAt.UnixNano(), version.MaxEventID, version.MaxAlertID, version.Writes)
+		tag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+		if etagMatches(r.Header.Get("If-None-Match"), tag) {
+			w.Header().Set("ETag", tag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(&etagWriter{ResponseWriter: w, tag: tag}, r)
+	}
+}
+
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter sets the ETag on successful responses only, so errors are
+// never cached.
+type etagWriter struct {
+	http.ResponseWriter
+	tag         string
+	wroteHeader bool
+}
+
+func (e *etagWriter) WriteHeader(code int) {
+	if !e.wroteHeader {
+		e.wroteHeader = true
+		if code == http.StatusOK {
+			e.Header().Set("ETag", e.tag)
+		}
+	}
+	e.ResponseWriter.WriteHeader(code)
+}
+
+func (e *etagWriter) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+func (e *etagWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// exportPage is how many rows an export reads from the database at a time.
+const exportPage = 1000
+
+var (
+	eventCSVHeader = []string{"id", "timestamp", "container", "container_id", "type", "severity", "message", "reason", "exit_code", "old_image", "new_image", "details"}
+	alertCSVHeader = append(append([]string{}, eventCSVHeader...), "incident_id", "acknowledged_at")
+)
+
+// exporter writes the rows of an export as CSV, or as NDJSON when csv is
+// nil.
+type exporter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// write writes item, as row in CSV.
+func (e exporter) write(item any, row []string) error {
+	if e.csv != nil {
+		return e.csv.Write(row)
+	}
+	return e.json.Encode(item)
+}
+
+func (e exporter) flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+// startExport checks the method and format of an export request, reads its
+// filter and writes the response headers. Exports list oldest first unless
+// the request asks for order=desc, and ignore limit.
+func (s *Server) startExport(w http.ResponseWriter, r *http.Request, what string, header []string) (store.Filter, int64, exporter, bool) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return store.Filter{}, 0, exporter{}, false
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid format %q, expected csv or ndjson", format))
+		return store.Filter{}, 0, exporter{}, false
+	}
+	filter, cursor, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return store.Filter{}, 0, exporter{}, false
+	}
+	if r.URL.Query().Get("order") == "" {
+		filter.Ascending = true
+		cursor, _ = strconv.ParseInt(r.URL.Query().Get("after_id"), 10, 64)
+	}
+	if err := s.applyClientDefaults(r, &filter); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return store.Filter{}, 0, exporter{}, false
+	}
+
+	filename := fmt.Sprintf("healthmon-%s-%s.%s", what, time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if format == "ndjson" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return filter, cursor, exporter{json: json.NewEncoder(w)}, true
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	out := exporter{csv: csv.NewWriter(w)}
+	_ = out.csv.Write(header)
+	return filter, cursor, out, true
+}
+
+// handleEventsExport streams every event matching the listing filters as
+// CSV or NDJSON, for audits and spreadsheets.
+func (s *Server) handleEventsExport(w http.ResponseWriter, r *http.Request) {
+	filter, cursor, out, ok := s.startExport(w, r, "events", eventCSVHeader)
+	if !ok {
+		return
+	}
+	s.export(w, r, out, func() (int, error) {
+		items, err := s.store.ListAllEvents(r.Context(), filter, cursor, exportPage)
+		for _, e := range items {
+			resp := toEventResponse(e)
+			if err := out.write(resp, eventCSVRow(resp)); err != nil {
+				return 0, err
+			}
+			cursor = e.ID
+		}
+		return len(items), err
+	})
+}
+
+// handleAlertsExport streams every alert matching the listing filters as
+// CSV or NDJSON.
+func (s *Server) handleAlertsExport(w http.ResponseWriter, r *http.Request) {
+	filter, cursor, out, ok := s.startExport(w, r, "alerts", alertCSVHeader)
+	if !ok {
+		return
+	}
+	s.export(w, r, out, func() (int, error) {
+		items, err := s.store.ListAllAlerts(r.Context(), filter, cursor, exportPage)
+		for _, a := range items {
+			resp := toAlertResponse(a)
+			if err := out.write(resp, alertCSVRow(resp)); err != nil {
+				return 0, err
+			}
+			cursor = a.ID
+		}
+		return len(items), err
+	})
+}
+
+// export writes pages until one comes back short, flushing after each so
+// large exports start downloading right away. Once rows are out the status
+// can no longer change, so a failure midway only cuts the download short.
+func (s *Server) export(w http.ResponseWriter, r *http.Request, out exporter, page func() (int, error)) {
+	rc := http.NewResponseController(w)
+	for {
+		n, err := page()
+		if err == nil {
+			err = out.flush()
+		}
+		if err != nil {
+			if r.Context().Err() == nil {
+				log.Printf("export %s failed: %v", r.URL.Path, err)
+			}
+			return
+		}
+		_ = rc.Flush()
+		if n < exportPage {
+			return
+		}
+	}
+}
+
+func eventCSVRow(e *EventResponse) []string {
+	return []string{
+		strconv.FormatInt(e.ID, 10), e.Timestamp, e.Container, e.ContainerID, e.Type, e.Severity,
+		e.Message, e.Reason, optionalInt(e.ExitCode), e.OldImage, e.NewImage, e.DetailsJSON,
+	}
+}
+
+func alertCSVRow(a *AlertResponse) []string {
+	incident := ""
+	if a.IncidentID != 0 {
+		incident = strconv.FormatInt(a.IncidentID, 10)
+	}
+	return []string{
+		strconv.FormatInt(a.ID, 10), a.Timestamp, a.Container, a.ContainerID, a.Type, a.Severity,
+		a.Message, a.Reason, optionalInt(a.ExitCode), a.OldImage, a.NewImage, a.DetailsJSON,
+		incident, a.AcknowledgedAt,
+	}
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestExportStreamsTheWholeFilteredResult(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+
+	now := time.Now().UTC()
+	// More than a page, so the export has to page through them.
+	total := exportPage + 5
+	for i := 0; i < total; i++ {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "restart", Severity: "blue", Message: "Restart event", Timestamp: now.Add(time.Duration(i-total) * time.Second)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "started", Severity: "blue", Message: "Container started", Timestamp: now.Add(-72 * time.Hour)}); err != nil {
+		t.Fatalf("add event: %v", err)
+	}
+	exitCode := 137
+	if _, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "oom_killed", Severity: "red", Message: "OOM, \"killed\"\nby the kernel", ExitCode: &exitCode, Timestamp: now}); err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events/export?since=24h", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="healthmon-events-`) || !strings.HasSuffix(got, `.csv"`) {
+		t.Fatalf("unexpected Content-Disposition %q", got)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != total+1 || rows[0][0] != "id" || rows[1][4] != "restart" {
+		t.Fatalf("expected a header and %d events oldest first, got %d rows starting %v", total, len(rows), rows[:2])
+	}
+	first, _ := strconv.Atoi(rows[1][0])
+	last, _ := strconv.Atoi(rows[total][0])
+	if first >= last {
+		t.Fatalf("expected ascending ids, got %d before %d", first, last)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/alerts/export?format=ndjson&severity=red", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("unexpected ndjson response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var alerts []AlertResponse
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var a AlertResponse
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			t.Fatalf("parse ndjson line %q: %v", scanner.Text(), err)
+		}
+		alerts = append(alerts, a)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "oom_killed" || *alerts[0].ExitCode != 137 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/alerts/export", nil))
+	rows, err = csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][6] != "OOM, \"killed\"\nby the kernel" || rows[1][8] != "137" {
+		t.Fatalf("expected the alert quoted in CSV, got %q (%v)", rows, err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/events/export?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", rec.Code)
+	}
+}
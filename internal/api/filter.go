@@ -0,0 +1,148 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// knownSeverities are the severities events and alerts are recorded with.
+var knownSeverities = []string{"blue", "green", "yellow", "red"}
+
+// checkSeverities rejects the first value that is not a known severity.
+func checkSeverities(values []string) error {
+	for _, v := range values {
+		if !slices.Contains(knownSeverities, v) {
+			return fmt.Errorf("invalid severity %q, expected one of %s", v, strings.Join(knownSeverities, ", "))
+		}
+	}
+	return nil
+}
+
+// parseFilter reads the filter, sort and cursor parameters shared by the
+// events and alerts listings. The cursor is the legacy before_id for the
+// default newest first order and after_id with order=asc; parsePage lets the
+// cursor parameter override it.
+func parseFilter(r *http.Request) (store.Filter, int64, error) {
+	q := r.URL.Query()
+	f := store.Filter{
+		Query:      strings.TrimSpace(q.Get("q")),
+		Containers: multiParam(q, "container"),
+		Groups:     multiParam(q, "group"),
+		Types:      multiParam(q, "type"),
+		Severities: multiParam(q, "severity"),
+	}
+	now := time.Now().UTC()
+	var err error
+	if v := q.Get("unacknowledged"); v != "" {
+		if f.Unacknowledged, err = strconv.ParseBool(v); err != nil {
+			return store.Filter{}, 0, fmt.Errorf("invalid unacknowledged %q", v)
+		}
+	}
+	if f.Since, err = parseTimeParam(q.Get("since"), now); err != nil {
+		return store.Filter{}, 0, fmt.Errorf("invalid since: %w", err)
+	}
+	if f.Until, err = parseTimeParam(q.Get("until"), now); err != nil {
+		return store.Filter{}, 0, fmt.Errorf("invalid until: %w", err)
+	}
+
+	switch strings.ToLower(q.Get("order")) {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return store.Filter{}, 0, fmt.Errorf("invalid order %q, expected asc or desc", q.Get("order"))
+	}
+
+	cursorParam := "before_id"
+	if f.Ascending {
+		cursorParam = "after_id"
+	}
+	cursor, _ := strconv.ParseInt(q.Get(cursorParam), 10, 64)
+	return f, cursor, nil
+}
+
+// multiParam accepts both repeated parameters and comma-separated values.
+func multiParam(q url.Values, key string) []string {
+	var out []string
+	for _, value := range q[key] {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// parseTimeParam accepts an RFC3339 timestamp or a duration back from now,
+// either in Go syntax ("36h") or in days ("7d").
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	d, err := parseDurationParam(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 time or duration, got %q", value)
+	}
+	return now.Add(-d), nil
+}
+
+// parseDurationParam accepts a non-negative duration in Go syntax ("36h") or
+// in days ("7d").
+func parseDurationParam(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("expected duration, got %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("expected duration, got %q", value)
+	}
+	return d, nil
+}
+
+// labelSelector matches a label by key, and by value unless anyValue is set.
+type labelSelector struct {
+	key      string
+	value    string
+	anyValue bool
+}
+
+// parseLabelSelectors reads label parameters: `key=value` matches a value,
+// a bare `key` matches any container with the label. Values may contain
+// commas, so selectors are repeated rather than comma-separated.
+func parseLabelSelectors(values []string) []labelSelector {
+	var out []labelSelector
+	for _, value := range values {
+		key, val, hasValue := strings.Cut(value, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		out = append(out, labelSelector{key: key, value: val, anyValue: !hasValue})
+	}
+	return out
+}
+
+// matchLabels reports whether labels match every selector.
+func matchLabels(labels map[string]string, selectors []labelSelector) bool {
+	for _, sel := range selectors {
+		value, ok := labels[sel.key]
+		if !ok || (!sel.anyValue && value != sel.value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"healthmon/internal/store"
+)
+
+var levelNames = map[stateLevel]string{
+	levelUnknown: "unknown",
+	levelOK:      "ok",
+	levelWarn:    "warn",
+	levelBad:     "bad",
+}
+
+// GraphResponse is a service map of the running containers. Edges of type
+// depends_on point from a container to a service it depends on; network
+// edges join two containers that share user-defined networks.
+type GraphResponse struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	Level        string   `json:"level"`
+	HealthStatus string   `json:"health_status"`
+	Role         string   `json:"role"`
+	Networks     []string `json:"networks"`
+}
+
+type GraphEdge struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Type     string   `json:"type"`
+	Networks []string `json:"networks,omitempty"`
+}
+
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, buildGraph(s.store.ListContainers(), s.store.GetContainer))
+}
+
+// buildGraph maps the present containers and what they depend on. A
+// dependency that is not a present container still gets a node, with the
+// status of its stored container ("removed") or "missing", so a broken
+// link stays visible.
+func buildGraph(containers []store.Container, lookup func(name string) (store.Container, bool)) GraphResponse {
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+
+	resp := GraphResponse{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	added := map[string]bool{}
+	addNode := func(c store.Container) {
+		status, level := containerState(c)
+		networks := c.Networks
+		if networks == nil {
+			networks = []string{}
+		}
+		resp.Nodes = append(resp.Nodes, GraphNode{
+			Name:         c.Name,
+			Status:       status,
+			Level:        levelNames[level],
+			HealthStatus: c.HealthStatus,
+			Role:         c.Role,
+			Networks:     networks,
+		})
+		added[c.Name] = true
+	}
+
+	var present []store.Container
+	for _, c := range containers {
+		if c.Present {
+			present = append(present, c)
+			addNode(c)
+		}
+	}
+	for _, c := range present {
+		for _, dep := range c.DependsOn {
+			if dep == c.Name {
+				continue
+			}
+			if !added[dep] {
+				if stored, ok := lookup(dep); ok {
+					addNode(stored)
+				} else {
+					resp.Nodes = append(resp.Nodes, GraphNode{Name: dep, Status: "missing", Level: levelNames[levelBad], Networks: []string{}})
+					added[dep] = true
+				}
+			}
+			resp.Edges = append(resp.Edges, GraphEdge{From: c.Name, To: dep, Type: "depends_on"})
+		}
+	}
+	for i, a := range present {
+		for _, b := range present[i+1:] {
+			if shared := sharedNetworks(a.Networks, b.Networks); len(shared) > 0 {
+				resp.Edges = append(resp.Edges, GraphEdge{From: a.Name, To: b.Name, Type: "network", Networks: shared})
+			}
+		}
+	}
+	sort.Slice(resp.Nodes, func(i, j int) bool { return resp.Nodes[i].Name < resp.Nodes[j].Name })
+	return resp
+}
+
+func sharedNetworks(a, b []string) []string {
+	var shared []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared = append(shared, x)
+			}
+		}
+	}
+	return shared
+}
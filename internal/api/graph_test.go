@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestGraphLinksDependenciesAndSharedNetworks(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, c := range []store.Container{
+		{Name: "web", ContainerID: "c-web", Status: "running", HealthStatus: "healthy", StartedAt: now, DependsOn: []string{"db", "cache"}, Networks: []string{"backend", "frontend"}},
+		{Name: "db", ContainerID: "c-db", Status: "running", HealthStatus: "unhealthy", StartedAt: now, Networks: []string{"backend"}},
+		{Name: "proxy", ContainerID: "c-proxy", Status: "running", StartedAt: now, Networks: []string{"frontend"}},
+		{Name: "cache", ContainerID: "c-cache", Status: "exited", StartedAt: now},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	// An upsert without the graph fields keeps the stored ones.
+	web, _ := st.GetContainer("web")
+	web.DependsOn, web.Networks = nil, nil
+	if err := st.UpsertContainer(ctx, web); err != nil {
+		t.Fatalf("upsert web: %v", err)
+	}
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	if err := st.SetContainerPresent(ctx, "cache", false); err != nil {
+		t.Fatalf("remove cache: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/graph", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("graph: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp GraphResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode graph: %v", err)
+	}
+
+	statuses := map[string]string{}
+	for _, node := range resp.Nodes {
+		statuses[node.Name] = node.Status + "/" + node.Level
+	}
+	wantStatuses := map[string]string{"cache": "removed/unknown", "db": "unhealthy/bad", "proxy": "running/ok", "web": "healthy/ok"}
+	if !reflect.DeepEqual(statuses, wantStatuses) {
+		t.Fatalf("expected nodes %v, got %v", wantStatuses, statuses)
+	}
+	wantEdges := []GraphEdge{
+		{From: "web", To: "db", Type: "depends_on"},
+		{From: "web", To: "cache", Type: "depends_on"},
+		{From: "db", To: "web", Type: "network", Networks: []string{"backend"}},
+		{From: "proxy", To: "web", Type: "network", Networks: []string{"frontend"}},
+	}
+	if !reflect.DeepEqual(resp.Edges, wantEdges) {
+		t.Fatalf("expected edges %+v, got %+v", wantEdges, resp.Edges)
+	}
+}
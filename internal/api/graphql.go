@@ -0,0 +1,1015 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file holds a small GraphQL query engine: a parser for query
+// documents and an executor that resolves their selections against
+// gqlObject values. It supports what dashboards need to fetch data in one
+// round trip: fields with arguments and aliases, variables, named and
+// inline fragments, @include and @skip, and __typename. Mutations,
+// subscriptions and introspection are not supported; the schema is
+// documented in the README.
+
+// gqlDocument is a parsed query document.
+type gqlDocument struct {
+	operations []gqlOperation
+	fragments  map[string]gqlFragment
+}
+
+type gqlOperation struct {
+	kind      string
+	name      string
+	variables []gqlVariable
+	selection []gqlSelection
+}
+
+type gqlVariable struct {
+	name       string
+	defaultVal any
+	hasDefault bool
+}
+
+type gqlFragment struct {
+	typeCondition string
+	selection     []gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread or an inline fragment.
+type gqlSelection struct {
+	// Fields have a name; fragment spreads a fragment and inline fragments
+	// neither.
+	alias      string
+	name       string
+	arguments  map[string]any
+	fragment   string
+	typeCond   string
+	directives []gqlDirective
+	selection  []gqlSelection
+}
+
+type gqlDirective struct {
+	name      string
+	arguments map[string]any
+}
+
+// gqlVariableRef is an argument value taken from a variable.
+type gqlVariableRef string
+
+// gqlEnum is an enum value, which arguments accept as a string.
+type gqlEnum string
+
+type gqlToken struct {
+	kind  byte // 'p' punctuator, 'n' name, 'i' int, 'f' float, 's' string, 0 end
+	value string
+	pos   int
+}
+
+type gqlParser struct {
+	src    string
+	pos    int
+	tok    gqlToken
+	depth  int
+	parsed int
+}
+
+// gqlMaxDepth and gqlMaxNodes bound the size of the documents accepted.
+// gqlMaxDepth also bounds the objects nested in a response, which fragments
+// can take deeper than the document, and gqlMaxResolved the fields resolved
+// for one query, since every list multiplies the fields below it.
+const (
+	gqlMaxDepth    = 12
+	gqlMaxNodes    = 2000
+	gqlMaxResolved = 10000
+)
+
+func parseGraphQL(src string) (*gqlDocument, error) {
+	p := &gqlParser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := &gqlDocument{fragments: make(map[string]gqlFragment)}
+	for p.tok.kind != 0 {
+		switch {
+		case p.tok.kind == 'p' && p.tok.value == "{":
+			selection, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, gqlOperation{kind: "query", selection: selection})
+		case p.tok.kind == 'n' && p.tok.value == "fragment":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.keyword("on"); err != nil {
+				return nil, err
+			}
+			typeCond, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.directives(); err != nil {
+				return nil, err
+			}
+			selection, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[name]; dup {
+				return nil, fmt.Errorf("fragment %q is defined twice", name)
+			}
+			doc.fragments[name] = gqlFragment{typeCondition: typeCond, selection: selection}
+		case p.tok.kind == 'n' && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	for name := range doc.fragments {
+		if doc.spreads(name, doc.fragments[name].selection, make(map[string]bool)) {
+			return nil, fmt.Errorf("fragment %q spreads itself", name)
+		}
+	}
+	return doc, nil
+}
+
+// spreads reports whether selection spreads the fragment name, directly or
+// through other fragments, at any depth. Such a fragment would expand
+// forever.
+func (doc *gqlDocument) spreads(name string, selection []gqlSelection, seen map[string]bool) bool {
+	for _, sel := range selection {
+		if sel.fragment != "" {
+			if sel.fragment == name {
+				return true
+			}
+			if seen[sel.fragment] {
+				continue
+			}
+			seen[sel.fragment] = true
+			if doc.spreads(name, doc.fragments[sel.fragment].selection, seen) {
+				return true
+			}
+			continue
+		}
+		if doc.spreads(name, sel.selection, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *gqlParser) operation() (gqlOperation, error) {
+	op := gqlOperation{kind: p.tok.value}
+	if err := p.next(); err != nil {
+		return op, err
+	}
+	if p.tok.kind == 'n' {
+		op.name = p.tok.value
+		if err := p.next(); err != nil {
+			return op, err
+		}
+	}
+	if p.is("(") {
+		if err := p.next(); err != nil {
+			return op, err
+		}
+		for !p.is(")") {
+			if err := p.expect("$"); err != nil {
+				return op, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return op, err
+			}
+			if err := p.expect(":"); err != nil {
+				return op, err
+			}
+			if err := p.typeRef(); err != nil {
+				return op, err
+			}
+			v := gqlVariable{name: name}
+			if p.is("=") {
+				if err := p.next(); err != nil {
+					return op, err
+				}
+				if v.defaultVal, err = p.value(true); err != nil {
+					return op, err
+				}
+				v.hasDefault = true
+			}
+			op.variables = append(op.variables, v)
+		}
+		if err := p.next(); err != nil {
+			return op, err
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return op, err
+	}
+	selection, err := p.selectionSet()
+	op.selection = selection
+	return op, err
+}
+
+// typeRef skips a variable type such as [String!]!; variables are checked
+// by the resolvers that use them.
+func (p *gqlParser) typeRef() error {
+	if p.is("[") {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.is("!") {
+		return p.next()
+	}
+	return nil
+}
+
+func (p *gqlParser) selectionSet() ([]gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	p.depth++
+	if p.depth > gqlMaxDepth {
+		return nil, fmt.Errorf("query is nested deeper than %d levels", gqlMaxDepth)
+	}
+	var out []gqlSelection
+	for !p.is("}") {
+		if p.tok.kind == 0 {
+			return nil, p.unexpected()
+		}
+		p.parsed++
+		if p.parsed > gqlMaxNodes {
+			return nil, fmt.Errorf("query selects more than %d fields", gqlMaxNodes)
+		}
+		sel, err := p.selectionItem()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	p.depth--
+	return out, p.next()
+}
+
+func (p *gqlParser) selectionItem() (gqlSelection, error) {
+	var sel gqlSelection
+	var err error
+	if p.is("...") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		if p.tok.kind == 'n' && p.tok.value != "on" {
+			sel.fragment = p.tok.value
+			if err := p.next(); err != nil {
+				return sel, err
+			}
+			sel.directives, err = p.directives()
+			return sel, err
+		}
+		if p.tok.kind == 'n' && p.tok.value == "on" {
+			if err := p.next(); err != nil {
+				return sel, err
+			}
+			if sel.typeCond, err = p.name(); err != nil {
+				return sel, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return sel, err
+		}
+		sel.selection, err = p.selectionSet()
+		return sel, err
+	}
+	if sel.name, err = p.name(); err != nil {
+		return sel, err
+	}
+	if p.is(":") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+	if sel.arguments, err = p.arguments(); err != nil {
+		return sel, err
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return sel, err
+	}
+	if p.is("{") {
+		sel.selection, err = p.selectionSet()
+	}
+	return sel, err
+}
+
+func (p *gqlParser) arguments() (map[string]any, error) {
+	if !p.is("(") {
+		return nil, nil
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	args := make(map[string]any)
+	for !p.is(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, p.next()
+}
+
+func (p *gqlParser) directives() ([]gqlDirective, error) {
+	var out []gqlDirective
+	for p.is("@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, gqlDirective{name: name, arguments: args})
+	}
+	return out, nil
+}
+
+// value parses an argument value. Default values of variables must be
+// constant.
+func (p *gqlParser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case 'p':
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("variable in constant value at %d", tok.pos)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			return gqlVariableRef(name), err
+		case "[":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			list := []any{}
+			for !p.is("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			return list, p.next()
+		case "{":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			obj := make(map[string]any)
+			for !p.is("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if obj[name], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return obj, p.next()
+		}
+	case 'i':
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int %s at %d", tok.value, tok.pos)
+		}
+		return n, p.next()
+	case 'f':
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %s at %d", tok.value, tok.pos)
+		}
+		return f, p.next()
+	case 's':
+		return tok.value, p.next()
+	case 'n':
+		var v any
+		switch tok.value {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = gqlEnum(tok.value)
+		}
+		return v, p.next()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *gqlParser) is(punct string) bool {
+	return p.tok.kind == 'p' && p.tok.value == punct
+}
+
+func (p *gqlParser) expect(punct string) error {
+	if !p.is(punct) {
+		return p.unexpected()
+	}
+	return p.next()
+}
+
+func (p *gqlParser) keyword(word string) error {
+	if p.tok.kind != 'n' || p.tok.value != word {
+		return p.unexpected()
+	}
+	return p.next()
+}
+
+func (p *gqlParser) name() (string, error) {
+	if p.tok.kind != 'n' {
+		return "", p.unexpected()
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *gqlParser) unexpected() error {
+	if p.tok.kind == 0 {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at %d", p.tok.value, p.tok.pos)
+}
+
+// next reads the next token, skipping whitespace, commas and comments.
+func (p *gqlParser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "\uFEFF"):
+			p.pos += len("\uFEFF")
+		default:
+			return p.token()
+		}
+	}
+	p.tok = gqlToken{pos: p.pos}
+	return nil
+}
+
+func (p *gqlParser) token() error {
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = gqlToken{kind: 'p', value: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		p.pos++
+		p.tok = gqlToken{kind: 'p', value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = gqlToken{kind: 'n', value: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.pos++
+		float := false
+		for p.pos < len(p.src) {
+			d := p.src[p.pos]
+			if isDigit(d) {
+				p.pos++
+				continue
+			}
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E')) {
+				float = true
+				p.pos++
+				continue
+			}
+			break
+		}
+		kind := byte('i')
+		if float {
+			kind = 'f'
+		}
+		p.tok = gqlToken{kind: kind, value: p.src[start:p.pos], pos: start}
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			return fmt.Errorf("unterminated string at %d", start)
+		}
+		p.tok = gqlToken{kind: 's', value: strings.TrimSpace(p.src[p.pos+3 : p.pos+3+end]), pos: start}
+		p.pos += 3 + end + 3
+	case c == '"':
+		value, err := p.stringValue()
+		if err != nil {
+			return err
+		}
+		p.tok = gqlToken{kind: 's', value: value, pos: start}
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		return fmt.Errorf("unexpected character %q at %d", r, start)
+	}
+	return nil
+}
+
+func (p *gqlParser) stringValue() (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\n', '\r':
+			return "", fmt.Errorf("unterminated string at %d", start)
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", fmt.Errorf("unterminated string at %d", start)
+			}
+			esc := p.src[p.pos+1]
+			p.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", fmt.Errorf("invalid escape at %d", p.pos-2)
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid escape at %d", p.pos-2)
+				}
+				b.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				return "", fmt.Errorf("invalid escape at %d", p.pos-2)
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string at %d", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// gqlObject is an object a query selects fields of. A field resolves to a
+// scalar, a []any, a map[string]any, which is selected like an object or
+// returned whole as JSON without a selection, or another gqlObject.
+type gqlObject interface {
+	typename() string
+	resolve(ctx context.Context, field string, args map[string]any) (any, error)
+}
+
+// gqlResolver resolves a field that takes arguments or loads more data.
+type gqlResolver func(ctx context.Context, args map[string]any) (any, error)
+
+// gqlType is an object backed by one of the REST response types: it serves
+// the response's JSON fields under their JSON names, and the fields in
+// extra through their resolvers.
+type gqlType struct {
+	name   string
+	fields map[string]any
+	known  map[string]bool
+	extra  map[string]gqlResolver
+}
+
+// newGQLType wraps a response struct.
+func newGQLType(name string, v any, extra map[string]gqlResolver) (*gqlType, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	t := &gqlType{name: name, known: jsonFieldNames(reflect.TypeOf(v)), extra: extra}
+	if err := json.Unmarshal(raw, &t.fields); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *gqlType) typename() string { return t.name }
+
+func (t *gqlType) resolve(ctx context.Context, field string, args map[string]any) (any, error) {
+	if r, ok := t.extra[field]; ok {
+		return r(ctx, args)
+	}
+	if !t.known[field] {
+		return nil, fmt.Errorf("%s has no field %q", t.name, field)
+	}
+	return t.fields[field], nil
+}
+
+// jsonFieldNames lists the JSON names of a struct's fields, including
+// those omitted when empty, which resolve to null.
+func jsonFieldNames(typ reflect.Type) map[string]bool {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	names := make(map[string]bool)
+	if typ.Kind() != reflect.Struct {
+		return names
+	}
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			for name := range jsonFieldNames(f.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// gqlError is an error of a GraphQL response, with the path of the field
+// it happened at.
+type gqlError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// gqlResult is a selected object, which keeps its fields in the order the
+// query asked for them.
+type gqlResult struct {
+	keys   []string
+	values map[string]any
+}
+
+func (r *gqlResult) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteByte(':')
+		v, err := json.Marshal(r.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+type gqlExecutor struct {
+	doc      *gqlDocument
+	vars     map[string]any
+	errors   []gqlError
+	resolved int
+	// halted is set once the query ran out of its budget or was canceled;
+	// the remaining fields resolve to null.
+	halted bool
+}
+
+// executeGraphQL runs the named operation of a document, or its only one,
+// against root.
+func executeGraphQL(ctx context.Context, doc *gqlDocument, operationName string, variables map[string]any, root gqlObject) (*gqlResult, []gqlError) {
+	var op *gqlOperation
+	for i := range doc.operations {
+		if operationName == "" || doc.operations[i].name == operationName {
+			if op != nil {
+				return nil, []gqlError{{Message: "document has several operations, operationName must name one"}}
+			}
+			op = &doc.operations[i]
+		}
+	}
+	if op == nil {
+		return nil, []gqlError{{Message: fmt.Sprintf("unknown operation %q", operationName)}}
+	}
+	if op.kind != "query" {
+		return nil, []gqlError{{Message: "only queries are supported, not " + op.kind}}
+	}
+	e := &gqlExecutor{doc: doc, vars: make(map[string]any)}
+	for _, v := range op.variables {
+		if value, ok := variables[v.name]; ok {
+			e.vars[v.name] = value
+		} else if v.hasDefault {
+			e.vars[v.name] = v.defaultVal
+		}
+	}
+	return e.selectObject(ctx, root, op.selection, nil), e.errors
+}
+
+type gqlField struct {
+	name      string
+	arguments map[string]any
+	selection []gqlSelection
+}
+
+// collect flattens fragments into the fields selected on an object of the
+// given type, merging the subselections of fields under the same key.
+func (e *gqlExecutor) collect(typename string, selection []gqlSelection, keys *[]string, fields map[string]*gqlField) {
+	for _, sel := range selection {
+		if !e.included(sel.directives) {
+			continue
+		}
+		switch {
+		case sel.fragment != "":
+			frag, ok := e.doc.fragments[sel.fragment]
+			if !ok || (frag.typeCondition != typename && frag.typeCondition != "Query") {
+				if !ok {
+					e.errors = append(e.errors, gqlError{Message: fmt.Sprintf("unknown fragment %q", sel.fragment)})
+				}
+				continue
+			}
+			e.collect(typename, frag.selection, keys, fields)
+		case sel.name == "":
+			if sel.typeCond == "" || sel.typeCond == typename {
+				e.collect(typename, sel.selection, keys, fields)
+			}
+		default:
+			key := sel.alias
+			if key == "" {
+				key = sel.name
+			}
+			if f, ok := fields[key]; ok {
+				f.selection = append(f.selection, sel.selection...)
+				continue
+			}
+			*keys = append(*keys, key)
+			fields[key] = &gqlField{name: sel.name, arguments: sel.arguments, selection: sel.selection}
+		}
+	}
+}
+
+// included evaluates @include(if:) and @skip(if:).
+func (e *gqlExecutor) included(directives []gqlDirective) bool {
+	for _, d := range directives {
+		cond, _ := e.argument(d.arguments["if"]).(bool)
+		if (d.name == "include" && !cond) || (d.name == "skip" && cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// argument substitutes the variables in an argument value.
+func (e *gqlExecutor) argument(v any) any {
+	switch v := v.(type) {
+	case gqlVariableRef:
+		// Defaults are parsed values, which can hold enums.
+		return e.argument(e.vars[string(v)])
+	case gqlEnum:
+		return string(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = e.argument(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = e.argument(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (e *gqlExecutor) selectObject(ctx context.Context, obj gqlObject, selection []gqlSelection, path []any) *gqlResult {
+	var keys []string
+	fields := make(map[string]*gqlField)
+	e.collect(obj.typename(), selection, &keys, fields)
+	result := &gqlResult{keys: keys, values: make(map[string]any, len(keys))}
+	for _, key := range keys {
+		f := fields[key]
+		fieldPath := append(append([]any{}, path...), key)
+		if !e.proceed(ctx, fieldPath) {
+			result.values[key] = nil
+			continue
+		}
+		if f.name == "__typename" {
+			result.values[key] = obj.typename()
+			continue
+		}
+		args := make(map[string]any, len(f.arguments))
+		for name, v := range f.arguments {
+			args[name] = e.argument(v)
+		}
+		value, err := obj.resolve(ctx, f.name, args)
+		if err != nil {
+			e.errors = append(e.errors, gqlError{Message: err.Error(), Path: fieldPath})
+			result.values[key] = nil
+			continue
+		}
+		result.values[key] = e.complete(ctx, value, f.selection, fieldPath)
+	}
+	return result
+}
+
+// proceed counts a field against the query's budget and reports whether
+// it is resolved. The first field over the budget, nested too deep or
+// reached after ctx is done gets the error and stops the query: the fields
+// after it are left null without one.
+func (e *gqlExecutor) proceed(ctx context.Context, path []any) bool {
+	if e.halted {
+		return false
+	}
+	depth := 0
+	for _, p := range path {
+		if _, ok := p.(string); ok {
+			depth++
+		}
+	}
+	var message string
+	switch e.resolved++; {
+	case ctx.Err() != nil:
+		message = ctx.Err().Error()
+	case depth > gqlMaxDepth:
+		message = fmt.Sprintf("query is nested deeper than %d levels", gqlMaxDepth)
+	case e.resolved > gqlMaxResolved:
+		message = fmt.Sprintf("query resolves more than %d fields", gqlMaxResolved)
+	default:
+		return true
+	}
+	e.halted = true
+	e.errors = append(e.errors, gqlError{Message: message, Path: path})
+	return false
+}
+
+// complete selects the subfields of a resolved value.
+func (e *gqlExecutor) complete(ctx context.Context, value any, selection []gqlSelection, path []any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case gqlObject:
+		if len(selection) == 0 {
+			e.errors = append(e.errors, gqlError{Message: "field of type " + v.typename() + " needs a selection of subfields", Path: path})
+			return nil
+		}
+		return e.selectObject(ctx, v, selection, path)
+	case map[string]any:
+		if len(selection) == 0 {
+			return v
+		}
+		return e.selectObject(ctx, gqlMap(v), selection, path)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = e.complete(ctx, item, selection, append(append([]any{}, path...), i))
+		}
+		return out
+	default:
+		if len(selection) > 0 {
+			e.errors = append(e.errors, gqlError{Message: "scalar field has no subfields", Path: path})
+			return nil
+		}
+		return v
+	}
+}
+
+// gqlMap is a JSON object selected like an object. Missing keys are null.
+type gqlMap map[string]any
+
+func (m gqlMap) typename() string { return "Object" }
+
+func (m gqlMap) resolve(_ context.Context, field string, _ map[string]any) (any, error) {
+	return m[field], nil
+}
+
+// gqlString reads a string argument, or "" when it is not given.
+func gqlString(args map[string]any, name string) (string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("argument %s must be a string", name)
+	}
+}
+
+// gqlStrings reads an argument that takes a string or a list of strings.
+func gqlStrings(args map[string]any, name string) ([]string, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %s must be a list of strings", name)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("argument %s must be a list of strings", name)
+	}
+}
+
+// gqlInt reads an int argument, or def when it is not given. Variables
+// decoded from JSON arrive as float64.
+func gqlInt(args map[string]any, name string, def int) (int, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return def, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %s must be an int", name)
+}
+
+// gqlBool reads a boolean argument, or false when it is not given.
+func gqlBool(args map[string]any, name string) (bool, error) {
+	switch v := args[name].(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	default:
+		return false, fmt.Errorf("argument %s must be a boolean", name)
+	}
+}
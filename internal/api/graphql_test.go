@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestGraphQLSelectsNestedFields(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, c := range []store.Container{
+		{Name: "web", ContainerID: "c-web", Status: "running", StartedAt: now, Labels: map[string]string{"healthmon.group": "media"}},
+		{Name: "db", ContainerID: "c-db", Status: "running", StartedAt: now},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	web, _ := st.GetContainer("web")
+	for i, severity := range []string{"blue", "red", "blue"} {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "restart", Severity: severity, Message: severity, Timestamp: now.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	if _, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "restart_loop", Severity: "red", Message: "restarting", Timestamp: now}); err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+
+	query := `
+query Dashboard($limit: Int = 1, $withStats: Boolean!) {
+  containers(group: "media") {
+    name
+    kind: __typename
+    alert_count
+    stats(window: "1d") @include(if: $withStats) { score }
+    latest: events(limit: $limit) { message }
+    ...Alerts
+  }
+  events(severity: [red]) { type container_info { name } }
+  missing: container(name: "nope") { name }
+}
+
+fragment Alerts on Container { alerts { type comments } }
+`
+	body, _ := json.Marshal(GraphQLRequest{Query: query, Variables: map[string]any{"withStats": true}})
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	want := `{"data":{"containers":[{"name":"web","kind":"Container","alert_count":1,"stats":{"score":100},"latest":[{"message":"blue"}],"alerts":[{"type":"restart_loop","comments":null}]}],"events":[{"type":"restart","container_info":{"name":"web"}}],"missing":null}}`
+	if got := string(bytes.TrimSpace(rec.Body.Bytes())); got != want {
+		t.Fatalf("unexpected response\n got %s\nwant %s", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/graphql?query="+url.QueryEscape(`{ containers { name status { x } nope } }`), nil))
+	var resp struct {
+		Data   map[string]any `json:"data"`
+		Errors []gqlError     `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(resp.Errors) != 4 || resp.Errors[0].Message != "scalar field has no subfields" {
+		t.Fatalf("expected field errors next to the data, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGraphQLRejectsInvalidDocuments(t *testing.T) {
+	for _, query := range []string{
+		`{ containers { name }`,
+		`mutation { ack(id: 1) }`,
+		`query A { containers { name } } query B { events { id } }`,
+		`{ ` + string(bytes.Repeat([]byte("a { "), 20)) + string(bytes.Repeat([]byte("}"), 21)),
+		`{ containers { ...F } } fragment F on Container { events { container_info { ...F } } }`,
+		`{ containers { ...A } } fragment A on Container { ...B } fragment B on Container { alerts { ... on Alert { ...A } } }`,
+	} {
+		doc, err := parseGraphQL(query)
+		if err != nil {
+			continue
+		}
+		if data, errs := executeGraphQL(context.Background(), doc, "", nil, &gqlType{name: "Query"}); data != nil || len(errs) == 0 {
+			t.Fatalf("expected %q to be rejected", query)
+		}
+	}
+}
+
+// fanOut is an object whose items are ten more of itself.
+type fanOut struct{}
+
+func (fanOut) typename() string { return "FanOut" }
+
+func (fanOut) resolve(_ context.Context, field string, _ map[string]any) (any, error) {
+	if field == "name" {
+		return "x", nil
+	}
+	items := make([]any, 10)
+	for i := range items {
+		items[i] = fanOut{}
+	}
+	return items, nil
+}
+
+func TestGraphQLStopsQueriesOverTheirBudget(t *testing.T) {
+	ctx := context.Background()
+	// 10 + 100 + ... + 100000 fields, far over the budget.
+	doc, err := parseGraphQL(`{ items { items { items { items { items { name } } } } } }`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	data, errs := executeGraphQL(ctx, doc, "", nil, fanOut{})
+	if data == nil || len(errs) != 1 || errs[0].Message != fmt.Sprintf("query resolves more than %d fields", gqlMaxResolved) {
+		t.Fatalf("expected the query to stop at its budget, got %+v", errs)
+	}
+
+	// Fragments nest deeper than the document does.
+	doc, err = parseGraphQL(`{ ...A }
+fragment A on FanOut { items { ...B } }
+fragment B on FanOut { items { ...C } }
+fragment C on FanOut { items { items { items { items { items { items { items { items { items { items { name } } } } } } } } } } }`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, errs := executeGraphQL(ctx, doc, "", nil, fanOut{}); len(errs) != 1 || errs[0].Message != fmt.Sprintf("query is nested deeper than %d levels", gqlMaxDepth) {
+		t.Fatalf("expected the query to stop at the depth limit, got %+v", errs)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	doc, err = parseGraphQL(`{ items { name } }`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, errs := executeGraphQL(canceled, doc, "", nil, fanOut{}); len(errs) != 1 || errs[0].Message != context.Canceled.Error() {
+		t.Fatalf("expected a canceled query to stop, got %+v", errs)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"healthmon/internal/store"
+)
+
+// GroupResponse rolls up the containers sharing a healthmon.group label.
+// Status is the worst level among them.
+type GroupResponse struct {
+	Name                 string   `json:"name"`
+	Status               string   `json:"status"`
+	Containers           []string `json:"containers"`
+	OK                   int      `json:"ok"`
+	Warn                 int      `json:"warn"`
+	Bad                  int      `json:"bad"`
+	UnacknowledgedAlerts int64    `json:"unacknowledged_alerts"`
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	items := s.store.ListContainers()
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	byName := map[string]*GroupResponse{}
+	worst := map[string]stateLevel{}
+	for _, c := range items {
+		name := c.Group()
+		if name == "" {
+			continue
+		}
+		group, ok := byName[name]
+		if !ok {
+			group = &GroupResponse{Name: name, Containers: []string{}}
+			byName[name] = group
+		}
+		group.Containers = append(group.Containers, c.Name)
+		_, level := containerState(c)
+		switch level {
+		case levelOK:
+			group.OK++
+		case levelWarn:
+			group.Warn++
+		case levelBad:
+			group.Bad++
+		}
+		if level > worst[name] {
+			worst[name] = level
+		}
+	}
+
+	resp := make([]GroupResponse, 0, len(byName))
+	for name, group := range byName {
+		unacked, err := s.store.CountAlerts(r.Context(), store.Filter{Groups: []string{name}, Unacknowledged: true})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		group.UnacknowledgedAlerts = unacked
+		group.Status = levelNames[worst[name]]
+		resp = append(resp, *group)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Name < resp[j].Name })
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestGroupsRollUpHealthAndFilterAlerts(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	media := map[string]string{store.GroupLabel: "media"}
+	infra := map[string]string{store.GroupLabel: "infra"}
+	for _, c := range []store.Container{
+		{Name: "jellyfin", Status: "running", HealthStatus: "healthy", Labels: media},
+		{Name: "sonarr", Status: "running", HealthStatus: "unhealthy", Labels: media},
+		{Name: "traefik", Status: "running", Labels: infra},
+		{Name: "scratch", Status: "running"},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	for _, name := range []string{"sonarr", "traefik"} {
+		c, _ := st.GetContainer(name)
+		if _, err := st.AddAlert(ctx, store.Alert{ContainerPK: c.ID, Container: name, Type: "unhealthy", Severity: "red", Message: "Container became unhealthy", Timestamp: now}); err != nil {
+			t.Fatalf("add alert: %v", err)
+		}
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	get := func(path string, out interface{}) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+	}
+
+	var groups []GroupResponse
+	get("/api/groups", &groups)
+	if len(groups) != 2 || groups[0].Name != "infra" || groups[1].Name != "media" {
+		t.Fatalf("expected infra and media groups, got %+v", groups)
+	}
+	if g := groups[1]; g.Status != "bad" || g.OK != 1 || g.Bad != 1 || len(g.Containers) != 2 || g.UnacknowledgedAlerts != 1 {
+		t.Fatalf("unexpected media rollup: %+v", g)
+	}
+	if g := groups[0]; g.Status != "ok" || g.UnacknowledgedAlerts != 1 {
+		t.Fatalf("unexpected infra rollup: %+v", g)
+	}
+
+	var containers []ContainerResponse
+	get("/api/containers?group=media", &containers)
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 media containers, got %+v", containers)
+	}
+	var alerts AlertListResponse
+	get("/api/alerts?group=media", &alerts)
+	if alerts.Total != 1 || len(alerts.Items) != 1 || alerts.Items[0].Container != "sonarr" {
+		t.Fatalf("expected the sonarr alert only, got %+v", alerts)
+	}
+	get("/api/alerts?group=none", &alerts)
+	if alerts.Total != 0 || len(alerts.Items) != 0 {
+		t.Fatalf("expected no alerts for an unknown group, got %+v", alerts)
+	}
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+	healthmonv1 "healthmon/proto/healthmon/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// grpcWatchBuffer is how many updates a Watch stream may fall behind
+	// before it is closed, like the queue of a WebSocket client.
+	grpcWatchBuffer = 64
+)
+
+// GRPC returns a gRPC server with the HealthmonService API, the read side
+// of the REST API and a Watch stream of the updates sent to WebSocket
+// clients. With auth enabled, calls need an API token of either scope as
+// "authorization: Bearer <token>" metadata, since every RPC only reads.
+// Call it before the monitor starts, like OnUpdate.
+func (s *Server) GRPC() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.grpcAuthorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.grpcAuthorize(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	svc := &grpcService{server: s, watchers: make(map[*grpcWatcher]struct{})}
+	s.OnUpdate(svc.publish)
+	healthmonv1.RegisterHealthmonServiceServer(srv, svc)
+	return srv
+}
+
+// grpcAuthorize checks the API token of a call. The proxy headers and OIDC
+// sessions of the web UI do not apply to gRPC clients.
+func (s *Server) grpcAuthorize(ctx context.Context) error {
+	if !s.auth.enabled() {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			if _, ok := s.auth.lookup(strings.TrimSpace(token)); ok {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or unknown API token")
+}
+
+type grpcService struct {
+	healthmonv1.UnimplementedHealthmonServiceServer
+	server *Server
+
+	mu       sync.Mutex
+	watchers map[*grpcWatcher]struct{}
+}
+
+// grpcWatcher is an open Watch stream. slow is closed when its queue
+// overflowed.
+type grpcWatcher struct {
+	containers []string
+	severities []string
+	updates    chan *healthmonv1.WatchResponse
+	slow       chan struct{}
+}
+
+func (g *grpcService) ListContainers(ctx context.Context, req *healthmonv1.ListContainersRequest) (*healthmonv1.ListContainersResponse, error) {
+	items := g.server.store.ListContainers()
+	if req.GetIncludeRemoved() {
+		items = g.server.store.ListAllContainers()
+	}
+	containers, err := g.server.containerResponses(ctx, items, req.GetGroups(), parseLabelSelectors(req.GetLabels()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &healthmonv1.ListContainersResponse{Containers: make([]*healthmonv1.Container, 0, len(containers))}
+	for _, c := range containers {
+		resp.Containers = append(resp.Containers, toProtoContainer(c))
+	}
+	return resp, nil
+}
+
+func (g *grpcService) GetContainer(ctx context.Context, req *healthmonv1.GetContainerRequest) (*healthmonv1.GetContainerResponse, error) {
+	c, ok := g.server.store.GetContainer(req.GetName())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "container not found")
+	}
+	containers, err := g.server.containerResponses(ctx, []store.Container{c}, nil, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &healthmonv1.GetContainerResponse{Container: toProtoContainer(containers[0])}, nil
+}
+
+func (g *grpcService) ListEvents(ctx context.Context, req *healthmonv1.ListEventsRequest) (*healthmonv1.ListEventsResponse, error) {
+	filter := store.Filter{
+		Query:      strings.TrimSpace(req.GetQuery()),
+		Containers: req.GetContainers(),
+		Groups:     req.GetGroups(),
+		Types:      req.GetTypes(),
+		Severities: req.GetSeverities(),
+		Since:      fromProtoTime(req.GetSince()),
+		Until:      fromProtoTime(req.GetUntil()),
+		Ascending:  req.GetAscending(),
+	}
+	p := grpcPage(req.GetCursor(), req.GetLimit())
+	query, cursor, limit := p.query(filter)
+	items, err := g.server.store.ListAllEvents(ctx, query, cursor, limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	total, err := g.server.store.CountEvents(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	list := toEventList(p, items, total)
+	resp := &healthmonv1.ListEventsResponse{Events: make([]*healthmonv1.Event, 0, len(list.Items)), Total: total}
+	for i := range list.Items {
+		resp.Events = append(resp.Events, toProtoEvent(&list.Items[i]))
+	}
+	if list.HasMore {
+		resp.NextCursor = list.Items[len(list.Items)-1].ID
+	}
+	return resp, nil
+}
+
+func (g *grpcService) ListAlerts(ctx context.Context, req *healthmonv1.ListAlertsRequest) (*healthmonv1.ListAlertsResponse, error) {
+	filter := store.Filter{
+		Containers:     req.GetContainers(),
+		Groups:         req.GetGroups(),
+		Types:          req.GetTypes(),
+		Severities:     req.GetSeverities(),
+		Since:          fromProtoTime(req.GetSince()),
+		Until:          fromProtoTime(req.GetUntil()),
+		Unacknowledged: req.GetUnacknowledged(),
+		Ascending:      req.GetAscending(),
+	}
+	p := grpcPage(req.GetCursor(), req.GetLimit())
+	query, cursor, limit := p.query(filter)
+	items, err := g.server.store.ListAllAlerts(ctx, query, cursor, limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	total, err := g.server.store.CountAlerts(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	list := toAlertList(p, items, total)
+	resp := &healthmonv1.ListAlertsResponse{Alerts: make([]*healthmonv1.Alert, 0, len(list.Items)), Total: total}
+	for i := range list.Items {
+		resp.Alerts = append(resp.Alerts, toProtoAlert(&list.Items[i]))
+	}
+	if list.HasMore {
+		resp.NextCursor = list.Items[len(list.Items)-1].ID
+	}
+	return resp, nil
+}
+
+func (g *grpcService) GetStats(ctx context.Context, req *healthmonv1.GetStatsRequest) (*healthmonv1.GetStatsResponse, error) {
+	window := 7 * 24 * time.Hour
+	if req.GetWindow() != nil {
+		if window = req.GetWindow().AsDuration(); window < time.Hour {
+			return nil, status.Error(codes.InvalidArgument, "window must be at least 1h")
+		}
+	}
+	stats, err := g.server.containerStats(ctx, window)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &healthmonv1.GetStatsResponse{
+		Window:     durationpb.New(window),
+		Since:      toProtoTime(stats.Since),
+		Until:      toProtoTime(stats.Until),
+		Containers: make([]*healthmonv1.ContainerStats, 0, len(stats.Containers)),
+	}
+	for _, c := range stats.Containers {
+		resp.Containers = append(resp.Containers, &healthmonv1.ContainerStats{
+			Name:          c.Name,
+			Score:         c.Score,
+			PreviousScore: c.PreviousScore,
+			Delta:         c.Delta,
+			Current:       toProtoStatsPeriod(c.Current),
+			Previous:      toProtoStatsPeriod(c.Previous),
+		})
+	}
+	return resp, nil
+}
+
+// Watch sends the updates of the watched containers until the client goes
+// away. A client that falls more than grpcWatchBuffer updates behind is
+// cut off with RESOURCE_EXHAUSTED and has to watch again.
+func (g *grpcService) Watch(req *healthmonv1.WatchRequest, stream grpc.ServerStreamingServer[healthmonv1.WatchResponse]) error {
+	w := &grpcWatcher{
+		containers: req.GetContainers(),
+		severities: req.GetSeverities(),
+		updates:    make(chan *healthmonv1.WatchResponse, grpcWatchBuffer),
+		slow:       make(chan struct{}),
+	}
+	g.mu.Lock()
+	g.watchers[w] = struct{}{}
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		delete(g.watchers, w)
+		g.mu.Unlock()
+	}()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-w.slow:
+			return status.Error(codes.ResourceExhausted, "client too slow, updates were dropped")
+		case update := <-w.updates:
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish queues an update for every watcher that wants it.
+func (g *grpcService) publish(_ context.Context, update EventUpdate) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.watchers) == 0 {
+		return
+	}
+	container := toProtoContainer(update.Container)
+	var event *healthmonv1.Event
+	if update.Event != nil {
+		event = toProtoEvent(update.Event)
+	}
+	var alert *healthmonv1.Alert
+	if update.Alert != nil {
+		alert = toProtoAlert(update.Alert)
+	}
+	for w := range g.watchers {
+		if len(w.containers) > 0 && !slices.Contains(w.containers, update.Container.Name) {
+			continue
+		}
+		msg := &healthmonv1.WatchResponse{Container: container}
+		if event != nil && w.wants(event.GetSeverity()) {
+			msg.Event = event
+		}
+		if alert != nil && w.wants(alert.GetSeverity()) {
+			msg.Alert = alert
+		}
+		select {
+		case w.updates <- msg:
+		default:
+			close(w.slow)
+			delete(g.watchers, w)
+		}
+	}
+}
+
+func (w *grpcWatcher) wants(severity string) bool {
+	return len(w.severities) == 0 || slices.Contains(w.severities, severity)
+}
+
+// grpcPage is the page after cursor in the listing order, 50 items long
+// unless limit says otherwise.
+func grpcPage(cursor int64, limit int32) page {
+	p := page{id: cursor, limit: defaultPageSize}
+	if limit > 0 {
+		p.limit = min(int(limit), maxPageSize)
+	}
+	return p
+}
+
+// toProtoTime parses a time of a REST response; empty and zero times are
+// left unset.
+func toProtoTime(value string) *timestamppb.Timestamp {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil || t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromProtoTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime().UTC()
+}
+
+func toProtoExitCode(code *int) *int32 {
+	if code == nil {
+		return nil
+	}
+	v := int32(*code)
+	return &v
+}
+
+func toProtoContainer(c ContainerResponse) *healthmonv1.Container {
+	return &healthmonv1.Container{
+		Id:                  c.ID,
+		Name:                c.Name,
+		DisplayName:         c.DisplayName,
+		Group:               c.Group,
+		ContainerId:         c.ContainerID,
+		Image:               c.Image,
+		ImageTag:            c.ImageTag,
+		ImageDigest:         c.ImageDigest,
+		ImageId:             c.ImageID,
+		Status:              c.Status,
+		HealthStatus:        c.HealthStatus,
+		Role:                c.Role,
+		Present:             c.Present,
+		CreatedAt:           toProtoTime(c.CreatedAt),
+		RegisteredAt:        toProtoTime(c.RegisteredAt),
+		StartedAt:           toProtoTime(c.StartedAt),
+		FinishedAt:          toProtoTime(c.FinishedAt),
+		RemovedAt:           toProtoTime(c.RemovedAt),
+		ExitCode:            toProtoExitCode(c.ExitCode),
+		Labels:              c.Labels,
+		HealthFailingStreak: int32(c.HealthFailingStreak),
+		UnhealthySince:      toProtoTime(c.UnhealthySince),
+		HealthPending:       c.HealthPending,
+		RestartLoop:         c.RestartLoop,
+		RestartStreak:       int32(c.RestartStreak),
+		RestartLoopSince:    toProtoTime(c.RestartLoopSince),
+		Platform:            c.Platform,
+		DependsOn:           c.DependsOn,
+		Networks:            c.Networks,
+		MemoryLimit:         c.MemoryLimit,
+		AlertCount:          c.AlertCount,
+	}
+}
+
+func toProtoEvent(e *EventResponse) *healthmonv1.Event {
+	return &healthmonv1.Event{
+		Id:          e.ID,
+		Container:   e.Container,
+		ContainerId: e.ContainerID,
+		Type:        e.Type,
+		Severity:    e.Severity,
+		Message:     e.Message,
+		Timestamp:   toProtoTime(e.Timestamp),
+		OldImage:    e.OldImage,
+		NewImage:    e.NewImage,
+		OldImageId:  e.OldImageID,
+		NewImageId:  e.NewImageID,
+		Reason:      e.Reason,
+		Details:     e.DetailsJSON,
+		ExitCode:    toProtoExitCode(e.ExitCode),
+	}
+}
+
+func toProtoAlert(a *AlertResponse) *healthmonv1.Alert {
+	return &healthmonv1.Alert{
+		Id:             a.ID,
+		Container:      a.Container,
+		ContainerId:    a.ContainerID,
+		Type:           a.Type,
+		Severity:       a.Severity,
+		Message:        a.Message,
+		Timestamp:      toProtoTime(a.Timestamp),
+		OldImage:       a.OldImage,
+		NewImage:       a.NewImage,
+		OldImageId:     a.OldImageID,
+		NewImageId:     a.NewImageID,
+		Reason:         a.Reason,
+		Details:        a.DetailsJSON,
+		ExitCode:       toProtoExitCode(a.ExitCode),
+		IncidentId:     a.IncidentID,
+		AcknowledgedAt: toProtoTime(a.AcknowledgedAt),
+	}
+}
+
+func toProtoStatsPeriod(p StatsPeriod) *healthmonv1.StatsPeriod {
+	return &healthmonv1.StatsPeriod{
+		Restarts:         p.Restarts,
+		Ooms:             p.OOMs,
+		UnhealthySeconds: p.UnhealthySeconds,
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+	healthmonv1 "healthmon/proto/healthmon/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCListsAndWatches(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", StartedAt: now}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	for i := range 3 {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: "c-web", Type: "restart", Severity: "blue", Message: "restart", Timestamp: now.Add(time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	server := NewServer(st, NewBroadcaster(), WSOptions{})
+	server.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"wiki": ScopeRead}})
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := server.GRPC()
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := healthmonv1.NewHealthmonServiceClient(conn)
+
+	if _, err := client.ListContainers(ctx, &healthmonv1.ListContainersRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected a call without token to be refused, got %v", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wiki")
+
+	containers, err := client.ListContainers(ctx, &healthmonv1.ListContainersRequest{})
+	if err != nil {
+		t.Fatalf("list containers: %v", err)
+	}
+	if len(containers.GetContainers()) != 1 || containers.GetContainers()[0].GetName() != "web" || containers.GetContainers()[0].GetStartedAt().AsTime().Unix() != now.Unix() {
+		t.Fatalf("unexpected containers %v", containers)
+	}
+
+	page, err := client.ListEvents(ctx, &healthmonv1.ListEventsRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(page.GetEvents()) != 2 || page.GetTotal() != 3 || page.GetNextCursor() == 0 {
+		t.Fatalf("unexpected first page %v", page)
+	}
+	page, err = client.ListEvents(ctx, &healthmonv1.ListEventsRequest{Limit: 2, Cursor: page.GetNextCursor()})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(page.GetEvents()) != 1 || page.GetNextCursor() != 0 {
+		t.Fatalf("unexpected last page %v", page)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.Watch(watchCtx, &healthmonv1.WatchRequest{Severities: []string{"red"}})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	// The stream is only registered once the server got the request, so
+	// keep sending until the first update comes through.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			server.Broadcast(ctx, EventUpdate{
+				Container: toContainerResponse(web),
+				Event:     &EventResponse{ID: 10, Container: "web", Severity: "blue"},
+				Alert:     &AlertResponse{ID: 11, Container: "web", Severity: "red"},
+			})
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if update.GetContainer().GetName() != "web" || update.GetEvent() != nil || update.GetAlert().GetId() != 11 {
+		t.Fatalf("expected the red alert without the blue event, got %v", update)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// defaultHealthWindow is the span of the health history without ?since=.
+const defaultHealthWindow = 24 * time.Hour
+
+type HealthTransitionResponse struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Timestamp string `json:"ts"`
+}
+
+// HealthSegmentResponse is a stretch of time a container spent in one
+// health status. Status is "unknown" before healthmon saw one and "" for
+// containers without a healthcheck.
+type HealthSegmentResponse struct {
+	Status  string `json:"status"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Seconds int64  `json:"seconds"`
+}
+
+type HealthHistoryResponse struct {
+	Container   string                     `json:"container"`
+	Since       string                     `json:"since"`
+	Until       string                     `json:"until"`
+	Transitions []HealthTransitionResponse `json:"transitions"`
+	Segments    []HealthSegmentResponse    `json:"segments"`
+	// UptimePercent is the share of the time with a healthy or unhealthy
+	// status that was healthy, or null when there was none.
+	UptimePercent *float64 `json:"uptime_percent"`
+}
+
+// handleHealthHistory serves GET /api/containers/{name}/health-history: the
+// health transitions of a container since ?since= (24 hours by default) and
+// the segments between them, ready to draw an uptime bar.
+func (s *Server) handleHealthHistory(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.store.GetContainer(name); !ok {
+		writeError(w, http.StatusNotFound, "container not found")
+		return
+	}
+	now := time.Now().UTC()
+	since, err := parseTimeParam(r.URL.Query().Get("since"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid since: "+err.Error())
+		return
+	}
+	if since.IsZero() {
+		since = now.Add(-defaultHealthWindow)
+	}
+	transitions, err := s.store.ListHealthTransitions(r.Context(), name, since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, buildHealthHistory(name, transitions, since, now))
+}
+
+func buildHealthHistory(name string, transitions []store.HealthTransition, since, until time.Time) HealthHistoryResponse {
+	resp := HealthHistoryResponse{
+		Container:   name,
+		Since:       formatMaybeTime(since),
+		Until:       formatMaybeTime(until),
+		Transitions: []HealthTransitionResponse{},
+		Segments:    []HealthSegmentResponse{},
+	}
+	status, start := "unknown", since
+	var healthy, tracked time.Duration
+	closeSegment := func(end time.Time) {
+		if !end.After(start) {
+			return
+		}
+		d := end.Sub(start)
+		resp.Segments = append(resp.Segments, HealthSegmentResponse{
+			Status:  status,
+			Start:   formatMaybeTime(start),
+			End:     formatMaybeTime(end),
+			Seconds: int64(d / time.Second),
+		})
+		switch status {
+		case "healthy":
+			healthy += d
+			tracked += d
+		case "unhealthy":
+			tracked += d
+		}
+	}
+	for _, t := range transitions {
+		if t.Timestamp.Before(since) {
+			status = t.To
+			continue
+		}
+		resp.Transitions = append(resp.Transitions, HealthTransitionResponse{From: t.From, To: t.To, Timestamp: formatMaybeTime(t.Timestamp)})
+		closeSegment(t.Timestamp)
+		status, start = t.To, t.Timestamp
+	}
+	closeSegment(until)
+	if tracked > 0 {
+		percent := float64(healthy) / float64(tracked) * 100
+		resp.UptimePercent = &percent
+	}
+	return resp
+}
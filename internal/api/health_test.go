@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestHealthHistorySegmentsTransitions(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	st.WithClock(clk)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	steps := []struct {
+		after  time.Duration
+		health string
+	}{
+		{0, "starting"},
+		{time.Minute, "healthy"},
+		{time.Hour, "healthy"}, // no change, no transition
+		{time.Hour, "unhealthy"},
+		{30 * time.Minute, "healthy"},
+	}
+	for _, step := range steps {
+		clk.Advance(step.after)
+		if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", HealthStatus: step.health}); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	// Closing flushes the background writes; reads keep working.
+	st.Close()
+
+	// A window starting while the container was healthy opens with that
+	// status.
+	since := start.Add(90 * time.Minute)
+	until := start.Add(3 * time.Hour)
+	transitions, err := st.ListHealthTransitions(ctx, "web", since)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	history := buildHealthHistory("web", transitions, since, until)
+	if len(history.Transitions) != 2 {
+		t.Fatalf("expected the two transitions in the window, got %+v", history.Transitions)
+	}
+	want := []HealthSegmentResponse{
+		{Status: "healthy", Start: "2026-05-01T01:30:00Z", End: "2026-05-01T02:01:00Z", Seconds: 31 * 60},
+		{Status: "unhealthy", Start: "2026-05-01T02:01:00Z", End: "2026-05-01T02:31:00Z", Seconds: 30 * 60},
+		{Status: "healthy", Start: "2026-05-01T02:31:00Z", End: "2026-05-01T03:00:00Z", Seconds: 29 * 60},
+	}
+	if len(history.Segments) != len(want) {
+		t.Fatalf("expected %d segments, got %+v", len(want), history.Segments)
+	}
+	for i := range want {
+		if history.Segments[i] != want[i] {
+			t.Fatalf("segment %d: expected %+v, got %+v", i, want[i], history.Segments[i])
+		}
+	}
+	if history.UptimePercent == nil || *history.UptimePercent < 66.6 || *history.UptimePercent > 66.7 {
+		t.Fatalf("expected 60 of 90 minutes healthy, got %v", history.UptimePercent)
+	}
+
+	// Before the first status healthmon saw, the status is unknown.
+	transitions, err = st.ListHealthTransitions(ctx, "web", start.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	history = buildHealthHistory("web", transitions, start.Add(-time.Hour), until)
+	if len(history.Transitions) != 4 || history.Transitions[0].From != "" || history.Segments[0].Status != "unknown" {
+		t.Fatalf("expected the history to open unknown, got %+v", history)
+	}
+}
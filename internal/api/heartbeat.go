@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+type HeartbeatResponse struct {
+	Name       string `json:"name"`
+	Interval   string `json:"interval"`
+	LastSeenAt string `json:"last_seen_at"`
+	DueAt      string `json:"due_at"`
+}
+
+// handleHeartbeat serves POST /api/heartbeat/{name}. Jobs call it when they
+// run; ?interval= sets how often they are expected to.
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/heartbeat/")
+	if !clientIDPattern.MatchString(name) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var interval time.Duration
+	if v := r.URL.Query().Get("interval"); v != "" {
+		var err error
+		if interval, err = parseDurationParam(v); err != nil || interval < time.Minute {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid interval %q, expected a duration of at least 1m", v))
+			return
+		}
+	}
+
+	c, err := s.store.RecordHeartbeat(r.Context(), name, interval)
+	if errors.Is(err, store.ErrNotHeartbeat) {
+		writeError(w, http.StatusConflict, fmt.Sprintf("%s is a container, not a heartbeat", name))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.Broadcast(r.Context(), EventUpdate{Container: toContainerResponse(c)})
+
+	writeJSON(w, http.StatusOK, HeartbeatResponse{
+		Name:       c.Name,
+		Interval:   c.TaskMaxAge.String(),
+		LastSeenAt: formatMaybeTime(c.LastSuccessAt),
+		DueAt:      formatMaybeTime(c.LastSuccessAt.Add(c.TaskMaxAge)),
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestHeartbeatCreatesPseudoContainer(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", StartedAt: time.Now()}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	post := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		return rec
+	}
+
+	rec := post("/api/heartbeat/nightly-backup?interval=1d")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("heartbeat: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp HeartbeatResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Interval != "24h0m0s" {
+		t.Fatalf("expected a 24h interval, got %q", resp.Interval)
+	}
+	if rec := post("/api/heartbeat/nightly-backup"); rec.Code != http.StatusOK {
+		t.Fatalf("second heartbeat: status %d", rec.Code)
+	}
+	c, ok := st.GetContainer("nightly-backup")
+	if !ok || c.Role != "heartbeat" || c.TaskMaxAge != 24*time.Hour {
+		t.Fatalf("expected a heartbeat keeping its interval, got %+v", c)
+	}
+
+	if rec := post("/api/heartbeat/web"); rec.Code != http.StatusConflict {
+		t.Fatalf("expected a conflict for a container name, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+func toHookRunResponse(h store.AlertHookRun) HookRunResponse {
+	return HookRunResponse{
+		ID:         h.ID,
+		AlertID:    h.AlertID,
+		Target:     h.Target,
+		Command:    h.Command,
+		ExitCode:   h.ExitCode,
+		Output:     h.Output,
+		Error:      h.Error,
+		StartedAt:  h.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		DurationMS: h.DurationMS,
+	}
+}
+
+// attachHookRuns fills in the hook results of a page of alerts.
+func (s *Server) attachHookRuns(ctx context.Context, alerts []AlertResponse) error {
+	ids := make([]int64, 0, len(alerts))
+	index := make(map[int64]int, len(alerts))
+	for i, a := range alerts {
+		ids = append(ids, a.ID)
+		index[a.ID] = i
+	}
+	runs, err := s.store.ListAlertHookRuns(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, h := range runs {
+		i := index[h.AlertID]
+		alerts[i].Hooks = append(alerts[i].Hooks, toHookRunResponse(h))
+	}
+	return nil
+}
+
+// handleAlertHooks serves GET /api/alerts/{id}/hooks.
+func (s *Server) handleAlertHooks(w http.ResponseWriter, r *http.Request) {
+	idPart, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/hooks")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if _, found, err := s.store.GetAlert(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	} else if !found {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	runs, err := s.store.ListAlertHookRuns(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]HookRunResponse, 0, len(runs))
+	for _, h := range runs {
+		resp = append(resp, toHookRunResponse(h))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAlertByID serves GET /api/alerts/{id}: the alert with its comments
+// and the results of its hooks.
+func (s *Server) handleAlertByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	alert, found, err := s.store.GetAlert(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	resp := []AlertResponse{*toAlertResponse(alert)}
+	if err := s.attachComments(r.Context(), resp); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := s.attachHookRuns(r.Context(), resp); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp[0])
+}
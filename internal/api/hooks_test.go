@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestAlertShowsItsHookRuns(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", Present: true}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", Type: "oom_killed", Severity: "red", Message: "Killed by the OOM killer", Timestamp: now})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+	if _, err := st.AddAlertHookRun(ctx, store.AlertHookRun{AlertID: alertID, Target: "healthmon", Command: "/scripts/dump.sh", ExitCode: 3, Output: "…heap dumped", Error: "exit code 3", StartedAt: now, DurationMS: 120}); err != nil {
+		t.Fatalf("add hook run: %v", err)
+	}
+
+	routes := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+	path := "/api/alerts/" + strconv.FormatInt(alertID, 10)
+	rec := serve(http.MethodGet, path)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var alert AlertResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &alert); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if alert.ID != alertID || alert.Type != "oom_killed" || len(alert.Hooks) != 1 {
+		t.Fatalf("unexpected alert %+v", alert)
+	}
+	if h := alert.Hooks[0]; h.ExitCode != 3 || h.Output != "…heap dumped" || h.Error != "exit code 3" || h.Command != "/scripts/dump.sh" {
+		t.Fatalf("unexpected hook run %+v", h)
+	}
+
+	if rec := serve(http.MethodGet, "/api/alerts/999"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown alert, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodDelete, path); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// DanglingImage is an untagged image no container uses.
+type DanglingImage struct {
+	ID        string `json:"id"`
+	SizeBytes int64  `json:"size_bytes"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// ReplacedImage is an image a container was recreated away from. Removed
+// images were cleaned up since; SizeBytes is their size when they were
+// replaced, or 0 when healthmon could not read it.
+type ReplacedImage struct {
+	ID         string `json:"id"`
+	Image      string `json:"image"`
+	Container  string `json:"container"`
+	ReplacedAt string `json:"replaced_at"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Removed    bool   `json:"removed"`
+}
+
+// ImageReport is served by GET /api/reports/images.
+type ImageReport struct {
+	GeneratedAt   string          `json:"generated_at"`
+	Dangling      []DanglingImage `json:"dangling"`
+	DanglingBytes int64           `json:"dangling_bytes"`
+	Replaced      []ReplacedImage `json:"replaced"`
+	// FreedBytes adds up the replaced images that were removed, and
+	// ReclaimableBytes those still on disk.
+	FreedBytes       int64 `json:"freed_bytes"`
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+	PruneEnabled     bool  `json:"prune_enabled"`
+}
+
+// ImagePruneResponse is the result of POST /api/reports/images/prune.
+type ImagePruneResponse struct {
+	Deleted        []string `json:"deleted"`
+	SpaceReclaimed int64    `json:"space_reclaimed_bytes"`
+}
+
+// ImageReporter reads the image report from Docker and prunes dangling
+// images.
+type ImageReporter interface {
+	ImageReport(ctx context.Context) (ImageReport, error)
+	PruneImages(ctx context.Context) (ImagePruneResponse, error)
+}
+
+// WithImages enables GET /api/reports/images, and with prune POST
+// /api/reports/images/prune.
+func (s *Server) WithImages(r ImageReporter, prune bool) {
+	s.images = r
+	s.imagePrune = prune
+}
+
+// handleImageReport serves GET /api/reports/images.
+func (s *Server) handleImageReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.images == nil {
+		writeError(w, http.StatusNotImplemented, "image reports are not available")
+		return
+	}
+	report, err := s.images.ImageReport(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	report.PruneEnabled = s.imagePrune
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleImagePrune serves POST /api/reports/images/prune. It only removes
+// dangling images, and only when HM_IMAGE_PRUNE allows it; being a POST it
+// needs an admin token.
+func (s *Server) handleImagePrune(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.images == nil || !s.imagePrune {
+		writeError(w, http.StatusForbidden, "image pruning is disabled, set HM_IMAGE_PRUNE=true to allow it")
+		return
+	}
+	result, err := s.images.PruneImages(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
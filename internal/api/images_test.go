@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubImages struct {
+	pruned int
+}
+
+func (s *stubImages) ImageReport(context.Context) (ImageReport, error) {
+	return ImageReport{Dangling: []DanglingImage{{ID: "sha256:orphan", SizeBytes: 42}}, DanglingBytes: 42, Replaced: []ReplacedImage{}}, nil
+}
+
+func (s *stubImages) PruneImages(context.Context) (ImagePruneResponse, error) {
+	s.pruned++
+	return ImagePruneResponse{Deleted: []string{"sha256:orphan"}, SpaceReclaimed: 42}, nil
+}
+
+func TestImagePruneIsGuarded(t *testing.T) {
+	images := &stubImages{}
+	server := NewServer(nil, NewBroadcaster(), WSOptions{})
+	server.WithImages(images, false)
+	routes := server.Routes()
+
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/reports/images", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report ImageReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if report.DanglingBytes != 42 || report.PruneEnabled {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	rec = httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/reports/images/prune", nil))
+	if rec.Code != http.StatusForbidden || images.pruned != 0 {
+		t.Fatalf("expected pruning to be refused, got %d after %d prunes", rec.Code, images.pruned)
+	}
+
+	server.WithImages(images, true)
+	rec = httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/reports/images/prune", nil))
+	if rec.Code != http.StatusOK || images.pruned != 1 {
+		t.Fatalf("expected one prune, got %d after %d prunes: %s", rec.Code, images.pruned, rec.Body.String())
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// IncidentListResponse is served by GET /api/incidents.
+type IncidentListResponse struct {
+	Items []IncidentResponse `json:"items"`
+	PageInfo
+}
+
+// handleIncidents serves GET /api/incidents, newest first. container
+// (repeatable) and since, a time or a duration back from now, narrow the
+// listing; open=true keeps the incidents still going.
+func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	p, err := parsePage(q, 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if p.backward {
+		writeError(w, http.StatusBadRequest, "incidents can only be paged forward")
+		return
+	}
+	f := store.IncidentFilter{Containers: multiParam(q, "container"), Open: q.Get("open") == "true"}
+	if f.Since, err = parseTimeParam(q.Get("since"), time.Now().UTC()); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid since: "+err.Error())
+		return
+	}
+	items, err := s.store.ListIncidents(r.Context(), f, p.id, p.limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	items, info := pageOf(p, items, func(inc store.Incident) int64 { return inc.ID })
+	resp := IncidentListResponse{Items: make([]IncidentResponse, 0, len(items)), PageInfo: info}
+	for _, inc := range items {
+		resp.Items = append(resp.Items, toIncidentResponse(inc))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleIncident serves GET /api/incidents/{id} and
+// /api/incidents/{id}/comments, and hands /api/incidents/{id}/bundle on.
+func (s *Server) handleIncident(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/incidents/")
+	if strings.HasSuffix(path, "/bundle") {
+		s.handleIncidentBundle(w, r)
+		return
+	}
+	path, comments := strings.CutSuffix(path, "/comments")
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	inc, found, err := s.store.GetIncident(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "incident not found")
+		return
+	}
+	if comments {
+		s.handleIncidentComments(w, r, inc)
+		return
+	}
+	writeJSON(w, http.StatusOK, toIncidentResponse(inc))
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestIncidentsListing(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	for _, name := range []string{"web", "db"} {
+		if err := st.UpsertContainer(ctx, store.Container{Name: name, ContainerID: "c-" + name, Status: "running", Present: true}); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	web, _ := st.GetContainer("web")
+	database, _ := st.GetContainer("db")
+	opened := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	resolved, err := st.AddIncident(ctx, store.Incident{ContainerPK: web.ID, Container: "web", Type: "unhealthy", Severity: "red", Message: "Container became unhealthy", OpenedAt: opened})
+	if err != nil {
+		t.Fatalf("add incident: %v", err)
+	}
+	alertID, err := st.AddAlert(ctx, store.Alert{ContainerPK: web.ID, Container: "web", Type: "unhealthy", Severity: "red", Message: "Container became unhealthy", Timestamp: opened})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+	if err := st.LinkAlertToIncident(ctx, alertID, resolved); err != nil {
+		t.Fatalf("link alert: %v", err)
+	}
+	if err := st.ResolveIncident(ctx, resolved, opened.Add(90*time.Second)); err != nil {
+		t.Fatalf("resolve incident: %v", err)
+	}
+	if _, err := st.AddIncident(ctx, store.Incident{ContainerPK: database.ID, Container: "db", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", OpenedAt: opened}); err != nil {
+		t.Fatalf("add incident: %v", err)
+	}
+
+	routes := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	list := func(query string) IncidentListResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/incidents"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp IncidentListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := list(""); len(resp.Items) != 2 || resp.Items[0].Container != "db" {
+		t.Fatalf("expected both incidents newest first, got %+v", resp.Items)
+	} else if len(resp.Items[0].AlertIDs) != 0 || len(resp.Items[1].AlertIDs) != 1 || resp.Items[1].AlertIDs[0] != alertID {
+		t.Fatalf("expected the alert IDs of each incident, got %+v", resp.Items)
+	}
+	if resp := list("?open=true"); len(resp.Items) != 1 || resp.Items[0].Container != "db" {
+		t.Fatalf("expected only the open incident, got %+v", resp.Items)
+	}
+	resp := list("?container=web&limit=1")
+	if len(resp.Items) != 1 || resp.HasMore {
+		t.Fatalf("expected the web incident alone, got %+v", resp)
+	}
+	if inc := resp.Items[0]; inc.DurationSeconds != 90 || len(inc.AlertIDs) != 1 || inc.AlertIDs[0] != alertID {
+		t.Fatalf("unexpected incident %+v", inc)
+	}
+
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/incidents/"+strconv.FormatInt(resolved, 10), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for one incident, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestContainersFilterByLabel(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	for _, c := range []store.Container{
+		{Name: "jellyfin", Status: "running", Labels: map[string]string{"healthmon.group": "media", "healthmon.display_name": "Jellyfin", "tier": "a,b"}},
+		{Name: "sonarr", Status: "running", Labels: map[string]string{"healthmon.group": "media"}},
+		{Name: "traefik", Status: "running", Labels: map[string]string{"healthmon.group": "infra"}},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	list := func(query string) []ContainerResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/containers"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var resp []ContainerResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if got := list("?label=healthmon.group=media"); len(got) != 2 {
+		t.Fatalf("expected 2 media containers, got %+v", got)
+	}
+	got := list("?label=healthmon.group=media&label=tier=a,b")
+	if len(got) != 1 || got[0].Name != "jellyfin" || got[0].DisplayName != "Jellyfin" || got[0].Group != "media" || got[0].Labels["tier"] != "a,b" {
+		t.Fatalf("expected jellyfin with its labels, got %+v", got)
+	}
+	if got := list("?label=tier"); len(got) != 1 {
+		t.Fatalf("expected a bare key to match containers with the label, got %+v", got)
+	}
+	if got := list(""); len(got) != 3 {
+		t.Fatalf("expected all containers without a selector, got %d", len(got))
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// isAlertLogsPath reports whether path is /api/alerts/{id}/logs.
+func isAlertLogsPath(path string) bool {
+	rest, ok := strings.CutPrefix(path, "/api/alerts/")
+	return ok && strings.HasSuffix(rest, "/logs")
+}
+
+// handleAlertLogs serves GET /api/alerts/{id}/logs, the log tail taken for
+// a restart_loop alert. Logs can hold secrets, so they stay out of the alert
+// itself and read tokens are refused here.
+func (s *Server) handleAlertLogs(w http.ResponseWriter, r *http.Request) {
+	idPart, _ := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/logs")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	logs, found, err := s.store.GetAlertLogs(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "no logs for this alert")
+		return
+	}
+	writeJSON(w, http.StatusOK, AlertLogsResponse{AlertID: id, Logs: logs})
+}
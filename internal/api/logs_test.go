@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestAlertLogsAreOnlyServedToAdmins(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "worker", ContainerID: "c-worker", Status: "restarting", Present: true}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	worker, _ := st.GetContainer("worker")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	alertID, err := st.AddAlert(ctx, store.Alert{
+		ContainerPK: worker.ID,
+		Container:   "worker",
+		Type:        "restart_loop",
+		Severity:    "red",
+		Message:     "Restart loop detected",
+		Timestamp:   now,
+		DetailsJSON: store.EncodeDetails(store.RestartDetails{RestartCount: 3, ExitCodes: []int{1, 1, 1}}),
+		Logs:        "connecting with password=hunter2\npanic: auth failed",
+	})
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"wiki": ScopeRead, "secret": ScopeAdmin}})
+	routes := srv.Routes()
+	serve := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+	path := "/api/alerts/" + strconv.FormatInt(alertID, 10)
+	for _, target := range []string{path, "/api/alerts"} {
+		rec := serve(target, "wiki")
+		if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "hunter2") || !strings.Contains(rec.Body.String(), "exit_codes") {
+			t.Fatalf("expected %s to leave the logs out, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serve(path+"/logs", "wiki"); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected the logs to be refused to a read token, got %d", rec.Code)
+	}
+	rec := serve(path+"/logs", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var logs AlertLogsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &logs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if logs.AlertID != alertID || logs.Logs != "connecting with password=hunter2\npanic: auth failed" {
+		t.Fatalf("unexpected logs %+v", logs)
+	}
+	if rec := serve("/api/alerts/999/logs", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an alert without logs, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,31 @@
+package api
+
+import "net/http"
+
+type MaintenanceResponse struct {
+	Status string `json:"status"`
+}
+
+// WithMaintenance enables POST /api/admin/db/maintenance. request queues a
+// run and returns false when one is already queued or running.
+func (s *Server) WithMaintenance(request func() bool) {
+	s.maintenance = request
+}
+
+// handleMaintenance starts database maintenance in the background; progress
+// and the result arrive as db_maintenance events on _healthmon.
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.maintenance == nil {
+		writeError(w, http.StatusNotImplemented, "database maintenance is not configured")
+		return
+	}
+	if !s.maintenance() {
+		writeError(w, http.StatusConflict, "database maintenance is already running")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, MaintenanceResponse{Status: "started"})
+}
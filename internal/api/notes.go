@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+const (
+	maxNotesLength = 4096
+	maxOwnerLength = 200
+)
+
+// NotesRequest is the body of PUT /api/containers/{name}/notes.
+type NotesRequest struct {
+	Notes      string `json:"notes"`
+	Owner      string `json:"owner"`
+	RunbookURL string `json:"runbook_url"`
+}
+
+func toNotesResponse(n store.ContainerNotes) NotesResponse {
+	return NotesResponse{
+		Container:  n.Container,
+		Notes:      n.Notes,
+		Owner:      n.Owner,
+		RunbookURL: n.RunbookURL,
+		UpdatedAt:  formatMaybeTime(n.UpdatedAt),
+		UpdatedBy:  n.UpdatedBy,
+	}
+}
+
+// containerNotes returns the notes of a container for a response, or nil.
+func (s *Server) containerNotes(name string) *NotesResponse {
+	n, ok := s.store.ContainerNotes(name)
+	if !ok {
+		return nil
+	}
+	resp := toNotesResponse(n)
+	return &resp
+}
+
+// handleNotes serves GET, PUT and DELETE /api/containers/{name}/notes.
+// Notes can be set for any container healthmon knows, removed ones
+// included.
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.store.GetContainer(name); !ok {
+		writeError(w, http.StatusNotFound, "container not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		n, ok := s.store.ContainerNotes(name)
+		if !ok {
+			n = store.ContainerNotes{Container: name}
+		}
+		writeJSON(w, http.StatusOK, toNotesResponse(n))
+	case http.MethodPut:
+		var req NotesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		n := store.ContainerNotes{
+			Container:  name,
+			Notes:      strings.TrimSpace(req.Notes),
+			Owner:      strings.TrimSpace(req.Owner),
+			RunbookURL: strings.TrimSpace(req.RunbookURL),
+			UpdatedBy:  requestActor(r),
+		}
+		if len(n.Notes) > maxNotesLength || len(n.Owner) > maxOwnerLength {
+			writeError(w, http.StatusBadRequest, "notes or owner too long")
+			return
+		}
+		if n.RunbookURL != "" {
+			if u, err := url.Parse(n.RunbookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				writeError(w, http.StatusBadRequest, "runbook_url must be an http or https URL")
+				return
+			}
+		}
+		saved, err := s.store.SetContainerNotes(r.Context(), n)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if saved.Container == "" {
+			saved.Container = name
+		}
+		writeJSON(w, http.StatusOK, toNotesResponse(saved))
+	case http.MethodDelete:
+		if _, err := s.store.DeleteContainerNotes(r.Context(), name); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
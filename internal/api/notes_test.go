@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestContainerNotesSurviveReload(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running", Present: true}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	serve := func(st *store.Store, method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		NewServer(st, NewBroadcaster(), WSOptions{}).Routes().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := serve(st, http.MethodPut, "/api/containers/web/notes", `{"runbook_url":"javascript:alert(1)"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a bad runbook URL to be refused, got %d", rec.Code)
+	}
+	if rec := serve(st, http.MethodPut, "/api/containers/ghost/notes", `{"owner":"nobody"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown container, got %d", rec.Code)
+	}
+	rec := serve(st, http.MethodPut, "/api/containers/web/notes", `{"notes":" Serves the shop ","owner":"team-shop","runbook_url":"https://wiki.example/web"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	st.Close()
+
+	// A fresh store reads the notes back from the database.
+	st = store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("reload store: %v", err)
+	}
+	rec = serve(st, http.MethodGet, "/api/containers", "")
+	var containers []ContainerResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &containers); err != nil {
+		t.Fatalf("decode containers: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Notes == nil {
+		t.Fatalf("expected notes on the container, got %+v", containers)
+	}
+	if n := containers[0].Notes; n.Notes != "Serves the shop" || n.Owner != "team-shop" || n.RunbookURL != "https://wiki.example/web" || n.UpdatedBy != "anonymous" {
+		t.Fatalf("unexpected notes %+v", n)
+	}
+
+	if rec := serve(st, http.MethodDelete, "/api/containers/web/notes", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	rec = serve(st, http.MethodGet, "/api/containers/web/notes", "")
+	var notes NotesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &notes); err != nil {
+		t.Fatalf("decode notes: %v", err)
+	}
+	if notes.Container != "web" || notes.Owner != "" || notes.UpdatedAt != "" {
+		t.Fatalf("expected empty notes after delete, got %+v", notes)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"healthmon/internal/store"
+)
+
+type NotificationResponse struct {
+	ID            int64  `json:"id"`
+	AlertID       int64  `json:"alert_id"`
+	Container     string `json:"container"`
+	AlertType     string `json:"alert_type"`
+	Notifier      string `json:"notifier"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	Error         string `json:"error,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	NextAttemptAt string `json:"next_attempt_at,omitempty"`
+}
+
+type NotificationListResponse struct {
+	Items []NotificationResponse `json:"items"`
+}
+
+// handleNotifications serves GET /api/notifications, the delivery status of
+// alerts per notifier, newest first. It filters by alert_id, container,
+// notifier and status and pages with before_id and limit.
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	alertID, _ := strconv.ParseInt(q.Get("alert_id"), 10, 64)
+	beforeID, _ := strconv.ParseInt(q.Get("before_id"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	filter := store.NotificationFilter{
+		AlertID:   alertID,
+		Container: q.Get("container"),
+		Notifier:  q.Get("notifier"),
+		Statuses:  multiParam(q, "status"),
+	}
+	items, err := s.store.ListNotifications(r.Context(), filter, beforeID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := NotificationListResponse{Items: make([]NotificationResponse, 0, len(items))}
+	for _, n := range items {
+		resp.Items = append(resp.Items, NotificationResponse{
+			ID:            n.ID,
+			AlertID:       n.AlertID,
+			Container:     n.Container,
+			AlertType:     n.AlertType,
+			Notifier:      n.Notifier,
+			Status:        n.Status,
+			Attempts:      n.Attempts,
+			Error:         n.Error,
+			CreatedAt:     formatMaybeTime(n.CreatedAt),
+			UpdatedAt:     formatMaybeTime(n.UpdatedAt),
+			NextAttemptAt: formatMaybeTime(n.NextAttemptAt),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
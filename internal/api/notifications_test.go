@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestNotificationsListDeliveryStatus(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, n := range []store.Notification{
+		{AlertID: 1, Container: "web", AlertType: "restart_loop", Notifier: "Telegram", Status: store.NotificationSent, Attempts: 1},
+		{AlertID: 1, Container: "web", AlertType: "restart_loop", Notifier: "Apprise", Status: store.NotificationRetrying, Attempts: 1, Error: "apprise status 502 Bad Gateway", NextAttemptAt: now.Add(time.Minute)},
+		{AlertID: 2, Container: "db", AlertType: "oom_killed", Notifier: "Telegram", Status: store.NotificationSkipped, Attempts: 1},
+	} {
+		n.CreatedAt, n.UpdatedAt = now, now
+		if _, err := st.AddNotification(ctx, n); err != nil {
+			t.Fatalf("add notification: %v", err)
+		}
+	}
+	if err := st.UpdateNotification(ctx, store.Notification{ID: 2, Status: store.NotificationSent, Attempts: 2, UpdatedAt: now.Add(time.Minute)}); err != nil {
+		t.Fatalf("update notification: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	list := func(target string) NotificationListResponse {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var resp NotificationListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	all := list("/api/notifications")
+	if len(all.Items) != 3 || all.Items[0].Notifier != "Telegram" || all.Items[0].Status != "skipped" {
+		t.Fatalf("expected all deliveries newest first, got %+v", all.Items)
+	}
+	retried := all.Items[1]
+	if retried.Status != "sent" || retried.Attempts != 2 || retried.Error != "" || retried.NextAttemptAt != "" || retried.UpdatedAt != "2026-03-01T12:01:00Z" {
+		t.Fatalf("expected the retried delivery to be sent, got %+v", retried)
+	}
+	if got := list("/api/notifications?alert_id=1&notifier=Telegram"); len(got.Items) != 1 || got.Items[0].Status != "sent" {
+		t.Fatalf("expected one Telegram delivery of alert 1, got %+v", got.Items)
+	}
+	if got := list("/api/notifications?status=skipped,failed"); len(got.Items) != 1 || got.Items[0].Container != "db" {
+		t.Fatalf("expected the skipped delivery, got %+v", got.Items)
+	}
+}
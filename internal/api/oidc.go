@@ -0,0 +1,423 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	oidcPathPrefix    = "/auth/"
+	oidcLoginPath     = "/auth/login"
+	oidcCallbackPath  = "/auth/callback"
+	oidcLogoutPath    = "/auth/logout"
+	sessionCookieName = "hm_session"
+	stateCookieName   = "hm_oidc_state"
+	// loginTimeout is how long a user has to finish logging in at the
+	// provider.
+	loginTimeout = 10 * time.Minute
+)
+
+// OIDCOptions configures the login with an OpenID Connect provider.
+type OIDCOptions struct {
+	// Issuer is the provider's issuer URL; its discovery document is read
+	// from Issuer/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL of /auth/callback as the browser
+	// reaches it, registered with the provider.
+	RedirectURL string
+	Scopes      []string
+	// UserClaim and GroupsClaim name the ID token claims holding the user
+	// name and the user's groups.
+	UserClaim   string
+	GroupsClaim string
+	// SessionSecret signs the session cookies. Without one a random secret
+	// is used, and users have to log in again after a restart.
+	SessionSecret []byte
+	SessionTTL    time.Duration
+}
+
+// OIDC logs browser users in with the authorization code flow and keeps
+// them logged in with a signed session cookie. The ID token comes straight
+// from the provider's token endpoint over TLS, which OpenID Connect Core
+// 3.1.3.7 accepts in place of checking its signature; its issuer, audience,
+// expiry and nonce are still checked.
+type OIDC struct {
+	opts   OIDCOptions
+	client *http.Client
+	now    func() time.Time
+
+	mu       sync.Mutex
+	provider *oidcProvider
+}
+
+// oidcProvider is the part of the discovery document healthmon uses.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// oidcSession is the content of the session cookie.
+type oidcSession struct {
+	User    string   `json:"u"`
+	Groups  []string `json:"g,omitempty"`
+	Expires int64    `json:"exp"`
+}
+
+// oidcLogin is the content of the state cookie that carries a login from
+// the redirect to the provider to the callback.
+type oidcLogin struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+	Expires  int64  `json:"exp"`
+}
+
+// NewOIDC checks opts and fills in defaults. The provider is only contacted
+// on the first login, so healthmon starts while it is down.
+func NewOIDC(opts OIDCOptions) (*OIDC, error) {
+	if opts.Issuer == "" || opts.ClientID == "" || opts.RedirectURL == "" {
+		return nil, errors.New("OIDC needs an issuer, a client id and a redirect URL")
+	}
+	redirect, err := url.Parse(opts.RedirectURL)
+	if err != nil || !redirect.IsAbs() || redirect.Path != oidcCallbackPath {
+		return nil, fmt.Errorf("invalid OIDC redirect URL %q, expected an absolute URL ending in %s", opts.RedirectURL, oidcCallbackPath)
+	}
+	if len(opts.Scopes) == 0 {
+		opts.Scopes = []string{"openid", "profile", "email", "groups"}
+	}
+	if opts.UserClaim == "" {
+		opts.UserClaim = "preferred_username"
+	}
+	if opts.GroupsClaim == "" {
+		opts.GroupsClaim = "groups"
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 12 * time.Hour
+	}
+	if len(opts.SessionSecret) == 0 {
+		opts.SessionSecret = make([]byte, 32)
+		if _, err := rand.Read(opts.SessionSecret); err != nil {
+			return nil, err
+		}
+	}
+	return &OIDC{opts: opts, client: &http.Client{Timeout: 10 * time.Second}, now: time.Now}, nil
+}
+
+func (o *OIDC) register(mux *http.ServeMux) {
+	mux.HandleFunc(oidcLoginPath, o.handleLogin)
+	mux.HandleFunc(oidcCallbackPath, o.handleCallback)
+	mux.HandleFunc(oidcLogoutPath, o.handleLogout)
+}
+
+// session returns the user of a valid session cookie.
+func (o *OIDC) session(r *http.Request) (string, []string, bool) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return "", nil, false
+	}
+	var sess oidcSession
+	if !o.verify(cookie.Value, &sess) || o.now().Unix() >= sess.Expires || sess.User == "" {
+		return "", nil, false
+	}
+	return sess.User, sess.Groups, true
+}
+
+// redirectToLogin sends a browser without a session to the login, which
+// brings it back to the page it asked for.
+func (o *OIDC) redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, oidcLoginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+}
+
+func (o *OIDC) handleLogin(w http.ResponseWriter, r *http.Request) {
+	provider, err := o.discover(r.Context())
+	if err != nil {
+		log.Printf("oidc discovery failed: %v", err)
+		writeError(w, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+	login := oidcLogin{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		Next:     localPath(r.URL.Query().Get("next")),
+		Expires:  o.now().Add(loginTimeout).Unix(),
+	}
+	o.setCookie(w, stateCookieName, oidcPathPrefix, o.sign(login), loginTimeout)
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.opts.ClientID},
+		"redirect_uri":          {o.opts.RedirectURL},
+		"scope":                 {strings.Join(o.opts.Scopes, " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	http.Redirect(w, r, withQuery(provider.AuthorizationEndpoint, q), http.StatusFound)
+}
+
+func (o *OIDC) handleCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if msg := q.Get("error"); msg != "" {
+		writeError(w, http.StatusUnauthorized, "login failed: "+msg)
+		return
+	}
+	var login oidcLogin
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil || !o.verify(cookie.Value, &login) || o.now().Unix() >= login.Expires ||
+		!hmac.Equal([]byte(login.State), []byte(q.Get("state"))) {
+		writeError(w, http.StatusBadRequest, "login expired or invalid, try again")
+		return
+	}
+	o.setCookie(w, stateCookieName, oidcPathPrefix, "", -1)
+
+	provider, err := o.discover(r.Context())
+	if err != nil {
+		log.Printf("oidc discovery failed: %v", err)
+		writeError(w, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+	claims, err := o.exchange(r.Context(), provider, q.Get("code"), login.Verifier)
+	if err != nil {
+		log.Printf("oidc login failed: %v", err)
+		writeError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+	sess, err := o.sessionFromClaims(provider, claims, login.Nonce)
+	if err != nil {
+		log.Printf("oidc login failed: %v", err)
+		writeError(w, http.StatusUnauthorized, "login failed")
+		return
+	}
+	o.setCookie(w, sessionCookieName, "/", o.sign(sess), o.opts.SessionTTL)
+	http.Redirect(w, r, login.Next, http.StatusFound)
+}
+
+func (o *OIDC) handleLogout(w http.ResponseWriter, r *http.Request) {
+	o.setCookie(w, sessionCookieName, "/", "", -1)
+	target := "/"
+	if provider, err := o.discover(r.Context()); err == nil && provider.EndSessionEndpoint != "" {
+		redirect, _ := url.Parse(o.opts.RedirectURL)
+		target = withQuery(provider.EndSessionEndpoint, url.Values{
+			"client_id":                {o.opts.ClientID},
+			"post_logout_redirect_uri": {redirect.Scheme + "://" + redirect.Host + "/"},
+		})
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// discover reads the provider's discovery document once it is needed and
+// keeps it.
+func (o *OIDC) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.provider != nil {
+		return o.provider, nil
+	}
+	issuer := strings.TrimSuffix(o.opts.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var provider oidcProvider
+	if err := o.doJSON(req, &provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, expected %q", provider.Issuer, o.opts.Issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" {
+		return nil, errors.New("discovery document lacks the authorization or token endpoint")
+	}
+	o.provider = &provider
+	return o.provider, nil
+}
+
+// exchange trades the authorization code for tokens and returns the claims
+// of the ID token.
+func (o *OIDC) exchange(ctx context.Context, provider *oidcProvider, code, verifier string) (map[string]any, error) {
+	if code == "" {
+		return nil, errors.New("callback without a code")
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.opts.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(o.opts.ClientID), url.QueryEscape(o.opts.ClientSecret))
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := o.doJSON(req, &tokens); err != nil {
+		return nil, err
+	}
+	parts := strings.Split(tokens.IDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token response without an ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode ID token: %w", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("decode ID token: %w", err)
+	}
+	return claims, nil
+}
+
+func (o *OIDC) sessionFromClaims(provider *oidcProvider, claims map[string]any, nonce string) (oidcSession, error) {
+	if iss, _ := claims["iss"].(string); iss != provider.Issuer {
+		return oidcSession{}, fmt.Errorf("ID token issued by %q, expected %q", iss, provider.Issuer)
+	}
+	if !claimContains(claims["aud"], o.opts.ClientID) {
+		return oidcSession{}, fmt.Errorf("ID token is not for client %q", o.opts.ClientID)
+	}
+	if exp, _ := claims["exp"].(float64); int64(exp) <= o.now().Unix() {
+		return oidcSession{}, errors.New("ID token expired")
+	}
+	if got, _ := claims["nonce"].(string); !hmac.Equal([]byte(got), []byte(nonce)) {
+		return oidcSession{}, errors.New("ID token nonce does not match")
+	}
+	user, _ := claims[o.opts.UserClaim].(string)
+	if user == "" {
+		user, _ = claims["sub"].(string)
+	}
+	if user == "" {
+		return oidcSession{}, fmt.Errorf("ID token has neither %s nor sub", o.opts.UserClaim)
+	}
+	var groups []string
+	switch v := claims[o.opts.GroupsClaim].(type) {
+	case string:
+		groups = []string{v}
+	case []any:
+		for _, g := range v {
+			if group, ok := g.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return oidcSession{User: user, Groups: groups, Expires: o.now().Add(o.opts.SessionTTL).Unix()}, nil
+}
+
+func (o *OIDC) doJSON(req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// sign encodes v with an HMAC of it, for a cookie the browser cannot forge.
+func (o *OIDC) sign(v any) string {
+	payload, _ := json.Marshal(v)
+	mac := hmac.New(sha256.New, o.opts.SessionSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (o *OIDC) verify(value string, v any) bool {
+	encoded, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, o.opts.SessionSecret)
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil)) && json.Unmarshal(payload, v) == nil
+}
+
+func (o *OIDC) setCookie(w http.ResponseWriter, name, path, value string, ttl time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(o.opts.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if ttl < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(ttl / time.Second)
+	}
+	http.SetCookie(w, cookie)
+}
+
+func claimContains(claim any, want string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == want
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localPath keeps a post-login redirect on this site.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+func withQuery(endpoint string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + q.Encode()
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
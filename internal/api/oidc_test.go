@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeProvider is an OpenID Connect provider that logs everyone in as the
+// same user without asking.
+func fakeProvider(t *testing.T, clientID string, claims map[string]any) *httptest.Server {
+	t.Helper()
+	var provider *httptest.Server
+	var nonce, challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 provider.URL,
+			"authorization_endpoint": provider.URL + "/authorize",
+			"token_endpoint":         provider.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != clientID || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		nonce, challenge = q.Get("nonce"), q.Get("code_challenge")
+		http.Redirect(w, r, q.Get("redirect_uri")+"?code=abc&state="+url.QueryEscape(q.Get("state")), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != clientID || secret != "s3cret" || r.FormValue("code") != "abc" || r.FormValue("code_verifier") == "" || challenge == "" {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		payload := map[string]any{"iss": provider.URL, "aud": clientID, "exp": time.Now().Add(time.Hour).Unix(), "nonce": nonce}
+		for key, value := range claims {
+			payload[key] = value
+		}
+		encoded, _ := json.Marshal(payload)
+		token := "e30." + base64.RawURLEncoding.EncodeToString(encoded) + ".sig"
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": token, "access_token": "at"})
+	})
+	provider = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+func TestOIDCLoginMapsGroupsToRoles(t *testing.T) {
+	provider := fakeProvider(t, "healthmon", map[string]any{"preferred_username": "alice", "groups": []string{"family"}})
+
+	srv := NewServer(nil, NewBroadcaster(), WSOptions{})
+	app := httptest.NewServer(nil)
+	defer app.Close()
+	oidc, err := NewOIDC(OIDCOptions{
+		Issuer:       provider.URL,
+		ClientID:     "healthmon",
+		ClientSecret: "s3cret",
+		RedirectURL:  app.URL + "/auth/callback",
+	})
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+	srv.WithAuth(AuthOptions{OIDC: oidc, Roles: RoleMapping{Groups: map[string]TokenScope{"family": ScopeRead}}})
+	app.Config.Handler = srv.authMiddleware(func() http.Handler {
+		mux := http.NewServeMux()
+		oidc.register(mux)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("hello " + requestActor(r)))
+		})
+		return mux
+	}())
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+
+	// An API call without a session is refused rather than redirected.
+	resp, err := client.Get(app.URL + "/api/containers")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for the API, got %d", resp.StatusCode)
+	}
+
+	// A page goes through the provider and comes back logged in.
+	resp, err = client.Get(app.URL + "/containers/web")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "hello user:alice" || resp.Request.URL.Path != "/containers/web" {
+		t.Fatalf("expected to land logged in on the page, got %d %q at %s", resp.StatusCode, body, resp.Request.URL)
+	}
+
+	// The family group only grants read access.
+	req, _ := http.NewRequest(http.MethodPost, app.URL+"/api/events", nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for a read role, got %d", resp.StatusCode)
+	}
+
+	// A forged session is not accepted.
+	appURL, _ := url.Parse(app.URL)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"u":"root","exp":9999999999}`)) + ".AAAA"
+	jar.SetCookies(appURL, []*http.Cookie{{Name: sessionCookieName, Value: forged}})
+	resp, err = client.Get(app.URL + "/api/containers")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected a forged session to be refused, got %d", resp.StatusCode)
+	}
+}
+
+func TestLocalPathKeepsRedirectsOnSite(t *testing.T) {
+	for next, want := range map[string]string{
+		"/containers/web?x=1":   "/containers/web?x=1",
+		"//evil.example/":       "/",
+		"https://evil.example/": "/",
+		"/\\evil.example":       "/",
+		"":                      "/",
+	} {
+		if got := localPath(next); got != want {
+			t.Fatalf("localPath(%q) = %q, expected %q", next, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+const (
+	// defaultPageSize is the page size of the events and alerts listings
+	// when the request has no limit.
+	defaultPageSize = 50
+	// maxPageSize caps the limit a request can ask for, so one request
+	// cannot load a whole table.
+	maxPageSize = 500
+)
+
+// page is the position and size of a requested page: the rows after id in
+// the listing order, or before it when backward is set.
+type page struct {
+	id       int64
+	backward bool
+	limit    int
+}
+
+// parsePage reads cursor and limit, capped at maxPageSize. Without a cursor the page starts at the
+// legacy before_id or after_id cursor already read by parseFilter, or at the
+// start of the listing.
+func parsePage(q url.Values, legacyCursor int64) (page, error) {
+	p := page{id: legacyCursor, limit: defaultPageSize}
+	if limit, _ := strconv.Atoi(q.Get("limit")); limit > 0 {
+		p.limit = min(limit, maxPageSize)
+	}
+	if v := q.Get("cursor"); v != "" {
+		var err error
+		if p.id, p.backward, err = decodeCursor(v); err != nil {
+			return page{}, fmt.Errorf("invalid cursor %q", v)
+		}
+	}
+	return p, nil
+}
+
+// query returns the filter, cursor and limit to ask the store for: one row
+// more than the page holds, to tell whether there are more, and in reverse
+// order when paging backward.
+func (p page) query(f store.Filter) (store.Filter, int64, int) {
+	if p.backward {
+		f.Ascending = !f.Ascending
+	}
+	return f, p.id, p.limit + 1
+}
+
+// pageOf trims the rows returned for p.query to the page, puts them in the
+// listing order and sets the cursors around them.
+func pageOf[T any](p page, items []T, id func(T) int64) ([]T, PageInfo) {
+	info := PageInfo{HasMore: len(items) > p.limit}
+	if info.HasMore {
+		items = items[:p.limit]
+	}
+	if p.backward {
+		slices.Reverse(items)
+	}
+	if len(items) == 0 {
+		if p.id > 0 {
+			cursor := encodeCursor(p.id, p.backward)
+			if p.backward {
+				info.PrevCursor = cursor
+			} else {
+				info.NextCursor = cursor
+			}
+		}
+		return items, info
+	}
+	info.PrevCursor = encodeCursor(id(items[0]), true)
+	info.NextCursor = encodeCursor(id(items[len(items)-1]), false)
+	return items, info
+}
+
+// Cursors are opaque to clients; they encode the direction and the id of
+// the row the page starts after.
+func encodeCursor(id int64, backward bool) string {
+	dir := "next"
+	if backward {
+		dir = "prev"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(dir + ":" + strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, bool, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false, err
+	}
+	dir, idPart, ok := strings.Cut(string(raw), ":")
+	if !ok || (dir != "next" && dir != "prev") {
+		return 0, false, fmt.Errorf("unknown cursor %q", raw)
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, fmt.Errorf("unknown cursor %q", raw)
+	}
+	return id, dir == "prev", nil
+}
+
+func toEventList(p page, items []store.Event, total int64) EventListResponse {
+	items, info := pageOf(p, items, func(e store.Event) int64 { return e.ID })
+	resp := EventListResponse{Items: make([]EventResponse, 0, len(items)), Total: total, PageInfo: info}
+	for _, e := range items {
+		resp.Items = append(resp.Items, *toEventResponse(e))
+	}
+	return resp
+}
+
+func toAlertList(p page, items []store.Alert, total int64) AlertListResponse {
+	items, info := pageOf(p, items, func(a store.Alert) int64 { return a.ID })
+	resp := AlertListResponse{Items: make([]AlertResponse, 0, len(items)), Total: total, PageInfo: info}
+	for _, a := range items {
+		resp.Items = append(resp.Items, *toAlertResponse(a))
+	}
+	return resp
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestEventCursorsSurviveNewRows(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	addEvent := func(i int) {
+		t.Helper()
+		msg := fmt.Sprintf("event %d", i)
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", Type: "restart", Severity: "yellow", Message: msg, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		addEvent(i)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	get := func(target string) EventListResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, rec.Code, rec.Body.String())
+		}
+		var resp EventListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+	messages := func(resp EventListResponse) []string {
+		out := []string{}
+		for _, e := range resp.Items {
+			out = append(out, e.Message)
+		}
+		return out
+	}
+	expect := func(resp EventListResponse, hasMore bool, want ...string) {
+		t.Helper()
+		got := messages(resp)
+		if fmt.Sprint(got) != fmt.Sprint(want) || resp.HasMore != hasMore {
+			t.Fatalf("expected %v (has_more %v), got %v (has_more %v)", want, hasMore, got, resp.HasMore)
+		}
+	}
+
+	first := get("/api/events?limit=2")
+	expect(first, true, "event 5", "event 4")
+
+	// Rows that arrive mid-scroll do not shift the next page.
+	addEvent(6)
+	second := get("/api/events?limit=2&cursor=" + first.NextCursor)
+	expect(second, true, "event 3", "event 2")
+	last := get("/api/events?limit=2&cursor=" + second.NextCursor)
+	expect(last, false, "event 1")
+
+	// Paging back from the first page picks up just the new row.
+	newer := get("/api/events?limit=2&cursor=" + first.PrevCursor)
+	expect(newer, false, "event 6")
+	if newer.PrevCursor == "" {
+		t.Fatalf("expected a cursor to poll for newer rows")
+	}
+	empty := get("/api/events?limit=2&cursor=" + newer.PrevCursor)
+	expect(empty, false)
+	if empty.PrevCursor != newer.PrevCursor {
+		t.Fatalf("expected an empty page to hand back its cursor, got %q", empty.PrevCursor)
+	}
+
+	// Paging backward returns the rows next to the cursor in listing order.
+	back := get("/api/containers/web/events?limit=2&cursor=" + last.PrevCursor)
+	expect(back, true, "event 3", "event 2")
+
+	// Oldest first, the next cursor leads to newer rows.
+	asc := get("/api/events?limit=4&order=asc")
+	expect(asc, true, "event 1", "event 2", "event 3", "event 4")
+	expect(get("/api/events?limit=4&order=asc&cursor="+asc.NextCursor), false, "event 5", "event 6")
+
+	// A limit above maxPageSize is capped.
+	if p, err := parsePage(url.Values{"limit": {"1000000"}}, 0); err != nil || p.limit != maxPageSize {
+		t.Fatalf("expected the limit to be capped at %d, got %d (%v)", maxPageSize, p.limit, err)
+	}
+
+	// The legacy before_id still works.
+	expect(get(fmt.Sprintf("/api/events?limit=2&before_id=%d", first.Items[1].ID)), true, "event 3", "event 2")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/alerts?cursor=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid cursor, got %d", rec.Code)
+	}
+}
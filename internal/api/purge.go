@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+)
+
+type PurgeResponse struct {
+	Container string `json:"container"`
+	Events    int64  `json:"events"`
+	Alerts    int64  `json:"alerts"`
+	Incidents int64  `json:"incidents"`
+}
+
+// handlePurgeContainer serves DELETE /api/containers/{name}: it deletes a
+// removed container together with its history. Containers that are still
+// present cannot be purged.
+func (s *Server) handlePurgeContainer(w http.ResponseWriter, r *http.Request, name string) {
+	c, ok := s.store.GetContainer(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "container not found")
+		return
+	}
+	if c.Present {
+		writeError(w, http.StatusConflict, "container is still present")
+		return
+	}
+	result, ok, err := s.store.PurgeContainer(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "container not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, PurgeResponse{
+		Container: name,
+		Events:    result.Events,
+		Alerts:    result.Alerts,
+		Incidents: result.Incidents,
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestRemovedContainersAreListedAndPurged(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	for _, name := range []string{"web", "old"} {
+		if err := st.UpsertContainer(ctx, store.Container{Name: name, ContainerID: "c-" + name, Status: "running"}); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	old, _ := st.GetContainer("old")
+	if _, err := st.AddEvent(ctx, store.Event{ContainerPK: old.ID, Container: "old", Type: "stopped", Severity: "red", Message: "stopped", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("add event: %v", err)
+	}
+	if err := st.SetContainerPresent(ctx, "old", false); err != nil {
+		t.Fatalf("set absent: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+	names := func(present string) []string {
+		rec := do(http.MethodGet, "/api/containers?present="+present)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("present=%s: expected 200, got %d: %s", present, rec.Code, rec.Body.String())
+		}
+		var items []ContainerResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		var out []string
+		for _, c := range items {
+			if !c.Present && c.RemovedAt == "" {
+				t.Fatalf("expected removed_at on %s", c.Name)
+			}
+			out = append(out, c.Name)
+		}
+		return out
+	}
+
+	if got := names("true"); len(got) != 1 || got[0] != "web" {
+		t.Fatalf("present=true: got %v", got)
+	}
+	if got := names("false"); len(got) != 1 || got[0] != "old" {
+		t.Fatalf("present=false: got %v", got)
+	}
+	if got := names("all"); len(got) != 2 {
+		t.Fatalf("present=all: got %v", got)
+	}
+	if rec := do(http.MethodGet, "/api/containers?present=maybe"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid present, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/containers/web"); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 purging a present container, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/containers/missing"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 purging an unknown container, got %d", rec.Code)
+	}
+	rec := do(http.MethodDelete, "/api/containers/old")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var purged PurgeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &purged); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if purged.Events != 1 {
+		t.Fatalf("expected one purged event, got %+v", purged)
+	}
+	if got := names("all"); len(got) != 1 || got[0] != "web" {
+		t.Fatalf("expected only web after the purge, got %v", got)
+	}
+	if _, ok := st.GetContainer("old"); ok {
+		t.Fatalf("expected the purged container to be gone from the database")
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LimitOptions protects the API, and the single database writer behind it,
+// from clients that send too much.
+type LimitOptions struct {
+	// RequestsPerSecond is how many /api/ requests a client IP may make per
+	// second on average, with bursts of up to Burst. Zero disables the limit.
+	RequestsPerSecond float64
+	Burst             int
+	// MaxBodyBytes caps request bodies; zero leaves them unlimited.
+	MaxBodyBytes int64
+}
+
+// idleBucket is how often idle buckets are dropped, and the least a
+// client's bucket is kept after its last request. A bucket is only dropped
+// once it had time to fill up again, which takes longer at a low rate, so
+// dropping it changes nothing.
+const idleBucket = 10 * time.Minute
+
+// maxBuckets bounds the buckets kept at once. When a new client arrives with
+// the map full, buckets that have filled up again are dropped first, then
+// the least recently used one.
+const maxBuckets = 10000
+
+// WithLimits enables per-IP rate limiting and the request body limit.
+func (s *Server) WithLimits(opts LimitOptions) {
+	s.limits = opts
+	if opts.RequestsPerSecond > 0 {
+		s.limiter = newRateLimiter(opts.RequestsPerSecond, max(opts.Burst, 1), time.Now)
+	}
+}
+
+func (s *Server) limitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.limits.MaxBodyBytes > 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodyBytes)
+		}
+		if s.limiter != nil && strings.HasPrefix(r.URL.Path, "/api/") {
+			if wait, ok := s.limiter.allow(s.clientIP(r)); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "too many requests")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimiter is a token bucket per client IP.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	// refill is how long an empty bucket takes to fill up.
+	refill time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	limited   int64
+}
+
+type bucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+func newRateLimiter(rate float64, burst int, now func() time.Time) *rateLimiter {
+	refill := time.Duration(float64(burst) / rate * float64(time.Second))
+	return &rateLimiter{rate: rate, burst: float64(burst), refill: refill, now: now, buckets: make(map[string]*bucket)}
+}
+
+// allow takes a token from the bucket of ip. When it is empty it reports
+// how long until the next token.
+func (l *rateLimiter) allow(ip string) (time.Duration, bool) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[ip]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.evict(now)
+		}
+		b = &bucket{tokens: l.burst, seen: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.seen).Seconds()*l.rate)
+	b.seen = now
+	if b.tokens < 1 {
+		l.limited++
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep drops the buckets of clients that have been quiet for a while, so
+// the map does not grow with every address ever seen.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucket {
+		return
+	}
+	l.lastSweep = now
+	idle := max(idleBucket, l.refill)
+	for ip, b := range l.buckets {
+		if now.Sub(b.seen) >= idle {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// evict makes room for a new bucket.
+func (l *rateLimiter) evict(now time.Time) {
+	var oldest string
+	var oldestSeen time.Time
+	for ip, b := range l.buckets {
+		if now.Sub(b.seen) >= l.refill {
+			delete(l.buckets, ip)
+		} else if oldest == "" || b.seen.Before(oldestSeen) {
+			oldest, oldestSeen = ip, b.seen
+		}
+	}
+	if len(l.buckets) >= maxBuckets {
+		delete(l.buckets, oldest)
+	}
+}
+
+// Limited returns how many requests were turned away so far.
+func (l *rateLimiter) Limited() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limited
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefillsPerIP(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 3, func() time.Time { return now })
+	for i := range 3 {
+		if _, ok := l.allow("10.0.0.1"); !ok {
+			t.Fatalf("request %d within the burst was limited", i+1)
+		}
+	}
+	wait, ok := l.allow("10.0.0.1")
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected the fourth request to wait 500ms, got %v (allowed %v)", wait, ok)
+	}
+	if _, ok := l.allow("10.0.0.2"); !ok {
+		t.Fatalf("another client should have its own bucket")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if _, ok := l.allow("10.0.0.1"); !ok {
+		t.Fatalf("expected a token after 500ms")
+	}
+	if l.Limited() != 1 {
+		t.Fatalf("expected one limited request, got %d", l.Limited())
+	}
+
+	now = now.Add(idleBucket)
+	l.allow("10.0.0.3")
+	if len(l.buckets) != 1 {
+		t.Fatalf("expected idle buckets to be dropped, got %d", len(l.buckets))
+	}
+}
+
+func TestRateLimiterKeepsBucketsUntilTheyRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	// One request a minute with a burst of 20 takes 20 minutes to refill.
+	l := newRateLimiter(1.0/60, 20, func() time.Time { return now })
+	for range 20 {
+		l.allow("10.0.0.1")
+	}
+	now = now.Add(idleBucket)
+	l.allow("10.0.0.2")
+	if _, ok := l.buckets["10.0.0.1"]; !ok {
+		t.Fatalf("expected the bucket, only half full again, to be kept after %s", idleBucket)
+	}
+	now = now.Add(20 * time.Minute)
+	l.allow("10.0.0.2")
+	if _, ok := l.buckets["10.0.0.1"]; ok {
+		t.Fatalf("expected the bucket to be dropped once it refilled")
+	}
+}
+
+func TestLimitMiddleware(t *testing.T) {
+	srv := NewServer(nil, NewBroadcaster(), WSOptions{})
+	srv.WithLimits(LimitOptions{RequestsPerSecond: 1, Burst: 1, MaxBodyBytes: 16})
+	handler := srv.limitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tooLarge *http.MaxBytesError
+		if _, err := io.ReadAll(r.Body); errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	do := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("/api/events", "{}"); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected the first request through, got %d", rec.Code)
+	}
+	rec := do("/api/events", "{}")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := do("/assets/app.js", strings.Repeat("x", 64)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected the body limit outside /api/ too, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiterBoundsBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(1, 5, func() time.Time { return now })
+	for i := range maxBuckets + 100 {
+		l.allow(fmt.Sprintf("client-%d", i))
+		now = now.Add(time.Millisecond)
+	}
+	if len(l.buckets) > maxBuckets {
+		t.Fatalf("expected at most %d buckets, got %d", maxBuckets, len(l.buckets))
+	}
+	if _, ok := l.buckets[fmt.Sprintf("client-%d", maxBuckets+99)]; !ok {
+		t.Fatalf("expected the latest client to keep its bucket")
+	}
+}
+
+func TestLimitMiddlewareOnlyBelievesTrustedProxies(t *testing.T) {
+	srv := NewServer(nil, NewBroadcaster(), WSOptions{})
+	srv.WithLimits(LimitOptions{RequestsPerSecond: 1, Burst: 1})
+	srv.WithTrustedProxies([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+	handler := srv.limitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	do := func(remote, forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/containers", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A client talking directly cannot get a fresh bucket per request by
+	// making up forwarded addresses.
+	if code := do("192.0.2.1:1234", "198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("expected the first request through, got %d", code)
+	}
+	if code := do("192.0.2.1:1234", "198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected a spoofed X-Forwarded-For to share the peer's bucket, got %d", code)
+	}
+	if len(srv.limiter.buckets) != 1 {
+		t.Fatalf("expected one bucket, got %d", len(srv.limiter.buckets))
+	}
+
+	// Behind a trusted proxy each visitor has their own bucket, and what the
+	// visitor prepended to the header is ignored.
+	if code := do("10.0.0.2:80", "203.0.113.9, 203.0.113.1"); code != http.StatusNoContent {
+		t.Fatalf("expected the first visitor through, got %d", code)
+	}
+	if code := do("10.0.0.2:80", "203.0.113.10, 203.0.113.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected the same visitor to be limited, got %d", code)
+	}
+	if code := do("10.0.0.2:80", "203.0.113.2, 10.0.0.3"); code != http.StatusNoContent {
+		t.Fatalf("expected another visitor through, got %d", code)
+	}
+}
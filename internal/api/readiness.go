@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"healthmon/internal/store"
+)
+
+const (
+	defaultReadinessSamples = 100
+	maxReadinessSamples     = 1000
+)
+
+// ReadinessSampleResponse is one start of a container and how long it took
+// to become healthy.
+type ReadinessSampleResponse struct {
+	ContainerID string  `json:"container_id"`
+	ImageID     string  `json:"image_id"`
+	StartedAt   string  `json:"started_at"`
+	ReadyAt     string  `json:"ready_at"`
+	Seconds     float64 `json:"seconds"`
+}
+
+// ReadinessResponse is served by GET /api/containers/{name}/readiness. The
+// percentiles are taken over the listed samples and are 0 without any.
+type ReadinessResponse struct {
+	Container  string                    `json:"container"`
+	Samples    []ReadinessSampleResponse `json:"samples"`
+	P50Seconds float64                   `json:"p50_seconds"`
+	P90Seconds float64                   `json:"p90_seconds"`
+	P99Seconds float64                   `json:"p99_seconds"`
+}
+
+// handleReadiness serves GET /api/containers/{name}/readiness: the latest
+// ?limit= starts (100 by default) with the time each took from start to
+// the first healthy status, newest first, and their percentiles.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.store.GetContainer(name); !ok {
+		writeError(w, http.StatusNotFound, "container not found")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultReadinessSamples
+	}
+	samples, err := s.store.ListReadinessSamples(r.Context(), name, min(limit, maxReadinessSamples))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, toReadinessResponse(name, samples))
+}
+
+func toReadinessResponse(name string, samples []store.ReadinessSample) ReadinessResponse {
+	resp := ReadinessResponse{
+		Container:  name,
+		Samples:    make([]ReadinessSampleResponse, 0, len(samples)),
+		P50Seconds: store.ReadinessPercentile(samples, 50).Seconds(),
+		P90Seconds: store.ReadinessPercentile(samples, 90).Seconds(),
+		P99Seconds: store.ReadinessPercentile(samples, 99).Seconds(),
+	}
+	for _, sample := range samples {
+		resp.Samples = append(resp.Samples, ReadinessSampleResponse{
+			ContainerID: sample.ContainerID,
+			ImageID:     sample.ImageID,
+			StartedAt:   formatMaybeTime(sample.StartedAt),
+			ReadyAt:     formatMaybeTime(sample.ReadyAt),
+			Seconds:     sample.Duration.Seconds(),
+		})
+	}
+	return resp
+}
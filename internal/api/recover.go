@@ -0,0 +1,33 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"healthmon/internal/crash"
+)
+
+// WithCrashReporter makes recovered handler panics go through r, so they are
+// recorded like the monitor's own.
+func (s *Server) WithCrashReporter(r *crash.Reporter) {
+	s.crash = r
+}
+
+// recoverMiddleware answers 500 when a handler panics instead of letting
+// net/http drop the connection without a trace.
+func (s *Server) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+			s.crash.Handle(r.Context(), r.Method+" "+r.URL.Path, v)
+			writeError(w, http.StatusInternalServerError, "internal error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
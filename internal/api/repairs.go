@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"healthmon/internal/store"
+)
+
+type IntegrityCheckResponse struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Rows        int64  `json:"rows"`
+	Repaired    bool   `json:"repaired"`
+}
+
+type RepairResponse struct {
+	ID          int64  `json:"id"`
+	Check       string `json:"check"`
+	Description string `json:"description"`
+	Rows        int64  `json:"rows"`
+	Repaired    bool   `json:"repaired"`
+	Timestamp   string `json:"ts"`
+}
+
+// RepairsResponse lists the checks of the last startup, including those
+// that found nothing, and the findings of every startup so far.
+type RepairsResponse struct {
+	CheckedAt string                   `json:"checked_at,omitempty"`
+	Checks    []IntegrityCheckResponse `json:"checks"`
+	History   []RepairResponse         `json:"history"`
+}
+
+// handleRepairs serves GET /api/admin/repairs.
+func (s *Server) handleRepairs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	history, err := s.store.ListRepairs(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := RepairsResponse{Checks: []IntegrityCheckResponse{}, History: make([]RepairResponse, 0, len(history))}
+	if report, ok := s.store.LastIntegrityCheck(); ok {
+		resp.CheckedAt = formatMaybeTime(report.CheckedAt)
+		for _, check := range report.Checks {
+			resp.Checks = append(resp.Checks, IntegrityCheckResponse(check))
+		}
+	}
+	for _, repair := range history {
+		resp.History = append(resp.History, toRepairResponse(repair))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func toRepairResponse(r store.Repair) RepairResponse {
+	return RepairResponse{
+		ID:          r.ID,
+		Check:       r.Check,
+		Description: r.Description,
+		Rows:        r.Rows,
+		Repaired:    r.Repaired,
+		Timestamp:   formatMaybeTime(r.Timestamp),
+	}
+}
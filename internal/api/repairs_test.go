@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestRepairsReportStartupChecks(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	if _, err := dbConn.SQL.ExecContext(ctx, `
+INSERT INTO containers (id, name, container_id, image, image_tag, image_id, created_at_container, first_seen_at, status, caps, read_only, "user", updated_at, last_event_id)
+VALUES (1, 'web', 'cid-web', 'nginx', 'latest', 'img', '2026-03-01T10:00:00Z', '2026-03-01T10:00:00Z', 'running', '[]', 0, '0:0', '2026-03-01T10:00:00Z', 99);
+INSERT INTO events (id, container_pk, container_name, container_id, event_type, severity, message, ts) VALUES
+  (10, 1, 'web', 'cid-web', 'started', 'blue', 'Container started', '2026-03-01T10:00:00Z'),
+  (11, 1, 'web-old', 'cid-web', 'restart', 'red', 'Container restarted', '2026-03-01T11:00:00Z'),
+  (12, 7, 'gone', 'cid-gone', 'started', 'blue', 'Container started', '2026-03-01T11:00:00Z');
+`); err != nil {
+		t.Fatalf("seed db: %v", err)
+	}
+
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if _, err := st.CheckIntegrity(ctx); err != nil {
+		t.Fatalf("first check: %v", err)
+	}
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if c, _ := st.GetContainer("web"); c.LastEventID != 11 {
+		t.Fatalf("expected last_event_id to be repaired to 11, got %d", c.LastEventID)
+	}
+	// A second startup finds only what cannot be repaired.
+	if _, err := st.CheckIntegrity(ctx); err != nil {
+		t.Fatalf("second check: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewServer(st, NewBroadcaster(), WSOptions{}).Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/repairs", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp RepairsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	found := map[string]int64{}
+	for _, check := range resp.Checks {
+		found[check.Name] = check.Rows
+	}
+	if len(resp.Checks) == 0 || found["dangling_last_event"] != 0 || found["event_container_names"] != 0 || found["orphan_events"] != 1 {
+		t.Fatalf("unexpected last checks: %+v", resp.Checks)
+	}
+
+	var history []string
+	for _, repair := range resp.History {
+		history = append(history, repair.Check)
+		if repair.Check == "orphan_events" && repair.Repaired {
+			t.Fatalf("expected orphan events to be reported, not repaired")
+		}
+	}
+	want := []string{"orphan_events", "orphan_events", "event_container_names", "dangling_last_event"}
+	if len(history) != len(want) {
+		t.Fatalf("expected history %v, got %v", want, history)
+	}
+	for i := range want {
+		if history[i] != want[i] {
+			t.Fatalf("expected history %v, got %v", want, history)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// RestartScheduleRequest plans a restart of Container at At (RFC3339, default
+// now) and, with Every, repeatedly from then on.
+type RestartScheduleRequest struct {
+	Container string `json:"container"`
+	At        string `json:"at"`
+	Every     string `json:"every"`
+}
+
+type RestartScheduleResponse struct {
+	ID        int64  `json:"id"`
+	Container string `json:"container"`
+	NextRunAt string `json:"next_run_at"`
+	Every     string `json:"every"`
+	CreatedAt string `json:"created_at"`
+	LastRunAt string `json:"last_run_at"`
+}
+
+// handleRestartSchedules serves GET and POST /api/restarts.
+func (s *Server) handleRestartSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.store.ListRestartSchedules(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp := make([]RestartScheduleResponse, 0, len(items))
+		for _, rs := range items {
+			resp = append(resp, toRestartScheduleResponse(rs))
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case http.MethodPost:
+		var req RestartScheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+		rs, err := s.parseRestartSchedule(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if rs, err = s.store.AddRestartSchedule(r.Context(), rs); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, toRestartScheduleResponse(rs))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleRestartSchedule serves DELETE /api/restarts/{id}.
+func (s *Server) handleRestartSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/restarts/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	found, err := s.store.DeleteRestartSchedule(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "restart schedule not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) parseRestartSchedule(req RestartScheduleRequest) (store.RestartSchedule, error) {
+	c, ok := s.store.GetContainer(req.Container)
+	// Heartbeats and healthmon itself have no Docker container to restart.
+	if !ok || !c.Present || c.ContainerID == "" {
+		return store.RestartSchedule{}, fmt.Errorf("unknown container %q", req.Container)
+	}
+	rs := store.RestartSchedule{Container: c.Name, NextRunAt: time.Now().UTC()}
+	if req.At != "" {
+		at, err := time.Parse(time.RFC3339, req.At)
+		if err != nil {
+			return store.RestartSchedule{}, fmt.Errorf("invalid at %q, expected an RFC3339 timestamp", req.At)
+		}
+		rs.NextRunAt = at.UTC()
+	}
+	if req.Every != "" {
+		every, err := parseDurationParam(req.Every)
+		if err != nil || every < time.Hour {
+			return store.RestartSchedule{}, fmt.Errorf("invalid every %q, expected a duration of at least 1h", req.Every)
+		}
+		rs.Interval = every
+	}
+	return rs, nil
+}
+
+func toRestartScheduleResponse(rs store.RestartSchedule) RestartScheduleResponse {
+	every := ""
+	if rs.Interval > 0 {
+		every = rs.Interval.String()
+	}
+	return RestartScheduleResponse{
+		ID:        rs.ID,
+		Container: rs.Container,
+		NextRunAt: rs.NextRunAt.UTC().Format("2006-01-02T15:04:05Z"),
+		Every:     every,
+		CreatedAt: rs.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		LastRunAt: formatMaybeTime(rs.LastRunAt),
+	}
+}
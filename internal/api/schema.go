@@ -0,0 +1,356 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	defaultGraphQLItems = 50
+	maxGraphQLItems     = 500
+)
+
+// GraphQLRequest is the body of POST /api/graphql.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// GraphQLResponse holds the selected data, and the errors of the fields
+// that resolved to null because of them.
+type GraphQLResponse struct {
+	Data   *gqlResult `json:"data"`
+	Errors []gqlError `json:"errors,omitempty"`
+}
+
+// handleGraphQL serves /api/graphql: a query as ?query= (with ?variables=
+// as JSON and ?operationName=) on GET, or as a JSON GraphQLRequest on POST.
+// Documents that do not parse get a 400; errors of single fields are
+// reported next to the rest of the data.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req GraphQLRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid variables")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json")
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: []gqlError{{Message: err.Error()}}})
+		return
+	}
+	q := &gqlQuery{server: s, stats: make(map[time.Duration]StatsResponse)}
+	data, errs := executeGraphQL(r.Context(), doc, req.OperationName, req.Variables, q.root())
+	if data == nil {
+		writeJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: errs})
+		return
+	}
+	writeJSON(w, http.StatusOK, GraphQLResponse{Data: data, Errors: errs})
+}
+
+// gqlQuery resolves one query. It loads the alert counts and the stats of
+// a window once, however many containers select them.
+type gqlQuery struct {
+	server      *Server
+	alertCounts map[int64]int64
+	stats       map[time.Duration]StatsResponse
+}
+
+// root is the Query type.
+func (q *gqlQuery) root() gqlObject {
+	return &gqlType{name: "Query", extra: map[string]gqlResolver{
+		"containers": q.containers,
+		"container": func(ctx context.Context, args map[string]any) (any, error) {
+			name, err := gqlString(args, "name")
+			if err != nil {
+				return nil, err
+			}
+			c, ok := q.server.store.GetContainer(name)
+			if !ok {
+				return nil, nil
+			}
+			return q.container(ctx, c)
+		},
+		"events": func(ctx context.Context, args map[string]any) (any, error) {
+			return q.events(ctx, args, nil)
+		},
+		"alerts": func(ctx context.Context, args map[string]any) (any, error) {
+			return q.alerts(ctx, args, nil)
+		},
+		"stats": func(ctx context.Context, args map[string]any) (any, error) {
+			stats, err := q.containerStats(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+			return newGQLType("Stats", stats, nil)
+		},
+	}}
+}
+
+// containers lists the containers like GET /api/containers, with the
+// present, group and label arguments for its parameters.
+func (q *gqlQuery) containers(ctx context.Context, args map[string]any) (any, error) {
+	present, err := gqlString(args, "present")
+	if err != nil {
+		return nil, err
+	}
+	groups, err := gqlStrings(args, "group")
+	if err != nil {
+		return nil, err
+	}
+	labels, err := gqlStrings(args, "label")
+	if err != nil {
+		return nil, err
+	}
+	selectors := parseLabelSelectors(labels)
+
+	var items []store.Container
+	switch present {
+	case "", "true":
+		items = q.server.store.ListContainers()
+	case "false", "all":
+		items = q.server.store.ListAllContainers()
+		if present == "false" {
+			items = slices.DeleteFunc(items, func(c store.Container) bool { return c.Present })
+		}
+	default:
+		return nil, fmt.Errorf("invalid present %q, expected true, false or all", present)
+	}
+	out := make([]any, 0, len(items))
+	for _, c := range items {
+		if !matchLabels(c.Labels, selectors) || (len(groups) > 0 && !slices.Contains(groups, c.Group())) {
+			continue
+		}
+		obj, err := q.container(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// container is the Container type: the fields of ContainerResponse, and
+// events, alerts, stats and readiness.
+func (q *gqlQuery) container(ctx context.Context, c store.Container) (gqlObject, error) {
+	if q.alertCounts == nil {
+		counts, err := q.server.store.CountAlertsPerContainer(ctx)
+		if err != nil {
+			return nil, err
+		}
+		q.alertCounts = counts
+	}
+	resp := toContainerResponse(c)
+	resp.AlertCount = q.alertCounts[c.ID]
+	resp.Notes = q.server.containerNotes(c.Name)
+	return newGQLType("Container", resp, map[string]gqlResolver{
+		"events": func(ctx context.Context, args map[string]any) (any, error) {
+			return q.events(ctx, args, []string{c.Name})
+		},
+		"alerts": func(ctx context.Context, args map[string]any) (any, error) {
+			return q.alerts(ctx, args, []string{c.Name})
+		},
+		"stats": func(ctx context.Context, args map[string]any) (any, error) {
+			stats, err := q.containerStats(ctx, args)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range stats.Containers {
+				if item.Name == c.Name {
+					return newGQLType("ContainerStats", item, nil)
+				}
+			}
+			return nil, nil
+		},
+		"readiness": func(ctx context.Context, args map[string]any) (any, error) {
+			limit, err := gqlInt(args, "limit", defaultReadinessSamples)
+			if err != nil {
+				return nil, err
+			}
+			samples, err := q.server.store.ListReadinessSamples(ctx, c.Name, min(max(limit, 1), maxReadinessSamples))
+			if err != nil {
+				return nil, err
+			}
+			return newGQLType("Readiness", toReadinessResponse(c.Name, samples), nil)
+		},
+	})
+}
+
+// containerOf resolves the container an event or an alert belongs to.
+func (q *gqlQuery) containerOf(ctx context.Context, name string) (any, error) {
+	c, ok := q.server.store.GetContainer(name)
+	if !ok {
+		return nil, nil
+	}
+	return q.container(ctx, c)
+}
+
+// events lists the newest events, or the oldest with order: "asc", that
+// match the arguments.
+func (q *gqlQuery) events(ctx context.Context, args map[string]any, containers []string) (any, error) {
+	filter, limit, err := gqlFilter(args, containers)
+	if err != nil {
+		return nil, err
+	}
+	items, err := q.server.store.ListAllEvents(ctx, filter, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(items))
+	for _, e := range items {
+		obj, err := newGQLType("Event", toEventResponse(e), map[string]gqlResolver{
+			"container_info": func(ctx context.Context, _ map[string]any) (any, error) {
+				return q.containerOf(ctx, e.Container)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// alerts lists alerts like events, with their comments and hook runs.
+func (q *gqlQuery) alerts(ctx context.Context, args map[string]any, containers []string) (any, error) {
+	filter, limit, err := gqlFilter(args, containers)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Unacknowledged, err = gqlBool(args, "unacknowledged"); err != nil {
+		return nil, err
+	}
+	items, err := q.server.store.ListAllAlerts(ctx, filter, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	alerts := make([]AlertResponse, 0, len(items))
+	for _, a := range items {
+		alerts = append(alerts, *toAlertResponse(a))
+	}
+	if err := q.server.attachComments(ctx, alerts); err != nil {
+		return nil, err
+	}
+	if err := q.server.attachHookRuns(ctx, alerts); err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(alerts))
+	for _, a := range alerts {
+		obj, err := newGQLType("Alert", a, map[string]gqlResolver{
+			"container_info": func(ctx context.Context, _ map[string]any) (any, error) {
+				return q.containerOf(ctx, a.Container)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// containerStats computes the stats of the window argument, 7 days by
+// default, once per query.
+func (q *gqlQuery) containerStats(ctx context.Context, args map[string]any) (StatsResponse, error) {
+	window := 7 * 24 * time.Hour
+	v, err := gqlString(args, "window")
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	if v != "" {
+		if window, err = parseDurationParam(v); err != nil || window < time.Hour {
+			return StatsResponse{}, fmt.Errorf("invalid window %q, expected a duration of at least 1h", v)
+		}
+	}
+	if stats, ok := q.stats[window]; ok {
+		return stats, nil
+	}
+	stats, err := q.server.containerStats(ctx, window)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	q.stats[window] = stats
+	return stats, nil
+}
+
+// gqlFilter reads the arguments of the events and alerts fields, which
+// match the parameters of their REST listings, and the limit, 50 by
+// default and at most 500.
+func gqlFilter(args map[string]any, containers []string) (store.Filter, int, error) {
+	f := store.Filter{Containers: containers}
+	var err error
+	if f.Containers == nil {
+		if f.Containers, err = gqlStrings(args, "container"); err != nil {
+			return store.Filter{}, 0, err
+		}
+	}
+	if f.Groups, err = gqlStrings(args, "group"); err != nil {
+		return store.Filter{}, 0, err
+	}
+	if f.Types, err = gqlStrings(args, "type"); err != nil {
+		return store.Filter{}, 0, err
+	}
+	if f.Severities, err = gqlStrings(args, "severity"); err != nil {
+		return store.Filter{}, 0, err
+	}
+	if f.Query, err = gqlString(args, "q"); err != nil {
+		return store.Filter{}, 0, err
+	}
+	f.Query = strings.TrimSpace(f.Query)
+
+	now := time.Now().UTC()
+	for name, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		v, err := gqlString(args, name)
+		if err != nil {
+			return store.Filter{}, 0, err
+		}
+		if *t, err = parseTimeParam(v, now); err != nil {
+			return store.Filter{}, 0, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	order, err := gqlString(args, "order")
+	if err != nil {
+		return store.Filter{}, 0, err
+	}
+	switch strings.ToLower(order) {
+	case "", "desc":
+	case "asc":
+		f.Ascending = true
+	default:
+		return store.Filter{}, 0, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	limit, err := gqlInt(args, "limit", defaultGraphQLItems)
+	if err != nil {
+		return store.Filter{}, 0, err
+	}
+	return f, min(max(limit, 1), maxGraphQLItems), nil
+}
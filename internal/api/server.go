@@ -5,33 +5,60 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"healthmon/client"
+	"healthmon/internal/crash"
 	"healthmon/internal/store"
 
 	"nhooyr.io/websocket"
 )
 
 type Server struct {
-	store       *store.Store
-	broadcaster *Broadcaster
-	staticFS    http.FileSystem
-	wsOptions   WSOptions
+	store         *store.Store
+	broadcaster   *Broadcaster
+	staticFS      http.FileSystem
+	wsOptions     WSOptions
+	auth          AuthOptions
+	backup        BackupFunc
+	maintenance   func() bool
+	crash         *crash.Reporter
+	inspector     ContainerInspector
+	images        ImageReporter
+	imagePrune    bool
+	onUpdate      []func(context.Context, EventUpdate)
+	debounce      *debouncer
+	build         BuildInfo
+	monitorStatus func() MonitorStatus
+	startedAt     time.Time
+	limits        LimitOptions
+	limiter       *rateLimiter
+	// trustedProxies may set X-Forwarded-For and X-Real-Ip.
+	trustedProxies []netip.Prefix
 }
 
 type WSOptions struct {
 	OriginPatterns     []string
 	InsecureSkipVerify bool
+	// Debounce is how long further WebSocket updates of a container are held
+	// and coalesced after one was sent; 0 sends every update right away.
+	// Alerts are never held.
+	Debounce time.Duration
 }
 
 func NewServer(store *store.Store, broadcaster *Broadcaster, wsOptions WSOptions) *Server {
-	return &Server{store: store, broadcaster: broadcaster, wsOptions: wsOptions}
+	s := &Server{store: store, broadcaster: broadcaster, wsOptions: wsOptions, startedAt: time.Now()}
+	s.debounce = newDebouncer(wsOptions.Debounce, s.send)
+	return s
 }
 
 func (s *Server) WithStatic(fs http.FileSystem) {
@@ -40,17 +67,48 @@ func (s *Server) WithStatic(fs http.FileSystem) {
 
 func (s *Server) Routes() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/containers", s.handleContainers)
-	mux.HandleFunc("/api/containers/", s.handleContainerEvents)
-	mux.HandleFunc("/api/events", s.handleEvents)
-	mux.HandleFunc("/api/alerts", s.handleAlerts)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/containers", s.withETag(s.handleContainers))
+	mux.HandleFunc("/api/containers/", s.withETag(s.handleContainerHistory))
+	mux.HandleFunc("/api/events", s.withETag(s.handleEvents))
+	mux.HandleFunc("/api/alerts", s.withETag(s.handleAlerts))
+	mux.HandleFunc("/api/alerts/", s.handleAlert)
+	mux.HandleFunc("/api/incidents", s.handleIncidents)
+	mux.HandleFunc("/api/incidents/", s.handleIncident)
+	mux.HandleFunc("/api/summary", s.handleSummary)
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/graph", s.handleGraph)
+	mux.HandleFunc("/api/graphql", s.handleGraphQL)
+	mux.HandleFunc("/api/groups", s.handleGroups)
+	mux.HandleFunc("/api/heartbeat/", s.handleHeartbeat)
+	mux.HandleFunc("/api/restarts", s.handleRestartSchedules)
+	mux.HandleFunc("/api/restarts/", s.handleRestartSchedule)
+	mux.HandleFunc("/api/clients/", s.handleClientFilter)
 	mux.HandleFunc("/api/events/stream", s.handleStream)
+	mux.HandleFunc("/api/events/timeline", s.handleTimeline)
+	mux.HandleFunc("/api/events/export", s.handleEventsExport)
+	mux.HandleFunc("/api/alerts/export", s.handleAlertsExport)
+	mux.HandleFunc("/api/notifications", s.handleNotifications)
+	mux.HandleFunc("/api/audit", s.handleAudit)
+	mux.HandleFunc("/api/badge/", s.handleBadge)
+	mux.HandleFunc("/api/widget", s.handleWidget)
+	mux.HandleFunc("/api/admin/backup", s.handleBackup)
+	mux.HandleFunc("/api/admin/db/maintenance", s.handleMaintenance)
+	mux.HandleFunc("/api/admin/repairs", s.handleRepairs)
+	mux.HandleFunc("/api/reports/images", s.handleImageReport)
+	mux.HandleFunc("/api/reports/images/prune", s.handleImagePrune)
+
+	if s.auth.OIDC != nil {
+		s.auth.OIDC.register(mux)
+	}
 
 	if s.staticFS != nil {
 		mux.Handle("/", http.HandlerFunc(s.handleSPA))
 	}
 
-	return loggingMiddleware(mux)
+	return loggingMiddleware(s.limitMiddleware(gzipMiddleware(s.recoverMiddleware(s.authMiddleware(s.standbyMiddleware(s.auditMiddleware(timeFormatMiddleware(mux))))))))
 }
 
 func (s *Server) handleSPA(w http.ResponseWriter, r *http.Request) {
@@ -99,33 +157,94 @@ func (s *Server) handleContainers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items := s.store.ListContainers()
-	resp := make([]ContainerResponse, 0, len(items))
-	for _, c := range items {
-		resp = append(resp, toContainerResponse(c))
+	selectors := parseLabelSelectors(r.URL.Query()["label"])
+	groups := multiParam(r.URL.Query(), "group")
+	var items []store.Container
+	switch present := r.URL.Query().Get("present"); present {
+	case "", "true":
+		items = s.store.ListContainers()
+	case "false", "all":
+		items = s.store.ListAllContainers()
+		if present == "false" {
+			items = slices.DeleteFunc(items, func(c store.Container) bool { return c.Present })
+		}
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid present %q, expected true, false or all", present))
+		return
+	}
+	resp, err := s.containerResponses(r.Context(), items, groups, selectors)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
 
-func (s *Server) handleContainerEvents(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
+// containerResponses converts the containers in the groups, if any, that
+// match the label selectors, with their alert counts and notes.
+func (s *Server) containerResponses(ctx context.Context, items []store.Container, groups []string, selectors []labelSelector) ([]ContainerResponse, error) {
+	alertCounts, err := s.store.CountAlertsPerContainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]ContainerResponse, 0, len(items))
+	for _, c := range items {
+		if !matchLabels(c.Labels, selectors) || (len(groups) > 0 && !slices.Contains(groups, c.Group())) {
+			continue
+		}
+		item := toContainerResponse(c)
+		item.AlertCount = alertCounts[c.ID]
+		item.Notes = s.containerNotes(c.Name)
+		resp = append(resp, item)
 	}
+	return resp, nil
+}
 
+func (s *Server) handleContainerHistory(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/containers/")
 	parts := strings.Split(path, "/")
-	if len(parts) != 2 || parts[1] != "events" {
+	if r.Method == http.MethodDelete && len(parts) == 1 {
+		s.handlePurgeContainer(w, r, parts[0])
+		return
+	}
+	if len(parts) == 2 && parts[1] == "notes" {
+		s.handleNotes(w, r, parts[0])
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if len(parts) != 2 {
 		writeError(w, http.StatusNotFound, "not found")
 		return
 	}
 
-	name := parts[0]
+	switch parts[1] {
+	case "events":
+		s.handleContainerEvents(w, r, parts[0])
+	case "alerts":
+		s.handleContainerAlerts(w, r, parts[0])
+	case "health-history":
+		s.handleHealthHistory(w, r, parts[0])
+	case "readiness":
+		s.handleReadiness(w, r, parts[0])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleContainerEvents(w http.ResponseWriter, r *http.Request, name string) {
 	beforeID, _ := strconv.ParseInt(r.URL.Query().Get("before_id"), 10, 64)
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	p, err := parsePage(r.URL.Query(), beforeID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	items, err := s.store.ListEvents(r.Context(), name, beforeID, limit)
+	filter, cursor, limit := p.query(store.Filter{Containers: []string{name}})
+	items, err := s.store.ListAllEvents(r.Context(), filter, cursor, limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -136,40 +255,79 @@ func (s *Server) handleContainerEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := make([]EventResponse, 0, len(items))
-	for _, e := range items {
-		resp = append(resp, *toEventResponse(e))
+	writeJSON(w, http.StatusOK, toEventList(p, items, total))
+}
+
+func (s *Server) handleContainerAlerts(w http.ResponseWriter, r *http.Request, name string) {
+	beforeID, _ := strconv.ParseInt(r.URL.Query().Get("before_id"), 10, 64)
+	p, err := parsePage(r.URL.Query(), beforeID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	writeJSON(w, http.StatusOK, EventListResponse{Items: resp, Total: total})
+	filter, cursor, limit := p.query(store.Filter{Containers: []string{name}})
+	items, err := s.store.ListAllAlerts(r.Context(), filter, cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	total, err := s.store.CountAlertsByContainer(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := toAlertList(p, items, total)
+	if err := s.attachComments(r.Context(), resp.Items); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := s.attachHookRuns(r.Context(), resp.Items); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.handleAnnotation(w, r)
+		return
+	}
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	beforeID, _ := strconv.ParseInt(r.URL.Query().Get("before_id"), 10, 64)
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-
-	items, err := s.store.ListAllEvents(r.Context(), beforeID, limit)
+	filter, cursor, err := parseFilter(r)
 	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.applyClientDefaults(r, &filter); err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	total, err := s.store.CountAllEvents(r.Context())
+	p, err := parsePage(r.URL.Query(), cursor)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	resp := make([]EventResponse, 0, len(items))
-	for _, e := range items {
-		resp = append(resp, *toEventResponse(e))
+	query, cursor, limit := p.query(filter)
+	items, err := s.store.ListAllEvents(r.Context(), query, cursor, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	total, err := s.store.CountEvents(r.Context(), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	writeJSON(w, http.StatusOK, EventListResponse{Items: resp, Total: total})
+	writeJSON(w, http.StatusOK, toEventList(p, items, total))
 }
 
 func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
@@ -178,26 +336,43 @@ func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	beforeID, _ := strconv.ParseInt(r.URL.Query().Get("before_id"), 10, 64)
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	filter, cursor, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.applyClientDefaults(r, &filter); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	p, err := parsePage(r.URL.Query(), cursor)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	items, err := s.store.ListAllAlerts(r.Context(), beforeID, limit)
+	query, cursor, limit := p.query(filter)
+	items, err := s.store.ListAllAlerts(r.Context(), query, cursor, limit)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	total, err := s.store.CountAllAlerts(r.Context())
+	total, err := s.store.CountAlerts(r.Context(), filter)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	resp := make([]AlertResponse, 0, len(items))
-	for _, a := range items {
-		resp = append(resp, *toAlertResponse(a))
+	resp := toAlertList(p, items, total)
+	if err := s.attachComments(r.Context(), resp.Items); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-
-	writeJSON(w, http.StatusOK, AlertListResponse{Items: resp, Total: total})
+	if err := s.attachHookRuns(r.Context(), resp.Items); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
@@ -208,14 +383,21 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		return
 	}
-	peer := clientIP(r)
+	peer := s.clientIP(r)
+	client := clientID(r)
 	log.Printf("ws connect: %s", peer)
 	defer func() {
 		log.Printf("ws disconnect: %s", peer)
 		conn.Close(websocket.StatusNormalClosure, "closing")
 	}()
 
-	s.broadcaster.Add(conn)
+	if client != "" {
+		if severities, _, err := s.store.ClientSeverities(r.Context(), client); err == nil {
+			s.broadcaster.SetFilter(client, severities)
+		}
+	}
+	delta, _ := strconv.ParseBool(r.URL.Query().Get("delta"))
+	s.broadcaster.Add(conn, client, delta)
 	defer s.broadcaster.Remove(conn)
 
 	ctx := r.Context()
@@ -227,108 +409,75 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Broadcast pushes an update to WebSocket clients. Clients whose severity
+// filter excludes the event or alert still get the container state, without
+// the event or alert itself.
+// Broadcast sends an update to WebSocket clients, debounced per container,
+// and to every OnUpdate hook right away.
 func (s *Server) Broadcast(ctx context.Context, update EventUpdate) {
+	for _, fn := range s.onUpdate {
+		fn(ctx, update)
+	}
+	s.debounce.push(ctx, update)
+}
+
+func (s *Server) send(ctx context.Context, update EventUpdate) {
 	payload, err := json.Marshal(update)
 	if err != nil {
 		return
 	}
-	s.broadcaster.Broadcast(ctx, payload)
-}
-
-type ContainerResponse struct {
-	ID                   int64              `json:"id"`
-	Name                 string             `json:"name"`
-	ContainerID          string             `json:"container_id"`
-	CurrentContainerName string             `json:"current_container_name"`
-	Image                string             `json:"image"`
-	ImageTag             string             `json:"image_tag"`
-	ImageID              string             `json:"image_id"`
-	CreatedAt            string             `json:"created_at"`
-	RegisteredAt         string             `json:"registered_at"`
-	StartedAt            string             `json:"started_at"`
-	FinishedAt           string             `json:"finished_at"`
-	ExitCode             *int               `json:"exit_code"`
-	Status               string             `json:"status"`
-	Role                 string             `json:"role"`
-	Caps                 []string           `json:"caps"`
-	ReadOnly             bool               `json:"read_only"`
-	NoNewPrivileges      bool               `json:"no_new_privileges"`
-	MemoryReservation    int64              `json:"memory_reservation"`
-	MemoryLimit          int64              `json:"memory_limit"`
-	User                 string             `json:"user"`
-	Present              bool               `json:"present"`
-	HealthStatus         string             `json:"health_status"`
-	HealthFailingStreak  int                `json:"health_failing_streak"`
-	UnhealthySince       string             `json:"unhealthy_since"`
-	RestartLoop          bool               `json:"restart_loop"`
-	RestartStreak        int                `json:"restart_streak"`
-	RestartLoopSince     string             `json:"restart_loop_since"`
-	Healthcheck          *store.Healthcheck `json:"healthcheck"`
-}
-
-type EventResponse struct {
-	ID                  int64  `json:"id"`
-	ContainerPK         int64  `json:"container_pk"`
-	Container           string `json:"container"`
-	ContainerID         string `json:"container_id"`
-	ParsedContainerName string `json:"parsed_container_name"`
-	Type                string `json:"type"`
-	Message             string `json:"message"`
-	Timestamp           string `json:"timestamp"`
-	OldImage            string `json:"old_image"`
-	NewImage            string `json:"new_image"`
-	OldImageID          string `json:"old_image_id"`
-	NewImageID          string `json:"new_image_id"`
-	Reason              string `json:"reason"`
-	DetailsJSON         string `json:"details"`
-	ExitCode            *int   `json:"exit_code"`
-}
-
-type EventListResponse struct {
-	Items []EventResponse `json:"items"`
-	Total int64           `json:"total"`
-}
-
-type AlertResponse struct {
-	ID                  int64  `json:"id"`
-	ContainerPK         int64  `json:"container_pk"`
-	Container           string `json:"container"`
-	ContainerID         string `json:"container_id"`
-	ParsedContainerName string `json:"parsed_container_name"`
-	Type                string `json:"type"`
-	Message             string `json:"message"`
-	Timestamp           string `json:"timestamp"`
-	OldImage            string `json:"old_image"`
-	NewImage            string `json:"new_image"`
-	OldImageID          string `json:"old_image_id"`
-	NewImageID          string `json:"new_image_id"`
-	Reason              string `json:"reason"`
-	DetailsJSON         string `json:"details"`
-	ExitCode            *int   `json:"exit_code"`
+	severity := ""
+	if update.Event != nil {
+		severity = update.Event.Severity
+	} else if update.Alert != nil {
+		severity = update.Alert.Severity
+	}
+	filtered := payload
+	if severity != "" {
+		stripped := update
+		stripped.Event = nil
+		stripped.Events = nil
+		stripped.Alert = nil
+		if filtered, err = json.Marshal(stripped); err != nil {
+			return
+		}
+	}
+	s.broadcaster.BroadcastFiltered(ctx, severity, payload, filtered)
 }
 
-type AlertListResponse struct {
-	Items []AlertResponse `json:"items"`
-	Total int64           `json:"total"`
+// OnUpdate registers fn to receive every update sent to WebSocket clients,
+// e.g. to forward them to an MQTT broker.
+func (s *Server) OnUpdate(fn func(context.Context, EventUpdate)) {
+	s.onUpdate = append(s.onUpdate, fn)
 }
 
-type EventUpdate struct {
-	Container           ContainerResponse `json:"container"`
-	Event               *EventResponse    `json:"event,omitempty"`
-	Alert               *AlertResponse    `json:"alert,omitempty"`
-	ContainerEventTotal *int64            `json:"container_event_total,omitempty"`
-	EventTotal          *int64            `json:"event_total,omitempty"`
-	AlertTotal          *int64            `json:"alert_total,omitempty"`
-}
+// The response types are defined in the client package, so Go programs
+// using the API decode exactly what the server encodes.
+type (
+	ContainerResponse = client.Container
+	Healthcheck       = client.Healthcheck
+	HealthProbe       = client.HealthProbe
+	NotesResponse     = client.Notes
+	EventResponse     = client.Event
+	EventListResponse = client.EventList
+	AlertResponse     = client.Alert
+	AlertListResponse = client.AlertList
+	CommentResponse   = client.Comment
+	HookRunResponse   = client.HookRun
+	AlertLogsResponse = client.AlertLogs
+	PageInfo          = client.PageInfo
+	EventUpdate       = client.Update
+)
 
 func toContainerResponse(c store.Container) ContainerResponse {
-	return ContainerResponse{
+	resp := ContainerResponse{
 		ID:                   c.ID,
 		Name:                 c.Name,
 		ContainerID:          c.ContainerID,
 		CurrentContainerName: c.CurrentContainerName,
 		Image:                c.Image,
 		ImageTag:             c.ImageTag,
+		ImageDigest:          c.ImageDigest,
 		ImageID:              c.ImageID,
 		CreatedAt:            c.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
 		RegisteredAt:         c.RegisteredAt.UTC().Format("2006-01-02T15:04:05Z"),
@@ -347,11 +496,30 @@ func toContainerResponse(c store.Container) ContainerResponse {
 		HealthStatus:         c.HealthStatus,
 		HealthFailingStreak:  c.HealthFailingStreak,
 		UnhealthySince:       c.UnhealthySince.UTC().Format("2006-01-02T15:04:05Z"),
+		HealthPending:        c.HealthPending(time.Now()),
+		LastSuccessAt:        formatMaybeTime(c.LastSuccessAt),
+		Platform:             c.Platform,
+		DependsOn:            c.DependsOn,
+		Networks:             c.Networks,
+		Labels:               c.Labels,
+		DisplayName:          c.DisplayName(),
+		Group:                c.Group(),
 		RestartLoop:          c.RestartLoop,
 		RestartStreak:        c.RestartStreak,
 		RestartLoopSince:     c.RestartLoopSince.UTC().Format("2006-01-02T15:04:05Z"),
-		Healthcheck:          c.Healthcheck,
+		Healthcheck:          (*Healthcheck)(c.Healthcheck),
+		LastHealthProbe:      (*HealthProbe)(c.LastHealthProbe),
 	}
+	if c.UnhealthyGrace > 0 {
+		resp.UnhealthyGrace = c.UnhealthyGrace.String()
+	}
+	if c.TaskMaxAge > 0 {
+		resp.TaskMaxAge = c.TaskMaxAge.String()
+	}
+	if !c.Present {
+		resp.RemovedAt = formatMaybeTime(c.UpdatedAt)
+	}
+	return resp
 }
 
 func formatMaybeTime(t time.Time) string {
@@ -369,6 +537,7 @@ func toEventResponse(e store.Event) *EventResponse {
 		ContainerID:         e.ContainerID,
 		ParsedContainerName: e.ParsedContainerName,
 		Type:                e.Type,
+		Severity:            e.Severity,
 		Message:             e.Message,
 		Timestamp:           e.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
 		OldImage:            e.OldImage,
@@ -389,6 +558,7 @@ func toAlertResponse(a store.Alert) *AlertResponse {
 		ContainerID:         a.ContainerID,
 		ParsedContainerName: a.ParsedContainerName,
 		Type:                a.Type,
+		Severity:            a.Severity,
 		Message:             a.Message,
 		Timestamp:           a.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
 		OldImage:            a.OldImage,
@@ -398,6 +568,8 @@ func toAlertResponse(a store.Alert) *AlertResponse {
 		Reason:              a.Reason,
 		DetailsJSON:         a.DetailsJSON,
 		ExitCode:            a.ExitCode,
+		IncidentID:          a.IncidentID,
+		AcknowledgedAt:      formatMaybeTime(a.AcknowledgedAt),
 	}
 }
 
@@ -421,6 +593,12 @@ func (s *statusRecorder) WriteHeader(code int) {
 	s.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the Flusher of streaming
+// responses such as exports.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
 func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hijacker, ok := s.ResponseWriter.(http.Hijacker)
 	if !ok {
@@ -438,17 +616,44 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func clientIP(r *http.Request) string {
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		parts := strings.Split(forwarded, ",")
-		return strings.TrimSpace(parts[0])
+// WithTrustedProxies sets the reverse proxies whose X-Forwarded-For and
+// X-Real-Ip headers are believed. Requests from anywhere else are
+// attributed to the address they come from.
+func (s *Server) WithTrustedProxies(prefixes []netip.Prefix) {
+	s.trustedProxies = prefixes
+}
+
+// clientIP returns the address a request comes from. Behind trusted proxies
+// it is the last X-Forwarded-For hop the proxies did not add themselves, so
+// a client cannot pick its own address by sending the header.
+func (s *Server) clientIP(r *http.Request) string {
+	peer := remoteHost(r.RemoteAddr)
+	if !trustsAddr(s.trustedProxies, peer) {
+		return peer
 	}
-	if real := r.Header.Get("X-Real-Ip"); real != "" {
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !trustsAddr(s.trustedProxies, hops[i]) || i == 0 {
+			return hops[i]
+		}
+	}
+	if real := strings.TrimSpace(r.Header.Get("X-Real-Ip")); real != "" {
 		return real
 	}
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err == nil {
-		return ip
+	return peer
+}
+
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
 	}
-	return r.RemoteAddr
+	return host
 }
@@ -0,0 +1,15 @@
+package api
+
+import "net/http"
+
+// standbyMiddleware rejects writes while this instance is on standby: the
+// active instance owns the database and the Docker side.
+func (s *Server) standbyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && s.currentMonitorStatus().Role == "standby" {
+			writeError(w, http.StatusServiceUnavailable, "this instance is on standby and read-only")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// Health score penalties. A container starts every window at 100 and loses
+// points for each restart, each OOM kill and each hour spent unhealthy.
+const (
+	restartPenalty       = 2.0
+	oomPenalty           = 10.0
+	unhealthyHourPenalty = 1.0
+)
+
+type StatsResponse struct {
+	Window     string                   `json:"window"`
+	Since      string                   `json:"since"`
+	Until      string                   `json:"until"`
+	Containers []ContainerStatsResponse `json:"containers"`
+}
+
+// ContainerStatsResponse compares a container's health score over the last
+// window with the window before it. A negative delta means it got worse.
+type ContainerStatsResponse struct {
+	Name          string      `json:"name"`
+	Score         float64     `json:"score"`
+	PreviousScore float64     `json:"previous_score"`
+	Delta         float64     `json:"delta"`
+	Current       StatsPeriod `json:"current"`
+	Previous      StatsPeriod `json:"previous"`
+}
+
+type StatsPeriod struct {
+	Restarts         int64 `json:"restarts"`
+	OOMs             int64 `json:"ooms"`
+	UnhealthySeconds int64 `json:"unhealthy_seconds"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	window := 7 * 24 * time.Hour
+	if v := r.URL.Query().Get("window"); v != "" {
+		var err error
+		if window, err = parseDurationParam(v); err != nil || window < time.Hour {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid window %q, expected a duration of at least 1h", v))
+			return
+		}
+	}
+
+	resp, err := s.containerStats(r.Context(), window)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// containerStats scores the present containers over the last window and
+// the window before it.
+func (s *Server) containerStats(ctx context.Context, window time.Duration) (StatsResponse, error) {
+	until := time.Now().UTC()
+	since := until.Add(-window)
+	current, err := s.store.ContainerStatsBetween(ctx, since, until)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	previous, err := s.store.ContainerStatsBetween(ctx, since.Add(-window), since)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	resp := StatsResponse{
+		Window:     window.String(),
+		Since:      since.Format("2006-01-02T15:04:05Z"),
+		Until:      until.Format("2006-01-02T15:04:05Z"),
+		Containers: []ContainerStatsResponse{},
+	}
+	for _, c := range s.store.ListContainers() {
+		item := ContainerStatsResponse{
+			Name:     c.Name,
+			Current:  toStatsPeriod(current[c.ID]),
+			Previous: toStatsPeriod(previous[c.ID]),
+		}
+		item.Score = healthScore(current[c.ID])
+		item.PreviousScore = healthScore(previous[c.ID])
+		item.Delta = math.Round((item.Score-item.PreviousScore)*10) / 10
+		resp.Containers = append(resp.Containers, item)
+	}
+	// Worst first, so the service most in need of attention leads the list.
+	sort.Slice(resp.Containers, func(i, j int) bool {
+		a, b := resp.Containers[i], resp.Containers[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.Delta != b.Delta {
+			return a.Delta < b.Delta
+		}
+		return a.Name < b.Name
+	})
+
+	return resp, nil
+}
+
+// healthScore maps a window's stats to 0-100, rounded to one decimal.
+func healthScore(st store.ContainerStats) float64 {
+	penalty := restartPenalty*float64(st.Restarts) +
+		oomPenalty*float64(st.OOMs) +
+		unhealthyHourPenalty*st.Unhealthy.Hours()
+	return math.Round(math.Max(0, 100-penalty)*10) / 10
+}
+
+func toStatsPeriod(st store.ContainerStats) StatsPeriod {
+	return StatsPeriod{
+		Restarts:         st.Restarts,
+		OOMs:             st.OOMs,
+		UnhealthySeconds: int64(st.Unhealthy / time.Second),
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// readyTimeout bounds the database write check of /readyz.
+const readyTimeout = 2 * time.Second
+
+// BuildInfo describes the running healthmon binary.
+type BuildInfo struct {
+	Version string
+	Commit  string
+}
+
+// MonitorStatus is the state of the Docker side of healthmon.
+type MonitorStatus struct {
+	// EventStreamConnected reports whether the Docker event stream is open.
+	EventStreamConnected bool
+	// LastSyncAt is when the containers were last synced with Docker.
+	LastSyncAt time.Time
+	// LastEventAt is when the last Docker event arrived.
+	LastEventAt time.Time
+	// DockerError is why Docker could not be reached while disconnected.
+	DockerError string
+	// DockerRetryAt is when healthmon tries to reach Docker again.
+	DockerRetryAt time.Time
+	// Role is "active" or "standby" with HM_HA_INSTANCE set, else empty.
+	Role string
+	// EngineVersion and EngineAPIVersion are the versions of the Docker
+	// Engine last connected to, empty before the first connect.
+	EngineVersion    string
+	EngineAPIVersion string
+}
+
+type ReadyResponse struct {
+	Ready  bool            `json:"ready"`
+	Checks map[string]bool `json:"checks"`
+	Errors []string        `json:"errors,omitempty"`
+}
+
+type StatusResponse struct {
+	Version              string `json:"version"`
+	Commit               string `json:"commit"`
+	GoVersion            string `json:"go_version"`
+	StartedAt            string `json:"started_at"`
+	UptimeSeconds        int64  `json:"uptime_seconds"`
+	EventStreamConnected bool   `json:"event_stream_connected"`
+	Degraded             bool   `json:"degraded"`
+	DockerError          string `json:"docker_error,omitempty"`
+	DockerRetryAt        string `json:"docker_retry_at,omitempty"`
+	LastSyncAt           string `json:"last_sync_at"`
+	LastEventAt          string `json:"last_event_at"`
+	Containers           int    `json:"containers"`
+	HARole               string `json:"ha_role,omitempty"`
+	DockerVersion        string `json:"docker_version,omitempty"`
+	DockerAPIVersion     string `json:"docker_api_version,omitempty"`
+	// Cache reports how the store's container cache served reads.
+	Cache store.CacheStats `json:"cache"`
+	// WebSocket counts the stream connections.
+	WebSocket BroadcasterStats `json:"websocket"`
+	// RateLimited counts the API requests turned away by the rate limit.
+	RateLimited int64 `json:"rate_limited"`
+}
+
+// WithStatus sets what /readyz and /api/status report about the build and the
+// monitor. Without it the monitor is reported as not ready.
+func (s *Server) WithStatus(build BuildInfo, monitor func() MonitorStatus) {
+	s.build = build
+	s.monitorStatus = monitor
+}
+
+func (s *Server) currentMonitorStatus() MonitorStatus {
+	if s.monitorStatus == nil {
+		return MonitorStatus{}
+	}
+	return s.monitorStatus()
+}
+
+// handleHealthz is the liveness probe: healthmon answers HTTP.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz is the readiness probe: the Docker event stream is connected,
+// the containers were synced and the database accepts writes.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	status := s.currentMonitorStatus()
+	resp := ReadyResponse{Ready: true, Checks: map[string]bool{
+		"docker_events": status.EventStreamConnected,
+		"synced":        !status.LastSyncAt.IsZero(),
+		"database":      true,
+	}}
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+	if err := s.store.CheckWritable(ctx); err != nil {
+		resp.Checks["database"] = false
+		resp.Errors = append(resp.Errors, "database: "+err.Error())
+	}
+	code := http.StatusOK
+	for _, ok := range resp.Checks {
+		if !ok {
+			resp.Ready = false
+			code = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, resp)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	status := s.currentMonitorStatus()
+	resp := StatusResponse{
+		Version:              s.build.Version,
+		Commit:               s.build.Commit,
+		GoVersion:            runtime.Version(),
+		StartedAt:            s.startedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		UptimeSeconds:        int64(time.Since(s.startedAt) / time.Second),
+		EventStreamConnected: status.EventStreamConnected,
+		Degraded:             !status.EventStreamConnected && status.Role != "standby",
+		DockerError:          status.DockerError,
+		LastSyncAt:           formatMaybeTime(status.LastSyncAt),
+		LastEventAt:          formatMaybeTime(status.LastEventAt),
+		Containers:           len(s.store.ListContainers()),
+		HARole:               status.Role,
+		DockerVersion:        status.EngineVersion,
+		DockerAPIVersion:     status.EngineAPIVersion,
+		Cache:                s.store.CacheStats(),
+	}
+	if s.broadcaster != nil {
+		resp.WebSocket = s.broadcaster.Stats()
+	}
+	if s.limiter != nil {
+		resp.RateLimited = s.limiter.Limited()
+	}
+	if !status.DockerRetryAt.IsZero() {
+		resp.DockerRetryAt = formatMaybeTime(status.DockerRetryAt)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestReadyzReflectsMonitorAndSkipsAuth(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	status := MonitorStatus{}
+	srv := NewServer(st, NewBroadcaster(), WSOptions{})
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"secret": ScopeAdmin}})
+	srv.WithStatus(BuildInfo{Version: "1.2.3", Commit: "abc123"}, func() MonitorStatus { return status })
+	handler := srv.Routes()
+
+	get := func(path string) (*httptest.ResponseRecorder, ReadyResponse) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp ReadyResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	if rec, _ := get("/healthz"); rec.Code != http.StatusOK {
+		t.Fatalf("healthz: expected 200 without a token, got %d", rec.Code)
+	}
+	rec, resp := get("/readyz")
+	if rec.Code != http.StatusServiceUnavailable || resp.Ready || resp.Checks["docker_events"] || !resp.Checks["database"] {
+		t.Fatalf("readyz before sync: expected 503 with docker_events down, got %d %+v", rec.Code, resp)
+	}
+
+	status = MonitorStatus{EventStreamConnected: true, LastSyncAt: time.Now()}
+	if rec, resp := get("/readyz"); rec.Code != http.StatusOK || !resp.Ready {
+		t.Fatalf("readyz after sync: expected 200, got %d %+v", rec.Code, resp)
+	}
+	if rec, _ := get("/api/status"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status: expected auth to apply, got %d", rec.Code)
+	}
+}
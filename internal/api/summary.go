@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// SummaryResponse carries everything a dashboard needs on first load.
+type SummaryResponse struct {
+	Containers           int                `json:"containers"`
+	ByStatus             map[string]int     `json:"by_status"`
+	ByHealth             map[string]int     `json:"by_health"`
+	RestartLoops         int                `json:"restart_loops"`
+	UnacknowledgedAlerts int64              `json:"unacknowledged_alerts"`
+	AlertsLast24h        int64              `json:"alerts_last_24h"`
+	LatestIncidents      []IncidentResponse `json:"latest_incidents"`
+}
+
+type IncidentResponse struct {
+	ID         int64  `json:"id"`
+	Container  string `json:"container"`
+	Type       string `json:"type"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	OpenedAt   string `json:"opened_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+	// DurationSeconds runs until now while the incident is open.
+	DurationSeconds int64   `json:"duration_seconds"`
+	AlertIDs        []int64 `json:"alert_ids,omitempty"`
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	ctx := r.Context()
+	items := s.store.ListContainers()
+	resp := SummaryResponse{
+		Containers:      len(items),
+		ByStatus:        make(map[string]int),
+		ByHealth:        make(map[string]int),
+		LatestIncidents: []IncidentResponse{},
+	}
+	present := make(map[string]bool, len(items))
+	for _, c := range items {
+		present[c.Name] = true
+		status := c.Status
+		if status == "" {
+			status = "unknown"
+		}
+		resp.ByStatus[status]++
+		health := strings.ToLower(c.HealthStatus)
+		if health == "" {
+			health = "none"
+		}
+		resp.ByHealth[health]++
+		if c.RestartLoop {
+			resp.RestartLoops++
+		}
+	}
+
+	var err error
+	if resp.UnacknowledgedAlerts, err = s.store.CountAlerts(ctx, store.Filter{Unacknowledged: true}); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	since := time.Now().UTC().Add(-24 * time.Hour)
+	if resp.AlertsLast24h, err = s.store.CountAlerts(ctx, store.Filter{Since: since}); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	incidents, err := s.store.LatestIncidents(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, inc := range incidents {
+		if !present[inc.Container] {
+			continue
+		}
+		resp.LatestIncidents = append(resp.LatestIncidents, toIncidentResponse(inc))
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAlertAck serves POST /api/alerts/{id}/ack.
+func (s *Server) handleAlertAck(w http.ResponseWriter, r *http.Request) {
+	idPart, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/alerts/"), "/ack")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if !ok || err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	found, err := s.store.AcknowledgeAlert(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toIncidentResponse(inc store.Incident) IncidentResponse {
+	end := inc.ResolvedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return IncidentResponse{
+		ID:              inc.ID,
+		Container:       inc.Container,
+		Type:            inc.Type,
+		Severity:        inc.Severity,
+		Message:         inc.Message,
+		OpenedAt:        inc.OpenedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		ResolvedAt:      formatMaybeTime(inc.ResolvedAt),
+		AlertIDs:        inc.AlertIDs,
+		DurationSeconds: int64(end.Sub(inc.OpenedAt) / time.Second),
+	}
+}
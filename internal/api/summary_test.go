@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestSummaryCountsUnacknowledgedAlerts(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, c := range []store.Container{
+		{Name: "web", ContainerID: "c-web", Status: "running", HealthStatus: "healthy", StartedAt: now},
+		{Name: "worker", ContainerID: "c-worker", Status: "restarting", RestartLoop: true, StartedAt: now},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	worker, _ := st.GetContainer("worker")
+	var alertIDs []int64
+	for _, ts := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour)} {
+		id, err := st.AddAlert(ctx, store.Alert{ContainerPK: worker.ID, Container: "worker", ContainerID: "c-worker", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", Timestamp: ts})
+		if err != nil {
+			t.Fatalf("add alert: %v", err)
+		}
+		alertIDs = append(alertIDs, id)
+	}
+	if _, err := st.AddIncident(ctx, store.Incident{ContainerPK: worker.ID, Container: "worker", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", OpenedAt: now}); err != nil {
+		t.Fatalf("add incident: %v", err)
+	}
+
+	handler := NewServer(st, NewBroadcaster(), WSOptions{}).Routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/alerts/"+strconv.FormatInt(alertIDs[0], 10)+"/ack", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("ack: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/alerts/999/ack", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("ack unknown: expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("summary: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp SummaryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if resp.Containers != 2 || resp.ByStatus["running"] != 1 || resp.ByStatus["restarting"] != 1 || resp.ByHealth["healthy"] != 1 || resp.ByHealth["none"] != 1 {
+		t.Fatalf("unexpected container counts: %+v", resp)
+	}
+	if resp.RestartLoops != 1 || resp.UnacknowledgedAlerts != 1 || resp.AlertsLast24h != 1 {
+		t.Fatalf("unexpected alert counts: %+v", resp)
+	}
+	if len(resp.LatestIncidents) != 1 || resp.LatestIncidents[0].Container != "worker" {
+		t.Fatalf("unexpected incidents: %+v", resp.LatestIncidents)
+	}
+}
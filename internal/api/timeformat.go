@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const apiTimeLayout = "2006-01-02T15:04:05Z"
+
+// apiTimestamp matches a JSON string holding a timestamp in apiTimeLayout.
+// The leading group keeps escaped quotes inside messages from matching.
+var apiTimestamp = regexp.MustCompile(`(^|[^\\])"(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z)"`)
+
+// timeFormatMiddleware rewrites the timestamps in JSON responses when the
+// request asks for ?time=unix (epoch seconds as numbers) or ?time=relative
+// ("5m ago"), so clients without a date parser can use them directly.
+// ?time=rfc3339 is the default. Unset timestamps become null.
+func timeFormatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("time")
+		switch format {
+		case "", "rfc3339":
+			next.ServeHTTP(w, r)
+			return
+		case "unix", "relative":
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid time %q, expected unix, rfc3339 or relative", format))
+			return
+		}
+		if r.URL.Path == "/api/events/stream" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		body := buf.body.Bytes()
+		if isJSON(buf.header.Get("Content-Type")) {
+			body = rewriteTimes(body, format, time.Now().UTC())
+		}
+		for key, values := range buf.header {
+			w.Header()[key] = values
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(body)
+	})
+}
+
+func rewriteTimes(body []byte, format string, now time.Time) []byte {
+	return apiTimestamp.ReplaceAllFunc(body, func(match []byte) []byte {
+		sub := apiTimestamp.FindSubmatch(match)
+		t, err := time.Parse(apiTimeLayout, string(sub[2]))
+		if err != nil {
+			return match
+		}
+		var out []byte
+		switch {
+		case t.Year() <= 1:
+			out = []byte("null")
+		case format == "unix":
+			out = strconv.AppendInt(nil, t.Unix(), 10)
+		default:
+			out = strconv.AppendQuote(nil, relativeTime(t, now))
+		}
+		return append(append([]byte{}, sub[1]...), out...)
+	})
+}
+
+// relativeTime renders t relative to now, e.g. "5m ago" or "in 2h0m".
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d >= time.Second:
+		return compactDuration(d) + " ago"
+	case d <= -time.Second:
+		return "in " + compactDuration(-d)
+	default:
+		return "now"
+	}
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+// bufferedResponse holds a response so it can be rewritten before sending.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
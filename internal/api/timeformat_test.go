@@ -0,0 +1,18 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRewriteTimes(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"ts":"2026-03-01T11:55:00Z","items":["2026-03-01T10:00:00Z","0001-01-01T00:00:00Z"],"message":"at \"2026-03-01T11:55:00Z\""}`)
+
+	if got, want := string(rewriteTimes(body, "unix", now)), `{"ts":1772366100,"items":[1772359200,null],"message":"at \"2026-03-01T11:55:00Z\""}`; got != want {
+		t.Fatalf("unix:\n got %s\nwant %s", got, want)
+	}
+	if got, want := string(rewriteTimes(body, "relative", now)), `{"ts":"5m ago","items":["2h0m ago",null],"message":"at \"2026-03-01T11:55:00Z\""}`; got != want {
+		t.Fatalf("relative:\n got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxTimelineBuckets keeps a mistyped bucket size from producing a response
+// with millions of empty buckets.
+const maxTimelineBuckets = 2000
+
+type TimelineResponse struct {
+	Bucket  string           `json:"bucket"`
+	Since   string           `json:"since"`
+	Until   string           `json:"until"`
+	Buckets []TimelineBucket `json:"buckets"`
+}
+
+// TimelineBucket holds event and alert counts per severity for the bucket
+// starting at Start. Every bucket in the window is present, empty or not.
+type TimelineBucket struct {
+	Start  string           `json:"start"`
+	Events map[string]int64 `json:"events"`
+	Alerts map[string]int64 `json:"alerts"`
+}
+
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	filter, _, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkSeverities(filter.Severities); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.applyClientDefaults(r, &filter); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	bucket := time.Hour
+	if v := q.Get("bucket"); v != "" {
+		if bucket, err = parseDurationParam(v); err != nil || bucket < time.Minute {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid bucket %q, expected a duration of at least 1m", v))
+			return
+		}
+	}
+	window := 7 * 24 * time.Hour
+	if v := q.Get("window"); v != "" {
+		if window, err = parseDurationParam(v); err != nil || window <= 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid window %q", v))
+			return
+		}
+	}
+
+	until := filter.Until
+	if until.IsZero() {
+		until = time.Now().UTC()
+	}
+	if filter.Since.IsZero() {
+		filter.Since = until.Add(-window)
+	}
+	filter.Until = until
+	// Buckets are aligned to the Unix epoch, like the store's grouping.
+	size := int64(bucket / time.Second)
+	first := time.Unix(filter.Since.Unix()/size*size, 0).UTC()
+	if count := until.Sub(first)/bucket + 1; count > maxTimelineBuckets {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("window spans %d buckets, at most %d allowed", count, maxTimelineBuckets))
+		return
+	}
+
+	events, err := s.store.EventTimeline(r.Context(), filter, bucket)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	alerts, err := s.store.AlertTimeline(r.Context(), filter, bucket)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := TimelineResponse{
+		Bucket:  bucket.String(),
+		Since:   filter.Since.UTC().Format("2006-01-02T15:04:05Z"),
+		Until:   until.UTC().Format("2006-01-02T15:04:05Z"),
+		Buckets: []TimelineBucket{},
+	}
+	index := make(map[int64]int)
+	for start := first; !start.After(until); start = start.Add(bucket) {
+		index[start.Unix()] = len(resp.Buckets)
+		resp.Buckets = append(resp.Buckets, TimelineBucket{
+			Start:  start.UTC().Format("2006-01-02T15:04:05Z"),
+			Events: map[string]int64{},
+			Alerts: map[string]int64{},
+		})
+	}
+	for _, c := range events {
+		if i, ok := index[c.Start.Unix()]; ok {
+			resp.Buckets[i].Events[c.Severity] += c.Count
+		}
+	}
+	for _, c := range alerts {
+		if i, ok := index[c.Start.Unix()]; ok {
+			resp.Buckets[i].Alerts[c.Severity] += c.Count
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
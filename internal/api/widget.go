@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+var widgetEmoji = map[stateLevel]string{
+	levelUnknown: "⚪",
+	levelOK:      "🟢",
+	levelWarn:    "🟡",
+	levelBad:     "🔴",
+}
+
+// WidgetResponse is deliberately tiny: it is polled by status bars, e-ink
+// displays and watches, often over slow or flaky links.
+type WidgetResponse struct {
+	Emoji string       `json:"emoji"`
+	OK    int          `json:"ok"`
+	Bad   int          `json:"bad"`
+	Items []WidgetItem `json:"items"`
+}
+
+type WidgetItem struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Emoji    string `json:"emoji"`
+	Duration string `json:"duration,omitempty"`
+}
+
+func (s *Server) handleWidget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	now := time.Now().UTC()
+	items := s.store.ListContainers()
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+
+	resp := WidgetResponse{Items: make([]WidgetItem, 0, len(items))}
+	worst := levelOK
+	for _, c := range items {
+		status, level := containerState(c)
+		if level == levelBad {
+			resp.Bad++
+		} else {
+			resp.OK++
+		}
+		if level > worst {
+			worst = level
+		}
+		resp.Items = append(resp.Items, WidgetItem{
+			Name:     c.Name,
+			Status:   status,
+			Emoji:    widgetEmoji[level],
+			Duration: compactDuration(now.Sub(stateSince(c))),
+		})
+	}
+	resp.Emoji = widgetEmoji[worst]
+
+	w.Header().Set("Cache-Control", "no-cache")
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// stateSince returns when the container entered the state containerState
+// reports for it.
+func stateSince(c store.Container) time.Time {
+	switch {
+	case c.RestartLoop && !c.RestartLoopSince.IsZero():
+		return c.RestartLoopSince
+	case !c.UnhealthySince.IsZero():
+		return c.UnhealthySince
+	case c.Status == "running":
+		return c.StartedAt
+	case !c.FinishedAt.IsZero():
+		return c.FinishedAt
+	default:
+		return c.UpdatedAt
+	}
+}
+
+// compactDuration renders a duration with its two largest units, e.g. "3d4h"
+// or "12m".
+func compactDuration(d time.Duration) string {
+	if d <= 0 || d > 100*365*24*time.Hour {
+		return ""
+	}
+	days := int(d / (24 * time.Hour))
+	hours := int(d/time.Hour) % 24
+	minutes := int(d/time.Minute) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+// newStatusTestServer serves a store with containers in every state the
+// badges and the widget tell apart.
+func newStatusTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { dbConn.Close() })
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	t.Cleanup(st.Close)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	media := map[string]string{store.GroupLabel: "media"}
+	for _, c := range []store.Container{
+		{Name: "web", Status: "running", HealthStatus: "healthy", StartedAt: now.Add(-time.Hour)},
+		{Name: "jellyfin", Status: "running", HealthStatus: "starting", Labels: media},
+		{Name: "sonarr", Status: "running", HealthStatus: "unhealthy", UnhealthySince: now.Add(-5 * time.Minute), Labels: media},
+		{Name: "radarr", Status: "running", Labels: media},
+		{Name: "worker", Status: "restarting", RestartLoop: true},
+		{Name: "old", Status: "exited"},
+		{Name: "mail", Status: "running", Labels: map[string]string{store.GroupLabel: "mail"}},
+	} {
+		c.ContainerID = "c-" + c.Name
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	if err := st.SetContainerPresent(ctx, "old", false); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	return NewServer(st, NewBroadcaster(), WSOptions{})
+}
+
+func TestWidgetSummarizesContainers(t *testing.T) {
+	srv := newStatusTestServer(t)
+	handler := srv.Routes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/widget", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Fatalf("expected Cache-Control no-cache, got %q", got)
+	}
+	var resp WidgetResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// The removed container is left out; sonarr and worker are bad.
+	if resp.Emoji != widgetEmoji[levelBad] || resp.OK != 4 || resp.Bad != 2 || len(resp.Items) != 6 {
+		t.Fatalf("unexpected summary %+v", resp)
+	}
+	want := map[string]string{
+		"jellyfin": widgetEmoji[levelWarn],
+		"mail":     widgetEmoji[levelOK],
+		"radarr":   widgetEmoji[levelOK],
+		"sonarr":   widgetEmoji[levelBad],
+		"web":      widgetEmoji[levelOK],
+		"worker":   widgetEmoji[levelBad],
+	}
+	for _, item := range resp.Items {
+		if want[item.Name] != item.Emoji {
+			t.Errorf("%s: expected %s, got %+v", item.Name, want[item.Name], item)
+		}
+		if item.Name == "web" && item.Duration != "1h0m" {
+			t.Errorf("web: expected the time since start, got %q", item.Duration)
+		}
+	}
+
+	srv.WithAuth(AuthOptions{Tokens: map[string]TokenScope{"watch": ScopeRead}})
+	handler = srv.Routes()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/widget", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the widget to need a token, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/widget?token=watch", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a read token to get the widget, got %d", rec.Code)
+	}
+}
+
+func TestCompactDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                           "",
+		45 * time.Second:            "45s",
+		12 * time.Minute:            "12m",
+		3*time.Hour + 5*time.Minute: "3h5m",
+		76 * time.Hour:              "3d4h",
+	} {
+		if got := compactDuration(d); got != want {
+			t.Errorf("compactDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
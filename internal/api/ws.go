@@ -2,44 +2,246 @@ package api
 
 import (
 	"context"
+	"errors"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"nhooyr.io/websocket"
 )
 
+const (
+	// wsQueueSize is how many messages wait for a slow connection. When its
+	// queue is full the oldest message is dropped; a connection that misses
+	// a whole queue's worth since its last successful write is closed.
+	wsQueueSize = 64
+	// wsWriteTimeout is how long a single write may take before the
+	// connection is closed as stalled.
+	wsWriteTimeout = 5 * time.Second
+	// wsPingInterval is how often connections are pinged, and wsPongTimeout
+	// how long the pong may take. A connection that misses it is closed, so
+	// clients that vanished behind a NAT do not linger until a write fails.
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 10 * time.Second
+)
+
+// Broadcaster sends updates to the WebSocket connections. Every connection
+// has its own queue and writer goroutine, so a stalled client only falls
+// behind itself.
 type Broadcaster struct {
-	mu    sync.Mutex
-	conns map[*websocket.Conn]struct{}
+	mu sync.Mutex
+	// conns maps each connection to its subscriber.
+	conns         map[*websocket.Conn]*subscriber
+	filters       map[string]map[string]bool
+	writeTimeout  time.Duration
+	pingInterval  time.Duration
+	pongTimeout   time.Duration
+	snapshotEvery time.Duration
+
+	pingTimeouts    atomic.Int64
+	slowDisconnects atomic.Int64
+}
+
+// BroadcasterStats counts the WebSocket connections and those closed for
+// missing a pong or being too slow since startup.
+type BroadcasterStats struct {
+	Connections     int   `json:"connections"`
+	PingTimeouts    int64 `json:"ping_timeouts"`
+	SlowDisconnects int64 `json:"slow_disconnects"`
+}
+
+// subscriber is a connection, the client id it identified as, if any, and
+// the messages waiting to be written to it. With delta set, container
+// states are sent as changes against sent.
+type subscriber struct {
+	conn   *websocket.Conn
+	client string
+	delta  bool
+	sent   map[string]*sentContainer
+	wake   chan struct{}
+	done   chan struct{}
+
+	mu      sync.Mutex
+	queue   [][]byte
+	dropped int
 }
 
 func NewBroadcaster() *Broadcaster {
-	return &Broadcaster{conns: make(map[*websocket.Conn]struct{})}
+	return &Broadcaster{
+		conns:         make(map[*websocket.Conn]*subscriber),
+		filters:       make(map[string]map[string]bool),
+		writeTimeout:  wsWriteTimeout,
+		pingInterval:  wsPingInterval,
+		pongTimeout:   wsPongTimeout,
+		snapshotEvery: wsSnapshotInterval,
+	}
 }
 
-func (b *Broadcaster) Add(conn *websocket.Conn) {
+// Stats returns the current connection count and disconnect counters.
+func (b *Broadcaster) Stats() BroadcasterStats {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.conns[conn] = struct{}{}
+	return BroadcasterStats{
+		Connections:     len(b.conns),
+		PingTimeouts:    b.pingTimeouts.Load(),
+		SlowDisconnects: b.slowDisconnects.Load(),
+	}
+}
+
+// Add starts sending broadcasts to conn until Remove. With delta, updates
+// carry only the container fields that changed.
+func (b *Broadcaster) Add(conn *websocket.Conn, client string, delta bool) {
+	sub := &subscriber{conn: conn, client: client, delta: delta, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	b.mu.Lock()
+	b.conns[conn] = sub
+	b.mu.Unlock()
+	go b.write(sub)
+	go b.keepalive(sub)
 }
 
 func (b *Broadcaster) Remove(conn *websocket.Conn) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	delete(b.conns, conn)
+	if sub, ok := b.conns[conn]; ok {
+		close(sub.done)
+		delete(b.conns, conn)
+	}
+}
+
+// SetFilter limits the events and alerts sent to a client's connections to
+// the given severities. An empty list lets everything through.
+func (b *Broadcaster) SetFilter(client string, severities []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(severities) == 0 {
+		delete(b.filters, client)
+		return
+	}
+	allowed := make(map[string]bool, len(severities))
+	for _, severity := range severities {
+		allowed[severity] = true
+	}
+	b.filters[client] = allowed
 }
 
 func (b *Broadcaster) Broadcast(ctx context.Context, payload []byte) {
+	b.BroadcastFiltered(ctx, "", payload, payload)
+}
+
+// BroadcastFiltered queues payload for every connection whose client
+// accepts severity and filtered for the rest; a nil filtered payload is not
+// sent. It never waits for a connection.
+func (b *Broadcaster) BroadcastFiltered(ctx context.Context, severity string, payload, filtered []byte) {
 	b.mu.Lock()
-	conns := make([]*websocket.Conn, 0, len(b.conns))
-	for conn := range b.conns {
-		conns = append(conns, conn)
+	defer b.mu.Unlock()
+	for _, sub := range b.conns {
+		msg := payload
+		if allowed, ok := b.filters[sub.client]; ok && severity != "" && !allowed[severity] {
+			msg = filtered
+		}
+		if msg != nil {
+			sub.push(msg)
+		}
 	}
-	b.mu.Unlock()
+}
+
+// push queues msg, dropping the oldest message when the queue is full.
+func (s *subscriber) push(msg []byte) {
+	s.mu.Lock()
+	if len(s.queue) >= wsQueueSize {
+		s.queue = s.queue[1:]
+		s.dropped++
+	}
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next queued message and how many were dropped since the
+// last successful write.
+func (s *subscriber) pop() ([]byte, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil, s.dropped, false
+	}
+	msg := s.queue[0]
+	s.queue = s.queue[1:]
+	return msg, s.dropped, true
+}
+
+// write drains a subscriber's queue until it is removed. A connection that
+// stalls on a write or falls a whole queue behind is closed, which ends its
+// stream handler and removes it.
+func (b *Broadcaster) write(sub *subscriber) {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case <-sub.wake:
+		}
+		for {
+			msg, dropped, ok := sub.pop()
+			if dropped >= wsQueueSize {
+				log.Printf("ws client too slow, %d messages dropped, disconnecting", dropped)
+				b.slowDisconnects.Add(1)
+				sub.conn.Close(websocket.StatusPolicyViolation, "too slow")
+				return
+			}
+			if !ok {
+				break
+			}
+			if sub.delta {
+				msg = sub.deltaPayload(msg, time.Now(), b.snapshotEvery)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), b.writeTimeout)
+			err := sub.conn.Write(ctx, websocket.MessageText, msg)
+			cancel()
+			if err != nil {
+				// A timed out write already closed the connection.
+				if errors.Is(err, context.DeadlineExceeded) {
+					b.slowDisconnects.Add(1)
+				}
+				sub.conn.Close(websocket.StatusPolicyViolation, "write failed")
+				return
+			}
+			sub.mu.Lock()
+			sub.dropped -= dropped
+			sub.mu.Unlock()
+		}
+	}
+}
 
-	for _, conn := range conns {
-		writeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-		_ = conn.Write(writeCtx, websocket.MessageText, payload)
+// keepalive pings a subscriber until it is removed and closes its
+// connection when a pong does not arrive in time. Pongs are read by the
+// stream handler's read loop.
+func (b *Broadcaster) keepalive(sub *subscriber) {
+	ticker := time.NewTicker(b.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sub.done:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), b.pongTimeout)
+		err := sub.conn.Ping(ctx)
 		cancel()
+		if err != nil {
+			select {
+			case <-sub.done:
+				return
+			default:
+			}
+			log.Printf("ws client missed a pong, disconnecting: %v", err)
+			b.pingTimeouts.Add(1)
+			// No close handshake: the peer is not answering.
+			sub.conn.CloseNow()
+			return
+		}
 	}
 }
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+func TestStalledClientDoesNotHoldUpBroadcasts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b := NewBroadcaster()
+	b.writeTimeout = 200 * time.Millisecond
+	srv := httptest.NewServer(NewServer(nil, b, WSOptions{}).Routes())
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/events/stream"
+
+	stalled, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer stalled.CloseNow()
+	fast, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer fast.CloseNow()
+	fast.SetReadLimit(1 << 20)
+	waitForConns(t, b, 2)
+
+	// Big enough to fill the socket buffers of the client that never reads.
+	const messages = 40
+	body := strings.Repeat("x", 512<<10)
+	started := time.Now()
+	for i := 0; i < messages; i++ {
+		b.Broadcast(ctx, []byte(fmt.Sprintf(`{"n":%d,"body":"%s"}`, i, body)))
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("expected broadcasting not to wait for clients, took %v", elapsed)
+	}
+
+	for i := 0; i < messages; i++ {
+		_, msg, err := fast.Read(ctx)
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if want := fmt.Sprintf(`{"n":%d,`, i); !strings.HasPrefix(string(msg), want) {
+			t.Fatalf("expected message %d in order, got %.20s", i, msg)
+		}
+	}
+	waitForConns(t, b, 1)
+}
+
+func TestSubscriberQueueDropsOldest(t *testing.T) {
+	sub := &subscriber{wake: make(chan struct{}, 1)}
+	for i := 0; i < wsQueueSize+3; i++ {
+		sub.push([]byte(fmt.Sprint(i)))
+	}
+	msg, dropped, ok := sub.pop()
+	if !ok || string(msg) != "3" || dropped != 3 || len(sub.queue) != wsQueueSize-1 {
+		t.Fatalf("expected the three oldest dropped, got %q after %d dropped", msg, dropped)
+	}
+}
+
+func waitForConns(t *testing.T, b *Broadcaster, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.mu.Lock()
+		n := len(b.conns)
+		b.mu.Unlock()
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d connections, have %d", want, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnresponsiveClientIsDisconnectedByPing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b := NewBroadcaster()
+	b.pingInterval = 50 * time.Millisecond
+	b.pongTimeout = 100 * time.Millisecond
+	srv := httptest.NewServer(NewServer(nil, b, WSOptions{}).Routes())
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/events/stream"
+
+	// Pongs are only sent while reading, so this one never answers.
+	silent, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer silent.CloseNow()
+	alive, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer alive.CloseNow()
+	go func() {
+		for {
+			if _, _, err := alive.Read(ctx); err != nil {
+				return
+			}
+		}
+	}()
+
+	waitForConns(t, b, 1)
+	time.Sleep(300 * time.Millisecond)
+	if stats := b.Stats(); stats.Connections != 1 || stats.PingTimeouts != 1 {
+		t.Fatalf("expected the silent client dropped and the other kept, got %+v", stats)
+	}
+}
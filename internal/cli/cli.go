@@ -0,0 +1,289 @@
+// Package cli implements the healthmon subcommands that query a running
+// instance through its HTTP API, e.g. `healthmon alerts --since 1h` over
+// SSH, and render the result as a table or JSON.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"healthmon/internal/api"
+)
+
+// Commands are the subcommands Run understands.
+var Commands = []string{"containers", "alerts", "events"}
+
+// Options says which instance to query and where to print.
+type Options struct {
+	// URL is the base address of the instance, e.g. http://localhost:8080.
+	URL string
+	// Token is sent as a bearer token when the instance requires one.
+	Token  string
+	Out    io.Writer
+	Client *http.Client
+}
+
+// ErrUsage is returned for invalid arguments, after the usage was printed.
+var ErrUsage = errors.New("invalid arguments")
+
+// maxMessage bounds the messages in tables so a row fits a terminal line.
+const maxMessage = 80
+
+// Run runs one subcommand with its arguments.
+func Run(ctx context.Context, opts Options, command string, args []string) error {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	switch command {
+	case "containers":
+		return runContainers(ctx, opts, args)
+	case "alerts":
+		return runAlerts(ctx, opts, args)
+	case "events":
+		return runEvents(ctx, opts, args)
+	default:
+		return fmt.Errorf("unknown command %q, expected one of %s", command, strings.Join(Commands, ", "))
+	}
+}
+
+// commonFlags are the flags of every subcommand.
+type commonFlags struct {
+	url   *string
+	token *string
+	json  *bool
+}
+
+func newFlagSet(name string, opts Options, usage string) (*flag.FlagSet, commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(opts.Out)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: healthmon %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs, commonFlags{
+		url:   fs.String("url", opts.URL, "address of the healthmon instance (HM_CLI_URL)"),
+		token: fs.String("token", opts.Token, "API token (HM_CLI_TOKEN)"),
+		json:  fs.Bool("json", false, "print the raw JSON response"),
+	}
+}
+
+// parse parses flags placed before and after the positional arguments, so
+// `healthmon events web --limit 5` works like `healthmon events --limit 5 web`.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		// The flag set has printed the error and usage already.
+		if err := fs.Parse(args); err != nil {
+			return nil, ErrUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func runContainers(ctx context.Context, opts Options, args []string) error {
+	fs, common := newFlagSet("containers", opts, "containers [--group name] [--json]")
+	group := fs.String("group", "", "only list the containers of this group")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+	query := url.Values{}
+	if *group != "" {
+		query.Set("group", *group)
+	}
+	var containers []api.ContainerResponse
+	if err := get(ctx, opts, common, "/api/containers", query, &containers); err != nil || *common.json {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(opts.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tHEALTH\tRESTARTS\tALERTS\tSTARTED\tIMAGE")
+	for _, c := range containers {
+		status := c.Status
+		if !c.Present {
+			status = "removed"
+		}
+		restarts := strconv.Itoa(c.RestartStreak)
+		if c.RestartLoop {
+			restarts += " (loop)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", c.Name, status, dash(c.HealthStatus), restarts, c.AlertCount, dash(c.StartedAt), c.Image)
+	}
+	return tw.Flush()
+}
+
+func runAlerts(ctx context.Context, opts Options, args []string) error {
+	fs, common := newFlagSet("alerts", opts, "alerts [--since 1h] [--container name] [--severity red] [--unacked] [--limit n] [--json]")
+	since := fs.String("since", "", "only alerts newer than this duration (e.g. 1h, 7d) or RFC3339 time")
+	container := fs.String("container", "", "only alerts of these containers, comma-separated")
+	severity := fs.String("severity", "", "only alerts of these severities, comma-separated")
+	unacked := fs.Bool("unacked", false, "hide acknowledged alerts")
+	limit := fs.Int("limit", 50, "maximum number of alerts")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		fs.Usage()
+		return ErrUsage
+	}
+	query := listQuery(*since, *limit)
+	setIf(query, "container", *container)
+	setIf(query, "severity", *severity)
+	if *unacked {
+		query.Set("unacknowledged", "true")
+	}
+	var alerts api.AlertListResponse
+	if err := get(ctx, opts, common, "/api/alerts", query, &alerts); err != nil || *common.json {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(opts.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIME\tSEVERITY\tCONTAINER\tTYPE\tMESSAGE")
+	for _, a := range alerts.Items {
+		severity := a.Severity
+		if a.AcknowledgedAt != "" {
+			severity += " (ack)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", a.ID, a.Timestamp, severity, a.Container, a.Type, oneLine(a.Message))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return footer(opts.Out, len(alerts.Items), alerts.Total, "alerts")
+}
+
+func runEvents(ctx context.Context, opts Options, args []string) error {
+	fs, common := newFlagSet("events", opts, "events <container> [--since 1h] [--limit n] [--json]")
+	since := fs.String("since", "", "only events newer than this duration (e.g. 1h, 7d) or RFC3339 time")
+	limit := fs.Int("limit", 50, "maximum number of events")
+	positional, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return ErrUsage
+	}
+	query := listQuery(*since, *limit)
+	query.Set("container", positional[0])
+	var events api.EventListResponse
+	if err := get(ctx, opts, common, "/api/events", query, &events); err != nil || *common.json {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(opts.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIME\tSEVERITY\tTYPE\tREASON\tMESSAGE")
+	for _, e := range events.Items {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.Timestamp, e.Severity, e.Type, dash(e.Reason), oneLine(e.Message))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return footer(opts.Out, len(events.Items), events.Total, "events")
+}
+
+func listQuery(since string, limit int) url.Values {
+	query := url.Values{}
+	setIf(query, "since", since)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return query
+}
+
+func setIf(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// get fetches path and decodes it into out. With --json the response is
+// copied to the output instead. Tables ask for relative timestamps, which
+// read better in a terminal than RFC3339.
+func get(ctx context.Context, opts Options, common commonFlags, path string, query url.Values, out any) error {
+	base := strings.TrimRight(*common.url, "/")
+	if base == "" {
+		return errors.New("no healthmon address, set HM_CLI_URL or --url")
+	}
+	if !*common.json {
+		query.Set("time", "relative")
+	}
+	target := base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if *common.token != "" {
+		req.Header.Set("Authorization", "Bearer "+*common.token)
+	}
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		if body.Error != "" {
+			return fmt.Errorf("%s: %s", resp.Status, body.Error)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if *common.json {
+		_, err := io.Copy(opts.Out, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func footer(w io.Writer, shown int, total int64, what string) error {
+	if int64(shown) >= total {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%d of %d %s, raise --limit to see more\n", shown, total, what)
+	return err
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// oneLine keeps the first line of a message, cut to maxMessage runes.
+func oneLine(s string) string {
+	s, _, cut := strings.Cut(s, "\n")
+	if r := []rune(s); len(r) > maxMessage {
+		s, cut = string(r[:maxMessage-1]), true
+	}
+	if cut {
+		s += "…"
+	}
+	return s
+}
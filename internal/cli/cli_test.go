@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestCommandsQueryTheAPI(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, c := range []store.Container{
+		{Name: "web", ContainerID: "c-web", Image: "nginx:1.27", Status: "running", HealthStatus: "healthy", Present: true, StartedAt: now},
+		{Name: "worker", ContainerID: "c-worker", Image: "worker:2", Status: "restarting", Present: true, RestartLoop: true, RestartStreak: 4, StartedAt: now},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert %s: %v", c.Name, err)
+		}
+	}
+	worker, _ := st.GetContainer("worker")
+	for _, a := range []store.Alert{
+		{Type: "restart_loop", Severity: "red", Message: "Restart loop detected", Timestamp: now.Add(-30 * time.Minute)},
+		{Type: "unhealthy", Severity: "yellow", Message: "Container unhealthy\nsecond line", Timestamp: now.Add(-3 * time.Hour)},
+	} {
+		a.ContainerPK, a.Container, a.ContainerID = worker.ID, "worker", "c-worker"
+		if _, err := st.AddAlert(ctx, a); err != nil {
+			t.Fatalf("add alert: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: worker.ID, Container: "worker", ContainerID: "c-worker", Type: "restart", Severity: "blue", Message: "Restart event", Reason: "exit 1", Timestamp: now.Add(-time.Duration(i) * time.Minute)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	server := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	server.WithAuth(api.AuthOptions{Tokens: map[string]api.TokenScope{"secret": api.ScopeRead}})
+	httpServer := httptest.NewServer(server.Routes())
+	defer httpServer.Close()
+
+	run := func(token string, command string, args ...string) (string, error) {
+		var out bytes.Buffer
+		err := Run(ctx, Options{URL: httpServer.URL, Token: token, Out: &out}, command, args)
+		return out.String(), err
+	}
+
+	out, err := run("secret", "containers")
+	if err != nil {
+		t.Fatalf("containers: %v", err)
+	}
+	if !strings.Contains(out, "NAME") || !strings.Contains(out, "4 (loop)") || !strings.Contains(out, "nginx:1.27") {
+		t.Fatalf("unexpected containers table:\n%s", out)
+	}
+
+	out, err = run("secret", "alerts", "--since", "1h")
+	if err != nil {
+		t.Fatalf("alerts: %v", err)
+	}
+	if !strings.Contains(out, "restart_loop") || strings.Contains(out, "unhealthy") || !strings.Contains(out, "ago") {
+		t.Fatalf("expected only the last hour's alert with a relative time:\n%s", out)
+	}
+
+	// Flags may follow the container name.
+	out, err = run("secret", "events", "worker", "--limit", "2")
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if strings.Count(out, "Restart event") != 2 || !strings.Contains(out, "2 of 3 events") {
+		t.Fatalf("unexpected events table:\n%s", out)
+	}
+
+	out, err = run("secret", "alerts", "--json")
+	if err != nil {
+		t.Fatalf("alerts --json: %v", err)
+	}
+	var alerts api.AlertListResponse
+	if err := json.Unmarshal([]byte(out), &alerts); err != nil || alerts.Total != 2 {
+		t.Fatalf("expected the raw alert list, got %q (%v)", out, err)
+	}
+
+	if _, err := run("", "containers"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected a missing token to be rejected, got %v", err)
+	}
+	if _, err := run("secret", "events"); err != ErrUsage {
+		t.Fatalf("expected a usage error without a container, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeTickerFiresWhenAdvancedPastPeriod(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+	ticker := fake.NewTicker(30 * time.Second)
+
+	fake.Advance(29 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatalf("ticker fired before its period elapsed")
+	default:
+	}
+
+	fake.Advance(time.Second)
+	select {
+	case got := <-ticker.C():
+		if !got.Equal(start.Add(30 * time.Second)) {
+			t.Fatalf("unexpected tick time %s", got)
+		}
+	default:
+		t.Fatalf("expected ticker to fire")
+	}
+
+	// Ticks the reader missed are dropped rather than queued.
+	fake.Advance(5 * time.Minute)
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Fatalf("expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	fake.Advance(time.Minute)
+	select {
+	case <-ticker.C():
+		t.Fatalf("stopped ticker fired")
+	default:
+	}
+	if !fake.Now().Equal(start.Add(30*time.Second + 6*time.Minute)) {
+		t.Fatalf("unexpected fake time %s", fake.Now())
+	}
+}
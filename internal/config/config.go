@@ -7,16 +7,107 @@ import (
 )
 
 type Config struct {
-	DBPath               string
-	DockerHost           string
-	HTTPAddr             string
-	TelegramEnabled      bool
-	TelegramToken        string
-	TelegramChatID       string
-	RestartWindowSeconds int
-	RestartThreshold     int
-	WSOriginPatterns     []string
-	WSInsecureSkipVerify bool
+	DBPath                string
+	DBDSN                 string
+	DockerHost            string
+	HTTPAddr              string
+	GRPCAddr              string
+	TelegramEnabled       bool
+	TelegramToken         string
+	TelegramChatID        string
+	QuietHours            string
+	QuietHoursPassRed     bool
+	TelegramCommands      bool
+	TelegramAllowedChats  []string
+	TelegramFormat        string
+	PublicURL             string
+	GrafanaURL            string
+	GrafanaToken          string
+	GrafanaDashboardUID   string
+	GrafanaTags           []string
+	AppriseURL            string
+	AppriseTag            string
+	NotifyURLs            []string
+	AlertSeverities       string
+	AlertRules            string
+	AlertHooks            string
+	HookTimeoutSeconds    int
+	NotifyRetryHours      int
+	MQTTURL               string
+	MQTTTopicPrefix       string
+	MQTTClientID          string
+	RestartWindowSeconds  int
+	RestartThreshold      int
+	StormContainers       int
+	StormWindowSeconds    int
+	UnhealthyGraceSeconds int
+	TaskMaxAgeSeconds     int
+	WSOriginPatterns      []string
+	WSInsecureSkipVerify  bool
+	WSDebounceMillis      int
+	APITokens             map[string]string
+	APIRateLimit          int
+	APIRateBurst          int
+	APIMaxBodyKB          int
+	APIMaxHeaderKB        int
+	AuthProxyHeader       string
+	AuthProxyGroupsHeader string
+	TrustedProxies        []string
+	AuthUsers             map[string]string
+	AuthGroups            map[string]string
+	AuthDefaultRole       string
+	OIDCIssuer            string
+	OIDCClientID          string
+	OIDCClientSecret      string
+	OIDCRedirectURL       string
+	OIDCScopes            []string
+	OIDCUserClaim         string
+	OIDCGroupsClaim       string
+	SessionSecret         string
+	SessionHours          int
+	ResyncIntervalSeconds int
+	BackupDir             string
+	BackupKeep            int
+	DBMaintenanceAt       string
+	RemovedRetentionDays  int
+	StatsIntervalSeconds  int
+	MemoryPressurePercent int
+	MemoryPressureSamples int
+	PIDsPressurePercent   int
+	FDsPressurePercent    int
+	ProcDir               string
+	ClockCheckSeconds     int
+	ClockDriftSeconds     int
+	NTPCheck              bool
+	StuckStateSeconds     int
+	StuckStates           []string
+	PausedAlertSeconds    int
+	ExpectedContainers    []string
+	ExpectedGraceSeconds  int
+	AutohealAfterSeconds  int
+	AutohealMaxAttempts   int
+	ReadinessRegressPct   int
+	ImageReportHours      int
+	AnomalyDays           int
+	AnomalyZScore         int
+	AnomalyMinEvents      int
+	ImagePrune            bool
+	LatestAlertGroups     []string
+	ChangelogLookup       bool
+	ErrorReportURL        string
+	ServiceLabels         []string
+	LabelAllowlist        []string
+	IgnoreContainers      []string
+	OnlyContainers        []string
+	HAInstance            string
+	RestartLoopLogLines   int
+	HALeaseSeconds        int
+	SystemdUnits          []string
+	SystemdBus            string
+	SystemdPollSeconds    int
+	CLIURL                string
+	CLIToken              string
+	RecordDir             string
 }
 
 func Load() Config {
@@ -25,16 +116,107 @@ func Load() Config {
 		origins = defaultWSOriginPatterns()
 	}
 	return Config{
-		DBPath:               getEnv("HM_DB_PATH", "./healthmon.db"),
-		DockerHost:           getEnv("HM_DOCKER_HOST", "unix:///var/run/docker.sock"),
-		HTTPAddr:             getEnv("HM_HTTP_ADDR", ":8080"),
-		TelegramEnabled:      getEnvBool("HM_TG_ENABLED", false),
-		TelegramToken:        os.Getenv("HM_TG_TOKEN"),
-		TelegramChatID:       os.Getenv("HM_TG_CHAT_ID"),
-		RestartWindowSeconds: getEnvInt("HM_RESTART_WINDOW_SECONDS", 300),
-		RestartThreshold:     getEnvInt("HM_RESTART_THRESHOLD", 3),
-		WSOriginPatterns:     origins,
-		WSInsecureSkipVerify: getEnvBool("HM_WS_INSECURE_SKIP_VERIFY", false),
+		DBPath:                getEnv("HM_DB_PATH", "./healthmon.db"),
+		DBDSN:                 os.Getenv("HM_DB_DSN"),
+		DockerHost:            getEnv("HM_DOCKER_HOST", "unix:///var/run/docker.sock"),
+		HTTPAddr:              getEnv("HM_HTTP_ADDR", ":8080"),
+		GRPCAddr:              os.Getenv("HM_GRPC_ADDR"),
+		TelegramEnabled:       getEnvBool("HM_TG_ENABLED", false),
+		TelegramToken:         os.Getenv("HM_TG_TOKEN"),
+		TelegramChatID:        os.Getenv("HM_TG_CHAT_ID"),
+		QuietHours:            getEnv("HM_QUIET_HOURS", ""),
+		QuietHoursPassRed:     getEnvBool("HM_QUIET_HOURS_RED", true),
+		TelegramCommands:      getEnvBool("HM_TG_COMMANDS", false),
+		TelegramAllowedChats:  parseCSV(getEnv("HM_TG_ALLOWED_CHATS", os.Getenv("HM_TG_CHAT_ID"))),
+		TelegramFormat:        os.Getenv("HM_TG_FORMAT"),
+		PublicURL:             os.Getenv("HM_PUBLIC_URL"),
+		GrafanaURL:            os.Getenv("HM_GRAFANA_URL"),
+		GrafanaToken:          os.Getenv("HM_GRAFANA_TOKEN"),
+		GrafanaDashboardUID:   os.Getenv("HM_GRAFANA_DASHBOARD_UID"),
+		GrafanaTags:           parseCSV(getEnv("HM_GRAFANA_TAGS", "healthmon")),
+		AppriseURL:            os.Getenv("HM_APPRISE_URL"),
+		AppriseTag:            os.Getenv("HM_APPRISE_TAG"),
+		NotifyURLs:            strings.Fields(os.Getenv("HM_NOTIFY_URLS")),
+		AlertSeverities:       os.Getenv("HM_ALERT_SEVERITIES"),
+		AlertRules:            os.Getenv("HM_ALERT_RULES"),
+		AlertHooks:            os.Getenv("HM_ALERT_HOOKS"),
+		HookTimeoutSeconds:    getEnvInt("HM_HOOK_TIMEOUT_SECONDS", 30),
+		NotifyRetryHours:      getEnvInt("HM_NOTIFY_RETRY_HOURS", 24),
+		MQTTURL:               os.Getenv("HM_MQTT_URL"),
+		MQTTTopicPrefix:       getEnv("HM_MQTT_TOPIC_PREFIX", "healthmon"),
+		MQTTClientID:          getEnv("HM_MQTT_CLIENT_ID", "healthmon"),
+		RestartWindowSeconds:  getEnvInt("HM_RESTART_WINDOW_SECONDS", 300),
+		RestartThreshold:      getEnvInt("HM_RESTART_THRESHOLD", 3),
+		StormContainers:       getEnvInt("HM_RESTART_STORM_CONTAINERS", 5),
+		StormWindowSeconds:    getEnvInt("HM_RESTART_STORM_WINDOW_SECONDS", 60),
+		UnhealthyGraceSeconds: getEnvInt("HM_UNHEALTHY_GRACE_SECONDS", 0),
+		TaskMaxAgeSeconds:     getEnvInt("HM_TASK_MAX_AGE_SECONDS", 0),
+		WSOriginPatterns:      origins,
+		WSInsecureSkipVerify:  getEnvBool("HM_WS_INSECURE_SKIP_VERIFY", false),
+		WSDebounceMillis:      getEnvInt("HM_WS_DEBOUNCE_MS", 250),
+		APITokens:             parseTokenScopes(os.Getenv("HM_API_TOKENS")),
+		APIRateLimit:          getEnvInt("HM_API_RATE_LIMIT", 20),
+		APIRateBurst:          getEnvInt("HM_API_RATE_BURST", 50),
+		APIMaxBodyKB:          getEnvInt("HM_API_MAX_BODY_KB", 1024),
+		APIMaxHeaderKB:        getEnvInt("HM_API_MAX_HEADER_KB", 64),
+		AuthProxyHeader:       os.Getenv("HM_AUTH_PROXY_HEADER"),
+		AuthProxyGroupsHeader: os.Getenv("HM_AUTH_PROXY_GROUPS_HEADER"),
+		TrustedProxies:        parseCSV(os.Getenv("HM_TRUSTED_PROXIES")),
+		AuthUsers:             parseTokenScopes(os.Getenv("HM_AUTH_USERS")),
+		AuthGroups:            parseTokenScopes(os.Getenv("HM_AUTH_GROUPS")),
+		AuthDefaultRole:       os.Getenv("HM_AUTH_DEFAULT_ROLE"),
+		OIDCIssuer:            os.Getenv("HM_OIDC_ISSUER"),
+		OIDCClientID:          os.Getenv("HM_OIDC_CLIENT_ID"),
+		OIDCClientSecret:      os.Getenv("HM_OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:       os.Getenv("HM_OIDC_REDIRECT_URL"),
+		OIDCScopes:            parseCSV(os.Getenv("HM_OIDC_SCOPES")),
+		OIDCUserClaim:         os.Getenv("HM_OIDC_USER_CLAIM"),
+		OIDCGroupsClaim:       os.Getenv("HM_OIDC_GROUPS_CLAIM"),
+		SessionSecret:         os.Getenv("HM_SESSION_SECRET"),
+		SessionHours:          getEnvInt("HM_SESSION_HOURS", 12),
+		ResyncIntervalSeconds: getEnvInt("HM_RESYNC_INTERVAL_SECONDS", 0),
+		BackupDir:             getEnv("HM_BACKUP_DIR", "./backups"),
+		BackupKeep:            getEnvInt("HM_BACKUP_KEEP", 7),
+		DBMaintenanceAt:       os.Getenv("HM_DB_MAINTENANCE_AT"),
+		RemovedRetentionDays:  getEnvInt("HM_REMOVED_RETENTION_DAYS", 0),
+		StatsIntervalSeconds:  getEnvInt("HM_STATS_INTERVAL_SECONDS", 30),
+		MemoryPressurePercent: getEnvInt("HM_MEMORY_PRESSURE_PERCENT", 90),
+		MemoryPressureSamples: getEnvInt("HM_MEMORY_PRESSURE_SAMPLES", 3),
+		PIDsPressurePercent:   getEnvInt("HM_PIDS_PRESSURE_PERCENT", 90),
+		FDsPressurePercent:    getEnvInt("HM_FDS_PRESSURE_PERCENT", 90),
+		ProcDir:               os.Getenv("HM_PROC_DIR"),
+		ClockCheckSeconds:     getEnvInt("HM_CLOCK_CHECK_SECONDS", 300),
+		ClockDriftSeconds:     getEnvInt("HM_CLOCK_DRIFT_SECONDS", 5),
+		NTPCheck:              getEnvBool("HM_NTP_CHECK", false),
+		StuckStateSeconds:     getEnvInt("HM_STUCK_STATE_SECONDS", 600),
+		StuckStates:           parseCSV(getEnv("HM_STUCK_STATES", "created,dead,removing")),
+		PausedAlertSeconds:    getEnvInt("HM_PAUSED_ALERT_SECONDS", 0),
+		ExpectedContainers:    parseCSV(os.Getenv("HM_EXPECTED_CONTAINERS")),
+		ExpectedGraceSeconds:  getEnvInt("HM_EXPECTED_GRACE_SECONDS", 120),
+		AutohealAfterSeconds:  getEnvInt("HM_AUTOHEAL_AFTER_SECONDS", 300),
+		AutohealMaxAttempts:   getEnvInt("HM_AUTOHEAL_MAX_ATTEMPTS", 3),
+		ReadinessRegressPct:   getEnvInt("HM_READINESS_REGRESSION_PERCENT", 50),
+		ImageReportHours:      getEnvInt("HM_IMAGE_REPORT_HOURS", 0),
+		AnomalyDays:           getEnvInt("HM_ANOMALY_DAYS", 14),
+		AnomalyZScore:         getEnvInt("HM_ANOMALY_ZSCORE", 3),
+		AnomalyMinEvents:      getEnvInt("HM_ANOMALY_MIN_EVENTS", 5),
+		ImagePrune:            getEnvBool("HM_IMAGE_PRUNE", false),
+		LatestAlertGroups:     parseCSV(os.Getenv("HM_LATEST_ALERT_GROUPS")),
+		ChangelogLookup:       getEnvBool("HM_CHANGELOG_LOOKUP", true),
+		ErrorReportURL:        os.Getenv("HM_ERROR_REPORT_URL"),
+		ServiceLabels:         parseCSV(os.Getenv("HM_SERVICE_LABELS")),
+		LabelAllowlist:        parseCSV(os.Getenv("HM_LABEL_ALLOWLIST")),
+		IgnoreContainers:      parsePatterns(os.Getenv("HM_IGNORE_CONTAINERS")),
+		OnlyContainers:        parsePatterns(os.Getenv("HM_ONLY_CONTAINERS")),
+		HAInstance:            os.Getenv("HM_HA_INSTANCE"),
+		RestartLoopLogLines:   getEnvInt("HM_RESTART_LOOP_LOG_LINES", 50),
+		HALeaseSeconds:        getEnvInt("HM_HA_LEASE_SECONDS", 15),
+		SystemdUnits:          parseCSV(os.Getenv("HM_SYSTEMD_UNITS")),
+		SystemdBus:            getEnv("HM_SYSTEMD_BUS", "unix:path=/run/dbus/system_bus_socket"),
+		SystemdPollSeconds:    getEnvInt("HM_SYSTEMD_INTERVAL_SECONDS", 15),
+		CLIURL:                os.Getenv("HM_CLI_URL"),
+		CLIToken:              os.Getenv("HM_CLI_TOKEN"),
+		RecordDir:             os.Getenv("HM_RECORD_DIR"),
 	}
 }
 
@@ -88,3 +270,56 @@ func parseCSV(value string) []string {
 	}
 	return out
 }
+
+// parsePatterns splits container name patterns like parseCSV, except that a
+// regular expression between slashes runs to its closing slash, so it can
+// hold commas as in /^db-\d{1,3}$/.
+func parsePatterns(value string) []string {
+	var out []string
+	var pattern string
+	open := false
+	for _, part := range strings.Split(value, ",") {
+		if open {
+			pattern += "," + part
+		} else {
+			pattern = strings.TrimSpace(part)
+		}
+		trimmed := strings.TrimSpace(pattern)
+		open = strings.HasPrefix(trimmed, "/") && (len(trimmed) == 1 || !strings.HasSuffix(trimmed, "/"))
+		if !open && trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	if trimmed := strings.TrimSpace(pattern); open && trimmed != "" {
+		// An unclosed expression is kept as it is; the monitor reports it.
+		out = append(out, trimmed)
+	}
+	return out
+}
+
+// parseTokenScopes parses `token[:scope]` entries, also used for the
+// `user[:role]` and `group[:role]` mappings. A bare entry gets the admin
+// scope; unknown scopes fall back to read-only.
+func parseTokenScopes(value string) map[string]string {
+	entries := parseCSV(value)
+	if len(entries) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		token, scope, hasScope := strings.Cut(entry, ":")
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		switch {
+		case !hasScope:
+			scope = "admin"
+		case scope != "admin":
+			scope = "read"
+		}
+		out[token] = scope
+	}
+	return out
+}
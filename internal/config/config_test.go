@@ -0,0 +1,23 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePatternsKeepsCommasInRegularExpressions(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"ci-runner-*, buildx_*", []string{"ci-runner-*", "buildx_*"}},
+		{`/^db-\d{1,3}$/`, []string{`/^db-\d{1,3}$/`}},
+		{`web, /^worker-[a-z]{2,}-\d{1,3}$/ ,tmp-*`, []string{"web", `/^worker-[a-z]{2,}-\d{1,3}$/`, "tmp-*"}},
+		{"/, x", []string{"/, x"}},
+	} {
+		if got := parsePatterns(tc.value); !slices.Equal(got, tc.want) {
+			t.Fatalf("parsePatterns(%q) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
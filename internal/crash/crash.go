@@ -0,0 +1,188 @@
+// Package crash recovers panics in long-running handlers so one bad event or
+// request cannot take the whole monitor down, and optionally reports them.
+package crash
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportInterval limits reports of the same panic to one per interval, so a
+// handler that panics on every event does not flood the endpoint.
+const reportInterval = time.Hour
+
+// Panic is a recovered panic.
+type Panic struct {
+	Where string
+	Value interface{}
+	Stack []byte
+}
+
+// Report is what gets sent to the error reporting endpoint. It leaves out the
+// panic value, which may name containers or images, and strips arguments and
+// local paths from the stack trace.
+type Report struct {
+	Where     string    `json:"where"`
+	Panic     string    `json:"panic"`
+	Stack     string    `json:"stack"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	Time      time.Time `json:"time"`
+}
+
+type Reporter struct {
+	endpoint string
+	client   *http.Client
+
+	mu       sync.Mutex
+	handlers []func(context.Context, Panic)
+	sent     map[string]time.Time
+}
+
+// New returns a Reporter that posts anonymized reports to endpoint, or only
+// logs and runs its handlers when endpoint is empty.
+func New(endpoint string) *Reporter {
+	return &Reporter{
+		endpoint: strings.TrimSpace(endpoint),
+		client:   &http.Client{Timeout: 5 * time.Second},
+		sent:     make(map[string]time.Time),
+	}
+}
+
+// OnPanic registers fn to run for every recovered panic.
+func (r *Reporter) OnPanic(fn func(context.Context, Panic)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, fn)
+}
+
+// Handle records a value returned by recover. It must be called from the
+// deferred function that recovered, so the stack still shows the panic.
+// A nil Reporter only logs.
+func (r *Reporter) Handle(ctx context.Context, where string, value interface{}) {
+	p := Panic{Where: where, Value: value, Stack: debug.Stack()}
+	log.Printf("recovered panic in %s: %v\n%s", where, value, p.Stack)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	handlers := append([]func(context.Context, Panic){}, r.handlers...)
+	r.mu.Unlock()
+	for _, fn := range handlers {
+		r.runHandler(ctx, fn, p)
+	}
+	r.report(p)
+}
+
+// runHandler keeps a panicking handler from turning recovery into a crash.
+func (r *Reporter) runHandler(ctx context.Context, fn func(context.Context, Panic), p Panic) {
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("panic handler for %s panicked: %v", p.Where, v)
+		}
+	}()
+	fn(ctx, p)
+}
+
+func (r *Reporter) report(p Panic) {
+	if r.endpoint == "" {
+		return
+	}
+	report := Report{
+		Where:     p.Where,
+		Panic:     fmt.Sprintf("%T", p.Value),
+		Stack:     anonymizeStack(p.Stack),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Time:      time.Now().UTC(),
+	}
+	key := report.Where + "\x00" + report.Stack
+	r.mu.Lock()
+	if last, ok := r.sent[key]; ok && report.Time.Sub(last) < reportInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.sent[key] = report.Time
+	r.mu.Unlock()
+
+	go func() {
+		if err := r.send(report); err != nil {
+			log.Printf("error report failed: %v", err)
+		}
+	}()
+}
+
+func (r *Reporter) send(report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error report status %s", resp.Status)
+	}
+	return nil
+}
+
+var (
+	stackArgs    = regexp.MustCompile(`\((0x[0-9a-f]+|\.\.\.|\{[^)]*\})(, (0x[0-9a-f]+|\.\.\.|\{[^)]*\}))*\)$`)
+	stackOffset  = regexp.MustCompile(` \+0x[0-9a-f]+$`)
+	stackCreated = regexp.MustCompile(` in goroutine \d+$`)
+)
+
+// anonymizeStack drops goroutine ids, call arguments, program counter offsets
+// and everything in file paths up to the module or package directory, which
+// would otherwise reveal the build machine's user name.
+func anonymizeStack(stack []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			out = append(out, "goroutine")
+		case strings.HasPrefix(line, "\t"):
+			line = stackOffset.ReplaceAllString(strings.TrimPrefix(line, "\t"), "")
+			out = append(out, "\t"+trimPath(line))
+		default:
+			line = stackCreated.ReplaceAllString(line, "")
+			out = append(out, stackArgs.ReplaceAllString(line, "()"))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func trimPath(path string) string {
+	for _, marker := range []string{"/pkg/mod/", "/healthmon/", "/src/"} {
+		if i := strings.LastIndex(path, marker); i >= 0 {
+			return path[i+len(marker):]
+		}
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
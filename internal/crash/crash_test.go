@@ -0,0 +1,31 @@
+package crash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeStackDropsArgumentsAndLocalPaths(t *testing.T) {
+	stack := `goroutine 42 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:26 +0x5e
+healthmon/internal/monitor.(*Monitor).handleEvent(0xc0001a2000, {0x10f3a80, 0xc000120000}, {{0xc00012c0f0, 0x9}, ...})
+	/home/alice/src/healthmon/internal/monitor/monitor.go:240 +0x1c5
+created by healthmon/internal/monitor.(*Monitor).Start in goroutine 1
+	/home/alice/src/healthmon/internal/monitor/monitor.go:71 +0x2b
+`
+	got := anonymizeStack([]byte(stack))
+	want := `goroutine
+runtime/debug.Stack()
+	runtime/debug/stack.go:26
+healthmon/internal/monitor.(*Monitor).handleEvent()
+	internal/monitor/monitor.go:240
+created by healthmon/internal/monitor.(*Monitor).Start
+	internal/monitor/monitor.go:71`
+	if got != want {
+		t.Fatalf("unexpected stack:\n%s", got)
+	}
+	if strings.Contains(got, "alice") {
+		t.Fatalf("stack still contains the local user name")
+	}
+}
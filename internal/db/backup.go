@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const backupPrefix = "healthmon-"
+
+var ErrBackupUnsupported = errors.New("backups are only supported for SQLite; use pg_dump for PostgreSQL")
+
+// Backup writes a consistent snapshot of the database into dir with VACUUM
+// INTO, which is safe while other connections keep writing, and then removes
+// all but the newest keep snapshots. A keep of zero or less keeps everything.
+func (db *DB) Backup(ctx context.Context, dir string, keep int) (string, error) {
+	if db.Dialect != DialectSQLite {
+		return "", ErrBackupUnsupported
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := backupPrefix + time.Now().UTC().Format("20060102-150405") + ".db"
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("backup %s already exists", path)
+	}
+	if _, err := db.SQL.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return "", fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+
+	if err := pruneBackups(dir, keep); err != nil {
+		return path, fmt.Errorf("prune backups: %w", err)
+	}
+	return path, nil
+}
+
+func pruneBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), backupPrefix) || !strings.HasSuffix(e.Name(), ".db") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	if len(names) <= keep {
+		return nil
+	}
+	// Timestamped names sort chronologically.
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore replaces the SQLite database at dst with the snapshot at src after
+// checking that src is an intact healthmon database. healthmon must not be
+// running against dst while this happens.
+func Restore(ctx context.Context, src, dst string) error {
+	if err := verifyBackup(ctx, src); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".restore"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// A WAL left over from the replaced database would be replayed on top of
+	// the restored one. It goes only once the swap is done, so a failed
+	// restore leaves the old database whole.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dst + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("restored %s, but remove its stale %s before starting healthmon: %w", dst, suffix, err)
+		}
+	}
+	return nil
+}
+
+func verifyBackup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("check %s: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("check %s: %s", path, result)
+	}
+	var migrations int
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations`).Scan(&migrations); err != nil {
+		return fmt.Errorf("%s is not a healthmon database: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupPrunesOldSnapshotsAndRestores(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "healthmon.db")
+	dbConn, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	backupDir := filepath.Join(dir, "backups")
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"healthmon-20200101-000000.db", "healthmon-20200102-000000.db", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(backupDir, name), []byte("old"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	if _, err := dbConn.SQL.ExecContext(ctx, `INSERT INTO containers (name, container_id, image, image_tag, image_id, created_at_container, first_seen_at, status, caps, read_only, user, updated_at) VALUES ('imapsync', 'cid', 'imapsync', 'latest', 'img', '2026-03-01T17:00:00Z', '2026-03-01T17:00:00Z', 'running', '[]', 0, '0:0', '2026-03-01T17:00:00Z')`); err != nil {
+		t.Fatalf("insert container: %v", err)
+	}
+
+	path, err := dbConn.Backup(ctx, backupDir, 2)
+	if err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatalf("read backup dir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 3 || names[0] != "healthmon-20200102-000000.db" || names[1] != filepath.Base(path) || names[2] != "notes.txt" {
+		t.Fatalf("unexpected backup dir contents: %v", names)
+	}
+
+	if _, err := dbConn.SQL.ExecContext(ctx, `DELETE FROM containers`); err != nil {
+		t.Fatalf("delete containers: %v", err)
+	}
+	dbConn.Close()
+
+	if err := Restore(ctx, filepath.Join(backupDir, "healthmon-20200102-000000.db"), dbPath); err == nil {
+		t.Fatalf("expected restore of a non-database file to fail")
+	}
+	// A restore that cannot swap the file in keeps the old WAL.
+	blocked := filepath.Join(dir, "blocked.db")
+	if err := os.MkdirAll(filepath.Join(blocked, "keep"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(blocked+"-wal", []byte("wal"), 0o644); err != nil {
+		t.Fatalf("write wal: %v", err)
+	}
+	if err := Restore(ctx, path, blocked); err == nil {
+		t.Fatalf("expected restore over a directory to fail")
+	}
+	if _, err := os.Stat(blocked + "-wal"); err != nil {
+		t.Fatalf("expected the WAL to survive a failed restore: %v", err)
+	}
+	if _, err := os.Stat(blocked + ".restore"); !os.IsNotExist(err) {
+		t.Fatalf("expected the temporary copy to be removed, got %v", err)
+	}
+
+	if err := os.WriteFile(dbPath+"-wal", []byte("stale"), 0o644); err != nil {
+		t.Fatalf("write wal: %v", err)
+	}
+	if err := Restore(ctx, path, dbPath); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale WAL to be removed, got %v", err)
+	}
+
+	restored, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open restored db: %v", err)
+	}
+	defer restored.Close()
+	var count int
+	if err := restored.SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM containers`).Scan(&count); err != nil {
+		t.Fatalf("count containers: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected restored database to contain 1 container, got %d", count)
+	}
+}
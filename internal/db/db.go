@@ -11,14 +11,41 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
 	_ "modernc.org/sqlite"
 )
 
-//go:embed migrations/*.sql
+//go:embed migrations/*.sql migrations_postgres/*.sql
 var migrationsFS embed.FS
 
+type Dialect string
+
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+)
+
+// Querier is the part of database/sql the store depends on. *sql.DB and
+// *sql.Tx satisfy it directly for SQLite; Postgres connections are wrapped so
+// that the store can keep writing queries with `?` placeholders.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Tx is a Querier bound to a transaction.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
 type DB struct {
-	SQL *sql.DB
+	SQL     *sql.DB
+	Dialect Dialect
 }
 
 func Open(path string) (*DB, error) {
@@ -29,19 +56,76 @@ func Open(path string) (*DB, error) {
 	}
 	sqlDB.SetMaxOpenConns(1)
 	sqlDB.SetConnMaxLifetime(0)
-	return &DB{SQL: sqlDB}, nil
+	return &DB{SQL: sqlDB, Dialect: DialectSQLite}, nil
+}
+
+// OpenPostgres connects to PostgreSQL. Timestamps are stored as TIMESTAMPTZ
+// and read back in UTC, so the store sees the same RFC 3339 strings it
+// writes on SQLite.
+func OpenPostgres(dsn string) (*DB, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB := stdlib.OpenDB(*config, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		conn.TypeMap().RegisterType(&pgtype.Type{Name: "timestamptz", OID: pgtype.TimestamptzOID, Codec: &pgtype.TimestamptzCodec{ScanLocation: time.UTC}})
+		return nil
+	}))
+	sqlDB.SetMaxOpenConns(10)
+	sqlDB.SetConnMaxIdleTime(5 * time.Minute)
+	return &DB{SQL: sqlDB, Dialect: DialectPostgres}, nil
 }
 
 func (db *DB) Close() error {
 	return db.SQL.Close()
 }
 
+// Querier returns the connection the store should run its queries on.
+func (db *DB) Querier() Querier {
+	if db.Dialect == DialectPostgres {
+		return postgresQuerier{db: db.SQL}
+	}
+	return db.SQL
+}
+
+// DialectOf reports which SQL dialect a Querier speaks.
+func DialectOf(q Querier) Dialect {
+	switch q.(type) {
+	case postgresQuerier, postgresTx:
+		return DialectPostgres
+	default:
+		return DialectSQLite
+	}
+}
+
+// Begin starts a transaction on a Querier returned by DB.Querier.
+func Begin(ctx context.Context, q Querier) (Tx, error) {
+	switch conn := q.(type) {
+	case *sql.DB:
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return tx, nil
+	case postgresQuerier:
+		tx, err := conn.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return postgresTx{tx: tx}, nil
+	default:
+		return nil, fmt.Errorf("begin transaction: unsupported querier %T", q)
+	}
+}
+
 func (db *DB) Migrate(ctx context.Context) error {
-	if _, err := db.SQL.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
+	q := db.Querier()
+	if _, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
 		return err
 	}
 
-	entries, err := migrationsFS.ReadDir("migrations")
+	dir := migrationsDir(db.Dialect)
+	entries, err := migrationsFS.ReadDir(dir)
 	if err != nil {
 		return err
 	}
@@ -63,14 +147,14 @@ func (db *DB) Migrate(ctx context.Context) error {
 		}
 
 		var exists int
-		if err := db.SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, ver).Scan(&exists); err != nil {
+		if err := q.QueryRowContext(ctx, `SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, ver).Scan(&exists); err != nil {
 			return err
 		}
 		if exists > 0 {
 			continue
 		}
 
-		path := filepath.Join("migrations", name)
+		path := filepath.Join(dir, name)
 		content, err := migrationsFS.ReadFile(path)
 		if err != nil {
 			return err
@@ -81,10 +165,17 @@ func (db *DB) Migrate(ctx context.Context) error {
 		}
 
 		now := time.Now().UTC().Format(time.RFC3339)
-		if _, err := db.SQL.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, ver, now); err != nil {
+		if _, err := q.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, ver, now); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+func migrationsDir(dialect Dialect) string {
+	if dialect == DialectPostgres {
+		return "migrations_postgres"
+	}
+	return "migrations"
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaintenanceResult describes a maintenance run. Sizes are in bytes.
+type MaintenanceResult struct {
+	Steps      []string
+	SizeBefore int64
+	SizeAfter  int64
+	Duration   time.Duration
+}
+
+type maintenanceStep struct {
+	name  string
+	query string
+}
+
+// SQLite checkpoints the WAL before VACUUM so it rewrites an up to date file,
+// and again after it, because VACUUM itself goes through the WAL and would
+// otherwise leave it as large as the database.
+var sqliteMaintenance = []maintenanceStep{
+	{"wal_checkpoint", `PRAGMA wal_checkpoint(TRUNCATE)`},
+	{"vacuum", `VACUUM`},
+	{"analyze", `ANALYZE`},
+	{"wal_checkpoint", `PRAGMA wal_checkpoint(TRUNCATE)`},
+}
+
+// Postgres checkpoints on its own; CHECKPOINT needs superuser rights.
+var postgresMaintenance = []maintenanceStep{
+	{"vacuum", `VACUUM`},
+	{"analyze", `ANALYZE`},
+}
+
+// Maintain reclaims space left by deleted rows, refreshes the query planner
+// statistics and, for SQLite, truncates the WAL. progress is called with the
+// name of each step before it runs. Writes wait while a step runs, so this is
+// meant for quiet hours.
+func (db *DB) Maintain(ctx context.Context, progress func(step string)) (MaintenanceResult, error) {
+	started := time.Now()
+	steps := sqliteMaintenance
+	if db.Dialect == DialectPostgres {
+		steps = postgresMaintenance
+	}
+
+	var result MaintenanceResult
+	var err error
+	if result.SizeBefore, err = db.size(ctx); err != nil {
+		return result, err
+	}
+	for _, step := range steps {
+		if progress != nil {
+			progress(step.name)
+		}
+		if _, err := db.SQL.ExecContext(ctx, step.query); err != nil {
+			return result, fmt.Errorf("%s: %w", step.name, err)
+		}
+		result.Steps = append(result.Steps, step.name)
+	}
+	if result.SizeAfter, err = db.size(ctx); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(started)
+	return result, nil
+}
+
+func (db *DB) size(ctx context.Context) (int64, error) {
+	var size int64
+	if db.Dialect == DialectPostgres {
+		err := db.SQL.QueryRowContext(ctx, `SELECT pg_database_size(current_database())`).Scan(&size)
+		return size, err
+	}
+	err := db.SQL.QueryRowContext(ctx, `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&size)
+	return size, err
+}
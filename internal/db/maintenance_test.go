@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaintainShrinksSQLiteAfterDeletes(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	if _, err := dbConn.SQL.ExecContext(ctx, `INSERT INTO containers (name, container_id, image, image_tag, image_id, created_at_container, first_seen_at, status, caps, read_only, user, updated_at) VALUES ('imapsync', 'cid', 'imapsync', 'latest', 'img', '2026-03-01T17:00:00Z', '2026-03-01T17:00:00Z', 'running', '[]', 0, '0:0', '2026-03-01T17:00:00Z')`); err != nil {
+		t.Fatalf("insert container: %v", err)
+	}
+	message := strings.Repeat("x", 1000)
+	for i := 0; i < 500; i++ {
+		if _, err := dbConn.SQL.ExecContext(ctx, `INSERT INTO events (container_pk, container_name, container_id, event_type, severity, message, ts) VALUES (1, 'imapsync', 'cid', 'restart', 'blue', ?, ?)`, message, fmt.Sprintf("2026-03-01T17:%02d:%02dZ", i/60%60, i%60)); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+	}
+	if _, err := dbConn.SQL.ExecContext(ctx, `DELETE FROM events`); err != nil {
+		t.Fatalf("delete events: %v", err)
+	}
+
+	var steps []string
+	result, err := dbConn.Maintain(ctx, func(step string) { steps = append(steps, step) })
+	if err != nil {
+		t.Fatalf("maintain: %v", err)
+	}
+	if strings.Join(steps, ",") != "wal_checkpoint,vacuum,analyze,wal_checkpoint" {
+		t.Fatalf("unexpected steps: %v", steps)
+	}
+	if result.SizeAfter >= result.SizeBefore {
+		t.Fatalf("expected vacuum to shrink the database, got %d -> %d bytes", result.SizeBefore, result.SizeAfter)
+	}
+}
@@ -247,6 +247,56 @@ INSERT INTO alerts (
 	}
 }
 
+func TestMigrateTagsLegacyDetailsWithKind(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := applyMigrationsUpTo(ctx, dbConn, 20); err != nil {
+		t.Fatalf("apply base migrations: %v", err)
+	}
+	_, err = dbConn.SQL.ExecContext(ctx, `
+INSERT INTO containers (id, name, container_id, image, image_tag, image_id, created_at_container, first_seen_at, status, caps, read_only, user, updated_at)
+VALUES (10, 'web', 'cid-web', 'nginx', 'latest', 'sha256:image', '2026-03-01T17:00:00Z', '2026-03-01T17:00:00Z', 'running', '[]', 0, '', '2026-03-01T17:00:00Z');
+
+INSERT INTO events (id, container_pk, container_name, container_id, event_type, severity, message, ts, details) VALUES
+  (100, 10, 'web', 'cid-web', 'restart', 'blue', 'Restart event: die', '2026-03-01T18:00:00Z', '{"restart_count":2}'),
+  (101, 10, 'web', 'cid-web', 'resync_drift', 'blue', 'Drift', '2026-03-01T18:00:00Z', '{"status":{"before":"exited","after":"running"}}'),
+  (102, 10, 'web', 'cid-web', 'image_changed', 'blue', 'Image changed', '2026-03-01T18:00:00Z', '{}'),
+  (103, 10, 'web', 'cid-web', 'started', 'blue', 'Started', '2026-03-01T18:00:00Z', NULL);
+
+INSERT INTO alerts (id, container_pk, container_name, container_id, alert_type, severity, message, ts, details) VALUES
+  (200, 10, 'web', 'cid-web', 'db_write_failed', 'red', 'Write failed', '2026-03-01T18:00:00Z', '{"error":"disk full","write":"event"}');
+`)
+	if err != nil {
+		t.Fatalf("seed rows: %v", err)
+	}
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("run migration: %v", err)
+	}
+
+	expected := map[string]sql.NullString{
+		`SELECT details FROM events WHERE id = 100`: {String: `{"kind":"restart","restart_count":2}`, Valid: true},
+		`SELECT details FROM events WHERE id = 101`: {String: `{"kind":"drift","changes":{"status":{"before":"exited","after":"running"}}}`, Valid: true},
+		`SELECT details FROM events WHERE id = 102`: {String: `{"kind":"image_update"}`, Valid: true},
+		`SELECT details FROM events WHERE id = 103`: {},
+		`SELECT details FROM alerts WHERE id = 200`: {String: `{"kind":"diagnostic","error":"disk full","write":"event"}`, Valid: true},
+	}
+	for query, want := range expected {
+		var got sql.NullString
+		if err := dbConn.SQL.QueryRowContext(ctx, query).Scan(&got); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		if got != want {
+			t.Fatalf("%s: expected %#v, got %#v", query, want, got)
+		}
+	}
+}
+
 func applyMigrationsUpTo(ctx context.Context, dbConn *DB, maxVersion int) error {
 	if _, err := dbConn.SQL.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`); err != nil {
 		return err
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS incidents (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  container_pk INTEGER NOT NULL,
+  container_name TEXT NOT NULL,
+  incident_type TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  message TEXT NOT NULL,
+  opened_at TEXT NOT NULL,
+  resolved_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_container_opened ON incidents(container_pk, opened_at DESC);
+
+ALTER TABLE alerts ADD COLUMN incident_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_incident ON alerts(incident_id);
@@ -0,0 +1,22 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS events_fts USING fts5(
+  message,
+  reason,
+  details,
+  content='events',
+  content_rowid='id'
+);
+
+INSERT INTO events_fts(events_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS events_fts_insert AFTER INSERT ON events BEGIN
+  INSERT INTO events_fts(rowid, message, reason, details) VALUES (new.id, new.message, new.reason, new.details);
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_fts_delete AFTER DELETE ON events BEGIN
+  INSERT INTO events_fts(events_fts, rowid, message, reason, details) VALUES ('delete', old.id, old.message, old.reason, old.details);
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_fts_update AFTER UPDATE OF message, reason, details ON events BEGIN
+  INSERT INTO events_fts(events_fts, rowid, message, reason, details) VALUES ('delete', old.id, old.message, old.reason, old.details);
+  INSERT INTO events_fts(rowid, message, reason, details) VALUES (new.id, new.message, new.reason, new.details);
+END;
@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN unhealthy_grace_seconds INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE containers ADD COLUMN last_success_at TEXT;
+ALTER TABLE containers ADD COLUMN task_max_age_seconds INTEGER NOT NULL DEFAULT 0;
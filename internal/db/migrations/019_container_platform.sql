@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN platform TEXT NOT NULL DEFAULT '';
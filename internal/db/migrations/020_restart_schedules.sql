@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS restart_schedules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  container_name TEXT NOT NULL,
+  next_run_at TEXT NOT NULL,
+  interval_seconds INTEGER NOT NULL DEFAULT 0,
+  created_at TEXT NOT NULL,
+  last_run_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_restart_schedules_next_run ON restart_schedules(next_run_at);
@@ -0,0 +1,2 @@
+ALTER TABLE containers ADD COLUMN depends_on TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE containers ADD COLUMN networks TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN labels TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN last_health_probe TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS repairs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  check_name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  rows_affected INTEGER NOT NULL,
+  repaired INTEGER NOT NULL,
+  ts TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_repairs_ts ON repairs(ts);
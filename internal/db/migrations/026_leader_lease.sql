@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS leader_lease (
+  name TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,
+  acquired_at TEXT NOT NULL,
+  expires_at TEXT NOT NULL
+);
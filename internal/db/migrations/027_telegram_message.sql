@@ -0,0 +1 @@
+ALTER TABLE alerts ADD COLUMN telegram_message_id INTEGER NOT NULL DEFAULT 0;
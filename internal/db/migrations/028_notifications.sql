@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS notifications (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  alert_id INTEGER NOT NULL,
+  container_name TEXT NOT NULL,
+  alert_type TEXT NOT NULL,
+  notifier TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TEXT NOT NULL,
+  updated_at TEXT NOT NULL,
+  next_attempt_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_alert ON notifications(alert_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
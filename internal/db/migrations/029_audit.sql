@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  ts TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  ip TEXT NOT NULL,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  params TEXT NOT NULL DEFAULT '',
+  status INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
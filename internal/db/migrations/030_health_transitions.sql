@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS health_transitions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  container_pk INTEGER NOT NULL,
+  from_status TEXT NOT NULL,
+  to_status TEXT NOT NULL,
+  ts TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_transitions_container_ts ON health_transitions(container_pk, ts);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS alert_comments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  alert_id INTEGER NOT NULL,
+  author TEXT NOT NULL,
+  body TEXT NOT NULL,
+  created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_comments_alert ON alert_comments(alert_id, id);
@@ -0,0 +1,6 @@
+ALTER TABLE containers ADD COLUMN config_fingerprint TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS settings (
+  name TEXT PRIMARY KEY,
+  value TEXT NOT NULL
+);
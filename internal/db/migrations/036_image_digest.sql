@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN image_digest TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,91 @@
+CREATE TABLE IF NOT EXISTS containers (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  container_id TEXT NOT NULL,
+  current_container_name TEXT NOT NULL DEFAULT '',
+  image TEXT NOT NULL,
+  image_tag TEXT NOT NULL,
+  image_id TEXT NOT NULL,
+  created_at_container TIMESTAMPTZ NOT NULL,
+  first_seen_at TIMESTAMPTZ NOT NULL,
+  registered_at TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01T00:00:00Z',
+  started_at TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01T00:00:00Z',
+  finished_at TIMESTAMPTZ,
+  exit_code INTEGER,
+  status TEXT NOT NULL,
+  role TEXT NOT NULL DEFAULT 'service',
+  caps TEXT NOT NULL,
+  read_only INTEGER NOT NULL,
+  no_new_privileges INTEGER NOT NULL DEFAULT 0,
+  memory_reservation BIGINT NOT NULL DEFAULT 0,
+  memory_limit BIGINT NOT NULL DEFAULT 0,
+  "user" TEXT NOT NULL,
+  last_event_id BIGINT,
+  updated_at TIMESTAMPTZ NOT NULL,
+  present INTEGER NOT NULL DEFAULT 1,
+  health_status TEXT NOT NULL DEFAULT '',
+  health_failing_streak INTEGER NOT NULL DEFAULT 0,
+  unhealthy_since TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01T00:00:00Z',
+  restart_loop INTEGER NOT NULL DEFAULT 0,
+  restart_streak INTEGER NOT NULL DEFAULT 0,
+  restart_loop_since TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01T00:00:00Z',
+  healthcheck TEXT
+);
+
+CREATE TABLE IF NOT EXISTS events (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  container_pk BIGINT NOT NULL,
+  container_name TEXT NOT NULL,
+  container_id TEXT NOT NULL,
+  parsed_container_name TEXT,
+  event_type TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  message TEXT NOT NULL,
+  ts TIMESTAMPTZ NOT NULL,
+  old_image TEXT,
+  new_image TEXT,
+  old_image_id TEXT,
+  new_image_id TEXT,
+  reason TEXT,
+  details TEXT,
+  exit_code INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_container_ts ON events(container_pk, ts DESC);
+
+CREATE TABLE IF NOT EXISTS alerts (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  container_pk BIGINT NOT NULL,
+  container_name TEXT NOT NULL,
+  container_id TEXT NOT NULL,
+  parsed_container_name TEXT,
+  alert_type TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  message TEXT NOT NULL,
+  ts TIMESTAMPTZ NOT NULL,
+  old_image TEXT,
+  new_image TEXT,
+  old_image_id TEXT,
+  new_image_id TEXT,
+  reason TEXT,
+  details TEXT,
+  exit_code INTEGER,
+  incident_id BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_container_ts ON alerts(container_pk, ts DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_ts ON alerts(ts DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_incident ON alerts(incident_id);
+
+CREATE TABLE IF NOT EXISTS incidents (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  container_pk BIGINT NOT NULL,
+  container_name TEXT NOT NULL,
+  incident_type TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  message TEXT NOT NULL,
+  opened_at TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_container_opened ON incidents(container_pk, opened_at DESC);
@@ -0,0 +1,6 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS search tsvector
+  GENERATED ALWAYS AS (
+    to_tsvector('simple', coalesce(message, '') || ' ' || coalesce(reason, '') || ' ' || coalesce(details, ''))
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_search ON events USING GIN (search);
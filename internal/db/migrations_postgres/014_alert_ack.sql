@@ -0,0 +1,3 @@
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_unacknowledged ON alerts(id) WHERE acknowledged_at IS NULL;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS client_filters (
+  client TEXT PRIMARY KEY,
+  severities TEXT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
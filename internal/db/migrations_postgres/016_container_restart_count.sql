@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS docker_restart_count INTEGER NOT NULL DEFAULT -1;
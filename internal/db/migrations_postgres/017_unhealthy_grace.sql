@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS unhealthy_grace_seconds INTEGER NOT NULL DEFAULT 0;
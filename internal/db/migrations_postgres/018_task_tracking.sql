@@ -0,0 +1,2 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS last_success_at TIMESTAMPTZ;
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS task_max_age_seconds INTEGER NOT NULL DEFAULT 0;
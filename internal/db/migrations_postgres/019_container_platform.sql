@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS platform TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS restart_schedules (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  container_name TEXT NOT NULL,
+  next_run_at TIMESTAMPTZ NOT NULL,
+  interval_seconds BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL,
+  last_run_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_restart_schedules_next_run ON restart_schedules(next_run_at);
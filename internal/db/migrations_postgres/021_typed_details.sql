@@ -0,0 +1,24 @@
+-- Details payloads carry a "kind" naming their shape since this version.
+-- Tag the payloads written before, recognized by their first key or type.
+UPDATE events SET details = '{"kind":"restart",' || substr(details, 2) WHERE details LIKE '{"restart_count":%';
+UPDATE events SET details = '{"kind":"self_inflicted",' || substr(details, 2) WHERE details LIKE '{"action":%';
+UPDATE events SET details = '{"kind":"panic",' || substr(details, 2) WHERE details LIKE '{"stack":%';
+UPDATE events SET details = '{"kind":"scale",' || substr(details, 2) WHERE details LIKE '{"new_replicas":%';
+UPDATE events SET details = '{"kind":"task",' || substr(details, 2) WHERE details LIKE '{"duration_seconds":%';
+UPDATE events SET details = '{"kind":"emulation",' || substr(details, 2) WHERE details LIKE '{"host_arch":%';
+UPDATE events SET details = '{"kind":"db_maintenance",' || substr(details, 2) WHERE details LIKE '{"trigger":%';
+UPDATE events SET details = '{"kind":"backfill",' || substr(details, 2) WHERE details LIKE '{"source":%';
+UPDATE events SET details = '{"kind":"image_update"}' WHERE event_type = 'image_changed' AND details = '{}';
+UPDATE events SET details = '{"kind":"image_update",' || substr(details, 2) WHERE event_type = 'image_changed' AND details LIKE '{"%' AND details NOT LIKE '{"kind":%';
+UPDATE events SET details = '{"kind":"drift","changes":' || details || '}' WHERE event_type = 'resync_drift' AND details LIKE '{%' AND details NOT LIKE '{"kind":%';
+UPDATE alerts SET details = '{"kind":"restart",' || substr(details, 2) WHERE details LIKE '{"restart_count":%';
+UPDATE alerts SET details = '{"kind":"self_inflicted",' || substr(details, 2) WHERE details LIKE '{"action":%';
+UPDATE alerts SET details = '{"kind":"panic",' || substr(details, 2) WHERE details LIKE '{"stack":%';
+UPDATE alerts SET details = '{"kind":"scale",' || substr(details, 2) WHERE details LIKE '{"new_replicas":%';
+UPDATE alerts SET details = '{"kind":"task",' || substr(details, 2) WHERE details LIKE '{"duration_seconds":%';
+UPDATE alerts SET details = '{"kind":"emulation",' || substr(details, 2) WHERE details LIKE '{"host_arch":%';
+UPDATE alerts SET details = '{"kind":"db_maintenance",' || substr(details, 2) WHERE details LIKE '{"trigger":%';
+UPDATE alerts SET details = '{"kind":"backfill",' || substr(details, 2) WHERE details LIKE '{"source":%';
+UPDATE alerts SET details = '{"kind":"image_update"}' WHERE alert_type = 'image_changed' AND details = '{}';
+UPDATE alerts SET details = '{"kind":"image_update",' || substr(details, 2) WHERE alert_type = 'image_changed' AND details LIKE '{"%' AND details NOT LIKE '{"kind":%';
+UPDATE alerts SET details = '{"kind":"diagnostic",' || substr(details, 2) WHERE alert_type IN ('db_write_failed', 'notification_failed', 'docker_disconnected') AND details LIKE '{"%' AND details NOT LIKE '{"kind":%';
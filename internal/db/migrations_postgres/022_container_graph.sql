@@ -0,0 +1,2 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS depends_on TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS networks TEXT NOT NULL DEFAULT '[]';
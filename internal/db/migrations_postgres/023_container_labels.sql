@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '{}';
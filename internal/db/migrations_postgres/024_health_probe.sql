@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS last_health_probe TEXT NOT NULL DEFAULT '';
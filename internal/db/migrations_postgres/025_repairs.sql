@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS repairs (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  check_name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  rows_affected BIGINT NOT NULL,
+  repaired INTEGER NOT NULL,
+  ts TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_repairs_ts ON repairs(ts);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS leader_lease (
+  name TEXT PRIMARY KEY,
+  holder TEXT NOT NULL,
+  acquired_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS telegram_message_id BIGINT NOT NULL DEFAULT 0;
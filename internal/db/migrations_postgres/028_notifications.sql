@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS notifications (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  alert_id BIGINT NOT NULL,
+  container_name TEXT NOT NULL,
+  alert_type TEXT NOT NULL,
+  notifier TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  next_attempt_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_alert ON notifications(alert_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_status ON notifications(status);
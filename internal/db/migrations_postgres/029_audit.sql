@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  ts TIMESTAMPTZ NOT NULL,
+  actor TEXT NOT NULL,
+  ip TEXT NOT NULL,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  params TEXT NOT NULL DEFAULT '',
+  status INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
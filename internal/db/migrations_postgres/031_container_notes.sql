@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS container_notes (
+  container_name TEXT PRIMARY KEY,
+  notes TEXT NOT NULL DEFAULT '',
+  owner TEXT NOT NULL DEFAULT '',
+  runbook_url TEXT NOT NULL DEFAULT '',
+  updated_at TIMESTAMPTZ NOT NULL,
+  updated_by TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS alert_hook_runs (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  alert_id BIGINT NOT NULL,
+  target TEXT NOT NULL,
+  command TEXT NOT NULL,
+  exit_code INTEGER NOT NULL,
+  output TEXT NOT NULL,
+  error TEXT NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL,
+  duration_ms BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_hook_runs_alert ON alert_hook_runs(alert_id, id);
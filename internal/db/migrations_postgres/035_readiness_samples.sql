@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS readiness_samples (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  container_pk BIGINT NOT NULL,
+  container_id TEXT NOT NULL,
+  image_id TEXT NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL,
+  ready_at TIMESTAMPTZ NOT NULL,
+  duration_ms BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_readiness_samples_start ON readiness_samples(container_pk, started_at);
+CREATE INDEX IF NOT EXISTS idx_readiness_samples_ready ON readiness_samples(container_pk, ready_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS image_digest TEXT NOT NULL DEFAULT '';
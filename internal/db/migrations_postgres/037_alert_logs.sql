@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS alert_logs (
+  alert_id BIGINT PRIMARY KEY,
+  logs TEXT NOT NULL
+);
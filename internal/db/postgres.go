@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+type postgresQuerier struct {
+	db *sql.DB
+}
+
+func (q postgresQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return q.db.ExecContext(ctx, rebindPostgres(query), args...)
+}
+
+func (q postgresQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.db.QueryContext(ctx, rebindPostgres(query), args...)
+}
+
+func (q postgresQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return q.db.QueryRowContext(ctx, rebindPostgres(query), args...)
+}
+
+type postgresTx struct {
+	tx *sql.Tx
+}
+
+func (t postgresTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRowContext(ctx, rebindPostgres(query), args...)
+}
+
+func (t postgresTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t postgresTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// rebindPostgres rewrites `?` placeholders into Postgres' positional `$n`
+// form, leaving question marks inside quoted literals and identifiers alone.
+func rebindPostgres(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+			b.WriteByte(ch)
+		case ch == '\'' || ch == '"':
+			quote = ch
+			b.WriteByte(ch)
+		case ch == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRebindPostgresSkipsQuotedQuestionMarks(t *testing.T) {
+	got := rebindPostgres(`SELECT id FROM events WHERE message = 'why?' AND "user" = ? AND id < ? LIMIT ?`)
+	want := `SELECT id FROM events WHERE message = 'why?' AND "user" = $1 AND id < $2 LIMIT $3`
+	if got != want {
+		t.Fatalf("unexpected rebind:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestPostgresMigrationsFollowSQLiteMigrations(t *testing.T) {
+	const postgresBaseline = 12
+
+	sqliteEntries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		t.Fatalf("read sqlite migrations: %v", err)
+	}
+	postgresEntries, err := migrationsFS.ReadDir("migrations_postgres")
+	if err != nil {
+		t.Fatalf("read postgres migrations: %v", err)
+	}
+	postgres := make(map[string]struct{}, len(postgresEntries))
+	for _, entry := range postgresEntries {
+		postgres[entry.Name()] = struct{}{}
+	}
+	for _, entry := range sqliteEntries {
+		version, err := strconv.Atoi(strings.Split(entry.Name(), "_")[0])
+		if err != nil {
+			t.Fatalf("invalid migration filename %s", entry.Name())
+		}
+		if version <= postgresBaseline {
+			continue
+		}
+		if _, ok := postgres[entry.Name()]; !ok {
+			t.Fatalf("sqlite migration %s has no postgres counterpart", entry.Name())
+		}
+	}
+}
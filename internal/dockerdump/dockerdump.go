@@ -0,0 +1,211 @@
+// Package dockerdump reads the Docker event and inspect dumps written with
+// HM_RECORD_DIR and serves them through a minimal Docker API, so a recorded
+// misbehavior can be replayed offline.
+package dockerdump
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/moby/api/types/events"
+)
+
+// Inspect is one line of an inspects dump: a container inspect response
+// and the event it followed. EventIndex is the index of the latest event
+// received before the inspect, or -1 for inspects made before the first
+// one, such as during the startup sync.
+type Inspect struct {
+	EventIndex int             `json:"event_index"`
+	TimeNano   int64           `json:"timeNano"`
+	ID         string          `json:"id"`
+	Action     string          `json:"action"`
+	Inspect    json.RawMessage `json:"inspect"`
+}
+
+// maxLine bounds a dump line; inspects of containers with many labels and
+// mounts get large.
+const maxLine = 20 * 1024 * 1024
+
+// LoadEvents reads an events dump, one events.Message per line.
+func LoadEvents(path string) ([]events.Message, error) {
+	var out []events.Message
+	err := readLines(path, func(line []byte) error {
+		var msg events.Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return fmt.Errorf("parse event: %w", err)
+		}
+		out = append(out, msg)
+		return nil
+	})
+	return out, err
+}
+
+// LoadInspects reads an inspects dump.
+func LoadInspects(path string) ([]Inspect, error) {
+	var out []Inspect
+	err := readLines(path, func(line []byte) error {
+		var record Inspect
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("parse inspect: %w", err)
+		}
+		out = append(out, record)
+		return nil
+	})
+	return out, err
+}
+
+func readLines(path string, fn func([]byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// Server answers the Docker API calls healthmon makes from an inspects
+// dump. Inspecting a container returns the latest recorded response at or
+// before the current event, and the container list holds the containers
+// inspected before the first event. Everything else is not found.
+type Server struct {
+	mu       sync.Mutex
+	inspects map[string][]Inspect
+	index    int
+	http     *http.Server
+	listener net.Listener
+}
+
+func NewServer(inspects []Inspect) *Server {
+	byID := make(map[string][]Inspect)
+	for _, record := range inspects {
+		if record.ID == "" || len(record.Inspect) == 0 {
+			continue
+		}
+		byID[record.ID] = append(byID[record.ID], record)
+	}
+	for _, records := range byID {
+		sort.SliceStable(records, func(i, j int) bool { return records[i].EventIndex < records[j].EventIndex })
+	}
+	return &Server{inspects: byID, index: -1}
+}
+
+// Start listens on a loopback port and returns the address to use as
+// HM_DOCKER_HOST.
+func (s *Server) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	s.listener = listener
+	s.http = &http.Server{Handler: http.HandlerFunc(s.handle), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = s.http.Serve(listener) }()
+	return "tcp://" + listener.Addr().String(), nil
+}
+
+func (s *Server) Close() {
+	if s.http == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.http.Shutdown(ctx)
+}
+
+// SetEventIndex moves the server to the time of event i.
+func (s *Server) SetEventIndex(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = i
+}
+
+// inspect returns the response for id at the current event.
+func (s *Server) inspect(id string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.inspects[id]
+	if len(records) == 0 {
+		return nil, false
+	}
+	found := records[0]
+	for _, record := range records {
+		if record.EventIndex > s.index {
+			break
+		}
+		found = record
+	}
+	return found.Inspect, true
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	path := stripAPIVersion(r.URL.Path)
+	switch {
+	case path == "/_ping":
+		_, _ = w.Write([]byte("OK"))
+	case path == "/version":
+		writeJSON(w, map[string]string{"ApiVersion": "1.44", "MinAPIVersion": "1.24", "Version": "replay"})
+	case path == "/containers/json":
+		s.mu.Lock()
+		items := []map[string]string{}
+		for id, records := range s.inspects {
+			if records[0].EventIndex < 0 {
+				items = append(items, map[string]string{"Id": id})
+			}
+		}
+		s.mu.Unlock()
+		sort.Slice(items, func(i, j int) bool { return items[i]["Id"] < items[j]["Id"] })
+		writeJSON(w, items)
+	case strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/json"):
+		raw, ok := s.inspect(strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json"))
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"No such container"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(raw)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"not recorded"}`)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// stripAPIVersion removes the /v1.44 prefix of a Docker API path.
+func stripAPIVersion(path string) string {
+	if !strings.HasPrefix(path, "/v") {
+		return path
+	}
+	rest := path[2:]
+	i := strings.IndexByte(rest, '/')
+	if i <= 0 || strings.Trim(rest[:i], "0123456789.") != "" {
+		return path
+	}
+	return rest[i:]
+}
@@ -0,0 +1,189 @@
+package dockerdump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/moby/api/types/events"
+)
+
+// Recorder writes the Docker event stream and the container inspect
+// responses a Docker client receives into a pair of <name>.events.jsonl and
+// <name>.inspects.jsonl dumps, for HM_RECORD_DIR.
+type Recorder struct {
+	mu       sync.Mutex
+	events   *os.File
+	inspects *os.File
+	// count is the number of events written; the last one has index
+	// count-1.
+	count  int
+	last   map[string]events.Message
+	closed bool
+}
+
+// NewRecorder creates the dump files in dir, named after the time the
+// recording started.
+func NewRecorder(dir string, now time.Time) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, "record-"+now.UTC().Format("20060102-150405"))
+	eventsFile, err := os.OpenFile(base+".events.jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	inspectsFile, err := os.OpenFile(base+".inspects.jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		eventsFile.Close()
+		return nil, err
+	}
+	log.Printf("recording docker events and inspects to %s.*.jsonl", base)
+	return &Recorder{events: eventsFile, inspects: inspectsFile, last: make(map[string]events.Message)}, nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	err := r.events.Close()
+	if ierr := r.inspects.Close(); err == nil {
+		err = ierr
+	}
+	return err
+}
+
+// Hook is a Docker client response hook, see client.WithResponseHook. It replaces the body of event
+// stream and container inspect responses with one that copies what the
+// client reads into the dumps.
+func (r *Recorder) Hook(resp *http.Response) {
+	if resp.Request == nil || resp.Request.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return
+	}
+	path := stripAPIVersion(resp.Request.URL.Path)
+	switch {
+	case path == "/events":
+		resp.Body = &eventTee{body: resp.Body, rec: r}
+	case path != "/containers/json" && strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/json"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")
+		if id == "" || strings.Contains(id, "/") {
+			return
+		}
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+		resp.Body = &inspectTee{body: resp.Body, rec: r, id: id}
+	}
+}
+
+func (r *Recorder) writeEvent(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var msg events.Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	if _, err := fmt.Fprintf(r.events, "%s\n", line); err != nil {
+		log.Printf("record event: %v", err)
+		return
+	}
+	r.count++
+	if msg.Actor.ID != "" {
+		r.last[msg.Actor.ID] = msg
+	}
+}
+
+// writeInspect records an inspect response with the latest event so far
+// and the latest event of the container; inspects made during the startup
+// sync have event index -1.
+func (r *Recorder) writeInspect(id string, body []byte) {
+	if !json.Valid(body) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	record := Inspect{EventIndex: r.count - 1, ID: id, Inspect: json.RawMessage(bytes.TrimSpace(body))}
+	if msg, ok := r.last[id]; ok {
+		record.TimeNano = msg.TimeNano
+		record.Action = string(msg.Action)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	if _, err := fmt.Fprintf(r.inspects, "%s\n", line); err != nil {
+		log.Printf("record inspect: %v", err)
+	}
+}
+
+// eventTee passes the event stream through and records each complete line.
+type eventTee struct {
+	body    io.ReadCloser
+	rec     *Recorder
+	pending []byte
+}
+
+func (t *eventTee) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+	t.pending = append(t.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(t.pending, '\n')
+		if i < 0 {
+			break
+		}
+		t.rec.writeEvent(t.pending[:i])
+		t.pending = t.pending[i+1:]
+	}
+	return n, err
+}
+
+func (t *eventTee) Close() error {
+	return t.body.Close()
+}
+
+// inspectTee passes an inspect response through and records it once the
+// client is done with it.
+type inspectTee struct {
+	body io.ReadCloser
+	rec  *Recorder
+	id   string
+	buf  bytes.Buffer
+	once sync.Once
+}
+
+func (t *inspectTee) Read(p []byte) (int, error) {
+	n, err := t.body.Read(p)
+	t.buf.Write(p[:n])
+	if err == io.EOF {
+		t.flush()
+	}
+	return n, err
+}
+
+func (t *inspectTee) Close() error {
+	t.flush()
+	return t.body.Close()
+}
+
+func (t *inspectTee) flush() {
+	t.once.Do(func() { t.rec.writeInspect(t.id, t.buf.Bytes()) })
+}
@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// diunMessages are the log messages diun writes when it notices a changed
+// image. Older releases say "New image found", newer ones "Image update found".
+var diunMessages = map[string]bool{
+	"new image found":    true,
+	"image update found": true,
+}
+
+// parseDiun reads diun logs, either JSON (--log-json) or the default console
+// format, and turns update notices into image_update_available events. diun
+// only logs the image, so the events are matched to containers on import.
+func parseDiun(r io.Reader) (History, error) {
+	var h History
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var (
+			msg    string
+			fields map[string]string
+			ts     time.Time
+			err    error
+		)
+		if strings.HasPrefix(line, "{") {
+			msg, fields, ts, err = parseDiunJSON(line)
+		} else {
+			msg, fields, ts, err = parseDiunConsole(line)
+		}
+		if err != nil {
+			return History{}, fmt.Errorf("parse diun log line %d: %w", lineNo, err)
+		}
+		if !diunMessages[strings.ToLower(msg)] {
+			continue
+		}
+		image := fields["image"]
+		if image == "" {
+			h.Skipped++
+			continue
+		}
+		h.Events = append(h.Events, store.Event{
+			Type:       "image_update_available",
+			Severity:   "blue",
+			Message:    "diun found a new image for " + image,
+			Timestamp:  ts,
+			NewImage:   image,
+			NewImageID: fields["digest"],
+			Reason:     reason(SourceDiun),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
+
+func parseDiunJSON(line string) (string, map[string]string, time.Time, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return "", nil, time.Time{}, err
+	}
+	fields := make(map[string]string, len(raw))
+	for key, val := range raw {
+		if s, ok := val.(string); ok {
+			fields[key] = s
+		}
+	}
+	ts, err := time.Parse(time.RFC3339Nano, fields["time"])
+	if err != nil {
+		return "", nil, time.Time{}, fmt.Errorf("invalid time %q", fields["time"])
+	}
+	return fields["message"], fields, ts.UTC(), nil
+}
+
+// parseDiunConsole parses lines such as
+//
+//	Mon, 02 Jan 2006 15:04:05 UTC INF Image update found image=docker.io/library/nginx:latest provider=docker
+//
+// where the timestamp is RFC 1123 and key=value fields follow the message.
+func parseDiunConsole(line string) (string, map[string]string, time.Time, error) {
+	var level string
+	var rest string
+	var stamp string
+	for _, lvl := range []string{" DBG ", " INF ", " WRN ", " ERR ", " FTL "} {
+		if idx := strings.Index(line, lvl); idx >= 0 {
+			stamp, level, rest = line[:idx], strings.TrimSpace(lvl), line[idx+len(lvl):]
+			break
+		}
+	}
+	if level == "" {
+		return "", nil, time.Time{}, fmt.Errorf("no log level in %q", line)
+	}
+	ts, err := time.Parse(time.RFC1123, stamp)
+	if err != nil {
+		return "", nil, time.Time{}, fmt.Errorf("invalid time %q", stamp)
+	}
+
+	fields := make(map[string]string)
+	words := strings.Fields(rest)
+	msgWords := make([]string, 0, len(words))
+	for _, word := range words {
+		if key, val, ok := strings.Cut(word, "="); ok && key != "" && !strings.ContainsAny(key, `"`) {
+			fields[key] = strings.Trim(val, `"`)
+			continue
+		}
+		msgWords = append(msgWords, word)
+	}
+	return strings.Join(msgWords, " "), fields, ts.UTC(), nil
+}
@@ -0,0 +1,210 @@
+// Package importer converts history exported from other container monitors
+// into healthmon containers, events and alerts.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/distribution/reference"
+
+	"healthmon/internal/store"
+)
+
+// Source names a tool whose exports can be imported.
+type Source string
+
+const (
+	SourceUptimeKuma Source = "uptime-kuma"
+	SourceDiun       Source = "diun"
+	SourceWatchtower Source = "watchtower"
+	SourceJournald   Source = "journald"
+)
+
+// Sources lists the supported sources in the order shown in usage text.
+var Sources = []Source{SourceUptimeKuma, SourceDiun, SourceWatchtower, SourceJournald}
+
+// History is what a parser extracted from an export. Events with an empty
+// Container were matched by image only (diun does not log container names)
+// and are attached to every container running that image on import, or by
+// ContainerID only (the Docker daemon logs) and are attached to the container
+// that had that id.
+type History struct {
+	Events []store.Event
+	Alerts []store.Alert
+	// Backfill marks history that overlaps what healthmon recorded itself,
+	// such as the daemon logs. Its events are only imported where healthmon
+	// has no event of the same type within backfillWindow, which fills the
+	// gaps while healthmon was down.
+	Backfill bool
+	// Skipped counts records that were understood but cannot be imported,
+	// such as Uptime Kuma monitors that do not watch a container.
+	Skipped int
+}
+
+// backfillWindow is how far apart a backfilled event and one healthmon
+// recorded itself may be to count as the same.
+const backfillWindow = 5 * time.Second
+
+// Result summarizes an import.
+type Result struct {
+	Containers int
+	Events     int
+	Alerts     int
+	Skipped    int
+}
+
+// Parse reads an export produced by source.
+func Parse(source Source, r io.Reader) (History, error) {
+	switch source {
+	case SourceUptimeKuma:
+		return parseUptimeKuma(r)
+	case SourceDiun:
+		return parseDiun(r)
+	case SourceWatchtower:
+		return parseWatchtower(r)
+	case SourceJournald:
+		return parseJournald(r)
+	default:
+		return History{}, fmt.Errorf("unknown import source %q", source)
+	}
+}
+
+// Import writes h to st. Containers that healthmon has never seen are
+// created as removed so their history shows up without them counting as
+// running; they become present again once Docker reports them.
+func Import(ctx context.Context, st *store.Store, h History) (Result, error) {
+	res := Result{Skipped: h.Skipped}
+
+	events := make([]store.Event, 0, len(h.Events))
+	for _, e := range h.Events {
+		if e.Container == "" && e.ContainerID != "" {
+			name, ok, err := st.ContainerNameByContainerID(ctx, e.ContainerID)
+			if err != nil {
+				return res, err
+			}
+			if !ok {
+				res.Skipped++
+				continue
+			}
+			e.Container = name
+		}
+		if e.Container != "" && h.Backfill {
+			seen, err := st.HasEventNear(ctx, e.Container, e.Type, e.Timestamp, backfillWindow)
+			if err != nil {
+				return res, err
+			}
+			if seen {
+				continue
+			}
+		}
+		if e.Container != "" {
+			events = append(events, e)
+			continue
+		}
+		matched := containersWithImage(st, e.NewImage)
+		if len(matched) == 0 {
+			res.Skipped++
+			continue
+		}
+		for _, c := range matched {
+			e.Container = c.Name
+			events = append(events, e)
+		}
+	}
+
+	first := make(map[string]time.Time)
+	last := make(map[string]time.Time)
+	images := make(map[string]string)
+	seen := func(name string, ts time.Time, image string) {
+		if t, ok := first[name]; !ok || ts.Before(t) {
+			first[name] = ts
+		}
+		if t, ok := last[name]; !ok || ts.After(t) {
+			last[name] = ts
+			if image != "" {
+				images[name] = image
+			}
+		}
+	}
+	for _, e := range events {
+		seen(e.Container, e.Timestamp, e.NewImage)
+	}
+	for _, a := range h.Alerts {
+		seen(a.Container, a.Timestamp, a.NewImage)
+	}
+
+	names := make([]string, 0, len(first))
+	for name := range first {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := st.GetContainer(name); ok {
+			continue
+		}
+		image, tag := splitImage(images[name])
+		c := store.Container{
+			Name:         name,
+			Image:        image,
+			ImageTag:     tag,
+			RegisteredAt: first[name],
+			UpdatedAt:    last[name],
+		}
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			return res, fmt.Errorf("create container %s: %w", name, err)
+		}
+		if err := st.SetContainerPresent(ctx, name, false); err != nil {
+			return res, fmt.Errorf("create container %s: %w", name, err)
+		}
+		res.Containers++
+	}
+
+	insertedEvents, insertedAlerts, err := st.ImportHistory(ctx, events, h.Alerts)
+	if err != nil {
+		return res, err
+	}
+	res.Events = insertedEvents
+	res.Alerts = insertedAlerts
+	return res, nil
+}
+
+func containersWithImage(st *store.Store, image string) []store.Container {
+	name, tag := splitImage(image)
+	if name == "" {
+		return nil
+	}
+	var matched []store.Container
+	for _, c := range st.ListContainers() {
+		if c.Image == name && c.ImageTag == tag {
+			matched = append(matched, c)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	return matched
+}
+
+// splitImage normalizes an image reference the way the monitor does before
+// storing it, so imported images compare equal to observed ones.
+func splitImage(image string) (string, string) {
+	if image == "" {
+		return "", ""
+	}
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return image, ""
+	}
+	ref = reference.TagNameOnly(ref)
+	tag := ""
+	if tagged, ok := ref.(reference.NamedTagged); ok {
+		tag = tagged.Tag()
+	}
+	return ref.Name(), tag
+}
+
+func reason(source Source) string {
+	return "import:" + string(source)
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestImportIsIdempotentAcrossSources(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Image: "docker.io/library/nginx", ImageTag: "latest", CreatedAt: now, StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	kuma := `{"version":"1.23.0","monitorList":[
+  {"id":1,"name":"DB","type":"docker","docker_container":"db"},
+  {"id":2,"name":"Site","type":"http"}],
+ "heartbeatList":{"1":[
+  {"status":1,"msg":"","time":"2025-01-01 10:00:00.000"},
+  {"status":0,"msg":"container exited","time":"2025-01-01 11:00:00.000"},
+  {"status":0,"msg":"container exited","time":"2025-01-01 11:01:00.000"},
+  {"status":2,"msg":"","time":"2025-01-01 11:02:00.000"},
+  {"status":1,"msg":"","time":"2025-01-01 11:05:00.000"}]}}`
+	diun := strings.Join([]string{
+		`{"level":"info","image":"nginx:latest","provider":"docker","time":"2025-01-02T08:00:00Z","message":"Image update found"}`,
+		`Thu, 02 Jan 2025 09:00:00 UTC INF New image found image=docker.io/library/redis:7 provider=docker`,
+		`Thu, 02 Jan 2025 09:00:01 UTC INF Cron triggered`,
+	}, "\n")
+	watchtower := strings.Join([]string{
+		`time="2025-01-03T04:00:00Z" level=info msg="Found new nginx:latest image (sha256:0123456789ab)"`,
+		`time="2025-01-03T04:00:01Z" level=info msg="Stopping /web (c-web) with SIGTERM"`,
+		`time="2025-01-03T04:00:05Z" level=info msg="Creating /web"`,
+		`time="2025-01-03T04:00:06Z" level=info msg="Session done" Failed=0 Scanned=2 Updated=1 notify=no`,
+	}, "\n")
+
+	inputs := []struct {
+		source Source
+		data   string
+	}{{SourceUptimeKuma, kuma}, {SourceDiun, diun}, {SourceWatchtower, watchtower}}
+
+	for round := 0; round < 2; round++ {
+		var total Result
+		for _, in := range inputs {
+			h, err := Parse(in.source, strings.NewReader(in.data))
+			if err != nil {
+				t.Fatalf("parse %s: %v", in.source, err)
+			}
+			res, err := Import(ctx, st, h)
+			if err != nil {
+				t.Fatalf("import %s: %v", in.source, err)
+			}
+			total.Containers += res.Containers
+			total.Events += res.Events
+			total.Alerts += res.Alerts
+			total.Skipped += res.Skipped
+		}
+		if round == 0 && (total.Containers != 1 || total.Events != 2 || total.Alerts != 2 || total.Skipped != 2) {
+			t.Fatalf("first import = %+v, want 1 container, 2 events, 2 alerts, 2 skipped", total)
+		}
+		if round == 1 && (total.Containers != 0 || total.Events != 0 || total.Alerts != 0) {
+			t.Fatalf("repeated import = %+v, want nothing new", total)
+		}
+	}
+
+	imported, ok := st.GetContainer("db")
+	if !ok || imported.Present {
+		t.Fatalf("expected imported container db to exist as removed, got %+v (found=%v)", imported, ok)
+	}
+	alerts, err := st.ListAlerts(ctx, "db", 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 || alerts[0].Type != "healthy" || alerts[1].Type != "unhealthy" || alerts[1].Message != "Uptime Kuma reported down: container exited" {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+
+	events, err := st.ListEvents(ctx, "web", 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events for web, got %+v", events)
+	}
+	if events[0].Type != "image_changed" || events[0].NewImage != "nginx:latest" || events[0].NewImageID != "0123456789ab" {
+		t.Fatalf("unexpected watchtower event: %+v", events[0])
+	}
+	if events[1].Type != "image_update_available" || events[1].Reason != "import:diun" {
+		t.Fatalf("unexpected diun event: %+v", events[1])
+	}
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// backfilledReason marks events reconstructed from daemon logs.
+const backfilledReason = "backfilled"
+
+// containerdTaskPath prefixes the shim path containerd logs for a task of a
+// Docker container; the container id follows.
+const containerdTaskPath = "/io.containerd.runtime.v2.task/moby/"
+
+// parseJournald reads dockerd and containerd logs, as written by
+// `journalctl -u docker -u containerd -o json` or in plain text, and
+// reconstructs the restart (die) and started events of Docker containers.
+// The daemons only log container ids, so events are matched to containers
+// on import, and events healthmon recorded itself are skipped.
+//
+// dockerd logs every task exit as an ignored TaskDelete event; containerd
+// logs "starting signal loop" with the task's path when a container starts.
+func parseJournald(r io.Reader) (History, error) {
+	h := History{Backfill: true}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var journalTime time.Time
+		if strings.HasPrefix(line, "{") {
+			var entry struct {
+				Message  json.RawMessage `json:"MESSAGE"`
+				Realtime string          `json:"__REALTIME_TIMESTAMP"`
+			}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return History{}, fmt.Errorf("parse journal line %d: %w", lineNo, err)
+			}
+			// journalctl writes messages that are not valid UTF-8 as byte
+			// arrays; those are never the lines looked for here.
+			if err := json.Unmarshal(entry.Message, &line); err != nil {
+				continue
+			}
+			if us, err := strconv.ParseInt(entry.Realtime, 10, 64); err == nil {
+				journalTime = time.UnixMicro(us).UTC()
+			}
+		}
+		// Text exports prefix the logfmt part with the journal's timestamp,
+		// host and process.
+		if i := strings.Index(line, "time="); i > 0 {
+			line = line[i:]
+		}
+		fields := parseLogfmt(line)
+
+		var e store.Event
+		switch {
+		case fields["msg"] == "ignoring event" && fields["topic"] == "/tasks/delete" && fields["namespace"] == "moby":
+			e = store.Event{ContainerID: fields["container"], Type: "restart", Message: "Container died (backfilled from dockerd logs)"}
+		case fields["msg"] == "starting signal loop" && strings.Contains(fields["path"], containerdTaskPath):
+			path := fields["path"]
+			e = store.Event{ContainerID: path[strings.Index(path, containerdTaskPath)+len(containerdTaskPath):], Type: "started", Message: "Container started (backfilled from containerd logs)"}
+		default:
+			continue
+		}
+		if e.ContainerID == "" {
+			h.Skipped++
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, fields["time"])
+		switch {
+		case err == nil:
+			e.Timestamp = ts.UTC()
+		case !journalTime.IsZero():
+			e.Timestamp = journalTime
+		default:
+			return History{}, fmt.Errorf("parse journal line %d: invalid time %q", lineNo, fields["time"])
+		}
+		e.Severity = "blue"
+		e.Reason = backfilledReason
+		e.DetailsJSON = store.EncodeDetails(store.BackfillDetails{Source: "journald"})
+		h.Events = append(h.Events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return History{}, err
+	}
+	return h, nil
+}
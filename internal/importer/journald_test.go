@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestJournaldBackfillsOnlyGaps(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	webID := strings.Repeat("a", 64)
+	otherID := strings.Repeat("b", 64)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: webID, Image: "nginx", CreatedAt: now, StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+	if _, err := st.AddEvent(ctx, store.Event{ContainerPK: web.ID, Container: "web", ContainerID: webID, Type: "restart", Severity: "blue", Message: "Restart event: die", Timestamp: time.Date(2026, 3, 1, 10, 0, 2, 0, time.UTC), Reason: "die"}); err != nil {
+		t.Fatalf("add event: %v", err)
+	}
+
+	journal := strings.Join([]string{
+		// Seen live by healthmon.
+		`{"__REALTIME_TIMESTAMP":"1772359201000000","_SYSTEMD_UNIT":"docker.service","MESSAGE":"time=\"2026-03-01T10:00:01.123456789Z\" level=info msg=\"ignoring event\" container=` + webID + ` module=libcontainerd namespace=moby topic=/tasks/delete type=\"*events.TaskDelete\""}`,
+		// Missed while healthmon was down.
+		`{"__REALTIME_TIMESTAMP":"1772362800000000","_SYSTEMD_UNIT":"docker.service","MESSAGE":"time=\"2026-03-01T11:00:00.000000000Z\" level=info msg=\"ignoring event\" container=` + webID + ` module=libcontainerd namespace=moby topic=/tasks/delete type=\"*events.TaskDelete\""}`,
+		`2026-03-01T11:00:01+0000 host containerd[812]: time="2026-03-01T11:00:01.5Z" level=info msg="starting signal loop" namespace=moby path=/run/containerd/io.containerd.runtime.v2.task/moby/` + webID + ` pid=4242 runtime=io.containerd.runc.v2`,
+		// Unknown to healthmon, and not a container event.
+		`time="2026-03-01T11:05:00Z" level=info msg="ignoring event" container=` + otherID + ` module=libcontainerd namespace=moby topic=/tasks/delete type="*events.TaskDelete"`,
+		`time="2026-03-01T11:06:00Z" level=info msg="API listen on /run/docker.sock"`,
+	}, "\n")
+
+	for round := 0; round < 2; round++ {
+		h, err := Parse(SourceJournald, strings.NewReader(journal))
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		res, err := Import(ctx, st, h)
+		if err != nil {
+			t.Fatalf("import: %v", err)
+		}
+		if round == 0 && (res.Events != 2 || res.Skipped != 1) {
+			t.Fatalf("first import = %+v, want 2 events and 1 skipped", res)
+		}
+		if round == 1 && res.Events != 0 {
+			t.Fatalf("repeated import = %+v, want nothing new", res)
+		}
+	}
+
+	events, err := st.ListEvents(ctx, "web", 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events for web, got %+v", events)
+	}
+	if events[0].Type != "started" || events[0].Reason != "backfilled" || !events[0].Timestamp.Equal(time.Date(2026, 3, 1, 11, 0, 1, 0, time.UTC)) {
+		t.Fatalf("unexpected start event: %+v", events[0])
+	}
+	if events[1].Type != "restart" || events[1].Reason != "backfilled" || events[1].ContainerID != webID {
+		t.Fatalf("unexpected die event: %+v", events[1])
+	}
+}
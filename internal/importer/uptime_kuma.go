@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// Uptime Kuma heartbeat statuses. Pending (2) and maintenance (3) are not
+// status changes healthmon would have alerted on.
+const (
+	kumaDown = 0
+	kumaUp   = 1
+)
+
+// kumaExport is the subset of an Uptime Kuma backup (Settings -> Backup ->
+// Export) that healthmon understands. Heartbeats are keyed by monitor id;
+// older exports call the list importantHeartbeatList.
+type kumaExport struct {
+	Version     string                     `json:"version"`
+	MonitorList []kumaMonitor              `json:"monitorList"`
+	Heartbeats  map[string][]kumaHeartbeat `json:"heartbeatList"`
+	Important   map[string][]kumaHeartbeat `json:"importantHeartbeatList"`
+}
+
+type kumaMonitor struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	DockerContainer string `json:"docker_container"`
+}
+
+type kumaHeartbeat struct {
+	Status int    `json:"status"`
+	Msg    string `json:"msg"`
+	Time   string `json:"time"`
+}
+
+// parseUptimeKuma turns status changes of Docker container monitors into
+// unhealthy and healthy alerts. Other monitor types do not name a container
+// and are skipped.
+func parseUptimeKuma(r io.Reader) (History, error) {
+	var export kumaExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return History{}, fmt.Errorf("parse uptime kuma export: %w", err)
+	}
+	if export.MonitorList == nil {
+		return History{}, fmt.Errorf("parse uptime kuma export: no monitorList")
+	}
+
+	heartbeats := export.Heartbeats
+	if len(heartbeats) == 0 {
+		heartbeats = export.Important
+	}
+
+	var h History
+	for _, mon := range export.MonitorList {
+		container := strings.TrimPrefix(mon.DockerContainer, "/")
+		if mon.Type != "docker" || container == "" {
+			h.Skipped++
+			continue
+		}
+
+		beats := append([]kumaHeartbeat(nil), heartbeats[strconv.FormatInt(mon.ID, 10)]...)
+		sort.SliceStable(beats, func(i, j int) bool { return beats[i].Time < beats[j].Time })
+
+		prev := -1
+		for _, beat := range beats {
+			if beat.Status != kumaDown && beat.Status != kumaUp {
+				continue
+			}
+			if beat.Status == prev {
+				continue
+			}
+			ts, err := parseKumaTime(beat.Time)
+			if err != nil {
+				return History{}, fmt.Errorf("parse uptime kuma heartbeat for %s: %w", mon.Name, err)
+			}
+			alert := store.Alert{
+				Container: container,
+				Timestamp: ts,
+				Reason:    reason(SourceUptimeKuma),
+			}
+			if beat.Status == kumaDown {
+				alert.Type = "unhealthy"
+				alert.Severity = "red"
+				alert.Message = "Uptime Kuma reported down"
+			} else {
+				// The first heartbeat of a monitor is usually up; only a
+				// recovery is worth an alert.
+				if prev == -1 {
+					prev = beat.Status
+					continue
+				}
+				alert.Type = "healthy"
+				alert.Severity = "green"
+				alert.Message = "Uptime Kuma reported up"
+			}
+			if msg := strings.TrimSpace(beat.Msg); msg != "" {
+				alert.Message += ": " + msg
+			}
+			h.Alerts = append(h.Alerts, alert)
+			prev = beat.Status
+		}
+	}
+	return h, nil
+}
+
+// parseKumaTime parses heartbeat times, which Uptime Kuma stores in UTC
+// without a zone.
+func parseKumaTime(val string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05.000", "2006-01-02 15:04:05", time.RFC3339Nano} {
+		if ts, err := time.Parse(layout, val); err == nil {
+			return ts.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", val)
+}
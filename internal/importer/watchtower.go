@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+var (
+	watchtowerFound    = regexp.MustCompile(`^Found new (\S+) image \((?:sha256:)?([0-9a-f]+)\)$`)
+	watchtowerCreating = regexp.MustCompile(`^Creating /(\S+)$`)
+	watchtowerSession  = regexp.MustCompile(`^Session done`)
+)
+
+// parseWatchtower reads watchtower's logfmt output and records an
+// image_changed event for every container it recreated. Watchtower logs the
+// images it pulled before it recreates anything and does not say which image
+// belongs to which container, so the image is only attached when a session
+// updated a single image.
+func parseWatchtower(r io.Reader) (History, error) {
+	var h History
+	type found struct {
+		image string
+		id    string
+	}
+	var pending []found
+	var created []int
+
+	endSession := func() {
+		if len(pending) == 1 {
+			for _, idx := range created {
+				h.Events[idx].NewImage = pending[0].image
+				h.Events[idx].NewImageID = pending[0].id
+			}
+		}
+		pending = nil
+		created = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := parseLogfmt(line)
+		msg := fields["msg"]
+		switch {
+		case watchtowerFound.MatchString(msg):
+			match := watchtowerFound.FindStringSubmatch(msg)
+			pending = append(pending, found{image: match[1], id: match[2]})
+		case watchtowerCreating.MatchString(msg):
+			ts, err := time.Parse(time.RFC3339Nano, fields["time"])
+			if err != nil {
+				return History{}, fmt.Errorf("parse watchtower log line %d: invalid time %q", lineNo, fields["time"])
+			}
+			name := watchtowerCreating.FindStringSubmatch(msg)[1]
+			created = append(created, len(h.Events))
+			h.Events = append(h.Events, store.Event{
+				Container: name,
+				Type:      "image_changed",
+				Severity:  "blue",
+				Message:   "Container recreated by watchtower",
+				Timestamp: ts.UTC(),
+				Reason:    reason(SourceWatchtower),
+			})
+		case watchtowerSession.MatchString(msg):
+			endSession()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return History{}, err
+	}
+	endSession()
+	return h, nil
+}
+
+// parseLogfmt splits a logrus text line into its key=value pairs. Values may
+// be double-quoted with backslash escapes.
+func parseLogfmt(line string) map[string]string {
+	fields := make(map[string]string)
+	for len(line) > 0 {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			break
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		if strings.HasPrefix(line, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(line); i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+					b.WriteByte(line[i])
+					continue
+				}
+				if line[i] == '"' {
+					break
+				}
+				b.WriteByte(line[i])
+			}
+			fields[key] = b.String()
+			if i < len(line) {
+				i++
+			}
+			line = line[i:]
+			continue
+		}
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			end = len(line)
+		}
+		fields[key] = line[:end]
+		line = line[end:]
+	}
+	return fields
+}
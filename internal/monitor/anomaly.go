@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	// anomalyInterval is how often event rates are compared to their
+	// baseline.
+	anomalyInterval = time.Hour
+	// anomalyMinHistory is how many days of history a container needs
+	// before its rates are judged, so new containers are left alone.
+	anomalyMinHistory = 3
+	anomalyDay        = 24 * time.Hour
+)
+
+// eventBaseline is the daily count of one event type on one container over
+// the past days, and its count over the last 24 hours.
+type eventBaseline struct {
+	container string
+	eventType string
+	days      []float64
+	current   int64
+}
+
+// meanStdDev returns the mean and the population standard deviation of
+// values.
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// anomalous reports whether the current count is more than z standard
+// deviations above the mean. The deviation is taken as at least 1, so a
+// container that never had an event of a type is not flagged for a few.
+func (b eventBaseline) anomalous(z float64, minEvents int64) (mean, stddev float64, ok bool) {
+	mean, stddev = meanStdDev(b.days)
+	if b.current < minEvents {
+		return mean, stddev, false
+	}
+	return mean, stddev, float64(b.current) > mean+z*math.Max(stddev, 1)
+}
+
+// eventBaselines groups hourly event rates into rolling days ending at end:
+// day 0 is the last 24 hours, the days before it are the history. Days are
+// half-open, so the bucket starting exactly 24 hours before end is the
+// first hour of day 0. History starts at the first day a container had any
+// event.
+func eventBaselines(rates []store.EventRate, end time.Time, days int) []eventBaseline {
+	first := make(map[string]int)
+	counts := make(map[[2]string][]int64)
+	var order [][2]string
+	for _, r := range rates {
+		if !r.Start.Before(end) {
+			continue
+		}
+		index := int((end.Sub(r.Start) - time.Nanosecond) / anomalyDay)
+		if index > days {
+			continue
+		}
+		if index > first[r.Container] {
+			first[r.Container] = index
+		}
+		key := [2]string{r.Container, r.Type}
+		if _, ok := counts[key]; !ok {
+			counts[key] = make([]int64, days+1)
+			order = append(order, key)
+		}
+		counts[key][index] += r.Count
+	}
+	baselines := make([]eventBaseline, 0, len(order))
+	for _, key := range order {
+		history := first[key[0]]
+		b := eventBaseline{container: key[0], eventType: key[1], current: counts[key][0], days: make([]float64, 0, history)}
+		for i := 1; i <= history; i++ {
+			b.days = append(b.days, float64(counts[key][i]))
+		}
+		baselines = append(baselines, b)
+	}
+	return baselines
+}
+
+// watchAnomalies compares every container's event rates to their history
+// once an hour.
+func (m *Monitor) watchAnomalies(ctx context.Context) {
+	if m.cfg.AnomalyDays <= 0 {
+		return
+	}
+	ticker := m.clock.NewTicker(anomalyInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.guard(ctx, "anomaly check", func() { m.checkAnomalies(ctx) })
+		}
+	}
+}
+
+// checkAnomalies records an anomaly event for each container and event
+// type whose count over the last 24 hours lies more than HM_ANOMALY_ZSCORE
+// standard deviations above its daily mean over the HM_ANOMALY_DAYS
+// before, such as a container recreated a dozen times in a day. Each
+// container and type is reported at most once a day.
+func (m *Monitor) checkAnomalies(ctx context.Context) {
+	now := m.clock.Now()
+	end := now.Truncate(time.Hour).Add(time.Hour)
+	rates, err := m.store.EventRates(ctx, end.Add(-time.Duration(m.cfg.AnomalyDays+1)*anomalyDay), time.Hour)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("anomaly check failed: %v", err)
+		}
+		return
+	}
+	recent, err := m.store.ListAllEvents(ctx, store.Filter{Types: []string{"anomaly"}, Since: now.Add(-anomalyDay)}, 0, 1000)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("anomaly check failed: %v", err)
+		}
+		return
+	}
+	reported := make(map[[2]string]bool, len(recent))
+	for _, e := range recent {
+		if details, err := store.DecodeDetails(e.DetailsJSON); err == nil {
+			if d, ok := details.(*store.AnomalyDetails); ok {
+				reported[[2]string{e.Container, d.EventType}] = true
+			}
+		}
+	}
+
+	z := float64(m.cfg.AnomalyZScore)
+	for _, b := range eventBaselines(rates, end, m.cfg.AnomalyDays) {
+		if b.eventType == "anomaly" || len(b.days) < anomalyMinHistory || reported[[2]string{b.container, b.eventType}] {
+			continue
+		}
+		mean, stddev, ok := b.anomalous(z, int64(m.cfg.AnomalyMinEvents))
+		if !ok {
+			continue
+		}
+		c, found := m.store.GetContainer(b.container)
+		if !found {
+			continue
+		}
+		message := fmt.Sprintf("%d %s events in the last 24h, against %.1f ± %.1f a day over the %d days before",
+			b.current, b.eventType, mean, stddev, len(b.days))
+		e := m.infoEvent(c.Name, c.ContainerID, "", "anomaly", message, "", "", "", "", "baseline", nil)
+		e.DetailsJSON = store.EncodeDetails(store.AnomalyDetails{
+			EventType: b.eventType,
+			Count:     b.current,
+			Mean:      mean,
+			StdDev:    stddev,
+			Days:      len(b.days),
+		})
+		m.emitEvent(ctx, e)
+	}
+}
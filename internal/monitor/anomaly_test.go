@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestAnomalyFlagsUnusualEventRates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{AnomalyDays: 14, AnomalyZScore: 3, AnomalyMinEvents: 5}
+	mon, st := newStatsMonitor(t, cfg,
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true},
+		store.Container{Name: "db", ContainerID: "cid-db", Status: "running", Present: true},
+		store.Container{Name: "fresh", ContainerID: "cid-fresh", Status: "running", Present: true},
+	)
+	fake := clock.NewFake(now)
+	mon.WithClock(fake)
+
+	emit := func(name, eventType string, at time.Time, n int) {
+		fake.Set(at)
+		for range n {
+			mon.emitInfo(ctx, name, "cid-"+name, "", eventType, "Event", "", "", "", "", "", nil)
+		}
+	}
+	for d := 10; d >= 1; d-- {
+		emit("web", "image_changed", now.Add(-time.Duration(d)*24*time.Hour), (d+1)%2)
+		emit("db", "restart", now.Add(-time.Duration(d)*24*time.Hour), 8)
+	}
+	emit("web", "image_changed", now.Add(-2*time.Hour), 12)
+	emit("db", "restart", now.Add(-2*time.Hour), 9)
+	emit("fresh", "restart", now.Add(-2*time.Hour), 20)
+	fake.Set(now)
+
+	mon.checkAnomalies(ctx)
+	mon.checkAnomalies(ctx)
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"anomaly"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Container != "web" {
+		t.Fatalf("expected one anomaly on web, got %+v", events)
+	}
+	if events[0].Message != "12 image_changed events in the last 24h, against 0.5 ± 0.5 a day over the 10 days before" {
+		t.Fatalf("unexpected message %q", events[0].Message)
+	}
+	details, err := store.DecodeDetails(events[0].DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.AnomalyDetails); !ok || d.EventType != "image_changed" || d.Count != 12 || d.Days != 10 {
+		t.Fatalf("unexpected details %+v", details)
+	}
+}
+
+func TestEventBaselinesSplitDaysAtTheirBoundaries(t *testing.T) {
+	end := time.Date(2026, 3, 1, 13, 0, 0, 0, time.UTC)
+	rate := func(start time.Time, n int64) store.EventRate {
+		return store.EventRate{Container: "web", Type: "restart", Start: start, Count: n}
+	}
+	baselines := eventBaselines([]store.EventRate{
+		rate(end, 100),
+		rate(end.Add(-time.Hour), 1),
+		rate(end.Add(-anomalyDay), 2),
+		rate(end.Add(-anomalyDay-time.Hour), 4),
+		rate(end.Add(-2*anomalyDay), 8),
+		rate(end.Add(-3*anomalyDay), 16),
+		rate(end.Add(-3*anomalyDay-time.Hour), 32),
+	}, end, 2)
+	if len(baselines) != 1 {
+		t.Fatalf("expected one baseline, got %+v", baselines)
+	}
+	b := baselines[0]
+	if b.current != 3 {
+		t.Fatalf("expected the last 24 hours to hold the hours starting at and after end-24h, got %d", b.current)
+	}
+	if len(b.days) != 2 || b.days[0] != 12 || b.days[1] != 16 {
+		t.Fatalf("expected each earlier day to end just before the next one, got %v", b.days)
+	}
+}
@@ -0,0 +1,105 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	autohealLabel      = "healthmon.autoheal"
+	autohealAfterLabel = "healthmon.autoheal_after"
+)
+
+// autohealState counts the restarts autoheal made for one unhealthy spell.
+type autohealState struct {
+	attempts int
+	last     time.Time
+	gaveUp   bool
+}
+
+// autohealTracker remembers the autoheal restarts of each container until it
+// is healthy again. It is only used by the heal check goroutine.
+type autohealTracker struct {
+	states map[string]autohealState
+}
+
+func newAutohealTracker() *autohealTracker {
+	return &autohealTracker{states: make(map[string]autohealState)}
+}
+
+// autohealAfter returns how long a container opted in with
+// healthmon.autoheal=true must be unhealthy before it is restarted, or false
+// when it did not opt in.
+func (m *Monitor) autohealAfter(c store.Container) (time.Duration, bool) {
+	if enabled, err := strconv.ParseBool(c.Labels[autohealLabel]); err != nil || !enabled {
+		return 0, false
+	}
+	if value := strings.TrimSpace(c.Labels[autohealAfterLabel]); value != "" {
+		if after, err := time.ParseDuration(value); err == nil && after > 0 {
+			return after, true
+		}
+		log.Printf("ignoring invalid %s label %q", autohealAfterLabel, value)
+	}
+	return time.Duration(m.cfg.AutohealAfterSeconds) * time.Second, true
+}
+
+// checkAutoheal restarts opted-in containers that have been unhealthy for
+// their autoheal period. Each further attempt waits twice as long as the
+// one before, and after HM_AUTOHEAL_MAX_ATTEMPTS autoheal gives up until the
+// container is healthy again. Containers in a restart loop are left alone:
+// restarting them would only fight Docker's restart policy.
+func (m *Monitor) checkAutoheal(ctx context.Context) {
+	now := m.clock.Now()
+	seen := make(map[string]bool)
+	for _, c := range m.store.ListContainers() {
+		after, ok := m.autohealAfter(c)
+		if !ok || !strings.EqualFold(c.HealthStatus, "unhealthy") || c.UnhealthySince.IsZero() {
+			continue
+		}
+		seen[c.Name] = true
+		state := m.autoheal.states[c.Name]
+		if state.gaveUp || c.RestartLoop || !strings.EqualFold(c.Status, "running") || now.Sub(c.UnhealthySince) < after {
+			continue
+		}
+		if _, busy := m.selfActions.active(c.Name, now); busy {
+			continue
+		}
+		if state.attempts > 0 && now.Sub(state.last) < after<<state.attempts {
+			continue
+		}
+		if state.attempts >= m.cfg.AutohealMaxAttempts {
+			state.gaveUp = true
+			m.autoheal.states[c.Name] = state
+			m.emitAlert(ctx, c.Name, c.ContainerID, "", "autoheal_gave_up",
+				fmt.Sprintf("Autoheal gave up after %d restarts, container is still unhealthy", state.attempts), "red", nil)
+			continue
+		}
+		state.attempts++
+		state.last = now
+		m.autoheal.states[c.Name] = state
+		if err := m.restartContainer(ctx, c, "autoheal"); err != nil {
+			log.Printf("autoheal restart of %s failed: %v", c.Name, err)
+			m.emitAlert(ctx, c.Name, c.ContainerID, "", "autoheal_failed", fmt.Sprintf("Autoheal restart failed: %v", err), "red", nil)
+			continue
+		}
+		message := fmt.Sprintf("Restarted after being unhealthy for %s (attempt %d of %d)",
+			now.Sub(c.UnhealthySince).Round(time.Second), state.attempts, m.cfg.AutohealMaxAttempts)
+		m.emitInfo(ctx, c.Name, c.ContainerID, "", "autoheal_restart", message, "", "", "", "", "autoheal", nil)
+	}
+	// A container that is healthy again, or gone, starts over.
+	for name := range m.autoheal.states {
+		if seen[name] {
+			continue
+		}
+		if c, ok := m.store.GetContainer(name); ok && c.Present && strings.EqualFold(c.HealthStatus, "starting") {
+			continue
+		}
+		delete(m.autoheal.states, name)
+	}
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+func TestAutohealRestartsUnhealthyContainersWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDockerServer(t, nil, nil)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	labels := map[string]string{autohealLabel: "true"}
+	web := store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true, Labels: labels, HealthStatus: "unhealthy", UnhealthySince: start}
+	loop := store.Container{Name: "loop", ContainerID: "cid-loop", Status: "running", Present: true, Labels: labels, HealthStatus: "unhealthy", UnhealthySince: start, RestartLoop: true}
+	plain := store.Container{Name: "plain", ContainerID: "cid-plain", Status: "running", Present: true, HealthStatus: "unhealthy", UnhealthySince: start}
+	mon, st := newStatsMonitor(t, config.Config{AutohealAfterSeconds: 300, AutohealMaxAttempts: 2}, web, loop, plain)
+	mon.WithClock(fake)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	restarts := func() int {
+		mock.mu.Lock()
+		defer mock.mu.Unlock()
+		return len(mock.restarted)
+	}
+	// Not unhealthy for long enough yet.
+	fake.Advance(4 * time.Minute)
+	mon.checkAutoheal(ctx)
+	if n := restarts(); n != 0 {
+		t.Fatalf("expected no restarts yet, got %d", n)
+	}
+	fake.Advance(time.Minute)
+	mon.checkAutoheal(ctx)
+	// The second attempt waits twice the period.
+	fake.Advance(5 * time.Minute)
+	mon.checkAutoheal(ctx)
+	if n := restarts(); n != 1 {
+		t.Fatalf("expected one restart before the backoff passed, got %d", n)
+	}
+	fake.Advance(5 * time.Minute)
+	mon.checkAutoheal(ctx)
+	fake.Advance(20 * time.Minute)
+	mon.checkAutoheal(ctx)
+	mon.checkAutoheal(ctx)
+	mock.mu.Lock()
+	restarted := append([]string(nil), mock.restarted...)
+	mock.mu.Unlock()
+	if len(restarted) != 2 || restarted[0] != "cid-web" || restarted[1] != "cid-web" {
+		t.Fatalf("expected two restarts of cid-web, got %v", restarted)
+	}
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"autoheal_restart"}, Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 || events[0].Container != "web" || events[1].Message != "Restarted after being unhealthy for 15m0s (attempt 2 of 2)" {
+		t.Fatalf("unexpected autoheal events %+v", events)
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"autoheal_gave_up"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Container != "web" {
+		t.Fatalf("expected autoheal to give up once, got %+v", alerts)
+	}
+
+	// Healthy again, a new unhealthy spell starts over.
+	web.HealthStatus, web.UnhealthySince = "healthy", time.Time{}
+	if err := st.UpsertContainer(ctx, web); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	mon.checkAutoheal(ctx)
+	if _, ok := mon.autoheal.states["web"]; ok {
+		t.Fatalf("expected autoheal state to be reset")
+	}
+}
@@ -0,0 +1,188 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"strings"
+
+	"healthmon/internal/api"
+	"healthmon/internal/store"
+)
+
+// severityAliases lets HM_ALERT_SEVERITIES name severities by meaning as
+// well as by color.
+var severityAliases = map[string]string{
+	"red":      "red",
+	"critical": "red",
+	"yellow":   "yellow",
+	"warning":  "yellow",
+	"blue":     "blue",
+	"info":     "blue",
+	"green":    "green",
+	"ok":       "green",
+}
+
+// silentSeverity keeps an alert type's severity but stops its notifications.
+const silentSeverity = "none"
+
+var alertTypePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// alertRule raises an alert of its own type for every event of a given type,
+// e.g. for annotations posted by a deploy script or events imported from
+// another tool, which otherwise never alert.
+type alertRule struct {
+	alertType string
+	event     string
+	container string
+	severity  string
+	message   string
+}
+
+func (r alertRule) matches(e *api.EventResponse) bool {
+	if e == nil || e.Type != r.event {
+		return false
+	}
+	if r.container == "" {
+		return true
+	}
+	ok, _ := path.Match(r.container, e.Container)
+	return ok
+}
+
+// alertCatalog is the configurable part of the alert types: severities that
+// replace the built-in ones and rules that add types of their own.
+type alertCatalog struct {
+	severities map[string]string
+	silent     map[string]bool
+	rules      []alertRule
+}
+
+// newAlertCatalog reads HM_ALERT_SEVERITIES and HM_ALERT_RULES. Invalid
+// entries are logged and skipped.
+func newAlertCatalog(severities, rules string) alertCatalog {
+	c := alertCatalog{severities: make(map[string]string), silent: make(map[string]bool)}
+	for _, entry := range strings.Split(severities, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		alertType, severity, err := parseAlertSeverity(entry)
+		if err != nil {
+			log.Printf("ignoring HM_ALERT_SEVERITIES entry %q: %v", entry, err)
+			continue
+		}
+		if severity == silentSeverity {
+			c.silent[alertType] = true
+		} else {
+			c.severities[alertType] = severity
+		}
+	}
+	for _, entry := range strings.Split(rules, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		rule, err := parseAlertRule(entry)
+		if err != nil {
+			log.Printf("ignoring HM_ALERT_RULES entry %q: %v", entry, err)
+			continue
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c
+}
+
+// parseAlertSeverity parses a "type=severity" entry.
+func parseAlertSeverity(entry string) (string, string, error) {
+	alertType, severity, ok := strings.Cut(entry, "=")
+	alertType = strings.TrimSpace(alertType)
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if !ok || !alertTypePattern.MatchString(alertType) {
+		return "", "", fmt.Errorf("expected <alert type>=<severity>")
+	}
+	if severity == silentSeverity {
+		return alertType, severity, nil
+	}
+	color, ok := severityAliases[severity]
+	if !ok {
+		return "", "", fmt.Errorf("unknown severity %q, expected red, yellow, blue, green or none", severity)
+	}
+	return alertType, color, nil
+}
+
+// parseAlertRule parses a rule like
+// "type=deploy_failed,event=deploy_failed,container=web*,severity=red".
+// The alert type and the event type are required; severity defaults to
+// yellow and the message to the event's.
+func parseAlertRule(entry string) (alertRule, error) {
+	rule := alertRule{severity: "yellow"}
+	for _, field := range strings.Split(entry, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return alertRule{}, fmt.Errorf("expected key=value, got %q", field)
+		}
+		switch key {
+		case "type":
+			rule.alertType = value
+		case "event":
+			rule.event = value
+		case "container":
+			if _, err := path.Match(value, ""); err != nil {
+				return alertRule{}, fmt.Errorf("invalid container pattern %q", value)
+			}
+			rule.container = value
+		case "severity":
+			color, ok := severityAliases[strings.ToLower(value)]
+			if !ok {
+				return alertRule{}, fmt.Errorf("unknown severity %q, expected red, yellow, blue or green", value)
+			}
+			rule.severity = color
+		case "message":
+			rule.message = value
+		default:
+			return alertRule{}, fmt.Errorf("unknown field %q, expected type, event, container, severity or message", key)
+		}
+	}
+	if !alertTypePattern.MatchString(rule.alertType) || rule.event == "" {
+		return alertRule{}, fmt.Errorf("type and event are required")
+	}
+	return rule, nil
+}
+
+// severity returns the severity an alert of the given type is raised with.
+func (c alertCatalog) severity(alertType, builtin string) string {
+	if severity, ok := c.severities[alertType]; ok {
+		return severity
+	}
+	return builtin
+}
+
+// applyAlertRules raises the alerts the rules ask for when an event is
+// broadcast, whether it comes from Docker, an importer or the API.
+func (m *Monitor) applyAlertRules(ctx context.Context, update api.EventUpdate) {
+	if update.Event == nil {
+		return
+	}
+	e := update.Event
+	for _, rule := range m.catalog.rules {
+		if !rule.matches(e) {
+			continue
+		}
+		message := rule.message
+		if message == "" {
+			message = e.Message
+		}
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:           e.Container,
+			ContainerID:         e.ContainerID,
+			ParsedContainerName: e.ParsedContainerName,
+			Type:                rule.alertType,
+			Severity:            rule.severity,
+			Message:             message,
+			Timestamp:           m.clock.Now(),
+			Reason:              e.Type,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestAlertCatalogOverridesSeveritiesAndAddsRuleTypes(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	var delivered atomic.Int32
+	apprise := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer apprise.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{
+		AppriseURL:      apprise.URL,
+		AlertSeverities: "failure_no_restart=warning, image_changed=none",
+		AlertRules:      "type=deploy_failed,event=deploy_failed,container=web*,severity=critical;type=ignored,event=deploy_failed,container=db",
+	}
+	mon := New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(clock.NewFake(now))
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "cid-web", CreatedAt: now, StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	mon.emitAlert(ctx, "web", "cid-web", "web", "failure_no_restart", "Container failed without restart policy", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "image_changed", "Image updated", "blue", nil)
+	mon.emitEvent(ctx, store.Event{Container: "web", ContainerID: "cid-web", Type: "deploy_failed", Severity: "blue", Message: "Deploy v1.2.3 failed", Timestamp: now, Reason: "annotation"})
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Type+"/"+a.Severity+"/"+a.Message)
+	}
+	want := []string{
+		"failure_no_restart/yellow/Container failed without restart policy",
+		"image_changed/blue/Image updated",
+		"deploy_failed/red/Deploy v1.2.3 failed",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected alerts %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected alerts %v", got)
+		}
+	}
+	// image_changed is kept out of the notifications.
+	mon.notifiers.Retry(ctx)
+	if n := delivered.Load(); n != 2 {
+		t.Fatalf("expected 2 notifications, got %d", n)
+	}
+}
+
+func TestAlertCatalogSkipsInvalidEntries(t *testing.T) {
+	c := newAlertCatalog("restarted=purple,Bad Type=red,oom_killed=info", "type=x;event=y;type=deploy,event=deploy,colour=red;type=deploy,event=deploy")
+	if len(c.severities) != 1 || c.severity("oom_killed", "red") != "blue" || c.severity("restarted", "blue") != "blue" {
+		t.Fatalf("unexpected severities %v", c.severities)
+	}
+	if len(c.rules) != 1 || c.rules[0].alertType != "deploy" || c.rules[0].severity != "yellow" {
+		t.Fatalf("unexpected rules %+v", c.rules)
+	}
+}
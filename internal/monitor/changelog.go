@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// imageSourceLabel is the OCI label with the URL of the repository an
+	// image was built from.
+	imageSourceLabel = "org.opencontainers.image.source"
+	// changelogCacheSize bounds the versions whose release page is
+	// remembered; the least recently used is dropped first.
+	changelogCacheSize = 256
+	// changelogMissTTL is how long a version without a release page, or
+	// whose lookup failed, links the list of releases before it is looked
+	// up again.
+	changelogMissTTL = time.Hour
+)
+
+// changelogResolver finds the release notes of an image version on GitHub.
+// Lookups go over the network, so they run off the event loop; cached
+// answers are safe to use anywhere.
+type changelogResolver struct {
+	client *http.Client
+	// base is where repositories are looked up, https://github.com outside
+	// of tests.
+	base string
+	// lookup allows checking which release page exists; without it the
+	// list of releases is linked.
+	lookup bool
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// changelogEntry is a cached link. Misses expire, found pages do not.
+type changelogEntry struct {
+	key     string
+	link    string
+	expires time.Time
+}
+
+func newChangelogResolver(lookup bool, now func() time.Time) *changelogResolver {
+	return &changelogResolver{
+		client:  &http.Client{Timeout: 5 * time.Second},
+		base:    "https://github.com",
+		lookup:  lookup,
+		now:     now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// githubRepo returns "owner/repo" for a GitHub repository URL such as
+// https://github.com/owner/repo.git, or "" for anything else.
+func githubRepo(source string) string {
+	u, err := url.Parse(strings.TrimSpace(source))
+	if err != nil || !strings.EqualFold(strings.TrimPrefix(u.Host, "www."), "github.com") {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+}
+
+// cached returns the link resolve would return when it is known without a
+// lookup: for images not built from a GitHub repository, versions that
+// cannot be looked up and versions looked up before. ok is false when
+// resolve has to ask GitHub.
+func (r *changelogResolver) cached(source, version, tag string) (link string, ok bool) {
+	repo, version := changelogTarget(source, version, tag)
+	if repo == "" {
+		return "", true
+	}
+	if version == "" || !r.lookup {
+		return r.base + "/" + repo + "/releases", true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	el, found := r.entries[repo+"@"+version]
+	if !found {
+		return "", false
+	}
+	entry := el.Value.(*changelogEntry)
+	if !entry.expires.IsZero() && !r.now().Before(entry.expires) {
+		r.order.Remove(el)
+		delete(r.entries, entry.key)
+		return "", false
+	}
+	r.order.MoveToFront(el)
+	return entry.link, true
+}
+
+// resolve returns the link to the release notes of version, or of the
+// image tag when the image has no version label. The release is looked up
+// as tagged with and without a "v" prefix; when neither page exists, or
+// the version is unknown, the list of releases is linked instead. It
+// returns "" for images not built from a GitHub repository.
+func (r *changelogResolver) resolve(ctx context.Context, source, version, tag string) string {
+	if link, ok := r.cached(source, version, tag); ok {
+		return link
+	}
+	repo, version := changelogTarget(source, version, tag)
+	link := r.base + "/" + repo + "/releases"
+	candidates := []string{version}
+	if trimmed := strings.TrimPrefix(version, "v"); trimmed != version {
+		candidates = append(candidates, trimmed)
+	} else {
+		candidates = append(candidates, "v"+version)
+	}
+	expires := r.now().Add(changelogMissTTL)
+	for _, candidate := range candidates {
+		page := link + "/tag/" + url.PathEscape(candidate)
+		if r.exists(ctx, page) {
+			link = page
+			expires = time.Time{}
+			break
+		}
+	}
+	r.remember(repo+"@"+version, link, expires)
+	return link
+}
+
+// changelogTarget returns the GitHub repository of source and the version
+// to look up, the image tag when the version is unknown.
+func changelogTarget(source, version, tag string) (string, string) {
+	if version == "" && tag != "latest" {
+		version = tag
+	}
+	return githubRepo(source), version
+}
+
+func (r *changelogResolver) remember(key, link string, expires time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if el, ok := r.entries[key]; ok {
+		el.Value = &changelogEntry{key: key, link: link, expires: expires}
+		r.order.MoveToFront(el)
+		return
+	}
+	r.entries[key] = r.order.PushFront(&changelogEntry{key: key, link: link, expires: expires})
+	for r.order.Len() > changelogCacheSize {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*changelogEntry).key)
+	}
+}
+
+func (r *changelogResolver) exists(ctx context.Context, page string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, page, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
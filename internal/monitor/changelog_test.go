@@ -0,0 +1,159 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestChangelogResolvesGitHubReleases(t *testing.T) {
+	var requests []string
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path != "/example/app/releases/tag/v1.4.2" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer github.Close()
+
+	ctx := context.Background()
+	r := newChangelogResolver(true, time.Now)
+	r.base = github.URL
+	cases := []struct {
+		source, version, tag, want string
+	}{
+		{"https://github.com/example/app.git", "1.4.2", "latest", github.URL + "/example/app/releases/tag/v1.4.2"},
+		{"https://github.com/example/app", "", "v1.4.2", github.URL + "/example/app/releases/tag/v1.4.2"},
+		{"https://github.com/example/app", "2.0.0", "2", github.URL + "/example/app/releases"},
+		{"https://github.com/example/app", "", "latest", github.URL + "/example/app/releases"},
+		{"https://gitlab.com/example/app", "1.4.2", "", ""},
+		{"", "1.4.2", "", ""},
+	}
+	for _, c := range cases {
+		if got := r.resolve(ctx, c.source, c.version, c.tag); got != c.want {
+			t.Errorf("resolve(%q, %q, %q) = %q, want %q", c.source, c.version, c.tag, got, c.want)
+		}
+	}
+	// Resolved versions are not looked up again.
+	before := len(requests)
+	r.resolve(ctx, "https://github.com/example/app", "1.4.2", "")
+	if len(requests) != before || requests[0] != "HEAD /example/app/releases/tag/1.4.2" {
+		t.Fatalf("unexpected lookups %v", requests)
+	}
+}
+
+func TestChangelogCacheIsBoundedAndForgetsMisses(t *testing.T) {
+	lookups := 0
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		if !strings.HasSuffix(r.URL.Path, "/tag/v1.0.0") {
+			http.NotFound(w, r)
+		}
+	}))
+	defer github.Close()
+
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	r := newChangelogResolver(true, fake.Now)
+	r.base = github.URL
+	const source = "https://github.com/example/app"
+
+	if link := r.resolve(ctx, source, "2.0.0", ""); link != github.URL+"/example/app/releases" {
+		t.Fatalf("expected the list of releases for a missing release, got %q", link)
+	}
+	if _, ok := r.cached(source, "2.0.0", ""); !ok {
+		t.Fatalf("expected the miss to be cached for a while")
+	}
+	fake.Advance(changelogMissTTL)
+	if _, ok := r.cached(source, "2.0.0", ""); ok {
+		t.Fatalf("expected the miss to expire")
+	}
+
+	r.resolve(ctx, source, "1.0.0", "")
+	fake.Advance(changelogMissTTL)
+	if link, ok := r.cached(source, "1.0.0", ""); !ok || link != github.URL+"/example/app/releases/tag/v1.0.0" {
+		t.Fatalf("expected a found release to stay cached, got %q %v", link, ok)
+	}
+	for i := range changelogCacheSize {
+		r.remember("example/other@"+strconv.Itoa(i), "", time.Time{})
+	}
+	if _, ok := r.cached(source, "1.0.0", ""); ok || len(r.entries) != changelogCacheSize || r.order.Len() != changelogCacheSize {
+		t.Fatalf("expected the least recently used entry to be dropped, have %d entries", len(r.entries))
+	}
+	if lookups != 4 {
+		t.Fatalf("expected both spellings of each version to be looked up once, got %d lookups", lookups)
+	}
+}
+
+func TestImageChangeWaitsForChangelogOffTheEventLoop(t *testing.T) {
+	release := make(chan struct{})
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		if r.URL.Path != "/example/app/releases/tag/v1.4.2" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer github.Close()
+	defer close(release)
+
+	ctx := context.Background()
+	mon, st := newStatsMonitor(t, config.Config{ChangelogLookup: true}, store.Container{Name: "app", ContainerID: "cid-app", Status: "running", Present: true})
+	mon.changelog.base = github.URL
+	mon.images["sha256:old"] = imageMeta{Version: "1.4.1"}
+	mon.images["sha256:new"] = imageMeta{Version: "1.4.2", Source: "https://github.com/example/app"}
+	existing := store.Container{Name: "app", Image: "example/app:1.4.1", ImageID: "sha256:old"}
+	updated := store.Container{Name: "app", Image: "example/app:1.4.2", ImageID: "sha256:new", ImageTag: "1.4.2"}
+	imageChanges := func() []store.Event {
+		t.Helper()
+		events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"image_changed"}}, 0, 10)
+		if err != nil {
+			t.Fatalf("list events: %v", err)
+		}
+		return events
+	}
+
+	mon.emitImageChanged(ctx, existing, updated, "cid-app", "")
+	if events := imageChanges(); len(events) != 0 {
+		t.Fatalf("expected the update to wait for the lookup, got %+v", events)
+	}
+	release <- struct{}{}
+	release <- struct{}{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(imageChanges()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	events := imageChanges()
+	if len(events) != 1 || !strings.Contains(events[0].DetailsJSON, `"changelog_url":"`+github.URL+`/example/app/releases/tag/v1.4.2"`) {
+		t.Fatalf("expected the update with its release link, got %+v", events)
+	}
+
+	// The release page is known now, so the next update is recorded
+	// right away.
+	mon.emitImageChanged(ctx, existing, updated, "cid-app", "")
+	if events := imageChanges(); len(events) != 2 {
+		t.Fatalf("expected the second update to be recorded right away, got %d", len(events))
+	}
+}
+
+func TestTelegramLinksChangelog(t *testing.T) {
+	mon, _ := newStatsMonitor(t, config.Config{}, store.Container{Name: "app", ContainerID: "cid-app", Status: "running", Present: true})
+	link := "https://github.com/example/app/releases/tag/v1.4.2"
+	a := store.Alert{
+		Container:   "app",
+		Type:        "image_changed",
+		Severity:    "blue",
+		Message:     "Container image updated to 1.4.2",
+		DetailsJSON: store.EncodeDetails(store.ImageUpdateDetails{NewVersion: "1.4.2", ChangelogURL: link}),
+	}
+	if text := mon.telegramText(a, "HTML"); !strings.Contains(text, `Changelog: <a href="`+link+`">`) {
+		t.Fatalf("expected a changelog link, got %q", text)
+	}
+}
@@ -0,0 +1,174 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+	"healthmon/internal/systemd"
+
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/client"
+)
+
+const (
+	clockCheckLabel = "healthmon.check.clock"
+	// clockProbeTimeout bounds one exec of the clock probe.
+	clockProbeTimeout = 10 * time.Second
+)
+
+// clockProbeCmd prints the container's time in seconds. date from busybox
+// and coreutils both understand it; finer resolution is not portable.
+var clockProbeCmd = []string{"date", "-u", "+%s"}
+
+// clockWatch is the state of the clock checks. It is only used by their
+// goroutine.
+type clockWatch struct {
+	ntp      *systemd.Client
+	drifting map[string]bool
+	// ntpSynced is nil until the first successful read.
+	ntpSynced *bool
+}
+
+// clockCheckEnabled reports whether a container opted in to the clock
+// probe with healthmon.check.clock=true.
+func clockCheckEnabled(labels map[string]string) bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(labels[clockCheckLabel]))
+	return err == nil && enabled
+}
+
+// watchClocks compares the clocks of the containers labeled
+// healthmon.check.clock=true with the host's, and with HM_NTP_CHECK checks
+// that the host clock is synchronized, every HM_CLOCK_CHECK_SECONDS.
+func (m *Monitor) watchClocks(ctx context.Context) {
+	if m.cfg.ClockCheckSeconds <= 0 {
+		return
+	}
+	w := &clockWatch{drifting: make(map[string]bool)}
+	if m.cfg.NTPCheck {
+		w.ntp = systemd.New(m.cfg.SystemdBus)
+		defer w.ntp.Close()
+	}
+	ticker := m.clock.NewTicker(time.Duration(m.cfg.ClockCheckSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		m.guard(ctx, "clock checks", func() { m.checkClocks(ctx, w) })
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (m *Monitor) checkClocks(ctx context.Context, w *clockWatch) {
+	if w.ntp != nil {
+		if synced, err := w.ntp.NTPSynchronized(); err != nil {
+			log.Printf("NTP status: %v", err)
+		} else {
+			m.applyNTPStatus(ctx, w, synced)
+		}
+	}
+	for _, c := range m.store.ListContainers() {
+		if !c.Present || c.Status != "running" || c.ContainerID == "" || !clockCheckEnabled(c.Labels) {
+			continue
+		}
+		offset, err := m.probeClock(ctx, c.ContainerID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("clock probe for %s failed: %v", c.Name, err)
+			}
+			continue
+		}
+		m.applyClockOffset(ctx, w, c, offset)
+	}
+}
+
+// probeClock returns how far the container's clock is ahead of the host's,
+// to the second.
+func (m *Monitor) probeClock(ctx context.Context, containerID string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, clockProbeTimeout)
+	defer cancel()
+	created, err := m.docker.ExecCreate(ctx, containerID, client.ExecCreateOptions{Cmd: clockProbeCmd, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return 0, err
+	}
+	before := time.Now()
+	attached, err := m.docker.ExecAttach(ctx, created.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer attached.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attached.Reader); err != nil {
+		return 0, err
+	}
+	after := time.Now()
+	seconds, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected output %q %q", strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()))
+	}
+	// date truncates to the second, so the container's time is somewhere in
+	// the second it printed; compare with the middle of it and of the exec.
+	host := before.Add(after.Sub(before) / 2)
+	return time.Unix(seconds, 0).Add(500 * time.Millisecond).Sub(host), nil
+}
+
+// applyClockOffset raises clock_drift when a container's clock is off by
+// more than HM_CLOCK_DRIFT_SECONDS and clock_synced once it is back.
+func (m *Monitor) applyClockOffset(ctx context.Context, w *clockWatch, c store.Container, offset time.Duration) {
+	limit := time.Duration(m.cfg.ClockDriftSeconds) * time.Second
+	drifting := offset.Abs() > limit
+	if drifting == w.drifting[c.Name] {
+		return
+	}
+	w.drifting[c.Name] = drifting
+	seconds := math.Round(offset.Seconds())
+	direction := "ahead of"
+	if offset < 0 {
+		direction = "behind"
+	}
+	a := store.Alert{
+		Container:   c.Name,
+		ContainerID: c.ContainerID,
+		Timestamp:   m.clock.Now(),
+		DetailsJSON: store.EncodeDetails(store.ClockDetails{OffsetSeconds: seconds}),
+	}
+	if drifting {
+		a.Type, a.Severity = "clock_drift", "yellow"
+		a.Message = fmt.Sprintf("Container clock is %s %s the host", time.Duration(math.Abs(seconds))*time.Second, direction)
+	} else {
+		a.Type, a.Severity = "clock_synced", "green"
+		a.Message = "Container clock matches the host again"
+	}
+	m.emitAlertRecord(ctx, a)
+}
+
+// applyNTPStatus files ntp_unsynchronized on _healthmon when the host clock
+// loses its time server, and ntp_synchronized when it is back.
+func (m *Monitor) applyNTPStatus(ctx context.Context, w *clockWatch, synced bool) {
+	if w.ntpSynced != nil && *w.ntpSynced == synced {
+		return
+	}
+	first := w.ntpSynced == nil
+	w.ntpSynced = &synced
+	if first && synced {
+		return
+	}
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	a := store.Alert{Container: selfContainerName, Timestamp: m.clock.Now()}
+	if synced {
+		a.Type, a.Severity, a.Message = "ntp_synchronized", "green", "Host clock is synchronized to a time server again"
+	} else {
+		a.Type, a.Severity, a.Message = "ntp_unsynchronized", "yellow", "Host clock is not synchronized to a time server"
+	}
+	m.emitAlertRecord(ctx, a)
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestClockDriftAndNTPAlerts(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{ClockCheckSeconds: 300, ClockDriftSeconds: 5}
+	web := store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Labels: map[string]string{clockCheckLabel: "true"}}
+	mon, st := newStatsMonitor(t, cfg, web)
+	w := &clockWatch{drifting: make(map[string]bool)}
+
+	mon.applyClockOffset(ctx, w, web, 2*time.Second)
+	mon.applyClockOffset(ctx, w, web, -42*time.Second)
+	mon.applyClockOffset(ctx, w, web, -40*time.Second)
+	mon.applyClockOffset(ctx, w, web, 0)
+	// The first reading of a synchronized clock is not news.
+	mon.applyNTPStatus(ctx, w, true)
+	mon.applyNTPStatus(ctx, w, false)
+	mon.applyNTPStatus(ctx, w, false)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Container+" "+a.Type+": "+a.Message)
+	}
+	want := []string{
+		"web clock_drift: Container clock is 42s behind the host",
+		"web clock_synced: Container clock matches the host again",
+		"_healthmon ntp_unsynchronized: Host clock is not synchronized to a time server",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected alerts:\n%s", strings.Join(got, "\n"))
+	}
+	details, err := store.DecodeDetails(alerts[0].DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.ClockDetails); !ok || d.OffsetSeconds != -42 {
+		t.Fatalf("unexpected details %+v", details)
+	}
+	if clockCheckEnabled(map[string]string{clockCheckLabel: "yes"}) || !clockCheckEnabled(web.Labels) {
+		t.Fatalf("unexpected clock check label parsing")
+	}
+}
@@ -0,0 +1,106 @@
+package monitor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// loadConfigSalt reads the salt configuration fingerprints are hashed with.
+// Without it containers are not fingerprinted and config_changed is never
+// recorded.
+func (m *Monitor) loadConfigSalt(ctx context.Context) {
+	salt, err := m.store.ConfigHashSalt(ctx)
+	if err != nil {
+		log.Printf("config hash salt failed, configuration changes are not tracked: %v", err)
+		return
+	}
+	m.configSalt = salt
+}
+
+// configFingerprint hashes the environment and command of a container with
+// the salt, so changes can be told apart without storing secrets.
+func (m *Monitor) configFingerprint(cfg *container.Config) *store.ConfigFingerprint {
+	if m.configSalt == "" || cfg == nil {
+		return nil
+	}
+	hash := func(value string) string {
+		mac := hmac.New(sha256.New, []byte(m.configSalt))
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	f := &store.ConfigFingerprint{Env: make(map[string]string, len(cfg.Env))}
+	for _, entry := range cfg.Env {
+		name, _, _ := strings.Cut(entry, "=")
+		f.Env[name] = hash(entry)
+	}
+	command, _ := json.Marshal([][]string{cfg.Entrypoint, cfg.Cmd})
+	f.Command = hash(string(command))
+	return f
+}
+
+// diffConfig compares two fingerprints by variable name.
+func diffConfig(before, after *store.ConfigFingerprint) store.ConfigChangeDetails {
+	var d store.ConfigChangeDetails
+	for name, hash := range after.Env {
+		if previous, ok := before.Env[name]; !ok {
+			d.Added = append(d.Added, name)
+		} else if previous != hash {
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for name := range before.Env {
+		if _, ok := after.Env[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	slices.Sort(d.Added)
+	slices.Sort(d.Removed)
+	slices.Sort(d.Changed)
+	d.Command = before.Command != after.Command
+	return d
+}
+
+// configChangeMessage describes a change, e.g. "Configuration changed:
+// LOG_LEVEL changed; DEBUG added; command changed".
+func configChangeMessage(d store.ConfigChangeDetails) string {
+	var parts []string
+	for _, group := range []struct {
+		names []string
+		verb  string
+	}{{d.Changed, "changed"}, {d.Added, "added"}, {d.Removed, "removed"}} {
+		if len(group.names) > 0 {
+			parts = append(parts, strings.Join(group.names, ", ")+" "+group.verb)
+		}
+	}
+	if d.Command {
+		parts = append(parts, "command changed")
+	}
+	return "Configuration changed: " + strings.Join(parts, "; ")
+}
+
+// emitConfigChanged records a config_changed event when a recreated
+// container's environment or command differs from the previous instance.
+// Containers fingerprinted before an upgrade, or without a salt, are
+// skipped.
+func (m *Monitor) emitConfigChanged(ctx context.Context, existing, newInfo store.Container, id, parsedName string) {
+	if existing.Config == nil || newInfo.Config == nil {
+		return
+	}
+	d := diffConfig(existing.Config, newInfo.Config)
+	if len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && !d.Command {
+		return
+	}
+	e := m.infoEvent(newInfo.Name, id, parsedName, "config_changed", configChangeMessage(d), existing.Image, newInfo.Image, existing.ImageID, newInfo.ImageID, "recreate", nil)
+	e.DetailsJSON = store.EncodeDetails(d)
+	m.emitEvent(ctx, e)
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestRecreateWithNewEnvRecordsConfigChanged(t *testing.T) {
+	ctx := context.Background()
+	mon, st := newStatsMonitor(t, config.Config{})
+	mon.loadConfigSalt(ctx)
+	if mon.configSalt == "" {
+		t.Fatalf("expected a config hash salt")
+	}
+
+	before := store.Container{Name: "web", ContainerID: "cid-1", Status: "running", Present: true, Image: "web", ImageID: "sha256:web",
+		Config: mon.configFingerprint(&container.Config{Env: []string{"DB_PASSWORD=hunter2", "LOG_LEVEL=info", "DEBUG=1"}, Cmd: []string{"serve"}})}
+	if err := st.UpsertContainer(ctx, before); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	existing, _ := st.GetContainer("web")
+	stored, _ := json.Marshal(existing.Config)
+	if existing.Config == nil || strings.Contains(string(stored), "hunter2") || strings.Contains(string(stored), "info") {
+		t.Fatalf("expected only hashes to be stored, got %s", stored)
+	}
+
+	same := before
+	same.ContainerID = "cid-2"
+	same.Config = mon.configFingerprint(&container.Config{Env: []string{"DEBUG=1", "LOG_LEVEL=info", "DB_PASSWORD=hunter2"}, Cmd: []string{"serve"}})
+	mon.emitConfigChanged(ctx, existing, same, "cid-2", "")
+
+	after := before
+	after.ContainerID = "cid-3"
+	after.Config = mon.configFingerprint(&container.Config{Env: []string{"DB_PASSWORD=hunter3", "LOG_LEVEL=info", "CACHE=on"}, Cmd: []string{"serve", "--workers=4"}})
+	mon.emitConfigChanged(ctx, existing, after, "cid-3", "")
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"config_changed"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Message != "Configuration changed: DB_PASSWORD changed; CACHE added; DEBUG removed; command changed" {
+		t.Fatalf("unexpected config_changed events %+v", events)
+	}
+	details, err := store.DecodeDetails(events[0].DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.ConfigChangeDetails); !ok || len(d.Changed) != 1 || !d.Command {
+		t.Fatalf("unexpected details %+v", details)
+	}
+}
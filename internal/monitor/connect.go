@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/moby/moby/client"
+
+	"healthmon/internal/store"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// connectDocker subscribes to the Docker event stream and syncs the
+// containers, retrying with backoff until the engine answers or ctx is done.
+// Until then healthmon runs degraded: the API keeps serving stored data and
+// /api/status says why Docker is unreachable.
+//
+// cause is the error that broke an established stream, or nil at startup.
+// Losing the engine files docker_disconnected on _healthmon and getting it
+// back docker_reconnected. It returns the new stream and the function that
+// closes it.
+func (m *Monitor) connectDocker(ctx context.Context, cause error) (client.EventsResult, context.CancelFunc, error) {
+	since := m.clock.Now()
+	if cause != nil {
+		m.state.failed(cause, time.Time{})
+		log.Printf("docker event stream disconnected: %v", cause)
+		m.diagnose(ctx, "docker_disconnected", "red", fmt.Sprintf("Docker event stream disconnected: %v", cause), store.DiagnosticDetails{Error: cause.Error()})
+	}
+
+	delay := minReconnectDelay
+	for attempt := 1; ; attempt++ {
+		if cause != nil || attempt > 1 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return client.EventsResult{}, nil, ctx.Err()
+			case <-timer.C:
+			}
+			delay = min(delay*2, maxReconnectDelay)
+		}
+
+		// Subscribe before syncing so nothing falls between the two.
+		streamCtx, cancel := context.WithCancel(ctx)
+		stream := m.docker.Events(streamCtx, client.EventsListOptions{})
+		m.loadEngine(ctx)
+		if err := m.syncExisting(ctx); err != nil {
+			cancel()
+			if ctx.Err() != nil {
+				return client.EventsResult{}, nil, ctx.Err()
+			}
+			m.state.failed(err, m.clock.Now().Add(delay))
+			if attempt == 1 && cause == nil {
+				log.Printf("docker unavailable, serving stored data until it is back: %v", err)
+				m.diagnose(ctx, "docker_disconnected", "red", fmt.Sprintf("Docker is unavailable: %v", err), store.DiagnosticDetails{Error: err.Error()})
+			} else {
+				log.Printf("docker connect attempt %d failed: %v", attempt, err)
+			}
+			continue
+		}
+
+		m.state.setConnected(true)
+		if cause != nil || attempt > 1 {
+			downtime := m.clock.Now().Sub(since).Round(time.Second)
+			log.Printf("docker event stream connected after %s", downtime)
+			if _, ok := m.ensureSelfContainer(ctx); ok {
+				m.emitAlert(ctx, selfContainerName, "", "", "docker_reconnected", fmt.Sprintf("Docker event stream connected after %s", downtime), "green", nil)
+			}
+		}
+		if identity, ok := m.daemonIdentity(ctx); ok {
+			if cause != nil && m.daemonID != "" && identity != m.daemonID {
+				m.daemonRestarted(ctx, since)
+			}
+			m.daemonID = identity
+		}
+		return stream, cancel, nil
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/events"
+)
+
+func TestStartWaitsForDockerInDegradedMode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// One event that is never released keeps the stream open.
+	mock := newMockDockerServer(t, []events.Message{{Type: "container", Action: "start"}}, nil)
+	mock.unavailable.Store(true)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	mon := New(config.Config{DockerHost: host}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	done := make(chan error, 1)
+	go func() { done <- mon.Start(ctx) }()
+
+	waitFor := func(what string, cond func(api.MonitorStatus) bool) api.MonitorStatus {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			select {
+			case err := <-done:
+				t.Fatalf("Start returned while waiting for %s: %v", what, err)
+			default:
+			}
+			if status := mon.Status(); cond(status) {
+				return status
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s, status %+v", what, mon.Status())
+		return api.MonitorStatus{}
+	}
+
+	status := waitFor("degraded mode", func(s api.MonitorStatus) bool { return s.DockerError != "" })
+	if status.EventStreamConnected || status.DockerRetryAt.IsZero() || !strings.Contains(status.DockerError, "daemon not ready") {
+		t.Fatalf("unexpected degraded status: %+v", status)
+	}
+
+	mock.unavailable.Store(false)
+	status = waitFor("connection", func(s api.MonitorStatus) bool { return s.EventStreamConnected })
+	if status.DockerError != "" || status.LastSyncAt.IsZero() {
+		t.Fatalf("unexpected connected status: %+v", status)
+	}
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}, Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 || alerts[0].Type != "docker_disconnected" || alerts[1].Type != "docker_reconnected" {
+		t.Fatalf("expected docker_disconnected then docker_reconnected, got %+v", alerts)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected Start to stop with the context, got %v", err)
+	}
+}
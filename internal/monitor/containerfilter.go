@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"log"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// containerFilter decides which containers healthmon monitors, from
+// HM_IGNORE_CONTAINERS and HM_ONLY_CONTAINERS. Patterns are globs, or
+// regular expressions when written between slashes (/^ci-runner-\d+$/).
+type containerFilter struct {
+	ignore []namePattern
+	only   []namePattern
+}
+
+type namePattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func newContainerFilter(ignore, only []string) containerFilter {
+	return containerFilter{ignore: compilePatterns(ignore), only: compilePatterns(only)}
+}
+
+func compilePatterns(values []string) []namePattern {
+	var out []namePattern
+	for _, value := range values {
+		if expr, ok := strings.CutPrefix(value, "/"); ok && len(expr) > 1 && strings.HasSuffix(expr, "/") {
+			re, err := regexp.Compile(strings.TrimSuffix(expr, "/"))
+			if err != nil {
+				log.Printf("ignoring invalid container pattern %q: %v", value, err)
+				continue
+			}
+			out = append(out, namePattern{re: re})
+			continue
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			log.Printf("ignoring invalid container pattern %q: %v", value, err)
+			continue
+		}
+		out = append(out, namePattern{glob: value})
+	}
+	return out
+}
+
+func (p namePattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	ok, _ := path.Match(p.glob, name)
+	return ok
+}
+
+func matchAny(patterns []namePattern, names []string) bool {
+	for _, p := range patterns {
+		for _, name := range names {
+			if name != "" && p.match(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ignored reports whether a container stays out of healthmon: it is labeled
+// healthmon.ignore=true, matches HM_IGNORE_CONTAINERS, or does not match
+// HM_ONLY_CONTAINERS when that is set. Patterns are checked against both
+// the container name and the service name.
+func (m *Monitor) ignored(name string, labels map[string]string) bool {
+	if ignoredByLabel(labels) {
+		return true
+	}
+	names := []string{name, resolveServiceName(labels, m.nameLabels, name)}
+	if matchAny(m.filter.ignore, names) {
+		return true
+	}
+	return len(m.filter.only) > 0 && !matchAny(m.filter.only, names)
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import "testing"
+
+func TestIgnoredContainers(t *testing.T) {
+	m := &Monitor{
+		nameLabels: serviceNameLabels,
+		filter:     newContainerFilter([]string{"ci-runner-*", `/^buildx_buildkit_.+\d$/`, "["}, nil),
+	}
+	cases := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{"nginx", nil, false},
+		{"ci-runner-42", nil, true},
+		{"buildx_buildkit_default0", nil, true},
+		{"buildx_buildkit_default", nil, false},
+		{"web", map[string]string{"healthmon.ignore": "true"}, true},
+		{"web", map[string]string{"healthmon.ignore": "no"}, false},
+		// The service name counts too.
+		{"stack-job-1", map[string]string{composeServiceLabel: "ci-runner-x"}, true},
+	}
+	for _, tc := range cases {
+		if got := m.ignored(tc.name, tc.labels); got != tc.want {
+			t.Fatalf("ignored(%q, %v) = %v, want %v", tc.name, tc.labels, got, tc.want)
+		}
+	}
+
+	m.filter = newContainerFilter(nil, []string{"media-*"})
+	if m.ignored("media-jellyfin", nil) || !m.ignored("traefik", nil) {
+		t.Fatalf("expected HM_ONLY_CONTAINERS to keep only matching containers")
+	}
+	if !m.ignored("media-sonarr", map[string]string{"healthmon.ignore": "1"}) {
+		t.Fatalf("expected the ignore label to win over HM_ONLY_CONTAINERS")
+	}
+}
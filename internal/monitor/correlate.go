@@ -0,0 +1,203 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+const incidentUnhealthyRestartLoop = "unhealthy_restart_loop"
+
+// correlateAlert folds the alerts of a container that is unhealthy and
+// restart-looping at the same time into a single incident. When handled is
+// true the alert belongs to an incident and the caller must not notify on it
+// individually; notice, when set, is the combined notification to send instead.
+func (m *Monitor) correlateAlert(ctx context.Context, c store.Container, a *store.Alert) (notice *store.Alert, handled bool) {
+	switch a.Type {
+	case "unhealthy", "restart_loop", "healthy", "restart_healed":
+	default:
+		return nil, false
+	}
+
+	unhealthy := strings.EqualFold(c.HealthStatus, "unhealthy")
+	incident, open, err := m.store.GetOpenIncidentByContainerPK(ctx, c.ID)
+	if err != nil {
+		log.Printf("incident lookup failed for %s: %v", c.Name, err)
+		return nil, false
+	}
+
+	if open && incident.Type == incidentUnhealthyRestartLoop {
+		if err := m.store.LinkAlertToIncident(ctx, a.ID, incident.ID); err != nil {
+			log.Printf("incident link failed for %s: %v", c.Name, err)
+			return nil, false
+		}
+		a.IncidentID = incident.ID
+		if unhealthy || c.RestartLoop {
+			return nil, true
+		}
+		if err := m.store.ResolveIncident(ctx, incident.ID, a.Timestamp); err != nil {
+			log.Printf("incident resolve failed for %s: %v", c.Name, err)
+		}
+		return &store.Alert{
+			Container: c.Name,
+			Severity:  "green",
+			Message:   "Container recovered: healthy again and restart loop healed",
+		}, true
+	}
+
+	if !unhealthy || !c.RestartLoop {
+		return nil, false
+	}
+	counterpart := ""
+	switch a.Type {
+	case "unhealthy":
+		counterpart = "restart_loop"
+	case "restart_loop":
+		counterpart = "unhealthy"
+	default:
+		return nil, false
+	}
+
+	message := "Container is unhealthy and in a restart loop"
+	if c.RestartStreak > 0 {
+		message = fmt.Sprintf("Container is unhealthy and in a restart loop (%d restarts)", c.RestartStreak)
+	}
+	// The incident the first of the two alerts opened grows into the
+	// combined one.
+	incidentID := incident.ID
+	if open {
+		err = m.store.RetypeIncident(ctx, incidentID, incidentUnhealthyRestartLoop, "red", message)
+	} else {
+		incidentID, err = m.store.AddIncident(ctx, store.Incident{
+			ContainerPK: c.ID,
+			Container:   c.Name,
+			Type:        incidentUnhealthyRestartLoop,
+			Severity:    "red",
+			Message:     message,
+			OpenedAt:    a.Timestamp,
+		})
+	}
+	if err != nil {
+		log.Printf("incident persist failed for %s: %v", c.Name, err)
+		return nil, false
+	}
+	if prev, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, counterpart); err == nil && found {
+		if err := m.store.LinkAlertToIncident(ctx, prev.ID, incidentID); err != nil {
+			log.Printf("incident link failed for %s: %v", c.Name, err)
+		}
+	}
+	if err := m.store.LinkAlertToIncident(ctx, a.ID, incidentID); err != nil {
+		log.Printf("incident link failed for %s: %v", c.Name, err)
+	}
+	a.IncidentID = incidentID
+	log.Printf("incident: type=%s container=%s id=%d", incidentUnhealthyRestartLoop, c.Name, incidentID)
+	return &store.Alert{
+		Container: c.Name,
+		Severity:  "red",
+		Message:   message,
+	}, true
+}
+
+// incidentRecoveries maps a recovery alert type to the red alert types it
+// ends. Those open an incident, which is resolved once every one of them
+// that is still going has recovered.
+var incidentRecoveries = map[string][]string{
+	"restart_healed":      {"restart_loop"},
+	"healthy":             {"unhealthy"},
+	"task_recovered":      {"task_failed", "task_overdue"},
+	"heartbeat_recovered": {"heartbeat_missed"},
+	"docker_reconnected":  {"docker_disconnected"},
+	"unit_recovered":      {"unit_failed"},
+	"memory_recovered":    {"memory_pressure"},
+	"pids_recovered":      {"pids_pressure"},
+	"fd_recovered":        {"fd_pressure"},
+	"clock_synced":        {"clock_drift"},
+	"ntp_synchronized":    {"ntp_unsynchronized"},
+	"container_unstuck":   {"container_stuck"},
+	"pause_ended":         {"paused_too_long"},
+	"network_restored":    {"network_lost"},
+	"container_returned":  {"container_missing"},
+	"restart_storm_ended": {"restart_storm"},
+}
+
+// incidentOnsets are the alert types that open an incident when they are
+// red.
+var incidentOnsets = func() map[string]bool {
+	onsets := make(map[string]bool)
+	for _, types := range incidentRecoveries {
+		for _, t := range types {
+			onsets[t] = true
+		}
+	}
+	return onsets
+}()
+
+// groupIncident files an alert the correlation above left alone into the
+// container's incident. A red alert opens one, e.g. unhealthy or
+// restart_loop; every alert until it is resolved joins it, and it is
+// resolved at the recovery of the last red alert that is still going, e.g.
+// healthy or restart_healed.
+func (m *Monitor) groupIncident(ctx context.Context, c store.Container, a *store.Alert) {
+	if a.ID == 0 || c.ID == 0 {
+		return
+	}
+	incident, open, err := m.store.GetOpenIncidentByContainerPK(ctx, c.ID)
+	if err != nil {
+		log.Printf("incident lookup failed for %s: %v", c.Name, err)
+		return
+	}
+	if !open {
+		if a.Severity != "red" || !incidentOnsets[a.Type] {
+			return
+		}
+		incident.ID, err = m.store.AddIncident(ctx, store.Incident{
+			ContainerPK: c.ID,
+			Container:   c.Name,
+			Type:        a.Type,
+			Severity:    a.Severity,
+			Message:     a.Message,
+			OpenedAt:    a.Timestamp,
+		})
+		if err != nil {
+			log.Printf("incident persist failed for %s: %v", c.Name, err)
+			return
+		}
+	}
+	if err := m.store.LinkAlertToIncident(ctx, a.ID, incident.ID); err != nil {
+		log.Printf("incident link failed for %s: %v", c.Name, err)
+		return
+	}
+	a.IncidentID = incident.ID
+	if _, recovery := incidentRecoveries[a.Type]; !recovery {
+		return
+	}
+	alerts, err := m.store.IncidentAlerts(ctx, incident.ID)
+	if err != nil {
+		log.Printf("incident alerts lookup failed for %s: %v", c.Name, err)
+		return
+	}
+	if len(ongoingOnsets(alerts)) > 0 {
+		return
+	}
+	if err := m.store.ResolveIncident(ctx, incident.ID, a.Timestamp); err != nil {
+		log.Printf("incident resolve failed for %s: %v", c.Name, err)
+	}
+}
+
+// ongoingOnsets returns the red alert types of an incident that were not
+// followed by their recovery.
+func ongoingOnsets(alerts []store.Alert) map[string]bool {
+	ongoing := make(map[string]bool)
+	for _, a := range alerts {
+		if a.Severity == "red" && incidentOnsets[a.Type] {
+			ongoing[a.Type] = true
+		}
+		for _, t := range incidentRecoveries[a.Type] {
+			delete(ongoing, t)
+		}
+	}
+	return ongoing
+}
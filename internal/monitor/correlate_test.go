@@ -0,0 +1,140 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestCorrelateLinksUnhealthyAndRestartLoopIntoOneIncident(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := store.New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	c := store.Container{
+		Name:         "imapsync",
+		ContainerID:  "cid-1",
+		Image:        "ghcr.io/example/imapsync",
+		ImageTag:     "latest",
+		ImageID:      "sha256:image",
+		CreatedAt:    now.Add(-time.Hour),
+		RegisteredAt: now.Add(-time.Hour),
+		StartedAt:    now.Add(-time.Minute),
+		Status:       "running",
+		Role:         "service",
+		Caps:         []string{},
+		User:         "0:0",
+		Present:      true,
+		HealthStatus: "unhealthy",
+		UpdatedAt:    now,
+	}
+	if err := st.UpsertContainer(ctx, c); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	server := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	mon := New(config.Config{}, st, server)
+
+	mon.emitAlert(ctx, "imapsync", "cid-1", "imapsync", "unhealthy", "Container became unhealthy", "red", nil)
+
+	c, _ = st.GetContainer("imapsync")
+	c.RestartLoop = true
+	c.RestartStreak = 3
+	if err := st.UpsertContainer(ctx, c); err != nil {
+		t.Fatalf("upsert looping container: %v", err)
+	}
+	mon.emitAlert(ctx, "imapsync", "cid-1", "imapsync", "restart_loop", "Restart loop detected", "red", nil)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(alerts))
+	}
+	incidentID := alerts[0].IncidentID
+	if incidentID == 0 {
+		t.Fatalf("expected restart_loop alert to be linked to an incident")
+	}
+	if alerts[1].IncidentID != incidentID {
+		t.Fatalf("expected unhealthy alert linked to incident %d, got %d", incidentID, alerts[1].IncidentID)
+	}
+
+	c, _ = st.GetContainer("imapsync")
+	c.HealthStatus = "healthy"
+	c.RestartLoop = false
+	c.RestartStreak = 0
+	if err := st.UpsertContainer(ctx, c); err != nil {
+		t.Fatalf("upsert healed container: %v", err)
+	}
+	mon.emitAlert(ctx, "imapsync", "cid-1", "imapsync", "healthy", "Container became healthy", "green", nil)
+
+	incident, ok, err := st.GetIncident(ctx, incidentID)
+	if err != nil || !ok {
+		t.Fatalf("get incident: ok=%v err=%v", ok, err)
+	}
+	if incident.ResolvedAt.IsZero() {
+		t.Fatalf("expected incident to be resolved")
+	}
+	if len(incident.AlertIDs) != 3 {
+		t.Fatalf("expected 3 linked alerts, got %v", incident.AlertIDs)
+	}
+}
+
+func TestAlertsGroupIntoIncidentsUntilEveryOnsetRecovers(t *testing.T) {
+	ctx := context.Background()
+	mon, st := newStatsMonitor(t, config.Config{},
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true, HealthStatus: "healthy"},
+	)
+
+	mon.emitAlert(ctx, "web", "cid-web", "web", "heartbeat_missed", "Heartbeat missed", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "memory_pressure", "Memory at 95% of the limit", "yellow", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "network_lost", "Lost network backend", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "heartbeat_recovered", "Heartbeat is back", "green", nil)
+
+	incidents, err := st.ListIncidents(ctx, store.IncidentFilter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list incidents: %v", err)
+	}
+	if len(incidents) != 1 || incidents[0].Type != "heartbeat_missed" || len(incidents[0].AlertIDs) != 4 {
+		t.Fatalf("expected one incident holding all four alerts, got %+v", incidents)
+	}
+	if !incidents[0].ResolvedAt.IsZero() {
+		t.Fatal("expected the incident to stay open while the network is lost")
+	}
+
+	mon.emitAlert(ctx, "web", "cid-web", "web", "network_restored", "Network backend is back", "green", nil)
+	inc, _, err := st.GetIncident(ctx, incidents[0].ID)
+	if err != nil {
+		t.Fatalf("get incident: %v", err)
+	}
+	if inc.ResolvedAt.IsZero() || len(inc.AlertIDs) != 5 {
+		t.Fatalf("expected the last recovery to resolve the incident, got %+v", inc)
+	}
+
+	// Green and yellow alerts outside an incident do not open one.
+	mon.emitAlert(ctx, "web", "cid-web", "web", "memory_pressure", "Memory at 95% of the limit", "yellow", nil)
+	if open, err := st.ListIncidents(ctx, store.IncidentFilter{Open: true}, 0, 10); err != nil || len(open) != 0 {
+		t.Fatalf("expected no open incident, got %+v (%v)", open, err)
+	}
+}
@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/client"
+)
+
+const (
+	// maxCrashLogBytes caps the log tail stored with a restart_loop alert.
+	maxCrashLogBytes = 4096
+	// crashExitCodes is how many exit codes of recent restarts are stored.
+	crashExitCodes = 10
+	// telegramCrashLines is how much of the log tail goes into Telegram.
+	telegramCrashLines = 10
+)
+
+// restartLoopDetails describes a restart loop with what is needed to tell
+// why the container keeps crashing: the exit codes of its recent restarts,
+// and apart from them the tail of its logs, which only admins and the
+// notifications get to see. Whatever cannot be read is left out.
+func (m *Monitor) restartLoopDetails(ctx context.Context, c store.Container, restarts int) (store.RestartDetails, string) {
+	details := store.RestartDetails{RestartCount: restarts}
+	if c.ID > 0 {
+		codes, err := m.store.RecentExitCodes(ctx, c.ID, crashExitCodes)
+		if err != nil {
+			log.Printf("exit codes of %s: %v", c.Name, err)
+		}
+		details.ExitCodes = codes
+	}
+	var logs string
+	if m.cfg.RestartLoopLogLines > 0 && m.docker != nil && c.ContainerID != "" {
+		tail, err := m.logTail(ctx, c.ContainerID, m.cfg.RestartLoopLogLines)
+		if err != nil {
+			log.Printf("logs of %s: %v", c.Name, err)
+		}
+		logs = capLogTail(tail, maxCrashLogBytes)
+	}
+	return details, logs
+}
+
+// logTail returns the last lines of a container's stdout and stderr.
+func (m *Monitor) logTail(ctx context.Context, id string, lines int) (string, error) {
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return "", err
+	}
+	logs, err := m.docker.ContainerLogs(ctx, id, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer logs.Close()
+
+	var out bytes.Buffer
+	if inspect.Container.Config != nil && inspect.Container.Config.Tty {
+		_, err = io.Copy(&out, logs)
+	} else {
+		_, err = stdcopy.StdCopy(&out, &out, logs)
+	}
+	return out.String(), err
+}
+
+// capLogTail keeps the end of logs within limit bytes, starting at a line
+// boundary, since the last lines are the ones that explain a crash.
+func capLogTail(logs string, limit int) string {
+	logs = strings.TrimRight(logs, "\n")
+	if len(logs) <= limit {
+		return logs
+	}
+	logs = logs[len(logs)-limit:]
+	if i := strings.IndexByte(logs, '\n'); i >= 0 {
+		logs = logs[i+1:]
+	}
+	return strings.ToValidUTF8(logs, "")
+}
+
+// crashSummary renders the exit codes and the last log lines of a
+// restart_loop alert for a Telegram message in format f. It is empty when
+// the alert has neither.
+func crashSummary(a store.Alert, f telegramFormat) string {
+	var b strings.Builder
+	details, _ := store.DecodeDetails(a.DetailsJSON)
+	if restart, ok := details.(*store.RestartDetails); ok && len(restart.ExitCodes) > 0 {
+		codes := make([]string, len(restart.ExitCodes))
+		for i, code := range restart.ExitCodes {
+			codes[i] = strconv.Itoa(code)
+		}
+		b.WriteString("\n" + f.escape("Exit codes: "+strings.Join(codes, ", ")))
+	}
+	if a.Logs != "" {
+		lines := strings.Split(a.Logs, "\n")
+		if len(lines) > telegramCrashLines {
+			lines = lines[len(lines)-telegramCrashLines:]
+		}
+		b.WriteString("\n" + f.escape("Last logs:") + "\n" + f.pre(strings.Join(lines, "\n")))
+	}
+	return b.String()
+}
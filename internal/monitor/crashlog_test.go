@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestRestartLoopDetailsCarryExitCodes(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "worker", ContainerID: "cid-worker", Status: "restarting", Present: true}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	c, _ := st.GetContainer("worker")
+	for i, code := range []int{1, 1, 137} {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: c.ID, Container: c.Name, Type: "restart", Severity: "blue", Message: "Restart event: die", Timestamp: now.Add(time.Duration(i) * time.Second), ExitCode: &code}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	mon := New(config.Config{RestartLoopLogLines: 50}, st, nil)
+	details, logs := mon.restartLoopDetails(ctx, c, 3)
+	if details.RestartCount != 3 || len(details.ExitCodes) != 3 || details.ExitCodes[2] != 137 || logs != "" {
+		t.Fatalf("unexpected details: %+v, logs %q", details, logs)
+	}
+
+	alert := store.Alert{Type: "restart_loop", DetailsJSON: store.EncodeDetails(details), Logs: "starting\npanic: config missing"}
+	if got, want := crashSummary(alert, ""), "\nExit codes: 1, 1, 137\nLast logs:\nstarting\npanic: config missing"; got != want {
+		t.Fatalf("expected summary %q, got %q", want, got)
+	}
+}
+
+func TestCapLogTailKeepsWholeLastLines(t *testing.T) {
+	logs := strings.Repeat("noise line\n", 100) + "fatal: cannot bind :8080\n"
+	capped := capLogTail(logs, 64)
+	if len(capped) > 64 || !strings.HasSuffix(capped, "fatal: cannot bind :8080") || strings.HasPrefix(capped, "ine") {
+		t.Fatalf("unexpected tail %q", capped)
+	}
+	if got := capLogTail("short\n", 64); got != "short" {
+		t.Fatalf("expected short logs to be kept, got %q", got)
+	}
+}
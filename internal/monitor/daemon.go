@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/moby/moby/client"
+)
+
+// daemonRestartWindow is how long after the event stream comes back the
+// container restarts that follow are put down to the daemon restart.
+const daemonRestartWindow = time.Minute
+
+// daemonRestartAction marks the events of containers the Docker daemon
+// restarted, like healthmon's own actions.
+const daemonRestartAction = "daemon_restart"
+
+// daemonRestarted records a daemon_restarted event on _healthmon when the
+// event stream broke and came back to a different daemon, and marks every
+// container for a minute so the restarts the daemon causes are recorded as
+// self-inflicted and never count toward restart loops.
+func (m *Monitor) daemonRestarted(ctx context.Context, since time.Time) {
+	now := m.clock.Now()
+	m.selfActions.markHost(daemonRestartAction, now.Add(daemonRestartWindow))
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	message := fmt.Sprintf("Docker daemon restarted, it was unreachable for %s", now.Sub(since).Round(time.Second))
+	m.emitInfo(ctx, selfContainerName, "", "", "daemon_restarted", message, "", "", "", "", daemonRestartAction, nil)
+}
+
+// daemonIdentity returns what tells one run of the Docker daemon from
+// another. /info has no start time, so this is the engine ID together with
+// its version: a reinstall or an upgrade changes them, a stream that merely
+// dropped does not.
+func (m *Monitor) daemonIdentity(ctx context.Context) (string, bool) {
+	info, err := m.docker.Info(ctx, client.InfoOptions{})
+	if err != nil {
+		log.Printf("docker info failed: %v", err)
+		return "", false
+	}
+	return info.Info.ID + "/" + info.Info.ServerVersion, true
+}
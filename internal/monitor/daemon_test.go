@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+func TestRestartsAfterDaemonRestartDoNotCountTowardLoops(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	inspect := container.InspectResponse{
+		ID:         "cid-web",
+		Created:    start.Add(-time.Hour).Format(time.RFC3339Nano),
+		State:      &container.State{Status: "running", StartedAt: start.Format(time.RFC3339Nano)},
+		HostConfig: &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: "always"}},
+		Config:     &container.Config{Image: "web:latest", Labels: map[string]string{"com.docker.compose.service": "web"}},
+		Image:      "sha256:web",
+	}
+	raw, err := json.Marshal(inspect)
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+	mock := newMockDockerServer(t, nil, []inspectRecord{{ID: "cid-web", Inspect: raw}})
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	mon, st := newStatsMonitor(t, config.Config{RestartWindowSeconds: 600, RestartThreshold: 2},
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true})
+	fake := clock.NewFake(start)
+	mon.WithClock(fake)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	mon.daemonRestarted(ctx, start.Add(-20*time.Second))
+	mon.handleRestartLike(ctx, "web", "cid-web", "restart", nil, "")
+	mon.handleRestartLike(ctx, "web", "cid-web", "restart", nil, "")
+	if got, _ := st.GetContainer("web"); got.RestartLoop {
+		t.Fatalf("restarts by the daemon must not enter a restart loop")
+	}
+
+	// After the window restarts count again.
+	fake.Advance(2 * time.Minute)
+	mon.handleRestartLike(ctx, "web", "cid-web", "restart", nil, "")
+	mon.handleRestartLike(ctx, "web", "cid-web", "restart", nil, "")
+	if got, _ := st.GetContainer("web"); !got.RestartLoop {
+		t.Fatalf("expected a restart loop once the daemon restart is over")
+	}
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Ascending: true}, 0, 20)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	tagged := 0
+	var daemon *store.Event
+	for i, e := range events {
+		if e.Type == "restart" && e.Reason == selfInflictedReason {
+			tagged++
+			if details, err := store.DecodeDetails(e.DetailsJSON); err != nil || details.(*store.SelfInflictedDetails).Action != daemonRestartAction {
+				t.Fatalf("expected the restart to be put down to the daemon, got %s", e.DetailsJSON)
+			}
+		}
+		if e.Type == "daemon_restarted" {
+			daemon = &events[i]
+		}
+	}
+	if tagged != 2 {
+		t.Fatalf("expected 2 restarts marked as caused by the daemon, got %d in %+v", tagged, events)
+	}
+	if daemon == nil || daemon.Container != selfContainerName || daemon.Message != "Docker daemon restarted, it was unreachable for 20s" {
+		t.Fatalf("unexpected daemon_restarted event %+v", daemon)
+	}
+}
+
+func TestReconnectReportsDaemonRestartOnlyForANewDaemon(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDockerServer(t, nil, nil)
+	mock.daemonID.Store("engine-a")
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	mon, st := newStatsMonitor(t, config.Config{})
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+	connect := func(cause error) {
+		t.Helper()
+		_, cancel, err := mon.connectDocker(ctx, cause)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		cancel()
+	}
+	restarts := func() int {
+		t.Helper()
+		events, err := st.ListAllEvents(ctx, store.Filter{}, 0, 50)
+		if err != nil {
+			t.Fatalf("list events: %v", err)
+		}
+		n := 0
+		for _, e := range events {
+			if e.Type == "daemon_restarted" {
+				n++
+			}
+		}
+		return n
+	}
+
+	connect(nil)
+	connect(errors.New("unexpected EOF"))
+	if n := restarts(); n != 0 {
+		t.Fatalf("expected a dropped stream to the same daemon not to count as a restart, got %d", n)
+	}
+	mock.daemonID.Store("engine-b")
+	connect(errors.New("unexpected EOF"))
+	if n := restarts(); n != 1 {
+		t.Fatalf("expected one daemon_restarted event for the new daemon, got %d", n)
+	}
+}
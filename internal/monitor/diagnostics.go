@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// diagnosticInterval limits how often the same kind of failure is filed, so
+// a broken database or bot does not flood the history.
+const diagnosticInterval = time.Minute
+
+// diagnostics remembers when each kind of self-diagnostic was last filed
+// and how many were dropped since.
+type diagnostics struct {
+	mu         sync.Mutex
+	last       map[string]time.Time
+	suppressed map[string]int
+}
+
+func newDiagnostics() *diagnostics {
+	return &diagnostics{last: make(map[string]time.Time), suppressed: make(map[string]int)}
+}
+
+// allow reports whether kind may be filed now, and how many were dropped
+// since it last was.
+func (d *diagnostics) allow(kind string, now time.Time) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.last[kind]; ok && now.Sub(last) < diagnosticInterval {
+		d.suppressed[kind]++
+		return 0, false
+	}
+	d.last[kind] = now
+	dropped := d.suppressed[kind]
+	delete(d.suppressed, kind)
+	return dropped, true
+}
+
+// diagnose files a failure of healthmon itself as an alert on _healthmon,
+// so it shows up in the dashboard rather than only in the logs. kind is the
+// alert type and groups failures for rate limiting.
+func (m *Monitor) diagnose(ctx context.Context, kind, severity, message string, details store.DiagnosticDetails) {
+	dropped, ok := m.diagnostics.allow(kind, m.clock.Now())
+	if !ok {
+		return
+	}
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	if dropped > 0 {
+		message = fmt.Sprintf("%s (%d more since the last report)", message, dropped)
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   selfContainerName,
+		Type:        kind,
+		Severity:    severity,
+		Message:     message,
+		Timestamp:   m.clock.Now(),
+		DetailsJSON: store.EncodeDetails(details),
+	})
+}
+
+// diagnoseWrite files a failed database write.
+func (m *Monitor) diagnoseWrite(ctx context.Context, what string, err error) {
+	m.diagnose(ctx, "db_write_failed", "red", fmt.Sprintf("Database write failed (%s): %v", what, err), store.DiagnosticDetails{Error: err.Error(), Write: what})
+}
+
+// diagnoseNotification files a notification that could not be delivered.
+func (m *Monitor) diagnoseNotification(ctx context.Context, channel string, a store.Alert, err error) {
+	m.diagnose(ctx, "notification_failed", "yellow", fmt.Sprintf("%s notification for %s failed: %v", channel, a.Container, err), store.DiagnosticDetails{
+		Error:     err.Error(),
+		Channel:   channel,
+		Container: a.Container,
+		Alert:     a.Type,
+	})
+}
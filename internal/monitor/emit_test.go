@@ -88,7 +88,7 @@ func TestEmitPrefersContainerID(t *testing.T) {
 		Reason:      "start",
 	})
 
-	events, err := st.ListAllEvents(ctx, 0, 10)
+	events, err := st.ListAllEvents(ctx, store.Filter{}, 0, 10)
 	if err != nil {
 		t.Fatalf("list events: %v", err)
 	}
@@ -130,3 +130,35 @@ func TestInspectToContainerPrefersServiceLabels(t *testing.T) {
 		t.Fatalf("expected current container name to keep runtime container name, got %q", info.CurrentContainerName)
 	}
 }
+
+func TestInspectToContainerMatchesServiceByLabel(t *testing.T) {
+	mon := New(config.Config{ServiceLabels: []string{"com.hashicorp.nomad.job_name"}}, store.New(nil), api.NewServer(nil, api.NewBroadcaster(), api.WSOptions{}))
+	inspect := func(name string, labels map[string]string) store.Container {
+		return mon.inspectToContainer(container.InspectResponse{
+			ID:         "cid-" + name,
+			Name:       "/" + name,
+			State:      &container.State{Status: "running"},
+			Config:     &container.Config{Image: "ghcr.io/example/webapp:1", Labels: labels},
+			HostConfig: &container.HostConfig{},
+		})
+	}
+
+	run := inspect("webapp-run-3f2a9c", map[string]string{
+		"healthmon.service":          "webapp",
+		"com.docker.compose.service": "webapp-run",
+	})
+	if run.Name != "webapp" {
+		t.Fatalf("expected healthmon.service to win over compose labels, got %q", run.Name)
+	}
+	nomad := inspect("server-7d1c2e4b", map[string]string{
+		"com.hashicorp.nomad.job_name": "api",
+		"com.docker.compose.service":   "ignored",
+	})
+	if nomad.Name != "api" {
+		t.Fatalf("expected configured label to win, got %q", nomad.Name)
+	}
+	plain := inspect("standalone", nil)
+	if plain.Name != "standalone" {
+		t.Fatalf("expected container name fallback, got %q", plain.Name)
+	}
+}
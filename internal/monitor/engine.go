@@ -0,0 +1,78 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+// Settings the last seen Docker Engine is kept under.
+const (
+	engineVersionSetting    = "docker_engine_version"
+	engineAPIVersionSetting = "docker_api_version"
+)
+
+// loadEngine reads the version and architecture of the Docker host on
+// every connect, since an engine upgrade restarts the daemon and breaks the
+// event stream.
+func (m *Monitor) loadEngine(ctx context.Context) {
+	version, err := m.docker.ServerVersion(ctx, client.ServerVersionOptions{})
+	if err != nil {
+		if m.hostArch == "" {
+			log.Printf("docker version failed, emulation checks disabled: %v", err)
+		}
+		return
+	}
+	m.hostArch = version.Arch
+	m.state.setEngine(version.Version, version.APIVersion)
+	m.recordEngine(ctx, version.Version, version.APIVersion)
+}
+
+// recordEngine stores the engine version and files an engine_changed event
+// on _healthmon when it differs from the one seen before, so restarts after
+// a host upgrade can be traced back to it.
+func (m *Monitor) recordEngine(ctx context.Context, version, apiVersion string) {
+	previous, found, err := m.store.Setting(ctx, engineVersionSetting)
+	if err != nil {
+		log.Printf("read docker engine version failed: %v", err)
+		return
+	}
+	previousAPI, _, err := m.store.Setting(ctx, engineAPIVersionSetting)
+	if err != nil {
+		log.Printf("read docker api version failed: %v", err)
+		return
+	}
+	if found && previous == version && previousAPI == apiVersion {
+		return
+	}
+	if err := m.store.SetSetting(ctx, engineVersionSetting, version); err != nil {
+		log.Printf("store docker engine version failed: %v", err)
+		return
+	}
+	if err := m.store.SetSetting(ctx, engineAPIVersionSetting, apiVersion); err != nil {
+		log.Printf("store docker api version failed: %v", err)
+		return
+	}
+	if !found {
+		return
+	}
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	message := fmt.Sprintf("Docker Engine changed from %s to %s", previous, version)
+	if previousAPI != apiVersion {
+		message += fmt.Sprintf(" (API %s -> %s)", previousAPI, apiVersion)
+	}
+	e := m.infoEvent(selfContainerName, "", "", "engine_changed", message, "", "", "", "", "engine", nil)
+	e.DetailsJSON = store.EncodeDetails(store.EngineDetails{
+		OldVersion:    previous,
+		NewVersion:    version,
+		OldAPIVersion: previousAPI,
+		NewAPIVersion: apiVersion,
+	})
+	m.emitEvent(ctx, e)
+}
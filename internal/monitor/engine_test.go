@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+func TestEngineUpgradeIsRecordedOnce(t *testing.T) {
+	ctx := context.Background()
+	mock := newMockDockerServer(t, nil, nil)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	mon, st := newStatsMonitor(t, config.Config{})
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+	if err := st.SetSetting(ctx, engineVersionSetting, "28.5.0"); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+	if err := st.SetSetting(ctx, engineAPIVersionSetting, "1.43"); err != nil {
+		t.Fatalf("set setting: %v", err)
+	}
+
+	mon.loadEngine(ctx)
+	// A reconnect to the same engine records nothing.
+	mon.loadEngine(ctx)
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"engine_changed"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Container != selfContainerName || events[0].Message != "Docker Engine changed from 28.5.0 to 29.2.1 (API 1.43 -> 1.44)" {
+		t.Fatalf("unexpected engine events %+v", events)
+	}
+	if status := mon.Status(); status.EngineVersion != "29.2.1" || status.EngineAPIVersion != "1.44" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if version, _, _ := st.Setting(ctx, engineVersionSetting); version != "29.2.1" {
+		t.Fatalf("expected the new version to be stored, got %q", version)
+	}
+}
@@ -0,0 +1,284 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+)
+
+const (
+	execCheckLabel         = "healthmon.check.exec"
+	execCheckIntervalLabel = "healthmon.check.interval"
+	execCheckTimeoutLabel  = "healthmon.check.timeout"
+	execCheckRetriesLabel  = "healthmon.check.retries"
+
+	execCheckTick = 5 * time.Second
+)
+
+var errExecCheckTimeout = errors.New("check timed out")
+
+// execCheck is a healthcheck healthmon runs itself through docker exec, for
+// images whose HEALTHCHECK is missing or wrong. It replaces the image's
+// healthcheck: its results become the container's health status and go
+// through the same unhealthy/healthy handling as Docker's.
+type execCheck struct {
+	cmd      []string
+	interval time.Duration
+	timeout  time.Duration
+	retries  int
+}
+
+// parseExecCheck reads the healthmon.check.* labels. Interval, timeout and
+// retries default to 30s, 10s and 3 like Docker's HEALTHCHECK.
+func parseExecCheck(labels map[string]string) (execCheck, bool) {
+	command := strings.TrimSpace(labels[execCheckLabel])
+	if command == "" {
+		return execCheck{}, false
+	}
+	check := execCheck{
+		cmd:      []string{"sh", "-c", command},
+		interval: 30 * time.Second,
+		timeout:  10 * time.Second,
+		retries:  3,
+	}
+	for label, target := range map[string]*time.Duration{execCheckIntervalLabel: &check.interval, execCheckTimeoutLabel: &check.timeout} {
+		if value := strings.TrimSpace(labels[label]); value != "" {
+			if d, err := time.ParseDuration(value); err == nil && d > 0 {
+				*target = d
+			} else {
+				log.Printf("ignoring invalid %s label %q", label, value)
+			}
+		}
+	}
+	if value := strings.TrimSpace(labels[execCheckRetriesLabel]); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			check.retries = n
+		} else {
+			log.Printf("ignoring invalid %s label %q", execCheckRetriesLabel, value)
+		}
+	}
+	return check, true
+}
+
+// healthcheck describes the check the way Docker's healthchecks are stored.
+func (c execCheck) healthcheck() *store.Healthcheck {
+	return &store.Healthcheck{
+		Test:     []string{"CMD-SHELL", c.cmd[2]},
+		Interval: durationString(c.interval),
+		Timeout:  durationString(c.timeout),
+		Retries:  c.retries,
+	}
+}
+
+// execChecks holds the health of containers with an exec check, keyed by
+// container id. It is reset when the container starts, like Docker resets a
+// container's health to starting.
+type execChecks struct {
+	mu      sync.Mutex
+	byID    map[string]*execCheckState
+	results chan execCheckResult
+}
+
+type execCheckState struct {
+	status  string
+	streak  int
+	lastRun time.Time
+	running bool
+	probe   *store.HealthProbe
+}
+
+type execCheckResult struct {
+	container store.Container
+	check     execCheck
+	exitCode  int
+	err       error
+	at        time.Time
+}
+
+func newExecChecks() *execChecks {
+	return &execChecks{byID: make(map[string]*execCheckState), results: make(chan execCheckResult, 16)}
+}
+
+// state returns the state of a container's check. The caller holds e.mu.
+func (e *execChecks) state(id string) *execCheckState {
+	st, ok := e.byID[id]
+	if !ok {
+		st = &execCheckState{status: "starting"}
+		e.byID[id] = st
+	}
+	return st
+}
+
+// health returns the status and failing streak of a container's check.
+func (e *execChecks) health(id string) (string, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.state(id)
+	return st.status, st.streak
+}
+
+// probe returns the latest run of a container's check, like the last entry
+// of Docker's health log.
+func (e *execChecks) probe(id string) *store.HealthProbe {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.state(id).probe
+}
+
+// reset starts a container's check over, when the container starts.
+func (e *execChecks) reset(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.byID, id)
+}
+
+// due marks the check of c as running and returns true when its interval
+// has passed and no run is in flight.
+func (e *execChecks) due(c store.Container, check execCheck, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.state(c.ContainerID)
+	if st.running || (!st.lastRun.IsZero() && now.Sub(st.lastRun) < check.interval) {
+		return false
+	}
+	st.running = true
+	st.lastRun = now
+	return true
+}
+
+// record applies a check result and returns the new status and whether it
+// changed. A container turns unhealthy after retries consecutive failures
+// and healthy on the first success.
+func (e *execChecks) record(r execCheckResult) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	st := e.state(r.container.ContainerID)
+	st.running = false
+	st.probe = &store.HealthProbe{ExitCode: r.exitCode, At: r.at}
+	if r.err != nil {
+		st.probe.Output = r.err.Error()
+	}
+	prev := st.status
+	if r.err == nil {
+		st.status = "healthy"
+		st.streak = 0
+	} else {
+		st.streak++
+		if st.streak >= r.check.retries {
+			st.status = "unhealthy"
+		}
+	}
+	return st.status, st.status != prev
+}
+
+// isCheckExec reports whether an exec event belongs to a check healthmon
+// ran, an exec check or the clock probe, so it is not logged as container
+// activity.
+func (m *Monitor) isCheckExec(ctx context.Context, msg events.Message) bool {
+	if !strings.HasPrefix(string(msg.Action), "exec_") {
+		return false
+	}
+	c, ok, _ := m.store.GetContainerByContainerID(ctx, msg.Actor.ID)
+	if !ok {
+		return false
+	}
+	command := msg.Actor.Attributes["execCommand"]
+	if clockCheckEnabled(c.Labels) && command == strings.Join(clockProbeCmd, " ") {
+		return true
+	}
+	check, ok := parseExecCheck(c.Labels)
+	return ok && command == strings.Join(check.cmd, " ")
+}
+
+// watchExecChecks starts the exec checks that are due. Results are applied
+// on the event loop, like Docker's health_status events.
+func (m *Monitor) watchExecChecks(ctx context.Context) {
+	ticker := m.clock.NewTicker(execCheckTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.guard(ctx, "exec checks", func() { m.startExecChecks(ctx) })
+		}
+	}
+}
+
+func (m *Monitor) startExecChecks(ctx context.Context) {
+	now := m.clock.Now()
+	for _, c := range m.store.ListContainers() {
+		if !c.Present || c.Status != "running" || c.ContainerID == "" {
+			continue
+		}
+		check, ok := parseExecCheck(c.Labels)
+		if !ok || !m.checks.due(c, check, now) {
+			continue
+		}
+		go func() {
+			exitCode, err := m.runExecCheck(ctx, c.ContainerID, check)
+			select {
+			case m.checks.results <- execCheckResult{container: c, check: check, exitCode: exitCode, err: err, at: m.clock.Now()}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+// runExecCheck runs the check command in the container and waits for it to
+// exit. A non-zero exit code, an exec error or a timeout is a failure; the
+// exit code is -1 when the command did not finish, as in Docker's health log.
+func (m *Monitor) runExecCheck(ctx context.Context, containerID string, check execCheck) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, check.timeout)
+	defer cancel()
+	created, err := m.docker.ExecCreate(ctx, containerID, client.ExecCreateOptions{Cmd: check.cmd})
+	if err != nil {
+		return -1, err
+	}
+	if _, err := m.docker.ExecStart(ctx, created.ID, client.ExecStartOptions{Detach: true}); err != nil {
+		return -1, err
+	}
+	for {
+		inspect, err := m.docker.ExecInspect(ctx, created.ID, client.ExecInspectOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return -1, errExecCheckTimeout
+			}
+			return -1, err
+		}
+		if !inspect.Running {
+			if inspect.ExitCode != 0 {
+				return inspect.ExitCode, fmt.Errorf("exit code %d", inspect.ExitCode)
+			}
+			return 0, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, errExecCheckTimeout
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// applyExecCheck records a check result and, when the health status
+// changed, hands it to the regular health handling.
+func (m *Monitor) applyExecCheck(ctx context.Context, r execCheckResult) {
+	if r.err != nil {
+		log.Printf("exec check failed for %s: %v", r.container.Name, r.err)
+	}
+	status, changed := m.checks.record(r)
+	if changed {
+		m.handleHealth(ctx, r.container.CurrentContainerName, r.container.ContainerID, status)
+	}
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+func TestParseExecCheckDefaultsAndOverrides(t *testing.T) {
+	if _, ok := parseExecCheck(map[string]string{"healthmon.role": "service"}); ok {
+		t.Fatalf("expected no check without the exec label")
+	}
+	check, ok := parseExecCheck(map[string]string{
+		execCheckLabel:         "wget -qO- http://localhost:8080/health",
+		execCheckIntervalLabel: "1m",
+		execCheckRetriesLabel:  "nope",
+	})
+	if !ok {
+		t.Fatalf("expected a check")
+	}
+	if check.interval != time.Minute || check.timeout != 10*time.Second || check.retries != 3 {
+		t.Fatalf("unexpected check settings: %+v", check)
+	}
+	hc := check.healthcheck()
+	if hc.Test[0] != "CMD-SHELL" || hc.Test[1] != "wget -qO- http://localhost:8080/health" || hc.Interval != "1m0s" {
+		t.Fatalf("unexpected healthcheck: %+v", hc)
+	}
+}
+
+func TestExecChecksTurnUnhealthyAfterRetries(t *testing.T) {
+	checks := newExecChecks()
+	c := store.Container{Name: "web", ContainerID: "cid-web"}
+	check := execCheck{cmd: []string{"sh", "-c", "true"}, interval: 30 * time.Second, timeout: time.Second, retries: 2}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if status, _ := checks.health(c.ContainerID); status != "starting" {
+		t.Fatalf("expected starting before the first run, got %s", status)
+	}
+	if !checks.due(c, check, now) {
+		t.Fatalf("expected the first run to be due")
+	}
+	if checks.due(c, check, now.Add(time.Minute)) {
+		t.Fatalf("expected no second run while one is in flight")
+	}
+
+	failed := execCheckResult{container: c, check: check, err: errors.New("exit code 1")}
+	if status, changed := checks.record(failed); status != "starting" || changed {
+		t.Fatalf("expected one failure to stay starting, got %s changed=%v", status, changed)
+	}
+	if checks.due(c, check, now.Add(10*time.Second)) {
+		t.Fatalf("expected the interval to be respected")
+	}
+	if !checks.due(c, check, now.Add(30*time.Second)) {
+		t.Fatalf("expected a run after the interval")
+	}
+	if status, changed := checks.record(failed); status != "unhealthy" || !changed {
+		t.Fatalf("expected unhealthy after retries, got %s changed=%v", status, changed)
+	}
+	if _, streak := checks.health(c.ContainerID); streak != 2 {
+		t.Fatalf("expected a failing streak of 2, got %d", streak)
+	}
+	if status, changed := checks.record(execCheckResult{container: c, check: check}); status != "healthy" || !changed {
+		t.Fatalf("expected healthy after a success, got %s changed=%v", status, changed)
+	}
+
+	checks.reset(c.ContainerID)
+	if status, streak := checks.health(c.ContainerID); status != "starting" || streak != 0 {
+		t.Fatalf("expected a restart to reset the check, got %s/%d", status, streak)
+	}
+}
@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// expectedLabel marks a container that must keep existing: once healthmon
+// has seen it, it alerts when the container is removed or stops running,
+// until its record is purged.
+const expectedLabel = "healthmon.expected"
+
+// expectedSpell is one stretch an expected container spent missing.
+type expectedSpell struct {
+	since   time.Time
+	alerted bool
+}
+
+// expectedTracker remembers since when each expected container has been
+// missing. It is only used by the heal check goroutine.
+type expectedTracker struct {
+	spells   map[string]expectedSpell
+	restored bool
+}
+
+func newExpectedTracker() *expectedTracker {
+	return &expectedTracker{spells: make(map[string]expectedSpell)}
+}
+
+// expectedNames returns the containers listed in HM_EXPECTED_CONTAINERS and
+// those labeled healthmon.expected=true, sorted.
+func (m *Monitor) expectedNames() []string {
+	names := append([]string{}, m.cfg.ExpectedContainers...)
+	for _, c := range m.store.ListAllContainers() {
+		if expected, err := strconv.ParseBool(c.Labels[expectedLabel]); err == nil && expected {
+			names = append(names, c.Name)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// runsAtAll reports whether a container is up in some form. Paused and
+// restarting containers are covered by their own alerts.
+func runsAtAll(c store.Container) bool {
+	if !c.Present {
+		return false
+	}
+	switch strings.ToLower(c.Status) {
+	case "exited", "created", "dead", "removing":
+		return false
+	}
+	return true
+}
+
+// checkExpected raises container_missing for expected containers that do
+// not exist or do not run at all for longer than HM_EXPECTED_GRACE_SECONDS,
+// which gives recreates time to finish, and container_returned once they run
+// again. A container healthmon never saw is reported on _healthmon.
+func (m *Monitor) checkExpected(ctx context.Context) {
+	names := m.expectedNames()
+	if len(names) == 0 {
+		return
+	}
+	grace := time.Duration(m.cfg.ExpectedGraceSeconds) * time.Second
+	now := m.clock.Now()
+	if !m.expected.restored {
+		m.restoreExpected(ctx, names)
+		m.expected.restored = true
+	}
+	for _, name := range names {
+		c, known := m.store.GetContainer(name)
+		spell, tracked := m.expected.spells[name]
+		if known && runsAtAll(c) {
+			if tracked && spell.alerted {
+				m.emitExpectedAlert(ctx, name, "container_returned", "green",
+					fmt.Sprintf("Expected container is running again after %s", now.Sub(spell.since).Round(time.Second)), spell.since, now)
+			}
+			delete(m.expected.spells, name)
+			continue
+		}
+		if !tracked {
+			spell.since = now
+			// Stored containers know since when they are gone or stopped,
+			// also across restarts of healthmon.
+			switch {
+			case known && !c.Present && !c.UpdatedAt.IsZero():
+				spell.since = c.UpdatedAt
+			case known && !c.FinishedAt.IsZero():
+				spell.since = c.FinishedAt
+			}
+		}
+		if !spell.alerted && now.Sub(spell.since) >= grace {
+			spell.alerted = true
+			var message string
+			switch {
+			case !known:
+				message = fmt.Sprintf("Expected container %s does not exist", name)
+			case !c.Present:
+				message = fmt.Sprintf("Expected container was removed %s ago", now.Sub(spell.since).Round(time.Second))
+			default:
+				message = fmt.Sprintf("Expected container is %s for %s", strings.ToLower(c.Status), now.Sub(spell.since).Round(time.Second))
+			}
+			m.emitExpectedAlert(ctx, name, "container_missing", "red", message, spell.since, now)
+		}
+		m.expected.spells[name] = spell
+	}
+	for name := range m.expected.spells {
+		if !slices.Contains(names, name) {
+			delete(m.expected.spells, name)
+		}
+	}
+}
+
+// emitExpectedAlert files an alert on the container, or on _healthmon when
+// healthmon has no record of it.
+func (m *Monitor) emitExpectedAlert(ctx context.Context, name, alertType, severity, message string, since, now time.Time) {
+	a := store.Alert{
+		Container:   name,
+		Type:        alertType,
+		Severity:    severity,
+		Message:     message,
+		Timestamp:   now,
+		DetailsJSON: store.EncodeDetails(store.ExpectedDetails{Container: name, Since: since.UTC().Format(time.RFC3339)}),
+	}
+	if c, ok := m.store.GetContainer(name); ok {
+		a.ContainerID = c.ContainerID
+	} else if _, ok := m.ensureSelfContainer(ctx); ok {
+		a.Container = selfContainerName
+	} else {
+		return
+	}
+	m.emitAlertRecord(ctx, a)
+}
+
+// restoreExpected picks up the container_missing alerts raised before a
+// restart, so they are not raised again and are followed up once the
+// container runs.
+func (m *Monitor) restoreExpected(ctx context.Context, names []string) {
+	for _, name := range names {
+		latest, found := m.latestExpectedAlert(ctx, name)
+		if !found || latest.Type != "container_missing" {
+			continue
+		}
+		spell := expectedSpell{since: latest.Timestamp, alerted: true}
+		if details, err := store.DecodeDetails(latest.DetailsJSON); err == nil {
+			if d, ok := details.(*store.ExpectedDetails); ok {
+				if since, err := time.Parse(time.RFC3339, d.Since); err == nil {
+					spell.since = since
+				}
+			}
+		}
+		m.expected.spells[name] = spell
+	}
+}
+
+// latestExpectedAlert returns the latest container_missing or
+// container_returned alert about a container, on its own record or, when it
+// has none, on _healthmon.
+func (m *Monitor) latestExpectedAlert(ctx context.Context, name string) (store.Alert, bool) {
+	types := []string{"container_missing", "container_returned"}
+	if c, ok := m.store.GetContainer(name); ok {
+		latest, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, types...)
+		if err != nil {
+			log.Printf("expected alert lookup failed for %s: %v", name, err)
+			return store.Alert{}, false
+		}
+		if found {
+			return latest, true
+		}
+	}
+	alerts, err := m.store.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}, Types: types}, 0, 100)
+	if err != nil {
+		log.Printf("expected alert lookup failed for %s: %v", name, err)
+		return store.Alert{}, false
+	}
+	for _, a := range alerts {
+		if details, err := store.DecodeDetails(a.DetailsJSON); err == nil {
+			if d, ok := details.(*store.ExpectedDetails); ok && d.Container == name {
+				return a, true
+			}
+		}
+	}
+	return store.Alert{}, false
+}
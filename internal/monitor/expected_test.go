@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestExpectedContainersAlertWhenMissing(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{ExpectedContainers: []string{"backup", "web"}, ExpectedGraceSeconds: 120}
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	db := store.Container{Name: "db", ContainerID: "cid-db", Status: "running", Present: true, Labels: map[string]string{expectedLabel: "true"}}
+	web := store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true}
+	mon, st := newStatsMonitor(t, cfg, db, web)
+	mon.WithClock(fake)
+	st.WithClock(fake)
+
+	mon.checkExpected(ctx)
+	// db is deleted; a recreate within the grace period would not alert.
+	if err := st.SetContainerPresent(ctx, "db", false); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	fake.Advance(time.Minute)
+	mon.checkExpected(ctx)
+	fake.Advance(2 * time.Minute)
+	mon.checkExpected(ctx)
+
+	// A restarted healthmon does not raise the same alerts again.
+	mon.expected = newExpectedTracker()
+	mon.checkExpected(ctx)
+
+	db.ContainerID = "cid-db-2"
+	if err := st.UpsertContainer(ctx, db); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	fake.Advance(time.Minute)
+	mon.checkExpected(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Container+" "+a.Type+": "+a.Message)
+	}
+	want := []string{
+		"_healthmon container_missing: Expected container backup does not exist",
+		"db container_missing: Expected container was removed 3m0s ago",
+		"db container_returned: Expected container is running again after 4m0s",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected alerts:\n%s", strings.Join(got, "\n"))
+	}
+}
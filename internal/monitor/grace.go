@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const unhealthyGraceLabel = "healthmon.unhealthy_grace"
+
+// unhealthyGrace returns how long a container must stay unhealthy before it is
+// alerted on: the healthmon.unhealthy_grace label when it holds a valid
+// duration ("0" turns the grace off), HM_UNHEALTHY_GRACE_SECONDS otherwise.
+func (m *Monitor) unhealthyGrace(labels map[string]string) time.Duration {
+	if value := strings.TrimSpace(labels[unhealthyGraceLabel]); value != "" {
+		if value == "0" {
+			return 0
+		}
+		if grace, err := time.ParseDuration(value); err == nil && grace >= 0 {
+			return grace
+		}
+		log.Printf("ignoring invalid %s label %q", unhealthyGraceLabel, value)
+	}
+	return time.Duration(m.cfg.UnhealthyGraceSeconds) * time.Second
+}
+
+// unhealthyAlerted reports whether the unhealthy alert for the container's
+// current unhealthy spell has been raised.
+func (m *Monitor) unhealthyAlerted(ctx context.Context, c store.Container) bool {
+	latest, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, "unhealthy", "healthy")
+	if err != nil {
+		log.Printf("unhealthy alert lookup failed for %s: %v", c.Name, err)
+		return true
+	}
+	return found && latest.Type == "unhealthy" && !latest.Timestamp.Before(c.UnhealthySince.Truncate(time.Second))
+}
+
+// checkUnhealthyGrace raises the unhealthy alert for containers that stayed
+// unhealthy past their grace period.
+func (m *Monitor) checkUnhealthyGrace(ctx context.Context) {
+	now := m.clock.Now()
+	for _, c := range m.store.ListContainers() {
+		if c.UnhealthyGrace <= 0 || !strings.EqualFold(c.HealthStatus, "unhealthy") || c.UnhealthySince.IsZero() {
+			continue
+		}
+		if c.HealthPending(now) || m.unhealthyAlerted(ctx, c) {
+			continue
+		}
+		message := fmt.Sprintf("Container unhealthy for more than %s", c.UnhealthyGrace)
+		m.emitUnhealthy(ctx, c, c.Name, c.ContainerID, c.CurrentContainerName, message)
+	}
+}
@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestUnhealthyAlertWaitsForGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := fake.Now()
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:           "jvm",
+		ContainerID:    "cid-jvm",
+		Status:         "running",
+		StartedAt:      now.Add(-time.Hour),
+		Present:        true,
+		HealthStatus:   "unhealthy",
+		UnhealthySince: now,
+		UnhealthyGrace: 2 * time.Minute,
+		UpdatedAt:      now,
+	}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+	unhealthyAlerts := func() int {
+		t.Helper()
+		alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"unhealthy"}}, 0, 10)
+		if err != nil {
+			t.Fatalf("list alerts: %v", err)
+		}
+		return len(alerts)
+	}
+
+	fake.Advance(time.Minute)
+	mon.checkUnhealthyGrace(ctx)
+	if got, _ := st.GetContainer("jvm"); !got.HealthPending(fake.Now()) {
+		t.Fatalf("expected the container to be pending inside the grace period")
+	}
+	if n := unhealthyAlerts(); n != 0 {
+		t.Fatalf("expected no alert inside the grace period, got %d", n)
+	}
+
+	fake.Advance(2 * time.Minute)
+	mon.checkUnhealthyGrace(ctx)
+	mon.checkUnhealthyGrace(ctx)
+	if n := unhealthyAlerts(); n != 1 {
+		t.Fatalf("expected one alert once the grace period passed, got %d", n)
+	}
+}
+
+func TestUnhealthyGraceLabelOverridesDefault(t *testing.T) {
+	mon := New(config.Config{UnhealthyGraceSeconds: 30}, nil, nil)
+	cases := map[string]time.Duration{
+		"":        30 * time.Second,
+		"2m":      2 * time.Minute,
+		"0":       0,
+		"garbage": 30 * time.Second,
+	}
+	for label, want := range cases {
+		if got := mon.unhealthyGrace(map[string]string{unhealthyGraceLabel: label}); got != want {
+			t.Fatalf("label %q: expected %s, got %s", label, want, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+const (
+	composeDependsOnLabel = "com.docker.compose.depends_on"
+	dependsOnLabel        = "healthmon.depends_on"
+)
+
+// dependsOn returns the services a container depends on, sorted. Compose
+// records depends_on as "service:condition:restart" entries; the
+// healthmon.depends_on label is a plain comma-separated list of names.
+func dependsOn(labels map[string]string) []string {
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(labels[composeDependsOnLabel], ",") {
+		service, _, _ := strings.Cut(entry, ":")
+		if service = strings.TrimSpace(service); service != "" {
+			seen[service] = true
+		}
+	}
+	for _, service := range strings.Split(labels[dependsOnLabel], ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			seen[service] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// containerNetworks returns the user-defined networks a container is
+// attached to, sorted. Docker's bridge, host and none networks are left
+// out: sharing them says nothing about how services relate.
+func containerNetworks(inspect container.InspectResponse) []string {
+	seen := map[string]bool{}
+	if inspect.NetworkSettings != nil {
+		for name := range inspect.NetworkSettings.Networks {
+			switch name {
+			case "bridge", "host", "none":
+				continue
+			}
+			seen[name] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,152 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	leaseName   = "monitor"
+	roleActive  = "active"
+	roleStandby = "standby"
+)
+
+// Run monitors Docker until ctx is done. With HM_HA_INSTANCE set, instances
+// sharing a database elect one active instance through a lease in the
+// database; the others stay on standby, serving the API from the database,
+// and take over once the active instance stops renewing its lease.
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.cfg.HAInstance == "" {
+		return m.Start(ctx)
+	}
+	ttl := time.Duration(m.cfg.HALeaseSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+	ticker := m.clock.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	m.state.setRole(roleStandby)
+	var active *activeRun
+	// expires is when the lease this instance last renewed runs out.
+	var expires time.Time
+	defer func() {
+		if active != nil {
+			active.stop()
+			m.releaseLease()
+		}
+	}()
+	standby := func() {
+		active.stop()
+		active = nil
+		m.state.setRole(roleStandby)
+	}
+
+	for {
+		lease, replaced, err := m.store.AcquireLease(ctx, leaseName, m.cfg.HAInstance, ttl)
+		switch {
+		case err != nil && active != nil && m.clock.Now().Before(expires):
+			// Nobody else can take the lease before it expires, so a
+			// failed renewal does not end the active run by itself.
+			log.Printf("lease renewal failed: %v; staying active until the lease expires at %s", err, expires.UTC().Format(time.RFC3339))
+		case err != nil && active != nil:
+			// A standby may have taken over by now.
+			log.Printf("lease renewal failed: %v; the lease expired, going on standby", err)
+			standby()
+		case err != nil:
+			log.Printf("lease acquisition failed: %v", err)
+		case lease.Holder == m.cfg.HAInstance:
+			expires = lease.ExpiresAt
+			if active != nil {
+				break
+			}
+			log.Printf("instance %s is now active", m.cfg.HAInstance)
+			// The standby's cache may be a tick behind the last active
+			// instance's writes.
+			if err := m.store.Load(ctx); err != nil {
+				log.Printf("reload store: %v", err)
+			}
+			m.state.setRole(roleActive)
+			if replaced.Holder != "" {
+				m.reportFailover(ctx, replaced)
+			}
+			active = m.startActive(ctx)
+		case active != nil:
+			// Another instance took over, e.g. because renewals failed
+			// for longer than the lease.
+			log.Printf("instance %s lost the lease to %s, going on standby", m.cfg.HAInstance, lease.Holder)
+			standby()
+		default:
+			if err := m.store.Load(ctx); err != nil {
+				log.Printf("standby reload failed: %v", err)
+			}
+		}
+
+		var stopped <-chan error
+		if active != nil {
+			stopped = active.done
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-stopped:
+			// The monitor gave up on its own; hand over to the standby.
+			active.cancel()
+			active = nil
+			m.releaseLease()
+			return err
+		case <-ticker.C():
+		}
+	}
+}
+
+// activeRun is the monitor running while this instance holds the lease.
+type activeRun struct {
+	cancel context.CancelFunc
+	done   chan error
+}
+
+func (m *Monitor) startActive(ctx context.Context) *activeRun {
+	ctx, cancel := context.WithCancel(ctx)
+	run := &activeRun{cancel: cancel, done: make(chan error, 1)}
+	go func() { run.done <- m.Start(ctx) }()
+	return run
+}
+
+// stop cancels the run and waits for the monitor to return.
+func (r *activeRun) stop() {
+	r.cancel()
+	<-r.done
+}
+
+// releaseLease lets a standby take over right away instead of waiting for
+// the lease to expire.
+func (m *Monitor) releaseLease() {
+	if err := m.store.ReleaseLease(context.Background(), leaseName, m.cfg.HAInstance); err != nil {
+		log.Printf("release lease: %v", err)
+	}
+}
+
+// reportFailover files a monitor_failover alert on _healthmon after this
+// instance took over from one whose lease expired.
+func (m *Monitor) reportFailover(ctx context.Context, replaced store.Lease) {
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container: selfContainerName,
+		Type:      "monitor_failover",
+		Severity:  "yellow",
+		Message:   fmt.Sprintf("Instance %s took over from %s, which stopped renewing its lease at %s", m.cfg.HAInstance, replaced.Holder, replaced.ExpiresAt.UTC().Format(time.RFC3339)),
+		Timestamp: m.clock.Now(),
+		DetailsJSON: store.EncodeDetails(store.FailoverDetails{
+			Instance:         m.cfg.HAInstance,
+			PreviousInstance: replaced.Holder,
+			LeaseExpiredAt:   replaced.ExpiresAt.UTC().Format(time.RFC3339),
+		}),
+	})
+}
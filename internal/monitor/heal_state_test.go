@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"healthmon/internal/api"
+	"healthmon/internal/clock"
 	"healthmon/internal/config"
 	"healthmon/internal/db"
 	"healthmon/internal/store"
@@ -89,7 +90,7 @@ func TestCheckHealsClearsPersistedRestartLoopWithoutInMemoryTracker(t *testing.T
 		t.Fatalf("expected restart_streak=0, got %d", updated.RestartStreak)
 	}
 
-	alerts, err := st.ListAllAlerts(ctx, 0, 20)
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{}, 0, 20)
 	if err != nil {
 		t.Fatalf("list alerts: %v", err)
 	}
@@ -104,3 +105,86 @@ func TestCheckHealsClearsPersistedRestartLoopWithoutInMemoryTracker(t *testing.T
 		t.Fatalf("expected restart_healed alert")
 	}
 }
+
+func TestCheckHealsWaitsForRestartWindowOnFakeClock(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := fake.Now()
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:          "imapsync",
+		ContainerID:   "cid-1",
+		Image:         "ghcr.io/example/imapsync",
+		ImageTag:      "latest",
+		ImageID:       "sha256:image",
+		CreatedAt:     now.Add(-3 * time.Hour),
+		RegisteredAt:  now.Add(-3 * time.Hour),
+		StartedAt:     now,
+		Status:        "running",
+		Role:          "service",
+		Caps:          []string{},
+		User:          "0:0",
+		Present:       true,
+		RestartLoop:   true,
+		RestartStreak: 4,
+		UpdatedAt:     now,
+	}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	container, _ := st.GetContainer("imapsync")
+	if _, err := st.AddEvent(ctx, store.Event{
+		ContainerPK: container.ID,
+		Container:   container.Name,
+		ContainerID: container.ContainerID,
+		Type:        "restart",
+		Severity:    "blue",
+		Message:     "Restart event: die",
+		Timestamp:   now,
+		Reason:      "die",
+	}); err != nil {
+		t.Fatalf("add restart event: %v", err)
+	}
+
+	server := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	mon := New(config.Config{
+		RestartWindowSeconds: 300,
+		RestartThreshold:     3,
+	}, st, server)
+	mon.WithClock(fake)
+
+	fake.Advance(4 * time.Minute)
+	mon.checkHeals(ctx)
+	if got, _ := st.GetContainer("imapsync"); !got.RestartLoop {
+		t.Fatalf("expected restart loop to hold inside the window")
+	}
+
+	fake.Advance(2 * time.Minute)
+	mon.checkHeals(ctx)
+	got, _ := st.GetContainer("imapsync")
+	if got.RestartLoop {
+		t.Fatalf("expected restart loop to heal once the window passed")
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"restart_healed"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || !alerts[0].Timestamp.Equal(fake.Now()) {
+		t.Fatalf("expected one restart_healed alert stamped with the fake time, got %#v", alerts)
+	}
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"context"
+	"strings"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// maxProbeSummary bounds the probe output quoted in alert messages; the
+// full output is kept in the alert details.
+const maxProbeSummary = 200
+
+// lastHealthProbe returns the latest entry of Docker's health log.
+func lastHealthProbe(log []*container.HealthcheckResult) *store.HealthProbe {
+	if len(log) == 0 || log[len(log)-1] == nil {
+		return nil
+	}
+	last := log[len(log)-1]
+	return &store.HealthProbe{
+		ExitCode: last.ExitCode,
+		Output:   strings.TrimSpace(last.Output),
+		At:       last.End,
+	}
+}
+
+// emitUnhealthy raises the unhealthy alert for c, quoting the output of the
+// failed healthcheck so the alert says why the check failed. c is the zero
+// Container when the container is not known.
+func (m *Monitor) emitUnhealthy(ctx context.Context, c store.Container, name, id, parsedName, message string) {
+	alert := store.Alert{
+		Container:           name,
+		ContainerID:         id,
+		ParsedContainerName: parsedName,
+		Type:                "unhealthy",
+		Severity:            "red",
+		Message:             message,
+		Timestamp:           m.clock.Now(),
+	}
+	if probe := c.LastHealthProbe; probe != nil {
+		if summary := probeSummary(probe.Output); summary != "" {
+			alert.Message += ": " + summary
+		}
+		alert.DetailsJSON = store.EncodeDetails(store.HealthCheckDetails{ExitCode: probe.ExitCode, Output: probe.Output})
+	}
+	m.emitAlertRecord(ctx, alert)
+}
+
+// probeSummary is the first non-empty line of the probe output, shortened
+// for a one-line message.
+func probeSummary(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if len(line) > maxProbeSummary {
+			line = strings.ToValidUTF8(line[:maxProbeSummary], "") + "…"
+		}
+		return line
+	}
+	return ""
+}
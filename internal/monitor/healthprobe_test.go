@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestLastHealthProbeTakesLatestLogEntry(t *testing.T) {
+	if probe := lastHealthProbe(nil); probe != nil {
+		t.Fatalf("expected no probe without a health log, got %+v", probe)
+	}
+	end := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	probe := lastHealthProbe([]*container.HealthcheckResult{
+		{ExitCode: 0, Output: "ok", End: end.Add(-30 * time.Second)},
+		{ExitCode: 1, Output: "curl: (7) Failed to connect\n", End: end},
+	})
+	if probe == nil || probe.ExitCode != 1 || probe.Output != "curl: (7) Failed to connect" || !probe.At.Equal(end) {
+		t.Fatalf("unexpected probe: %+v", probe)
+	}
+
+	if got := probeSummary("\n  \nfirst line\nsecond line"); got != "first line" {
+		t.Fatalf("expected the first non-empty line, got %q", got)
+	}
+	if got := probeSummary(strings.Repeat("x", 500)); len(got) > maxProbeSummary+len("…") {
+		t.Fatalf("expected the summary to be shortened, got %d bytes", len(got))
+	}
+}
+
+func TestUnhealthyAlertCarriesFailingProbe(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := fake.Now()
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:            "api",
+		ContainerID:     "cid-api",
+		Status:          "running",
+		StartedAt:       now.Add(-time.Hour),
+		Present:         true,
+		HealthStatus:    "unhealthy",
+		UnhealthySince:  now,
+		UnhealthyGrace:  time.Minute,
+		LastHealthProbe: &store.HealthProbe{ExitCode: 1, Output: "HTTP 503 Service Unavailable\nbody: db down", At: now},
+		UpdatedAt:       now,
+	}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	// The probe survives a reload from the database.
+	reloaded := store.New(dbConn.SQL)
+	if c, ok := reloaded.GetContainer("api"); !ok || c.LastHealthProbe == nil || c.LastHealthProbe.ExitCode != 1 {
+		t.Fatalf("expected the probe to be stored, got %+v", c.LastHealthProbe)
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+	fake.Advance(2 * time.Minute)
+	mon.checkUnhealthyGrace(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"unhealthy"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected one unhealthy alert, got %d", len(alerts))
+	}
+	if want := "Container unhealthy for more than 1m0s: HTTP 503 Service Unavailable"; alerts[0].Message != want {
+		t.Fatalf("expected message %q, got %q", want, alerts[0].Message)
+	}
+	details, err := store.DecodeDetails(alerts[0].DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	check, ok := details.(*store.HealthCheckDetails)
+	if !ok || check.ExitCode != 1 || check.Output != "HTTP 503 Service Unavailable\nbody: db down" {
+		t.Fatalf("unexpected details: %#v", details)
+	}
+}
@@ -0,0 +1,326 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/client"
+)
+
+// maxHookOutput bounds the output kept of a hook; the end is kept, where
+// errors usually are.
+const maxHookOutput = 16 << 10
+
+const (
+	hookTargetHealthmon = "healthmon"
+	hookTargetContainer = "container"
+)
+
+// alertHook runs a command when an alert of a matching type is raised, e.g.
+// to dump diagnostics or flush a cache. run commands run next to healthmon,
+// exec commands inside the alerting container.
+type alertHook struct {
+	alertType string
+	container string
+	target    string
+	command   string
+	// args are the words of a run command. The healthmon image has no
+	// shell, so run commands are started directly.
+	args []string
+}
+
+func (h alertHook) matches(a store.Alert) bool {
+	if ok, _ := path.Match(h.alertType, a.Type); !ok {
+		return false
+	}
+	if h.container == "" {
+		return true
+	}
+	ok, _ := path.Match(h.container, a.Container)
+	return ok
+}
+
+// parseAlertHooks reads HM_ALERT_HOOKS. Invalid entries are logged and
+// skipped.
+func parseAlertHooks(hooks string) []alertHook {
+	var parsed []alertHook
+	for _, entry := range strings.Split(hooks, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		hook, err := parseAlertHook(entry)
+		if err != nil {
+			log.Printf("ignoring HM_ALERT_HOOKS entry %q: %v", entry, err)
+			continue
+		}
+		parsed = append(parsed, hook)
+	}
+	return parsed
+}
+
+// parseAlertHook parses a hook like
+// "type=restart_loop,container=web*,exec=kill -QUIT 1". The command comes
+// last and takes the rest of the entry, commas included.
+func parseAlertHook(entry string) (alertHook, error) {
+	var hook alertHook
+	rest := entry
+	for rest != "" {
+		var field string
+		field, rest, _ = strings.Cut(rest, ",")
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.TrimSpace(key)
+		if key == "run" || key == "exec" {
+			if rest != "" {
+				value += "," + rest
+			}
+			rest = ""
+		}
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return alertHook{}, fmt.Errorf("expected key=value, got %q", field)
+		}
+		switch key {
+		case "type", "container":
+			if _, err := path.Match(value, ""); err != nil {
+				return alertHook{}, fmt.Errorf("invalid %s pattern %q", key, value)
+			}
+			if key == "type" {
+				hook.alertType = value
+			} else {
+				hook.container = value
+			}
+		case "run":
+			args, err := splitCommand(value)
+			if err != nil {
+				return alertHook{}, err
+			}
+			hook.target, hook.command, hook.args = hookTargetHealthmon, value, args
+		case "exec":
+			hook.target, hook.command = hookTargetContainer, value
+		default:
+			return alertHook{}, fmt.Errorf("unknown field %q, expected type, container, run or exec", key)
+		}
+	}
+	if hook.alertType == "" || hook.command == "" {
+		return alertHook{}, fmt.Errorf("type and run or exec are required")
+	}
+	return hook, nil
+}
+
+// startAlertHooks runs the hooks matching an alert in the background. The
+// results are stored with the alert and broadcast as they come in.
+func (m *Monitor) startAlertHooks(ctx context.Context, container store.Container, a store.Alert) {
+	for _, hook := range m.hooks {
+		if !hook.matches(a) {
+			continue
+		}
+		// The alert may come from an API request, whose context ends with
+		// the response.
+		ctx := context.WithoutCancel(ctx)
+		go m.guard(ctx, "alert hook", func() {
+			run := m.runAlertHook(ctx, hook, container, a)
+			saved, err := m.store.AddAlertHookRun(ctx, run)
+			if err != nil {
+				log.Printf("alert hook result persist failed: %v", err)
+				m.diagnoseWrite(ctx, "alert hook result", err)
+				return
+			}
+			m.server.Broadcast(ctx, api.EventUpdate{
+				Container: api.ContainerResponse{Name: container.Name, Present: container.Present},
+				HookRun: &api.HookRunResponse{
+					ID:         saved.ID,
+					AlertID:    saved.AlertID,
+					Target:     saved.Target,
+					Command:    saved.Command,
+					ExitCode:   saved.ExitCode,
+					Output:     saved.Output,
+					Error:      saved.Error,
+					StartedAt:  saved.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+					DurationMS: saved.DurationMS,
+				},
+			})
+		})
+	}
+}
+
+// runAlertHook runs one hook within HM_HOOK_TIMEOUT_SECONDS.
+func (m *Monitor) runAlertHook(ctx context.Context, hook alertHook, container store.Container, a store.Alert) store.AlertHookRun {
+	run := store.AlertHookRun{AlertID: a.ID, Target: hook.target, Command: hook.command, ExitCode: -1, StartedAt: m.clock.Now()}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(m.cfg.HookTimeoutSeconds)*time.Second)
+	defer cancel()
+	started := time.Now()
+	output := &tailBuffer{limit: maxHookOutput}
+	var err error
+	if hook.target == hookTargetContainer {
+		run.ExitCode, err = m.execHook(ctx, container, hook.command, output)
+	} else {
+		run.ExitCode, err = runHookCommand(ctx, hook.args, a, output)
+	}
+	run.DurationMS = time.Since(started).Milliseconds()
+	run.Output = output.String()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %ds", m.cfg.HookTimeoutSeconds)
+	}
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("alert hook %q for %s %s failed: %v", hook.command, a.Container, a.Type, err)
+	}
+	return run
+}
+
+// runHookCommand runs a command next to healthmon with the alert in its
+// environment.
+func runHookCommand(ctx context.Context, args []string, a store.Alert, output io.Writer) (int, error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"HM_ALERT_ID="+strconv.FormatInt(a.ID, 10),
+		"HM_ALERT_TYPE="+a.Type,
+		"HM_ALERT_SEVERITY="+a.Severity,
+		"HM_ALERT_MESSAGE="+a.Message,
+		"HM_CONTAINER="+a.Container,
+		"HM_CONTAINER_ID="+a.ContainerID,
+	)
+	cmd.Stdout, cmd.Stderr = output, output
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		return exitErr.ExitCode(), fmt.Errorf("exit code %d", exitErr.ExitCode())
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// splitCommand splits a run command into words at spaces. Single and double
+// quotes keep spaces in a word, and a backslash outside single quotes
+// takes the next character literally. Nothing else is interpreted: there
+// are no variables, pipes or redirections without an explicit shell.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", command)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return args, nil
+}
+
+// execHook runs a command inside the container through docker exec. The
+// attached stream does not end with ctx, so it is closed when ctx is done;
+// Docker has no way to stop the command itself, which is left to finish
+// in the container.
+func (m *Monitor) execHook(ctx context.Context, container store.Container, command string, output io.Writer) (int, error) {
+	if !container.Present || container.ContainerID == "" || container.Status != "running" {
+		return -1, fmt.Errorf("container is not running")
+	}
+	created, err := m.docker.ExecCreate(ctx, container.ContainerID, client.ExecCreateOptions{Cmd: []string{"sh", "-c", command}, AttachStdout: true, AttachStderr: true})
+	if err != nil {
+		return -1, err
+	}
+	attached, err := m.docker.ExecAttach(ctx, created.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return -1, err
+	}
+	defer attached.Close()
+	stop := context.AfterFunc(ctx, attached.Close)
+	defer stop()
+	if _, err := stdcopy.StdCopy(output, output, attached.Reader); err != nil {
+		if ctx.Err() != nil {
+			return -1, ctx.Err()
+		}
+		return -1, err
+	}
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	inspect, err := m.docker.ExecInspect(ctx, created.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return -1, err
+	}
+	if inspect.ExitCode != 0 {
+		return inspect.ExitCode, fmt.Errorf("exit code %d", inspect.ExitCode)
+	}
+	return 0, nil
+}
+
+// tailBuffer keeps the last limit bytes written to it, so a hook that
+// prints without end cannot fill the memory.
+type tailBuffer struct {
+	limit int
+	buf   []byte
+	cut   bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	// Trim once the buffer doubles, not on every write.
+	if len(t.buf) > 2*t.limit {
+		t.trim()
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) trim() {
+	if len(t.buf) > t.limit {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.limit:]...)
+		t.cut = true
+	}
+}
+
+// String returns the kept output, marked with "…" when its start was cut.
+func (t *tailBuffer) String() string {
+	t.trim()
+	if !t.cut {
+		return string(t.buf)
+	}
+	return "…" + strings.ToValidUTF8(string(t.buf), "")
+}
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+func TestParseAlertHooks(t *testing.T) {
+	hooks := parseAlertHooks("type=restart_loop,container=web*,exec=kill -QUIT 1, then wait;type=oom_*,run=/scripts/dump.sh;type=x;run=true;type=a,shell=ls")
+	if len(hooks) != 2 {
+		t.Fatalf("expected 2 hooks, got %+v", hooks)
+	}
+	if h := hooks[0]; h.alertType != "restart_loop" || h.container != "web*" || h.target != hookTargetContainer || h.command != "kill -QUIT 1, then wait" {
+		t.Fatalf("unexpected exec hook %+v", h)
+	}
+	if h := hooks[1]; !h.matches(store.Alert{Type: "oom_killed", Container: "db"}) || h.target != hookTargetHealthmon || !slices.Equal(h.args, []string{"/scripts/dump.sh"}) {
+		t.Fatalf("unexpected run hook %+v", h)
+	}
+	if hooks := parseAlertHooks(`type=x,run=/scripts/dump.sh "unterminated`); len(hooks) != 0 {
+		t.Fatalf("expected an unterminated quote to be rejected, got %+v", hooks)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for command, want := range map[string][]string{
+		`/scripts/dump.sh`:                       {"/scripts/dump.sh"},
+		`  curl -fsS   http://cache/flush `:      {"curl", "-fsS", "http://cache/flush"},
+		`sh -c 'echo "$HM_CONTAINER" && exit 3'`: {"sh", "-c", `echo "$HM_CONTAINER" && exit 3`},
+		`notify "disk full" it\'s\ over ''`:      {"notify", "disk full", "it's over", ""},
+	} {
+		got, err := splitCommand(command)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("splitCommand(%q) = %q, %v, want %q", command, got, err, want)
+		}
+	}
+	for _, command := range []string{"", "   ", `echo "open`, `echo 'open`, `echo \`} {
+		if _, err := splitCommand(command); err == nil {
+			t.Errorf("expected %q to be rejected", command)
+		}
+	}
+}
+
+func TestAlertHooksStoreTheirOutput(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{
+		AlertHooks:         `type=oom_killed,run=sh -c 'echo "$HM_CONTAINER $HM_ALERT_TYPE, dumping" && exit 3';type=oom_killed,container=db,run=sleep 5`,
+		HookTimeoutSeconds: 1,
+	}
+	mon, st := newStatsMonitor(t, cfg,
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true},
+		store.Container{Name: "db", ContainerID: "cid-db", Status: "running", Present: true},
+	)
+	mon.emitAlert(ctx, "web", "cid-web", "", "oom_killed", "Killed by the OOM killer", "red", nil)
+	mon.emitAlert(ctx, "db", "cid-db", "", "oom_killed", "Killed by the OOM killer", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "", "unhealthy", "Unhealthy", "red", nil)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil || len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %d: %v", len(alerts), err)
+	}
+	var runs []store.AlertHookRun
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		runs, err = st.ListAlertHookRuns(ctx, alerts[0].ID, alerts[1].ID, alerts[2].ID)
+		if err != nil {
+			t.Fatalf("list hook runs: %v", err)
+		}
+		if len(runs) >= 3 {
+			break
+		}
+	}
+	slices.SortFunc(runs, func(a, b store.AlertHookRun) int { return int(a.AlertID - b.AlertID) })
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 hook runs, got %+v", runs)
+	}
+	if r := runs[0]; r.AlertID != alerts[0].ID || r.ExitCode != 3 || r.Output != "web oom_killed, dumping\n" || r.Error != "exit code 3" {
+		t.Fatalf("unexpected hook run %+v", r)
+	}
+	i := slices.IndexFunc(runs, func(r store.AlertHookRun) bool { return r.Command == "sleep 5" })
+	if i < 0 || runs[i].AlertID != alerts[1].ID || runs[i].ExitCode != -1 || runs[i].Error != "timed out after 1s" {
+		t.Fatalf("expected the sleep to time out, got %+v", runs[1:])
+	}
+}
+
+func TestExecHookStopsWaitingAtTheTimeout(t *testing.T) {
+	// The exec starts and never prints or exits.
+	hang := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/_ping"):
+			w.Header().Set("Api-Version", "1.44")
+		case strings.HasSuffix(r.URL.Path, "/containers/cid-web/exec"):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"Id":"exec-1"}`))
+		case strings.HasSuffix(r.URL.Path, "/exec/exec-1/start"):
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, _ = buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.multiplexed-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			_ = buf.Flush()
+			<-hang
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	defer close(hang)
+
+	mon, _ := newStatsMonitor(t, config.Config{})
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(ts.URL, "http://")), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := mon.execHook(ctx, store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true}, "sleep 60", &tailBuffer{limit: maxHookOutput})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("expected the hook to time out, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("exec hook kept waiting past its timeout")
+	}
+}
+
+func TestTailBufferKeepsTheEnd(t *testing.T) {
+	out := &tailBuffer{limit: 8}
+	for range 10 {
+		_, _ = out.Write([]byte("abc"))
+	}
+	if got := out.String(); got != "…bcabcabc" {
+		t.Fatalf("unexpected tail %q", got)
+	}
+	short := &tailBuffer{limit: 8}
+	_, _ = short.Write([]byte("ok\n"))
+	if got := short.String(); got != "ok\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// OCI labels image builds use to record what they were built from.
+const (
+	imageVersionLabel  = "org.opencontainers.image.version"
+	imageRevisionLabel = "org.opencontainers.image.revision"
+)
+
+// imageMeta is what healthmon reads from an image inspect.
+type imageMeta struct {
+	Platform string
+	// RepoDigests are the registry digests ("repo@sha256:...") of the image.
+	RepoDigests []string
+	Version     string
+	Revision    string
+	Source      string
+	Size        int64
+}
+
+// inspectImage returns the metadata of an image, cached per image id since
+// images are immutable. Caching also keeps the metadata of an image that was
+// removed right after its container was recreated, as Watchtower's cleanup
+// does. It runs on the event loop only.
+func (m *Monitor) inspectImage(ctx context.Context, imageID string) (imageMeta, bool) {
+	if imageID == "" {
+		return imageMeta{}, false
+	}
+	if meta, ok := m.images[imageID]; ok {
+		return meta, true
+	}
+	inspect, err := m.docker.ImageInspect(ctx, imageID)
+	if err != nil {
+		return imageMeta{}, false
+	}
+	meta := imageMeta{
+		Platform:    inspect.Os + "/" + inspect.Architecture,
+		RepoDigests: inspect.RepoDigests,
+		Size:        inspect.Size,
+	}
+	if inspect.Variant != "" {
+		meta.Platform += "/" + inspect.Variant
+	}
+	if inspect.Config != nil {
+		meta.Version = inspect.Config.Labels[imageVersionLabel]
+		meta.Revision = inspect.Config.Labels[imageRevisionLabel]
+		meta.Source = inspect.Config.Labels[imageSourceLabel]
+	}
+	m.images[imageID] = meta
+	return meta, true
+}
+
+// digest returns the registry digest of the image as pulled from repo,
+// falling back to any digest it has.
+func (meta imageMeta) digest(repo string) string {
+	for _, rd := range meta.RepoDigests {
+		name, digest, ok := strings.Cut(rd, "@")
+		if ok && name == repo {
+			return digest
+		}
+	}
+	for _, rd := range meta.RepoDigests {
+		if _, digest, ok := strings.Cut(rd, "@"); ok {
+			return digest
+		}
+	}
+	return ""
+}
+
+// label renders the version and short revision of an image, e.g.
+// "1.4.2 (3f9c2ab)", or "" when the image carries neither label.
+func (meta imageMeta) label() string {
+	revision := meta.Revision
+	if len(revision) > 7 && isHex(revision) {
+		revision = revision[:7]
+	}
+	switch {
+	case meta.Version != "" && revision != "":
+		return fmt.Sprintf("%s (%s)", meta.Version, revision)
+	case meta.Version != "":
+		return meta.Version
+	default:
+		return revision
+	}
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func newImageUpdateDetails(oldRepo string, oldMeta imageMeta, newRepo string, newMeta imageMeta) store.ImageUpdateDetails {
+	return store.ImageUpdateDetails{
+		OldDigest:   oldMeta.digest(oldRepo),
+		NewDigest:   newMeta.digest(newRepo),
+		OldVersion:  oldMeta.Version,
+		NewVersion:  newMeta.Version,
+		OldRevision: oldMeta.Revision,
+		NewRevision: newMeta.Revision,
+		OldSize:     oldMeta.Size,
+		NewSize:     newMeta.Size,
+	}
+}
+
+// imageUpdateMessage describes an image update by version when the images
+// are labelled, e.g. "Container image updated: 1.4.1 (9d1e0c4) -> 1.4.2
+// (3f9c2ab)".
+func imageUpdateMessage(oldMeta, newMeta imageMeta) string {
+	oldLabel, newLabel := oldMeta.label(), newMeta.label()
+	switch {
+	case newLabel == "":
+		return "Container image updated"
+	case oldLabel == "" || oldLabel == newLabel:
+		return "Container image updated to " + newLabel
+	default:
+		return fmt.Sprintf("Container image updated: %s -> %s", oldLabel, newLabel)
+	}
+}
+
+// emitImageChanged records an image update of a recreated container, with
+// the digests and versions of both images and a link to the release notes
+// in the details. When the link takes a GitHub lookup, the update is
+// recorded once the lookup is done, off the event loop, with the time it
+// was seen.
+func (m *Monitor) emitImageChanged(ctx context.Context, existing, newInfo store.Container, id, parsedName string) {
+	oldMeta, _ := m.inspectImage(ctx, existing.ImageID)
+	newMeta, _ := m.inspectImage(ctx, newInfo.ImageID)
+	update := newImageUpdateDetails(existing.Image, oldMeta, newInfo.Image, newMeta)
+	message := imageUpdateMessage(oldMeta, newMeta)
+	at := m.clock.Now()
+	if link, ok := m.changelog.cached(newMeta.Source, newMeta.Version, newInfo.ImageTag); ok {
+		update.ChangelogURL = link
+		m.recordImageChanged(ctx, existing, newInfo, id, parsedName, message, update, at)
+		return
+	}
+	go m.guard(ctx, "changelog lookup", func() {
+		update.ChangelogURL = m.changelog.resolve(ctx, newMeta.Source, newMeta.Version, newInfo.ImageTag)
+		m.recordImageChanged(ctx, existing, newInfo, id, parsedName, message, update, at)
+	})
+}
+
+func (m *Monitor) recordImageChanged(ctx context.Context, existing, newInfo store.Container, id, parsedName, message string, update store.ImageUpdateDetails, at time.Time) {
+	details := store.EncodeDetails(update)
+	e := m.infoEvent(newInfo.Name, id, parsedName, "image_changed", fmt.Sprintf("Image changed %s -> %s", existing.Image, newInfo.Image), existing.Image, newInfo.Image, existing.ImageID, newInfo.ImageID, "recreate", nil)
+	e.Timestamp = at
+	e.DetailsJSON = details
+	m.emitEvent(ctx, e)
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:           newInfo.Name,
+		ContainerID:         id,
+		ParsedContainerName: parsedName,
+		Type:                "image_changed",
+		Severity:            "blue",
+		Message:             message,
+		Timestamp:           at,
+		OldImage:            existing.Image,
+		NewImage:            newInfo.Image,
+		OldImageID:          existing.ImageID,
+		NewImageID:          newInfo.ImageID,
+		Reason:              "recreate",
+		DetailsJSON:         details,
+	})
+}
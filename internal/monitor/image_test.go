@@ -0,0 +1,36 @@
+package monitor
+
+import "testing"
+
+func TestImageUpdateMessage(t *testing.T) {
+	oldMeta := imageMeta{Version: "1.4.1", Revision: "9d1e0c4b7a2f6e8d5c3b1a0f9e8d7c6b5a4f3e2d"}
+	newMeta := imageMeta{Version: "1.4.2", Revision: "3f9c2ab1e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b9"}
+	cases := []struct {
+		oldMeta, newMeta imageMeta
+		want             string
+	}{
+		{oldMeta, newMeta, "Container image updated: 1.4.1 (9d1e0c4) -> 1.4.2 (3f9c2ab)"},
+		{imageMeta{}, newMeta, "Container image updated to 1.4.2 (3f9c2ab)"},
+		{newMeta, imageMeta{Version: "1.4.2", Revision: "3f9c2ab1e4d5c6b7a8f9e0d1c2b3a4f5e6d7c8b9"}, "Container image updated to 1.4.2 (3f9c2ab)"},
+		{imageMeta{Version: "v2"}, imageMeta{Revision: "main"}, "Container image updated: v2 -> main"},
+		{oldMeta, imageMeta{}, "Container image updated"},
+	}
+	for _, c := range cases {
+		if got := imageUpdateMessage(c.oldMeta, c.newMeta); got != c.want {
+			t.Errorf("imageUpdateMessage(%+v, %+v) = %q, want %q", c.oldMeta, c.newMeta, got, c.want)
+		}
+	}
+}
+
+func TestImageDigestPrefersRepository(t *testing.T) {
+	meta := imageMeta{RepoDigests: []string{
+		"docker.io/library/nginx@sha256:aaa",
+		"ghcr.io/example/nginx@sha256:bbb",
+	}}
+	if got := meta.digest("ghcr.io/example/nginx"); got != "sha256:bbb" {
+		t.Fatalf("expected the digest of the container's repository, got %q", got)
+	}
+	if got := meta.digest("registry.local/nginx"); got != "sha256:aaa" {
+		t.Fatalf("expected the first digest as fallback, got %q", got)
+	}
+}
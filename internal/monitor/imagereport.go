@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+// imageReportEvents is how many image_changed events the image report
+// looks back on.
+const imageReportEvents = 500
+
+// imageLister lists images; the Docker client in production.
+type imageLister interface {
+	ImageList(ctx context.Context, options client.ImageListOptions) (client.ImageListResult, error)
+}
+
+// ImageReport lists the dangling images and the images containers were
+// recreated away from, with the space they take or took.
+func (m *Monitor) ImageReport(ctx context.Context) (api.ImageReport, error) {
+	return m.imageReport(ctx, m.docker)
+}
+
+// PruneImages removes the dangling images, like `docker image prune`.
+func (m *Monitor) PruneImages(ctx context.Context) (api.ImagePruneResponse, error) {
+	result, err := m.docker.ImagePrune(ctx, client.ImagePruneOptions{Filters: make(client.Filters).Add("dangling", "true")})
+	if err != nil {
+		return api.ImagePruneResponse{}, err
+	}
+	resp := api.ImagePruneResponse{Deleted: []string{}, SpaceReclaimed: int64(result.Report.SpaceReclaimed)}
+	for _, d := range result.Report.ImagesDeleted {
+		if d.Deleted != "" {
+			resp.Deleted = append(resp.Deleted, d.Deleted)
+		}
+	}
+	if _, ok := m.ensureSelfContainer(ctx); ok {
+		m.emitInfo(ctx, selfContainerName, "", "", "images_pruned", fmt.Sprintf("Pruned %d dangling images, %s reclaimed", len(resp.Deleted), formatSize(resp.SpaceReclaimed)), "", "", "", "", "prune", nil)
+	}
+	return resp, nil
+}
+
+func (m *Monitor) imageReport(ctx context.Context, images imageLister) (api.ImageReport, error) {
+	all, err := images.ImageList(ctx, client.ImageListOptions{})
+	if err != nil {
+		return api.ImageReport{}, err
+	}
+	dangling, err := images.ImageList(ctx, client.ImageListOptions{Filters: make(client.Filters).Add("dangling", "true")})
+	if err != nil {
+		return api.ImageReport{}, err
+	}
+	report := api.ImageReport{
+		GeneratedAt: m.clock.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Dangling:    []api.DanglingImage{},
+		Replaced:    []api.ReplacedImage{},
+	}
+	for _, img := range dangling.Items {
+		report.Dangling = append(report.Dangling, api.DanglingImage{
+			ID:        img.ID,
+			SizeBytes: img.Size,
+			CreatedAt: time.Unix(img.Created, 0).UTC().Format("2006-01-02T15:04:05Z"),
+		})
+		report.DanglingBytes += img.Size
+	}
+
+	present := make(map[string]int64, len(all.Items))
+	for _, img := range all.Items {
+		present[img.ID] = img.Size
+	}
+	inUse := make(map[string]bool)
+	for _, c := range m.store.ListContainers() {
+		if c.Present {
+			inUse[c.ImageID] = true
+		}
+	}
+	events, err := m.store.ListAllEvents(ctx, store.Filter{Types: []string{"image_changed"}}, 0, imageReportEvents)
+	if err != nil {
+		return api.ImageReport{}, err
+	}
+	seen := make(map[string]bool)
+	for _, e := range events {
+		if e.OldImageID == "" || e.OldImageID == e.NewImageID || seen[e.OldImageID] || inUse[e.OldImageID] {
+			continue
+		}
+		seen[e.OldImageID] = true
+		replaced := api.ReplacedImage{
+			ID:         e.OldImageID,
+			Image:      e.OldImage,
+			Container:  e.Container,
+			ReplacedAt: e.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+		}
+		if size, ok := present[e.OldImageID]; ok {
+			replaced.SizeBytes = size
+			report.ReclaimableBytes += size
+		} else {
+			replaced.Removed = true
+			if details, err := store.DecodeDetails(e.DetailsJSON); err == nil {
+				if d, ok := details.(*store.ImageUpdateDetails); ok {
+					replaced.SizeBytes = d.OldSize
+				}
+			}
+			report.FreedBytes += replaced.SizeBytes
+		}
+		report.Replaced = append(report.Replaced, replaced)
+	}
+	return report, nil
+}
+
+// watchImageReport files an image_report event on _healthmon every
+// HM_IMAGE_REPORT_HOURS.
+func (m *Monitor) watchImageReport(ctx context.Context) {
+	if m.cfg.ImageReportHours <= 0 {
+		return
+	}
+	ticker := m.clock.NewTicker(time.Duration(m.cfg.ImageReportHours) * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.guard(ctx, "image report", func() { m.recordImageReport(ctx, m.docker) })
+		}
+	}
+}
+
+func (m *Monitor) recordImageReport(ctx context.Context, images imageLister) {
+	report, err := m.imageReport(ctx, images)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("image report failed: %v", err)
+		}
+		return
+	}
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	message := fmt.Sprintf("%d dangling images use %s; recreates freed %s, %s more can be reclaimed",
+		len(report.Dangling), formatSize(report.DanglingBytes), formatSize(report.FreedBytes), formatSize(report.ReclaimableBytes))
+	e := m.infoEvent(selfContainerName, "", "", "image_report", message, "", "", "", "", "report", nil)
+	e.DetailsJSON = store.EncodeDetails(store.ImageReportDetails{
+		DanglingImages:   len(report.Dangling),
+		DanglingBytes:    report.DanglingBytes,
+		FreedBytes:       report.FreedBytes,
+		ReclaimableBytes: report.ReclaimableBytes,
+	})
+	m.emitEvent(ctx, e)
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+)
+
+// fakeImages lists the images on disk and, for the dangling filter, the
+// untagged ones among them.
+type fakeImages struct {
+	images   []image.Summary
+	dangling []image.Summary
+}
+
+func (f fakeImages) ImageList(_ context.Context, options client.ImageListOptions) (client.ImageListResult, error) {
+	if options.Filters["dangling"]["true"] {
+		return client.ImageListResult{Items: f.dangling}, nil
+	}
+	return client.ImageListResult{Items: f.images}, nil
+}
+
+func TestImageReportCountsDanglingAndReplacedImages(t *testing.T) {
+	ctx := context.Background()
+	mon, st := newStatsMonitor(t, config.Config{},
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true, ImageID: "sha256:web-3"},
+		store.Container{Name: "db", ContainerID: "cid-db", Status: "running", Present: true, ImageID: "sha256:db-1"},
+	)
+	changed := func(name, oldID, newID string, oldSize int64) {
+		e := mon.infoEvent(name, "cid-"+name, "", "image_changed", "Image changed", name+":old", name+":new", oldID, newID, "recreate", nil)
+		e.DetailsJSON = store.EncodeDetails(store.ImageUpdateDetails{OldSize: oldSize})
+		mon.emitEvent(ctx, e)
+	}
+	changed("web", "sha256:web-1", "sha256:web-2", 100<<20)
+	changed("web", "sha256:web-2", "sha256:web-3", 120<<20)
+	// db went back to the image it runs now, which is not reclaimable.
+	changed("db", "sha256:db-1", "sha256:db-2", 50<<20)
+
+	images := fakeImages{
+		images: []image.Summary{
+			{ID: "sha256:web-2", Size: 120 << 20},
+			{ID: "sha256:web-3", Size: 130 << 20},
+			{ID: "sha256:db-1", Size: 50 << 20},
+			{ID: "sha256:orphan", Size: 10 << 20},
+		},
+		dangling: []image.Summary{{ID: "sha256:orphan", Size: 10 << 20, Created: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Unix()}},
+	}
+	report, err := mon.imageReport(ctx, images)
+	if err != nil {
+		t.Fatalf("image report: %v", err)
+	}
+	if len(report.Dangling) != 1 || report.DanglingBytes != 10<<20 || report.Dangling[0].CreatedAt != "2026-01-01T00:00:00Z" {
+		t.Fatalf("unexpected dangling images %+v", report.Dangling)
+	}
+	if report.FreedBytes != 100<<20 || report.ReclaimableBytes != 120<<20 || len(report.Replaced) != 2 {
+		t.Fatalf("unexpected replaced images %+v", report)
+	}
+	if r := report.Replaced[0]; r.ID != "sha256:web-2" || r.Removed || r.Container != "web" {
+		t.Fatalf("expected the latest replaced image first, got %+v", r)
+	}
+
+	mon.recordImageReport(ctx, images)
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"image_report"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Container != selfContainerName || events[0].Message != "1 dangling images use 10.0 MiB; recreates freed 100.0 MiB, 120.0 MiB more can be reclaimed" {
+		t.Fatalf("unexpected image report events %+v", events)
+	}
+}
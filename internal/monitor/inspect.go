@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/client"
+)
+
+var errNotStarted = errors.New("docker client not connected yet")
+
+// containerID resolves the Docker id of a stored container.
+func (m *Monitor) containerID(name string) (string, error) {
+	if m.docker == nil {
+		return "", errNotStarted
+	}
+	c, ok := m.store.GetContainer(name)
+	if !ok || c.ContainerID == "" {
+		return "", fmt.Errorf("no docker container for %s", name)
+	}
+	return c.ContainerID, nil
+}
+
+// InspectContainer returns Docker's inspect output for a stored container.
+func (m *Monitor) InspectContainer(ctx context.Context, name string) (json.RawMessage, error) {
+	id, err := m.containerID(name)
+	if err != nil {
+		return nil, err
+	}
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return inspect.Raw, nil
+}
+
+// ContainerLogs returns up to tail lines of stdout and stderr a stored
+// container wrote between since and until, with timestamps.
+func (m *Monitor) ContainerLogs(ctx context.Context, name string, since, until time.Time, tail int) ([]byte, error) {
+	id, err := m.containerID(name)
+	if err != nil {
+		return nil, err
+	}
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	logs, err := m.docker.ContainerLogs(ctx, id, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      strconv.FormatInt(since.Unix(), 10),
+		Until:      strconv.FormatInt(until.Unix(), 10),
+		Timestamps: true,
+		Tail:       strconv.Itoa(tail),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer logs.Close()
+
+	var out bytes.Buffer
+	// Without a TTY Docker multiplexes stdout and stderr into one stream.
+	if inspect.Container.Config != nil && inspect.Container.Config.Tty {
+		_, err = io.Copy(&out, logs)
+	} else {
+		_, err = stdcopy.StdCopy(&out, &out, logs)
+	}
+	return out.Bytes(), err
+}
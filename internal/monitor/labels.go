@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"strconv"
+	"strings"
+)
+
+const ignoreLabel = "healthmon.ignore"
+
+// keptLabels returns the labels to store for a container: all of them
+// without HM_LABEL_ALLOWLIST, otherwise those matching an entry, where an
+// entry ending in "*" matches a prefix. healthmon's own labels are always
+// kept.
+func (m *Monitor) keptLabels(labels map[string]string) map[string]string {
+	kept := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if m.labelAllowed(key) {
+			kept[key] = value
+		}
+	}
+	return kept
+}
+
+func (m *Monitor) labelAllowed(key string) bool {
+	if len(m.cfg.LabelAllowlist) == 0 || strings.HasPrefix(key, "healthmon.") {
+		return true
+	}
+	for _, entry := range m.cfg.LabelAllowlist {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredByLabel reports whether a container opted out of monitoring with
+// healthmon.ignore=true. Docker event attributes carry the container's
+// labels, so events can be checked without an inspect.
+func ignoredByLabel(labels map[string]string) bool {
+	ignore, err := strconv.ParseBool(strings.TrimSpace(labels[ignoreLabel]))
+	return err == nil && ignore
+}
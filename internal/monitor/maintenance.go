@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+// MaintenanceFunc runs database maintenance, calling progress before each
+// step.
+type MaintenanceFunc func(ctx context.Context, progress func(step string)) (db.MaintenanceResult, error)
+
+type maintenance struct {
+	run      MaintenanceFunc
+	requests chan struct{}
+	pending  atomic.Bool
+}
+
+// WithMaintenance enables database maintenance, on request and, with
+// HM_DB_MAINTENANCE_AT, every night.
+func (m *Monitor) WithMaintenance(fn MaintenanceFunc) {
+	m.maintenance = &maintenance{run: fn, requests: make(chan struct{}, 1)}
+}
+
+// RequestMaintenance queues a maintenance run. It returns false when
+// maintenance is not enabled or a run is already queued or in progress.
+func (m *Monitor) RequestMaintenance() bool {
+	if m.maintenance == nil || !m.maintenance.pending.CompareAndSwap(false, true) {
+		return false
+	}
+	m.maintenance.requests <- struct{}{}
+	return true
+}
+
+// watchMaintenance runs requested maintenance and, when HM_DB_MAINTENANCE_AT
+// is set, the nightly run.
+func (m *Monitor) watchMaintenance(ctx context.Context) {
+	if m.maintenance == nil {
+		return
+	}
+	at, scheduled := m.maintenanceTime()
+	var next time.Time
+	if scheduled {
+		next = nextMaintenance(m.clock.Now(), at)
+	}
+	ticker := m.clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.maintenance.requests:
+			m.guard(ctx, "db maintenance", func() { m.runMaintenance(ctx, "manual") })
+			m.maintenance.pending.Store(false)
+		case <-ticker.C():
+			now := m.clock.Now()
+			if !scheduled || now.Before(next) {
+				continue
+			}
+			next = nextMaintenance(now, at)
+			if !m.maintenance.pending.CompareAndSwap(false, true) {
+				continue
+			}
+			m.guard(ctx, "db maintenance", func() { m.runMaintenance(ctx, "scheduled") })
+			m.maintenance.pending.Store(false)
+		}
+	}
+}
+
+// maintenanceTime parses HM_DB_MAINTENANCE_AT as the offset into the day.
+func (m *Monitor) maintenanceTime() (time.Duration, bool) {
+	if m.cfg.DBMaintenanceAt == "" {
+		return 0, false
+	}
+	t, err := time.Parse("15:04", m.cfg.DBMaintenanceAt)
+	if err != nil {
+		log.Printf("invalid HM_DB_MAINTENANCE_AT %q, expected HH:MM; scheduled maintenance is disabled", m.cfg.DBMaintenanceAt)
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// nextMaintenance returns the first time after now that is at into a day in
+// the local time zone.
+func nextMaintenance(now time.Time, at time.Duration) time.Time {
+	local := now.In(time.Local)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	next := day.Add(at)
+	if !next.After(local) {
+		next = day.AddDate(0, 0, 1).Add(at)
+	}
+	return next.UTC()
+}
+
+// runMaintenance runs database maintenance and reports each step as a
+// db_maintenance event on _healthmon, so the UI can follow along.
+func (m *Monitor) runMaintenance(ctx context.Context, trigger string) {
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	log.Printf("db maintenance started (%s)", trigger)
+	result, err := m.maintenance.run(ctx, func(step string) {
+		m.emitInfo(ctx, selfContainerName, "", "", "db_maintenance", "Database maintenance: "+step, "", "", "", "", step, nil)
+	})
+	if err != nil {
+		log.Printf("db maintenance failed: %v", err)
+		m.emitAlert(ctx, selfContainerName, "", "", "db_maintenance_failed", fmt.Sprintf("Database maintenance failed: %v", err), "yellow", nil)
+		return
+	}
+
+	details := store.EncodeDetails(store.MaintenanceDetails{
+		Trigger:    trigger,
+		Steps:      result.Steps,
+		SizeBefore: result.SizeBefore,
+		SizeAfter:  result.SizeAfter,
+		DurationMS: result.Duration.Milliseconds(),
+	})
+	e := m.infoEvent(selfContainerName, "", "", "db_maintenance", fmt.Sprintf("Database maintenance finished in %s: %s -> %s", result.Duration.Round(time.Millisecond), formatSize(result.SizeBefore), formatSize(result.SizeAfter)), "", "", "", "", "finished", nil)
+	e.DetailsJSON = details
+	m.emitEvent(ctx, e)
+	log.Printf("db maintenance finished in %s", result.Duration)
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
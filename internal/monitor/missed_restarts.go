@@ -0,0 +1,89 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// missedRestarts returns how many times Docker restarted a container since
+// healthmon last inspected it, based on Docker's RestartCount. A new
+// container id means the count started over; a negative stored count means
+// it was never recorded.
+func missedRestarts(existing store.Container, hasExisting bool, info store.Container) int {
+	if !hasExisting || existing.ContainerID != info.ContainerID {
+		return info.DockerRestartCount
+	}
+	if existing.DockerRestartCount < 0 {
+		return 0
+	}
+	if missed := info.DockerRestartCount - existing.DockerRestartCount; missed > 0 {
+		return missed
+	}
+	return 0
+}
+
+// missedRestartLoop decides whether restarts that happened while healthmon
+// was not watching amount to a restart loop. Docker only keeps the count, so
+// the restarts are known to fall inside the restart window when healthmon
+// last saw the container within it, or when the container is still
+// restarting or both died and started again within the window.
+func (m *Monitor) missedRestartLoop(info store.Container, lastSeen time.Time, missed int, now time.Time) bool {
+	window, threshold := m.restarts.limits(restartTrackerKey(info.ContainerID, info.Name))
+	if missed < threshold {
+		return false
+	}
+	if strings.EqualFold(info.Status, "restarting") {
+		return true
+	}
+	recent := func(t time.Time) bool {
+		return !t.IsZero() && now.Sub(t) <= window
+	}
+	if recent(lastSeen) {
+		return true
+	}
+	return recent(info.StartedAt) && recent(info.FinishedAt)
+}
+
+// recordMissedRestarts stores a restart event summarizing restarts that
+// happened while healthmon was down and, when they formed a loop, the
+// restart_loop alert that would have been raised. The container must already
+// be stored with its loop state.
+func (m *Monitor) recordMissedRestarts(ctx context.Context, info store.Container, missed int, loop bool) {
+	ts := info.StartedAt
+	if ts.IsZero() {
+		ts = m.clock.Now()
+	}
+	details := store.EncodeDetails(store.RestartDetails{RestartCount: missed})
+	m.emitEvent(ctx, store.Event{
+		Container:   info.Name,
+		ContainerID: info.ContainerID,
+		Type:        "restart",
+		Severity:    "blue",
+		Message:     fmt.Sprintf("Restarted %d times while healthmon was not watching", missed),
+		Timestamp:   ts,
+		Reason:      "missed",
+		DetailsJSON: details,
+	})
+	if !loop {
+		return
+	}
+	if stored, ok := m.store.GetContainer(info.Name); ok {
+		info.ID = stored.ID
+	}
+	crash, logs := m.restartLoopDetails(ctx, info, missed)
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   info.Name,
+		ContainerID: info.ContainerID,
+		Type:        "restart_loop",
+		Severity:    "red",
+		Message:     fmt.Sprintf("Restart loop detected (%d restarts while healthmon was not watching)", missed),
+		Timestamp:   m.clock.Now(),
+		Reason:      "missed",
+		DetailsJSON: store.EncodeDetails(crash),
+		Logs:        logs,
+	})
+}
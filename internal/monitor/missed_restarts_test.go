@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+func TestSyncExistingDetectsRestartLoopMissedWhileDown(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	inspect := container.InspectResponse{
+		ID:           "cid-api",
+		Name:         "/api",
+		Created:      now.Add(-time.Hour).Format(time.RFC3339Nano),
+		RestartCount: 9,
+		State: &container.State{
+			Status:     "restarting",
+			StartedAt:  now.Add(-20 * time.Second).Format(time.RFC3339Nano),
+			FinishedAt: now.Add(-5 * time.Second).Format(time.RFC3339Nano),
+		},
+		HostConfig: &container.HostConfig{
+			RestartPolicy: container.RestartPolicy{Name: "always"},
+		},
+		Config: &container.Config{Image: "ghcr.io/example/api:latest"},
+		Image:  "sha256:image-api",
+	}
+	raw, err := json.Marshal(inspect)
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+
+	mock := newMockDockerServer(t, nil, []inspectRecord{{ID: "cid-api", Inspect: raw}})
+	mock.containers = []string{"cid-api"}
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:               "api",
+		ContainerID:        "cid-api",
+		Image:              "ghcr.io/example/api",
+		ImageTag:           "latest",
+		CreatedAt:          now.Add(-time.Hour),
+		RegisteredAt:       now.Add(-time.Hour),
+		StartedAt:          now.Add(-30 * time.Minute),
+		Status:             "running",
+		Present:            true,
+		DockerRestartCount: 1,
+		UpdatedAt:          now.Add(-30 * time.Minute),
+	}); err != nil {
+		t.Fatalf("upsert existing: %v", err)
+	}
+
+	mon := New(config.Config{RestartThreshold: 3, RestartWindowSeconds: 300}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	if err := mon.syncExisting(ctx); err != nil {
+		t.Fatalf("sync existing: %v", err)
+	}
+
+	got, ok := st.GetContainer("api")
+	if !ok {
+		t.Fatalf("container not found")
+	}
+	if !got.RestartLoop || got.RestartStreak != 8 {
+		t.Fatalf("expected restart loop with streak 8, got loop=%v streak=%d", got.RestartLoop, got.RestartStreak)
+	}
+	if got.DockerRestartCount != 9 {
+		t.Fatalf("expected stored restart count 9, got %d", got.DockerRestartCount)
+	}
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Containers: []string{"api"}, Types: []string{"restart"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Reason != "missed" {
+		t.Fatalf("expected one missed restart event, got %+v", events)
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{"api"}, Types: []string{"restart_loop"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected one restart_loop alert, got %d", len(alerts))
+	}
+}
@@ -15,25 +15,21 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"healthmon/internal/api"
 	"healthmon/internal/config"
 	"healthmon/internal/db"
+	"healthmon/internal/dockerdump"
 	"healthmon/internal/store"
 
 	"github.com/moby/moby/api/types/events"
 	"nhooyr.io/websocket"
 )
 
-type inspectRecord struct {
-	EventIndex int             `json:"event_index"`
-	TimeNano   int64           `json:"timeNano"`
-	ID         string          `json:"id"`
-	Action     string          `json:"action"`
-	Inspect    json.RawMessage `json:"inspect"`
-}
+type inspectRecord = dockerdump.Inspect
 
 type inspectQueue struct {
 	mu   sync.Mutex
@@ -71,6 +67,13 @@ type mockDockerServer struct {
 	t          *testing.T
 	events     []events.Message
 	inspects   *inspectQueue
+	containers []string
+	mu         sync.Mutex
+	restarted  []string
+	// unavailable makes listing containers fail, as when dockerd is not up.
+	unavailable atomic.Bool
+	// daemonID is the ID /info reports.
+	daemonID   atomic.Value
 	httpServer *http.Server
 	listener   net.Listener
 	doneOnce   sync.Once
@@ -138,14 +141,30 @@ func (m *mockDockerServer) handle(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"ApiVersion":"1.44","MinAPIVersion":"1.12","Version":"29.2.1"}`))
 		return
+	case path == "/info":
+		id, _ := m.daemonID.Load().(string)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"ID": id, "ServerVersion": "29.2.1"})
+		return
 	case path == "/containers/json":
+		if m.unavailable.Load() {
+			http.Error(w, "daemon not ready", http.StatusInternalServerError)
+			return
+		}
+		items := make([]map[string]string, 0, len(m.containers))
+		for _, id := range m.containers {
+			items = append(items, map[string]string{"Id": id})
+		}
 		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte("[]"))
+		_ = json.NewEncoder(w).Encode(items)
 		return
 	case path == "/events":
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		flusher, _ := w.(http.Flusher)
+		if flusher != nil {
+			flusher.Flush()
+		}
 		enc := json.NewEncoder(w)
 		for _, msg := range m.events {
 			select {
@@ -165,6 +184,12 @@ func (m *mockDockerServer) handle(w http.ResponseWriter, r *http.Request) {
 		}
 		m.doneOnce.Do(func() { close(m.doneCh) })
 		return
+	case strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/restart"):
+		m.mu.Lock()
+		m.restarted = append(m.restarted, strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/restart"))
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
 	case strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/json"):
 		id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")
 		raw, ok := m.inspects.Next(id)
@@ -457,7 +482,7 @@ func TestMonitorReplayLinksEvents(t *testing.T) {
 
 	mock.WaitEventsDone(t, 5*time.Second)
 
-	eventsList, err := st.ListAllEvents(ctx, 0, 5000)
+	eventsList, err := st.ListAllEvents(ctx, store.Filter{}, 0, 5000)
 	if err != nil {
 		t.Fatalf("list events: %v", err)
 	}
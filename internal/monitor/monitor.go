@@ -11,7 +11,10 @@ import (
 	"time"
 
 	"healthmon/internal/api"
+	"healthmon/internal/clock"
 	"healthmon/internal/config"
+	"healthmon/internal/crash"
+	"healthmon/internal/dockerdump"
 	"healthmon/internal/notify"
 	"healthmon/internal/store"
 
@@ -22,18 +25,51 @@ import (
 )
 
 type Monitor struct {
-	cfg        config.Config
-	store      *store.Store
-	server     *api.Server
-	telegram   *notify.Telegram
-	restarts   *restartTracker
-	docker     *client.Client
-	capDefault []string
+	cfg         config.Config
+	store       *store.Store
+	server      *api.Server
+	telegram    *notify.Telegram
+	mutes       *mutes
+	tgFormat    telegramFormat
+	notifiers   *notify.Dispatcher
+	annotations *notify.Dispatcher
+	restarts    *restartTracker
+	replicas    *replicaTracker
+	selfActions *selfActions
+	docker      *client.Client
+	clock       clock.Clock
+	crash       *crash.Reporter
+	capDefault  []string
+	nameLabels  []string
+	hostArch    string
+	daemonID    string
+	images      map[string]imageMeta
+	changelog   *changelogResolver
+	state       monitorState
+	maintenance *maintenance
+	diagnostics *diagnostics
+	checks      *execChecks
+	stuck       *stuckTracker
+	paused      *pausedTracker
+	expected    *expectedTracker
+	autoheal    *autohealTracker
+	storm       *stormTracker
+	latest      map[string]string
+	filter      containerFilter
+	catalog     alertCatalog
+	hooks       []alertHook
+	// configSalt salts the configuration fingerprints; see
+	// configFingerprint.
+	configSalt string
 }
 
 const composeServiceLabel = "com.docker.compose.service"
 
+// serviceNameLabels identify the logical service a container belongs to, so
+// containers with generated names (compose run, Nomad, CI) still share one
+// history. HM_SERVICE_LABELS adds labels checked before these.
 var serviceNameLabels = []string{
+	"healthmon.service",
 	"healthmon.name",
 	composeServiceLabel,
 	"io.podman.compose.service",
@@ -41,45 +77,207 @@ var serviceNameLabels = []string{
 }
 
 func New(cfg config.Config, store *store.Store, server *api.Server) *Monitor {
-	return &Monitor{
-		cfg:        cfg,
-		store:      store,
-		server:     server,
-		telegram:   notify.NewTelegram(cfg.TelegramEnabled, cfg.TelegramToken, cfg.TelegramChatID),
-		restarts:   newRestartTracker(cfg.RestartWindowSeconds, cfg.RestartThreshold),
-		capDefault: defaultCaps(),
+	m := &Monitor{
+		cfg:         cfg,
+		store:       store,
+		server:      server,
+		telegram:    notify.NewTelegram(cfg.TelegramEnabled, cfg.TelegramToken, cfg.TelegramChatID),
+		mutes:       newMutes(),
+		tgFormat:    parseTelegramFormat(cfg.TelegramFormat),
+		restarts:    newRestartTracker(cfg.RestartWindowSeconds, cfg.RestartThreshold),
+		replicas:    newReplicaTracker(),
+		selfActions: newSelfActions(),
+		diagnostics: newDiagnostics(),
+		checks:      newExecChecks(),
+		stuck:       newStuckTracker(cfg.StuckStates),
+		paused:      newPausedTracker(),
+		expected:    newExpectedTracker(),
+		autoheal:    newAutohealTracker(),
+		storm:       newStormTracker(),
+		latest:      make(map[string]string),
+		filter:      newContainerFilter(cfg.IgnoreContainers, cfg.OnlyContainers),
+		catalog:     newAlertCatalog(cfg.AlertSeverities, cfg.AlertRules),
+		hooks:       parseAlertHooks(cfg.AlertHooks),
+		clock:       clock.Real{},
+		crash:       crash.New(cfg.ErrorReportURL),
+		images:      make(map[string]imageMeta),
+		capDefault:  defaultCaps(),
+		nameLabels:  append(append([]string{}, cfg.ServiceLabels...), serviceNameLabels...),
+	}
+	m.changelog = newChangelogResolver(cfg.ChangelogLookup, func() time.Time { return m.clock.Now() })
+	m.crash.OnPanic(m.recordPanic)
+	if store != nil {
+		store.OnWriteError(func(what string, err error) { m.diagnoseWrite(context.Background(), what, err) })
+	}
+	m.registerNotifiers(store)
+	if server != nil && len(m.catalog.rules) > 0 {
+		server.OnUpdate(m.applyAlertRules)
+	}
+	return m
+}
+
+// registerNotifiers sets up the chat channels alerts are sent to and the
+// Grafana annotations every alert is marked with. Both record their
+// deliveries in the notifications table.
+func (m *Monitor) registerNotifiers(st *store.Store) {
+	var status notify.StatusStore
+	if st != nil {
+		status = st
+	}
+	m.notifiers = notify.NewDispatcher(status)
+	m.annotations = notify.NewDispatcher(status)
+	for _, d := range []*notify.Dispatcher{m.notifiers, m.annotations} {
+		d.WithRetryPeriod(time.Duration(m.cfg.NotifyRetryHours) * time.Hour)
+		d.OnFailure(m.notificationFailed)
+		d.OnGiveUp(m.notificationUndelivered)
+	}
+	m.notifiers.SkipIf(func(a notify.Alert) bool {
+		_, muted := m.mutes.muted(a.Container, m.clock.Now())
+		return muted
+	})
+	if m.telegram != nil {
+		m.notifiers.Register(telegramNotifier{m: m})
+	}
+	if apprise := notify.NewApprise(m.cfg.AppriseURL, m.cfg.AppriseTag); apprise != nil {
+		m.notifiers.Register(apprise)
+	}
+	for _, raw := range m.cfg.NotifyURLs {
+		notifiers, err := notify.ParseURL(raw)
+		if err != nil {
+			log.Printf("HM_NOTIFY_URLS: %v", err)
+			continue
+		}
+		for _, n := range notifiers {
+			m.notifiers.Register(n)
+		}
+	}
+	if value := strings.TrimSpace(m.cfg.QuietHours); value != "" {
+		if schedule, err := notify.ParseQuietSchedule(value, m.cfg.QuietHoursPassRed); err != nil {
+			log.Printf("invalid HM_QUIET_HOURS %q: %v; quiet hours are disabled", value, err)
+		} else if err := m.notifiers.WithQuietHours(schedule); err != nil {
+			log.Printf("HM_QUIET_HOURS: %v", err)
+		}
 	}
+	if grafana := notify.NewGrafana(m.cfg.GrafanaURL, m.cfg.GrafanaToken, m.cfg.GrafanaDashboardUID, m.cfg.GrafanaTags); grafana != nil {
+		m.annotations.Register(grafana)
+	}
+}
+
+// WithClock replaces the clock used for timestamps, restart windows and the
+// periodic heal and resync checks.
+func (m *Monitor) WithClock(c clock.Clock) {
+	m.clock = c
+	m.notifiers.WithClock(c)
+	m.annotations.WithClock(c)
 }
 
 func (m *Monitor) Start(ctx context.Context) error {
-	cli, err := client.NewClientWithOpts(client.WithHost(m.cfg.DockerHost), client.WithAPIVersionNegotiation())
+	opts := []client.Opt{client.WithHost(m.cfg.DockerHost), client.WithAPIVersionNegotiation()}
+	if m.cfg.RecordDir != "" {
+		rec, err := dockerdump.NewRecorder(m.cfg.RecordDir, m.clock.Now())
+		if err != nil {
+			return fmt.Errorf("record: %w", err)
+		}
+		defer rec.Close()
+		opts = append(opts, client.WithResponseHook(rec.Hook))
+	}
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return err
 	}
 	m.docker = cli
 
-	if err := m.syncExisting(ctx); err != nil {
+	m.restoreRestartHistory(ctx)
+	m.loadConfigSalt(ctx)
+	go m.watchMaintenance(ctx)
+	go m.watchTelegramCommands(ctx)
+	go m.watchUnits(ctx)
+	go m.watchRetention(ctx)
+	m.restoreNotifications(ctx)
+	go m.notifiers.Run(ctx)
+	go m.annotations.Run(ctx)
+
+	stream, closeStream, err := m.connectDocker(ctx, nil)
+	if err != nil {
 		return err
 	}
+	defer func() {
+		// A reconnect interrupted by shutdown leaves no stream to close.
+		if closeStream != nil {
+			closeStream()
+		}
+		m.state.setConnected(false)
+	}()
 
 	go m.watchHeals(ctx)
+	go m.watchExecChecks(ctx)
+	go m.watchStats(ctx)
+	go m.watchClocks(ctx)
+	go m.watchImageReport(ctx)
+	go m.watchAnomalies(ctx)
+
+	// Resyncs run on the event loop so they never race with event handlers.
+	var resync <-chan time.Time
+	if interval := m.resyncInterval(); interval > 0 {
+		ticker := m.clock.NewTicker(interval)
+		defer ticker.Stop()
+		resync = ticker.C()
+	}
+	scaleTicker := m.clock.NewTicker(scaleSettle)
+	defer scaleTicker.Stop()
 
-	stream := cli.Events(ctx, client.EventsListOptions{})
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-resync:
+			m.guard(ctx, "resync", func() {
+				if err := m.resync(ctx); err != nil {
+					log.Printf("resync failed: %v", err)
+				}
+			})
+		case <-scaleTicker.C():
+			m.guard(ctx, "scale", func() { m.flushScaleChanges(ctx) })
+		case result := <-m.checks.results:
+			m.guard(ctx, "exec check", func() { m.applyExecCheck(ctx, result) })
 		case err := <-stream.Err:
-			return err
+			closeStream()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if stream, closeStream, err = m.connectDocker(ctx, err); err != nil {
+				return err
+			}
 		case msg := <-stream.Messages:
-			if msg.Type != "container" {
-				continue
+			m.state.event(m.clock.Now())
+			switch msg.Type {
+			case "container":
+				m.guard(ctx, "event "+string(msg.Action), func() { m.handleEvent(ctx, msg) })
+			case "network":
+				m.guard(ctx, "network event "+string(msg.Action), func() { m.handleNetworkEvent(ctx, msg) })
 			}
-			m.handleEvent(ctx, msg)
 		}
 	}
 }
 
+// restoreRestartHistory rebuilds the restart tracker from the restart events
+// stored within the restart window, so restarting healthmon in the middle of a
+// loop does not reset the streak and delay the restart_loop alert.
+func (m *Monitor) restoreRestartHistory(ctx context.Context) {
+	now := m.clock.Now()
+	for _, c := range m.store.ListContainers() {
+		key := restartTrackerKey(c.ContainerID, c.Name)
+		m.restarts.configure(key, c.Labels)
+		window, _ := m.restarts.limits(key)
+		restarts, err := m.store.RestartTimestampsSince(ctx, c.ID, now.Add(-window))
+		if err != nil {
+			log.Printf("restore restart history for %s: %v", c.Name, err)
+			continue
+		}
+		m.restarts.restore(key, restarts, c.RestartLoop, now)
+	}
+}
+
 func (m *Monitor) syncExisting(ctx context.Context) error {
 	result, err := m.docker.ContainerList(ctx, client.ContainerListOptions{All: true})
 	if err != nil {
@@ -87,20 +285,33 @@ func (m *Monitor) syncExisting(ctx context.Context) error {
 	}
 
 	presentNames := make(map[string]struct{}, len(result.Items))
+	m.replicas.members = make(map[string]map[string]string)
 	for _, c := range result.Items {
 		inspect, err := m.docker.ContainerInspect(ctx, c.ID, client.ContainerInspectOptions{})
 		if err != nil {
 			continue
 		}
+		var labels map[string]string
+		if inspect.Container.Config != nil {
+			labels = inspect.Container.Config.Labels
+		}
+		if m.ignored(strings.TrimPrefix(inspect.Container.Name, "/"), labels) {
+			continue
+		}
 		info := m.inspectToContainer(inspect.Container)
 		if info.Name == "" {
 			continue
 		}
 		name := info.Name
 		presentNames[name] = struct{}{}
+		m.trackReplica(name, inspect.Container)
 		autoRestart := hasAutoRestartPolicy(inspect.Container)
-		now := time.Now().UTC()
-		if existing, ok := m.store.GetContainer(name); ok {
+		restartKey := restartTrackerKey(info.ContainerID, info.Name)
+		m.restarts.configure(restartKey, info.Labels)
+		restartWindow, _ := m.restarts.limits(restartKey)
+		now := m.clock.Now()
+		existing, hasExisting := m.store.GetContainer(name)
+		if hasExisting {
 			info.RegisteredAt = existing.RegisteredAt
 			if info.StartedAt.IsZero() {
 				info.StartedAt = existing.StartedAt
@@ -133,17 +344,31 @@ func (m *Monitor) syncExisting(ctx context.Context) error {
 				}
 				// If monitor was down and container has been running longer than the
 				// restart-loop window, treat loop as healed on startup sync.
-				if info.RestartLoop && strings.ToLower(info.Status) == "running" && !info.StartedAt.IsZero() && now.Sub(info.StartedAt) > m.restarts.window {
+				if info.RestartLoop && strings.ToLower(info.Status) == "running" && !info.StartedAt.IsZero() && now.Sub(info.StartedAt) > restartWindow {
 					info.RestartLoop = false
 					info.RestartStreak = 0
 					info.RestartLoopSince = time.Time{}
-					m.restarts.markHealed(restartTrackerKey(info.ContainerID, info.Name))
+					m.restarts.markHealed(restartKey)
 				}
 			} else {
 				info.RestartLoop = false
 				info.RestartStreak = 0
 				info.RestartLoopSince = time.Time{}
-				m.restarts.reset(restartTrackerKey(info.ContainerID, info.Name))
+				m.restarts.reset(restartKey)
+			}
+		}
+		missed := missedRestarts(existing, hasExisting, info)
+		missedLoop := false
+		if autoRestart && missed > 0 && !info.RestartLoop {
+			var lastSeen time.Time
+			if hasExisting {
+				lastSeen = existing.UpdatedAt
+			}
+			if missedLoop = m.missedRestartLoop(info, lastSeen, missed, now); missedLoop {
+				info.RestartLoop = true
+				info.RestartStreak = missed
+				info.RestartLoopSince = now
+				m.restarts.seed(restartKey, missed, minTime(info.StartedAt, now))
 			}
 		}
 		if strings.ToLower(info.HealthStatus) == "unhealthy" && info.UnhealthySince.IsZero() {
@@ -152,19 +377,32 @@ func (m *Monitor) syncExisting(ctx context.Context) error {
 		if info.RegisteredAt.IsZero() {
 			info.RegisteredAt = minTime(info.CreatedAt, now)
 		}
+		info.Platform = m.imagePlatform(ctx, info.ImageID)
 		if err := m.store.UpsertContainer(ctx, info); err != nil {
 			return err
 		}
+		if missed > 0 && (missedLoop || (hasExisting && existing.ContainerID == info.ContainerID)) {
+			m.recordMissedRestarts(ctx, info, missed, missedLoop)
+		}
+		m.alertEmulation(ctx, info, existing.Platform)
+	}
+	// Pseudo-containers are not in Docker's list but must stay present.
+	presentNames[selfContainerName] = struct{}{}
+	for _, c := range m.store.ListContainers() {
+		if c.Role == "heartbeat" || c.Role == unitRole {
+			presentNames[c.Name] = struct{}{}
+		}
 	}
 	if err := m.store.MarkAbsentExcept(ctx, presentNames); err != nil {
 		return err
 	}
+	m.state.synced(m.clock.Now())
 	return nil
 }
 
 func (m *Monitor) handleEvent(ctx context.Context, msg events.Message) {
 	name := strings.TrimPrefix(msg.Actor.Attributes["name"], "/")
-	if isHealthcheckExecEvent(msg) {
+	if isHealthcheckExecEvent(msg) || m.ignored(name, msg.Actor.Attributes) || m.isCheckExec(ctx, msg) {
 		return
 	}
 	if !isHealthcheckStatusEvent(msg) {
@@ -175,12 +413,16 @@ func (m *Monitor) handleEvent(ctx context.Context, msg events.Message) {
 	case msg.Action == "create":
 		m.handleCreate(ctx, name, msg.Actor.ID)
 	case msg.Action == "start":
+		m.checks.reset(msg.Actor.ID)
 		m.handleStart(ctx, name, msg.Actor.ID)
 	case msg.Action == "stop":
 		exitCode := parseExitCode(msg.Actor.Attributes["exitCode"])
 		m.handleStop(ctx, name, msg.Actor.ID, exitCode)
 	case msg.Action == "die":
 		exitCode := parseExitCode(msg.Actor.Attributes["exitCode"])
+		if m.handleTaskExit(ctx, name, msg.Actor.ID, exitCode) {
+			return
+		}
 		if exitCode == nil || *exitCode == 0 {
 			m.handleStop(ctx, name, msg.Actor.ID, exitCode)
 		} else {
@@ -190,6 +432,8 @@ func (m *Monitor) handleEvent(ctx context.Context, msg events.Message) {
 		m.handleRestartLike(ctx, name, msg.Actor.ID, "restart", nil, "")
 	case msg.Action == "oom":
 		m.handleRestartLike(ctx, name, msg.Actor.ID, "oom", nil, "")
+	case msg.Action == "pause" || msg.Action == "unpause":
+		m.handlePause(ctx, name, msg.Actor.ID, msg.Action == "pause")
 	case msg.Action == "kill":
 		m.handleSignal(ctx, name, msg.Actor.ID, strings.TrimSpace(msg.Actor.Attributes["signal"]))
 	case strings.HasPrefix(string(msg.Action), "health_status:"):
@@ -197,6 +441,10 @@ func (m *Monitor) handleEvent(ctx context.Context, msg events.Message) {
 	case msg.Action == "rename":
 		m.handleRename(ctx, msg, name)
 	case msg.Action == "destroy" || msg.Action == "remove" || msg.Action == "rm":
+		m.checks.reset(msg.Actor.ID)
+		if m.replicaRemoved(ctx, msg.Actor.ID) {
+			return
+		}
 		serviceName := ""
 		if container, ok, _ := m.store.GetContainerByContainerID(ctx, msg.Actor.ID); ok {
 			serviceName = container.Name
@@ -220,8 +468,13 @@ func (m *Monitor) handleCreate(ctx context.Context, parsedName, id string) {
 		return
 	}
 	name := newInfo.Name
+	if m.replicaCreated(name, parsedName, inspect.Container) {
+		// An extra replica of a scaled compose service: the settled count is
+		// recorded as one scaled_up event instead of a create per replica.
+		return
+	}
 
-	now := time.Now().UTC()
+	now := m.clock.Now()
 	existing, has := m.store.GetContainer(name)
 	if has {
 		newInfo.RegisteredAt = existing.RegisteredAt
@@ -253,16 +506,15 @@ func (m *Monitor) handleCreate(ctx context.Context, parsedName, id string) {
 		}
 		imageChanged := existing.ImageID != newInfo.ImageID || existing.ImageTag != newInfo.ImageTag
 		if imageChanged {
-			m.emitInfo(ctx, name, id, parsedName, "image_changed", fmt.Sprintf("Image changed %s -> %s", existing.Image, newInfo.Image), existing.Image, newInfo.Image, existing.ImageID, newInfo.ImageID, "recreate", nil)
-			m.emitAlert(ctx, name, id, parsedName, "image_changed", "Container image updated", "blue", nil)
+			m.emitImageChanged(ctx, existing, newInfo, id, parsedName)
 		} else {
 			m.emitInfo(ctx, name, id, parsedName, "recreated", "Container recreated", existing.Image, newInfo.Image, existing.ImageID, newInfo.ImageID, "recreate", nil)
 		}
+		m.emitConfigChanged(ctx, existing, newInfo, id, parsedName)
 		m.emitAlert(ctx, name, id, parsedName, "recreated", "Container recreated", "blue", nil)
 	}
 
-	_ = m.store.UpsertContainer(ctx, newInfo)
-	m.emitInfo(ctx, name, id, parsedName, "created", "Container created", "", "", "", "", "create", nil)
+	m.upsertWithEvent(ctx, newInfo, m.infoEvent(name, id, parsedName, "created", "Container created", "", "", "", "", "create", nil))
 }
 
 func (m *Monitor) handleStart(ctx context.Context, parsedName, id string) {
@@ -282,7 +534,9 @@ func (m *Monitor) handleStart(ctx context.Context, parsedName, id string) {
 		info.RestartLoopSince = time.Time{}
 		m.restarts.reset(restartTrackerKey(id, name))
 	}
+	previousPlatform := ""
 	if existing, ok := m.store.GetContainer(name); ok {
+		previousPlatform = existing.Platform
 		info.RegisteredAt = existing.RegisteredAt
 		if info.StartedAt.IsZero() {
 			info.StartedAt = existing.StartedAt
@@ -298,16 +552,17 @@ func (m *Monitor) handleStart(ctx context.Context, parsedName, id string) {
 		}
 	}
 	if strings.ToLower(info.HealthStatus) == "unhealthy" && info.UnhealthySince.IsZero() {
-		info.UnhealthySince = time.Now().UTC()
+		info.UnhealthySince = m.clock.Now()
 	}
 	if info.RegisteredAt.IsZero() {
-		info.RegisteredAt = minTime(info.CreatedAt, time.Now().UTC())
+		info.RegisteredAt = minTime(info.CreatedAt, m.clock.Now())
 	}
 	if info.StartedAt.IsZero() {
-		info.StartedAt = time.Now().UTC()
+		info.StartedAt = m.clock.Now()
 	}
-	_ = m.store.UpsertContainer(ctx, info)
-	m.emitInfo(ctx, name, id, parsedName, "started", "Container started", "", "", "", "", "start", nil)
+	info.Platform = m.imagePlatform(ctx, info.ImageID)
+	m.upsertWithEvent(ctx, info, m.infoEvent(name, id, parsedName, "started", "Container started", "", "", "", "", "start", nil))
+	m.alertEmulation(ctx, info, previousPlatform)
 }
 
 func (m *Monitor) handleRename(ctx context.Context, msg events.Message, newName string) {
@@ -352,11 +607,10 @@ func (m *Monitor) handleRename(ctx context.Context, msg events.Message, newName
 		}
 	}
 	if info.RegisteredAt.IsZero() {
-		info.RegisteredAt = minTime(info.CreatedAt, time.Now().UTC())
+		info.RegisteredAt = minTime(info.CreatedAt, m.clock.Now())
 	}
 	info.CurrentContainerName = newName
-	_ = m.store.UpsertContainer(ctx, info)
-	m.emitInfo(ctx, info.Name, msg.Actor.ID, newName, "renamed", fmt.Sprintf("Container renamed %s -> %s", oldName, newName), "", "", "", "", "rename", nil)
+	m.upsertWithEvent(ctx, info, m.infoEvent(info.Name, msg.Actor.ID, newName, "renamed", fmt.Sprintf("Container renamed %s -> %s", oldName, newName), "", "", "", "", "rename", nil))
 }
 
 func (m *Monitor) handleHealth(ctx context.Context, parsedName, id, status string) {
@@ -389,13 +643,13 @@ func (m *Monitor) handleHealth(ctx context.Context, parsedName, id, status strin
 		}
 		if strings.ToLower(info.HealthStatus) == "unhealthy" {
 			if info.UnhealthySince.IsZero() {
-				info.UnhealthySince = time.Now().UTC()
+				info.UnhealthySince = m.clock.Now()
 			}
 		} else {
 			info.UnhealthySince = time.Time{}
 		}
 		if info.RegisteredAt.IsZero() {
-			info.RegisteredAt = minTime(info.CreatedAt, time.Now().UTC())
+			info.RegisteredAt = minTime(info.CreatedAt, m.clock.Now())
 		}
 		_ = m.store.UpsertContainer(ctx, info)
 		status = strings.ToLower(info.HealthStatus)
@@ -405,22 +659,38 @@ func (m *Monitor) handleHealth(ctx context.Context, parsedName, id, status strin
 		if status == "unhealthy" {
 			existing.HealthFailingStreak = prevStreak + 1
 			if existing.UnhealthySince.IsZero() {
-				existing.UnhealthySince = time.Now().UTC()
+				existing.UnhealthySince = m.clock.Now()
 			}
 		} else if status == "healthy" {
 			existing.HealthFailingStreak = 0
 			existing.UnhealthySince = time.Time{}
 		}
-		existing.UpdatedAt = time.Now().UTC()
+		existing.UpdatedAt = m.clock.Now()
 		_ = m.store.UpsertContainer(ctx, existing)
 	}
 
 	switch status {
 	case "unhealthy":
 		if prevStatus != "unhealthy" {
-			m.emitAlert(ctx, name, id, parsedName, "unhealthy", "Container became unhealthy", "red", nil)
+			// With a grace period the alert is raised by checkUnhealthyGrace
+			// once the container has stayed unhealthy long enough.
+			current, ok := m.store.GetContainer(name)
+			if ok && current.UnhealthyGrace > 0 {
+				return
+			}
+			m.emitUnhealthy(ctx, current, name, id, parsedName, "Container became unhealthy")
 		}
 	case "healthy":
+		if prevStatus == "starting" {
+			if current, ok := m.store.GetContainer(name); ok {
+				m.recordReadiness(ctx, current, parsedName)
+			}
+		}
+		if prevStatus == "unhealthy" && existing.HealthPending(m.clock.Now()) {
+			message := fmt.Sprintf("Container recovered after %s unhealthy, within the %s grace period", m.clock.Now().Sub(existing.UnhealthySince).Round(time.Second), existing.UnhealthyGrace)
+			m.emitInfo(ctx, name, id, parsedName, "unhealthy_recovered", message, "", "", "", "", "health", nil)
+			return
+		}
 		if prevStatus == "unhealthy" || prevStreak > 0 {
 			message := "Container became healthy"
 			if prevStreak > 0 {
@@ -432,7 +702,7 @@ func (m *Monitor) handleHealth(ctx context.Context, parsedName, id, status strin
 }
 
 func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason string, exitCode *int, signal string) {
-	now := time.Now().UTC()
+	now := m.clock.Now()
 	name := ""
 	restartKey := restartTrackerKey(id, "")
 
@@ -462,23 +732,38 @@ func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason
 	if name == "" {
 		return
 	}
+	if inspectErr == nil {
+		m.restarts.configure(restartKey, info.Labels)
+	} else if existing, ok := m.store.GetContainer(name); ok {
+		m.restarts.configure(restartKey, existing.Labels)
+	}
 	if !hasAutoRestart {
 		m.restarts.reset(restartKey)
 	}
+	// Restarts healthmon caused itself never count toward a loop.
+	action, selfInflicted := m.selfActions.active(name, now)
 
 	streak := 0
 	enteredLoop := false
-	if hasAutoRestart {
+	if hasAutoRestart && !selfInflicted {
 		streak, enteredLoop = m.restarts.record(restartKey, now)
 	}
+	// During a restart storm the single containers' alerts give way to the
+	// one restart_storm alert.
+	storming := !selfInflicted && m.recordStormRestart(ctx, name, now)
 	inLoop := hasAutoRestart && (m.restarts.inLoop(restartKey) || wasInLoop)
 	message := fmt.Sprintf("Restart event: %s", reason)
 	if signal != "" {
 		message = fmt.Sprintf("Restart event: %s (signal %s)", reason, signal)
 	}
-	m.emitInfo(ctx, name, id, parsedName, "restart", message, "", "", "", "", reason, exitCode)
+	e := m.infoEvent(name, id, parsedName, "restart", message, "", "", "", "", reason, exitCode)
+	if selfInflicted {
+		tagSelfInflicted(&e, action)
+		reason = selfInflictedReason
+	}
+	m.emitEvent(ctx, e)
 
-	if c, ok := m.store.GetContainer(name); ok {
+	if c, ok := m.store.GetContainer(name); ok && !selfInflicted {
 		c.RestartLoop = inLoop
 		if c.RestartLoop {
 			if c.RestartStreak <= 0 || enteredLoop {
@@ -501,10 +786,28 @@ func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason
 	}
 
 	if reason == "oom" {
-		m.emitAlert(ctx, name, id, parsedName, "oom_killed", "Container killed by OOM", "red", exitCode)
+		var oom store.OOMDetails
+		if inspectErr == nil && inspect.Container.HostConfig != nil {
+			oom.MemoryLimit = inspect.Container.HostConfig.Memory
+		}
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:           name,
+			ContainerID:         id,
+			ParsedContainerName: parsedName,
+			Type:                "oom_killed",
+			Severity:            "red",
+			Message:             "Container killed by OOM",
+			Timestamp:           now,
+			ExitCode:            exitCode,
+			DetailsJSON:         store.EncodeDetails(oom),
+		})
 	}
-	if enteredLoop && !wasInLoop {
-		details, _ := json.Marshal(map[string]int{"restart_count": streak})
+	if enteredLoop && !wasInLoop && !storming {
+		c, _ := m.store.GetContainer(name)
+		if c.ContainerID == "" {
+			c.ContainerID = id
+		}
+		details, logs := m.restartLoopDetails(ctx, c, streak)
 		m.emitAlertRecord(ctx, store.Alert{
 			Container:           name,
 			ContainerID:         id,
@@ -513,7 +816,8 @@ func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason
 			Severity:            "red",
 			Message:             "Restart loop detected",
 			Timestamp:           now,
-			DetailsJSON:         string(details),
+			DetailsJSON:         store.EncodeDetails(details),
+			Logs:                logs,
 		})
 	}
 
@@ -548,7 +852,7 @@ func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason
 			info.StartedAt = now
 		}
 		_ = m.store.UpsertContainer(ctx, info)
-		if shouldAlertNoRestartPolicyFailure(reason, exitCode, inspect.Container) {
+		if !storming && shouldAlertNoRestartPolicyFailure(reason, exitCode, inspect.Container) {
 			m.emitAlert(ctx, name, id, parsedName, "failure_no_restart", "Container failed without restart policy", "red", exitCode)
 		}
 		return
@@ -565,12 +869,18 @@ func (m *Monitor) handleRestartLike(ctx context.Context, parsedName, id, reason
 }
 
 func (m *Monitor) handleStop(ctx context.Context, parsedName, id string, exitCode *int) {
-	now := time.Now().UTC()
+	now := m.clock.Now()
 	name := ""
 	if container, ok, _ := m.store.GetContainerByContainerID(ctx, id); ok {
 		name = container.Name
 	}
-	m.emitInfo(ctx, name, id, parsedName, "stopped", "Container stopped", "", "", "", "", "stop", exitCode)
+	reason := "stop"
+	e := m.infoEvent(name, id, parsedName, "stopped", "Container stopped", "", "", "", "", reason, exitCode)
+	if action, ok := m.selfActions.active(name, now); ok {
+		tagSelfInflicted(&e, action)
+		reason = selfInflictedReason
+	}
+	m.emitEvent(ctx, e)
 
 	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
 	if err == nil {
@@ -597,7 +907,7 @@ func (m *Monitor) handleStop(ctx context.Context, parsedName, id string, exitCod
 			info.StartedAt = now
 		}
 		_ = m.store.UpsertContainer(ctx, info)
-		if shouldAlertNoRestartPolicyFailure("stop", exitCode, inspect.Container) {
+		if shouldAlertNoRestartPolicyFailure(reason, exitCode, inspect.Container) {
 			m.emitAlert(ctx, name, id, parsedName, "failure_no_restart", "Container failed without restart policy", "red", exitCode)
 		}
 		return
@@ -627,25 +937,40 @@ func (m *Monitor) handleSignal(ctx context.Context, parsedName, id, signal strin
 		message = fmt.Sprintf("Signal sent: %s", signal)
 		reason = fmt.Sprintf("signal_%s", strings.ToLower(signal))
 	}
-	m.emitInfo(ctx, name, id, parsedName, "signal", message, "", "", "", "", reason, nil)
+	e := m.infoEvent(name, id, parsedName, "signal", message, "", "", "", "", reason, nil)
+	if action, ok := m.selfActions.active(name, m.clock.Now()); ok {
+		tagSelfInflicted(&e, action)
+	}
+	m.emitEvent(ctx, e)
 }
 
 func (m *Monitor) watchHeals(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := m.clock.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			m.checkHeals(ctx)
+		case <-ticker.C():
+			m.guard(ctx, "heal check", func() {
+				m.checkHeals(ctx)
+				m.checkUnhealthyGrace(ctx)
+				m.checkOverdue(ctx)
+				m.checkStuck(ctx)
+				m.checkPaused(ctx)
+				m.checkExpected(ctx)
+				m.checkAutoheal(ctx)
+				m.checkStorm(ctx)
+				m.checkLatestTags(ctx)
+				m.runScheduledRestarts(ctx)
+			})
 		}
 	}
 }
 
 func (m *Monitor) checkHeals(ctx context.Context) {
-	now := time.Now().UTC()
+	now := m.clock.Now()
 	for _, c := range m.store.ListContainers() {
 		if !c.RestartLoop {
 			continue
@@ -659,7 +984,9 @@ func (m *Monitor) checkHeals(ctx context.Context) {
 			log.Printf("restart heal check failed for %s: %v", c.Name, err)
 			continue
 		}
-		if ok && now.Sub(lastRestart) <= m.restarts.window {
+		key := restartTrackerKey(c.ContainerID, c.Name)
+		m.restarts.configure(key, c.Labels)
+		if window, _ := m.restarts.limits(key); ok && now.Sub(lastRestart) <= window {
 			continue
 		}
 
@@ -668,14 +995,12 @@ func (m *Monitor) checkHeals(ctx context.Context) {
 		c.RestartStreak = 0
 		c.RestartLoopSince = time.Time{}
 		c.UpdatedAt = now
-		_ = m.store.UpsertContainer(ctx, c)
-		m.restarts.markHealed(restartTrackerKey(c.ContainerID, c.Name))
+		m.restarts.markHealed(key)
 		message := "Restart loop healed"
 		if streak > 0 {
 			message = fmt.Sprintf("Restart loop healed after %d restarts", streak)
 		}
-		details, _ := json.Marshal(map[string]int{"restart_count": streak})
-		m.emitAlertRecord(ctx, store.Alert{
+		m.upsertWithAlert(ctx, c, store.Alert{
 			Container:           c.Name,
 			ContainerID:         c.ContainerID,
 			ParsedContainerName: "",
@@ -683,27 +1008,63 @@ func (m *Monitor) checkHeals(ctx context.Context) {
 			Severity:            "green",
 			Message:             message,
 			Timestamp:           now,
-			DetailsJSON:         string(details),
+			DetailsJSON:         store.EncodeDetails(store.RestartDetails{RestartCount: streak}),
 		})
 	}
 }
 
 func (m *Monitor) emitInfo(ctx context.Context, name, id, parsedName, eventType, message, oldImage, newImage, oldImageID, newImageID, reason string, exitCode *int) {
-	m.emitEvent(ctx, store.Event{
+	m.emitEvent(ctx, m.infoEvent(name, id, parsedName, eventType, message, oldImage, newImage, oldImageID, newImageID, reason, exitCode))
+}
+
+func (m *Monitor) infoEvent(name, id, parsedName, eventType, message, oldImage, newImage, oldImageID, newImageID, reason string, exitCode *int) store.Event {
+	return store.Event{
 		Container:           name,
 		ContainerID:         id,
 		ParsedContainerName: parsedName,
 		Type:                eventType,
 		Severity:            "blue",
 		Message:             message,
-		Timestamp:           time.Now().UTC(),
+		Timestamp:           m.clock.Now(),
 		OldImage:            oldImage,
 		NewImage:            newImage,
 		OldImageID:          oldImageID,
 		NewImageID:          newImageID,
 		Reason:              reason,
 		ExitCode:            exitCode,
-	})
+	}
+}
+
+// upsertWithEvent stores a container update and the event describing it in
+// one transaction, then broadcasts the event.
+func (m *Monitor) upsertWithEvent(ctx context.Context, info store.Container, e store.Event) {
+	log.Printf("event: type=%s severity=%s container=%s", e.Type, e.Severity, info.Name)
+	container, id, err := m.store.UpsertContainerWithEvent(ctx, info, e)
+	if err != nil {
+		log.Printf("event persist failed: %v", err)
+		m.diagnoseWrite(ctx, e.Type+" event", err)
+		return
+	}
+	e.ID = id
+	e.Container = container.Name
+	e.ContainerPK = container.ID
+	m.publishEvent(ctx, container, e)
+}
+
+// upsertWithAlert is upsertWithEvent for alerts.
+func (m *Monitor) upsertWithAlert(ctx context.Context, info store.Container, a store.Alert) {
+	a.Severity = m.catalog.severity(a.Type, a.Severity)
+	log.Printf("alert: type=%s severity=%s container=%s", a.Type, a.Severity, info.Name)
+	container, id, err := m.store.UpsertContainerWithAlert(ctx, info, a)
+	if err != nil {
+		log.Printf("alert persist failed: %v", err)
+		m.diagnoseWrite(ctx, a.Type+" alert", err)
+		return
+	}
+	a.ID = id
+	a.Container = container.Name
+	a.ContainerPK = container.ID
+	m.publishAlert(ctx, container, a)
 }
 
 func (m *Monitor) emitAlert(ctx context.Context, name, id, parsedName, alertType, message, severity string, exitCode *int) {
@@ -714,7 +1075,7 @@ func (m *Monitor) emitAlert(ctx context.Context, name, id, parsedName, alertType
 		Type:                alertType,
 		Severity:            severity,
 		Message:             message,
-		Timestamp:           time.Now().UTC(),
+		Timestamp:           m.clock.Now(),
 		ExitCode:            exitCode,
 	}
 	m.emitAlertRecord(ctx, alert)
@@ -739,9 +1100,14 @@ func (m *Monitor) emitEvent(ctx context.Context, e store.Event) {
 	id, err := m.store.AddEvent(ctx, e)
 	if err != nil {
 		log.Printf("event persist failed: %v", err)
+		m.diagnoseWrite(ctx, e.Type+" event", err)
 		return
 	}
 	e.ID = id
+	m.publishEvent(ctx, container, e)
+}
+
+func (m *Monitor) publishEvent(ctx context.Context, container store.Container, e store.Event) {
 	if latest, latestOK := m.store.GetContainer(container.Name); latestOK {
 		container = latest
 	}
@@ -786,7 +1152,8 @@ func (m *Monitor) emitEvent(ctx context.Context, e store.Event) {
 			RestartLoop:          container.RestartLoop,
 			RestartStreak:        container.RestartStreak,
 			RestartLoopSince:     container.RestartLoopSince.UTC().Format("2006-01-02T15:04:05Z"),
-			Healthcheck:          container.Healthcheck,
+			Healthcheck:          (*api.Healthcheck)(container.Healthcheck),
+			LastHealthProbe:      (*api.HealthProbe)(container.LastHealthProbe),
 		},
 		Event: &api.EventResponse{
 			ID:                  e.ID,
@@ -812,11 +1179,23 @@ func (m *Monitor) emitEvent(ctx context.Context, e store.Event) {
 	if hasContainerEventTotal {
 		update.ContainerEventTotal = &containerEventTotal
 	}
+	update.Container.AlertCount = m.containerAlertCount(ctx, container.Name)
+	if n, ok := m.store.ContainerNotes(container.Name); ok {
+		update.Container.Notes = &api.NotesResponse{
+			Container:  n.Container,
+			Notes:      n.Notes,
+			Owner:      n.Owner,
+			RunbookURL: n.RunbookURL,
+			UpdatedAt:  formatMaybeTime(n.UpdatedAt),
+			UpdatedBy:  n.UpdatedBy,
+		}
+	}
 
 	m.server.Broadcast(ctx, update)
 }
 
 func (m *Monitor) emitAlertRecord(ctx context.Context, a store.Alert) {
+	a.Severity = m.catalog.severity(a.Type, a.Severity)
 	var container store.Container
 	var ok bool
 	if a.ContainerID != "" {
@@ -835,12 +1214,21 @@ func (m *Monitor) emitAlertRecord(ctx context.Context, a store.Alert) {
 	id, err := m.store.AddAlert(ctx, a)
 	if err != nil {
 		log.Printf("alert persist failed: %v", err)
+		m.diagnoseWrite(ctx, a.Type+" alert", err)
 		return
 	}
 	a.ID = id
+	m.publishAlert(ctx, container, a)
+}
+
+func (m *Monitor) publishAlert(ctx context.Context, container store.Container, a store.Alert) {
 	if latest, latestOK := m.store.GetContainer(container.Name); latestOK {
 		container = latest
 	}
+	notice, handled := m.correlateAlert(ctx, container, &a)
+	if !handled {
+		m.groupIncident(ctx, container, &a)
+	}
 
 	alertTotal, err := m.store.CountAllAlerts(ctx)
 	hasAlertTotal := err == nil
@@ -877,7 +1265,8 @@ func (m *Monitor) emitAlertRecord(ctx context.Context, a store.Alert) {
 			RestartLoop:          container.RestartLoop,
 			RestartStreak:        container.RestartStreak,
 			RestartLoopSince:     container.RestartLoopSince.UTC().Format("2006-01-02T15:04:05Z"),
-			Healthcheck:          container.Healthcheck,
+			Healthcheck:          (*api.Healthcheck)(container.Healthcheck),
+			LastHealthProbe:      (*api.HealthProbe)(container.LastHealthProbe),
 		},
 		Alert: &api.AlertResponse{
 			ID:                  a.ID,
@@ -895,27 +1284,130 @@ func (m *Monitor) emitAlertRecord(ctx context.Context, a store.Alert) {
 			Reason:              a.Reason,
 			DetailsJSON:         a.DetailsJSON,
 			ExitCode:            a.ExitCode,
+			IncidentID:          a.IncidentID,
 		},
 	}
 	if hasAlertTotal {
 		update.AlertTotal = &alertTotal
 	}
+	update.Container.AlertCount = m.containerAlertCount(ctx, container.Name)
 
 	m.server.Broadcast(ctx, update)
-	m.sendTelegram(ctx, a)
+	m.startAlertHooks(ctx, container, a)
+	// Reporting a failed notification through the channel that just failed
+	// would only fail again.
+	if a.Type == "notification_failed" || a.Type == "notification_undelivered" {
+		return
+	}
+	// HM_ALERT_SEVERITIES can keep a type in the dashboard only.
+	if m.catalog.silent[a.Type] {
+		return
+	}
+	m.annotate(ctx, a)
+	if handled {
+		if notice != nil {
+			// The combined notice stands in for the alert, so follow-ups
+			// reply to it.
+			notice.ID, notice.ContainerPK = a.ID, a.ContainerPK
+			m.sendNotifications(ctx, *notice)
+		}
+		return
+	}
+	m.sendNotifications(ctx, a)
+}
+
+// sendNotifications sends an alert to the chat channels.
+func (m *Monitor) sendNotifications(ctx context.Context, a store.Alert) {
+	m.notifiers.Dispatch(ctx, m.notifyAlert(a))
+}
+
+func (m *Monitor) containerAlertCount(ctx context.Context, name string) int64 {
+	total, err := m.store.CountAlertsByContainer(ctx, name)
+	if err != nil {
+		log.Printf("container alert total count failed: %v", err)
+	}
+	return total
+}
+
+// annotate marks an alert on Grafana graphs. Image updates are covered too,
+// since every image_changed event comes with an image_changed alert.
+func (m *Monitor) annotate(ctx context.Context, a store.Alert) {
+	m.annotations.Dispatch(ctx, m.notifyAlert(a))
 }
 
-func (m *Monitor) sendTelegram(ctx context.Context, a store.Alert) {
-	if m.telegram == nil {
+// notifyAlert renders an alert for the notifiers, with the same details as
+// the Telegram message in plain text.
+func (m *Monitor) notifyAlert(a store.Alert) notify.Alert {
+	return notify.Alert{
+		Alert: a,
+		Title: m.telegramPrefix(a) + " " + a.Container,
+		Body:  a.Message + m.alertExtras(a, ""),
+		Group: m.containerGroup(a.Container),
+	}
+}
+
+// notificationFailed reports the first failed attempt of a delivery as a
+// notification_failed alert.
+func (m *Monitor) notificationFailed(ctx context.Context, notifier string, a notify.Alert, err error) {
+	m.diagnoseNotification(ctx, notifier, a.Alert, err)
+}
+
+// notificationUndelivered reports a delivery that was given up on as a
+// notification_undelivered alert, so the alert it carried is not lost
+// without a trace.
+func (m *Monitor) notificationUndelivered(ctx context.Context, n store.Notification, a notify.Alert) {
+	m.diagnose(ctx, "notification_undelivered", "red", fmt.Sprintf("%s notification for %s could not be delivered after %d attempts: %s", n.Notifier, a.Container, n.Attempts, a.Message), store.DiagnosticDetails{
+		Error:     n.Error,
+		Channel:   n.Notifier,
+		Container: a.Container,
+		Alert:     a.Type,
+	})
+}
+
+// restoreNotifications puts the deliveries that were waiting for a retry
+// when healthmon stopped back into the retry queues. Those of channels that
+// are no longer configured, or whose alert is gone, are marked failed.
+func (m *Monitor) restoreNotifications(ctx context.Context) {
+	pending, err := m.store.ListNotifications(ctx, store.NotificationFilter{Statuses: []string{store.NotificationRetrying}}, 0, 10000)
+	if err != nil {
+		log.Printf("notification restore failed: %v", err)
 		return
 	}
-	prefix := strings.ToUpper(a.Severity)
-	text := fmt.Sprintf("[%s] %s: %s", prefix, a.Container, a.Message)
-	if err := m.telegram.Send(ctx, text); err != nil {
-		log.Printf("telegram send failed: %v", err)
+	for _, n := range pending {
+		a, ok, err := m.store.GetAlert(ctx, n.AlertID)
+		if err != nil {
+			log.Printf("notification restore failed for alert %d: %v", n.AlertID, err)
+			continue
+		}
+		if ok {
+			if a.Logs, _, err = m.store.GetAlertLogs(ctx, a.ID); err != nil {
+				log.Printf("logs of alert %d: %v", a.ID, err)
+			}
+			rendered := m.notifyAlert(a)
+			if m.notifiers.Requeue(ctx, n, rendered) || m.annotations.Requeue(ctx, n, rendered) {
+				continue
+			}
+		}
+		n.Status = store.NotificationFailed
+		n.Error = "not retried after restart: channel no longer configured or alert deleted"
+		n.NextAttemptAt = time.Time{}
+		n.UpdatedAt = m.clock.Now()
+		if err := m.store.UpdateNotification(ctx, n); err != nil {
+			log.Printf("notification status persist failed: %v", err)
+		}
+	}
+	if len(pending) > 0 {
+		log.Printf("restored %d pending notifications", len(pending))
 	}
 }
 
+// containerGroup returns the healthmon.group of a stored container, so
+// notifications of different stacks can be told apart.
+func (m *Monitor) containerGroup(name string) string {
+	c, _ := m.store.GetContainer(name)
+	return c.Group()
+}
+
 func (m *Monitor) inspectToContainer(inspect container.InspectResponse) store.Container {
 	created := parseDockerTime(inspect.Created)
 	status := "unknown"
@@ -940,12 +1432,14 @@ func (m *Monitor) inspectToContainer(inspect container.InspectResponse) store.Co
 		user = "0:0"
 	}
 	role := resolveRole(labels)
-	serviceName := resolveServiceName(labels, name)
+	serviceName := resolveServiceName(labels, m.nameLabels, name)
 	healthStatus := ""
 	healthFailingStreak := 0
+	var healthProbe *store.HealthProbe
 	if inspect.State != nil && inspect.State.Health != nil {
 		healthStatus = string(inspect.State.Health.Status)
 		healthFailingStreak = inspect.State.Health.FailingStreak
+		healthProbe = lastHealthProbe(inspect.State.Health.Log)
 	}
 	var startedAt time.Time
 	var finishedAt time.Time
@@ -973,8 +1467,14 @@ func (m *Monitor) inspectToContainer(inspect container.InspectResponse) store.Co
 		} else {
 			healthStatus = ""
 			healthFailingStreak = 0
+			healthProbe = nil
 		}
 	}
+	if check, ok := parseExecCheck(labels); ok {
+		healthcheck = check.healthcheck()
+		healthStatus, healthFailingStreak = m.checks.health(inspect.ID)
+		healthProbe = m.checks.probe(inspect.ID)
+	}
 
 	return store.Container{
 		Name:                 serviceName,
@@ -982,6 +1482,7 @@ func (m *Monitor) inspectToContainer(inspect container.InspectResponse) store.Co
 		CurrentContainerName: name,
 		Image:                imageName,
 		ImageTag:             imageTag,
+		ImageDigest:          imageRefDigest(image),
 		ImageID:              inspect.Image,
 		CreatedAt:            created,
 		StartedAt:            startedAt,
@@ -998,13 +1499,21 @@ func (m *Monitor) inspectToContainer(inspect container.InspectResponse) store.Co
 		HealthStatus:         healthStatus,
 		HealthFailingStreak:  healthFailingStreak,
 		Healthcheck:          healthcheck,
-		UpdatedAt:            time.Now().UTC(),
+		LastHealthProbe:      healthProbe,
+		Config:               m.configFingerprint(inspect.Config),
+		DockerRestartCount:   inspect.RestartCount,
+		UnhealthyGrace:       m.unhealthyGrace(labels),
+		TaskMaxAge:           m.taskMaxAge(labels, role),
+		DependsOn:            dependsOn(labels),
+		Networks:             containerNetworks(inspect),
+		Labels:               m.keptLabels(labels),
+		UpdatedAt:            m.clock.Now(),
 		Present:              true,
 	}
 }
 
-func resolveServiceName(labels map[string]string, fallback string) string {
-	for _, key := range serviceNameLabels {
+func resolveServiceName(labels map[string]string, keys []string, fallback string) string {
+	for _, key := range keys {
 		if labels == nil {
 			break
 		}
@@ -1126,7 +1635,7 @@ func shouldAlertNoRestartPolicyFailure(reason string, exitCode *int, inspect con
 	if hasAutoRestartPolicy(inspect) {
 		return false
 	}
-	if reason == "oom" {
+	if reason == "oom" || reason == selfInflictedReason {
 		return false
 	}
 	if exitCode == nil {
@@ -1232,6 +1741,8 @@ type restartTracker struct {
 	mu        sync.Mutex
 	data      map[string][]time.Time
 	loop      map[string]bool
+	// overrides are the limits set with the healthmon.restart_* labels.
+	overrides map[string]restartLimits
 }
 
 func restartTrackerKey(containerID, name string) string {
@@ -1250,18 +1761,53 @@ func newRestartTracker(windowSeconds, threshold int) *restartTracker {
 		threshold: threshold,
 		data:      make(map[string][]time.Time),
 		loop:      make(map[string]bool),
+		overrides: make(map[string]restartLimits),
+	}
+}
+
+// configure applies a container's restart limit labels, or drops the
+// override when it has none.
+func (r *restartTracker) configure(name string, labels map[string]string) {
+	limits := parseRestartLimits(labels)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limits == (restartLimits{}) {
+		delete(r.overrides, name)
+		return
 	}
+	r.overrides[name] = limits
+}
+
+// limits returns the restart window and threshold of a container.
+func (r *restartTracker) limits(name string) (time.Duration, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.limitsLocked(name)
+}
+
+func (r *restartTracker) limitsLocked(name string) (time.Duration, int) {
+	window, threshold := r.window, r.threshold
+	if o, ok := r.overrides[name]; ok {
+		if o.window > 0 {
+			window = o.window
+		}
+		if o.threshold > 0 {
+			threshold = o.threshold
+		}
+	}
+	return window, threshold
 }
 
 func (r *restartTracker) record(name string, ts time.Time) (int, bool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	window, threshold := r.limitsLocked(name)
 	list := r.data[name]
 	list = append(list, ts)
-	list = r.prune(list, ts)
+	list = prune(list, ts, window)
 	r.data[name] = list
 	enteredLoop := false
-	if len(list) >= r.threshold {
+	if len(list) >= threshold {
 		if !r.loop[name] {
 			enteredLoop = true
 		}
@@ -1270,6 +1816,34 @@ func (r *restartTracker) record(name string, ts time.Time) (int, bool) {
 	return len(list), enteredLoop
 }
 
+// seed marks a container as looping with count restarts at ts, for loops
+// detected from Docker's restart count rather than observed events.
+func (r *restartTracker) seed(name string, count int, ts time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]time.Time, count)
+	for i := range list {
+		list[i] = ts
+	}
+	r.data[name] = list
+	r.loop[name] = true
+}
+
+// restore replaces the restart history of a container, for rebuilding the
+// tracker from stored restart events after healthmon restarts.
+func (r *restartTracker) restore(name string, restarts []time.Time, loop bool, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	window, _ := r.limitsLocked(name)
+	list := prune(restarts, now, window)
+	if len(list) > 0 {
+		r.data[name] = list
+	}
+	if loop {
+		r.loop[name] = true
+	}
+}
+
 func (r *restartTracker) inLoop(name string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -1279,13 +1853,13 @@ func (r *restartTracker) inLoop(name string) bool {
 func (r *restartTracker) canHeal(name string, now time.Time) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	list := r.data[name]
-	list = r.prune(list, now)
+	window, _ := r.limitsLocked(name)
+	list := prune(r.data[name], now, window)
 	r.data[name] = list
 	if len(list) == 0 {
 		return true
 	}
-	return now.Sub(list[len(list)-1]) > r.window
+	return now.Sub(list[len(list)-1]) > window
 }
 
 func (r *restartTracker) markHealed(name string) {
@@ -1303,7 +1877,7 @@ func (r *restartTracker) markHealthy(name string) {
 		return
 	}
 	last := list[len(list)-1]
-	if time.Since(last) > r.window {
+	if window, _ := r.limitsLocked(name); time.Since(last) > window {
 		delete(r.loop, name)
 	}
 }
@@ -1315,8 +1889,8 @@ func (r *restartTracker) reset(name string) {
 	delete(r.loop, name)
 }
 
-func (r *restartTracker) prune(list []time.Time, now time.Time) []time.Time {
-	cut := now.Add(-r.window)
+func prune(list []time.Time, now time.Time, window time.Duration) []time.Time {
+	cut := now.Add(-window)
 	idx := 0
 	for idx < len(list) && list[idx].Before(cut) {
 		idx++
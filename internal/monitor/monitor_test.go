@@ -1,8 +1,14 @@
 package monitor
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
 )
 
 func TestRestartTrackerDoesNotReenterWithoutHeal(t *testing.T) {
@@ -50,3 +56,73 @@ func TestRestartTrackerUsesStableServiceIdentityAcrossContainerRename(t *testing
 		t.Fatal("same service should not re-enter loop")
 	}
 }
+
+func TestRestartTrackerAppliesLabelOverridesPerContainer(t *testing.T) {
+	tracker := newRestartTracker(300, 3)
+	base := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	bridge := restartTrackerKey("cid-bridge", "iot-bridge")
+	db := restartTrackerKey("cid-db", "postgres")
+	tracker.configure(bridge, map[string]string{restartThresholdLabel: "6", restartWindowLabel: "1m"})
+	tracker.configure(db, map[string]string{restartThresholdLabel: "many"})
+
+	for i := range 5 {
+		if _, entered := tracker.record(bridge, base.Add(time.Duration(i)*5*time.Second)); entered {
+			t.Fatalf("restart %d of the bridge should not enter a loop", i+1)
+		}
+	}
+	// Restarts older than the bridge's one minute window no longer count.
+	if _, entered := tracker.record(bridge, base.Add(2*time.Minute)); entered {
+		t.Fatal("restart after the window should not enter a loop")
+	}
+	for i := range 3 {
+		_, entered := tracker.record(db, base.Add(time.Duration(i)*time.Minute))
+		if entered != (i == 2) {
+			t.Fatalf("restart %d of the database: entered=%v", i+1, entered)
+		}
+	}
+	if window, threshold := tracker.limits(db); window != 300*time.Second || threshold != 3 {
+		t.Fatalf("invalid label should keep the global limits, got %s/%d", window, threshold)
+	}
+
+	tracker.configure(bridge, nil)
+	if window, threshold := tracker.limits(bridge); window != 300*time.Second || threshold != 3 {
+		t.Fatalf("removed labels should restore the global limits, got %s/%d", window, threshold)
+	}
+}
+
+func TestRestoreRestartHistoryKeepsStreakAcrossHealthmonRestart(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, store.Container{Name: "worker", ContainerID: "cid-worker", Status: "running", Present: true, StartedAt: now}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	worker, _ := st.GetContainer("worker")
+	for _, ago := range []time.Duration{20 * time.Minute, 2 * time.Minute, time.Minute} {
+		if _, err := st.AddEvent(ctx, store.Event{ContainerPK: worker.ID, Container: "worker", ContainerID: "cid-worker", Type: "restart", Severity: "blue", Message: "restart", Timestamp: now.Add(-ago)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	mon := New(config.Config{RestartThreshold: 3, RestartWindowSeconds: 300}, st, nil)
+	mon.restoreRestartHistory(ctx)
+
+	key := restartTrackerKey("cid-worker", "worker")
+	streak, entered := mon.restarts.record(key, now)
+	if !entered || streak != 3 {
+		t.Fatalf("expected the third restart in the window to enter a loop, got streak=%d entered=%v", streak, entered)
+	}
+}
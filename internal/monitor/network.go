@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+)
+
+// handleNetworkEvent records a running container being disconnected from a
+// network it was attached to, or connected to a new one. Losing a network
+// raises network_lost for services; network_restored follows once the
+// container is reconnected to it.
+//
+// Docker also disconnects containers from their networks when they stop or
+// are removed; those disconnects are left to the stop and destroy events.
+func (m *Monitor) handleNetworkEvent(ctx context.Context, msg events.Message) {
+	if msg.Action != events.ActionConnect && msg.Action != events.ActionDisconnect {
+		return
+	}
+	network, id := msg.Actor.Attributes["name"], msg.Actor.Attributes["container"]
+	switch network {
+	case "", "bridge", "host", "none":
+		return
+	}
+	existing, ok, err := m.store.GetContainerByContainerID(ctx, id)
+	if err != nil || !ok {
+		return
+	}
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil || inspect.Container.State == nil || !inspect.Container.State.Running {
+		return
+	}
+	info := m.inspectToContainer(inspect.Container)
+	if info.Name == "" {
+		return
+	}
+	had := slices.Contains(existing.Networks, network)
+	disconnected := msg.Action == events.ActionDisconnect
+	if disconnected != had {
+		return
+	}
+	log.Printf("event: container=%s action=network_%s network=%s", info.Name, msg.Action, network)
+	info.RegisteredAt = existing.RegisteredAt
+	info.StartedAt = existing.StartedAt
+	info.UnhealthySince = existing.UnhealthySince
+	info.RestartLoop = existing.RestartLoop
+	info.RestartStreak = existing.RestartStreak
+	info.RestartLoopSince = existing.RestartLoopSince
+	if info.Networks == nil {
+		// Upserts keep the stored networks when left nil.
+		info.Networks = []string{}
+	}
+	parsedName := existing.CurrentContainerName
+	details := store.EncodeDetails(store.NetworkDetails{Network: network})
+
+	e := m.infoEvent(info.Name, id, parsedName, "network_connected", fmt.Sprintf("Connected to network %s", network), "", "", "", "", "network_connect", nil)
+	if disconnected {
+		e = m.infoEvent(info.Name, id, parsedName, "network_disconnected", fmt.Sprintf("Disconnected from network %s", network), "", "", "", "", "network_disconnect", nil)
+	}
+	e.DetailsJSON = details
+	if action, ok := m.selfActions.active(info.Name, m.clock.Now()); ok {
+		tagSelfInflicted(&e, action)
+	}
+	m.upsertWithEvent(ctx, info, e)
+
+	if info.Role != "service" {
+		return
+	}
+	a := store.Alert{
+		Container:           info.Name,
+		ContainerID:         id,
+		ParsedContainerName: parsedName,
+		Timestamp:           m.clock.Now(),
+		DetailsJSON:         details,
+	}
+	if disconnected {
+		a.Type, a.Severity, a.Message = "network_lost", "red", fmt.Sprintf("Disconnected from network %s", network)
+	} else if m.networkLost(ctx, info.Name, network) {
+		a.Type, a.Severity, a.Message = "network_restored", "green", fmt.Sprintf("Reconnected to network %s", network)
+	} else {
+		return
+	}
+	m.emitAlertRecord(ctx, a)
+}
+
+// networkLost reports whether the latest network alert of the container
+// for the network is network_lost.
+func (m *Monitor) networkLost(ctx context.Context, name, network string) bool {
+	alerts, err := m.store.ListAllAlerts(ctx, store.Filter{Containers: []string{name}, Types: []string{"network_lost", "network_restored"}}, 0, 50)
+	if err != nil {
+		log.Printf("network alert lookup failed for %s: %v", name, err)
+		return false
+	}
+	for _, a := range alerts {
+		details, err := store.DecodeDetails(a.DetailsJSON)
+		if err != nil {
+			continue
+		}
+		if d, ok := details.(*store.NetworkDetails); ok && d.Network == network {
+			return a.Type == "network_lost"
+		}
+	}
+	return false
+}
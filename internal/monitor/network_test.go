@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+)
+
+func TestNetworkDisconnectAlertsServices(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	inspectJSON := func(id, name string, running bool, networks ...string) inspectRecord {
+		t.Helper()
+		endpoints := map[string]*network.EndpointSettings{"bridge": {}}
+		for _, n := range networks {
+			endpoints[n] = &network.EndpointSettings{}
+		}
+		status := container.ContainerState("running")
+		if !running {
+			status = "exited"
+		}
+		raw, err := json.Marshal(container.InspectResponse{
+			ID:              id,
+			Name:            "/" + name,
+			Created:         now.Add(-time.Hour).Format(time.RFC3339Nano),
+			State:           &container.State{Status: status, Running: running, StartedAt: now.Add(-time.Hour).Format(time.RFC3339Nano)},
+			HostConfig:      &container.HostConfig{},
+			Config:          &container.Config{Image: "traefik:v3"},
+			Image:           "sha256:image-" + name,
+			NetworkSettings: &container.NetworkSettings{Networks: endpoints},
+		})
+		if err != nil {
+			t.Fatalf("marshal inspect: %v", err)
+		}
+		return inspectRecord{ID: id, Inspect: raw}
+	}
+	mock := newMockDockerServer(t, nil, []inspectRecord{
+		inspectJSON("cid-proxy", "proxy", true, "backend"),
+		inspectJSON("cid-proxy", "proxy", true, "backend", "proxy"),
+		inspectJSON("cid-web", "web", false),
+	})
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	mon, st := newStatsMonitor(t, config.Config{},
+		store.Container{Name: "proxy", ContainerID: "cid-proxy", Status: "running", Role: "service", Present: true, Networks: []string{"backend", "proxy"}},
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Role: "service", Present: true, Networks: []string{"proxy"}},
+	)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	event := func(action events.Action, containerID string) events.Message {
+		return events.Message{Type: events.NetworkEventType, Action: action, Actor: events.Actor{ID: "net-proxy", Attributes: map[string]string{"name": "proxy", "container": containerID, "type": "bridge"}}}
+	}
+	mon.handleNetworkEvent(ctx, event(events.ActionDisconnect, "cid-proxy"))
+	mon.handleNetworkEvent(ctx, event(events.ActionConnect, "cid-proxy"))
+	// web stopped: its disconnect is part of the stop, not an outage.
+	mon.handleNetworkEvent(ctx, event(events.ActionDisconnect, "cid-web"))
+
+	evts, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"network_connected", "network_disconnected"}, Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(evts) != 2 || evts[0].Type != "network_disconnected" || evts[1].Type != "network_connected" {
+		t.Fatalf("expected a disconnect and a connect event, got %+v", evts)
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Container+" "+a.Type+": "+a.Message)
+	}
+	want := "proxy network_lost: Disconnected from network proxy\nproxy network_restored: Reconnected to network proxy"
+	if strings.Join(got, "\n") != want {
+		t.Fatalf("unexpected alerts:\n%s", strings.Join(got, "\n"))
+	}
+	if c, _ := st.GetContainer("proxy"); strings.Join(c.Networks, ",") != "backend,proxy" {
+		t.Fatalf("expected the proxy network back, got %v", c.Networks)
+	}
+}
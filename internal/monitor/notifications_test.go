@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestUndeliveredNotificationsAreRetriedAcrossRestartsAndFiled(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	var up atomic.Bool
+	var delivered atomic.Int32
+	apprise := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		delivered.Add(1)
+	}))
+	defer apprise.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	cfg := config.Config{AppriseURL: apprise.URL, NotifyRetryHours: 1}
+	mon := New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(clk)
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "cid-web", CreatedAt: now, StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	mon.emitAlert(ctx, "web", "cid-web", "web", "oom_killed", "Container was OOM killed", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "restart_loop", "Restart loop detected", "red", nil)
+	mon.notifiers.Retry(ctx)
+
+	// A restart loses the queue; the next monitor picks the deliveries up
+	// from the database.
+	clk.Advance(time.Minute)
+	up.Store(true)
+	mon = New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(clk)
+	mon.restoreNotifications(ctx)
+	mon.notifiers.Retry(ctx)
+	if delivered.Load() != 2 {
+		t.Fatalf("expected both alerts delivered after the restart, got %d", delivered.Load())
+	}
+	sent, err := st.ListNotifications(ctx, store.NotificationFilter{Notifier: "Apprise", Statuses: []string{store.NotificationSent}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if len(sent) != 2 || sent[0].Attempts != 2 {
+		t.Fatalf("expected two deliveries sent on the second attempt, got %+v", sent)
+	}
+
+	// Down for longer than the retry period: the alert is given up on and
+	// filed as undelivered.
+	up.Store(false)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "unhealthy", "Container became unhealthy", "red", nil)
+	for i := 0; i < 8; i++ {
+		clk.Advance(10 * time.Minute)
+		mon.notifiers.Retry(ctx)
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}, Types: []string{"notification_undelivered"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0].Message, "Apprise notification for web could not be delivered after") || !strings.Contains(alerts[0].Message, "Container became unhealthy") {
+		t.Fatalf("expected one notification_undelivered alert, got %+v", alerts)
+	}
+	failed, err := st.ListNotifications(ctx, store.NotificationFilter{Statuses: []string{store.NotificationFailed}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if len(failed) != 1 || failed[0].AlertType != "unhealthy" || !strings.Contains(failed[0].Error, "502") {
+		t.Fatalf("expected the unhealthy delivery to have failed, got %+v", failed)
+	}
+}
@@ -0,0 +1,159 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+// pausedTracker remembers when containers were paused. Containers found
+// paused without a pause event, e.g. after a restart of healthmon, are timed
+// from when they were first seen.
+type pausedTracker struct {
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+func newPausedTracker() *pausedTracker {
+	return &pausedTracker{since: make(map[string]time.Time)}
+}
+
+func (p *pausedTracker) pause(name string, at time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.since[name] = at
+}
+
+// unpause forgets the container's pause and returns when it began, or the
+// zero time when that is not known.
+func (p *pausedTracker) unpause(name string) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	since := p.since[name]
+	delete(p.since, name)
+	return since
+}
+
+// pausedSince returns when the container was paused, starting the clock at
+// now if it was not known to be.
+func (p *pausedTracker) pausedSince(name string, now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	since, ok := p.since[name]
+	if !ok {
+		since = now
+		p.since[name] = since
+	}
+	return since
+}
+
+// handlePause records paused and unpaused events. Unpausing a container
+// that was alerted on as paused_too_long files pause_ended.
+func (m *Monitor) handlePause(ctx context.Context, parsedName, id string, paused bool) {
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return
+	}
+	info := m.inspectToContainer(inspect.Container)
+	if info.Name == "" {
+		return
+	}
+	name := info.Name
+	now := m.clock.Now()
+	if existing, ok := m.store.GetContainer(name); ok {
+		info.RegisteredAt = existing.RegisteredAt
+		info.StartedAt = existing.StartedAt
+		info.UnhealthySince = existing.UnhealthySince
+		info.RestartLoop = existing.RestartLoop
+		info.RestartStreak = existing.RestartStreak
+		info.RestartLoopSince = existing.RestartLoopSince
+	}
+	if info.RegisteredAt.IsZero() {
+		info.RegisteredAt = minTime(info.CreatedAt, now)
+	}
+	e := m.infoEvent(name, id, parsedName, "paused", "Container paused", "", "", "", "", "pause", nil)
+	if !paused {
+		e = m.infoEvent(name, id, parsedName, "unpaused", "Container unpaused", "", "", "", "", "unpause", nil)
+	}
+	if action, ok := m.selfActions.active(name, now); ok {
+		tagSelfInflicted(&e, action)
+	}
+	if paused {
+		m.paused.pause(name, now)
+		m.upsertWithEvent(ctx, info, e)
+		return
+	}
+	since := m.paused.unpause(name)
+	m.upsertWithEvent(ctx, info, e)
+	m.endPause(ctx, info, since, now)
+}
+
+// endPause follows up on a paused_too_long alert once the container runs
+// again.
+func (m *Monitor) endPause(ctx context.Context, c store.Container, since, now time.Time) {
+	if m.cfg.PausedAlertSeconds <= 0 {
+		return
+	}
+	stored, ok := m.store.GetContainer(c.Name)
+	if !ok || !m.pausedAlerted(ctx, stored) {
+		return
+	}
+	message := "Container unpaused"
+	if !since.IsZero() {
+		message = fmt.Sprintf("Container unpaused after %s", now.Sub(since).Round(time.Second))
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   c.Name,
+		ContainerID: c.ContainerID,
+		Type:        "pause_ended",
+		Severity:    "green",
+		Message:     message,
+		Timestamp:   now,
+	})
+}
+
+// pausedAlerted reports whether paused_too_long was raised for the
+// container's current pause.
+func (m *Monitor) pausedAlerted(ctx context.Context, c store.Container) bool {
+	latest, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, "paused_too_long", "pause_ended")
+	if err != nil {
+		log.Printf("paused alert lookup failed for %s: %v", c.Name, err)
+		return true
+	}
+	return found && latest.Type == "paused_too_long"
+}
+
+// checkPaused raises paused_too_long for services that stayed paused for
+// more than HM_PAUSED_ALERT_SECONDS: a paused container still counts as
+// present, but serves nothing.
+func (m *Monitor) checkPaused(ctx context.Context) {
+	limit := time.Duration(m.cfg.PausedAlertSeconds) * time.Second
+	if limit <= 0 {
+		return
+	}
+	now := m.clock.Now()
+	for _, c := range m.store.ListContainers() {
+		if !c.Present || c.Role != "service" || !strings.EqualFold(c.Status, "paused") {
+			continue
+		}
+		since := m.paused.pausedSince(c.Name, now)
+		if now.Sub(since) < limit || m.pausedAlerted(ctx, c) {
+			continue
+		}
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:   c.Name,
+			ContainerID: c.ContainerID,
+			Type:        "paused_too_long",
+			Severity:    "red",
+			Message:     fmt.Sprintf("Container paused for more than %s", limit),
+			Timestamp:   now,
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+)
+
+func TestPausedServiceAlertsAndRecovers(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	now := fake.Now()
+
+	var inspects []inspectRecord
+	for _, status := range []container.ContainerState{"paused", "running"} {
+		raw, err := json.Marshal(container.InspectResponse{
+			ID:         "cid-proxy",
+			Name:       "/proxy",
+			Created:    now.Add(-time.Hour).Format(time.RFC3339Nano),
+			State:      &container.State{Status: status, StartedAt: now.Add(-time.Hour).Format(time.RFC3339Nano)},
+			HostConfig: &container.HostConfig{},
+			Config:     &container.Config{Image: "traefik:v3"},
+			Image:      "sha256:image-proxy",
+		})
+		if err != nil {
+			t.Fatalf("marshal inspect: %v", err)
+		}
+		inspects = append(inspects, inspectRecord{ID: "cid-proxy", Inspect: raw})
+	}
+	mock := newMockDockerServer(t, nil, inspects)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	mon, st := newStatsMonitor(t, config.Config{PausedAlertSeconds: 300},
+		store.Container{Name: "proxy", ContainerID: "cid-proxy", Status: "running", Role: "service", Present: true})
+	mon.WithClock(fake)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	event := func(action events.Action) events.Message {
+		return events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: "cid-proxy", Attributes: map[string]string{"name": "proxy"}}}
+	}
+	mon.handleEvent(ctx, event("pause"))
+	fake.Advance(4 * time.Minute)
+	mon.checkPaused(ctx)
+	fake.Advance(2 * time.Minute)
+	mon.checkPaused(ctx)
+	mon.checkPaused(ctx)
+	fake.Advance(time.Minute)
+	mon.handleEvent(ctx, event("unpause"))
+
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"paused", "unpaused"}, Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != "paused" || events[1].Type != "unpaused" {
+		t.Fatalf("expected paused and unpaused events, got %+v", events)
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Type+" "+a.Severity+": "+a.Message)
+	}
+	want := "paused_too_long red: Container paused for more than 5m0s\npause_ended green: Container unpaused after 7m0s"
+	if strings.Join(got, "\n") != want {
+		t.Fatalf("unexpected alerts:\n%s", strings.Join(got, "\n"))
+	}
+	if c, _ := st.GetContainer("proxy"); c.Status != "running" {
+		t.Fatalf("expected proxy to be running again, got %q", c.Status)
+	}
+}
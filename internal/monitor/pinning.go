@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"healthmon/internal/store"
+
+	"github.com/distribution/reference"
+)
+
+// imageRefDigest returns the digest an image reference pins, such as
+// "sha256:..." for nginx@sha256:..., or "" for a tag. A pinned digest of a
+// multi-arch image is the digest of its index, the same on every platform.
+func imageRefDigest(image string) string {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	if digested, ok := ref.(reference.Digested); ok {
+		return digested.Digest().String()
+	}
+	return ""
+}
+
+// floatingLatest reports whether a container follows the latest tag rather
+// than a version or a digest.
+func floatingLatest(c store.Container) bool {
+	return c.ImageDigest == "" && c.ImageTag == "latest"
+}
+
+// checkLatestTags raises latest_tag for running containers in one of the
+// HM_LATEST_ALERT_GROUPS that follow the latest tag, once per container
+// instance, so a recreate onto latest again is reported again. m.latest
+// maps the containers it looked at to the container id it saw; it is used
+// by the heal checks only.
+func (m *Monitor) checkLatestTags(ctx context.Context) {
+	if len(m.cfg.LatestAlertGroups) == 0 {
+		return
+	}
+	for _, c := range m.store.ListContainers() {
+		if !runsAtAll(c) || !floatingLatest(c) || !slices.Contains(m.cfg.LatestAlertGroups, c.Group()) {
+			continue
+		}
+		if m.latest[c.Name] == c.ContainerID {
+			continue
+		}
+		m.latest[c.Name] = c.ContainerID
+		// Alerts raised before healthmon restarted count too.
+		last, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, "latest_tag")
+		if err != nil {
+			log.Printf("latest tag check failed for %s: %v", c.Name, err)
+			delete(m.latest, c.Name)
+			continue
+		}
+		if found && last.ContainerID == c.ContainerID {
+			continue
+		}
+		m.emitAlert(ctx, c.Name, c.ContainerID, "", "latest_tag",
+			fmt.Sprintf("Runs %s:latest in group %s; pin a version or digest", c.Image, c.Group()), "yellow", nil)
+	}
+}
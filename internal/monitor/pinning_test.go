@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestImageRefDigest(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	cases := map[string]string{
+		"nginx":                     "",
+		"nginx:1.27":                "",
+		"nginx@" + digest:           digest,
+		"ghcr.io/a/b:1.0@" + digest: digest,
+		"not a reference":           "",
+	}
+	for image, want := range cases {
+		if got := imageRefDigest(image); got != want {
+			t.Errorf("imageRefDigest(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestLatestTagAlertsOncePerContainer(t *testing.T) {
+	ctx := context.Background()
+	prod := map[string]string{store.GroupLabel: "prod"}
+	mon, st := newStatsMonitor(t, config.Config{LatestAlertGroups: []string{"prod"}},
+		store.Container{Name: "web", ContainerID: "cid-web", Image: "nginx", ImageTag: "latest", Status: "running", Present: true, Labels: prod},
+		store.Container{Name: "db", ContainerID: "cid-db", Image: "postgres", ImageTag: "16", Status: "running", Present: true, Labels: prod},
+		store.Container{Name: "cache", ContainerID: "cid-cache", Image: "redis", ImageDigest: "sha256:abc", Status: "running", Present: true, Labels: prod},
+		store.Container{Name: "dev", ContainerID: "cid-dev", Image: "nginx", ImageTag: "latest", Status: "running", Present: true},
+	)
+	mon.checkLatestTags(ctx)
+	mon.checkLatestTags(ctx)
+	// A fresh healthmon does not report the same container again.
+	mon.latest = make(map[string]string)
+	mon.checkLatestTags(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"latest_tag"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Container != "web" || alerts[0].Message != "Runs nginx:latest in group prod; pin a version or digest" {
+		t.Fatalf("expected one latest_tag alert on web, got %+v", alerts)
+	}
+
+	web, _ := st.GetContainer("web")
+	web.ContainerID = "cid-web-2"
+	if err := st.UpsertContainer(ctx, web); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	mon.checkLatestTags(ctx)
+	if alerts, _ = st.ListAllAlerts(ctx, store.Filter{Types: []string{"latest_tag"}}, 0, 10); len(alerts) != 2 {
+		t.Fatalf("expected the recreated container to be reported again, got %+v", alerts)
+	}
+}
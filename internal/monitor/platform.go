@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"healthmon/internal/store"
+)
+
+// nativeArchs lists the architectures a host runs without emulation besides
+// its own.
+var nativeArchs = map[string][]string{
+	"amd64": {"386"},
+	"arm64": {"arm"},
+}
+
+// imagePlatform returns the os/arch[/variant] of an image.
+func (m *Monitor) imagePlatform(ctx context.Context, imageID string) string {
+	meta, _ := m.inspectImage(ctx, imageID)
+	return meta.Platform
+}
+
+// emulated reports whether an image of the given platform needs emulation
+// (binfmt/qemu) on a host of hostArch.
+func emulated(hostArch, platform string) bool {
+	parts := strings.Split(platform, "/")
+	if hostArch == "" || len(parts) < 2 || parts[1] == "" || parts[1] == hostArch {
+		return false
+	}
+	for _, arch := range nativeArchs[hostArch] {
+		if parts[1] == arch {
+			return false
+		}
+	}
+	return true
+}
+
+// alertEmulation raises emulated_platform when a container starts running
+// under emulation, i.e. its platform changed to one the host cannot run
+// natively. The container must already be stored.
+func (m *Monitor) alertEmulation(ctx context.Context, info store.Container, previousPlatform string) {
+	if info.Platform == previousPlatform || !emulated(m.hostArch, info.Platform) {
+		return
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:           info.Name,
+		ContainerID:         info.ContainerID,
+		ParsedContainerName: info.CurrentContainerName,
+		Type:                "emulated_platform",
+		Severity:            "yellow",
+		Message:             fmt.Sprintf("Running a %s image under emulation on a %s host", info.Platform, m.hostArch),
+		Timestamp:           m.clock.Now(),
+		DetailsJSON:         store.EncodeDetails(store.EmulationDetails{Platform: info.Platform, HostArch: m.hostArch}),
+	})
+}
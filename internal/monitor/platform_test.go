@@ -0,0 +1,23 @@
+package monitor
+
+import "testing"
+
+func TestEmulated(t *testing.T) {
+	cases := []struct {
+		host, platform string
+		want           bool
+	}{
+		{"arm64", "linux/amd64", true},
+		{"amd64", "linux/arm64/v8", true},
+		{"arm64", "linux/arm64/v8", false},
+		{"arm64", "linux/arm/v7", false},
+		{"amd64", "linux/386", false},
+		{"", "linux/amd64", false},
+		{"arm64", "", false},
+	}
+	for _, c := range cases {
+		if got := emulated(c.host, c.platform); got != c.want {
+			t.Errorf("emulated(%q, %q) = %v, want %v", c.host, c.platform, got, c.want)
+		}
+	}
+}
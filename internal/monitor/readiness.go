@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	// readinessHistory is how many readiness samples the regression check
+	// looks back on.
+	readinessHistory = 50
+	// readinessMinRegression keeps a start a few seconds slower than usual
+	// from counting as a regression, however fast the image was before.
+	readinessMinRegression = 5 * time.Second
+)
+
+// recordReadiness stores how long the container took from its start to its
+// first healthy status. The first start after an image change is compared
+// with the median of the previous image's starts, and readiness_regressed
+// is raised when it took more than HM_READINESS_REGRESSION_PERCENT longer.
+func (m *Monitor) recordReadiness(ctx context.Context, c store.Container, parsedName string) {
+	now := m.clock.Now()
+	if c.StartedAt.IsZero() || now.Before(c.StartedAt) {
+		return
+	}
+	sample := store.ReadinessSample{
+		ContainerID: c.ContainerID,
+		ImageID:     c.ImageID,
+		StartedAt:   c.StartedAt,
+		ReadyAt:     now,
+		Duration:    now.Sub(c.StartedAt),
+	}
+	added, err := m.store.AddReadinessSample(ctx, c.Name, sample)
+	if err != nil {
+		log.Printf("readiness persist failed: %v", err)
+		m.diagnoseWrite(ctx, "readiness sample", err)
+		return
+	}
+	if !added || m.cfg.ReadinessRegressPct <= 0 {
+		return
+	}
+	samples, err := m.store.ListReadinessSamples(ctx, c.Name, readinessHistory)
+	if err != nil {
+		log.Printf("readiness history failed: %v", err)
+		return
+	}
+	previous := previousImageSamples(samples, c.ImageID)
+	if len(previous) == 0 {
+		return
+	}
+	baseline := store.ReadinessPercentile(previous, 50)
+	limit := baseline + baseline*time.Duration(m.cfg.ReadinessRegressPct)/100
+	if sample.Duration <= limit || sample.Duration-baseline < readinessMinRegression {
+		return
+	}
+	message := fmt.Sprintf("Became healthy after %s, up from a median of %s before the image changed",
+		sample.Duration.Round(time.Second), baseline.Round(time.Second))
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:           c.Name,
+		ContainerID:         c.ContainerID,
+		ParsedContainerName: parsedName,
+		Type:                "readiness_regressed",
+		Severity:            "yellow",
+		Message:             message,
+		Timestamp:           now,
+		DetailsJSON: store.EncodeDetails(store.ReadinessDetails{
+			DurationMS: sample.Duration.Milliseconds(),
+			BaselineMS: baseline.Milliseconds(),
+			OldImageID: previous[0].ImageID,
+			NewImageID: c.ImageID,
+		}),
+	})
+}
+
+// previousImageSamples returns the samples of the image the container ran
+// before imageID, given samples newest first. It returns nothing unless
+// the newest sample is the only one of imageID, so a regression is only
+// reported for the first start after an image change.
+func previousImageSamples(samples []store.ReadinessSample, imageID string) []store.ReadinessSample {
+	if len(samples) < 2 || samples[0].ImageID != imageID || samples[1].ImageID == imageID {
+		return nil
+	}
+	end := 1
+	for end < len(samples) && samples[end].ImageID == samples[1].ImageID {
+		end++
+	}
+	return samples[1:end]
+}
@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestReadinessRegressionAfterImageChange(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	web := store.Container{Name: "web", ContainerID: "cid-web", Status: "running", Present: true, ImageID: "sha256:old"}
+	mon, st := newStatsMonitor(t, config.Config{ReadinessRegressPct: 50}, web)
+	fake := clock.NewFake(start)
+	mon.WithClock(fake)
+
+	ready := func(imageID string, took time.Duration) {
+		fake.Advance(time.Hour)
+		c := web
+		c.ImageID = imageID
+		c.StartedAt = fake.Now()
+		fake.Advance(took)
+		mon.recordReadiness(ctx, c, "")
+		// A second healthy status of the same start is not another sample.
+		mon.recordReadiness(ctx, c, "")
+	}
+	for _, took := range []time.Duration{10 * time.Second, 12 * time.Second, 11 * time.Second} {
+		ready("sha256:old", took)
+	}
+	ready("sha256:new", 30*time.Second)
+	ready("sha256:new", 40*time.Second)
+	// Slower, but not by enough to matter.
+	ready("sha256:newer", 44*time.Second)
+
+	samples, err := st.ListReadinessSamples(ctx, "web", 10)
+	if err != nil {
+		t.Fatalf("list samples: %v", err)
+	}
+	if len(samples) != 6 || samples[0].Duration != 44*time.Second || samples[0].ImageID != "sha256:newer" {
+		t.Fatalf("unexpected samples %+v", samples)
+	}
+	if p50 := store.ReadinessPercentile(samples, 50); p50 != 12*time.Second {
+		t.Fatalf("expected a median of 12s, got %s", p50)
+	}
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Types: []string{"readiness_regressed"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Message != "Became healthy after 30s, up from a median of 11s before the image changed" {
+		t.Fatalf("expected one readiness_regressed alert, got %+v", alerts)
+	}
+	details, err := store.DecodeDetails(alerts[0].DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.ReadinessDetails); !ok || d.BaselineMS != 11000 || d.OldImageID != "sha256:old" || d.NewImageID != "sha256:new" {
+		t.Fatalf("unexpected details %+v", details)
+	}
+}
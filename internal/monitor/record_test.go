@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+)
+
+func TestRecordDirCapturesReplayableDumps(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().UTC()
+	raw, err := json.Marshal(container.InspectResponse{
+		ID:         "cid-web",
+		Name:       "/web",
+		Created:    now.Format(time.RFC3339Nano),
+		State:      &container.State{Status: "running", Running: true, StartedAt: now.Format(time.RFC3339Nano)},
+		HostConfig: &container.HostConfig{},
+		Config:     &container.Config{Image: "nginx:1.27"},
+		Image:      "sha256:image-web",
+	})
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+	messages := []events.Message{
+		{Type: "container", Action: "create", Actor: events.Actor{ID: "cid-web", Attributes: map[string]string{"name": "web"}}, TimeNano: now.UnixNano()},
+		{Type: "container", Action: "start", Actor: events.Actor{ID: "cid-web", Attributes: map[string]string{"name": "web"}}, TimeNano: now.Add(time.Second).UnixNano()},
+	}
+	mock := newMockDockerServer(t, messages, []inspectRecord{{ID: "cid-web", Inspect: raw}})
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	dir := t.TempDir()
+	mon := New(config.Config{DockerHost: host, RecordDir: dir}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	done := make(chan error, 1)
+	go func() { done <- mon.Start(ctx) }()
+	for range messages {
+		mock.AllowNext()
+	}
+	mock.WaitEventsDone(t, 5*time.Second)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if c, ok := st.GetContainer("web"); ok && c.Status == "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the start event")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	eventDumps, _ := filepath.Glob(filepath.Join(dir, "*.events.jsonl"))
+	inspectDumps, _ := filepath.Glob(filepath.Join(dir, "*.inspects.jsonl"))
+	if len(eventDumps) != 1 || len(inspectDumps) != 1 {
+		t.Fatalf("expected one pair of dumps, got %v and %v", eventDumps, inspectDumps)
+	}
+	recorded, err := loadEventsJSONL(eventDumps[0])
+	if err != nil {
+		t.Fatalf("load recorded events: %v", err)
+	}
+	if len(recorded) != 2 || recorded[0].Action != "create" || recorded[1].Actor.ID != "cid-web" {
+		t.Fatalf("unexpected recorded events %+v", recorded)
+	}
+	records, err := loadInspectJSONL(inspectDumps[0])
+	if err != nil {
+		t.Fatalf("load recorded inspects: %v", err)
+	}
+	if len(records) == 0 {
+		t.Fatalf("expected recorded inspects")
+	}
+	for _, r := range records {
+		if r.ID != "cid-web" || r.EventIndex < 0 || r.Action == "" {
+			t.Fatalf("unexpected inspect record %+v", r)
+		}
+		var inspect container.InspectResponse
+		if err := json.Unmarshal(r.Inspect, &inspect); err != nil || inspect.Name != "/web" {
+			t.Fatalf("unexpected recorded inspect %s (%v)", r.Inspect, err)
+		}
+	}
+}
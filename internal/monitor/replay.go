@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+
+	"healthmon/internal/clock"
+)
+
+// replayStep is how often the periodic checks run while a replay moves
+// through recorded time, like watchHeals does live.
+const replayStep = 30 * time.Second
+
+// Replay runs the monitor against a recorded event stream instead of the
+// live one, as fast as it can: it moves fake to just before the first
+// message, syncs the containers the Docker API at HM_DOCKER_HOST lists, then
+// handles the messages in order with fake set to each one's recorded time.
+// The heal, unhealthy grace, overdue and scale checks run for every 30
+// seconds of recorded time passed, and keep running for a restart window
+// after the last message so loops that healed are seen as healed. before is called ahead of every
+// message, e.g. to make the Docker API answer inspects as it did then.
+// Scheduled restarts and exec checks do not run.
+func (m *Monitor) Replay(ctx context.Context, fake *clock.Fake, messages []events.Message, before func(i int)) error {
+	cli, err := client.NewClientWithOpts(client.WithHost(m.cfg.DockerHost), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	m.docker = cli
+	m.clock = fake
+	for _, msg := range messages {
+		if at := messageTime(msg); !at.IsZero() {
+			fake.Set(at.Add(-time.Second))
+			break
+		}
+	}
+
+	m.loadEngine(ctx)
+	if err := m.syncExisting(ctx); err != nil {
+		return err
+	}
+	checked := fake.Now()
+	advance := func(to time.Time) {
+		for !checked.Add(replayStep).After(to) {
+			checked = checked.Add(replayStep)
+			fake.Set(checked)
+			m.replayChecks(ctx)
+		}
+		if to.After(fake.Now()) {
+			fake.Set(to)
+		}
+	}
+
+	for i, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if at := messageTime(msg); at.After(fake.Now()) {
+			advance(at)
+		}
+		if before != nil {
+			before(i)
+		}
+		if msg.Type != "container" {
+			continue
+		}
+		m.guard(ctx, "event "+string(msg.Action), func() { m.handleEvent(ctx, msg) })
+	}
+	window := time.Duration(m.cfg.RestartWindowSeconds) * time.Second
+	advance(fake.Now().Add(max(window, scaleSettle) + replayStep))
+	return nil
+}
+
+func (m *Monitor) replayChecks(ctx context.Context) {
+	m.guard(ctx, "replay checks", func() {
+		m.flushScaleChanges(ctx)
+		m.checkHeals(ctx)
+		m.checkUnhealthyGrace(ctx)
+		m.checkOverdue(ctx)
+	})
+}
+
+// messageTime is when Docker emitted msg, or zero when the dump lacks it.
+func messageTime(msg events.Message) time.Time {
+	switch {
+	case msg.TimeNano != 0:
+		return time.Unix(0, msg.TimeNano).UTC()
+	case msg.Time != 0:
+		return time.Unix(msg.Time, 0).UTC()
+	default:
+		return time.Time{}
+	}
+}
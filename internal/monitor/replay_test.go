@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/dockerdump"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+)
+
+func TestReplayRunsInRecordedTime(t *testing.T) {
+	ctx := context.Background()
+	recorded := time.Date(2025, 11, 3, 2, 0, 0, 0, time.UTC)
+	raw, err := json.Marshal(container.InspectResponse{
+		ID:         "cid-api",
+		Name:       "/api",
+		Created:    recorded.Add(-time.Hour).Format(time.RFC3339Nano),
+		State:      &container.State{Status: "running", Running: true, StartedAt: recorded.Format(time.RFC3339Nano)},
+		HostConfig: &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: "always"}},
+		Config:     &container.Config{Image: "api:1"},
+		Image:      "sha256:image-api",
+	})
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+	actor := events.Actor{ID: "cid-api", Attributes: map[string]string{"name": "api", "exitCode": "1"}}
+	var messages []events.Message
+	for i := 0; i < 3; i++ {
+		at := recorded.Add(time.Duration(i) * 10 * time.Second)
+		messages = append(messages,
+			events.Message{Type: "container", Action: "die", Actor: actor, TimeNano: at.UnixNano()},
+			events.Message{Type: "container", Action: "start", Actor: actor, TimeNano: at.Add(time.Second).UnixNano()},
+		)
+	}
+	docker := dockerdump.NewServer([]dockerdump.Inspect{{EventIndex: -1, ID: "cid-api", Inspect: raw}})
+	host, err := docker.Start()
+	if err != nil {
+		t.Fatalf("start dump server: %v", err)
+	}
+	defer docker.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	fake := clock.NewFake(time.Now())
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	cfg := config.Config{DockerHost: host, RestartWindowSeconds: 60, RestartThreshold: 3}
+	mon := New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	var seen []int
+	if err := mon.Replay(ctx, fake, messages, func(i int) {
+		seen = append(seen, i)
+		docker.SetEventIndex(i)
+	}); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(seen) != len(messages) {
+		t.Fatalf("expected before to run for every message, got %v", seen)
+	}
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 || alerts[0].Type != "restart_loop" || alerts[1].Type != "restart_healed" {
+		t.Fatalf("expected restart_loop then restart_healed, got %+v", alerts)
+	}
+	if got := alerts[0].Timestamp; !got.Equal(recorded.Add(20 * time.Second)) {
+		t.Fatalf("expected the loop at the recorded time of the third crash, got %s", got)
+	}
+	if alerts[1].Timestamp.Sub(alerts[0].Timestamp) < time.Minute {
+		t.Fatalf("expected the heal a restart window later, got %s", alerts[1].Timestamp)
+	}
+}
@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	restartThresholdLabel = "healthmon.restart_threshold"
+	restartWindowLabel    = "healthmon.restart_window"
+)
+
+// restartLimits overrides HM_RESTART_THRESHOLD and HM_RESTART_WINDOW_SECONDS
+// for one container. Zero fields keep the global setting.
+type restartLimits struct {
+	window    time.Duration
+	threshold int
+}
+
+// parseRestartLimits reads the healthmon.restart_threshold label, a number
+// of restarts, and the healthmon.restart_window label, a duration like "15m"
+// or a number of seconds. Invalid values are logged and ignored.
+func parseRestartLimits(labels map[string]string) restartLimits {
+	var limits restartLimits
+	if value := strings.TrimSpace(labels[restartThresholdLabel]); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			limits.threshold = n
+		} else {
+			log.Printf("ignoring invalid %s label %q", restartThresholdLabel, value)
+		}
+	}
+	if value := strings.TrimSpace(labels[restartWindowLabel]); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			limits.window = time.Duration(seconds) * time.Second
+		} else if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			limits.window = d
+		} else {
+			log.Printf("ignoring invalid %s label %q", restartWindowLabel, value)
+		}
+	}
+	return limits
+}
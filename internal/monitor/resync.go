@@ -0,0 +1,121 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// resync repeats the startup sync against the running engine and records a
+// resync_drift event for every container whose stored state disagreed with
+// Docker, which catches changes the event stream missed.
+func (m *Monitor) resync(ctx context.Context) error {
+	before := make(map[string]store.Container)
+	for _, c := range m.store.ListContainers() {
+		before[c.Name] = c
+	}
+
+	if err := m.syncExisting(ctx); err != nil {
+		return err
+	}
+
+	after := make(map[string]store.Container)
+	for _, c := range m.store.ListContainers() {
+		after[c.Name] = c
+	}
+
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	drifted := 0
+	for _, name := range names {
+		prev, hadPrev := before[name]
+		next, hasNext := after[name]
+		if !hasNext {
+			// No longer present: the cache still holds the record, marked absent.
+			next, hasNext = m.store.GetContainer(name)
+			if !hasNext {
+				continue
+			}
+		}
+		changes := containerDrift(prev, hadPrev, next)
+		if len(changes) == 0 {
+			continue
+		}
+		drifted++
+
+		fields := make([]string, 0, len(changes))
+		for field := range changes {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			change := changes[field]
+			parts = append(parts, fmt.Sprintf("%s %s -> %s", field, displayDriftValue(change.Before), displayDriftValue(change.After)))
+		}
+		m.emitEvent(ctx, store.Event{
+			Container:   name,
+			ContainerID: next.ContainerID,
+			Type:        "resync_drift",
+			Severity:    "blue",
+			Message:     "Resync found drift: " + strings.Join(parts, ", "),
+			Timestamp:   m.clock.Now(),
+			Reason:      "resync",
+			DetailsJSON: store.EncodeDetails(store.DriftDetails{Changes: changes}),
+		})
+	}
+	log.Printf("resync: containers=%d drifted=%d", len(after), drifted)
+	return nil
+}
+
+func containerDrift(prev store.Container, hadPrev bool, next store.Container) map[string]store.DriftChange {
+	changes := make(map[string]store.DriftChange)
+	add := func(field, before, after string) {
+		if before != after {
+			changes[field] = store.DriftChange{Before: before, After: after}
+		}
+	}
+	add("present", strconv.FormatBool(hadPrev && prev.Present), strconv.FormatBool(next.Present))
+	if !hadPrev || !next.Present {
+		return changes
+	}
+	add("container_id", prev.ContainerID, next.ContainerID)
+	add("status", prev.Status, next.Status)
+	add("image", prev.Image+":"+prev.ImageTag, next.Image+":"+next.ImageTag)
+	add("image_id", prev.ImageID, next.ImageID)
+	add("health", prev.HealthStatus, next.HealthStatus)
+	return changes
+}
+
+func displayDriftValue(val string) string {
+	if val == "" {
+		return "none"
+	}
+	// Shorten container and image IDs the way the docker CLI does.
+	if id := strings.TrimPrefix(val, "sha256:"); len(id) == 64 && !strings.ContainsAny(id, ":/") {
+		return id[:12]
+	}
+	return val
+}
+
+func (m *Monitor) resyncInterval() time.Duration {
+	if m.cfg.ResyncIntervalSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(m.cfg.ResyncIntervalSeconds) * time.Second
+}
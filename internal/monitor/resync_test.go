@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+func TestResyncRecordsDriftForContainersDockerNoLongerReports(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	mock := newMockDockerServer(t, nil, nil)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := store.New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:         "imapsync",
+		ContainerID:  "cid-gone",
+		Image:        "ghcr.io/example/imapsync",
+		ImageTag:     "latest",
+		ImageID:      "sha256:image",
+		CreatedAt:    now.Add(-time.Hour),
+		RegisteredAt: now.Add(-time.Hour),
+		StartedAt:    now.Add(-time.Hour),
+		Status:       "running",
+		Role:         "service",
+		Caps:         []string{},
+		User:         "0:0",
+		Present:      true,
+		UpdatedAt:    now,
+	}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	srv := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	mon := New(config.Config{}, st, srv)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	if err := mon.resync(ctx); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+
+	got, ok := st.GetContainer("imapsync")
+	if !ok || got.Present {
+		t.Fatalf("expected container to be marked absent, got ok=%v present=%v", ok, got.Present)
+	}
+	events, err := st.ListEvents(ctx, "imapsync", 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "resync_drift" {
+		t.Fatalf("expected one resync_drift event, got %#v", events)
+	}
+	if !strings.Contains(events[0].Message, "present true -> false") {
+		t.Fatalf("unexpected drift message %q", events[0].Message)
+	}
+
+	// A second resync without changes records nothing new.
+	if err := mon.resync(ctx); err != nil {
+		t.Fatalf("resync: %v", err)
+	}
+	events, err = st.ListEvents(ctx, "imapsync", 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected no new drift events, got %d", len(events))
+	}
+}
@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// retentionCheck is how often removed containers are checked against
+// HM_REMOVED_RETENTION_DAYS.
+const retentionCheck = time.Hour
+
+// watchRetention purges containers that were removed longer than
+// HM_REMOVED_RETENTION_DAYS ago, with their history. Without the setting
+// removed containers are kept.
+func (m *Monitor) watchRetention(ctx context.Context) {
+	if m.cfg.RemovedRetentionDays <= 0 {
+		return
+	}
+	ticker := m.clock.NewTicker(retentionCheck)
+	defer ticker.Stop()
+	for {
+		m.guard(ctx, "retention", func() { m.purgeRemoved(ctx) })
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (m *Monitor) purgeRemoved(ctx context.Context) {
+	before := m.clock.Now().Add(-time.Duration(m.cfg.RemovedRetentionDays) * 24 * time.Hour)
+	purged, err := m.store.PurgeRemovedContainers(ctx, before)
+	for _, name := range purged {
+		log.Printf("purged %s, removed more than %d days ago", name, m.cfg.RemovedRetentionDays)
+	}
+	if err != nil {
+		log.Printf("retention purge failed: %v", err)
+		m.diagnoseWrite(ctx, "retention purge", err)
+	}
+}
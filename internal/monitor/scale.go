@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+const composeNumberLabel = "com.docker.compose.container-number"
+
+// scaleSettle is how long a compose service has to stay at one replica count
+// before the change is recorded, so `--scale web=5` yields one event.
+const scaleSettle = 5 * time.Second
+
+type scaleChange struct {
+	from        int
+	to          int
+	containerID string
+	parsedName  string
+	last        time.Time
+}
+
+// replicaTracker follows the replicas of compose services. Replicas share the
+// service name that healthmon stores them under and differ by their compose
+// container number; a recreated replica keeps its number, a scaled one does
+// not. It is only used from the event loop.
+type replicaTracker struct {
+	// members maps service name -> container id -> replica number.
+	members map[string]map[string]string
+	pending map[string]*scaleChange
+}
+
+func newReplicaTracker() *replicaTracker {
+	return &replicaTracker{
+		members: make(map[string]map[string]string),
+		pending: make(map[string]*scaleChange),
+	}
+}
+
+// count returns the number of distinct replicas of a service.
+func (r *replicaTracker) count(service string) int {
+	numbers := make(map[string]struct{})
+	for _, number := range r.members[service] {
+		numbers[number] = struct{}{}
+	}
+	return len(numbers)
+}
+
+func (r *replicaTracker) has(service, number string) bool {
+	for _, n := range r.members[service] {
+		if n == number {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *replicaTracker) add(service, id, number string) {
+	if r.members[service] == nil {
+		r.members[service] = make(map[string]string)
+	}
+	r.members[service][id] = number
+}
+
+// serviceOf returns the service a tracked container belongs to.
+func (r *replicaTracker) serviceOf(id string) (string, bool) {
+	for service, members := range r.members {
+		if _, ok := members[id]; ok {
+			return service, true
+		}
+	}
+	return "", false
+}
+
+func (r *replicaTracker) remove(service, id string) {
+	delete(r.members[service], id)
+	if len(r.members[service]) == 0 {
+		delete(r.members, service)
+	}
+}
+
+// other returns a remaining container id of a service.
+func (r *replicaTracker) other(service string) (string, bool) {
+	ids := make([]string, 0, len(r.members[service]))
+	for id := range r.members[service] {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return "", false
+	}
+	sort.Strings(ids)
+	return ids[0], true
+}
+
+func (r *replicaTracker) note(service string, from, to int, containerID, parsedName string, now time.Time) {
+	change, ok := r.pending[service]
+	if !ok {
+		change = &scaleChange{from: from}
+		r.pending[service] = change
+	}
+	change.to = to
+	change.containerID = containerID
+	change.parsedName = parsedName
+	change.last = now
+}
+
+func composeReplicaNumber(inspect container.InspectResponse) string {
+	if inspect.Config == nil || inspect.Config.Labels[composeServiceLabel] == "" {
+		return ""
+	}
+	return inspect.Config.Labels[composeNumberLabel]
+}
+
+// trackReplica records a compose container seen during sync.
+func (m *Monitor) trackReplica(service string, inspect container.InspectResponse) {
+	if number := composeReplicaNumber(inspect); number != "" {
+		m.replicas.add(service, inspect.ID, number)
+	}
+}
+
+// replicaCreated records a newly created compose container. It reports true
+// when the container is an additional replica rather than a replacement for
+// an existing one.
+func (m *Monitor) replicaCreated(service, parsedName string, inspect container.InspectResponse) bool {
+	number := composeReplicaNumber(inspect)
+	if number == "" {
+		return false
+	}
+	before := m.replicas.count(service)
+	scaled := before > 0 && !m.replicas.has(service, number)
+	m.replicas.add(service, inspect.ID, number)
+	if scaled {
+		m.replicas.note(service, before, m.replicas.count(service), inspect.ID, parsedName, m.clock.Now())
+	}
+	return scaled
+}
+
+// replicaRemoved forgets a destroyed compose container. It reports true when
+// other replicas of the service are still around, in which case the service
+// must stay present.
+func (m *Monitor) replicaRemoved(ctx context.Context, id string) bool {
+	service, ok := m.replicas.serviceOf(id)
+	if !ok {
+		return false
+	}
+	before := m.replicas.count(service)
+	m.replicas.remove(service, id)
+	after := m.replicas.count(service)
+	if after == 0 {
+		return false
+	}
+
+	existing, has := m.store.GetContainer(service)
+	if has && existing.ContainerID == id {
+		// The stored record followed the removed replica; point it at one
+		// that is still around.
+		if otherID, ok := m.replicas.other(service); ok {
+			if inspect, err := m.docker.ContainerInspect(ctx, otherID, client.ContainerInspectOptions{}); err == nil {
+				existing.ContainerID = otherID
+				existing.CurrentContainerName = m.inspectToContainer(inspect.Container).CurrentContainerName
+				existing.UpdatedAt = m.clock.Now()
+				_ = m.store.UpsertContainer(ctx, existing)
+			}
+		}
+	}
+
+	// The replaced container of a recreated replica shares its number with
+	// the replacement, so the count only drops on a real scale down.
+	if after < before {
+		m.replicas.note(service, before, after, existing.ContainerID, "", m.clock.Now())
+	}
+	return true
+}
+
+// flushScaleChanges records scale changes that have settled.
+func (m *Monitor) flushScaleChanges(ctx context.Context) {
+	now := m.clock.Now()
+	services := make([]string, 0, len(m.replicas.pending))
+	for service, change := range m.replicas.pending {
+		if now.Sub(change.last) >= scaleSettle {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+
+	for _, service := range services {
+		change := m.replicas.pending[service]
+		delete(m.replicas.pending, service)
+		if change.from == change.to {
+			continue
+		}
+		eventType, verb := "scaled_up", "Scaled up"
+		if change.to < change.from {
+			eventType, verb = "scaled_down", "Scaled down"
+		}
+		log.Printf("scale: service=%s replicas=%d->%d", service, change.from, change.to)
+		m.emitEvent(ctx, store.Event{
+			Container:           service,
+			ContainerID:         change.containerID,
+			ParsedContainerName: change.parsedName,
+			Type:                eventType,
+			Severity:            "blue",
+			Message:             fmt.Sprintf("%s from %d to %d replicas", verb, change.from, change.to),
+			Timestamp:           now,
+			Reason:              "scale",
+			DetailsJSON:         store.EncodeDetails(store.ScaleDetails{OldReplicas: change.from, NewReplicas: change.to}),
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+func composeInspect(id, number string) container.InspectResponse {
+	return container.InspectResponse{
+		ID: id,
+		Config: &container.Config{Labels: map[string]string{
+			composeServiceLabel: "web",
+			composeNumberLabel:  number,
+		}},
+	}
+}
+
+func TestScaleChangesAreCoalescedIntoOneEvent(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "cid-1", Status: "running", StartedAt: fake.Now()}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+	mon.trackReplica("web", composeInspect("cid-1", "1"))
+
+	// A recreated replica keeps its number and is not a scale change.
+	if mon.replicaCreated("web", "app-web-1", composeInspect("cid-1b", "1")) {
+		t.Fatalf("recreated replica reported as scaled")
+	}
+	if !mon.replicaRemoved(ctx, "cid-1b") {
+		t.Fatalf("expected web to keep a replica after the recreate")
+	}
+
+	// compose up --scale web=3
+	for _, replica := range []struct{ id, number string }{{"cid-2", "2"}, {"cid-3", "3"}} {
+		fake.Advance(time.Second)
+		if !mon.replicaCreated("web", "app-web-"+replica.number, composeInspect(replica.id, replica.number)) {
+			t.Fatalf("replica %s not reported as scaled", replica.number)
+		}
+		mon.flushScaleChanges(ctx)
+	}
+	fake.Advance(scaleSettle)
+	mon.flushScaleChanges(ctx)
+
+	// compose up --scale web=2
+	if !mon.replicaRemoved(ctx, "cid-3") {
+		t.Fatalf("expected web to stay present after scaling down")
+	}
+	fake.Advance(scaleSettle)
+	mon.flushScaleChanges(ctx)
+
+	events, err := st.ListEvents(ctx, "web", 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 scale events, got %+v", events)
+	}
+	if events[1].Type != "scaled_up" || events[1].Message != "Scaled up from 1 to 3 replicas" || events[1].DetailsJSON != `{"kind":"scale","old_replicas":1,"new_replicas":3}` {
+		t.Fatalf("unexpected scale up event: %+v", events[1])
+	}
+	if events[0].Type != "scaled_down" || events[0].Message != "Scaled down from 3 to 2 replicas" {
+		t.Fatalf("unexpected scale down event: %+v", events[0])
+	}
+	if got, _ := st.GetContainer("web"); !got.Present {
+		t.Fatalf("expected web to stay present")
+	}
+}
@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// runScheduledRestarts restarts the containers whose schedules are due. A
+// schedule whose container is gone or stopped is skipped for this run but
+// kept, so a recurring restart resumes once the container is back.
+func (m *Monitor) runScheduledRestarts(ctx context.Context) {
+	now := m.clock.Now()
+	due, err := m.store.DueRestartSchedules(ctx, now)
+	if err != nil {
+		log.Printf("restart schedules failed: %v", err)
+		return
+	}
+	for _, rs := range due {
+		c, ok := m.store.GetContainer(rs.Container)
+		switch {
+		case !ok || !c.Present:
+			log.Printf("scheduled restart skipped for %s: container not found", rs.Container)
+		case !strings.EqualFold(c.Status, "running"):
+			log.Printf("scheduled restart skipped for %s: container is %s", rs.Container, c.Status)
+		default:
+			m.restartPlanned(ctx, c, rs)
+		}
+		if err := m.store.CompleteRestartSchedule(ctx, rs, now); err != nil {
+			log.Printf("restart schedule %d update failed: %v", rs.ID, err)
+		}
+	}
+}
+
+func (m *Monitor) restartPlanned(ctx context.Context, c store.Container, rs store.RestartSchedule) {
+	if err := m.restartContainer(ctx, c, "scheduled_restart"); err != nil {
+		log.Printf("scheduled restart of %s failed: %v", c.Name, err)
+		m.emitAlert(ctx, c.Name, c.ContainerID, "", "planned_restart_failed", fmt.Sprintf("Scheduled restart failed: %v", err), "red", nil)
+		return
+	}
+	message := "Planned restart"
+	if rs.Interval > 0 {
+		message = fmt.Sprintf("Planned restart (every %s)", compactInterval(rs.Interval))
+	}
+	m.emitInfo(ctx, c.Name, c.ContainerID, "", "planned_restart", message, "", "", "", "", "scheduled", nil)
+}
+
+// compactInterval drops the zero minutes and seconds Duration.String adds,
+// so 24h reads "24h" rather than "24h0m0s".
+func compactInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+func TestScheduledRestartDoesNotCountTowardRestartLoop(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	inspect := container.InspectResponse{
+		ID:      "cid-leaky",
+		Created: now.Add(-time.Hour).Format(time.RFC3339Nano),
+		State: &container.State{
+			Status:    "running",
+			StartedAt: now.Add(-time.Minute).Format(time.RFC3339Nano),
+		},
+		HostConfig: &container.HostConfig{
+			RestartPolicy: container.RestartPolicy{Name: "always"},
+		},
+		Config: &container.Config{
+			Image:  "ghcr.io/example/leaky:latest",
+			Labels: map[string]string{"com.docker.compose.service": "leaky"},
+		},
+		Image: "sha256:image-leaky",
+	}
+	raw, err := json.Marshal(inspect)
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+	mock := newMockDockerServer(t, nil, []inspectRecord{{ID: "cid-leaky", Inspect: raw}})
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:        "leaky",
+		ContainerID: "cid-leaky",
+		Status:      "running",
+		Role:        "service",
+		Present:     true,
+		StartedAt:   now.Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if _, err := st.AddRestartSchedule(ctx, store.RestartSchedule{Container: "leaky", NextRunAt: now.Add(-time.Second)}); err != nil {
+		t.Fatalf("add schedule: %v", err)
+	}
+
+	srv := api.NewServer(st, api.NewBroadcaster(), api.WSOptions{})
+	mon := New(config.Config{RestartWindowSeconds: 600, RestartThreshold: 2}, st, srv)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	mon.runScheduledRestarts(ctx)
+	mock.mu.Lock()
+	restarted := append([]string(nil), mock.restarted...)
+	mock.mu.Unlock()
+	if len(restarted) != 1 || restarted[0] != "cid-leaky" {
+		t.Fatalf("expected one restart of cid-leaky, got %v", restarted)
+	}
+	if remaining, _ := st.ListRestartSchedules(ctx); len(remaining) != 0 {
+		t.Fatalf("expected one-time schedule to be removed, got %+v", remaining)
+	}
+
+	// Docker reports the restart as die and restart events.
+	exitCode := 143
+	mon.handleRestartLike(ctx, "leaky", "cid-leaky", "die", &exitCode, "")
+	mon.handleRestartLike(ctx, "leaky", "cid-leaky", "restart", nil, "")
+	mon.handleRestartLike(ctx, "leaky", "cid-leaky", "restart", nil, "")
+
+	got, _ := st.GetContainer("leaky")
+	if got.RestartLoop {
+		t.Fatalf("planned restarts must not enter a restart loop")
+	}
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("expected no alerts, got %+v", alerts)
+	}
+	events, err := st.ListAllEvents(ctx, store.Filter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	planned := 0
+	for _, e := range events {
+		if e.Type == "restart" && e.Reason == selfInflictedReason {
+			planned++
+		}
+	}
+	if planned != 3 {
+		t.Fatalf("expected 3 restart events marked self-inflicted, got %d in %+v", planned, events)
+	}
+}
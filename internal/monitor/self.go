@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"healthmon/internal/crash"
+	"healthmon/internal/store"
+)
+
+// selfContainerName is the pseudo-container healthmon files alerts about
+// itself under. The leading underscore keeps it from clashing with Docker
+// container names, which must start with a letter or digit.
+const selfContainerName = "_healthmon"
+
+// maxPanicStack caps the stack trace stored with a panic alert.
+const maxPanicStack = 16 << 10
+
+// CrashReporter returns the reporter that records panics recovered by the
+// monitor, for the HTTP server to share.
+func (m *Monitor) CrashReporter() *crash.Reporter {
+	return m.crash
+}
+
+// guard runs fn and turns a panic into a self-alert instead of a crash.
+func (m *Monitor) guard(ctx context.Context, where string, fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			m.crash.Handle(ctx, where, v)
+		}
+	}()
+	fn()
+}
+
+// ensureSelfContainer creates the _healthmon pseudo-container on first use.
+func (m *Monitor) ensureSelfContainer(ctx context.Context) (store.Container, bool) {
+	if c, ok := m.store.GetContainer(selfContainerName); ok {
+		return c, true
+	}
+	now := m.clock.Now()
+	err := m.store.UpsertContainer(ctx, store.Container{
+		Name:         selfContainerName,
+		Image:        "healthmon",
+		CreatedAt:    now,
+		RegisteredAt: now,
+		StartedAt:    now,
+		Status:       "running",
+		Role:         "service",
+		Caps:         []string{},
+		Present:      true,
+		UpdatedAt:    now,
+	})
+	if err != nil {
+		log.Printf("create %s container: %v", selfContainerName, err)
+		return store.Container{}, false
+	}
+	return m.store.GetContainer(selfContainerName)
+}
+
+// recordPanic files a recovered panic as a red alert on _healthmon, with the
+// stack trace in the alert details.
+func (m *Monitor) recordPanic(ctx context.Context, p crash.Panic) {
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	stack := string(p.Stack)
+	if len(stack) > maxPanicStack {
+		stack = stack[:maxPanicStack]
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   selfContainerName,
+		Type:        "panic",
+		Severity:    "red",
+		Message:     fmt.Sprintf("Recovered panic in %s: %s", p.Where, strings.TrimSpace(fmt.Sprint(p.Value))),
+		Timestamp:   m.clock.Now(),
+		Reason:      p.Where,
+		DetailsJSON: store.EncodeDetails(store.PanicDetails{Where: p.Where, Stack: stack}),
+	})
+}
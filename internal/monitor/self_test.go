@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestGuardRecordsPanicAsSelfAlert(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	ran := false
+	mon.guard(ctx, "event die", func() {
+		var c *store.Container
+		_ = c.Name
+	})
+	mon.guard(ctx, "event start", func() { ran = true })
+	if !ran {
+		t.Fatalf("expected the monitor to keep handling after a panic")
+	}
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Type != "panic" || alerts[0].Reason != "event die" {
+		t.Fatalf("expected one panic alert for event die, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].DetailsJSON, "TestGuardRecordsPanicAsSelfAlert") {
+		t.Fatalf("expected the stack trace in the alert details, got %s", alerts[0].DetailsJSON)
+	}
+}
+
+func TestFailedNotificationsAreFiledOnceAMinute(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	grafana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer grafana.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+	mon := New(config.Config{GrafanaURL: grafana.URL, NotifyRetryHours: 24}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(clk)
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "cid-web", CreatedAt: now, StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	mon.emitAlert(ctx, "web", "cid-web", "web", "unhealthy", "Container became unhealthy", "red", nil)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "healthy", "Container became healthy", "green", nil)
+	mon.annotations.Retry(ctx)
+	clk.Advance(diagnosticInterval)
+	mon.emitAlert(ctx, "web", "cid-web", "web", "unhealthy", "Container became unhealthy", "red", nil)
+	mon.annotations.Retry(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}, Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 || alerts[0].Type != "notification_failed" || alerts[1].Type != "notification_failed" {
+		t.Fatalf("expected two notification_failed alerts, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].Message, "Grafana notification for web failed") || !strings.Contains(alerts[1].Message, "(1 more since the last report)") {
+		t.Fatalf("unexpected messages: %q, %q", alerts[0].Message, alerts[1].Message)
+	}
+}
+
+func TestFailedBackgroundWritesAreFiled(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "running"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	// Updates of known containers are written in the background; make them
+	// fail like a full disk would.
+	if _, err := dbConn.SQL.ExecContext(ctx, `CREATE TRIGGER fail_web BEFORE UPDATE ON containers WHEN OLD.name = 'web' BEGIN SELECT RAISE(FAIL, 'disk full'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "c-web", Status: "exited"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{selfContainerName}, Types: []string{"db_write_failed"}}, 0, 10)
+		if err != nil {
+			t.Fatalf("list alerts: %v", err)
+		}
+		if len(alerts) == 1 {
+			if !strings.Contains(alerts[0].Message, "container web") || !strings.Contains(alerts[0].Message, "disk full") {
+				t.Fatalf("unexpected alert %+v", alerts[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a db_write_failed alert, got %+v", alerts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c, ok := st.GetContainer("web"); !ok || c.Status != "running" {
+		t.Fatalf("expected the cache to fall back to the stored state, got %+v", c)
+	}
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+// selfInflictedWindow is how long after healthmon acts on a container the
+// Docker events it causes count as self-inflicted. A stop timeout plus a
+// slow start fit comfortably.
+const selfInflictedWindow = 2 * time.Minute
+
+// selfInflictedReason marks events caused by healthmon's own actions. They
+// never count toward restart loops, failure alerts or the health score.
+const selfInflictedReason = "self_inflicted"
+
+// selfActions remembers containers healthmon is acting on, e.g. restarting on
+// schedule, so the events that follow are not mistaken for failures. It is
+// shared by the event loop and the periodic checks.
+type selfActions struct {
+	mu      sync.Mutex
+	actions map[string]selfAction
+	// host covers every container, e.g. while the Docker daemon restarts
+	// them all.
+	host selfAction
+}
+
+type selfAction struct {
+	action string
+	until  time.Time
+}
+
+func newSelfActions() *selfActions {
+	return &selfActions{actions: make(map[string]selfAction)}
+}
+
+func (s *selfActions) mark(name, action string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[name] = selfAction{action: action, until: now.Add(selfInflictedWindow)}
+}
+
+// markHost marks every container until the given time.
+func (s *selfActions) markHost(action string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.host = selfAction{action: action, until: until}
+}
+
+// active returns the action healthmon is taking on a container, if any.
+func (s *selfActions) active(name string, now time.Time) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.actions[name]; ok {
+		if !now.After(a.until) {
+			return a.action, true
+		}
+		delete(s.actions, name)
+	}
+	if s.host.action != "" && !now.After(s.host.until) {
+		return s.host.action, true
+	}
+	return "", false
+}
+
+// restartContainer restarts a container through the Docker API on behalf of
+// action (e.g. "scheduled_restart"), marking it first so the die, kill and
+// restart events it causes are recorded as self-inflicted.
+func (m *Monitor) restartContainer(ctx context.Context, c store.Container, action string) error {
+	m.selfActions.mark(c.Name, action, m.clock.Now())
+	_, err := m.docker.ContainerRestart(ctx, c.ContainerID, client.ContainerRestartOptions{})
+	return err
+}
+
+// tagSelfInflicted marks e as caused by action, keeping its original reason
+// in the details.
+func tagSelfInflicted(e *store.Event, action string) {
+	e.Reason = selfInflictedReason
+	e.DetailsJSON = store.EncodeDetails(store.SelfInflictedDetails{Action: action, Reason: e.Reason})
+}
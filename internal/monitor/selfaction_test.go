@@ -0,0 +1,22 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelfActionsExpireAfterWindow(t *testing.T) {
+	actions := newSelfActions()
+	now := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	actions.mark("leaky", "scheduled_restart", now)
+
+	if action, ok := actions.active("leaky", now.Add(time.Minute)); !ok || action != "scheduled_restart" {
+		t.Fatalf("expected scheduled_restart to be active, got %q %v", action, ok)
+	}
+	if _, ok := actions.active("other", now); ok {
+		t.Fatalf("expected other containers to be unaffected")
+	}
+	if _, ok := actions.active("leaky", now.Add(selfInflictedWindow+time.Second)); ok {
+		t.Fatalf("expected the action to expire after the window")
+	}
+}
@@ -0,0 +1,322 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// statsReader reads a one-shot stats sample of a container, and its main
+// process for file descriptor counts; the Docker client in production.
+type statsReader interface {
+	ContainerStats(ctx context.Context, containerID string, options client.ContainerStatsOptions) (client.ContainerStatsResult, error)
+	ContainerInspect(ctx context.Context, containerID string, options client.ContainerInspectOptions) (client.ContainerInspectResult, error)
+}
+
+// statsWatch is the state of the resource sampler. It is only used by the
+// sampler's goroutine. A nil tracker turns its check off.
+type statsWatch struct {
+	reader statsReader
+	// procDir is the host's /proc, for counting file descriptors.
+	procDir string
+	memory  *pressureTracker
+	pids    *pressureTracker
+	fds     *pressureTracker
+}
+
+// newStatsWatch sets up the checks enabled in the configuration. It returns
+// nil when there is nothing to sample.
+func (m *Monitor) newStatsWatch(reader statsReader) *statsWatch {
+	if m.cfg.StatsIntervalSeconds <= 0 {
+		return nil
+	}
+	w := &statsWatch{reader: reader, procDir: m.cfg.ProcDir}
+	tracker := func(percent int) *pressureTracker {
+		if percent <= 0 {
+			return nil
+		}
+		return newPressureTracker(float64(percent), m.cfg.MemoryPressureSamples)
+	}
+	w.memory = tracker(m.cfg.MemoryPressurePercent)
+	w.pids = tracker(m.cfg.PIDsPressurePercent)
+	if w.procDir != "" {
+		w.fds = tracker(m.cfg.FDsPressurePercent)
+	}
+	if w.memory == nil && w.pids == nil && w.fds == nil {
+		return nil
+	}
+	return w
+}
+
+// watchStats samples the resource usage of the running containers every
+// HM_STATS_INTERVAL_SECONDS and alerts on sustained pressure on their
+// memory, PID and file descriptor limits.
+func (m *Monitor) watchStats(ctx context.Context) {
+	w := m.newStatsWatch(m.docker)
+	if w == nil {
+		return
+	}
+	ticker := m.clock.NewTicker(time.Duration(m.cfg.StatsIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.guard(ctx, "stats", func() { m.sampleStats(ctx, w) })
+		}
+	}
+}
+
+func (m *Monitor) sampleStats(ctx context.Context, w *statsWatch) {
+	sampled := make(map[string]bool)
+	for _, c := range m.store.ListContainers() {
+		if c.Role == unitRole || c.Name == selfContainerName || c.ContainerID == "" || !strings.EqualFold(c.Status, "running") {
+			continue
+		}
+		stats, err := readStats(ctx, w.reader, c.ContainerID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("stats for %s failed: %v", c.Name, err)
+			}
+			continue
+		}
+		sampled[c.Name] = true
+		if w.memory != nil {
+			m.checkMemory(ctx, w, c, stats)
+		}
+		if w.pids != nil {
+			m.checkPIDs(ctx, w, c, stats)
+		}
+		if w.fds != nil {
+			m.checkFDs(ctx, w, c)
+		}
+	}
+	for _, tracker := range []*pressureTracker{w.memory, w.pids, w.fds} {
+		if tracker != nil {
+			tracker.keep(sampled)
+		}
+	}
+}
+
+func readStats(ctx context.Context, reader statsReader, id string) (container.StatsResponse, error) {
+	result, err := reader.ContainerStats(ctx, id, client.ContainerStatsOptions{})
+	if err != nil {
+		return container.StatsResponse{}, err
+	}
+	defer result.Body.Close()
+	var stats container.StatsResponse
+	if err := json.NewDecoder(result.Body).Decode(&stats); err != nil {
+		return container.StatsResponse{}, err
+	}
+	return stats, nil
+}
+
+// memoryUsage is the memory a container uses the way `docker stats` counts
+// it: without the inactive page cache, which the kernel reclaims before it
+// OOM-kills anything.
+func memoryUsage(s container.MemoryStats) uint64 {
+	inactive, ok := s.Stats["inactive_file"]
+	if !ok {
+		inactive = s.Stats["total_inactive_file"]
+	}
+	if inactive > s.Usage {
+		return 0
+	}
+	return s.Usage - inactive
+}
+
+// checkMemory raises memory_pressure when a container stays above
+// HM_MEMORY_PRESSURE_PERCENT of its memory limit for
+// HM_MEMORY_PRESSURE_SAMPLES samples in a row, and memory_recovered once it
+// drops below again. Containers without a limit are skipped: Docker reports
+// the host's memory as their limit.
+func (m *Monitor) checkMemory(ctx context.Context, w *statsWatch, c store.Container, stats container.StatsResponse) {
+	limit := stats.MemoryStats.Limit
+	if c.MemoryLimit <= 0 || limit == 0 {
+		return
+	}
+	usage := memoryUsage(stats.MemoryStats)
+	percent := float64(usage) * 100 / float64(limit)
+	raise, recovered, samples := w.memory.observe(c.Name, percent)
+	switch {
+	case raise:
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:   c.Name,
+			ContainerID: c.ContainerID,
+			Type:        "memory_pressure",
+			Severity:    "yellow",
+			Message:     fmt.Sprintf("Memory at %.0f%% of its %s limit for %d samples", percent, formatBytes(limit), samples),
+			Timestamp:   m.clock.Now(),
+			DetailsJSON: store.EncodeDetails(store.MemoryPressureDetails{Percent: roundPercent(percent), UsageBytes: int64(usage), LimitBytes: int64(limit), Samples: samples}),
+		})
+	case recovered:
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:   c.Name,
+			ContainerID: c.ContainerID,
+			Type:        "memory_recovered",
+			Severity:    "green",
+			Message:     fmt.Sprintf("Memory back to %.0f%% of its %s limit", percent, formatBytes(limit)),
+			Timestamp:   m.clock.Now(),
+			DetailsJSON: store.EncodeDetails(store.MemoryPressureDetails{Percent: roundPercent(percent), UsageBytes: int64(usage), LimitBytes: int64(limit)}),
+		})
+	}
+}
+
+// checkPIDs raises pids_pressure when a container's process count stays
+// near its PID limit, which catches fork leaks before the container can no
+// longer start processes. Containers without a limit are skipped.
+func (m *Monitor) checkPIDs(ctx context.Context, w *statsWatch, c store.Container, stats container.StatsResponse) {
+	current, limit := stats.PidsStats.Current, stats.PidsStats.Limit
+	if limit == 0 {
+		return
+	}
+	m.checkLimit(ctx, w.pids, c, "pids", "PIDs", current, limit)
+}
+
+// checkFDs raises fd_pressure when the main process of a container keeps
+// most of its file descriptors open. It needs the host's /proc in
+// HM_PROC_DIR and healthmon in the host's PID namespace.
+func (m *Monitor) checkFDs(ctx context.Context, w *statsWatch, c store.Container) {
+	inspect, err := w.reader.ContainerInspect(ctx, c.ContainerID, client.ContainerInspectOptions{})
+	if err != nil || inspect.Container.State == nil || inspect.Container.State.Pid <= 0 {
+		return
+	}
+	open, limit, err := openFiles(w.procDir, inspect.Container.State.Pid)
+	if err != nil {
+		log.Printf("file descriptors of %s: %v", c.Name, err)
+		return
+	}
+	if limit == 0 {
+		return
+	}
+	m.checkLimit(ctx, w.fds, c, "fd", "Open files", open, limit)
+}
+
+// checkLimit feeds one sample of a resource with a hard limit to its
+// tracker and raises <kind>_pressure or <kind>_recovered.
+func (m *Monitor) checkLimit(ctx context.Context, tracker *pressureTracker, c store.Container, kind, label string, current, limit uint64) {
+	percent := float64(current) * 100 / float64(limit)
+	raise, recovered, samples := tracker.observe(c.Name, percent)
+	if !raise && !recovered {
+		return
+	}
+	a := store.Alert{
+		Container:   c.Name,
+		ContainerID: c.ContainerID,
+		Timestamp:   m.clock.Now(),
+		DetailsJSON: store.EncodeDetails(store.LimitPressureDetails{Resource: kind, Percent: roundPercent(percent), Current: int64(current), Limit: int64(limit), Samples: samples}),
+	}
+	if raise {
+		a.Type, a.Severity = kind+"_pressure", "yellow"
+		a.Message = fmt.Sprintf("%s at %d of %d (%.0f%%) for %d samples", label, current, limit, percent, samples)
+	} else {
+		a.Type, a.Severity = kind+"_recovered", "green"
+		a.Message = fmt.Sprintf("%s back to %d of %d (%.0f%%)", label, current, limit, percent)
+	}
+	m.emitAlertRecord(ctx, a)
+}
+
+// openFiles counts the open file descriptors of a process and reads its
+// soft limit from procDir/<pid>/limits. The limit is 0 when unlimited.
+func openFiles(procDir string, pid int) (uint64, uint64, error) {
+	dir := filepath.Join(procDir, strconv.Itoa(pid))
+	entries, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return 0, 0, err
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "limits"))
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		rest, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			break
+		}
+		// "unlimited" does not parse and counts as no limit.
+		limit, _ := strconv.ParseUint(fields[0], 10, 64)
+		return uint64(len(entries)), limit, nil
+	}
+	return 0, 0, fmt.Errorf("no open files limit in %s", filepath.Join(dir, "limits"))
+}
+
+// pressureTracker counts the consecutive samples each container spent at
+// or above a threshold.
+type pressureTracker struct {
+	threshold float64
+	samples   int
+	above     map[string]int
+	alerted   map[string]bool
+}
+
+func newPressureTracker(threshold float64, samples int) *pressureTracker {
+	return &pressureTracker{threshold: threshold, samples: max(samples, 1), above: make(map[string]int), alerted: make(map[string]bool)}
+}
+
+// observe records a sample. It reports raise for the sample that completes
+// a run of samples at or above the threshold, and recovered for the first
+// sample below it after that, along with the length of the run.
+func (p *pressureTracker) observe(name string, value float64) (raise, recovered bool, samples int) {
+	if value < p.threshold {
+		recovered = p.alerted[name]
+		delete(p.above, name)
+		delete(p.alerted, name)
+		return false, recovered, 0
+	}
+	p.above[name]++
+	samples = p.above[name]
+	if samples >= p.samples && !p.alerted[name] {
+		p.alerted[name] = true
+		return true, false, samples
+	}
+	return false, false, samples
+}
+
+// keep forgets the containers that were not sampled, e.g. because they
+// stopped, so a restarted container starts a fresh run.
+func (p *pressureTracker) keep(names map[string]bool) {
+	for name := range p.above {
+		if !names[name] {
+			delete(p.above, name)
+			delete(p.alerted, name)
+		}
+	}
+}
+
+func roundPercent(percent float64) float64 {
+	return float64(int64(percent*10+0.5)) / 10
+}
+
+// formatBytes renders a size in binary units, like "512 MiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	value := float64(n) / float64(div)
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d %ciB", int64(value), "KMGTPE"[exp])
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[exp])
+}
@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+// fakeStats serves the memory usage and PID count set per container ID.
+type fakeStats struct {
+	usage map[string]uint64
+	limit uint64
+	pids  map[string]uint64
+}
+
+func (f *fakeStats) ContainerStats(_ context.Context, id string, _ client.ContainerStatsOptions) (client.ContainerStatsResult, error) {
+	raw, _ := json.Marshal(container.StatsResponse{
+		MemoryStats: container.MemoryStats{
+			Usage: f.usage[id] + 64<<20,
+			Limit: f.limit,
+			Stats: map[string]uint64{"inactive_file": 64 << 20},
+		},
+		PidsStats: container.PidsStats{Current: f.pids[id], Limit: 100},
+	})
+	return client.ContainerStatsResult{Body: io.NopCloser(strings.NewReader(string(raw)))}, nil
+}
+
+// ContainerInspect reports every container's main process as PID 4242.
+func (f *fakeStats) ContainerInspect(_ context.Context, id string, _ client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+	return client.ContainerInspectResult{Container: container.InspectResponse{ID: id, State: &container.State{Pid: 4242}}}, nil
+}
+
+func newStatsMonitor(t *testing.T, cfg config.Config, containers ...store.Container) (*Monitor, *store.Store) {
+	t.Helper()
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { dbConn.Close() })
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	t.Cleanup(func() { st.Close() })
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	mon := New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(clock.NewFake(now))
+	for _, c := range containers {
+		c.CreatedAt, c.StartedAt = now, now
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+	}
+	return mon, st
+}
+
+func TestMemoryPressureAlertsAfterSustainedSamples(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{StatsIntervalSeconds: 30, MemoryPressurePercent: 90, MemoryPressureSamples: 3}
+	mon, st := newStatsMonitor(t, cfg,
+		store.Container{Name: "web", ContainerID: "cid-web", Status: "running", MemoryLimit: 512 << 20},
+		store.Container{Name: "db", ContainerID: "cid-db", Status: "running"},
+	)
+
+	reader := &fakeStats{usage: map[string]uint64{"cid-web": 480 << 20, "cid-db": 510 << 20}, limit: 512 << 20}
+	w := mon.newStatsWatch(reader)
+	for range 4 {
+		mon.sampleStats(ctx, w)
+	}
+	reader.usage["cid-web"] = 200 << 20
+	mon.sampleStats(ctx, w)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected memory_pressure and memory_recovered on web only, got %+v", alerts)
+	}
+	pressure, recovered := alerts[0], alerts[1]
+	if pressure.Container != "web" || pressure.Type != "memory_pressure" || pressure.Message != "Memory at 94% of its 512 MiB limit for 3 samples" {
+		t.Fatalf("unexpected alert %+v", pressure)
+	}
+	details, err := store.DecodeDetails(pressure.DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.MemoryPressureDetails); !ok || d.Percent != 93.8 || d.UsageBytes != 480<<20 || d.Samples != 3 {
+		t.Fatalf("unexpected details %+v", details)
+	}
+	if recovered.Type != "memory_recovered" || recovered.Severity != "green" {
+		t.Fatalf("unexpected alert %+v", recovered)
+	}
+}
+
+func TestPIDAndFileDescriptorPressure(t *testing.T) {
+	ctx := context.Background()
+	procDir := t.TempDir()
+	fdDir := filepath.Join(procDir, "4242", "fd")
+	if err := os.MkdirAll(fdDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for i := range 19 {
+		if err := os.WriteFile(filepath.Join(fdDir, strconv.Itoa(i)), nil, 0o644); err != nil {
+			t.Fatalf("write fd: %v", err)
+		}
+	}
+	limits := "Limit                     Soft Limit           Hard Limit           Units\nMax open files            20                   4096                 files\n"
+	if err := os.WriteFile(filepath.Join(procDir, "4242", "limits"), []byte(limits), 0o644); err != nil {
+		t.Fatalf("write limits: %v", err)
+	}
+
+	cfg := config.Config{StatsIntervalSeconds: 30, PIDsPressurePercent: 90, FDsPressurePercent: 90, MemoryPressureSamples: 2, ProcDir: procDir}
+	mon, st := newStatsMonitor(t, cfg, store.Container{Name: "worker", ContainerID: "cid-worker", Status: "running"})
+	reader := &fakeStats{pids: map[string]uint64{"cid-worker": 97}}
+	w := mon.newStatsWatch(reader)
+	if w.memory != nil {
+		t.Fatalf("memory pressure should be off")
+	}
+	mon.sampleStats(ctx, w)
+	mon.sampleStats(ctx, w)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Type+": "+a.Message)
+	}
+	want := "pids_pressure: PIDs at 97 of 100 (97%) for 2 samples,fd_pressure: Open files at 19 of 20 (95%) for 2 samples"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected alerts %v", got)
+	}
+}
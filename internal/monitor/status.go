@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"healthmon/internal/api"
+)
+
+// monitorState tracks the Docker connection for /readyz and /api/status. It
+// is written by the event loop and read by HTTP handlers.
+type monitorState struct {
+	mu        sync.Mutex
+	role      string
+	connected bool
+	lastSync  time.Time
+	lastEvent time.Time
+	lastError string
+	retryAt   time.Time
+	engine    string
+	engineAPI string
+}
+
+func (s *monitorState) setConnected(connected bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = connected
+	if connected {
+		s.lastError = ""
+		s.retryAt = time.Time{}
+	}
+}
+
+// failed records why Docker cannot be reached and when the next attempt is
+// due.
+func (s *monitorState) failed(err error, retryAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected = false
+	s.lastError = err.Error()
+	s.retryAt = retryAt
+}
+
+func (s *monitorState) setRole(role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.role = role
+}
+
+// setEngine records the version of the Docker Engine healthmon is connected
+// to.
+func (s *monitorState) setEngine(version, apiVersion string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.engine, s.engineAPI = version, apiVersion
+}
+
+func (s *monitorState) synced(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSync = at
+}
+
+func (s *monitorState) event(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastEvent = at
+}
+
+// Status reports whether the Docker event stream is connected, why not,
+// when containers were last synced and the last event arrived, and the
+// version of the engine.
+func (m *Monitor) Status() api.MonitorStatus {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+	return api.MonitorStatus{
+		EventStreamConnected: m.state.connected,
+		LastSyncAt:           m.state.lastSync,
+		LastEventAt:          m.state.lastEvent,
+		DockerError:          m.state.lastError,
+		DockerRetryAt:        m.state.retryAt,
+		Role:                 m.state.role,
+		EngineVersion:        m.state.engine,
+		EngineAPIVersion:     m.state.engineAPI,
+	}
+}
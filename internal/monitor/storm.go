@@ -0,0 +1,130 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// stormTracker remembers when each container last restarted, to tell a
+// host-wide restart storm from containers failing on their own. It is
+// shared by the event loop and the heal checks.
+type stormTracker struct {
+	mu       sync.Mutex
+	restarts map[string]time.Time
+	active   bool
+	since    time.Time
+	members  map[string]bool
+}
+
+func newStormTracker() *stormTracker {
+	return &stormTracker{restarts: make(map[string]time.Time)}
+}
+
+// record adds a restart and reports whether a storm is going on, and
+// whether it started with this restart, with the containers in it.
+func (s *stormTracker) record(name string, now time.Time, window time.Duration, limit int) (storming, started bool, names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restarts[name] = now
+	for other, at := range s.restarts {
+		if now.Sub(at) > window {
+			delete(s.restarts, other)
+		}
+	}
+	if s.active {
+		s.members[name] = true
+		return true, false, nil
+	}
+	if len(s.restarts) <= limit {
+		return false, false, nil
+	}
+	s.active, s.since, s.members = true, now, make(map[string]bool)
+	for other := range s.restarts {
+		s.members[other] = true
+	}
+	return true, true, sortedKeys(s.members)
+}
+
+// end reports a storm as over once no container restarted for a window,
+// with the containers that were part of it.
+func (s *stormTracker) end(now time.Time, window time.Duration) (ended bool, since time.Time, names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return false, time.Time{}, nil
+	}
+	for _, at := range s.restarts {
+		if now.Sub(at) <= window {
+			return false, time.Time{}, nil
+		}
+	}
+	s.active = false
+	s.restarts = make(map[string]time.Time)
+	return true, s.since, sortedKeys(s.members)
+}
+
+func (m *Monitor) stormWindow() time.Duration {
+	return time.Duration(m.cfg.StormWindowSeconds) * time.Second
+}
+
+// recordStormRestart counts a restart toward the storm detector. When more
+// than HM_RESTART_STORM_CONTAINERS containers restart within
+// HM_RESTART_STORM_WINDOW_SECONDS, one restart_storm alert is filed on
+// _healthmon. It reports whether a storm is going on, in which case the
+// restart alerts of the single containers are left out.
+func (m *Monitor) recordStormRestart(ctx context.Context, name string, now time.Time) bool {
+	if m.cfg.StormContainers <= 0 || name == "" {
+		return false
+	}
+	storming, started, names := m.storm.record(name, now, m.stormWindow(), m.cfg.StormContainers)
+	if started {
+		if _, ok := m.ensureSelfContainer(ctx); ok {
+			m.emitAlertRecord(ctx, store.Alert{
+				Container:   selfContainerName,
+				Type:        "restart_storm",
+				Severity:    "red",
+				Message:     fmt.Sprintf("%d containers restarted within %s, the host may be in trouble: %s", len(names), m.stormWindow(), strings.Join(names, ", ")),
+				Timestamp:   now,
+				DetailsJSON: store.EncodeDetails(store.StormDetails{Containers: names, WindowSeconds: m.cfg.StormWindowSeconds}),
+			})
+		}
+	}
+	return storming
+}
+
+// checkStorm files restart_storm_ended once no container restarted for a
+// window, naming the containers still in a restart loop, whose own alerts
+// were held back during the storm.
+func (m *Monitor) checkStorm(ctx context.Context) {
+	now := m.clock.Now()
+	ended, since, names := m.storm.end(now, m.stormWindow())
+	if !ended {
+		return
+	}
+	var looping []string
+	for _, name := range names {
+		if c, ok := m.store.GetContainer(name); ok && c.RestartLoop {
+			looping = append(looping, name)
+		}
+	}
+	message := fmt.Sprintf("Restart storm over after %s", now.Sub(since).Round(time.Second))
+	if len(looping) > 0 {
+		message += ", still in a restart loop: " + strings.Join(looping, ", ")
+	}
+	if _, ok := m.ensureSelfContainer(ctx); !ok {
+		return
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   selfContainerName,
+		Type:        "restart_storm_ended",
+		Severity:    "green",
+		Message:     message,
+		Timestamp:   now,
+		DetailsJSON: store.EncodeDetails(store.StormDetails{Containers: names, WindowSeconds: m.cfg.StormWindowSeconds}),
+	})
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+func TestRestartStormRaisesOneAlert(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var inspects []inspectRecord
+	var containers []store.Container
+	for _, name := range []string{"api", "db", "web"} {
+		raw, err := json.Marshal(container.InspectResponse{
+			ID:         "cid-" + name,
+			Created:    start.Add(-time.Hour).Format(time.RFC3339Nano),
+			State:      &container.State{Status: "running", StartedAt: start.Format(time.RFC3339Nano)},
+			HostConfig: &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: "always"}},
+			Config:     &container.Config{Image: name + ":latest", Labels: map[string]string{"com.docker.compose.service": name}},
+			Image:      "sha256:" + name,
+		})
+		if err != nil {
+			t.Fatalf("marshal inspect: %v", err)
+		}
+		inspects = append(inspects, inspectRecord{ID: "cid-" + name, Inspect: raw})
+		containers = append(containers, store.Container{Name: name, ContainerID: "cid-" + name, Status: "running", Present: true})
+	}
+	mock := newMockDockerServer(t, nil, inspects)
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	cfg := config.Config{RestartWindowSeconds: 600, RestartThreshold: 2, StormContainers: 2, StormWindowSeconds: 60}
+	mon, st := newStatsMonitor(t, cfg, containers...)
+	fake := clock.NewFake(start)
+	mon.WithClock(fake)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	for _, name := range []string{"api", "db", "web", "api"} {
+		fake.Advance(5 * time.Second)
+		mon.handleRestartLike(ctx, name, "cid-"+name, "restart", nil, "")
+	}
+	mon.checkStorm(ctx)
+	fake.Advance(2 * time.Minute)
+	mon.checkStorm(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	for _, a := range alerts {
+		got = append(got, a.Type+" "+a.Container+": "+a.Message)
+	}
+	want := []string{
+		"restart_storm _healthmon: 3 containers restarted within 1m0s, the host may be in trouble: api, db, web",
+		"restart_storm_ended _healthmon: Restart storm over after 2m5s, still in a restart loop: api",
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("unexpected alerts:\n%v\nwant\n%v", got, want)
+	}
+	if c, _ := st.GetContainer("api"); !c.RestartLoop {
+		t.Fatalf("expected api to be in a restart loop")
+	}
+}
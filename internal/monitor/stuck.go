@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+// stuckSpell is one stretch a container spent in a transient state.
+type stuckSpell struct {
+	state   string
+	since   time.Time
+	alerted bool
+}
+
+// stuckTracker remembers since when each container has been in one of
+// HM_STUCK_STATES. It is only used by the heal check goroutine.
+type stuckTracker struct {
+	states   map[string]bool
+	spells   map[string]stuckSpell
+	restored bool
+}
+
+func newStuckTracker(states []string) *stuckTracker {
+	t := &stuckTracker{states: make(map[string]bool), spells: make(map[string]stuckSpell)}
+	for _, state := range states {
+		t.states[strings.ToLower(state)] = true
+	}
+	return t
+}
+
+// checkStuck raises container_stuck for containers that stayed created,
+// dead or removing for longer than HM_STUCK_STATE_SECONDS, and
+// container_unstuck once they leave that state or are removed.
+func (m *Monitor) checkStuck(ctx context.Context) {
+	limit := time.Duration(m.cfg.StuckStateSeconds) * time.Second
+	if limit <= 0 || len(m.stuck.states) == 0 {
+		return
+	}
+	now := m.clock.Now()
+	containers := m.store.ListContainers()
+	if !m.stuck.restored {
+		m.restoreStuck(ctx, containers)
+		m.stuck.restored = true
+	}
+	seen := make(map[string]bool)
+	for _, c := range containers {
+		if c.Role == unitRole || c.Name == selfContainerName {
+			continue
+		}
+		state := strings.ToLower(c.Status)
+		spell, tracked := m.stuck.spells[c.Name]
+		if !c.Present || !m.stuck.states[state] {
+			if tracked && spell.alerted {
+				m.emitUnstuck(ctx, c, spell, now)
+			}
+			continue
+		}
+		seen[c.Name] = true
+		if !tracked || spell.state != state {
+			spell = stuckSpell{state: state, since: now}
+			// Docker keeps the creation time, so a container that was never
+			// started is timed from it even across healthmon restarts.
+			if state == "created" && !c.CreatedAt.IsZero() {
+				spell.since = c.CreatedAt
+			}
+		}
+		if !spell.alerted && now.Sub(spell.since) >= limit {
+			spell.alerted = true
+			m.emitAlertRecord(ctx, store.Alert{
+				Container:   c.Name,
+				ContainerID: c.ContainerID,
+				Type:        "container_stuck",
+				Severity:    "yellow",
+				Message:     fmt.Sprintf("Container stuck in %s state for %s", state, now.Sub(spell.since).Round(time.Second)),
+				Timestamp:   now,
+				DetailsJSON: store.EncodeDetails(store.StuckStateDetails{State: state, Since: spell.since.UTC().Format(time.RFC3339)}),
+			})
+		}
+		m.stuck.spells[c.Name] = spell
+	}
+	for name := range m.stuck.spells {
+		if !seen[name] {
+			delete(m.stuck.spells, name)
+		}
+	}
+}
+
+// restoreStuck picks up the container_stuck alerts raised before a restart,
+// so they are not raised again and are followed up once the container moves
+// on.
+func (m *Monitor) restoreStuck(ctx context.Context, containers []store.Container) {
+	for _, c := range containers {
+		latest, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, "container_stuck", "container_unstuck")
+		if err != nil {
+			log.Printf("stuck alert lookup failed for %s: %v", c.Name, err)
+			continue
+		}
+		if !found || latest.Type != "container_stuck" {
+			continue
+		}
+		spell := stuckSpell{since: latest.Timestamp, alerted: true}
+		if details, err := store.DecodeDetails(latest.DetailsJSON); err == nil {
+			if d, ok := details.(*store.StuckStateDetails); ok {
+				spell.state = d.State
+				if since, err := time.Parse(time.RFC3339, d.Since); err == nil {
+					spell.since = since
+				}
+			}
+		}
+		m.stuck.spells[c.Name] = spell
+	}
+}
+
+func (m *Monitor) emitUnstuck(ctx context.Context, c store.Container, spell stuckSpell, now time.Time) {
+	delete(m.stuck.spells, c.Name)
+	message := fmt.Sprintf("Container left the %s state, now %s", spell.state, strings.ToLower(c.Status))
+	if !c.Present {
+		message = fmt.Sprintf("Container removed after %s in %s state", now.Sub(spell.since).Round(time.Second), spell.state)
+	}
+	m.emitAlertRecord(ctx, store.Alert{
+		Container:   c.Name,
+		ContainerID: c.ContainerID,
+		Type:        "container_unstuck",
+		Severity:    "green",
+		Message:     message,
+		Timestamp:   now,
+		DetailsJSON: store.EncodeDetails(store.StuckStateDetails{State: spell.state, Since: spell.since.UTC().Format(time.RFC3339)}),
+	})
+}
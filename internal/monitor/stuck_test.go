@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/store"
+)
+
+func TestStuckContainersAlertOnceAndResolve(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Config{StuckStateSeconds: 600, StuckStates: []string{"created", "dead", "removing"}}
+	ghost := store.Container{Name: "ghost", ContainerID: "cid-ghost", Status: "created", Present: true}
+	web := store.Container{Name: "web", ContainerID: "cid-web", Status: "dead", Present: true}
+	worker := store.Container{Name: "worker", ContainerID: "cid-worker", Status: "running", Present: true}
+	mon, st := newStatsMonitor(t, cfg, ghost, web, worker)
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	mon.WithClock(fake)
+
+	mon.checkStuck(ctx)
+	fake.Advance(11 * time.Minute)
+	mon.checkStuck(ctx)
+	mon.checkStuck(ctx)
+
+	// A restarted healthmon does not raise the same alerts again.
+	mon.stuck = newStuckTracker(cfg.StuckStates)
+	mon.checkStuck(ctx)
+
+	web.Status = "running"
+	if err := st.UpsertContainer(ctx, web); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	fake.Advance(time.Minute)
+	mon.checkStuck(ctx)
+
+	alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 10)
+	if err != nil {
+		t.Fatalf("list alerts: %v", err)
+	}
+	var got []string
+	var webStuck store.Alert
+	for _, a := range alerts {
+		got = append(got, a.Container+" "+a.Type+": "+a.Message)
+		if a.Container == "web" && a.Type == "container_stuck" {
+			webStuck = a
+		}
+	}
+	// Containers are checked in no particular order.
+	slices.Sort(got)
+	want := []string{
+		"ghost container_stuck: Container stuck in created state for 11m0s",
+		"web container_stuck: Container stuck in dead state for 11m0s",
+		"web container_unstuck: Container left the dead state, now running",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected alerts:\n%s", strings.Join(got, "\n"))
+	}
+	details, err := store.DecodeDetails(webStuck.DetailsJSON)
+	if err != nil {
+		t.Fatalf("decode details: %v", err)
+	}
+	if d, ok := details.(*store.StuckStateDetails); !ok || d.State != "dead" || d.Since != "2026-03-01T12:00:00Z" {
+		t.Fatalf("unexpected details %+v", details)
+	}
+}
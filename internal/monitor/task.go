@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/client"
+)
+
+const taskMaxAgeLabel = "healthmon.task_max_age"
+
+// taskMaxAge returns how long a task may go without succeeding: the
+// healthmon.task_max_age label when it holds a valid duration ("0" turns the
+// check off), HM_TASK_MAX_AGE_SECONDS otherwise. Services never go overdue.
+func (m *Monitor) taskMaxAge(labels map[string]string, role string) time.Duration {
+	if role != "task" {
+		return 0
+	}
+	if value := strings.TrimSpace(labels[taskMaxAgeLabel]); value != "" {
+		if value == "0" {
+			return 0
+		}
+		if maxAge, err := time.ParseDuration(value); err == nil && maxAge >= 0 {
+			return maxAge
+		}
+		log.Printf("ignoring invalid %s label %q", taskMaxAgeLabel, value)
+	}
+	return time.Duration(m.cfg.TaskMaxAgeSeconds) * time.Second
+}
+
+// handleTaskExit records the exit of a task container: a clean exit becomes a
+// task_completed event and the task's last success, a failure a task_failed
+// alert. It returns false for services, and for failing tasks Docker restarts
+// by policy, which go through restart loop detection instead.
+func (m *Monitor) handleTaskExit(ctx context.Context, parsedName, id string, exitCode *int) bool {
+	inspect, err := m.docker.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return false
+	}
+	info := m.inspectToContainer(inspect.Container)
+	if info.Name == "" || info.Role != "task" {
+		return false
+	}
+	failed := exitCode != nil && *exitCode != 0
+	if failed && hasAutoRestartPolicy(inspect.Container) {
+		return false
+	}
+	// A task healthmon interrupted itself did not fail; the regular die
+	// handling records it as self-inflicted.
+	if _, ok := m.selfActions.active(info.Name, m.clock.Now()); ok {
+		return false
+	}
+
+	now := m.clock.Now()
+	existing, hasExisting := m.store.GetContainer(info.Name)
+	if hasExisting {
+		info.RegisteredAt = existing.RegisteredAt
+		if info.StartedAt.IsZero() {
+			info.StartedAt = existing.StartedAt
+		}
+	}
+	if info.RegisteredAt.IsZero() {
+		info.RegisteredAt = minTime(info.CreatedAt, now)
+	}
+	finished := info.FinishedAt
+	if finished.IsZero() {
+		finished = now
+	}
+	var duration time.Duration
+	if !info.StartedAt.IsZero() && finished.After(info.StartedAt) {
+		duration = finished.Sub(info.StartedAt).Round(time.Second)
+	}
+	details := store.EncodeDetails(store.TaskDetails{DurationSeconds: int64(duration / time.Second)})
+
+	if failed {
+		_ = m.store.UpsertContainer(ctx, info)
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:           info.Name,
+			ContainerID:         id,
+			ParsedContainerName: parsedName,
+			Type:                "task_failed",
+			Severity:            "red",
+			Message:             fmt.Sprintf("Task failed with exit code %d after %s", *exitCode, duration),
+			Timestamp:           now,
+			ExitCode:            exitCode,
+			DetailsJSON:         details,
+		})
+		return true
+	}
+
+	wasOverdue := hasExisting && m.taskOverdue(ctx, existing)
+	info.LastSuccessAt = finished
+	e := m.infoEvent(info.Name, id, parsedName, "task_completed", fmt.Sprintf("Task completed in %s", duration), "", "", "", "", "exit", exitCode)
+	e.Severity = "green"
+	e.DetailsJSON = details
+	m.upsertWithEvent(ctx, info, e)
+	if wasOverdue {
+		m.emitAlert(ctx, info.Name, id, parsedName, "task_recovered", "Task succeeded again", "green", exitCode)
+	}
+	return true
+}
+
+// overdueAlerts names the alerts raised when a task or heartbeat has not
+// checked in within its max age, and when it does again.
+type overdueAlerts struct {
+	overdue   string
+	recovered string
+}
+
+var overdueAlertTypes = map[string]overdueAlerts{
+	"task":      {overdue: "task_overdue", recovered: "task_recovered"},
+	"heartbeat": {overdue: "heartbeat_missed", recovered: "heartbeat_recovered"},
+}
+
+// taskOverdue reports whether a task_overdue alert is outstanding for c.
+func (m *Monitor) taskOverdue(ctx context.Context, c store.Container) bool {
+	return m.overdueOutstanding(ctx, c, overdueAlertTypes["task"])
+}
+
+func (m *Monitor) overdueOutstanding(ctx context.Context, c store.Container, types overdueAlerts) bool {
+	latest, found, err := m.store.GetLatestAlertByContainerPK(ctx, c.ID, types.overdue, types.recovered)
+	if err != nil {
+		log.Printf("overdue alert lookup failed for %s: %v", c.Name, err)
+		return true
+	}
+	return found && latest.Type == types.overdue
+}
+
+// checkOverdue raises task_overdue for tasks that have not exited cleanly
+// within their max age and heartbeat_missed for heartbeats that have not
+// checked in within their interval, once until they succeed again. Removed
+// tasks are checked too, since cron-style jobs often run with --rm.
+func (m *Monitor) checkOverdue(ctx context.Context) {
+	now := m.clock.Now()
+	for _, c := range m.store.ListAllContainers() {
+		types, ok := overdueAlertTypes[c.Role]
+		if !ok || c.TaskMaxAge <= 0 {
+			continue
+		}
+		since := c.LastSuccessAt
+		if since.IsZero() {
+			since = c.RegisteredAt
+		}
+		late := now.Sub(since) > c.TaskMaxAge
+		outstanding := m.overdueOutstanding(ctx, c, types)
+		switch {
+		case late && !outstanding:
+			m.emitAlert(ctx, c.Name, c.ContainerID, c.CurrentContainerName, types.overdue, overdueMessage(c), "red", nil)
+		case !late && outstanding:
+			m.emitAlert(ctx, c.Name, c.ContainerID, c.CurrentContainerName, types.recovered, recoveredMessage(c), "green", nil)
+		}
+	}
+}
+
+func overdueMessage(c store.Container) string {
+	if c.Role == "heartbeat" {
+		return fmt.Sprintf("No heartbeat for more than %s", c.TaskMaxAge)
+	}
+	if c.LastSuccessAt.IsZero() {
+		return fmt.Sprintf("Task has not succeeded since it was registered, more than %s ago", c.TaskMaxAge)
+	}
+	return fmt.Sprintf("Task has not succeeded for more than %s", c.TaskMaxAge)
+}
+
+func recoveredMessage(c store.Container) string {
+	if c.Role == "heartbeat" {
+		return "Heartbeat received again"
+	}
+	return "Task succeeded again"
+}
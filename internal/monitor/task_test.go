@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+)
+
+func TestOverdueTaskRecoversOnCleanExit(t *testing.T) {
+	ctx := context.Background()
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	now := fake.Now()
+
+	inspect := container.InspectResponse{
+		ID:      "cid-backup",
+		Name:    "/backup",
+		Created: now.Add(-3 * time.Hour).Format(time.RFC3339Nano),
+		State: &container.State{
+			Status:     "exited",
+			StartedAt:  now.Add(-90 * time.Second).Format(time.RFC3339Nano),
+			FinishedAt: now.Add(-30 * time.Second).Format(time.RFC3339Nano),
+		},
+		HostConfig: &container.HostConfig{},
+		Config: &container.Config{
+			Image:  "ghcr.io/example/backup:latest",
+			Labels: map[string]string{"healthmon.role": "task", taskMaxAgeLabel: "1h"},
+		},
+		Image: "sha256:image-backup",
+	}
+	raw, err := json.Marshal(inspect)
+	if err != nil {
+		t.Fatalf("marshal inspect: %v", err)
+	}
+	mock := newMockDockerServer(t, nil, []inspectRecord{{ID: "cid-backup", Inspect: raw}})
+	host, err := mock.Start()
+	if err != nil {
+		t.Fatalf("start mock docker: %v", err)
+	}
+	defer mock.Close()
+
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	defer st.Close()
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{
+		Name:         "backup",
+		ContainerID:  "cid-backup",
+		Status:       "exited",
+		Role:         "task",
+		Present:      true,
+		RegisteredAt: now.Add(-3 * time.Hour),
+		TaskMaxAge:   time.Hour,
+		UpdatedAt:    now,
+	}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Fatalf("new docker client: %v", err)
+	}
+	mon.docker = cli
+
+	mon.checkOverdue(ctx)
+	mon.checkOverdue(ctx)
+	alertTypes := func() []string {
+		t.Helper()
+		alerts, err := st.ListAllAlerts(ctx, store.Filter{Containers: []string{"backup"}, Ascending: true}, 0, 10)
+		if err != nil {
+			t.Fatalf("list alerts: %v", err)
+		}
+		types := make([]string, 0, len(alerts))
+		for _, a := range alerts {
+			types = append(types, a.Type)
+		}
+		return types
+	}
+	if got := alertTypes(); len(got) != 1 || got[0] != "task_overdue" {
+		t.Fatalf("expected a single task_overdue alert, got %v", got)
+	}
+
+	exitCode := 0
+	if !mon.handleTaskExit(ctx, "backup", "cid-backup", &exitCode) {
+		t.Fatalf("expected the task exit to be handled")
+	}
+	if got := alertTypes(); len(got) != 2 || got[1] != "task_recovered" {
+		t.Fatalf("expected task_recovered after a clean exit, got %v", got)
+	}
+	c, _ := st.GetContainer("backup")
+	if !c.LastSuccessAt.Equal(now.Add(-30 * time.Second)) {
+		t.Fatalf("expected last success at the finish time, got %s", c.LastSuccessAt)
+	}
+	events, err := st.ListAllEvents(ctx, store.Filter{Types: []string{"task_completed"}}, 0, 10)
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 1 || events[0].Message != "Task completed in 1m0s" {
+		t.Fatalf("expected one task_completed event with the run time, got %+v", events)
+	}
+}
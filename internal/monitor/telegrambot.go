@@ -0,0 +1,258 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	telegramPollWait   = 30 * time.Second
+	telegramRetryDelay = 5 * time.Second
+	telegramAlertLimit = 10
+	defaultMute        = time.Hour
+)
+
+const telegramHelp = `Commands:
+/status - containers that need attention
+/alerts - the latest alerts
+/mute <container> [1h] - hold its Telegram alerts for a while
+/unmute <container> - send its alerts again
+/restart <container> - restart it through Docker`
+
+// mutes holds the containers whose Telegram notifications were muted from
+// chat, until when. Mutes live in memory and end when healthmon restarts.
+type mutes struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newMutes() *mutes {
+	return &mutes{until: make(map[string]time.Time)}
+}
+
+func (s *mutes) mute(name string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.until[name] = until
+}
+
+// unmute ends a mute and reports whether there was one.
+func (s *mutes) unmute(name string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.until[name]
+	delete(s.until, name)
+	return ok && now.Before(until)
+}
+
+// muted returns until when a container is muted, if it is.
+func (s *mutes) muted(name string, now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.until[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !now.Before(until) {
+		delete(s.until, name)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// watchTelegramCommands long-polls the bot for commands when
+// HM_TG_COMMANDS is set and answers them in the chat they came from.
+// Messages from chats outside HM_TG_ALLOWED_CHATS are ignored.
+func (m *Monitor) watchTelegramCommands(ctx context.Context) {
+	if m.telegram == nil || !m.cfg.TelegramCommands {
+		return
+	}
+	var offset int64
+	for {
+		messages, err := m.telegram.Updates(ctx, offset, telegramPollWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("telegram updates failed: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+		for _, msg := range messages {
+			offset = msg.UpdateID + 1
+			if msg.Text == "" {
+				continue
+			}
+			chatID := strconv.FormatInt(msg.ChatID, 10)
+			if !slices.Contains(m.cfg.TelegramAllowedChats, chatID) {
+				log.Printf("ignoring telegram message from chat %s, which is not in HM_TG_ALLOWED_CHATS", chatID)
+				continue
+			}
+			var reply string
+			m.guard(ctx, "telegram command", func() { reply = m.telegramCommand(ctx, msg.Text, msg.From) })
+			if reply == "" {
+				continue
+			}
+			if err := m.telegram.SendTo(ctx, chatID, reply); err != nil {
+				log.Printf("telegram reply failed: %v", err)
+			}
+		}
+	}
+}
+
+// telegramCommand runs one chat command sent by from and returns the reply.
+// Text that is not a command gets no reply.
+func (m *Monitor) telegramCommand(ctx context.Context, text, from string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+	// In groups commands may be addressed as /status@healthmon_bot.
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	args := fields[1:]
+
+	switch command {
+	case "/status":
+		return m.telegramStatus(ctx)
+	case "/alerts":
+		return m.telegramAlerts(ctx)
+	case "/mute":
+		if len(args) == 0 || len(args) > 2 {
+			return "Usage: /mute <container> [duration], e.g. /mute web 1h"
+		}
+		c, ok := m.store.GetContainer(args[0])
+		if !ok {
+			return fmt.Sprintf("Unknown container %s", args[0])
+		}
+		d := defaultMute
+		if len(args) == 2 {
+			parsed, err := time.ParseDuration(args[1])
+			if err != nil || parsed <= 0 {
+				return fmt.Sprintf("Invalid duration %q, expected e.g. 30m or 2h", args[1])
+			}
+			d = parsed
+		}
+		until := m.clock.Now().Add(d)
+		m.mutes.mute(c.Name, until)
+		return fmt.Sprintf("Muted %s until %s", c.Name, until.In(time.Local).Format("Jan 2 15:04"))
+	case "/unmute":
+		if len(args) != 1 {
+			return "Usage: /unmute <container>"
+		}
+		if !m.mutes.unmute(args[0], m.clock.Now()) {
+			return fmt.Sprintf("%s is not muted", args[0])
+		}
+		return fmt.Sprintf("Unmuted %s", args[0])
+	case "/restart":
+		if len(args) != 1 {
+			return "Usage: /restart <container>"
+		}
+		return m.telegramRestart(ctx, args[0], from)
+	case "/start", "/help":
+		return telegramHelp
+	default:
+		return "Unknown command " + command + "\n\n" + telegramHelp
+	}
+}
+
+// telegramStatus summarizes the containers and lists the ones that need
+// attention.
+func (m *Monitor) telegramStatus(ctx context.Context) string {
+	containers := m.store.ListContainers()
+	sort.Slice(containers, func(i, j int) bool { return containers[i].Name < containers[j].Name })
+	byStatus := map[string]int{}
+	var unhealthy, looping []string
+	for _, c := range containers {
+		byStatus[c.Status]++
+		if strings.EqualFold(c.HealthStatus, "unhealthy") {
+			unhealthy = append(unhealthy, c.Name)
+		}
+		if c.RestartLoop {
+			looping = append(looping, c.Name)
+		}
+	}
+	statuses := make([]string, 0, len(byStatus))
+	for status, n := range byStatus {
+		statuses = append(statuses, fmt.Sprintf("%d %s", n, status))
+	}
+	sort.Strings(statuses)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d containers", len(containers))
+	if len(statuses) > 0 {
+		b.WriteString(": " + strings.Join(statuses, ", "))
+	}
+	if len(unhealthy) > 0 {
+		b.WriteString("\nUnhealthy: " + strings.Join(unhealthy, ", "))
+	}
+	if len(looping) > 0 {
+		b.WriteString("\nRestart loop: " + strings.Join(looping, ", "))
+	}
+	now := m.clock.Now()
+	for _, c := range containers {
+		if until, ok := m.mutes.muted(c.Name, now); ok {
+			fmt.Fprintf(&b, "\nMuted: %s until %s", c.Name, until.In(time.Local).Format("Jan 2 15:04"))
+		}
+	}
+	if unacked, err := m.store.CountAlerts(ctx, store.Filter{Unacknowledged: true}); err == nil {
+		fmt.Fprintf(&b, "\n%d unacknowledged alerts", unacked)
+	}
+	if status := m.Status(); !status.EventStreamConnected {
+		b.WriteString("\nDocker is not connected")
+		if status.DockerError != "" {
+			b.WriteString(": " + status.DockerError)
+		}
+	}
+	return b.String()
+}
+
+// telegramAlerts lists the latest alerts, newest first.
+func (m *Monitor) telegramAlerts(ctx context.Context) string {
+	alerts, err := m.store.ListAllAlerts(ctx, store.Filter{}, 0, telegramAlertLimit)
+	if err != nil {
+		return fmt.Sprintf("Listing alerts failed: %v", err)
+	}
+	if len(alerts) == 0 {
+		return "No alerts"
+	}
+	lines := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		lines = append(lines, fmt.Sprintf("%s [%s] %s: %s", a.Timestamp.In(time.Local).Format("Jan 2 15:04"), strings.ToUpper(a.Severity), a.Container, probeSummary(a.Message)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// telegramRestart restarts a container on behalf of a chat user and records
+// a manual_restart event. The restart's own events are self-inflicted.
+func (m *Monitor) telegramRestart(ctx context.Context, name, from string) string {
+	c, ok := m.store.GetContainer(name)
+	if !ok || !c.Present || c.ContainerID == "" {
+		return fmt.Sprintf("Unknown container %s", name)
+	}
+	if m.docker == nil {
+		return "Docker is not connected"
+	}
+	if err := m.restartContainer(ctx, c, "manual_restart"); err != nil {
+		log.Printf("restart of %s from telegram failed: %v", c.Name, err)
+		return fmt.Sprintf("Restart of %s failed: %v", c.Name, err)
+	}
+	message := "Restarted from Telegram"
+	if from != "" {
+		message += " by @" + from
+	}
+	m.emitInfo(ctx, c.Name, c.ContainerID, "", "manual_restart", message, "", "", "", "", "telegram", nil)
+	return fmt.Sprintf("Restarted %s", c.Name)
+}
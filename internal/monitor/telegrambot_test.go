@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestTelegramCommands(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	now := fake.Now()
+	for _, c := range []store.Container{
+		{Name: "web", ContainerID: "cid-web", Status: "running", HealthStatus: "unhealthy", Present: true, UpdatedAt: now},
+		{Name: "worker", ContainerID: "cid-worker", Status: "restarting", RestartLoop: true, Present: true, UpdatedAt: now},
+		{Name: "db", ContainerID: "cid-db", Status: "running", Present: true, UpdatedAt: now},
+	} {
+		if err := st.UpsertContainer(ctx, c); err != nil {
+			t.Fatalf("upsert container: %v", err)
+		}
+	}
+
+	mon := New(config.Config{}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+	mon.emitAlert(ctx, "worker", "cid-worker", "", "restart_loop", "Restart loop detected", "red", nil)
+
+	status := mon.telegramCommand(ctx, "/status@healthmon_bot", "")
+	for _, want := range []string{"3 containers: 1 restarting, 2 running", "Unhealthy: web", "Restart loop: worker", "1 unacknowledged alerts"} {
+		if !strings.Contains(status, want) {
+			t.Fatalf("expected status to contain %q, got:\n%s", want, status)
+		}
+	}
+	if alerts := mon.telegramCommand(ctx, "/alerts", ""); !strings.Contains(alerts, "[RED] worker: Restart loop detected") {
+		t.Fatalf("unexpected alerts reply:\n%s", alerts)
+	}
+
+	if reply := mon.telegramCommand(ctx, "/mute web 2h", ""); !strings.HasPrefix(reply, "Muted web until ") {
+		t.Fatalf("unexpected mute reply %q", reply)
+	}
+	if _, ok := mon.mutes.muted("web", fake.Now()); !ok {
+		t.Fatalf("expected web to be muted")
+	}
+	if reply := mon.telegramCommand(ctx, "/mute web soon", ""); !strings.HasPrefix(reply, "Invalid duration") {
+		t.Fatalf("unexpected reply to a bad duration %q", reply)
+	}
+	if reply := mon.telegramCommand(ctx, "/mute nope", ""); reply != "Unknown container nope" {
+		t.Fatalf("unexpected reply for an unknown container %q", reply)
+	}
+	fake.Advance(3 * time.Hour)
+	if _, ok := mon.mutes.muted("web", fake.Now()); ok {
+		t.Fatalf("expected the mute to expire")
+	}
+	mon.telegramCommand(ctx, "/mute db", "")
+	if reply := mon.telegramCommand(ctx, "/unmute db", ""); reply != "Unmuted db" {
+		t.Fatalf("unexpected unmute reply %q", reply)
+	}
+	if reply := mon.telegramCommand(ctx, "/unmute db", ""); reply != "db is not muted" {
+		t.Fatalf("unexpected second unmute reply %q", reply)
+	}
+
+	if reply := mon.telegramCommand(ctx, "/restart web", "alice"); reply != "Docker is not connected" {
+		t.Fatalf("unexpected restart reply without docker %q", reply)
+	}
+	if reply := mon.telegramCommand(ctx, "/frobnicate", ""); !strings.HasPrefix(reply, "Unknown command /frobnicate") {
+		t.Fatalf("unexpected reply to an unknown command %q", reply)
+	}
+	if reply := mon.telegramCommand(ctx, "thanks!", ""); reply != "" {
+		t.Fatalf("expected plain messages to be ignored, got %q", reply)
+	}
+}
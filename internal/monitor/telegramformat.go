@@ -0,0 +1,236 @@
+package monitor
+
+import (
+	"context"
+	"html"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"healthmon/internal/notify"
+	"healthmon/internal/store"
+)
+
+// telegramThreads maps an alert type to the alerts it follows up on. Its
+// Telegram message replies to the message of the latest of them, so a
+// recovery shows up under the alert it resolves.
+var telegramThreads = map[string][]string{
+	"restart_healed":      {"restart_loop"},
+	"healthy":             {"unhealthy"},
+	"task_recovered":      {"task_failed", "task_overdue"},
+	"heartbeat_recovered": {"heartbeat_missed"},
+	"docker_reconnected":  {"docker_disconnected"},
+	"unit_recovered":      {"unit_failed"},
+	"memory_recovered":    {"memory_pressure"},
+	"pids_recovered":      {"pids_pressure"},
+	"fd_recovered":        {"fd_pressure"},
+	"clock_synced":        {"clock_drift"},
+	"ntp_synchronized":    {"ntp_unsynchronized"},
+	"container_unstuck":   {"container_stuck"},
+	"pause_ended":         {"paused_too_long"},
+	"network_restored":    {"network_lost"},
+	"container_returned":  {"container_missing"},
+	"restart_storm_ended": {"restart_storm"},
+}
+
+// telegramFormat is the parse mode of alert messages: "" for plain text,
+// "HTML" or "MarkdownV2".
+type telegramFormat string
+
+// parseTelegramFormat reads HM_TG_FORMAT.
+func parseTelegramFormat(value string) telegramFormat {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "text":
+		return ""
+	case "html":
+		return "HTML"
+	case "markdown", "markdownv2":
+		return "MarkdownV2"
+	default:
+		log.Printf("invalid HM_TG_FORMAT %q, expected text, html or markdown; sending plain text", value)
+		return ""
+	}
+}
+
+// markdownSpecial are the characters MarkdownV2 requires to be escaped
+// outside of entities.
+const markdownSpecial = "_*[]()~`>#+-=|{}.!\\"
+
+func (f telegramFormat) escape(s string) string {
+	switch f {
+	case "HTML":
+		return html.EscapeString(s)
+	case "MarkdownV2":
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune(markdownSpecial, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	default:
+		return s
+	}
+}
+
+func (f telegramFormat) bold(s string) string {
+	switch f {
+	case "HTML":
+		return "<b>" + f.escape(s) + "</b>"
+	case "MarkdownV2":
+		return "*" + f.escape(s) + "*"
+	default:
+		return s
+	}
+}
+
+func (f telegramFormat) code(s string) string {
+	switch f {
+	case "HTML":
+		return "<code>" + f.escape(s) + "</code>"
+	case "MarkdownV2":
+		return "`" + escapeMarkdownCode(s) + "`"
+	default:
+		return s
+	}
+}
+
+func (f telegramFormat) pre(s string) string {
+	switch f {
+	case "HTML":
+		return "<pre>" + f.escape(s) + "</pre>"
+	case "MarkdownV2":
+		return "```\n" + escapeMarkdownCode(s) + "\n```"
+	default:
+		return s
+	}
+}
+
+// link renders a link, or the bare URL in plain text.
+func (f telegramFormat) link(text, target string) string {
+	switch f {
+	case "HTML":
+		return `<a href="` + html.EscapeString(target) + `">` + f.escape(text) + "</a>"
+	case "MarkdownV2":
+		return "[" + f.escape(text) + "](" + strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace(target) + ")"
+	default:
+		return target
+	}
+}
+
+// escapeMarkdownCode escapes the characters MarkdownV2 treats specially
+// inside code entities.
+func escapeMarkdownCode(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(s)
+}
+
+// telegramPrefix tags an alert's message with its severity and group, like
+// "[RED] [media]".
+func (m *Monitor) telegramPrefix(a store.Alert) string {
+	prefix := "[" + strings.ToUpper(a.Severity) + "]"
+	if group := m.containerGroup(a.Container); group != "" {
+		prefix += " [" + group + "]"
+	}
+	return prefix
+}
+
+// telegramText formats an alert with its details for Telegram.
+func (m *Monitor) telegramText(a store.Alert, f telegramFormat) string {
+	return f.bold(m.telegramPrefix(a)+" "+a.Container) + f.escape(": "+a.Message) + m.alertExtras(a, f)
+}
+
+// alertExtras renders the lines that follow an alert's message in a
+// notification: the container's image, the exit code, the crash report, the
+// release notes of an updated image and a link to the container in the
+// dashboard.
+func (m *Monitor) alertExtras(a store.Alert, f telegramFormat) string {
+	var b strings.Builder
+	if c, ok := m.store.GetContainer(a.Container); ok && c.Image != "" && a.Container != selfContainerName {
+		b.WriteString("\n" + f.escape("Image: ") + f.code(c.Image))
+	}
+	if a.ExitCode != nil {
+		b.WriteString("\n" + f.escape("Exit code: ") + f.code(strconv.Itoa(*a.ExitCode)))
+	}
+	if a.Type == "restart_loop" {
+		b.WriteString(crashSummary(a, f))
+	}
+	if a.Type == "image_changed" {
+		if details, err := store.DecodeDetails(a.DetailsJSON); err == nil {
+			if d, ok := details.(*store.ImageUpdateDetails); ok && d.ChangelogURL != "" {
+				b.WriteString("\n" + f.escape("Changelog: ") + f.link(d.ChangelogURL, d.ChangelogURL))
+			}
+		}
+	}
+	if n, ok := m.store.ContainerNotes(a.Container); ok {
+		if n.Owner != "" {
+			b.WriteString("\n" + f.escape("Owner: "+n.Owner))
+		}
+		if n.RunbookURL != "" {
+			b.WriteString("\n" + f.escape("Runbook: ") + f.link(n.RunbookURL, n.RunbookURL))
+		}
+		if n.Notes != "" {
+			b.WriteString("\n" + f.escape("Notes: "+n.Notes))
+		}
+	}
+	if base := strings.TrimRight(m.cfg.PublicURL, "/"); base != "" {
+		b.WriteString("\n" + f.link("Open in healthmon", base+"/?container="+url.QueryEscape(a.Container)))
+	}
+	return b.String()
+}
+
+// telegramReplyTo returns the Telegram message of the alert a follows up
+// on, or zero.
+func (m *Monitor) telegramReplyTo(ctx context.Context, a store.Alert) int64 {
+	parents, ok := telegramThreads[a.Type]
+	if !ok || a.ContainerPK == 0 {
+		return 0
+	}
+	parent, found, err := m.store.GetLatestAlertByContainerPK(ctx, a.ContainerPK, parents...)
+	if err != nil {
+		log.Printf("telegram thread lookup failed for %s: %v", a.Container, err)
+		return 0
+	}
+	if !found {
+		return 0
+	}
+	return parent.TelegramMessageID
+}
+
+// sendTelegramAlert sends a formatted alert and remembers its message, so
+// follow-ups can reply to it.
+func (m *Monitor) sendTelegramAlert(ctx context.Context, a store.Alert) error {
+	messageID, err := m.telegram.SendText(ctx, notify.TelegramText{
+		Text:      m.telegramText(a, m.tgFormat),
+		ParseMode: string(m.tgFormat),
+		ReplyTo:   m.telegramReplyTo(ctx, a),
+	})
+	if err != nil {
+		return err
+	}
+	if messageID != 0 && a.ID != 0 {
+		if err := m.store.SetAlertTelegramMessage(ctx, a.ID, messageID); err != nil {
+			log.Printf("telegram message id persist failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// telegramNotifier sends alerts as formatted Telegram messages, threaded
+// under the alerts they follow up on.
+type telegramNotifier struct {
+	m *Monitor
+}
+
+func (t telegramNotifier) Name() string {
+	return "Telegram"
+}
+
+func (t telegramNotifier) Send(ctx context.Context, a notify.Alert) error {
+	m := t.m
+	if a.Type == notify.DigestType {
+		return m.telegram.Send(ctx, a.Title+"\n"+a.Body)
+	}
+	return m.sendTelegramAlert(ctx, a.Alert)
+}
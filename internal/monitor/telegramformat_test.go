@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+)
+
+func TestTelegramFormatsEscapeText(t *testing.T) {
+	for _, tc := range []struct {
+		format telegramFormat
+		want   string
+	}{
+		{"", "[RED] a<b>_c: x.y\nhttps://hm.example/?container=a"},
+		{"HTML", "<b>[RED] a&lt;b&gt;_c</b>: x.y\n<a href=\"https://hm.example/?container=a\">Open in healthmon</a>"},
+		{"MarkdownV2", "*\\[RED\\] a<b\\>\\_c*: x\\.y\n[Open in healthmon](https://hm.example/?container=a)"},
+	} {
+		got := tc.format.bold("[RED] a<b>_c") + tc.format.escape(": x.y") + "\n" + tc.format.link("Open in healthmon", "https://hm.example/?container=a")
+		if got != tc.want {
+			t.Fatalf("format %q: got %q, want %q", tc.format, got, tc.want)
+		}
+	}
+	if got := telegramFormat("MarkdownV2").pre("a`b\\c"); got != "```\na\\`b\\\\c\n```" {
+		t.Fatalf("unexpected markdown code block %q", got)
+	}
+	if parseTelegramFormat("html") != "HTML" || parseTelegramFormat("markdown") != "MarkdownV2" || parseTelegramFormat("bogus") != "" {
+		t.Fatalf("unexpected parsed formats")
+	}
+}
+
+func TestTelegramAlertTextAndThread(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	if err := st.UpsertContainer(ctx, store.Container{Name: "web", ContainerID: "cid-web", Image: "nginx:1.27", Status: "running", Present: true, UpdatedAt: fake.Now()}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	web, _ := st.GetContainer("web")
+
+	mon := New(config.Config{PublicURL: "https://hm.example/"}, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+
+	exitCode := 137
+	loop := store.Alert{ContainerPK: web.ID, Container: "web", Type: "restart_loop", Severity: "red", Message: "Restart loop detected", Timestamp: fake.Now(), ExitCode: &exitCode}
+	want := "<b>[RED] web</b>: Restart loop detected\nImage: <code>nginx:1.27</code>\nExit code: <code>137</code>\n<a href=\"https://hm.example/?container=web\">Open in healthmon</a>"
+	if got := mon.telegramText(loop, "HTML"); got != want {
+		t.Fatalf("unexpected alert text:\n%s", got)
+	}
+
+	loop.ID, err = st.AddAlert(ctx, loop)
+	if err != nil {
+		t.Fatalf("add alert: %v", err)
+	}
+	healed := store.Alert{ContainerPK: web.ID, Container: "web", Type: "restart_healed", Severity: "green", Message: "Restart loop healed"}
+	if id := mon.telegramReplyTo(ctx, healed); id != 0 {
+		t.Fatalf("expected no thread before the loop was sent, got %d", id)
+	}
+	if err := st.SetAlertTelegramMessage(ctx, loop.ID, 4242); err != nil {
+		t.Fatalf("set telegram message: %v", err)
+	}
+	if id := mon.telegramReplyTo(ctx, healed); id != 4242 {
+		t.Fatalf("expected restart_healed to reply to message 4242, got %d", id)
+	}
+	if id := mon.telegramReplyTo(ctx, store.Alert{ContainerPK: web.ID, Type: "image_changed"}); id != 0 {
+		t.Fatalf("expected unrelated alerts not to be threaded, got %d", id)
+	}
+
+	if _, err := st.SetContainerNotes(ctx, store.ContainerNotes{Container: "web", Owner: "team-shop", RunbookURL: "https://wiki.example/web"}); err != nil {
+		t.Fatalf("set notes: %v", err)
+	}
+	want = "Restart loop healed\nImage: nginx:1.27\nOwner: team-shop\nRunbook: https://wiki.example/web\nhttps://hm.example/?container=web"
+	if got := mon.notifyAlert(healed).Body; got != want {
+		t.Fatalf("unexpected alert body:\n%s", got)
+	}
+}
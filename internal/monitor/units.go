@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"healthmon/internal/store"
+	"healthmon/internal/systemd"
+)
+
+// unitRole marks the pseudo-containers that stand for systemd units.
+const unitRole = "unit"
+
+const (
+	unitActiveStateLabel = "systemd.active_state"
+	unitSubStateLabel    = "systemd.sub_state"
+	unitDescriptionLabel = "systemd.description"
+
+	// maxUnitRestartEvents caps the restart events recorded for one poll,
+	// when a unit restarted many times in between.
+	maxUnitRestartEvents = 10
+)
+
+// unitReader reads the state of a systemd unit; systemd.Client in
+// production.
+type unitReader interface {
+	Unit(name string) (systemd.Unit, error)
+}
+
+// unitWatch is the state of the HM_SYSTEMD_UNITS poller. It is only used
+// by the poller's goroutine.
+type unitWatch struct {
+	reader unitReader
+	// restarts is the NRestarts each unit had at the last poll. Restarts
+	// while healthmon was down are not recorded: the count is taken as is
+	// on the first poll.
+	restarts map[string]int
+	lastErr  string
+}
+
+// watchUnits polls the units in HM_SYSTEMD_UNITS and keeps them as
+// pseudo-containers with role "unit", so they share the containers'
+// events, alerts and restart loop detection.
+func (m *Monitor) watchUnits(ctx context.Context) {
+	if len(m.cfg.SystemdUnits) == 0 {
+		return
+	}
+	client := systemd.New(m.cfg.SystemdBus)
+	defer client.Close()
+	w := &unitWatch{reader: client, restarts: make(map[string]int)}
+
+	interval := time.Duration(m.cfg.SystemdPollSeconds) * time.Second
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := m.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	m.guard(ctx, "systemd units", func() { m.pollUnits(ctx, w) })
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.guard(ctx, "systemd units", func() { m.pollUnits(ctx, w) })
+		}
+	}
+}
+
+func (m *Monitor) pollUnits(ctx context.Context, w *unitWatch) {
+	for _, name := range m.cfg.SystemdUnits {
+		u, err := w.reader.Unit(name)
+		if err != nil {
+			// A missing bus fails every poll; say so once.
+			if msg := err.Error(); msg != w.lastErr {
+				log.Printf("systemd unit %s: %v", name, err)
+				w.lastErr = msg
+			}
+			continue
+		}
+		w.lastErr = ""
+		m.applyUnit(ctx, w, u)
+	}
+}
+
+// unitStatus maps a unit's active state to the container statuses the UI
+// and API know.
+func unitStatus(activeState string) string {
+	switch activeState {
+	case "active", "reloading":
+		return "running"
+	case "activating":
+		return "restarting"
+	default:
+		return "exited"
+	}
+}
+
+// applyUnit stores a unit's state and records what changed since the last
+// poll: an event for every active state change and restart, unit_failed
+// and unit_recovered alerts, and restart_loop once the restarts cross
+// HM_RESTART_THRESHOLD within HM_RESTART_WINDOW_SECONDS.
+func (m *Monitor) applyUnit(ctx context.Context, w *unitWatch, u systemd.Unit) {
+	now := m.clock.Now()
+	c, known := m.store.GetContainer(u.Name)
+	if known && c.Role != unitRole {
+		log.Printf("systemd unit %s has the name of a container and is not monitored", u.Name)
+		return
+	}
+	if u.LoadState == "not-found" {
+		if !known {
+			log.Printf("systemd unit %s does not exist", u.Name)
+		}
+		if known && c.Present {
+			log.Printf("systemd unit %s no longer exists", u.Name)
+			if err := m.store.SetContainerPresent(ctx, u.Name, false); err != nil {
+				log.Printf("systemd unit %s: %v", u.Name, err)
+			}
+		}
+		return
+	}
+	if !known {
+		c = store.Container{
+			Name:         u.Name,
+			Image:        "systemd",
+			Role:         unitRole,
+			Caps:         []string{},
+			CreatedAt:    now,
+			RegisteredAt: now,
+		}
+	}
+	prevState := c.Labels[unitActiveStateLabel]
+	c.Labels = map[string]string{
+		unitActiveStateLabel: u.ActiveState,
+		unitSubStateLabel:    u.SubState,
+		unitDescriptionLabel: u.Description,
+	}
+	c.Status = unitStatus(u.ActiveState)
+	c.Present = true
+	if !u.ActiveSince.IsZero() {
+		c.StartedAt = u.ActiveSince
+	} else if c.StartedAt.IsZero() {
+		c.StartedAt = now
+	}
+	c.FinishedAt = u.InactiveSince
+	c.ExitCode = nil
+	if u.ActiveState == "failed" {
+		code := u.ExitStatus
+		c.ExitCode = &code
+	}
+
+	last, seen := w.restarts[u.Name]
+	w.restarts[u.Name] = u.Restarts
+	restarts := 0
+	if seen && u.Restarts > last {
+		restarts = u.Restarts - last
+	}
+	key := restartTrackerKey("", u.Name)
+	wasInLoop := c.RestartLoop
+	streak := 0
+	for i := 0; i < restarts; i++ {
+		streak, _ = m.restarts.record(key, now)
+	}
+	if restarts > 0 && m.restarts.inLoop(key) {
+		if !c.RestartLoop {
+			c.RestartLoopSince = now
+		}
+		c.RestartLoop = true
+		c.RestartStreak = max(c.RestartStreak+restarts, streak)
+	}
+	c.UpdatedAt = now
+	if err := m.store.UpsertContainer(ctx, c); err != nil {
+		log.Printf("systemd unit %s: %v", u.Name, err)
+		return
+	}
+
+	for i := 0; i < min(restarts, maxUnitRestartEvents); i++ {
+		m.emitInfo(ctx, u.Name, "", "", "restart", fmt.Sprintf("Restart event: systemd restarted the unit (%d restarts in total)", u.Restarts), "", "", "", "", "systemd", nil)
+	}
+	if known && prevState != "" && prevState != u.ActiveState {
+		m.emitInfo(ctx, u.Name, "", "", "unit_state", fmt.Sprintf("Unit %s (%s)", u.ActiveState, u.SubState), "", "", "", "", u.ActiveState, c.ExitCode)
+		switch {
+		case u.ActiveState == "failed":
+			m.emitAlert(ctx, u.Name, "", "", "unit_failed", fmt.Sprintf("Unit failed with exit status %d", u.ExitStatus), "red", c.ExitCode)
+		case prevState == "failed" && u.ActiveState == "active":
+			m.emitAlert(ctx, u.Name, "", "", "unit_recovered", "Unit active again", "green", nil)
+		}
+	}
+	if c.RestartLoop && !wasInLoop {
+		m.emitAlertRecord(ctx, store.Alert{
+			Container:   u.Name,
+			Type:        "restart_loop",
+			Severity:    "red",
+			Message:     "Restart loop detected",
+			Timestamp:   now,
+			DetailsJSON: store.EncodeDetails(store.RestartDetails{RestartCount: c.RestartStreak, ExitCodes: []int{u.ExitStatus}}),
+		})
+	}
+}
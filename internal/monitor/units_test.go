@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+	"healthmon/internal/clock"
+	"healthmon/internal/config"
+	"healthmon/internal/db"
+	"healthmon/internal/store"
+	"healthmon/internal/systemd"
+)
+
+type fakeUnits map[string]systemd.Unit
+
+func (f fakeUnits) Unit(name string) (systemd.Unit, error) {
+	return f[name], nil
+}
+
+func TestSystemdUnitsFeedContainerModel(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := store.New(dbConn.SQL)
+	st.WithClock(fake)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	cfg := config.Config{SystemdUnits: []string{"nginx.service"}, RestartWindowSeconds: 300, RestartThreshold: 3}
+	mon := New(cfg, st, api.NewServer(st, api.NewBroadcaster(), api.WSOptions{}))
+	mon.WithClock(fake)
+
+	units := fakeUnits{}
+	w := &unitWatch{reader: units, restarts: make(map[string]int)}
+	poll := func(u systemd.Unit) {
+		u.Name = "nginx.service"
+		u.LoadState = "loaded"
+		units[u.Name] = u
+		fake.Advance(15 * time.Second)
+		mon.pollUnits(ctx, w)
+	}
+	alertTypes := func() []string {
+		alerts, err := st.ListAllAlerts(ctx, store.Filter{Ascending: true}, 0, 50)
+		if err != nil {
+			t.Fatalf("list alerts: %v", err)
+		}
+		types := []string{}
+		for _, a := range alerts {
+			types = append(types, a.Type)
+		}
+		return types
+	}
+
+	// Restarts before the first poll are taken as they are.
+	poll(systemd.Unit{ActiveState: "active", SubState: "running", Restarts: 4, ActiveSince: fake.Now()})
+	c, ok := st.GetContainer("nginx.service")
+	if !ok || c.Role != unitRole || c.Status != "running" || !c.Present || c.Labels[unitSubStateLabel] != "running" {
+		t.Fatalf("unexpected unit container %+v", c)
+	}
+	if got := alertTypes(); len(got) != 0 {
+		t.Fatalf("expected no alerts on first sight, got %v", got)
+	}
+
+	poll(systemd.Unit{ActiveState: "active", SubState: "running", Restarts: 7, ExitStatus: 1})
+	c, _ = st.GetContainer("nginx.service")
+	if !c.RestartLoop || c.RestartStreak != 3 {
+		t.Fatalf("expected a restart loop after three restarts, got %+v", c)
+	}
+	restarts, err := st.RestartTimestampsSince(ctx, c.ID, time.Time{})
+	if err != nil || len(restarts) != 3 {
+		t.Fatalf("expected three restart events, got %d (%v)", len(restarts), err)
+	}
+
+	poll(systemd.Unit{ActiveState: "failed", SubState: "failed", Restarts: 7, ExitStatus: 203})
+	c, _ = st.GetContainer("nginx.service")
+	if c.Status != "exited" || c.ExitCode == nil || *c.ExitCode != 203 {
+		t.Fatalf("expected the failed unit to be exited with its status, got %+v", c)
+	}
+	poll(systemd.Unit{ActiveState: "active", SubState: "running", Restarts: 7})
+
+	want := []string{"restart_loop", "unit_failed", "unit_recovered"}
+	if got := alertTypes(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("expected alerts %v, got %v", want, got)
+	}
+
+	units["nginx.service"] = systemd.Unit{Name: "nginx.service", LoadState: "not-found"}
+	mon.pollUnits(ctx, w)
+	if c, _ := st.GetContainer("nginx.service"); c.Present {
+		t.Fatalf("expected a removed unit to be absent")
+	}
+}
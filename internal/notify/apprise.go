@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Apprise posts notifications to an Apprise API server
+// (https://github.com/caronc/apprise-api), which fans them out to the
+// services configured there: Slack, Discord, ntfy, email and many more.
+type Apprise struct {
+	url    string
+	tag    string
+	client *http.Client
+}
+
+// Notification is one message for Apprise. Type is one of info, success,
+// warning and failure; Apprise picks icons and colors from it.
+type Notification struct {
+	Title string
+	Body  string
+	Type  string
+}
+
+type apprisePayload struct {
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// NewApprise returns nil when url is empty. url is the notify endpoint of a
+// stored configuration, e.g. http://apprise:8000/notify/healthmon. When tag
+// is set only the services with that tag are notified.
+func NewApprise(url, tag string) *Apprise {
+	if url == "" {
+		return nil
+	}
+	return &Apprise{url: url, tag: tag, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// appriseTypes maps severities to Apprise notification types.
+var appriseTypes = map[string]string{
+	"red":    "failure",
+	"yellow": "warning",
+	"green":  "success",
+	"blue":   "info",
+}
+
+func (a *Apprise) Name() string {
+	return "Apprise"
+}
+
+// Send posts the plain-text rendering of an alert, typed by its severity.
+func (a *Apprise) Send(ctx context.Context, alert Alert) error {
+	notifyType, ok := appriseTypes[alert.Severity]
+	if !ok {
+		notifyType = "info"
+	}
+	return a.Notify(ctx, Notification{Title: alert.Title, Body: alert.Body, Type: notifyType})
+}
+
+func (a *Apprise) Notify(ctx context.Context, n Notification) error {
+	if a == nil {
+		return nil
+	}
+	buf, err := json.Marshal(apprisePayload{Title: n.Title, Body: n.Body, Type: n.Type, Format: "text", Tag: a.tag})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("apprise status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppriseNotifyPostsToNotifyEndpoint(t *testing.T) {
+	var got apprisePayload
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	a := NewApprise(srv.URL+"/notify/healthmon", "ops")
+	if err := a.Notify(context.Background(), Notification{Title: "[RED] nginx", Body: "Restart loop detected", Type: "failure"}); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	if path != "/notify/healthmon" {
+		t.Fatalf("unexpected request to %s", path)
+	}
+	if got != (apprisePayload{Title: "[RED] nginx", Body: "Restart loop detected", Type: "failure", Format: "text", Tag: "ops"}) {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+}
+
+func TestAppriseReportsFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFailedDependency)
+	}))
+	defer srv.Close()
+
+	if err := NewApprise(srv.URL, "").Notify(context.Background(), Notification{Title: "x"}); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if NewApprise("", "") != nil {
+		t.Fatalf("expected nil notifier without url")
+	}
+}
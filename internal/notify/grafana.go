@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Grafana posts annotations through Grafana's HTTP API so container incidents
+// show up over metric graphs.
+type Grafana struct {
+	url          string
+	token        string
+	dashboardUID string
+	tags         []string
+	client       *http.Client
+}
+
+// Annotation is a point in time marked on Grafana graphs.
+type Annotation struct {
+	Time time.Time
+	Text string
+	Tags []string
+}
+
+type grafanaPayload struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// NewGrafana returns nil when url is empty. Without a dashboard UID the
+// annotations are organization wide and show up on every dashboard with an
+// annotation query matching their tags. tags are added to every annotation.
+func NewGrafana(url, token, dashboardUID string, tags []string) *Grafana {
+	if url == "" {
+		return nil
+	}
+	return &Grafana{
+		url:          strings.TrimSuffix(url, "/"),
+		token:        token,
+		dashboardUID: dashboardUID,
+		tags:         tags,
+		client:       &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *Grafana) Name() string {
+	return "Grafana"
+}
+
+// Send marks an alert on the graphs, tagged with its container, type,
+// severity and group.
+func (g *Grafana) Send(ctx context.Context, a Alert) error {
+	annotation := Annotation{
+		Time: a.Timestamp,
+		Text: fmt.Sprintf("%s: %s", a.Container, a.Message),
+		Tags: []string{a.Container, a.Type, a.Severity},
+	}
+	if a.Group != "" {
+		annotation.Tags = append(annotation.Tags, "group:"+a.Group)
+	}
+	return g.Annotate(ctx, annotation)
+}
+
+func (g *Grafana) Annotate(ctx context.Context, a Annotation) error {
+	if g == nil {
+		return nil
+	}
+	tags := append(append([]string{}, g.tags...), a.Tags...)
+	payload := grafanaPayload{
+		DashboardUID: g.dashboardUID,
+		Time:         a.Time.UnixMilli(),
+		Tags:         tags,
+		Text:         a.Text,
+	}
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url+"/api/annotations", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("grafana status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGrafanaAnnotatePostsToAnnotationsAPI(t *testing.T) {
+	var got grafanaPayload
+	var auth, path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	g := NewGrafana(srv.URL+"/", "glsa_token", "dash-1", []string{"healthmon"})
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := g.Annotate(context.Background(), Annotation{Time: at, Text: "nginx: Restart loop detected", Tags: []string{"nginx", "restart_loop"}}); err != nil {
+		t.Fatalf("annotate: %v", err)
+	}
+
+	if path != "/api/annotations" || auth != "Bearer glsa_token" {
+		t.Fatalf("unexpected request to %s with auth %q", path, auth)
+	}
+	if got.DashboardUID != "dash-1" || got.Time != at.UnixMilli() || got.Text != "nginx: Restart loop detected" {
+		t.Fatalf("unexpected payload %+v", got)
+	}
+	if len(got.Tags) != 3 || got.Tags[0] != "healthmon" || got.Tags[1] != "nginx" || got.Tags[2] != "restart_loop" {
+		t.Fatalf("expected configured tags before annotation tags, got %v", got.Tags)
+	}
+}
+
+func TestNewGrafanaDisabledWithoutURL(t *testing.T) {
+	if g := NewGrafana("", "token", "", nil); g != nil {
+		t.Fatalf("expected nil notifier")
+	}
+	var g *Grafana
+	if err := g.Annotate(context.Background(), Annotation{}); err != nil {
+		t.Fatalf("nil notifier should be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/api"
+)
+
+const (
+	mqttKeepAlive = 60 * time.Second
+	mqttTimeout   = 5 * time.Second
+)
+
+// MQTT publishes container updates to an MQTT broker for Home Assistant and
+// other automation. It speaks just enough MQTT 3.1.1 for that: QoS 0
+// publishes, retained messages and a last will.
+//
+// Topics, under a configurable prefix:
+//
+//	{prefix}/status              "online" or "offline", retained
+//	{prefix}/{container}/state   the container as in /api/containers, retained
+//	{prefix}/{container}/event   every event
+//	{prefix}/{container}/alert   every alert
+type MQTT struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	clientID string
+	prefix   string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewMQTT returns nil when rawURL is empty. rawURL is mqtt://[user:pass@]host[:port]
+// or mqtts:// for TLS.
+func NewMQTT(rawURL, clientID, prefix string) (*MQTT, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := &MQTT{clientID: clientID, prefix: strings.Trim(prefix, "/")}
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		q.useTLS = true
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("unsupported mqtt scheme %q", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	q.addr = net.JoinHostPort(u.Hostname(), port)
+	if u.User != nil {
+		q.username = u.User.Username()
+		q.password, _ = u.User.Password()
+	}
+	return q, nil
+}
+
+// Run keeps the connection alive until ctx is done, then marks healthmon
+// offline and disconnects.
+func (q *MQTT) Run(ctx context.Context) {
+	if q == nil {
+		return
+	}
+	ticker := time.NewTicker(mqttKeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			q.close()
+			return
+		case <-ticker.C:
+			if err := q.write(ctx, []byte{0xc0, 0x00}); err != nil {
+				log.Printf("mqtt ping failed: %v", err)
+			}
+		}
+	}
+}
+
+// PublishUpdate publishes a container update as described on MQTT.
+func (q *MQTT) PublishUpdate(ctx context.Context, update api.EventUpdate) {
+	if q == nil || update.Container.Name == "" {
+		return
+	}
+	base := q.topic(update.Container.Name)
+	messages := []mqttMessage{{"state", update.Container, true}}
+	if update.Event != nil {
+		messages = append(messages, mqttMessage{"event", update.Event, false})
+	}
+	if update.Alert != nil {
+		messages = append(messages, mqttMessage{"alert", update.Alert, false})
+	}
+	for _, msg := range messages {
+		payload, err := json.Marshal(msg.value)
+		if err != nil {
+			continue
+		}
+		if err := q.Publish(ctx, base+"/"+msg.suffix, payload, msg.retain); err != nil {
+			log.Printf("mqtt publish failed: %v", err)
+			return
+		}
+	}
+}
+
+type mqttMessage struct {
+	suffix string
+	value  interface{}
+	retain bool
+}
+
+// topic returns the topic prefix of a container. MQTT wildcards and
+// separators in the name are replaced so each container gets one level.
+func (q *MQTT) topic(container string) string {
+	name := strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(container)
+	return q.prefix + "/" + name
+}
+
+func (q *MQTT) statusTopic() string {
+	return q.prefix + "/status"
+}
+
+// Publish sends a QoS 0 message, connecting first if needed. A broken
+// connection is re-established once before giving up.
+func (q *MQTT) Publish(ctx context.Context, topic string, payload []byte, retain bool) error {
+	if q == nil {
+		return nil
+	}
+	return q.write(ctx, publishPacket(topic, payload, retain))
+}
+
+func (q *MQTT) write(ctx context.Context, packet []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if q.conn == nil {
+			if err = q.connect(ctx); err != nil {
+				return err
+			}
+		}
+		_ = q.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+		if _, err = q.conn.Write(packet); err == nil {
+			return nil
+		}
+		q.conn.Close()
+		q.conn = nil
+	}
+	return err
+}
+
+// connect dials the broker and announces healthmon online. q.mu is held.
+func (q *MQTT) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	var err error
+	if q.useTLS {
+		host, _, _ := net.SplitHostPort(q.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", q.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", q.addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(mqttTimeout))
+	if _, err := conn.Write(q.connectPacket()); err != nil {
+		conn.Close()
+		return err
+	}
+	reader := bufio.NewReader(conn)
+	var ack [4]byte
+	if _, err := io.ReadFull(reader, ack[:]); err != nil {
+		conn.Close()
+		return err
+	}
+	if ack[0] != 0x20 || ack[3] != 0 {
+		conn.Close()
+		return fmt.Errorf("mqtt connection refused (code %d)", ack[3])
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(publishPacket(q.statusTopic(), []byte("online"), true)); err != nil {
+		conn.Close()
+		return err
+	}
+	q.conn = conn
+	go q.drain(conn, reader)
+	return nil
+}
+
+// drain reads and drops what the broker sends (ping responses), and notices
+// when it closes the connection.
+func (q *MQTT) drain(conn net.Conn, reader *bufio.Reader) {
+	_, _ = io.Copy(io.Discard, reader)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.conn == conn {
+		q.conn.Close()
+		q.conn = nil
+	}
+}
+
+func (q *MQTT) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.conn == nil {
+		return
+	}
+	_ = q.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+	_, _ = q.conn.Write(publishPacket(q.statusTopic(), []byte("offline"), true))
+	_, _ = q.conn.Write([]byte{0xe0, 0x00})
+	q.conn.Close()
+	q.conn = nil
+}
+
+func (q *MQTT) connectPacket() []byte {
+	// Clean session, with a retained "offline" will on the status topic.
+	flags := byte(0x02 | 0x04 | 0x20)
+	payload := mqttString(nil, q.clientID)
+	payload = mqttString(payload, q.statusTopic())
+	payload = mqttString(payload, "offline")
+	if q.username != "" {
+		flags |= 0x80
+		payload = mqttString(payload, q.username)
+		if q.password != "" {
+			flags |= 0x40
+			payload = mqttString(payload, q.password)
+		}
+	}
+	keepAlive := int(mqttKeepAlive / time.Second)
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, flags, byte(keepAlive>>8), byte(keepAlive))
+	return mqttPacket(0x10, append(body, payload...))
+}
+
+func publishPacket(topic string, payload []byte, retain bool) []byte {
+	header := byte(0x30)
+	if retain {
+		header |= 0x01
+	}
+	return mqttPacket(header, append(mqttString(nil, topic), payload...))
+}
+
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+func mqttString(buf []byte, s string) []byte {
+	return append(append(buf, byte(len(s)>>8), byte(len(s))), s...)
+}
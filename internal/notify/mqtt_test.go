@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"healthmon/internal/api"
+)
+
+type mqttPublish struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// readMQTTPacket reads one packet and returns its header byte and body.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+func TestMQTTPublishesRetainedStateAndAlerts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	connect := make(chan []byte, 1)
+	published := make(chan mqttPublish, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		_, body, err := readMQTTPacket(r)
+		if err != nil {
+			return
+		}
+		connect <- body
+		_, _ = conn.Write([]byte{0x20, 0x02, 0x00, 0x00})
+		for {
+			header, body, err := readMQTTPacket(r)
+			if err != nil {
+				return
+			}
+			if header&0xf0 != 0x30 {
+				continue
+			}
+			n := int(body[0])<<8 | int(body[1])
+			published <- mqttPublish{topic: string(body[2 : 2+n]), payload: string(body[2+n:]), retain: header&0x01 != 0}
+		}
+	}()
+
+	q, err := NewMQTT("mqtt://user:secret@"+ln.Addr().String(), "healthmon-test", "home/healthmon/")
+	if err != nil {
+		t.Fatalf("new mqtt: %v", err)
+	}
+	q.PublishUpdate(context.Background(), api.EventUpdate{
+		Container: api.ContainerResponse{Name: "web/app", Status: "running"},
+		Alert:     &api.AlertResponse{Type: "restart_loop", Message: "Restart loop detected"},
+	})
+
+	select {
+	case body := <-connect:
+		// Protocol name, level 4, then flags: username, password, retained will, clean session.
+		if string(body[2:6]) != "MQTT" || body[6] != 4 || body[7] != 0xe6 {
+			t.Fatalf("unexpected connect header % x", body[:10])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no connect")
+	}
+	want := []mqttPublish{
+		{"home/healthmon/status", "online", true},
+		{"home/healthmon/web_app/state", "", true},
+		{"home/healthmon/web_app/alert", "", false},
+	}
+	for _, w := range want {
+		select {
+		case got := <-published:
+			if got.topic != w.topic || got.retain != w.retain || (w.payload != "" && got.payload != w.payload) {
+				t.Fatalf("expected %+v, got %+v", w, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %s", w.topic)
+		}
+	}
+}
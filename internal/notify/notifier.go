@@ -0,0 +1,351 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/store"
+)
+
+// Alert is an alert on its way to the notifiers. Title and Body are its
+// plain-text rendering, for notifiers that do not format their own.
+type Alert struct {
+	store.Alert
+	Title string
+	Body  string
+	Group string
+}
+
+// Notifier delivers alerts to one channel.
+type Notifier interface {
+	// Name identifies the notifier in delivery statuses and logs.
+	Name() string
+	Send(ctx context.Context, a Alert) error
+}
+
+// ErrSkipped is returned by a Notifier that deliberately did not deliver an
+// alert, e.g. because its container is muted. Skipped alerts are not
+// retried.
+var ErrSkipped = errors.New("skipped")
+
+// StatusStore persists the delivery status of every alert per notifier.
+type StatusStore interface {
+	AddNotification(ctx context.Context, n store.Notification) (int64, error)
+	UpdateNotification(ctx context.Context, n store.Notification) error
+}
+
+const (
+	// firstRetry is the wait before the second attempt of a failed
+	// delivery. It doubles with every attempt, up to maxRetryDelay.
+	firstRetry    = time.Minute
+	maxRetryDelay = time.Hour
+	// DefaultRetryPeriod is how long a failed delivery is retried for.
+	DefaultRetryPeriod = 24 * time.Hour
+	// maxQueued bounds the retry queue of each notifier; when it is full the
+	// oldest delivery is given up on.
+	maxQueued = 1000
+	// retryCheck is how often Run looks for due retries.
+	retryCheck = 15 * time.Second
+)
+
+// Dispatcher fans alerts out to the registered notifiers. Every delivery
+// goes through a queue per notifier, worked off by its own goroutine in Run,
+// so a channel that is slow or down holds up neither the others nor the
+// caller. The first attempt is made as soon as that goroutine gets to it;
+// failed deliveries wait in the queue and are retried with exponential
+// backoff until the retry period is over.
+type Dispatcher struct {
+	status    StatusStore
+	clock     clock.Clock
+	period    time.Duration
+	onFailure func(ctx context.Context, notifier string, a Alert, err error)
+	onGiveUp  func(ctx context.Context, n store.Notification, a Alert)
+	skip      func(a Alert) bool
+
+	mu     sync.Mutex
+	queues []*retryQueue
+}
+
+type retryQueue struct {
+	notifier Notifier
+	// wake tells the notifier's goroutine that a delivery is due now.
+	wake chan struct{}
+	// quiet holds alerts during the notifier's quiet hours, when it has
+	// any.
+	quiet *quietQueue
+
+	mu      sync.Mutex
+	pending []delivery
+}
+
+type delivery struct {
+	alert  Alert
+	record store.Notification
+}
+
+// NewDispatcher returns a dispatcher without notifiers. status may be nil,
+// in which case delivery statuses are only logged.
+func NewDispatcher(status StatusStore) *Dispatcher {
+	return &Dispatcher{status: status, clock: clock.Real{}, period: DefaultRetryPeriod}
+}
+
+// WithRetryPeriod sets how long after the first attempt a failed delivery
+// is still retried. Zero or less disables retries.
+func (d *Dispatcher) WithRetryPeriod(period time.Duration) {
+	d.period = period
+}
+
+// WithClock replaces the clock used for timestamps and retry delays.
+func (d *Dispatcher) WithClock(c clock.Clock) {
+	d.clock = c
+}
+
+// OnFailure sets a function called when the first attempt of a delivery
+// fails, e.g. to report it somewhere else.
+func (d *Dispatcher) OnFailure(fn func(ctx context.Context, notifier string, a Alert, err error)) {
+	d.onFailure = fn
+}
+
+// OnGiveUp sets a function called when a delivery has failed for good.
+func (d *Dispatcher) OnGiveUp(fn func(ctx context.Context, n store.Notification, a Alert)) {
+	d.onGiveUp = fn
+}
+
+// SkipIf sets a function that tells which alerts are not sent at all, e.g.
+// those of muted containers. They are recorded as skipped for every notifier
+// and left out of the quiet hours digest.
+func (d *Dispatcher) SkipIf(fn func(a Alert) bool) {
+	d.skip = fn
+}
+
+// Register adds a notifier. Every alert dispatched afterwards is sent to it.
+func (d *Dispatcher) Register(n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queues = append(d.queues, &retryQueue{notifier: n, wake: make(chan struct{}, 1)})
+}
+
+// Notifiers returns the names of the registered notifiers.
+func (d *Dispatcher) Notifiers() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	names := make([]string, 0, len(d.queues))
+	for _, q := range d.queues {
+		names = append(names, q.notifier.Name())
+	}
+	return names
+}
+
+func (d *Dispatcher) registered() []*retryQueue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*retryQueue{}, d.queues...)
+}
+
+// Dispatch queues a for every notifier and returns without waiting for the
+// deliveries. They are recorded as retrying, due now, until the first
+// attempt, so a delivery cut short by a restart is put back by Requeue.
+// A notifier in its quiet hours holds the alert for its digest instead.
+func (d *Dispatcher) Dispatch(ctx context.Context, a Alert) {
+	skipped := d.skip != nil && d.skip(a)
+	for _, q := range d.registered() {
+		now := d.clock.Now()
+		held := skipped || q.quiet.hold(a, now)
+		rec := store.Notification{
+			AlertID:   a.ID,
+			Container: a.Container,
+			AlertType: a.Type,
+			Notifier:  q.notifier.Name(),
+			CreatedAt: now,
+		}
+		rec.UpdatedAt = now
+		if held {
+			rec.Status = store.NotificationSkipped
+			d.save(ctx, &rec)
+			continue
+		}
+		rec.Status = store.NotificationRetrying
+		rec.NextAttemptAt = now
+		d.save(ctx, &rec)
+		d.enqueue(ctx, q, delivery{alert: a, record: rec})
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// attempt makes one delivery attempt, records its outcome and queues the
+// delivery again when it failed and has retries left.
+func (d *Dispatcher) attempt(ctx context.Context, q *retryQueue, dl delivery) {
+	err := q.notifier.Send(ctx, dl.alert)
+	dl.record.Attempts++
+	dl.record.UpdatedAt = d.clock.Now()
+	dl.record.NextAttemptAt = time.Time{}
+	dl.record.Error = ""
+	switch {
+	case err == nil:
+		dl.record.Status = store.NotificationSent
+	case errors.Is(err, ErrSkipped):
+		dl.record.Status = store.NotificationSkipped
+	default:
+		log.Printf("%s notification failed (attempt %d): %v", dl.record.Notifier, dl.record.Attempts, err)
+		dl.record.Error = err.Error()
+		dl.record.Status = store.NotificationFailed
+		if next := dl.record.UpdatedAt.Add(retryDelay(dl.record.Attempts)); next.Before(dl.record.CreatedAt.Add(d.period)) {
+			dl.record.Status = store.NotificationRetrying
+			dl.record.NextAttemptAt = next
+		}
+		if d.onFailure != nil && dl.record.Attempts == 1 {
+			d.onFailure(ctx, dl.record.Notifier, dl.alert, err)
+		}
+	}
+	d.save(ctx, &dl.record)
+	switch dl.record.Status {
+	case store.NotificationRetrying:
+		d.enqueue(ctx, q, dl)
+	case store.NotificationFailed:
+		d.giveUp(ctx, dl)
+	}
+}
+
+// retryDelay is the wait after the given number of failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+func (d *Dispatcher) giveUp(ctx context.Context, dl delivery) {
+	log.Printf("%s notification for alert %d given up after %d attempts", dl.record.Notifier, dl.record.AlertID, dl.record.Attempts)
+	if d.onGiveUp != nil {
+		d.onGiveUp(ctx, dl.record, dl.alert)
+	}
+}
+
+// Requeue puts back a delivery that was waiting for a retry when healthmon
+// stopped. It reports false when no registered notifier has its name.
+func (d *Dispatcher) Requeue(ctx context.Context, n store.Notification, a Alert) bool {
+	for _, q := range d.registered() {
+		if q.notifier.Name() == n.Notifier {
+			d.enqueue(ctx, q, delivery{alert: a, record: n})
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, q *retryQueue, dl delivery) {
+	q.mu.Lock()
+	var dropped []delivery
+	if len(q.pending) >= maxQueued {
+		dropped = append(dropped, q.pending[0])
+		q.pending = q.pending[1:]
+	}
+	q.pending = append(q.pending, dl)
+	q.mu.Unlock()
+	for _, old := range dropped {
+		old.record.Status = store.NotificationFailed
+		old.record.Error = "retry queue full: " + old.record.Error
+		old.record.NextAttemptAt = time.Time{}
+		old.record.UpdatedAt = d.clock.Now()
+		d.save(ctx, &old.record)
+		d.giveUp(ctx, old)
+	}
+}
+
+func (d *Dispatcher) save(ctx context.Context, rec *store.Notification) {
+	if d.status == nil {
+		return
+	}
+	if rec.ID == 0 {
+		id, err := d.status.AddNotification(ctx, *rec)
+		if err != nil {
+			log.Printf("notification status persist failed: %v", err)
+			return
+		}
+		rec.ID = id
+		return
+	}
+	if err := d.status.UpdateNotification(ctx, *rec); err != nil {
+		log.Printf("notification status persist failed: %v", err)
+	}
+}
+
+// Retry attempts the queued deliveries that are due, first attempts
+// included, notifier by notifier. Run does this on its own.
+func (d *Dispatcher) Retry(ctx context.Context) {
+	for _, q := range d.registered() {
+		d.retryQueue(ctx, q)
+	}
+}
+
+func (d *Dispatcher) retryQueue(ctx context.Context, q *retryQueue) {
+	now := d.clock.Now()
+	q.mu.Lock()
+	var due []delivery
+	rest := q.pending[:0]
+	for _, dl := range q.pending {
+		if dl.record.NextAttemptAt.After(now) {
+			rest = append(rest, dl)
+		} else {
+			due = append(due, dl)
+		}
+	}
+	q.pending = rest
+	q.mu.Unlock()
+	for _, dl := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		d.attempt(ctx, q, dl)
+	}
+}
+
+// Run works off the delivery queues until ctx is done, with one goroutine
+// per notifier, and sends the quiet hours digest. Deliveries still queued at
+// shutdown keep their retrying status and can be put back with Requeue.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	if d.quiet() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := d.clock.NewTicker(digestCheck)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C():
+					d.SendDigest(ctx)
+				}
+			}
+		}()
+	}
+	for _, q := range d.registered() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := d.clock.NewTicker(retryCheck)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-q.wake:
+					d.retryQueue(ctx, q)
+				case <-ticker.C():
+					d.retryQueue(ctx, q)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
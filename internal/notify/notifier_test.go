@@ -0,0 +1,226 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/store"
+)
+
+type fakeNotifier struct {
+	name  string
+	fails int
+	sent  int
+	err   error
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Send(ctx context.Context, a Alert) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("connection refused")
+	}
+	f.sent++
+	return nil
+}
+
+type memoryStatus struct {
+	items map[int64]store.Notification
+}
+
+func (m *memoryStatus) AddNotification(ctx context.Context, n store.Notification) (int64, error) {
+	n.ID = int64(len(m.items) + 1)
+	m.items[n.ID] = n
+	return n.ID, nil
+}
+
+func (m *memoryStatus) UpdateNotification(ctx context.Context, n store.Notification) error {
+	m.items[n.ID] = n
+	return nil
+}
+
+func TestDispatcherRetriesPerNotifierAndRecordsStatus(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	status := &memoryStatus{items: map[int64]store.Notification{}}
+	d := NewDispatcher(status)
+	d.WithClock(clk)
+	d.WithRetryPeriod(time.Hour)
+	var failures, givenUp []string
+	d.OnFailure(func(ctx context.Context, notifier string, a Alert, err error) {
+		failures = append(failures, notifier)
+	})
+	d.OnGiveUp(func(ctx context.Context, n store.Notification, a Alert) {
+		givenUp = append(givenUp, n.Notifier+" "+a.Type)
+	})
+
+	flaky := &fakeNotifier{name: "flaky", fails: 1}
+	down := &fakeNotifier{name: "down", fails: 100}
+	muted := &fakeNotifier{name: "muted", err: ErrSkipped}
+	d.Register(flaky)
+	d.Register(down)
+	d.Register(muted)
+
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 7, Container: "web", Type: "restart_loop", Severity: "red"}})
+	if got := status.items[1]; got.Status != store.NotificationRetrying || got.Attempts != 0 || flaky.sent+down.sent != 0 {
+		t.Fatalf("expected the deliveries to be queued without an attempt, got %+v", got)
+	}
+	// Run makes the first attempts right away; here Retry stands in for it.
+	d.Retry(ctx)
+	if got := status.items[1]; got.Notifier != "flaky" || got.Status != store.NotificationRetrying || got.Attempts != 1 || got.NextAttemptAt != clk.Now().Add(time.Minute) {
+		t.Fatalf("expected flaky to wait for a retry, got %+v", got)
+	}
+	if got := status.items[3]; got.Status != store.NotificationSkipped || got.AlertID != 7 {
+		t.Fatalf("expected muted to be skipped, got %+v", got)
+	}
+
+	d.Retry(ctx)
+	if flaky.sent != 0 {
+		t.Fatalf("expected no retry before it is due")
+	}
+	clk.Advance(time.Minute)
+	d.Retry(ctx)
+	if got := status.items[1]; flaky.sent != 1 || got.Status != store.NotificationSent || got.Attempts != 2 || got.Error != "" {
+		t.Fatalf("expected flaky to be sent on the retry, got %+v", got)
+	}
+
+	// The waits double, so within the hour down is tried at 0, 1, 3, 7, 15
+	// and 31 minutes; the next attempt would be past the retry period.
+	for _, wait := range []time.Duration{2, 4, 8} {
+		clk.Advance(wait * time.Minute)
+		d.Retry(ctx)
+		if got := status.items[2]; got.Status != store.NotificationRetrying {
+			t.Fatalf("expected down to be retried, got %+v", got)
+		}
+	}
+	clk.Advance(16 * time.Minute)
+	d.Retry(ctx)
+	if got := status.items[2]; got.Status != store.NotificationFailed || got.Attempts != 6 || got.Error != "connection refused" || !got.NextAttemptAt.IsZero() {
+		t.Fatalf("expected down to be given up on after six attempts, got %+v", got)
+	}
+	clk.Advance(time.Hour)
+	d.Retry(ctx)
+	if got := status.items[2]; got.Attempts != 6 {
+		t.Fatalf("expected no attempt after giving up, got %+v", got)
+	}
+	if len(failures) != 2 || len(givenUp) != 1 || givenUp[0] != "down restart_loop" {
+		t.Fatalf("expected first failures and the give up reported, got %v and %v", failures, givenUp)
+	}
+}
+
+func TestDispatcherRequeuesRestoredDeliveries(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	status := &memoryStatus{items: map[int64]store.Notification{}}
+	d := NewDispatcher(status)
+	d.WithClock(clk)
+	telegram := &fakeNotifier{name: "Telegram"}
+	d.Register(telegram)
+
+	pending := store.Notification{ID: 1, AlertID: 7, Notifier: "Telegram", Status: store.NotificationRetrying, Attempts: 3, CreatedAt: clk.Now().Add(-time.Hour), NextAttemptAt: clk.Now().Add(time.Minute)}
+	status.items[1] = pending
+	if d.Requeue(ctx, store.Notification{Notifier: "Apprise"}, Alert{}) {
+		t.Fatalf("expected no queue for an unregistered notifier")
+	}
+	if !d.Requeue(ctx, pending, Alert{Alert: store.Alert{ID: 7}}) {
+		t.Fatalf("expected the delivery to be requeued")
+	}
+	clk.Advance(time.Minute)
+	d.Retry(ctx)
+	if got := status.items[1]; telegram.sent != 1 || got.Status != store.NotificationSent || got.Attempts != 4 {
+		t.Fatalf("expected the restored delivery to be sent, got %+v", got)
+	}
+}
+
+func TestDispatcherSkipsAlertsForEveryNotifier(t *testing.T) {
+	ctx := context.Background()
+	status := &memoryStatus{items: map[int64]store.Notification{}}
+	d := NewDispatcher(status)
+	d.SkipIf(func(a Alert) bool { return a.Container == "web" })
+	chat := &fakeNotifier{name: "chat"}
+	mail := &fakeNotifier{name: "mail"}
+	d.Register(chat)
+	d.Register(mail)
+
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 1, Container: "web", Severity: "red"}})
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 2, Container: "db", Severity: "red"}})
+	d.Retry(ctx)
+	if chat.sent != 1 || mail.sent != 1 {
+		t.Fatalf("expected only the db alert to be sent, got %d and %d", chat.sent, mail.sent)
+	}
+	for _, id := range []int64{1, 2} {
+		if got := status.items[id]; got.AlertID != 1 || got.Status != store.NotificationSkipped {
+			t.Fatalf("expected the web alert to be skipped for every notifier, got %+v", got)
+		}
+	}
+}
+
+// blockingNotifier hangs in Send until it is released.
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (b *blockingNotifier) Name() string { return "stuck" }
+
+func (b *blockingNotifier) Send(ctx context.Context, a Alert) error {
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// channelNotifier reports every alert it sends.
+type channelNotifier struct {
+	sent chan int64
+}
+
+func (c *channelNotifier) Name() string { return "chat" }
+
+func (c *channelNotifier) Send(ctx context.Context, a Alert) error {
+	c.sent <- a.ID
+	return nil
+}
+
+func TestDispatchDoesNotWaitForTheNotifiers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := NewDispatcher(nil)
+	d.WithClock(clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)))
+	stuck := &blockingNotifier{release: make(chan struct{})}
+	defer close(stuck.release)
+	chat := &channelNotifier{sent: make(chan int64, 2)}
+	d.Register(stuck)
+	d.Register(chat)
+	go d.Run(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 1, Container: "web", Severity: "red"}})
+		d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 2, Container: "db", Severity: "red"}})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Dispatch waited for a notifier")
+	}
+	for _, want := range []int64{1, 2} {
+		select {
+		case got := <-chat.sent:
+			if got != want {
+				t.Fatalf("expected alert %d, got %d", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("a stuck notifier held up the others")
+		}
+	}
+}
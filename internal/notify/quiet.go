@@ -0,0 +1,243 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"healthmon/internal/store"
+)
+
+const (
+	// DigestType is the alert type of the quiet hours digest. Notifiers that
+	// format alerts themselves send its Title and Body as they are.
+	DigestType = "quiet_hours_digest"
+	// digestMaxLines caps the alerts listed in a digest, which keeps it below
+	// Telegram's 4096 and Discord's 2000 character limits.
+	digestMaxLines = 15
+	// digestLineLength cuts each listed alert.
+	digestLineLength = 100
+	// digestCheck is how often Run looks whether the quiet hours are over.
+	digestCheck = time.Minute
+)
+
+// QuietHours is a daily window, in local time, during which a dispatcher
+// holds alerts and sends them as one digest when the window ends. A window
+// whose end is before its start spans midnight.
+type QuietHours struct {
+	start time.Duration
+	end   time.Duration
+	// PassRed still sends red alerts right away.
+	PassRed bool
+}
+
+// ParseQuietHours parses a window like "23:00-07:00".
+func ParseQuietHours(value string, passRed bool) (QuietHours, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(value), "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+	q := QuietHours{
+		start:   time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+		end:     time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute,
+		PassRed: passRed,
+	}
+	if q.start == q.end {
+		return QuietHours{}, fmt.Errorf("window is empty")
+	}
+	return q, nil
+}
+
+// ParseQuietSchedule parses the quiet hours of each channel, entries like
+// "Telegram=23:00-07:00" separated by ";", where the name is the notifier's
+// as listed in /api/notifications. An entry without a name is the window of
+// every channel not listed. The result is keyed by name, "" for that
+// default.
+func ParseQuietSchedule(value string, passRed bool) (map[string]QuietHours, error) {
+	schedule := make(map[string]QuietHours)
+	for _, entry := range strings.Split(value, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, window, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, window = "", entry
+		}
+		name = strings.TrimSpace(name)
+		if _, dup := schedule[name]; dup {
+			if name == "" {
+				return nil, fmt.Errorf("more than one window for every channel")
+			}
+			return nil, fmt.Errorf("%s is listed twice", name)
+		}
+		hours, err := ParseQuietHours(window, passRed)
+		if err != nil {
+			if name == "" {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		schedule[name] = hours
+	}
+	return schedule, nil
+}
+
+// active reports whether t falls into the window.
+func (q QuietHours) active(t time.Time) bool {
+	local := t.In(time.Local)
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if q.start < q.end {
+		return offset >= q.start && offset < q.end
+	}
+	return offset >= q.start || offset < q.end
+}
+
+// holds reports whether an alert of severity raised at t waits for the
+// digest instead of being sent right away.
+func (q QuietHours) holds(severity string, t time.Time) bool {
+	if q.PassRed && severity == "red" {
+		return false
+	}
+	return q.active(t)
+}
+
+// quietQueue holds the alerts of a notifier during its quiet hours. It
+// lives in memory: alerts held when healthmon stops are left out of the
+// digest, but they are stored and shown in the dashboard.
+type quietQueue struct {
+	hours QuietHours
+	mu    sync.Mutex
+	held  []string
+}
+
+// hold queues a when it falls into the quiet hours, and reports whether it
+// did.
+func (q *quietQueue) hold(a Alert, now time.Time) bool {
+	if q == nil || !q.hours.holds(a.Severity, now) {
+		return false
+	}
+	line := a.Title + ": " + a.Message
+	if first, _, cut := strings.Cut(line, "\n"); cut {
+		line = first
+	}
+	if runes := []rune(line); len(runes) > digestLineLength {
+		line = string(runes[:digestLineLength-1]) + "…"
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.held = append(q.held, line)
+	return true
+}
+
+// digest returns the held alerts as one and empties the queue, once the
+// quiet hours are over. It returns false while they last or when nothing
+// was held.
+func (q *quietQueue) digest(now time.Time) (Alert, bool) {
+	if q == nil || q.hours.active(now) {
+		return Alert{}, false
+	}
+	q.mu.Lock()
+	held := q.held
+	q.held = nil
+	q.mu.Unlock()
+	if len(held) == 0 {
+		return Alert{}, false
+	}
+	noun := "alerts"
+	if len(held) == 1 {
+		noun = "alert"
+	}
+	var b strings.Builder
+	for i, line := range held {
+		if i == digestMaxLines {
+			fmt.Fprintf(&b, "… and %d more, see the dashboard", len(held)-i)
+			break
+		}
+		b.WriteString("• " + line + "\n")
+	}
+	title := fmt.Sprintf("Quiet hours digest: %d %s", len(held), noun)
+	body := strings.TrimSuffix(b.String(), "\n")
+	return Alert{
+		Alert: store.Alert{Type: DigestType, Severity: "blue", Message: title + "\n" + body, Timestamp: now},
+		Title: title,
+		Body:  body,
+	}, true
+}
+
+// WithQuietHours gives the registered notifiers the quiet hours of a
+// schedule from ParseQuietSchedule: their own window, or the one under ""
+// when they have none. Alerts raised during a notifier's quiet hours are
+// recorded as skipped for it and sent to it as one digest when the window
+// ends. Names that match no notifier are reported, the rest still apply.
+func (d *Dispatcher) WithQuietHours(schedule map[string]QuietHours) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	unknown := make(map[string]bool, len(schedule))
+	for name := range schedule {
+		unknown[name] = name != ""
+	}
+	for _, q := range d.queues {
+		name := q.notifier.Name()
+		hours, ok := schedule[name]
+		if ok {
+			unknown[name] = false
+		} else if hours, ok = schedule[""]; !ok {
+			continue
+		}
+		q.quiet = &quietQueue{hours: hours}
+	}
+	var names []string
+	for name, missing := range unknown {
+		if missing {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("no notifier named %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// quiet reports whether any notifier has quiet hours.
+func (d *Dispatcher) quiet() bool {
+	for _, q := range d.registered() {
+		if q.quiet != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SendDigest sends each notifier the alerts it held during its quiet hours
+// once they are over. The digest is not retried; a notifier that fails is
+// reported like a failed first attempt.
+func (d *Dispatcher) SendDigest(ctx context.Context) {
+	for _, q := range d.registered() {
+		digest, ok := q.quiet.digest(d.clock.Now())
+		if !ok {
+			continue
+		}
+		err := q.notifier.Send(ctx, digest)
+		if err == nil || errors.Is(err, ErrSkipped) {
+			continue
+		}
+		log.Printf("%s quiet hours digest failed: %v", q.notifier.Name(), err)
+		if d.onFailure != nil {
+			d.onFailure(ctx, q.notifier.Name(), digest, err)
+		}
+	}
+}
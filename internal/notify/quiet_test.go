@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/store"
+)
+
+type recordingNotifier struct {
+	name string
+	err  error
+	got  []Alert
+}
+
+func (r *recordingNotifier) Name() string { return r.name }
+
+func (r *recordingNotifier) Send(ctx context.Context, a Alert) error {
+	r.got = append(r.got, a)
+	return r.err
+}
+
+func TestQuietHoursSpanMidnight(t *testing.T) {
+	hours, err := ParseQuietHours("23:00-07:00", true)
+	if err != nil {
+		t.Fatalf("parse quiet hours: %v", err)
+	}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	for _, tc := range []struct {
+		at     time.Duration
+		active bool
+	}{
+		{22*time.Hour + 59*time.Minute, false},
+		{23 * time.Hour, true},
+		{3 * time.Hour, true},
+		{7 * time.Hour, false},
+		{12 * time.Hour, false},
+	} {
+		if got := hours.active(day.Add(tc.at)); got != tc.active {
+			t.Fatalf("active at %s = %v, want %v", tc.at, got, tc.active)
+		}
+	}
+
+	for _, value := range []string{"23:00", "25:00-07:00", "07:00-07:00"} {
+		if _, err := ParseQuietHours(value, true); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestDispatcherHoldsAlertsForEveryNotifierDuringQuietHours(t *testing.T) {
+	ctx := context.Background()
+	night := time.Date(2024, 3, 1, 2, 0, 0, 0, time.Local)
+	clk := clock.NewFake(night)
+	status := &memoryStatus{items: map[int64]store.Notification{}}
+	d := NewDispatcher(status)
+	d.WithClock(clk)
+	schedule, err := ParseQuietSchedule("23:00-07:00", true)
+	if err != nil {
+		t.Fatalf("parse quiet hours: %v", err)
+	}
+	var failures []string
+	d.OnFailure(func(ctx context.Context, notifier string, a Alert, err error) {
+		failures = append(failures, notifier+" "+a.Type)
+	})
+	slack := &recordingNotifier{name: "slack://T1"}
+	mail := &recordingNotifier{name: "smtp://mail:25/ops@example.com", err: errors.New("connection refused")}
+	d.Register(slack)
+	d.Register(mail)
+	if err := d.WithQuietHours(schedule); err != nil {
+		t.Fatalf("apply quiet hours: %v", err)
+	}
+
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 1, Container: "db", Severity: "red", Message: "Restart loop detected"}, Title: "[RED] db"})
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 2, Container: "web", Severity: "yellow", Message: "Container unhealthy\nprobe output"}, Title: "[YELLOW] web"})
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 3, Container: "api", Severity: "blue", Message: "Image changed"}, Title: "[BLUE] api"})
+	d.Retry(ctx)
+	if len(slack.got) != 1 || slack.got[0].ID != 1 {
+		t.Fatalf("expected only the red alert to pass through, got %+v", slack.got)
+	}
+	skipped := 0
+	for _, n := range status.items {
+		if n.Status == store.NotificationSkipped {
+			skipped++
+		}
+	}
+	if skipped != 4 {
+		t.Fatalf("expected the held alerts to be skipped for both notifiers, got %+v", status.items)
+	}
+
+	d.SendDigest(ctx)
+	if len(slack.got) != 1 {
+		t.Fatalf("expected no digest during the quiet hours")
+	}
+	clk.Set(time.Date(2024, 3, 1, 7, 1, 0, 0, time.Local))
+	d.SendDigest(ctx)
+	if len(slack.got) != 2 || len(mail.got) != 2 {
+		t.Fatalf("expected the digest to go to every notifier, got %d and %d", len(slack.got), len(mail.got))
+	}
+	digest := slack.got[1]
+	if digest.Type != DigestType || digest.Title != "Quiet hours digest: 2 alerts" || digest.Body != "• [YELLOW] web: Container unhealthy\n• [BLUE] api: Image changed" {
+		t.Fatalf("unexpected digest %+v", digest)
+	}
+	if len(failures) != 2 || failures[1] != mail.name+" "+DigestType {
+		t.Fatalf("expected the failed digest to be reported, got %v", failures)
+	}
+	d.SendDigest(ctx)
+	if len(slack.got) != 2 {
+		t.Fatalf("expected the queue to be empty after the digest")
+	}
+}
+
+func TestQuietHoursPerNotifier(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 3, 1, 22, 30, 0, 0, time.Local))
+	status := &memoryStatus{items: map[int64]store.Notification{}}
+	d := NewDispatcher(status)
+	d.WithClock(clk)
+	telegram := &recordingNotifier{name: "Telegram"}
+	mail := &recordingNotifier{name: "smtp://mail:25/ops@example.com,oncall@example.com"}
+	slack := &recordingNotifier{name: "slack://T1/B1"}
+	d.Register(telegram)
+	d.Register(mail)
+	d.Register(slack)
+	schedule, err := ParseQuietSchedule("23:00-07:00; Telegram=22:00-08:00; smtp://mail:25/ops@example.com,oncall@example.com = 01:00-02:00; Apprise=22:00-06:00", false)
+	if err != nil {
+		t.Fatalf("parse quiet schedule: %v", err)
+	}
+	if err := d.WithQuietHours(schedule); err == nil || err.Error() != "no notifier named Apprise" {
+		t.Fatalf("expected the unknown notifier to be reported, got %v", err)
+	}
+
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 1, Container: "web", Severity: "yellow", Message: "Container unhealthy"}, Title: "[YELLOW] web"})
+	d.Retry(ctx)
+	if len(telegram.got) != 0 || len(mail.got) != 1 || len(slack.got) != 1 {
+		t.Fatalf("expected only Telegram to be quiet at 22:30, got %d, %d and %d", len(telegram.got), len(mail.got), len(slack.got))
+	}
+	clk.Set(time.Date(2024, 3, 1, 23, 30, 0, 0, time.Local))
+	d.Dispatch(ctx, Alert{Alert: store.Alert{ID: 2, Container: "api", Severity: "blue", Message: "Image changed"}, Title: "[BLUE] api"})
+	d.Retry(ctx)
+	if len(telegram.got) != 0 || len(mail.got) != 2 || len(slack.got) != 1 {
+		t.Fatalf("expected Telegram and Slack to be quiet at 23:30, got %d, %d and %d", len(telegram.got), len(mail.got), len(slack.got))
+	}
+
+	clk.Set(time.Date(2024, 3, 2, 7, 30, 0, 0, time.Local))
+	d.SendDigest(ctx)
+	if len(telegram.got) != 0 || len(slack.got) != 2 || slack.got[1].Title != "Quiet hours digest: 1 alert" {
+		t.Fatalf("expected only Slack's quiet hours to be over, got %+v and %+v", telegram.got, slack.got)
+	}
+	clk.Set(time.Date(2024, 3, 2, 8, 0, 0, 0, time.Local))
+	d.SendDigest(ctx)
+	if len(telegram.got) != 1 || telegram.got[0].Title != "Quiet hours digest: 2 alerts" || len(mail.got) != 2 {
+		t.Fatalf("expected Telegram to get its own digest, got %+v", telegram.got)
+	}
+
+	for _, value := range []string{"Telegram=23:00", "23:00-07:00;22:00-06:00", "Telegram=23:00-07:00;Telegram=22:00-06:00"} {
+		if _, err := ParseQuietSchedule(value, true); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestDigestIsCapped(t *testing.T) {
+	hours, err := ParseQuietHours("23:00-07:00", false)
+	if err != nil {
+		t.Fatalf("parse quiet hours: %v", err)
+	}
+	q := &quietQueue{hours: hours}
+	night := time.Date(2024, 3, 1, 2, 0, 0, 0, time.Local)
+	if !q.hold(Alert{Alert: store.Alert{Severity: "red", Message: "Restart loop detected"}, Title: "[RED] db"}, night) {
+		t.Fatalf("expected red alerts to be held without PassRed")
+	}
+	for i := 0; i < digestMaxLines+5; i++ {
+		q.hold(Alert{Alert: store.Alert{Severity: "yellow", Message: strings.Repeat("x", 300)}, Title: "[YELLOW] web"}, night)
+	}
+	digest, ok := q.digest(time.Date(2024, 3, 1, 7, 1, 0, 0, time.Local))
+	if !ok || !strings.HasSuffix(digest.Body, "… and 6 more, see the dashboard") {
+		t.Fatalf("expected the digest to be capped, got:\n%s", digest.Body)
+	}
+	for _, line := range strings.Split(digest.Body, "\n") {
+		if len([]rune(line)) > digestLineLength+2 {
+			t.Fatalf("expected lines to be cut, got %q", line)
+		}
+	}
+}
@@ -6,18 +6,80 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 )
 
+const telegramAPI = "https://api.telegram.org"
+
 type Telegram struct {
-	token  string
-	chatID string
-	client *http.Client
+	token   string
+	chatID  string
+	baseURL string
+	client  *http.Client
 }
 
 type telegramPayload struct {
-	ChatID string `json:"chat_id"`
-	Text   string `json:"text"`
+	ChatID             string               `json:"chat_id"`
+	Text               string               `json:"text"`
+	ParseMode          string               `json:"parse_mode,omitempty"`
+	ReplyParameters    *telegramReply       `json:"reply_parameters,omitempty"`
+	LinkPreviewOptions *telegramLinkPreview `json:"link_preview_options,omitempty"`
+}
+
+type telegramReply struct {
+	MessageID                int64 `json:"message_id"`
+	AllowSendingWithoutReply bool  `json:"allow_sending_without_reply"`
+}
+
+type telegramLinkPreview struct {
+	IsDisabled bool `json:"is_disabled"`
+}
+
+type telegramSent struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"result"`
+}
+
+// TelegramText is a message to send. ParseMode is "", "HTML" or
+// "MarkdownV2"; the text must be escaped for it. ReplyTo threads the
+// message under an earlier one, which may have been deleted.
+type TelegramText struct {
+	Text      string
+	ParseMode string
+	ReplyTo   int64
+}
+
+// TelegramMessage is a text message sent to the bot, as returned by
+// Updates.
+type TelegramMessage struct {
+	UpdateID int64
+	ChatID   int64
+	From     string
+	Text     string
+}
+
+type telegramUpdates struct {
+	OK          bool             `json:"ok"`
+	Description string           `json:"description"`
+	Result      []telegramUpdate `json:"result"`
+}
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		From *struct {
+			Username string `json:"username"`
+		} `json:"from"`
+		Text string `json:"text"`
+	} `json:"message"`
 }
 
 func NewTelegram(enabled bool, token, chatID string) *Telegram {
@@ -25,36 +87,133 @@ func NewTelegram(enabled bool, token, chatID string) *Telegram {
 		return nil
 	}
 	return &Telegram{
-		token:  token,
-		chatID: chatID,
-		client: &http.Client{Timeout: 5 * time.Second},
+		token:   token,
+		chatID:  chatID,
+		baseURL: telegramAPI,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ChatID returns the chat alerts are sent to.
+func (t *Telegram) ChatID() string {
+	if t == nil {
+		return ""
 	}
+	return t.chatID
 }
 
 func (t *Telegram) Send(ctx context.Context, text string) error {
 	if t == nil {
 		return nil
 	}
-	payload := telegramPayload{ChatID: t.chatID, Text: text}
+	return t.SendTo(ctx, t.chatID, text)
+}
+
+// SendTo sends text to chatID instead of the configured chat, e.g. to answer
+// a command.
+func (t *Telegram) SendTo(ctx context.Context, chatID, text string) error {
+	_, err := t.post(ctx, chatID, TelegramText{Text: text})
+	return err
+}
+
+// SendText sends msg to the configured chat and returns the id of the new
+// message.
+func (t *Telegram) SendText(ctx context.Context, msg TelegramText) (int64, error) {
+	if t == nil {
+		return 0, nil
+	}
+	return t.post(ctx, t.chatID, msg)
+}
+
+func (t *Telegram) post(ctx context.Context, chatID string, msg TelegramText) (int64, error) {
+	if t == nil {
+		return 0, nil
+	}
+	payload := telegramPayload{ChatID: chatID, Text: msg.Text, ParseMode: msg.ParseMode}
+	if msg.ReplyTo != 0 {
+		payload.ReplyParameters = &telegramReply{MessageID: msg.ReplyTo, AllowSendingWithoutReply: true}
+	}
+	if msg.ParseMode != "" {
+		// Formatted alerts carry a dashboard link, whose preview would
+		// bury the alert.
+		payload.LinkPreviewOptions = &telegramLinkPreview{IsDisabled: true}
+	}
 	buf, err := json.Marshal(payload)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.method("sendMessage"), bytes.NewReader(buf))
 	if err != nil {
-		return err
+		return 0, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := t.client.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
+	var body telegramSent
+	_ = json.NewDecoder(resp.Body).Decode(&body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("telegram status %s", resp.Status)
+		if body.Description != "" {
+			return 0, fmt.Errorf("telegram status %s: %s", resp.Status, body.Description)
+		}
+		return 0, fmt.Errorf("telegram status %s", resp.Status)
+	}
+	return body.Result.MessageID, nil
+}
+
+// Updates long-polls the bot for messages with an update id of at least
+// offset, waiting up to wait for one to arrive. Pass the last UpdateID plus
+// one as the next offset to confirm the messages already seen.
+func (t *Telegram) Updates(ctx context.Context, offset int64, wait time.Duration) ([]TelegramMessage, error) {
+	if t == nil {
+		return nil, nil
 	}
-	return nil
+	query := url.Values{}
+	query.Set("offset", strconv.FormatInt(offset, 10))
+	query.Set("timeout", strconv.Itoa(int(wait/time.Second)))
+	query.Set("allowed_updates", `["message"]`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.method("getUpdates")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request is held open for wait, which the send timeout would cut.
+	client := &http.Client{Timeout: wait + t.client.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var body telegramUpdates
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || !body.OK {
+		if body.Description != "" {
+			return nil, fmt.Errorf("telegram status %s: %s", resp.Status, body.Description)
+		}
+		return nil, fmt.Errorf("telegram status %s", resp.Status)
+	}
+
+	messages := make([]TelegramMessage, 0, len(body.Result))
+	for _, u := range body.Result {
+		msg := TelegramMessage{UpdateID: u.UpdateID}
+		if u.Message != nil {
+			msg.ChatID = u.Message.Chat.ID
+			msg.Text = u.Message.Text
+			if u.Message.From != nil {
+				msg.From = u.Message.From.Username
+			}
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+func (t *Telegram) method(name string) string {
+	return fmt.Sprintf("%s/bot%s/%s", t.baseURL, t.token, name)
 }
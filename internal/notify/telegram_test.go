@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTelegramUpdatesAndReplies(t *testing.T) {
+	var sent telegramPayload
+	var offset, timeout string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bottoken/getUpdates":
+			offset = r.URL.Query().Get("offset")
+			timeout = r.URL.Query().Get("timeout")
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":41,"message":{"chat":{"id":-100},"from":{"username":"alice"},"text":"/status"}},{"update_id":42}]}`))
+		case "/bottoken/sendMessage":
+			if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+				t.Errorf("decode: %v", err)
+			}
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tg := NewTelegram(true, "token", "123")
+	tg.baseURL = srv.URL
+	messages, err := tg.Updates(context.Background(), 41, time.Second)
+	if err != nil {
+		t.Fatalf("updates: %v", err)
+	}
+	if offset != "41" || timeout != "1" {
+		t.Fatalf("unexpected offset %q and timeout %q", offset, timeout)
+	}
+	if len(messages) != 2 || messages[0] != (TelegramMessage{UpdateID: 41, ChatID: -100, From: "alice", Text: "/status"}) || messages[1].UpdateID != 42 {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+
+	if err := tg.SendTo(context.Background(), "-100", "3 containers"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if sent.ChatID != "-100" || sent.Text != "3 containers" {
+		t.Fatalf("unexpected reply %+v", sent)
+	}
+}
+
+func TestTelegramUpdatesReportsAPIErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"ok":false,"description":"Conflict: terminated by other getUpdates request"}`))
+	}))
+	defer srv.Close()
+
+	tg := NewTelegram(true, "token", "123")
+	tg.baseURL = srv.URL
+	if _, err := tg.Updates(context.Background(), 0, time.Second); err == nil || err.Error() != "telegram status 409 Conflict: Conflict: terminated by other getUpdates request" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
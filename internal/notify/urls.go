@@ -0,0 +1,342 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	slackHooksURL = "https://hooks.slack.com/services"
+	discordAPI    = "https://discord.com/api/webhooks"
+)
+
+// ParseURLs turns notification URLs in the style of shoutrrr into
+// notifiers, one per URL and one per chat of a telegram URL:
+//
+//	telegram://<bot token>@telegram?chats=<chat>[,<chat>]
+//	slack://[<bot name>@]<token a>/<token b>/<token c>
+//	discord://<webhook token>@<webhook id>
+//	smtp://[<user>[:<password>]@]<host>[:<port>]/?from=<address>&to=<address>[,<address>]
+//
+// Notifiers are named after their URL without credentials, which keeps the
+// names stable across restarts for retries.
+func ParseURLs(raws []string) ([]Notifier, error) {
+	out := make([]Notifier, 0, len(raws))
+	seen := make(map[string]bool)
+	for _, raw := range raws {
+		notifiers, err := ParseURL(raw)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notifiers {
+			if seen[n.Name()] {
+				return nil, fmt.Errorf("notification URL %s is listed twice", n.Name())
+			}
+			seen[n.Name()] = true
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// ParseURL returns the notifiers one notification URL describes. Each chat
+// of a telegram URL is a notifier of its own, so a chat that fails is
+// retried without repeating the message in the others.
+func ParseURL(raw string) ([]Notifier, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url.Parse quotes the URL, credentials included.
+		return nil, errors.New("invalid notification URL")
+	}
+	var n Notifier
+	switch u.Scheme {
+	case "telegram":
+		chats, err := parseTelegramURL(u)
+		if err != nil {
+			return nil, fmt.Errorf("%s notification URL: %w", u.Scheme, err)
+		}
+		out := make([]Notifier, 0, len(chats))
+		for _, chat := range chats {
+			out = append(out, chat)
+		}
+		return out, nil
+	case "slack":
+		n, err = parseSlackURL(u)
+	case "discord":
+		n, err = parseDiscordURL(u)
+	case "smtp":
+		n, err = parseSMTPURL(u)
+	default:
+		return nil, fmt.Errorf("unsupported notification URL scheme %q, expected telegram, slack, discord or smtp", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s notification URL: %w", u.Scheme, err)
+	}
+	return []Notifier{n}, nil
+}
+
+// plainText is the message URL notifiers send: the title, then the body.
+func plainText(a Alert) string {
+	if a.Body == "" {
+		return a.Title
+	}
+	return a.Title + "\n" + a.Body
+}
+
+// TelegramChat sends alerts to a Telegram chat. Unlike the notifier set up
+// with HM_TG_TOKEN it does not take commands.
+type TelegramChat struct {
+	bot  *Telegram
+	chat string
+}
+
+func parseTelegramURL(u *url.URL) ([]*TelegramChat, error) {
+	token := u.User.Username()
+	if password, ok := u.User.Password(); ok {
+		token += ":" + password
+	}
+	chats := splitList(u.Query().Get("chats"))
+	if token == "" || len(chats) == 0 {
+		return nil, errors.New("expected telegram://<bot token>@telegram?chats=<chat>")
+	}
+	bot := NewTelegram(true, token, chats[0])
+	out := make([]*TelegramChat, 0, len(chats))
+	for _, chat := range chats {
+		out = append(out, &TelegramChat{bot: bot, chat: chat})
+	}
+	return out, nil
+}
+
+func (t *TelegramChat) Name() string {
+	return "telegram://" + t.chat
+}
+
+func (t *TelegramChat) Send(ctx context.Context, a Alert) error {
+	return t.bot.SendTo(ctx, t.chat, plainText(a))
+}
+
+// Slack posts alerts to a Slack incoming webhook.
+type Slack struct {
+	team    string
+	bot     string
+	hookURL string
+	botName string
+	client  *http.Client
+}
+
+func parseSlackURL(u *url.URL) (*Slack, error) {
+	tokens := append([]string{u.Host}, strings.Split(strings.Trim(u.Path, "/"), "/")...)
+	if len(tokens) != 3 || tokens[0] == "" || tokens[1] == "" || tokens[2] == "" {
+		return nil, errors.New("expected slack://<token a>/<token b>/<token c>")
+	}
+	return &Slack{
+		team:    tokens[0],
+		bot:     tokens[1],
+		hookURL: slackHooksURL + "/" + strings.Join(tokens, "/"),
+		botName: u.User.Username(),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *Slack) Name() string {
+	return "slack://" + s.team + "/" + s.bot
+}
+
+func (s *Slack) Send(ctx context.Context, a Alert) error {
+	payload := map[string]string{"text": plainText(a)}
+	if s.botName != "" {
+		payload["username"] = s.botName
+	}
+	return postJSON(ctx, s.client, s.hookURL, payload)
+}
+
+// Discord posts alerts to a Discord webhook.
+type Discord struct {
+	webhookID string
+	hookURL   string
+	client    *http.Client
+}
+
+func parseDiscordURL(u *url.URL) (*Discord, error) {
+	token := u.User.Username()
+	if token == "" || u.Host == "" {
+		return nil, errors.New("expected discord://<webhook token>@<webhook id>")
+	}
+	return &Discord{
+		webhookID: u.Host,
+		hookURL:   discordAPI + "/" + u.Host + "/" + token,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (d *Discord) Name() string {
+	return "discord://" + d.webhookID
+}
+
+func (d *Discord) Send(ctx context.Context, a Alert) error {
+	// Discord caps messages at 2000 characters.
+	text := plainText(a)
+	if runes := []rune(text); len(runes) > 2000 {
+		text = string(runes[:1999]) + "…"
+	}
+	return postJSON(ctx, d.client, d.hookURL, map[string]string{"content": text})
+}
+
+// smtpTimeout bounds one delivery, from dialing to the server's answer to
+// the message.
+const smtpTimeout = 30 * time.Second
+
+// Email sends alerts by SMTP. Port 465 uses TLS from the start; other ports
+// upgrade with STARTTLS when the server offers it.
+type Email struct {
+	addr        string
+	host        string
+	auth        smtp.Auth
+	from        string
+	to          []string
+	implicitTLS bool
+	timeout     time.Duration
+	send        func(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func parseSMTPURL(u *url.URL) (*Email, error) {
+	q := u.Query()
+	from, to := q.Get("from"), splitList(q.Get("to"))
+	if u.Hostname() == "" || from == "" || len(to) == 0 {
+		return nil, errors.New("expected smtp://<host>[:<port>]/?from=<address>&to=<address>")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "25"
+	}
+	e := &Email{addr: net.JoinHostPort(u.Hostname(), port), host: u.Hostname(), from: from, to: to, implicitTLS: port == "465", timeout: smtpTimeout}
+	e.send = e.sendMail
+	if user := u.User.Username(); user != "" {
+		password, _ := u.User.Password()
+		e.auth = smtp.PlainAuth("", user, password, u.Hostname())
+	}
+	return e, nil
+}
+
+func (e *Email) Name() string {
+	return "smtp://" + e.addr + "/" + strings.Join(e.to, ",")
+}
+
+func (e *Email) Send(ctx context.Context, a Alert) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(a.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(a.Body, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	return e.send(ctx, e.addr, e.auth, e.from, e.to, msg.Bytes())
+}
+
+// sendMail is smtp.SendMail with a deadline: the connection is closed for
+// reads and writes once ctx is done, so a server that stops answering
+// cannot hold the delivery forever.
+func (e *Email) sendMail(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var conn net.Conn
+	var err error
+	if e.implicitTLS {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: e.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if !e.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: e.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload any) error {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error quotes the URL, whose path holds the webhook secret.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
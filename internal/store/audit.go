@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// AuditEntry is one mutating API call: who made it, from where, what it
+// asked for and how it was answered.
+type AuditEntry struct {
+	ID        int64
+	Timestamp time.Time
+	// Actor identifies the caller, e.g. by a fingerprint of its API token.
+	Actor  string
+	IP     string
+	Method string
+	Path   string
+	// Params holds the query string and the start of the request body.
+	Params string
+	Status int
+}
+
+// AuditFilter narrows ListAudit. Zero fields match everything.
+type AuditFilter struct {
+	Actor string
+	// PathPrefix matches calls whose path starts with it.
+	PathPrefix string
+}
+
+// AddAuditEntry records a mutating API call.
+func (s *Store) AddAuditEntry(ctx context.Context, e AuditEntry) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `INSERT INTO audit_log (ts, actor, ip, method, path, params, status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			formatTime(e.Timestamp), e.Actor, e.IP, e.Method, e.Path, e.Params, e.Status)
+		return err
+	})
+}
+
+// ListAudit returns the audit entries matching filter, newest first,
+// starting below beforeID when it is set.
+func (s *Store) ListAudit(ctx context.Context, filter AuditFilter, beforeID int64, limit int) ([]AuditEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var where []string
+	var args []interface{}
+	if beforeID > 0 {
+		where = append(where, "id < ?")
+		args = append(args, beforeID)
+	}
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.PathPrefix != "" {
+		where = append(where, "substr(path, 1, ?) = ?")
+		args = append(args, len(filter.PathPrefix), filter.PathPrefix)
+	}
+	query := `SELECT id, ts, actor, ip, method, path, params, status FROM audit_log`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var ts string
+		if err := rows.Scan(&e.ID, &ts, &e.Actor, &e.IP, &e.Method, &e.Path, &e.Params, &e.Status); err != nil {
+			return nil, err
+		}
+		e.Timestamp = parseTime(ts)
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// CacheStats counts how the container cache served reads since startup.
+// Loads are misses that found the container in the database; invalidations
+// are entries dropped because a background write of theirs failed.
+type CacheStats struct {
+	Entries       int   `json:"entries"`
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Loads         int64 `json:"loads"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// containerCache is the in-memory copy of the containers table, keyed by
+// name. Reads go through it and fall back to the database on a miss; every
+// write to a container goes through put, update or invalidate, so there is
+// one place that decides what the cache holds. Subscribers are told about
+// every change.
+type containerCache struct {
+	mu     sync.RWMutex
+	byName map[string]*Container
+	hooks  []func(Container)
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	loads         atomic.Int64
+	invalidations atomic.Int64
+}
+
+func newContainerCache() *containerCache {
+	return &containerCache{byName: make(map[string]*Container)}
+}
+
+// subscribe registers fn to be called with a copy of every container the
+// cache stores or changes. fn must not call back into the cache.
+func (c *containerCache) subscribe(fn func(Container)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, fn)
+}
+
+// get returns the cached container called name, counting the hit or miss.
+func (c *containerCache) get(name string) (Container, bool) {
+	cont, ok := c.peek(name)
+	c.count(ok)
+	return cont, ok
+}
+
+// peek is get without counting, for writers filling in defaults.
+func (c *containerCache) peek(name string) (Container, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cont, ok := c.byName[name]
+	if !ok {
+		return Container{}, false
+	}
+	return *cont, true
+}
+
+// find returns the first cached container match accepts, counting the hit
+// or miss.
+func (c *containerCache) find(match func(Container) bool) (Container, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, cont := range c.byName {
+		if match(*cont) {
+			c.count(true)
+			return *cont, true
+		}
+	}
+	c.count(false)
+	return Container{}, false
+}
+
+// list returns copies of the cached containers match accepts, or all of
+// them when match is nil.
+func (c *containerCache) list(match func(Container) bool) []Container {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	items := make([]Container, 0, len(c.byName))
+	for _, cont := range c.byName {
+		if match == nil || match(*cont) {
+			items = append(items, *cont)
+		}
+	}
+	return items
+}
+
+func (c *containerCache) count(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// load stores a container read from the database after a miss. It does not
+// replace an entry a writer stored in the meantime, which is newer.
+func (c *containerCache) load(cont Container) Container {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.byName[cont.Name]; ok {
+		return *cached
+	}
+	c.loads.Add(1)
+	c.byName[cont.Name] = &cont
+	return cont
+}
+
+// put stores cont, replacing the cached container of the same name.
+func (c *containerCache) put(cont Container) {
+	c.mu.Lock()
+	c.byName[cont.Name] = &cont
+	hooks := c.hooks
+	c.mu.Unlock()
+	notify(hooks, cont)
+}
+
+// update applies fn to the cached container called name, if any.
+func (c *containerCache) update(name string, fn func(*Container)) {
+	c.updateAll(func(cont *Container) bool {
+		if cont.Name != name {
+			return false
+		}
+		fn(cont)
+		return true
+	})
+}
+
+// updateAll applies fn to every cached container; fn reports whether it
+// changed the container.
+func (c *containerCache) updateAll(fn func(*Container) bool) {
+	c.mu.Lock()
+	var changed []Container
+	for _, cont := range c.byName {
+		if fn(cont) {
+			changed = append(changed, *cont)
+		}
+	}
+	hooks := c.hooks
+	c.mu.Unlock()
+	for _, cont := range changed {
+		notify(hooks, cont)
+	}
+}
+
+// invalidate drops the container called name, so the next read loads what
+// the database holds. Writers call it when a write they already applied to
+// the cache fails.
+func (c *containerCache) invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.byName[name]; ok {
+		delete(c.byName, name)
+		c.invalidations.Add(1)
+	}
+}
+
+func (c *containerCache) stats() CacheStats {
+	c.mu.RLock()
+	entries := len(c.byName)
+	c.mu.RUnlock()
+	return CacheStats{
+		Entries:       entries,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Loads:         c.loads.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+}
+
+func notify(hooks []func(Container), cont Container) {
+	for _, hook := range hooks {
+		hook(cont)
+	}
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestContainerCacheReadsThroughAndInvalidates(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+	var changes []Container
+	st.OnContainerChange(func(c Container) { changes = append(changes, c) })
+
+	now := time.Now().UTC()
+	web := Container{Name: "web", ContainerID: "c-web", Image: "nginx", Status: "running", StartedAt: now, UpdatedAt: now, Present: true}
+	if err := st.UpsertContainer(ctx, web); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	if _, ok := st.GetContainer("web"); !ok {
+		t.Fatalf("expected web to be cached")
+	}
+	if _, ok := st.GetContainer("missing"); ok {
+		t.Fatalf("expected missing container to be unknown")
+	}
+	if stats := st.CacheStats(); stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.Loads != 0 {
+		t.Fatalf("unexpected stats after reads: %+v", stats)
+	}
+
+	// A store that has not loaded the table reads containers through.
+	other := New(dbConn.SQL)
+	defer other.Close()
+	if c, ok := other.GetContainer("web"); !ok || c.ContainerID != "c-web" {
+		t.Fatalf("expected web to be read from the database, got %+v", c)
+	}
+	if _, ok := other.GetContainer("web"); !ok {
+		t.Fatalf("expected web to stay cached")
+	}
+	if stats := other.CacheStats(); stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.Loads != 1 {
+		t.Fatalf("unexpected stats after read-through: %+v", stats)
+	}
+
+	// A background write that fails drops the entry it already changed, so
+	// the next read serves what the database holds.
+	if _, err := dbConn.SQL.ExecContext(ctx, `CREATE TRIGGER block_updates BEFORE UPDATE ON containers BEGIN SELECT RAISE(ABORT, 'read only'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+	if err := st.SetContainerPresent(ctx, "web", false); err != nil {
+		t.Fatalf("set present: %v", err)
+	}
+	st.Close()
+	c, ok := st.GetContainer("web")
+	if !ok || !c.Present {
+		t.Fatalf("expected web to be read back as present, got %+v (found %v)", c, ok)
+	}
+	if stats := st.CacheStats(); stats.Invalidations != 1 || stats.Loads != 1 {
+		t.Fatalf("unexpected stats after failed write: %+v", stats)
+	}
+
+	if len(changes) != 2 || !changes[0].Present || changes[1].Present {
+		t.Fatalf("expected the upsert and the removal to be announced, got %+v", changes)
+	}
+}
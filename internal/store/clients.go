@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"healthmon/internal/db"
+)
+
+// ClientSeverities returns the severity filter saved for a dashboard client.
+func (s *Store) ClientSeverities(ctx context.Context, client string) ([]string, bool, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT severities FROM client_filters WHERE client = ?`, client).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var severities []string
+	if err := json.Unmarshal([]byte(raw), &severities); err != nil {
+		return nil, false, err
+	}
+	return severities, true, nil
+}
+
+// SetClientSeverities saves the severity filter of a dashboard client. An
+// empty list removes the filter.
+func (s *Store) SetClientSeverities(ctx context.Context, client string, severities []string) error {
+	if len(severities) == 0 {
+		return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+			_, err := q.ExecContext(ctx, `DELETE FROM client_filters WHERE client = ?`, client)
+			return err
+		})
+	}
+	raw, err := json.Marshal(severities)
+	if err != nil {
+		return err
+	}
+	updatedAt := formatTime(s.clock.Now())
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `
+INSERT INTO client_filters (client, severities, updated_at)
+VALUES (?, ?, ?)
+ON CONFLICT(client) DO UPDATE SET severities = excluded.severities, updated_at = excluded.updated_at
+`, client, string(raw), updatedAt)
+		return err
+	})
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// AlertComment is a note an admin left on an alert, e.g. "known issue,
+// upstream outage".
+type AlertComment struct {
+	ID        int64
+	AlertID   int64
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// AddAlertComment stores a comment and returns it with its id and time.
+func (s *Store) AddAlertComment(ctx context.Context, c AlertComment) (AlertComment, error) {
+	c.CreatedAt = s.clock.Now()
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		return q.QueryRowContext(ctx, `
+INSERT INTO alert_comments (alert_id, author, body, created_at)
+VALUES (?, ?, ?, ?)
+RETURNING id
+`, c.AlertID, c.Author, c.Body, formatTime(c.CreatedAt)).Scan(&c.ID)
+	})
+	if err != nil {
+		return AlertComment{}, err
+	}
+	return c, nil
+}
+
+// ListAlertComments returns the comments on the given alerts, oldest first.
+func (s *Store) ListAlertComments(ctx context.Context, alertIDs ...int64) ([]AlertComment, error) {
+	items := []AlertComment{}
+	if len(alertIDs) == 0 {
+		return items, nil
+	}
+	args := make([]interface{}, 0, len(alertIDs))
+	for _, id := range alertIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, alert_id, author, body, created_at
+FROM alert_comments
+WHERE alert_id IN (`+placeholders(len(args))+`)
+ORDER BY id ASC
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c AlertComment
+		var createdAt string
+		if err := rows.Scan(&c.ID, &c.AlertID, &c.Author, &c.Body, &createdAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = parseTime(createdAt)
+		items = append(items, c)
+	}
+	return items, rows.Err()
+}
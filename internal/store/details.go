@@ -0,0 +1,342 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Details is the typed payload stored as the DetailsJSON of an event or
+// alert. Every payload is a JSON object whose "kind" names its shape, so
+// consumers can decode it without guessing from the event type.
+type Details interface {
+	DetailsKind() string
+}
+
+// RestartDetails is attached to restart_loop and restart_healed alerts and
+// to restarts recorded after the fact. restart_loop alerts also carry the
+// exit codes of the recent restarts, oldest first; the tail of the
+// container's logs goes into Alert.Logs.
+type RestartDetails struct {
+	RestartCount int   `json:"restart_count"`
+	ExitCodes    []int `json:"exit_codes,omitempty"`
+}
+
+// ImageUpdateDetails is attached to image_changed events and alerts.
+// Versions and revisions come from the OCI image labels. Sizes are 0 when
+// the image could not be inspected.
+type ImageUpdateDetails struct {
+	OldDigest   string `json:"old_digest,omitempty"`
+	NewDigest   string `json:"new_digest,omitempty"`
+	OldVersion  string `json:"old_version,omitempty"`
+	NewVersion  string `json:"new_version,omitempty"`
+	OldRevision string `json:"old_revision,omitempty"`
+	NewRevision string `json:"new_revision,omitempty"`
+	OldSize     int64  `json:"old_size_bytes,omitempty"`
+	NewSize     int64  `json:"new_size_bytes,omitempty"`
+	// ChangelogURL links the release notes of the new image on GitHub.
+	ChangelogURL string `json:"changelog_url,omitempty"`
+}
+
+// OOMDetails is attached to oom_killed alerts. MemoryLimit is zero when the
+// container has no limit and the host ran out of memory.
+type OOMDetails struct {
+	MemoryLimit int64 `json:"memory_limit_bytes"`
+}
+
+// SelfInflictedDetails marks events caused by healthmon's own actions.
+type SelfInflictedDetails struct {
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// PanicDetails is attached to panic alerts on _healthmon.
+type PanicDetails struct {
+	Where string `json:"where"`
+	Stack string `json:"stack"`
+}
+
+// DriftDetails is attached to resync_drift events, keyed by field.
+type DriftDetails struct {
+	Changes map[string]DriftChange `json:"changes"`
+}
+
+type DriftChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ScaleDetails is attached to scaled_up and scaled_down events.
+type ScaleDetails struct {
+	OldReplicas int `json:"old_replicas"`
+	NewReplicas int `json:"new_replicas"`
+}
+
+// TaskDetails is attached to task_completed events and task_failed alerts.
+type TaskDetails struct {
+	DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// EmulationDetails is attached to emulated_platform alerts.
+type EmulationDetails struct {
+	Platform string `json:"platform"`
+	HostArch string `json:"host_arch"`
+}
+
+// MaintenanceDetails is attached to the db_maintenance event of a finished
+// run. Sizes are in bytes.
+type MaintenanceDetails struct {
+	Trigger    string   `json:"trigger"`
+	Steps      []string `json:"steps"`
+	SizeBefore int64    `json:"size_before"`
+	SizeAfter  int64    `json:"size_after"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// DiagnosticDetails is attached to the alerts healthmon files about its own
+// failures on _healthmon.
+type DiagnosticDetails struct {
+	Error     string `json:"error"`
+	Write     string `json:"write,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+	Container string `json:"container,omitempty"`
+	Alert     string `json:"alert,omitempty"`
+}
+
+// MemoryPressureDetails is attached to memory_pressure and memory_recovered
+// alerts. Usage leaves out the inactive page cache, like `docker stats`.
+type MemoryPressureDetails struct {
+	Percent    float64 `json:"percent"`
+	UsageBytes int64   `json:"usage_bytes"`
+	LimitBytes int64   `json:"limit_bytes"`
+	Samples    int     `json:"samples,omitempty"`
+}
+
+// LimitPressureDetails is attached to pids_pressure, fd_pressure and their
+// _recovered alerts. Resource is "pids" or "fd".
+type LimitPressureDetails struct {
+	Resource string  `json:"resource"`
+	Percent  float64 `json:"percent"`
+	Current  int64   `json:"current"`
+	Limit    int64   `json:"limit"`
+	Samples  int     `json:"samples,omitempty"`
+}
+
+// ClockDetails is attached to clock_drift and clock_synced alerts. The
+// offset is positive when the container's clock is ahead of the host's.
+type ClockDetails struct {
+	OffsetSeconds float64 `json:"offset_seconds"`
+}
+
+// StuckStateDetails is attached to container_stuck and container_unstuck
+// alerts with the transient state and when the container entered it.
+type StuckStateDetails struct {
+	State string `json:"state"`
+	Since string `json:"since"`
+}
+
+// ExpectedDetails is attached to container_missing and container_returned
+// alerts with the expected container, which matters when they are filed on
+// _healthmon, and since when it was missing.
+type ExpectedDetails struct {
+	Container string `json:"container"`
+	Since     string `json:"since"`
+}
+
+// NetworkDetails is attached to network_connected and network_disconnected
+// events and network_lost and network_restored alerts.
+type NetworkDetails struct {
+	Network string `json:"network"`
+}
+
+// ImageReportDetails is attached to image_report events on _healthmon.
+type ImageReportDetails struct {
+	DanglingImages   int   `json:"dangling_images"`
+	DanglingBytes    int64 `json:"dangling_bytes"`
+	FreedBytes       int64 `json:"freed_bytes"`
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+}
+
+// HealthCheckDetails is attached to unhealthy alerts with the result of
+// the healthcheck run that failed.
+type HealthCheckDetails struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// FailoverDetails is attached to monitor_failover alerts on _healthmon.
+type FailoverDetails struct {
+	Instance         string `json:"instance"`
+	PreviousInstance string `json:"previous_instance"`
+	LeaseExpiredAt   string `json:"lease_expired_at"`
+}
+
+// BackfillDetails marks events reconstructed from daemon logs.
+type BackfillDetails struct {
+	Source string `json:"source"`
+}
+
+// AnnotationDetails is attached to events posted by external tools, such as
+// a deployment pipeline, through POST /api/events.
+type AnnotationDetails struct {
+	Source string `json:"source,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// ConfigChangeDetails is attached to config_changed events with the names
+// of the environment variables that were added, removed or changed, and
+// whether the entrypoint or command changed. Values are never recorded.
+type ConfigChangeDetails struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Command bool     `json:"command"`
+}
+
+// EngineDetails is attached to engine_changed events on _healthmon.
+type EngineDetails struct {
+	OldVersion    string `json:"old_version"`
+	NewVersion    string `json:"new_version"`
+	OldAPIVersion string `json:"old_api_version"`
+	NewAPIVersion string `json:"new_api_version"`
+}
+
+// StormDetails is attached to restart_storm and restart_storm_ended alerts
+// on _healthmon with the containers that restarted.
+type StormDetails struct {
+	Containers    []string `json:"containers"`
+	WindowSeconds int      `json:"window_seconds"`
+}
+
+// AnomalyDetails is attached to anomaly events: the count of one event type
+// over the last 24 hours and its daily mean and standard deviation over the
+// days before.
+type AnomalyDetails struct {
+	EventType string  `json:"event_type"`
+	Count     int64   `json:"count"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"stddev"`
+	Days      int     `json:"days"`
+}
+
+// ReadinessDetails is attached to readiness_regressed alerts: how long the
+// first start of the new image took to become healthy, against the median
+// of the image before.
+type ReadinessDetails struct {
+	DurationMS int64  `json:"duration_ms"`
+	BaselineMS int64  `json:"baseline_ms"`
+	OldImageID string `json:"old_image_id"`
+	NewImageID string `json:"new_image_id"`
+}
+
+func (RestartDetails) DetailsKind() string        { return "restart" }
+func (ImageUpdateDetails) DetailsKind() string    { return "image_update" }
+func (OOMDetails) DetailsKind() string            { return "oom" }
+func (SelfInflictedDetails) DetailsKind() string  { return "self_inflicted" }
+func (PanicDetails) DetailsKind() string          { return "panic" }
+func (DriftDetails) DetailsKind() string          { return "drift" }
+func (ScaleDetails) DetailsKind() string          { return "scale" }
+func (TaskDetails) DetailsKind() string           { return "task" }
+func (EmulationDetails) DetailsKind() string      { return "emulation" }
+func (MaintenanceDetails) DetailsKind() string    { return "db_maintenance" }
+func (DiagnosticDetails) DetailsKind() string     { return "diagnostic" }
+func (BackfillDetails) DetailsKind() string       { return "backfill" }
+func (HealthCheckDetails) DetailsKind() string    { return "health_check" }
+func (FailoverDetails) DetailsKind() string       { return "failover" }
+func (AnnotationDetails) DetailsKind() string     { return "annotation" }
+func (MemoryPressureDetails) DetailsKind() string { return "memory_pressure" }
+func (LimitPressureDetails) DetailsKind() string  { return "limit_pressure" }
+func (ClockDetails) DetailsKind() string          { return "clock" }
+func (StuckStateDetails) DetailsKind() string     { return "stuck_state" }
+func (ExpectedDetails) DetailsKind() string       { return "expected" }
+func (NetworkDetails) DetailsKind() string        { return "network" }
+func (ImageReportDetails) DetailsKind() string    { return "image_report" }
+func (ConfigChangeDetails) DetailsKind() string   { return "config_change" }
+func (EngineDetails) DetailsKind() string         { return "engine" }
+func (StormDetails) DetailsKind() string          { return "storm" }
+func (AnomalyDetails) DetailsKind() string        { return "anomaly" }
+func (ReadinessDetails) DetailsKind() string      { return "readiness" }
+
+// detailKinds maps every kind to a constructor of its payload.
+var detailKinds = map[string]func() Details{
+	"restart":         func() Details { return &RestartDetails{} },
+	"image_update":    func() Details { return &ImageUpdateDetails{} },
+	"oom":             func() Details { return &OOMDetails{} },
+	"self_inflicted":  func() Details { return &SelfInflictedDetails{} },
+	"panic":           func() Details { return &PanicDetails{} },
+	"drift":           func() Details { return &DriftDetails{} },
+	"scale":           func() Details { return &ScaleDetails{} },
+	"task":            func() Details { return &TaskDetails{} },
+	"emulation":       func() Details { return &EmulationDetails{} },
+	"db_maintenance":  func() Details { return &MaintenanceDetails{} },
+	"diagnostic":      func() Details { return &DiagnosticDetails{} },
+	"backfill":        func() Details { return &BackfillDetails{} },
+	"health_check":    func() Details { return &HealthCheckDetails{} },
+	"failover":        func() Details { return &FailoverDetails{} },
+	"annotation":      func() Details { return &AnnotationDetails{} },
+	"memory_pressure": func() Details { return &MemoryPressureDetails{} },
+	"limit_pressure":  func() Details { return &LimitPressureDetails{} },
+	"clock":           func() Details { return &ClockDetails{} },
+	"stuck_state":     func() Details { return &StuckStateDetails{} },
+	"expected":        func() Details { return &ExpectedDetails{} },
+	"network":         func() Details { return &NetworkDetails{} },
+	"image_report":    func() Details { return &ImageReportDetails{} },
+	"config_change":   func() Details { return &ConfigChangeDetails{} },
+	"engine":          func() Details { return &EngineDetails{} },
+	"storm":           func() Details { return &StormDetails{} },
+	"anomaly":         func() Details { return &AnomalyDetails{} },
+	"readiness":       func() Details { return &ReadinessDetails{} },
+}
+
+// EncodeDetails serializes d for DetailsJSON, with "kind" as its first key.
+func EncodeDetails(d Details) string {
+	raw, err := json.Marshal(d)
+	if err != nil || len(raw) < 2 || raw[0] != '{' {
+		panic(fmt.Sprintf("details %T do not encode to an object", d))
+	}
+	kind, _ := json.Marshal(d.DetailsKind())
+	var buf bytes.Buffer
+	buf.WriteString(`{"kind":`)
+	buf.Write(kind)
+	if len(raw) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(raw[1:])
+	return buf.String()
+}
+
+// DecodeDetails parses DetailsJSON into its typed payload, a pointer to one
+// of the *Details structs. It returns nil for empty details.
+func DecodeDetails(raw string) (Details, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, fmt.Errorf("details: %w", err)
+	}
+	var kind string
+	if err := json.Unmarshal(fields["kind"], &kind); err != nil || kind == "" {
+		return nil, fmt.Errorf("details: missing kind")
+	}
+	newDetails, ok := detailKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("details: unknown kind %q", kind)
+	}
+	delete(fields, "kind")
+	rest, _ := json.Marshal(fields)
+	d := newDetails()
+	dec := json.NewDecoder(bytes.NewReader(rest))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(d); err != nil {
+		return nil, fmt.Errorf("%s details: %w", kind, err)
+	}
+	return d, nil
+}
+
+// validateDetails rejects details that do not match the schema of their
+// kind, before they are written.
+func validateDetails(raw string) error {
+	_, err := DecodeDetails(raw)
+	return err
+}
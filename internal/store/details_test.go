@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestDetailsRoundTripWithKindFirst(t *testing.T) {
+	raw := EncodeDetails(ScaleDetails{OldReplicas: 1, NewReplicas: 3})
+	if raw != `{"kind":"scale","old_replicas":1,"new_replicas":3}` {
+		t.Fatalf("unexpected encoding %s", raw)
+	}
+	if raw := EncodeDetails(ImageUpdateDetails{}); raw != `{"kind":"image_update"}` {
+		t.Fatalf("unexpected empty encoding %s", raw)
+	}
+
+	decoded, err := DecodeDetails(EncodeDetails(DriftDetails{Changes: map[string]DriftChange{"status": {Before: "exited", After: "running"}}}))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := &DriftDetails{Changes: map[string]DriftChange{"status": {Before: "exited", After: "running"}}}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Fatalf("expected %#v, got %#v", want, decoded)
+	}
+
+	for _, raw := range []string{
+		`{"restart_count":1}`,
+		`{"kind":"nope"}`,
+		`{"kind":"restart","restart_count":1,"extra":true}`,
+		`{"kind":"restart","restart_count":"one"}`,
+	} {
+		if _, err := DecodeDetails(raw); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestAddEventRejectsUntypedDetails(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if err := st.UpsertContainer(ctx, Container{Name: "web", ContainerID: "cid-web", Caps: []string{}, CreatedAt: now, UpdatedAt: now, Present: true}); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	c, _ := st.GetContainer("web")
+	event := Event{ContainerPK: c.ID, Container: c.Name, ContainerID: c.ContainerID, Type: "restart", Severity: "blue", Message: "Restart event: die", Timestamp: now}
+
+	event.DetailsJSON = `{"exit_code":1}`
+	if _, err := st.AddEvent(ctx, event); err == nil {
+		t.Fatalf("expected details without a kind to be rejected")
+	}
+	event.DetailsJSON = EncodeDetails(RestartDetails{RestartCount: 2})
+	if _, err := st.AddEvent(ctx, event); err != nil {
+		t.Fatalf("add typed event: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// Filter narrows event and alert listings. Zero fields do not filter; list
+// fields match any of their values.
+type Filter struct {
+	// Query is matched against event message, reason and details. Every word
+	// has to match, as a prefix on SQLite; words are taken literally rather
+	// than as FTS syntax. Alerts ignore it.
+	Query      string
+	Containers []string
+	// Groups matches containers by their healthmon.group label.
+	Groups     []string
+	Types      []string
+	Severities []string
+	Since      time.Time
+	Until      time.Time
+	// Unacknowledged keeps only alerts nobody has acknowledged yet. Events
+	// ignore it.
+	Unacknowledged bool
+	// Ascending lists oldest first instead of newest first.
+	Ascending bool
+}
+
+func (s *Store) CountEvents(ctx context.Context, f Filter) (int64, error) {
+	return s.countFiltered(ctx, f, "events")
+}
+
+func (s *Store) CountAlerts(ctx context.Context, f Filter) (int64, error) {
+	return s.countFiltered(ctx, f, "alerts")
+}
+
+func (s *Store) countFiltered(ctx context.Context, f Filter, table string) (int64, error) {
+	where, args, ok, err := s.filterWhere(ctx, f, table)
+	if err != nil || !ok {
+		return 0, err
+	}
+	var total int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM `+table+` WHERE `+where, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// filterWhere builds the WHERE clause for f on the events or alerts table. ok
+// is false when the filter cannot match anything, e.g. for unknown containers.
+func (s *Store) filterWhere(ctx context.Context, f Filter, table string) (string, []interface{}, bool, error) {
+	clauses := []string{"1 = 1"}
+	args := []interface{}{}
+
+	if terms := searchTerms(f.Query); len(terms) > 0 && table == "events" {
+		if db.DialectOf(s.db) == db.DialectPostgres {
+			clauses = append(clauses, `search @@ plainto_tsquery('simple', ?)`)
+			args = append(args, strings.Join(terms, " "))
+		} else {
+			quoted := make([]string, 0, len(terms))
+			for _, term := range terms {
+				quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+			}
+			clauses = append(clauses, `id IN (SELECT rowid FROM events_fts WHERE events_fts MATCH ?)`)
+			args = append(args, strings.Join(quoted, " "))
+		}
+	}
+	if len(f.Containers) > 0 {
+		pks := make([]interface{}, 0, len(f.Containers))
+		for _, name := range f.Containers {
+			c, found, err := s.GetContainerByName(ctx, name)
+			if err != nil {
+				return "", nil, false, err
+			}
+			if found {
+				pks = append(pks, c.ID)
+			}
+		}
+		if len(pks) == 0 {
+			return "", nil, false, nil
+		}
+		clauses = append(clauses, `container_pk IN (`+placeholders(len(pks))+`)`)
+		args = append(args, pks...)
+	}
+	if len(f.Groups) > 0 {
+		pks := s.groupContainerPKs(f.Groups)
+		if len(pks) == 0 {
+			return "", nil, false, nil
+		}
+		clauses = append(clauses, `container_pk IN (`+placeholders(len(pks))+`)`)
+		args = append(args, pks...)
+	}
+	if len(f.Types) > 0 {
+		column := "event_type"
+		if table == "alerts" {
+			column = "alert_type"
+		}
+		clauses = append(clauses, column+` IN (`+placeholders(len(f.Types))+`)`)
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	if len(f.Severities) > 0 {
+		clauses = append(clauses, `severity IN (`+placeholders(len(f.Severities))+`)`)
+		for _, severity := range f.Severities {
+			args = append(args, severity)
+		}
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, `ts >= ?`)
+		args = append(args, formatTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		clauses = append(clauses, `ts <= ?`)
+		args = append(args, formatTime(f.Until))
+	}
+	if f.Unacknowledged && table == "alerts" {
+		clauses = append(clauses, `acknowledged_at IS NULL`)
+	}
+	return strings.Join(clauses, " AND "), args, true, nil
+}
+
+// groupContainerPKs returns the ids of the containers, present or not, in
+// any of groups.
+func (s *Store) groupContainerPKs(groups []string) []interface{} {
+	var pks []interface{}
+	for _, c := range s.ListAllContainers() {
+		for _, group := range groups {
+			if c.Group() == group {
+				pks = append(pks, c.ID)
+				break
+			}
+		}
+	}
+	return pks
+}
+
+// pageClauses returns the cursor condition and sort order for a listing and
+// appends their arguments, including the limit, to args.
+func pageClauses(f Filter, cursor int64, limit int, args []interface{}) (string, string, []interface{}) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if f.Ascending {
+		if cursor < 0 {
+			cursor = 0
+		}
+		return `id > ?`, "ASC", append(args, cursor, limit)
+	}
+	if cursor <= 0 {
+		cursor = int64(^uint64(0) >> 1)
+	}
+	return `id < ?`, "DESC", append(args, cursor, limit)
+}
+
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestListAllEventsAppliesSearchAndFilters(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	pks := map[string]int64{}
+	for _, name := range []string{"nginx", "backup"} {
+		if err := st.UpsertContainer(ctx, Container{
+			Name:         name,
+			ContainerID:  "cid-" + name,
+			Image:        name,
+			ImageTag:     "latest",
+			ImageID:      "img-" + name,
+			CreatedAt:    now,
+			RegisteredAt: now,
+			StartedAt:    now,
+			Status:       "running",
+			Role:         "service",
+			Caps:         []string{},
+			User:         "0:0",
+			UpdatedAt:    now,
+			Present:      true,
+		}); err != nil {
+			t.Fatalf("upsert %s: %v", name, err)
+		}
+		c, _ := st.GetContainer(name)
+		pks[name] = c.ID
+	}
+
+	add := func(name, eventType, message, details string, ts time.Time) {
+		t.Helper()
+		if _, err := st.AddEvent(ctx, Event{
+			ContainerPK: pks[name],
+			Container:   name,
+			ContainerID: "cid-" + name,
+			Type:        eventType,
+			Severity:    "blue",
+			Message:     message,
+			Timestamp:   ts,
+			DetailsJSON: details,
+		}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	add("nginx", "restart", "Container exited with code 137", `{"kind":"restart","restart_count":1}`, now.Add(-2*time.Hour))
+	add("nginx", "restart", "Container exited with code 137", `{"kind":"restart","restart_count":1}`, now.Add(-10*24*time.Hour))
+	add("nginx", "restart", "Container exited with code 1", `{"kind":"restart","restart_count":1}`, now.Add(-time.Hour))
+	add("backup", "restart", "Container exited with code 137", `{"kind":"restart","restart_count":1}`, now.Add(-time.Hour))
+
+	filter := Filter{
+		Query:      "exit code 137",
+		Containers: []string{"nginx"},
+		Since:      now.Add(-7 * 24 * time.Hour),
+	}
+	items, err := st.ListAllEvents(ctx, filter, 0, 10)
+	if err != nil {
+		t.Fatalf("search events: %v", err)
+	}
+	total, err := st.CountEvents(ctx, filter)
+	if err != nil {
+		t.Fatalf("count events: %v", err)
+	}
+	if total != 1 || len(items) != 1 {
+		t.Fatalf("expected 1 match, got total=%d items=%d", total, len(items))
+	}
+	if items[0].Container != "nginx" || !items[0].Timestamp.After(now.Add(-3*time.Hour)) {
+		t.Fatalf("unexpected match %#v", items[0])
+	}
+
+	items, err = st.ListAllEvents(ctx, Filter{Query: `137" OR "1`}, 0, 10)
+	if err != nil {
+		t.Fatalf("search with quotes: %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("expected query syntax to be taken literally, got %d matches", len(items))
+	}
+
+	items, err = st.ListAllEvents(ctx, Filter{Containers: []string{"missing"}}, 0, 10)
+	if err != nil || len(items) != 0 {
+		t.Fatalf("expected no matches for unknown container, got %d (err=%v)", len(items), err)
+	}
+
+	items, err = st.ListAllEvents(ctx, Filter{Containers: []string{"nginx", "backup"}, Ascending: true}, 0, 2)
+	if err != nil {
+		t.Fatalf("list ascending: %v", err)
+	}
+	if len(items) != 2 || items[0].ID >= items[1].ID {
+		t.Fatalf("expected the two oldest events in ascending order, got %#v", items)
+	}
+	next, err := st.ListAllEvents(ctx, Filter{Containers: []string{"nginx", "backup"}, Ascending: true}, items[1].ID, 10)
+	if err != nil {
+		t.Fatalf("list ascending page 2: %v", err)
+	}
+	if len(next) != 2 || next[0].ID <= items[1].ID {
+		t.Fatalf("expected the remaining events after the cursor, got %#v", next)
+	}
+}
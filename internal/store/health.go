@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// HealthTransition is a change of a container's healthcheck status, e.g.
+// from healthy to unhealthy. From is empty for the first status healthmon
+// saw.
+type HealthTransition struct {
+	ID          int64
+	ContainerPK int64
+	From        string
+	To          string
+	Timestamp   time.Time
+}
+
+// normalizeHealth maps the statuses Docker reports to the ones transitions
+// are recorded with; "none" and "" both mean the container has no
+// healthcheck.
+func normalizeHealth(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if status == "none" {
+		return ""
+	}
+	return status
+}
+
+// healthTransition returns the transition an upsert of c makes from the
+// cached state of the container, or nil when its health did not change.
+func (s *Store) healthTransition(prev Container, hadPrev bool, c Container) *HealthTransition {
+	from, to := normalizeHealth(prev.HealthStatus), normalizeHealth(c.HealthStatus)
+	if !hadPrev {
+		from = ""
+	}
+	if from == to {
+		return nil
+	}
+	return &HealthTransition{From: from, To: to, Timestamp: s.clock.Now()}
+}
+
+// upsertContainerRow writes a container and, when its health changed, the
+// transition, and returns the container's row id.
+func upsertContainerRow(ctx context.Context, q db.Querier, args []interface{}, t *HealthTransition) (int64, error) {
+	var id int64
+	if err := q.QueryRowContext(ctx, upsertContainerQuery, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+	if t == nil {
+		return id, nil
+	}
+	_, err := q.ExecContext(ctx, `INSERT INTO health_transitions (container_pk, from_status, to_status, ts) VALUES (?, ?, ?, ?)`,
+		id, t.From, t.To, formatTime(t.Timestamp))
+	return id, err
+}
+
+// ListHealthTransitions returns the health transitions of a container since
+// the given time, oldest first, preceded by the last one before it, which
+// tells the status the container was in at since.
+func (s *Store) ListHealthTransitions(ctx context.Context, container string, since time.Time) ([]HealthTransition, error) {
+	c, ok, err := s.GetContainerByName(ctx, container)
+	if err != nil || !ok {
+		return []HealthTransition{}, err
+	}
+	items := []HealthTransition{}
+	scan := func(query string, args ...interface{}) error {
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			t := HealthTransition{ContainerPK: c.ID}
+			var ts string
+			if err := rows.Scan(&t.ID, &t.From, &t.To, &ts); err != nil {
+				return err
+			}
+			t.Timestamp = parseTime(ts)
+			items = append(items, t)
+		}
+		return rows.Err()
+	}
+	const columns = `SELECT id, from_status, to_status, ts FROM health_transitions`
+	if err := scan(columns+` WHERE container_pk = ? AND ts < ? ORDER BY ts DESC, id DESC LIMIT 1`, c.ID, formatTime(since)); err != nil {
+		return nil, err
+	}
+	if err := scan(columns+` WHERE container_pk = ? AND ts >= ? ORDER BY ts, id`, c.ID, formatTime(since)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
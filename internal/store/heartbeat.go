@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultHeartbeatInterval applies to heartbeats that never sent an interval.
+const DefaultHeartbeatInterval = time.Hour
+
+// ErrNotHeartbeat is returned when a heartbeat name is taken by a container.
+var ErrNotHeartbeat = errors.New("name belongs to a container")
+
+// RecordHeartbeat marks a check-in from an external job. Heartbeats are kept
+// as pseudo-containers with role "heartbeat", the check-in time in
+// LastSuccessAt and the expected interval in TaskMaxAge, so missed heartbeats
+// go through the same alerts as overdue tasks. A zero interval keeps the
+// previous one.
+func (s *Store) RecordHeartbeat(ctx context.Context, name string, interval time.Duration) (Container, error) {
+	now := s.clock.Now()
+	c, ok := s.GetContainer(name)
+	if ok && c.Role != "heartbeat" {
+		return Container{}, ErrNotHeartbeat
+	}
+	if !ok {
+		c = Container{
+			Name:         name,
+			Role:         "heartbeat",
+			Caps:         []string{},
+			CreatedAt:    now,
+			RegisteredAt: now,
+			StartedAt:    now,
+			Status:       "running",
+		}
+	}
+	switch {
+	case interval > 0:
+		c.TaskMaxAge = interval
+	case c.TaskMaxAge <= 0:
+		c.TaskMaxAge = DefaultHeartbeatInterval
+	}
+	c.LastSuccessAt = now
+	c.Present = true
+	c.UpdatedAt = now
+	if err := s.UpsertContainer(ctx, c); err != nil {
+		return Container{}, err
+	}
+	c, _ = s.GetContainer(name)
+	return c, nil
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// AlertHookRun is the result of a command HM_ALERT_HOOKS ran for an alert.
+// Target is "healthmon" for commands run next to healthmon and "container"
+// for commands run inside the alerting container. ExitCode is -1 when the
+// command did not finish.
+type AlertHookRun struct {
+	ID         int64
+	AlertID    int64
+	Target     string
+	Command    string
+	ExitCode   int
+	Output     string
+	Error      string
+	StartedAt  time.Time
+	DurationMS int64
+}
+
+// AddAlertHookRun stores the result of a hook and returns it with its id.
+func (s *Store) AddAlertHookRun(ctx context.Context, h AlertHookRun) (AlertHookRun, error) {
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		return q.QueryRowContext(ctx, `
+INSERT INTO alert_hook_runs (alert_id, target, command, exit_code, output, error, started_at, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, h.AlertID, h.Target, h.Command, h.ExitCode, h.Output, h.Error, formatTime(h.StartedAt), h.DurationMS).Scan(&h.ID)
+	})
+	if err != nil {
+		return AlertHookRun{}, err
+	}
+	return h, nil
+}
+
+// ListAlertHookRuns returns the hook results of the given alerts, oldest
+// first.
+func (s *Store) ListAlertHookRuns(ctx context.Context, alertIDs ...int64) ([]AlertHookRun, error) {
+	items := []AlertHookRun{}
+	if len(alertIDs) == 0 {
+		return items, nil
+	}
+	args := make([]interface{}, 0, len(alertIDs))
+	for _, id := range alertIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, alert_id, target, command, exit_code, output, error, started_at, duration_ms
+FROM alert_hook_runs
+WHERE alert_id IN (`+placeholders(len(args))+`)
+ORDER BY id ASC
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h AlertHookRun
+		var startedAt string
+		if err := rows.Scan(&h.ID, &h.AlertID, &h.Target, &h.Command, &h.ExitCode, &h.Output, &h.Error, &startedAt, &h.DurationMS); err != nil {
+			return nil, err
+		}
+		h.StartedAt = parseTime(startedAt)
+		items = append(items, h)
+	}
+	return items, rows.Err()
+}
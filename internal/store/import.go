@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// ImportHistory stores events and alerts converted from another monitoring
+// tool. Records are matched to containers by name, which must already exist.
+// A record is skipped when one with the same container, type, timestamp and
+// reason is already stored, so repeating an import does not duplicate it.
+// Containers' last_event_id is left alone since imported history predates
+// what healthmon observed itself. It returns how many events and alerts were
+// inserted.
+func (s *Store) ImportHistory(ctx context.Context, events []Event, alerts []Alert) (int, int, error) {
+	pks := make(map[string]int64)
+	for _, e := range events {
+		if c, ok := s.GetContainer(e.Container); ok {
+			pks[e.Container] = c.ID
+		}
+	}
+	for _, a := range alerts {
+		if c, ok := s.GetContainer(a.Container); ok {
+			pks[a.Container] = c.ID
+		}
+	}
+
+	for _, e := range events {
+		if pks[e.Container] == 0 {
+			return 0, 0, fmt.Errorf("import: unknown container %q", e.Container)
+		}
+		if err := validateDetails(e.DetailsJSON); err != nil {
+			return 0, 0, fmt.Errorf("import: %w", err)
+		}
+	}
+	for _, a := range alerts {
+		if pks[a.Container] == 0 {
+			return 0, 0, fmt.Errorf("import: unknown container %q", a.Container)
+		}
+		if err := validateDetails(a.DetailsJSON); err != nil {
+			return 0, 0, fmt.Errorf("import: %w", err)
+		}
+	}
+
+	var insertedEvents, insertedAlerts int
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		insertedEvents, insertedAlerts = 0, 0
+		for _, e := range events {
+			pk := pks[e.Container]
+			var existing int64
+			if err := q.QueryRowContext(ctx, `SELECT COUNT(1) FROM events WHERE container_pk = ? AND event_type = ? AND ts = ? AND reason = ?`, pk, e.Type, formatTime(e.Timestamp), e.Reason).Scan(&existing); err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+			_, err := q.ExecContext(ctx, `
+INSERT INTO events (container_pk, container_name, container_id, parsed_container_name, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, pk, e.Container, e.ContainerID, nullStr(e.ParsedContainerName), e.Type, e.Severity, e.Message, formatTime(e.Timestamp), nullStr(e.OldImage), nullStr(e.NewImage), nullStr(e.OldImageID), nullStr(e.NewImageID), nullStr(e.Reason), nullStr(e.DetailsJSON), nullIntPtr(e.ExitCode))
+			if err != nil {
+				return err
+			}
+			insertedEvents++
+		}
+		for _, a := range alerts {
+			pk := pks[a.Container]
+			var existing int64
+			if err := q.QueryRowContext(ctx, `SELECT COUNT(1) FROM alerts WHERE container_pk = ? AND alert_type = ? AND ts = ? AND reason = ?`, pk, a.Type, formatTime(a.Timestamp), a.Reason).Scan(&existing); err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+			_, err := q.ExecContext(ctx, `
+INSERT INTO alerts (container_pk, container_name, container_id, parsed_container_name, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, pk, a.Container, a.ContainerID, nullStr(a.ParsedContainerName), a.Type, a.Severity, a.Message, formatTime(a.Timestamp), nullStr(a.OldImage), nullStr(a.NewImage), nullStr(a.OldImageID), nullStr(a.NewImageID), nullStr(a.Reason), nullStr(a.DetailsJSON), nullIntPtr(a.ExitCode))
+			if err != nil {
+				return err
+			}
+			insertedAlerts++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return insertedEvents, insertedAlerts, nil
+}
+
+// ContainerNameByContainerID finds the container a Docker container id
+// belongs to, including ids it had before being recreated, which are only
+// kept in its events.
+func (s *Store) ContainerNameByContainerID(ctx context.Context, containerID string) (string, bool, error) {
+	if c, ok, err := s.GetContainerByContainerID(ctx, containerID); err != nil || ok {
+		return c.Name, ok, err
+	}
+	var name string
+	err := s.db.QueryRowContext(ctx, `SELECT container_name FROM events WHERE container_id = ? ORDER BY id DESC LIMIT 1`, containerID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return name, true, nil
+}
+
+// HasEventNear reports whether container has an event of eventType within
+// window of ts.
+func (s *Store) HasEventNear(ctx context.Context, container, eventType string, ts time.Time, window time.Duration) (bool, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM events WHERE container_name = ? AND event_type = ? AND ts >= ? AND ts <= ?`,
+		container, eventType, formatTime(ts.Add(-window)), formatTime(ts.Add(window))).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+const incidentColumns = `id, container_pk, container_name, incident_type, severity, message, opened_at, resolved_at`
+
+func (s *Store) AddIncident(ctx context.Context, inc Incident) (int64, error) {
+	var id int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		return q.QueryRowContext(ctx, `
+INSERT INTO incidents (container_pk, container_name, incident_type, severity, message, opened_at, resolved_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, inc.ContainerPK, inc.Container, inc.Type, inc.Severity, inc.Message, formatTime(inc.OpenedAt), nullTime(inc.ResolvedAt)).Scan(&id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *Store) LinkAlertToIncident(ctx context.Context, alertID, incidentID int64) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE alerts SET incident_id = ? WHERE id = ?`, incidentID, alertID)
+		return err
+	})
+}
+
+func (s *Store) ResolveIncident(ctx context.Context, incidentID int64, resolvedAt time.Time) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE incidents SET resolved_at = ? WHERE id = ? AND resolved_at IS NULL`, formatTime(resolvedAt), incidentID)
+		return err
+	})
+}
+
+// GetOpenIncidentByContainerPK returns the most recent unresolved incident for
+// a container, if any.
+func (s *Store) GetOpenIncidentByContainerPK(ctx context.Context, containerPK int64) (Incident, bool, error) {
+	if containerPK == 0 {
+		return Incident{}, false, nil
+	}
+	inc, err := s.scanIncident(s.db.QueryRowContext(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE container_pk = ? AND resolved_at IS NULL
+ORDER BY id DESC
+LIMIT 1
+`, containerPK))
+	if err == sql.ErrNoRows {
+		return Incident{}, false, nil
+	}
+	if err != nil {
+		return Incident{}, false, err
+	}
+	incs := []Incident{inc}
+	if err := s.loadIncidentAlertIDs(ctx, incs); err != nil {
+		return Incident{}, false, err
+	}
+	return incs[0], true, nil
+}
+
+// LatestIncidents returns the most recent incident of every container that
+// has one, resolved or not, newest first.
+func (s *Store) LatestIncidents(ctx context.Context) ([]Incident, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE id IN (SELECT MAX(id) FROM incidents GROUP BY container_pk)
+ORDER BY id DESC
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Incident{}
+	for rows.Next() {
+		inc, err := s.scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, inc)
+	}
+	return items, rows.Err()
+}
+
+func (s *Store) GetIncident(ctx context.Context, id int64) (Incident, bool, error) {
+	if id <= 0 {
+		return Incident{}, false, nil
+	}
+	inc, err := s.scanIncident(s.db.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return Incident{}, false, nil
+	}
+	if err != nil {
+		return Incident{}, false, err
+	}
+	incs := []Incident{inc}
+	if err := s.loadIncidentAlertIDs(ctx, incs); err != nil {
+		return Incident{}, false, err
+	}
+	return incs[0], true, nil
+}
+
+func (s *Store) scanIncident(row rowScanner) (Incident, error) {
+	var inc Incident
+	var openedAt string
+	var resolvedAt sql.NullString
+	if err := row.Scan(&inc.ID, &inc.ContainerPK, &inc.Container, &inc.Type, &inc.Severity, &inc.Message, &openedAt, &resolvedAt); err != nil {
+		return Incident{}, err
+	}
+	inc.OpenedAt = parseTime(openedAt)
+	if resolvedAt.Valid {
+		inc.ResolvedAt = parseTime(resolvedAt.String)
+	}
+	inc.Container = s.resolveContainerName(inc.ContainerPK, "", inc.Container)
+	return inc, nil
+}
+
+// loadIncidentAlertIDs fills in the alert IDs of incs with one query.
+func (s *Store) loadIncidentAlertIDs(ctx context.Context, incs []Incident) error {
+	if len(incs) == 0 {
+		return nil
+	}
+	index := make(map[int64]int, len(incs))
+	ids := make([]interface{}, 0, len(incs))
+	for i := range incs {
+		incs[i].AlertIDs = []int64{}
+		index[incs[i].ID] = i
+		ids = append(ids, incs[i].ID)
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT incident_id, id FROM alerts WHERE incident_id IN (`+placeholders(len(ids))+`) ORDER BY id ASC`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var incidentID, id int64
+		if err := rows.Scan(&incidentID, &id); err != nil {
+			return err
+		}
+		if i, ok := index[incidentID]; ok {
+			incs[i].AlertIDs = append(incs[i].AlertIDs, id)
+		}
+	}
+	return rows.Err()
+}
+
+// IncidentFilter narrows ListIncidents.
+type IncidentFilter struct {
+	Containers []string
+	// Open keeps only the incidents that are not resolved yet.
+	Open bool
+	// Since keeps the incidents opened at or after it.
+	Since time.Time
+}
+
+// ListIncidents returns a page of incidents with their alert IDs, newest
+// first, starting before cursor.
+func (s *Store) ListIncidents(ctx context.Context, f IncidentFilter, cursor int64, limit int) ([]Incident, error) {
+	clauses := []string{"1 = 1"}
+	var args []interface{}
+	if len(f.Containers) > 0 {
+		pks := make([]interface{}, 0, len(f.Containers))
+		for _, name := range f.Containers {
+			c, found, err := s.GetContainerByName(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				pks = append(pks, c.ID)
+			}
+		}
+		if len(pks) == 0 {
+			return []Incident{}, nil
+		}
+		clauses = append(clauses, `container_pk IN (`+placeholders(len(pks))+`)`)
+		args = append(args, pks...)
+	}
+	if f.Open {
+		clauses = append(clauses, `resolved_at IS NULL`)
+	}
+	if !f.Since.IsZero() {
+		clauses = append(clauses, `opened_at >= ?`)
+		args = append(args, formatTime(f.Since))
+	}
+	cursorClause, order, args := pageClauses(Filter{}, cursor, limit, args)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+incidentColumns+`
+FROM incidents
+WHERE `+strings.Join(clauses, " AND ")+` AND `+cursorClause+`
+ORDER BY id `+order+`
+LIMIT ?
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	items := []Incident{}
+	for rows.Next() {
+		inc, err := s.scanIncident(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		items = append(items, inc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.loadIncidentAlertIDs(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// IncidentAlerts returns the alerts grouped into an incident, oldest first.
+func (s *Store) IncidentAlerts(ctx context.Context, incidentID int64) ([]Alert, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+alertColumns+` FROM alerts WHERE incident_id = ? ORDER BY id ASC`, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Alert{}
+	for rows.Next() {
+		a, err := s.scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// RetypeIncident changes what an open incident is about, e.g. when a
+// container that was only unhealthy starts restart-looping too.
+func (s *Store) RetypeIncident(ctx context.Context, incidentID int64, incidentType, severity, message string) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE incidents SET incident_type = ?, severity = ?, message = ? WHERE id = ?`, incidentType, severity, message, incidentID)
+		return err
+	})
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// Lease is a named lock in the database held by one instance until it
+// expires. Instances sharing a database use it to elect the one that
+// monitors Docker.
+type Lease struct {
+	Name       string
+	Holder     string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+}
+
+// AcquireLease takes the lease called name for holder, or renews it when
+// holder already has it, so that it expires ttl from now. Another holder's
+// lease is only taken once it expired. It returns the lease as stored
+// afterwards and, when holder took it over from another instance, the
+// lease it replaced.
+func (s *Store) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (Lease, Lease, error) {
+	now := s.clock.Now()
+	var current, replaced Lease
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		previous, found, err := queryLease(ctx, q, name)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, `
+INSERT INTO leader_lease (name, holder, acquired_at, expires_at)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(name) DO UPDATE SET
+  holder=excluded.holder,
+  acquired_at=CASE WHEN leader_lease.holder = excluded.holder THEN leader_lease.acquired_at ELSE excluded.acquired_at END,
+  expires_at=excluded.expires_at
+WHERE leader_lease.holder = excluded.holder OR leader_lease.expires_at < excluded.acquired_at
+`, name, holder, formatTime(now), formatTime(now.Add(ttl)))
+		if err != nil {
+			return err
+		}
+		current, _, err = queryLease(ctx, q, name)
+		if err != nil {
+			return err
+		}
+		if found && previous.Holder != holder && current.Holder == holder {
+			replaced = previous
+		}
+		return nil
+	})
+	if err != nil {
+		return Lease{}, Lease{}, err
+	}
+	return current, replaced, nil
+}
+
+// ReleaseLease gives up holder's lease so another instance can take it
+// right away.
+func (s *Store) ReleaseLease(ctx context.Context, name, holder string) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `DELETE FROM leader_lease WHERE name = ? AND holder = ?`, name, holder)
+		return err
+	})
+}
+
+func queryLease(ctx context.Context, q db.Querier, name string) (Lease, bool, error) {
+	l := Lease{Name: name}
+	var acquiredAt, expiresAt string
+	err := q.QueryRowContext(ctx, `SELECT holder, acquired_at, expires_at FROM leader_lease WHERE name = ?`, name).Scan(&l.Holder, &acquiredAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return Lease{}, false, nil
+	}
+	if err != nil {
+		return Lease{}, false, err
+	}
+	l.AcquiredAt = parseTime(acquiredAt)
+	l.ExpiresAt = parseTime(expiresAt)
+	return l, true, nil
+}
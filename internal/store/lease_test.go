@@ -0,0 +1,61 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/db"
+)
+
+func TestLeaseIsTakenOverOnlyAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	st := New(dbConn.SQL)
+	defer st.Close()
+	st.WithClock(fake)
+
+	lease, replaced, err := st.AcquireLease(ctx, "monitor", "a", 15*time.Second)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if lease.Holder != "a" || replaced.Holder != "" {
+		t.Fatalf("expected a to take the free lease, got %+v replacing %+v", lease, replaced)
+	}
+
+	fake.Advance(10 * time.Second)
+	if lease, _, _ = st.AcquireLease(ctx, "monitor", "b", 15*time.Second); lease.Holder != "a" {
+		t.Fatalf("expected b to wait for a's lease, got %+v", lease)
+	}
+	if lease, _, _ = st.AcquireLease(ctx, "monitor", "a", 15*time.Second); lease.Holder != "a" || !lease.ExpiresAt.Equal(fake.Now().Add(15*time.Second)) {
+		t.Fatalf("expected a to renew its lease, got %+v", lease)
+	}
+
+	// a stops renewing.
+	fake.Advance(20 * time.Second)
+	lease, replaced, err = st.AcquireLease(ctx, "monitor", "b", 15*time.Second)
+	if err != nil {
+		t.Fatalf("take over: %v", err)
+	}
+	if lease.Holder != "b" || replaced.Holder != "a" || !lease.AcquiredAt.Equal(fake.Now()) {
+		t.Fatalf("expected b to take over from a, got %+v replacing %+v", lease, replaced)
+	}
+
+	// A released lease is free right away and replaces nothing.
+	if err := st.ReleaseLease(ctx, "monitor", "b"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if lease, replaced, _ = st.AcquireLease(ctx, "monitor", "a", 15*time.Second); lease.Holder != "a" || replaced.Holder != "" {
+		t.Fatalf("expected a to take the released lease, got %+v replacing %+v", lease, replaced)
+	}
+}
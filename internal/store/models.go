@@ -1,6 +1,9 @@
 package store
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Container struct {
 	ID                   int64
@@ -33,6 +36,79 @@ type Container struct {
 	RestartStreak        int
 	RestartLoopSince     time.Time
 	Healthcheck          *Healthcheck
+	// DockerRestartCount is Docker's RestartCount at the last inspect; it
+	// reveals restarts that happened while healthmon was not watching.
+	DockerRestartCount int
+	// UnhealthyGrace defers the unhealthy alert until the container has
+	// stayed unhealthy this long.
+	UnhealthyGrace time.Duration
+	// LastSuccessAt is when a task container last exited cleanly. Upserts
+	// keep the stored value when it is left zero.
+	LastSuccessAt time.Time
+	// TaskMaxAge is how long a task may go without a clean exit before it is
+	// reported overdue; zero disables the check.
+	TaskMaxAge time.Duration
+	// Platform is the os/arch[/variant] of the container's image. Upserts
+	// keep the stored value when it is left empty.
+	Platform string
+	// DependsOn lists the services this container depends on, from compose
+	// depends_on and the healthmon.depends_on label. Networks lists the
+	// user-defined networks it is attached to. Upserts keep the stored
+	// values when they are left nil.
+	DependsOn []string
+	Networks  []string
+	// Labels are the container's Docker labels, limited to
+	// HM_LABEL_ALLOWLIST. Upserts keep the stored labels when left nil.
+	Labels map[string]string
+	// LastHealthProbe is the latest run of the container's healthcheck, or
+	// nil when it has none or it has not run yet.
+	LastHealthProbe *HealthProbe
+	// Config fingerprints the environment and command of the container, to
+	// tell configuration changes apart on recreate. Upserts keep the stored
+	// fingerprint when it is left nil.
+	Config *ConfigFingerprint
+	// ImageDigest is the digest the container's image reference pins, as
+	// in image@sha256:..., or empty when it follows a floating tag.
+	ImageDigest string
+}
+
+// ConfigFingerprint holds salted hashes of a container's configuration,
+// never the values themselves: one per environment variable, keyed by its
+// name, and one of the entrypoint and command.
+type ConfigFingerprint struct {
+	Env     map[string]string `json:"env"`
+	Command string            `json:"command"`
+}
+
+// Labels healthmon reads for presentation.
+const (
+	DisplayNameLabel = "healthmon.display_name"
+	GroupLabel       = "healthmon.group"
+)
+
+// DisplayName is the name to show for the container, from the
+// healthmon.display_name label. It is empty when the label is not set.
+func (c Container) DisplayName() string {
+	return strings.TrimSpace(c.Labels[DisplayNameLabel])
+}
+
+// Group is the container's healthmon.group label, or empty.
+func (c Container) Group() string {
+	return strings.TrimSpace(c.Labels[GroupLabel])
+}
+
+// HealthPending reports whether the container is unhealthy but still within
+// its grace period, so no unhealthy alert has been raised yet.
+func (c Container) HealthPending(now time.Time) bool {
+	return c.UnhealthyGrace > 0 && strings.EqualFold(c.HealthStatus, "unhealthy") &&
+		!c.UnhealthySince.IsZero() && now.Sub(c.UnhealthySince) < c.UnhealthyGrace
+}
+
+// HealthProbe is one run of a healthcheck, from Docker's State.Health.Log.
+type HealthProbe struct {
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output"`
+	At       time.Time `json:"at"`
 }
 
 type Healthcheck struct {
@@ -80,4 +156,25 @@ type Alert struct {
 	Reason              string
 	DetailsJSON         string
 	ExitCode            *int
+	IncidentID          int64
+	AcknowledgedAt      time.Time
+	// TelegramMessageID is the id of the Telegram message the alert was
+	// sent as, or zero.
+	TelegramMessageID int64
+	// Logs is the tail of the container's logs taken for a restart_loop
+	// alert. They may hold secrets, so they are stored apart from the alert
+	// and only read back by GetAlertLogs.
+	Logs string
+}
+
+type Incident struct {
+	ID          int64
+	ContainerPK int64
+	Container   string
+	Type        string
+	Severity    string
+	Message     string
+	OpenedAt    time.Time
+	ResolvedAt  time.Time
+	AlertIDs    []int64
 }
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// ContainerNotes is what people know about a service that Docker does not:
+// free-text notes, who owns it and where its runbook lives. Notes are kept
+// by service name, so they survive the container being recreated.
+type ContainerNotes struct {
+	Container  string
+	Notes      string
+	Owner      string
+	RunbookURL string
+	UpdatedAt  time.Time
+	UpdatedBy  string
+}
+
+// empty reports whether the notes hold nothing worth keeping.
+func (n ContainerNotes) empty() bool {
+	return n.Notes == "" && n.Owner == "" && n.RunbookURL == ""
+}
+
+// ContainerNotes returns the notes of a container from memory.
+func (s *Store) ContainerNotes(name string) (ContainerNotes, bool) {
+	s.notesMu.RLock()
+	defer s.notesMu.RUnlock()
+	n, ok := s.notes[name]
+	return n, ok
+}
+
+// SetContainerNotes saves the notes of a container, replacing the previous
+// ones. Empty notes delete them.
+func (s *Store) SetContainerNotes(ctx context.Context, n ContainerNotes) (ContainerNotes, error) {
+	if n.empty() {
+		_, err := s.DeleteContainerNotes(ctx, n.Container)
+		return ContainerNotes{}, err
+	}
+	n.UpdatedAt = s.clock.Now()
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `
+INSERT INTO container_notes (container_name, notes, owner, runbook_url, updated_at, updated_by)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(container_name) DO UPDATE SET notes = excluded.notes, owner = excluded.owner, runbook_url = excluded.runbook_url, updated_at = excluded.updated_at, updated_by = excluded.updated_by
+`, n.Container, n.Notes, n.Owner, n.RunbookURL, formatTime(n.UpdatedAt), n.UpdatedBy)
+		return err
+	})
+	if err != nil {
+		return ContainerNotes{}, err
+	}
+	s.notesMu.Lock()
+	s.notes[n.Container] = n
+	s.notesMu.Unlock()
+	return n, nil
+}
+
+// DeleteContainerNotes removes the notes of a container and reports whether
+// it had any.
+func (s *Store) DeleteContainerNotes(ctx context.Context, name string) (bool, error) {
+	var deleted int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		res, err := q.ExecContext(ctx, `DELETE FROM container_notes WHERE container_name = ?`, name)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	s.notesMu.Lock()
+	delete(s.notes, name)
+	s.notesMu.Unlock()
+	return deleted > 0, nil
+}
+
+func (s *Store) loadNotes(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT container_name, notes, owner, runbook_url, updated_at, updated_by FROM container_notes`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	notes := make(map[string]ContainerNotes)
+	for rows.Next() {
+		var n ContainerNotes
+		var updatedAt string
+		if err := rows.Scan(&n.Container, &n.Notes, &n.Owner, &n.RunbookURL, &updatedAt, &n.UpdatedBy); err != nil {
+			return err
+		}
+		n.UpdatedAt = parseTime(updatedAt)
+		notes[n.Container] = n
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	s.notesMu.Lock()
+	s.notes = notes
+	s.notesMu.Unlock()
+	return nil
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// Delivery statuses of a notification.
+const (
+	NotificationSent     = "sent"
+	NotificationSkipped  = "skipped"
+	NotificationRetrying = "retrying"
+	NotificationFailed   = "failed"
+)
+
+// Notification is the delivery of one alert through one notifier: whether
+// it was sent, skipped (muted or held for a digest), is waiting for a retry
+// or failed for good, after how many attempts and with what error.
+type Notification struct {
+	ID            int64
+	AlertID       int64
+	Container     string
+	AlertType     string
+	Notifier      string
+	Status        string
+	Attempts      int
+	Error         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	NextAttemptAt time.Time
+}
+
+// NotificationFilter narrows ListNotifications. Zero fields match
+// everything.
+type NotificationFilter struct {
+	AlertID   int64
+	Container string
+	Notifier  string
+	Statuses  []string
+}
+
+const notificationColumns = `id, alert_id, container_name, alert_type, notifier, status, attempts, error, created_at, updated_at, next_attempt_at`
+
+// AddNotification stores the first delivery attempt of an alert and returns
+// its id.
+func (s *Store) AddNotification(ctx context.Context, n Notification) (int64, error) {
+	var id int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		return q.QueryRowContext(ctx, `
+INSERT INTO notifications (alert_id, container_name, alert_type, notifier, status, attempts, error, created_at, updated_at, next_attempt_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, n.AlertID, n.Container, n.AlertType, n.Notifier, n.Status, n.Attempts, n.Error, formatTime(n.CreatedAt), formatTime(n.UpdatedAt), nullTime(n.NextAttemptAt)).Scan(&id)
+	})
+	return id, err
+}
+
+// UpdateNotification records the outcome of a retry.
+func (s *Store) UpdateNotification(ctx context.Context, n Notification) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE notifications SET status = ?, attempts = ?, error = ?, updated_at = ?, next_attempt_at = ? WHERE id = ?`,
+			n.Status, n.Attempts, n.Error, formatTime(n.UpdatedAt), nullTime(n.NextAttemptAt), n.ID)
+		return err
+	})
+}
+
+// ListNotifications returns the deliveries matching filter, newest first,
+// starting below beforeID when it is set.
+func (s *Store) ListNotifications(ctx context.Context, filter NotificationFilter, beforeID int64, limit int) ([]Notification, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var where []string
+	var args []interface{}
+	if beforeID > 0 {
+		where = append(where, "id < ?")
+		args = append(args, beforeID)
+	}
+	if filter.AlertID > 0 {
+		where = append(where, "alert_id = ?")
+		args = append(args, filter.AlertID)
+	}
+	if filter.Container != "" {
+		where = append(where, "container_name = ?")
+		args = append(args, filter.Container)
+	}
+	if filter.Notifier != "" {
+		where = append(where, "notifier = ?")
+		args = append(args, filter.Notifier)
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status IN ("+placeholders(len(filter.Statuses))+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	query := `SELECT ` + notificationColumns + ` FROM notifications`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var createdAt, updatedAt string
+		var nextAttemptAt sql.NullString
+		if err := rows.Scan(&n.ID, &n.AlertID, &n.Container, &n.AlertType, &n.Notifier, &n.Status, &n.Attempts, &n.Error, &createdAt, &updatedAt, &nextAttemptAt); err != nil {
+			return nil, err
+		}
+		n.CreatedAt = parseTime(createdAt)
+		n.UpdatedAt = parseTime(updatedAt)
+		if nextAttemptAt.Valid {
+			n.NextAttemptAt = parseTime(nextAttemptAt.String)
+		}
+		items = append(items, n)
+	}
+	return items, rows.Err()
+}
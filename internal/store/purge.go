@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// PurgeResult counts what purging a container deleted.
+type PurgeResult struct {
+	Events    int64
+	Alerts    int64
+	Incidents int64
+}
+
+// PurgeContainer deletes a container with its events, alerts with their
+// comments and hook results, incidents, health transitions, readiness
+// samples, notification statuses, restart schedules and notes. It reports
+// false when there is no such container.
+func (s *Store) PurgeContainer(ctx context.Context, name string) (PurgeResult, bool, error) {
+	c, ok, err := s.GetContainerByName(ctx, name)
+	if err != nil || !ok {
+		return PurgeResult{}, false, err
+	}
+	var result PurgeResult
+	err = s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		for _, table := range []string{"alert_comments", "alert_hook_runs", "alert_logs"} {
+			if _, err := q.ExecContext(ctx, `DELETE FROM `+table+` WHERE alert_id IN (SELECT id FROM alerts WHERE container_pk = ?)`, c.ID); err != nil {
+				return err
+			}
+		}
+		deletes := []struct {
+			query string
+			count *int64
+		}{
+			{`DELETE FROM events WHERE container_pk = ?`, &result.Events},
+			{`DELETE FROM alerts WHERE container_pk = ?`, &result.Alerts},
+			{`DELETE FROM incidents WHERE container_pk = ?`, &result.Incidents},
+		}
+		for _, d := range deletes {
+			res, err := q.ExecContext(ctx, d.query, c.ID)
+			if err != nil {
+				return err
+			}
+			if *d.count, err = res.RowsAffected(); err != nil {
+				return err
+			}
+		}
+		for _, query := range []string{
+			`DELETE FROM notifications WHERE container_name = ?`,
+			`DELETE FROM restart_schedules WHERE container_name = ?`,
+			`DELETE FROM container_notes WHERE container_name = ?`,
+		} {
+			if _, err := q.ExecContext(ctx, query, c.Name); err != nil {
+				return err
+			}
+		}
+		for _, table := range []string{"health_transitions", "readiness_samples"} {
+			if _, err := q.ExecContext(ctx, `DELETE FROM `+table+` WHERE container_pk = ?`, c.ID); err != nil {
+				return err
+			}
+		}
+		_, err := q.ExecContext(ctx, `DELETE FROM containers WHERE id = ?`, c.ID)
+		return err
+	})
+	if err != nil {
+		return PurgeResult{}, true, err
+	}
+	s.cache.invalidate(c.Name)
+	s.notesMu.Lock()
+	delete(s.notes, c.Name)
+	s.notesMu.Unlock()
+	return result, true, nil
+}
+
+// PurgeRemovedContainers purges the containers that were removed before
+// the given time and returns their names.
+func (s *Store) PurgeRemovedContainers(ctx context.Context, before time.Time) ([]string, error) {
+	var purged []string
+	for _, c := range s.ListAllContainers() {
+		if c.Present || !c.UpdatedAt.Before(before) {
+			continue
+		}
+		if _, _, err := s.PurgeContainer(ctx, c.Name); err != nil {
+			return purged, err
+		}
+		purged = append(purged, c.Name)
+	}
+	return purged, nil
+}
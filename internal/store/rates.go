@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// EventRate is how many events of one type a container had within a time
+// bucket.
+type EventRate struct {
+	Container string
+	Type      string
+	Start     time.Time
+	Count     int64
+}
+
+// EventRates counts the events since the given time per container and type
+// in buckets of the given size, aligned to the Unix epoch. Empty buckets
+// are omitted.
+func (s *Store) EventRates(ctx context.Context, since time.Time, bucket time.Duration) ([]EventRate, error) {
+	size := int64(bucket / time.Second)
+	if size <= 0 {
+		size = 1
+	}
+	epoch := `CAST(strftime('%s', e.ts) AS INTEGER)`
+	if db.DialectOf(s.db) == db.DialectPostgres {
+		epoch = `CAST(FLOOR(EXTRACT(EPOCH FROM e.ts)) AS BIGINT)`
+	}
+	start := `(` + epoch + ` / ?) * ?`
+	rows, err := s.db.QueryContext(ctx, `
+SELECT c.name, e.event_type, `+start+` AS bucket_start, COUNT(1)
+FROM events e
+JOIN containers c ON c.id = e.container_pk
+WHERE e.ts >= ?
+GROUP BY c.name, e.event_type, bucket_start
+ORDER BY c.name ASC, e.event_type ASC, bucket_start ASC
+`, size, size, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []EventRate{}
+	for rows.Next() {
+		var item EventRate
+		var startUnix int64
+		if err := rows.Scan(&item.Container, &item.Type, &startUnix, &item.Count); err != nil {
+			return nil, err
+		}
+		item.Start = time.Unix(startUnix, 0).UTC()
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
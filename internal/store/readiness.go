@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// ReadinessSample is how long one start of a container took to pass its
+// healthcheck for the first time.
+type ReadinessSample struct {
+	ID          int64
+	ContainerPK int64
+	ContainerID string
+	ImageID     string
+	StartedAt   time.Time
+	ReadyAt     time.Time
+	Duration    time.Duration
+}
+
+// AddReadinessSample stores the readiness time of a start of the named
+// container. Every start is stored once; it reports false when the start
+// was already recorded or the container is unknown.
+func (s *Store) AddReadinessSample(ctx context.Context, container string, r ReadinessSample) (bool, error) {
+	c, ok, err := s.GetContainerByName(ctx, container)
+	if err != nil || !ok {
+		return false, err
+	}
+	var added int64
+	err = s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		res, err := q.ExecContext(ctx, `
+INSERT INTO readiness_samples (container_pk, container_id, image_id, started_at, ready_at, duration_ms)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(container_pk, started_at) DO NOTHING
+`, c.ID, r.ContainerID, r.ImageID, formatTime(r.StartedAt), formatTime(r.ReadyAt), r.Duration.Milliseconds())
+		if err != nil {
+			return err
+		}
+		added, err = res.RowsAffected()
+		return err
+	})
+	return added > 0, err
+}
+
+// ListReadinessSamples returns the latest readiness samples of a container,
+// newest first.
+func (s *Store) ListReadinessSamples(ctx context.Context, container string, limit int) ([]ReadinessSample, error) {
+	c, ok, err := s.GetContainerByName(ctx, container)
+	if err != nil || !ok {
+		return []ReadinessSample{}, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, container_id, image_id, started_at, ready_at, duration_ms
+FROM readiness_samples
+WHERE container_pk = ?
+ORDER BY ready_at DESC, id DESC
+LIMIT ?
+`, c.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReadinessSample{}
+	for rows.Next() {
+		r := ReadinessSample{ContainerPK: c.ID}
+		var startedAt, readyAt string
+		var ms int64
+		if err := rows.Scan(&r.ID, &r.ContainerID, &r.ImageID, &startedAt, &readyAt, &ms); err != nil {
+			return nil, err
+		}
+		r.StartedAt, r.ReadyAt = parseTime(startedAt), parseTime(readyAt)
+		r.Duration = time.Duration(ms) * time.Millisecond
+		items = append(items, r)
+	}
+	return items, rows.Err()
+}
+
+// ReadinessPercentile returns the p-th percentile (0-100) of the samples'
+// durations by the nearest-rank method, or 0 without samples.
+func ReadinessPercentile(samples []ReadinessSample, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	durations := make([]time.Duration, 0, len(samples))
+	for _, r := range samples {
+		durations = append(durations, r.Duration)
+	}
+	slices.Sort(durations)
+	rank := int(math.Ceil(float64(len(durations))*p/100)) - 1
+	return durations[max(0, min(rank, len(durations)-1))]
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// IntegrityCheck is the result of one consistency check run at startup.
+// Rows counts the rows that were inconsistent; Repaired reports whether
+// they were fixed or only reported.
+type IntegrityCheck struct {
+	Name        string
+	Description string
+	Rows        int64
+	Repaired    bool
+}
+
+// IntegrityReport is the outcome of the last CheckIntegrity run.
+type IntegrityReport struct {
+	CheckedAt time.Time
+	Checks    []IntegrityCheck
+}
+
+// Repair is a stored finding of a startup check that found rows to fix or
+// report. Findings are kept so an operator can tell when history was
+// rewritten and why.
+type Repair struct {
+	ID          int64
+	Check       string
+	Description string
+	Rows        int64
+	Repaired    bool
+	Timestamp   time.Time
+}
+
+// integrityCheck counts the rows violating an invariant with count and
+// fixes them with fix. Checks without a fix only report: fixing them would
+// mean deleting history.
+type integrityCheck struct {
+	name        string
+	description string
+	count       string
+	fix         string
+}
+
+var integrityChecks = []integrityCheck{
+	{
+		name:        "dangling_last_event",
+		description: "Containers whose last event no longer exists point at their latest remaining event",
+		count:       `SELECT COUNT(1) FROM containers WHERE last_event_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM events e WHERE e.id = containers.last_event_id)`,
+		fix:         `UPDATE containers SET last_event_id = (SELECT MAX(e.id) FROM events e WHERE e.container_pk = containers.id) WHERE last_event_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM events e WHERE e.id = containers.last_event_id)`,
+	},
+	{
+		name:        "event_container_names",
+		description: "Events recorded under another name than their container's were renamed to it",
+		count:       `SELECT COUNT(1) FROM events WHERE EXISTS (SELECT 1 FROM containers c WHERE c.id = events.container_pk AND c.name <> events.container_name)`,
+		fix:         `UPDATE events SET container_name = (SELECT c.name FROM containers c WHERE c.id = events.container_pk) WHERE EXISTS (SELECT 1 FROM containers c WHERE c.id = events.container_pk AND c.name <> events.container_name)`,
+	},
+	{
+		name:        "alert_container_names",
+		description: "Alerts recorded under another name than their container's were renamed to it",
+		count:       `SELECT COUNT(1) FROM alerts WHERE EXISTS (SELECT 1 FROM containers c WHERE c.id = alerts.container_pk AND c.name <> alerts.container_name)`,
+		fix:         `UPDATE alerts SET container_name = (SELECT c.name FROM containers c WHERE c.id = alerts.container_pk) WHERE EXISTS (SELECT 1 FROM containers c WHERE c.id = alerts.container_pk AND c.name <> alerts.container_name)`,
+	},
+	{
+		name:        "dangling_incidents",
+		description: "Alerts linked to an incident that no longer exists were unlinked",
+		count:       `SELECT COUNT(1) FROM alerts WHERE incident_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM incidents i WHERE i.id = alerts.incident_id)`,
+		fix:         `UPDATE alerts SET incident_id = NULL WHERE incident_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM incidents i WHERE i.id = alerts.incident_id)`,
+	},
+	{
+		name:        "orphan_events",
+		description: "Events whose container no longer exists; they are kept but not shown under any container",
+		count:       `SELECT COUNT(1) FROM events WHERE NOT EXISTS (SELECT 1 FROM containers c WHERE c.id = events.container_pk)`,
+	},
+	{
+		name:        "orphan_alerts",
+		description: "Alerts whose container no longer exists; they are kept but not shown under any container",
+		count:       `SELECT COUNT(1) FROM alerts WHERE NOT EXISTS (SELECT 1 FROM containers c WHERE c.id = alerts.container_pk)`,
+	},
+}
+
+// CheckIntegrity runs the startup consistency checks, fixes what can be
+// fixed and stores every finding. Run it before Load so the cache sees the
+// repaired rows.
+func (s *Store) CheckIntegrity(ctx context.Context) (IntegrityReport, error) {
+	report := IntegrityReport{CheckedAt: s.clock.Now()}
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		for _, check := range integrityChecks {
+			result := IntegrityCheck{Name: check.name, Description: check.description}
+			if err := q.QueryRowContext(ctx, check.count).Scan(&result.Rows); err != nil {
+				return err
+			}
+			if result.Rows > 0 && check.fix != "" {
+				res, err := q.ExecContext(ctx, check.fix)
+				if err != nil {
+					return err
+				}
+				if result.Rows, err = res.RowsAffected(); err != nil {
+					return err
+				}
+				result.Repaired = true
+			}
+			if result.Rows > 0 {
+				if _, err := q.ExecContext(ctx, `INSERT INTO repairs (check_name, description, rows_affected, repaired, ts) VALUES (?, ?, ?, ?, ?)`,
+					result.Name, result.Description, result.Rows, boolToInt(result.Repaired), formatTime(report.CheckedAt)); err != nil {
+					return err
+				}
+			}
+			report.Checks = append(report.Checks, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	s.integrity.Store(&report)
+	return report, nil
+}
+
+// LastIntegrityCheck returns the report of the last CheckIntegrity run, if
+// there was one.
+func (s *Store) LastIntegrityCheck() (IntegrityReport, bool) {
+	report := s.integrity.Load()
+	if report == nil {
+		return IntegrityReport{}, false
+	}
+	return *report, true
+}
+
+// ListRepairs returns the stored findings of past checks, newest first.
+func (s *Store) ListRepairs(ctx context.Context, limit int) ([]Repair, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT id, check_name, description, rows_affected, repaired, ts FROM repairs ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Repair{}
+	for rows.Next() {
+		var r Repair
+		var repaired int
+		var ts string
+		if err := rows.Scan(&r.ID, &r.Check, &r.Description, &r.Rows, &repaired, &ts); err != nil {
+			return nil, err
+		}
+		r.Repaired = repaired == 1
+		r.Timestamp = parseTime(ts)
+		items = append(items, r)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// RestartSchedule is a planned restart of a container, run once at NextRunAt
+// or, with an Interval, repeatedly from then on.
+type RestartSchedule struct {
+	ID        int64
+	Container string
+	NextRunAt time.Time
+	Interval  time.Duration
+	CreatedAt time.Time
+	LastRunAt time.Time
+}
+
+const restartScheduleColumns = `id, container_name, next_run_at, interval_seconds, created_at, last_run_at`
+
+func (s *Store) AddRestartSchedule(ctx context.Context, rs RestartSchedule) (RestartSchedule, error) {
+	rs.CreatedAt = s.clock.Now()
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		return q.QueryRowContext(ctx, `
+INSERT INTO restart_schedules (container_name, next_run_at, interval_seconds, created_at)
+VALUES (?, ?, ?, ?)
+RETURNING id
+`, rs.Container, formatTime(rs.NextRunAt), int64(rs.Interval/time.Second), formatTime(rs.CreatedAt)).Scan(&rs.ID)
+	})
+	if err != nil {
+		return RestartSchedule{}, err
+	}
+	return rs, nil
+}
+
+// ListRestartSchedules returns all schedules, next due first.
+func (s *Store) ListRestartSchedules(ctx context.Context) ([]RestartSchedule, error) {
+	return s.queryRestartSchedules(ctx, `SELECT `+restartScheduleColumns+` FROM restart_schedules ORDER BY next_run_at ASC, id ASC`)
+}
+
+// DueRestartSchedules returns the schedules due at now.
+func (s *Store) DueRestartSchedules(ctx context.Context, now time.Time) ([]RestartSchedule, error) {
+	return s.queryRestartSchedules(ctx, `SELECT `+restartScheduleColumns+` FROM restart_schedules WHERE next_run_at <= ? ORDER BY next_run_at ASC, id ASC`, formatTime(now))
+}
+
+func (s *Store) queryRestartSchedules(ctx context.Context, query string, args ...interface{}) ([]RestartSchedule, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RestartSchedule{}
+	for rows.Next() {
+		var rs RestartSchedule
+		var nextRunAt, createdAt string
+		var interval int64
+		var lastRunAt sql.NullString
+		if err := rows.Scan(&rs.ID, &rs.Container, &nextRunAt, &interval, &createdAt, &lastRunAt); err != nil {
+			return nil, err
+		}
+		rs.NextRunAt = parseTime(nextRunAt)
+		rs.Interval = time.Duration(interval) * time.Second
+		rs.CreatedAt = parseTime(createdAt)
+		if lastRunAt.Valid {
+			rs.LastRunAt = parseTime(lastRunAt.String)
+		}
+		items = append(items, rs)
+	}
+	return items, rows.Err()
+}
+
+// DeleteRestartSchedule removes a schedule and reports whether it existed.
+func (s *Store) DeleteRestartSchedule(ctx context.Context, id int64) (bool, error) {
+	var affected int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		res, err := q.ExecContext(ctx, `DELETE FROM restart_schedules WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	return affected > 0, err
+}
+
+// CompleteRestartSchedule records a run at ranAt. One-time schedules are
+// removed; recurring ones move to their first run time after ranAt.
+func (s *Store) CompleteRestartSchedule(ctx context.Context, rs RestartSchedule, ranAt time.Time) error {
+	if rs.Interval <= 0 {
+		_, err := s.DeleteRestartSchedule(ctx, rs.ID)
+		return err
+	}
+	next := rs.NextRunAt
+	for !next.After(ranAt) {
+		next = next.Add(rs.Interval)
+	}
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE restart_schedules SET next_run_at = ?, last_run_at = ? WHERE id = ?`, formatTime(next), formatTime(ranAt), rs.ID)
+		return err
+	})
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"healthmon/internal/db"
+)
+
+const configSaltSetting = "config_hash_salt"
+
+// ConfigHashSalt returns the salt configuration fingerprints are hashed
+// with. It is generated on first use and kept in the database, so
+// fingerprints stay comparable across restarts and between instances
+// sharing it.
+func (s *Store) ConfigHashSalt(ctx context.Context) (string, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	var value string
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		if _, err := q.ExecContext(ctx, `INSERT INTO settings (name, value) VALUES (?, ?) ON CONFLICT(name) DO NOTHING`, configSaltSetting, hex.EncodeToString(salt)); err != nil {
+			return err
+		}
+		return q.QueryRowContext(ctx, `SELECT value FROM settings WHERE name = ?`, configSaltSetting).Scan(&value)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New("config hash salt missing")
+	}
+	return value, err
+}
+
+// Setting returns a value healthmon keeps about itself or its host, such
+// as the last Docker Engine version it saw.
+func (s *Store) Setting(ctx context.Context, name string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE name = ?`, name).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// SetSetting stores a value, replacing the previous one.
+func (s *Store) SetSetting(ctx context.Context, name, value string) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `INSERT INTO settings (name, value) VALUES (?, ?) ON CONFLICT(name) DO UPDATE SET value = excluded.value`, name, value)
+		return err
+	})
+}
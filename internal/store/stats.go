@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// ContainerStats summarizes how a container behaved within a time window.
+type ContainerStats struct {
+	Restarts  int64
+	OOMs      int64
+	Unhealthy time.Duration
+}
+
+// ContainerStatsBetween returns per container stats for [since, until), keyed
+// by container primary key. Restarts healthmon caused itself are not
+// counted. Containers without restarts, OOM kills or unhealthy time are
+// omitted.
+func (s *Store) ContainerStatsBetween(ctx context.Context, since, until time.Time) (map[int64]ContainerStats, error) {
+	stats := make(map[int64]ContainerStats)
+	counts := []struct {
+		query string
+		add   func(*ContainerStats, int64)
+	}{
+		{`SELECT container_pk, COUNT(1) FROM events WHERE event_type = 'restart' AND COALESCE(reason, '') <> 'self_inflicted' AND ts >= ? AND ts < ? GROUP BY container_pk`, func(c *ContainerStats, n int64) { c.Restarts = n }},
+		{`SELECT container_pk, COUNT(1) FROM alerts WHERE alert_type = 'oom_killed' AND ts >= ? AND ts < ? GROUP BY container_pk`, func(c *ContainerStats, n int64) { c.OOMs = n }},
+	}
+	for _, count := range counts {
+		rows, err := s.db.QueryContext(ctx, count.query, formatTime(since), formatTime(until))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var pk, n int64
+			if err := rows.Scan(&pk, &n); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			item := stats[pk]
+			count.add(&item, n)
+			stats[pk] = item
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unhealthy, err := s.unhealthyDurations(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	for pk, d := range unhealthy {
+		item := stats[pk]
+		item.Unhealthy = d
+		stats[pk] = item
+	}
+	return stats, nil
+}
+
+// unhealthyDurations adds up the time between unhealthy and healthy alerts,
+// clipped to [since, until). A container that was unhealthy before since
+// counts from since; one that is still unhealthy counts until until.
+func (s *Store) unhealthyDurations(ctx context.Context, since, until time.Time) (map[int64]time.Duration, error) {
+	unhealthySince := make(map[int64]time.Time)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT container_pk, alert_type
+FROM alerts
+WHERE id IN (
+	SELECT MAX(id) FROM alerts
+	WHERE alert_type IN ('unhealthy', 'healthy') AND ts < ?
+	GROUP BY container_pk
+)
+`, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var pk int64
+		var alertType string
+		if err := rows.Scan(&pk, &alertType); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if alertType == "unhealthy" {
+			unhealthySince[pk] = since
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+SELECT container_pk, alert_type, ts
+FROM alerts
+WHERE alert_type IN ('unhealthy', 'healthy') AND ts >= ? AND ts < ?
+ORDER BY ts ASC, id ASC
+`, formatTime(since), formatTime(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	durations := make(map[int64]time.Duration)
+	for rows.Next() {
+		var pk int64
+		var alertType, ts string
+		if err := rows.Scan(&pk, &alertType, &ts); err != nil {
+			return nil, err
+		}
+		at := parseTime(ts)
+		start, open := unhealthySince[pk]
+		switch {
+		case alertType == "unhealthy" && !open:
+			unhealthySince[pk] = at
+		case alertType == "healthy" && open:
+			durations[pk] += at.Sub(start)
+			delete(unhealthySince, pk)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for pk, start := range unhealthySince {
+		durations[pk] += until.Sub(start)
+	}
+	return durations, nil
+}
+
+// RecentExitCodes returns the exit codes of the container's last restarts
+// that recorded one, at most limit, oldest first.
+func (s *Store) RecentExitCodes(ctx context.Context, containerPK int64, limit int) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT exit_code
+FROM events
+WHERE container_pk = ? AND event_type = 'restart' AND exit_code IS NOT NULL
+ORDER BY id DESC
+LIMIT ?
+`, containerPK, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []int
+	for rows.Next() {
+		var code int
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	slices.Reverse(codes)
+	return codes, rows.Err()
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestContainerStatsBetweenClipsUnhealthyTimeToWindow(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := st.UpsertContainer(ctx, Container{Name: "db", ContainerID: "cid-db", Status: "running", StartedAt: base}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	dbc, _ := st.GetContainer("db")
+	alert := func(alertType string, at time.Duration) {
+		t.Helper()
+		if _, err := st.AddAlert(ctx, Alert{ContainerPK: dbc.ID, Container: "db", ContainerID: "cid-db", Type: alertType, Severity: "red", Message: alertType, Timestamp: base.Add(at)}); err != nil {
+			t.Fatalf("add alert: %v", err)
+		}
+	}
+	// Unhealthy from before the window until 2h into it, then again for the
+	// last hour with no recovery.
+	alert("unhealthy", -time.Hour)
+	alert("healthy", 2*time.Hour)
+	alert("oom_killed", 3*time.Hour)
+	alert("unhealthy", 23*time.Hour)
+	for _, at := range []time.Duration{time.Hour, 5 * time.Hour, 30 * time.Hour} {
+		if _, err := st.AddEvent(ctx, Event{ContainerPK: dbc.ID, Container: "db", ContainerID: "cid-db", Type: "restart", Severity: "blue", Message: "restart", Timestamp: base.Add(at)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	stats, err := st.ContainerStatsBetween(ctx, base, base.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	got := stats[dbc.ID]
+	if got.Restarts != 2 || got.OOMs != 1 || got.Unhealthy != 3*time.Hour {
+		t.Fatalf("expected 2 restarts, 1 oom, 3h unhealthy, got %+v", got)
+	}
+}
@@ -7,303 +7,289 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"healthmon/internal/clock"
+	"healthmon/internal/db"
 )
 
+// Store is healthmon's storage, on SQLite or PostgreSQL alike. Both run the
+// same queries through a db.Querier, which takes care of the placeholders;
+// the few statements that differ check db.DialectOf. There is no second
+// implementation to abstract over, so the API and the monitor use Store
+// directly.
 type Store struct {
-	db         *sql.DB
-	mu         sync.RWMutex
-	containers map[string]*Container
+	db     db.Querier
+	writer *writer
+	clock  clock.Clock
+	cache  *containerCache
+	// upsertMu serializes container upserts, which fill in defaults from
+	// the cached container before writing.
+	upsertMu sync.Mutex
+	// integrity is the report of the last CheckIntegrity run.
+	integrity atomic.Pointer[IntegrityReport]
+	notesMu   sync.RWMutex
+	notes     map[string]ContainerNotes
+	// writeFailed is told about background writes that failed.
+	writeFailed atomic.Pointer[func(what string, err error)]
 }
 
-func New(db *sql.DB) *Store {
+func New(conn db.Querier) *Store {
 	return &Store{
-		db:         db,
-		containers: make(map[string]*Container),
+		db:     conn,
+		writer: newWriter(conn),
+		clock:  clock.Real{},
+		cache:  newContainerCache(),
+		notes:  make(map[string]ContainerNotes),
 	}
 }
 
-func (s *Store) Load(ctx context.Context) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// WithClock replaces the clock used for bookkeeping timestamps.
+func (s *Store) WithClock(c clock.Clock) {
+	s.clock = c
+}
+
+// OnWriteError registers fn to be told about writes that failed after the
+// call that issued them returned, such as updates of known containers. It
+// runs on its own goroutine and may write to the store.
+func (s *Store) OnWriteError(fn func(what string, err error)) {
+	s.writeFailed.Store(&fn)
+}
+
+// Close flushes pending writes. Writes issued after Close run directly
+// against the database.
+func (s *Store) Close() {
+	s.writer.close()
+}
+
+// OnContainerChange registers fn to be called with every container update
+// the store applies, after it is visible to readers. fn runs on the writing
+// goroutine and must not write to the store.
+func (s *Store) OnContainerChange(fn func(Container)) {
+	s.cache.subscribe(fn)
+}
 
-	rows, err := s.db.QueryContext(ctx, `SELECT id, name, container_id, current_container_name, image, image_tag, image_id, created_at_container, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, user, last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck FROM containers`)
+// CacheStats reports how the container cache has served reads.
+func (s *Store) CacheStats() CacheStats {
+	return s.cache.stats()
+}
+
+func (s *Store) Load(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+containerColumns+` FROM containers`)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var c Container
-		var capsJSON string
-		var readOnly int
-		var noNewPrivileges int
-		var memoryReservation int64
-		var memoryLimit int64
-		var present int
-		var createdAt string
-		var registeredAt string
-		var startedAt string
-		var finishedAt sql.NullString
-		var exitCode sql.NullInt64
-		var updatedAt string
-		var lastEventID sql.NullInt64
-		var healthStatus string
-		var healthFailingStreak int
-		var unhealthySince string
-		var restartLoop int
-		var restartStreak int
-		var restartLoopSince string
-		var healthcheck sql.NullString
-
-		if err := rows.Scan(&c.ID, &c.Name, &c.ContainerID, &c.CurrentContainerName, &c.Image, &c.ImageTag, &c.ImageID, &createdAt, &registeredAt, &startedAt, &finishedAt, &exitCode, &c.Status, &c.Role, &capsJSON, &readOnly, &noNewPrivileges, &memoryReservation, &memoryLimit, &c.User, &lastEventID, &updatedAt, &present, &healthStatus, &healthFailingStreak, &unhealthySince, &restartLoop, &restartStreak, &restartLoopSince, &healthcheck); err != nil {
-			return err
-		}
-		if err := json.Unmarshal([]byte(capsJSON), &c.Caps); err != nil {
+		c, err := scanContainer(rows)
+		if err != nil {
 			return err
 		}
-		c.ReadOnly = readOnly == 1
-		c.NoNewPrivileges = noNewPrivileges == 1
-		c.MemoryReservation = memoryReservation
-		c.MemoryLimit = memoryLimit
-		c.CreatedAt = parseTime(createdAt)
-		c.RegisteredAt = parseTime(registeredAt)
-		c.StartedAt = parseTime(startedAt)
-		if finishedAt.Valid {
-			c.FinishedAt = parseTime(finishedAt.String)
-		}
-		if exitCode.Valid {
-			val := int(exitCode.Int64)
-			c.ExitCode = &val
-		}
-		c.UpdatedAt = parseTime(updatedAt)
-		if lastEventID.Valid {
-			c.LastEventID = lastEventID.Int64
-		}
-		c.Present = present == 1
-		c.HealthStatus = healthStatus
-		c.HealthFailingStreak = healthFailingStreak
-		c.UnhealthySince = parseTime(unhealthySince)
-		c.RestartLoop = restartLoop == 1
-		c.RestartStreak = restartStreak
-		c.RestartLoopSince = parseTime(restartLoopSince)
-		if parsed, err := parseHealthcheck(healthcheck); err != nil {
-			return err
-		} else {
-			c.Healthcheck = parsed
-		}
-		if c.Role == "" {
-			c.Role = "service"
-		}
-		container := c
-		s.containers[container.Name] = &container
+		s.cache.put(c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
-	return rows.Err()
+	return s.loadNotes(ctx)
 }
 
 func (s *Store) ListContainers() []Container {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	return s.cache.list(func(c Container) bool { return c.Present })
+}
 
-	items := make([]Container, 0, len(s.containers))
-	for _, c := range s.containers {
-		if !c.Present {
-			continue
-		}
-		items = append(items, *c)
-	}
-	return items
+// ListAllContainers is ListContainers including removed containers.
+func (s *Store) ListAllContainers() []Container {
+	return s.cache.list(nil)
 }
 
 func (s *Store) GetContainer(name string) (Container, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	c, ok := s.containers[name]
-	if !ok {
-		return Container{}, false
-	}
-	return *c, true
+	c, ok, _ := s.GetContainerByName(context.Background(), name)
+	return c, ok
 }
 
+// GetContainerByName reads through the cache: a container that is not
+// cached is loaded from the database and cached.
 func (s *Store) GetContainerByName(ctx context.Context, name string) (Container, bool, error) {
-	s.mu.RLock()
-	if c, ok := s.containers[name]; ok {
-		clone := *c
-		s.mu.RUnlock()
-		return clone, true, nil
-	}
-	s.mu.RUnlock()
-
-	var c Container
-	var capsJSON string
-	var readOnly int
-	var present int
-	var memoryReservation int64
-	var memoryLimit int64
-	var createdAt string
-	var registeredAt string
-	var startedAt string
-	var updatedAt string
-	var lastEventID sql.NullInt64
-	var healthStatus string
-	var healthFailingStreak int
-	var unhealthySince string
-	var restartLoop int
-	var restartStreak int
-	var restartLoopSince string
-	var healthcheck sql.NullString
-
-	var noNewPrivileges int
-	var finishedAt sql.NullString
-	var exitCode sql.NullInt64
-	err := s.db.QueryRowContext(ctx, `SELECT id, name, container_id, current_container_name, image, image_tag, image_id, created_at_container, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, user, last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck FROM containers WHERE name = ?`, name).Scan(&c.ID, &c.Name, &c.ContainerID, &c.CurrentContainerName, &c.Image, &c.ImageTag, &c.ImageID, &createdAt, &registeredAt, &startedAt, &finishedAt, &exitCode, &c.Status, &c.Role, &capsJSON, &readOnly, &noNewPrivileges, &memoryReservation, &memoryLimit, &c.User, &lastEventID, &updatedAt, &present, &healthStatus, &healthFailingStreak, &unhealthySince, &restartLoop, &restartStreak, &restartLoopSince, &healthcheck)
-	if err == sql.ErrNoRows {
+	if name == "" {
 		return Container{}, false, nil
 	}
-	if err != nil {
-		return Container{}, false, err
-	}
-	if err := json.Unmarshal([]byte(capsJSON), &c.Caps); err != nil {
-		return Container{}, false, err
+	if c, ok := s.cache.get(name); ok {
+		return c, true, nil
 	}
-	c.ReadOnly = readOnly == 1
-	c.NoNewPrivileges = noNewPrivileges == 1
-	c.MemoryReservation = memoryReservation
-	c.MemoryLimit = memoryLimit
-	c.CreatedAt = parseTime(createdAt)
-	c.RegisteredAt = parseTime(registeredAt)
-	c.StartedAt = parseTime(startedAt)
-	if finishedAt.Valid {
-		c.FinishedAt = parseTime(finishedAt.String)
-	}
-	if exitCode.Valid {
-		val := int(exitCode.Int64)
-		c.ExitCode = &val
-	}
-	c.UpdatedAt = parseTime(updatedAt)
-	if lastEventID.Valid {
-		c.LastEventID = lastEventID.Int64
-	}
-	c.Present = present == 1
-	c.HealthStatus = healthStatus
-	c.HealthFailingStreak = healthFailingStreak
-	c.UnhealthySince = parseTime(unhealthySince)
-	c.RestartLoop = restartLoop == 1
-	c.RestartStreak = restartStreak
-	c.RestartLoopSince = parseTime(restartLoopSince)
-	if parsed, err := parseHealthcheck(healthcheck); err != nil {
-		return Container{}, false, err
-	} else {
-		c.Healthcheck = parsed
-	}
-	if c.Role == "" {
-		c.Role = "service"
-	}
-	s.mu.Lock()
-	s.containers[c.Name] = &c
-	s.mu.Unlock()
-	return c, true, nil
+	return s.loadContainer(ctx, `name = ?`, name)
 }
 
 func (s *Store) GetContainerByContainerID(ctx context.Context, containerID string) (Container, bool, error) {
 	if containerID == "" {
 		return Container{}, false, nil
 	}
-
-	s.mu.RLock()
-	for _, c := range s.containers {
-		if c.ContainerID == containerID {
-			copy := *c
-			s.mu.RUnlock()
-			return copy, true, nil
-		}
+	if c, ok := s.cache.find(func(c Container) bool { return c.ContainerID == containerID }); ok {
+		return c, true, nil
 	}
-	s.mu.RUnlock()
-
-	var c Container
-	var capsJSON string
-	var readOnly int
-	var present int
-	var memoryReservation int64
-	var memoryLimit int64
-	var createdAt string
-	var registeredAt string
-	var startedAt string
-	var updatedAt string
-	var lastEventID sql.NullInt64
-	var healthStatus string
-	var healthFailingStreak int
-	var unhealthySince string
-	var restartLoop int
-	var restartStreak int
-	var restartLoopSince string
-	var healthcheck sql.NullString
+	return s.loadContainer(ctx, `container_id = ?`, containerID)
+}
 
-	var noNewPrivileges int
-	var finishedAt sql.NullString
-	var exitCode sql.NullInt64
-	err := s.db.QueryRowContext(ctx, `SELECT id, name, container_id, current_container_name, image, image_tag, image_id, created_at_container, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, user, last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck FROM containers WHERE container_id = ?`, containerID).Scan(&c.ID, &c.Name, &c.ContainerID, &c.CurrentContainerName, &c.Image, &c.ImageTag, &c.ImageID, &createdAt, &registeredAt, &startedAt, &finishedAt, &exitCode, &c.Status, &c.Role, &capsJSON, &readOnly, &noNewPrivileges, &memoryReservation, &memoryLimit, &c.User, &lastEventID, &updatedAt, &present, &healthStatus, &healthFailingStreak, &unhealthySince, &restartLoop, &restartStreak, &restartLoopSince, &healthcheck)
+// loadContainer reads the container matching where from the database after
+// a cache miss and caches it.
+func (s *Store) loadContainer(ctx context.Context, where string, arg interface{}) (Container, bool, error) {
+	c, err := scanContainer(s.db.QueryRowContext(ctx, `SELECT `+containerColumns+` FROM containers WHERE `+where, arg))
 	if err == sql.ErrNoRows {
 		return Container{}, false, nil
 	}
 	if err != nil {
 		return Container{}, false, err
 	}
-	if err := json.Unmarshal([]byte(capsJSON), &c.Caps); err != nil {
-		return Container{}, false, err
+	return s.cache.load(c), true, nil
+}
+
+// writeContainerAsync queues a write of a container already applied to the
+// cache. If the write fails the cached container is dropped, so it is read
+// back from the database instead of serving a state that was never stored,
+// and the OnWriteError hook is told what was lost.
+func (s *Store) writeContainerAsync(name, what string, fn writeFunc) {
+	s.writer.asyncOr(fn, func(err error) {
+		s.cache.invalidate(name)
+		if hook := s.writeFailed.Load(); hook != nil {
+			// The hook usually records the failure, which goes through the
+			// writer this runs on.
+			go (*hook)(what, err)
+		}
+	})
+}
+
+func (s *Store) UpsertContainer(ctx context.Context, c Container) error {
+	s.upsertMu.Lock()
+	defer s.upsertMu.Unlock()
+
+	existing, hasExisting := s.cache.peek(c.Name)
+	c, args, err := s.prepareUpsert(c)
+	if err != nil {
+		return err
 	}
-	c.ReadOnly = readOnly == 1
-	c.NoNewPrivileges = noNewPrivileges == 1
-	c.MemoryReservation = memoryReservation
-	c.MemoryLimit = memoryLimit
-	c.CreatedAt = parseTime(createdAt)
-	c.RegisteredAt = parseTime(registeredAt)
-	c.StartedAt = parseTime(startedAt)
-	if finishedAt.Valid {
-		c.FinishedAt = parseTime(finishedAt.String)
+	transition := s.healthTransition(existing, hasExisting, c)
+
+	// Known containers are persisted in the background: the cache is the
+	// source of truth for reads and the writer keeps writes in order. New
+	// containers wait for the insert because callers need the row id.
+	if hasExisting && existing.ID > 0 {
+		c.ID = existing.ID
+		s.cache.put(c)
+		s.writeContainerAsync(c.Name, "container "+c.Name, func(ctx context.Context, q db.Querier) error {
+			_, err := upsertContainerRow(ctx, q, args, transition)
+			return err
+		})
+		return nil
 	}
-	if exitCode.Valid {
-		val := int(exitCode.Int64)
-		c.ExitCode = &val
+
+	var id int64
+	err = s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		var err error
+		id, err = upsertContainerRow(ctx, q, args, transition)
+		return err
+	})
+	if err != nil {
+		return err
 	}
-	c.UpdatedAt = parseTime(updatedAt)
-	if lastEventID.Valid {
-		c.LastEventID = lastEventID.Int64
+	c.ID = id
+	s.cache.put(c)
+	return nil
+}
+
+// UpsertContainerWithEvent persists a container update together with the
+// event that caused it in one transaction, so the event row and the
+// container's last_event_id can never disagree after a crash. It returns the
+// stored container and the event id.
+func (s *Store) UpsertContainerWithEvent(ctx context.Context, c Container, e Event) (Container, int64, error) {
+	if err := validateDetails(e.DetailsJSON); err != nil {
+		return Container{}, 0, err
 	}
-	c.Present = present == 1
-	c.HealthStatus = healthStatus
-	c.HealthFailingStreak = healthFailingStreak
-	c.UnhealthySince = parseTime(unhealthySince)
-	c.RestartLoop = restartLoop == 1
-	c.RestartStreak = restartStreak
-	c.RestartLoopSince = parseTime(restartLoopSince)
-	if parsed, err := parseHealthcheck(healthcheck); err != nil {
-		return Container{}, false, err
-	} else {
-		c.Healthcheck = parsed
+	s.upsertMu.Lock()
+	defer s.upsertMu.Unlock()
+
+	existing, hasExisting := s.cache.peek(c.Name)
+	c, args, err := s.prepareUpsert(c)
+	if err != nil {
+		return Container{}, 0, err
 	}
-	if c.Role == "" {
-		c.Role = "service"
+	transition := s.healthTransition(existing, hasExisting, c)
+
+	var containerPK, eventID int64
+	err = s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		var err error
+		if containerPK, err = upsertContainerRow(ctx, q, args, transition); err != nil {
+			return err
+		}
+		err = q.QueryRowContext(ctx, `
+INSERT INTO events (container_pk, container_name, container_id, parsed_container_name, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, containerPK, c.Name, e.ContainerID, nullStr(e.ParsedContainerName), e.Type, e.Severity, e.Message, formatTime(e.Timestamp), nullStr(e.OldImage), nullStr(e.NewImage), nullStr(e.OldImageID), nullStr(e.NewImageID), nullStr(e.Reason), nullStr(e.DetailsJSON), nullIntPtr(e.ExitCode)).Scan(&eventID)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, `UPDATE containers SET last_event_id = ? WHERE id = ?`, eventID, containerPK)
+		return err
+	})
+	if err != nil {
+		return Container{}, 0, err
 	}
-	s.mu.Lock()
-	s.containers[c.Name] = &c
-	s.mu.Unlock()
-	return c, true, nil
+	c.ID = containerPK
+	c.LastEventID = eventID
+	c.UpdatedAt = e.Timestamp
+	s.cache.put(c)
+	return c, eventID, nil
 }
 
-func (s *Store) UpsertContainer(ctx context.Context, c Container) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// UpsertContainerWithAlert is UpsertContainerWithEvent for alerts.
+func (s *Store) UpsertContainerWithAlert(ctx context.Context, c Container, a Alert) (Container, int64, error) {
+	if err := validateDetails(a.DetailsJSON); err != nil {
+		return Container{}, 0, err
+	}
+	s.upsertMu.Lock()
+	defer s.upsertMu.Unlock()
 
+	existing, hasExisting := s.cache.peek(c.Name)
+	c, args, err := s.prepareUpsert(c)
+	if err != nil {
+		return Container{}, 0, err
+	}
+	transition := s.healthTransition(existing, hasExisting, c)
+
+	var containerPK, alertID int64
+	err = s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		var err error
+		if containerPK, err = upsertContainerRow(ctx, q, args, transition); err != nil {
+			return err
+		}
+		return q.QueryRowContext(ctx, `
+INSERT INTO alerts (container_pk, container_name, container_id, parsed_container_name, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code, incident_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, containerPK, c.Name, a.ContainerID, nullStr(a.ParsedContainerName), a.Type, a.Severity, a.Message, formatTime(a.Timestamp), nullStr(a.OldImage), nullStr(a.NewImage), nullStr(a.OldImageID), nullStr(a.NewImageID), nullStr(a.Reason), nullStr(a.DetailsJSON), nullIntPtr(a.ExitCode), nullInt(a.IncidentID)).Scan(&alertID)
+	})
+	if err != nil {
+		return Container{}, 0, err
+	}
+	c.ID = containerPK
+	s.cache.put(c)
+	return c, alertID, nil
+}
+
+// prepareUpsert fills in defaults from the cached container and builds the
+// arguments for upsertContainerQuery. The caller must hold s.upsertMu.
+func (s *Store) prepareUpsert(c Container) (Container, []interface{}, error) {
 	if c.Role == "" {
 		c.Role = "service"
 	}
 	if c.CurrentContainerName == "" {
 		c.CurrentContainerName = c.Name
 	}
-	now := time.Now().UTC()
+	now := s.clock.Now()
+	existing, hasExisting := s.cache.peek(c.Name)
 	if c.RegisteredAt.IsZero() {
-		if existing, ok := s.containers[c.Name]; ok && !existing.RegisteredAt.IsZero() {
+		if hasExisting && !existing.RegisteredAt.IsZero() {
 			c.RegisteredAt = existing.RegisteredAt
 		} else if !c.CreatedAt.IsZero() && c.CreatedAt.Before(now) {
 			c.RegisteredAt = c.CreatedAt
@@ -311,15 +297,31 @@ func (s *Store) UpsertContainer(ctx context.Context, c Container) error {
 			c.RegisteredAt = now
 		}
 	}
-	if c.StartedAt.IsZero() {
-		if existing, ok := s.containers[c.Name]; ok && !existing.StartedAt.IsZero() {
+	if hasExisting {
+		if c.StartedAt.IsZero() {
 			c.StartedAt = existing.StartedAt
 		}
-	}
-	if c.LastEventID == 0 {
-		if existing, ok := s.containers[c.Name]; ok && existing.LastEventID > 0 {
+		if c.LastEventID == 0 {
 			c.LastEventID = existing.LastEventID
 		}
+		if c.LastSuccessAt.IsZero() {
+			c.LastSuccessAt = existing.LastSuccessAt
+		}
+		if c.Platform == "" {
+			c.Platform = existing.Platform
+		}
+		if c.DependsOn == nil {
+			c.DependsOn = existing.DependsOn
+		}
+		if c.Networks == nil {
+			c.Networks = existing.Networks
+		}
+		if c.Labels == nil {
+			c.Labels = existing.Labels
+		}
+		if c.Config == nil {
+			c.Config = existing.Config
+		}
 	}
 	if !c.Present {
 		c.Present = true
@@ -327,7 +329,7 @@ func (s *Store) UpsertContainer(ctx context.Context, c Container) error {
 
 	capsJSON, err := json.Marshal(c.Caps)
 	if err != nil {
-		return err
+		return Container{}, nil, err
 	}
 	readOnly := 0
 	if c.ReadOnly {
@@ -343,13 +345,36 @@ func (s *Store) UpsertContainer(ctx context.Context, c Container) error {
 	}
 	healthcheckJSON, err := marshalHealthcheck(c.Healthcheck)
 	if err != nil {
-		return err
+		return Container{}, nil, err
+	}
+	dependsOnJSON, err := marshalNames(c.DependsOn)
+	if err != nil {
+		return Container{}, nil, err
+	}
+	networksJSON, err := marshalNames(c.Networks)
+	if err != nil {
+		return Container{}, nil, err
+	}
+	labelsJSON, err := marshalLabels(c.Labels)
+	if err != nil {
+		return Container{}, nil, err
+	}
+	probeJSON, err := marshalHealthProbe(c.LastHealthProbe)
+	if err != nil {
+		return Container{}, nil, err
+	}
+	configJSON, err := marshalConfigFingerprint(c.Config)
+	if err != nil {
+		return Container{}, nil, err
 	}
 
-	var id int64
-	err = s.db.QueryRowContext(ctx, `
-INSERT INTO containers (name, container_id, current_container_name, image, image_tag, image_id, created_at_container, first_seen_at, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, user, last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	args := []interface{}{c.Name, c.ContainerID, c.CurrentContainerName, c.Image, c.ImageTag, c.ImageID, formatTime(c.CreatedAt), formatTime(c.RegisteredAt), formatTime(c.RegisteredAt), formatTime(c.StartedAt), nullTime(c.FinishedAt), nullIntPtr(c.ExitCode), c.Status, c.Role, string(capsJSON), readOnly, boolToInt(c.NoNewPrivileges), c.MemoryReservation, c.MemoryLimit, c.User, nullInt(c.LastEventID), formatTime(c.UpdatedAt), present, c.HealthStatus, c.HealthFailingStreak, formatTime(c.UnhealthySince), restartLoop, c.RestartStreak, formatTime(c.RestartLoopSince), healthcheckJSON, c.DockerRestartCount, int64(c.UnhealthyGrace / time.Second), nullTime(c.LastSuccessAt), int64(c.TaskMaxAge / time.Second), c.Platform, dependsOnJSON, networksJSON, labelsJSON, probeJSON, configJSON, c.ImageDigest}
+	return c, args, nil
+}
+
+const upsertContainerQuery = `
+INSERT INTO containers (name, container_id, current_container_name, image, image_tag, image_id, created_at_container, first_seen_at, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, "user", last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck, docker_restart_count, unhealthy_grace_seconds, last_success_at, task_max_age_seconds, platform, depends_on, networks, labels, last_health_probe, config_fingerprint, image_digest)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(name) DO UPDATE SET
   container_id=excluded.container_id,
   current_container_name=excluded.current_container_name,
@@ -369,7 +394,7 @@ ON CONFLICT(name) DO UPDATE SET
   no_new_privileges=excluded.no_new_privileges,
   memory_reservation=excluded.memory_reservation,
   memory_limit=excluded.memory_limit,
-  user=excluded.user,
+  "user"=excluded."user",
   last_event_id=excluded.last_event_id,
   updated_at=excluded.updated_at,
   present=excluded.present,
@@ -379,38 +404,46 @@ ON CONFLICT(name) DO UPDATE SET
   restart_loop=excluded.restart_loop,
   restart_streak=excluded.restart_streak,
   restart_loop_since=excluded.restart_loop_since,
-  healthcheck=excluded.healthcheck
+  healthcheck=excluded.healthcheck,
+  docker_restart_count=excluded.docker_restart_count,
+  unhealthy_grace_seconds=excluded.unhealthy_grace_seconds,
+  last_success_at=excluded.last_success_at,
+  task_max_age_seconds=excluded.task_max_age_seconds,
+  platform=excluded.platform,
+  depends_on=excluded.depends_on,
+  networks=excluded.networks,
+  labels=excluded.labels,
+  last_health_probe=excluded.last_health_probe,
+  config_fingerprint=excluded.config_fingerprint,
+  image_digest=excluded.image_digest
 RETURNING id
-`, c.Name, c.ContainerID, c.CurrentContainerName, c.Image, c.ImageTag, c.ImageID, formatTime(c.CreatedAt), formatTime(c.RegisteredAt), formatTime(c.RegisteredAt), formatTime(c.StartedAt), nullTime(c.FinishedAt), nullIntPtr(c.ExitCode), c.Status, c.Role, string(capsJSON), readOnly, boolToInt(c.NoNewPrivileges), c.MemoryReservation, c.MemoryLimit, c.User, nullInt(c.LastEventID), formatTime(c.UpdatedAt), present, c.HealthStatus, c.HealthFailingStreak, formatTime(c.UnhealthySince), restartLoop, c.RestartStreak, formatTime(c.RestartLoopSince), healthcheckJSON).Scan(&id)
-	if err != nil {
-		return err
-	}
-	copy := c
-	copy.ID = id
-	s.containers[c.Name] = &copy
-	return nil
-}
+`
 
 func (s *Store) AddEvent(ctx context.Context, e Event) (int64, error) {
-	res, err := s.db.ExecContext(ctx, `
-INSERT INTO events (container_pk, container_name, container_id, parsed_container_name, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-`, e.ContainerPK, e.Container, e.ContainerID, nullStr(e.ParsedContainerName), e.Type, e.Severity, e.Message, formatTime(e.Timestamp), nullStr(e.OldImage), nullStr(e.NewImage), nullStr(e.OldImageID), nullStr(e.NewImageID), nullStr(e.Reason), nullStr(e.DetailsJSON), nullIntPtr(e.ExitCode))
-	if err != nil {
+	if err := validateDetails(e.DetailsJSON); err != nil {
 		return 0, err
 	}
-	id, err := res.LastInsertId()
+	var id int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		err := q.QueryRowContext(ctx, `
+INSERT INTO events (container_pk, container_name, container_id, parsed_container_name, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, e.ContainerPK, e.Container, e.ContainerID, nullStr(e.ParsedContainerName), e.Type, e.Severity, e.Message, formatTime(e.Timestamp), nullStr(e.OldImage), nullStr(e.NewImage), nullStr(e.OldImageID), nullStr(e.NewImageID), nullStr(e.Reason), nullStr(e.DetailsJSON), nullIntPtr(e.ExitCode)).Scan(&id)
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, `UPDATE containers SET last_event_id = ? WHERE id = ?`, id, e.ContainerPK)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
 	e.ID = id
-	s.mu.Lock()
-	if c, ok := s.containers[e.Container]; ok {
+	s.cache.update(e.Container, func(c *Container) {
 		c.LastEventID = id
 		c.UpdatedAt = e.Timestamp
-	}
-	s.mu.Unlock()
-	_, _ = s.db.ExecContext(ctx, `UPDATE containers SET last_event_id = ? WHERE id = ?`, id, e.ContainerPK)
+	})
 	return id, nil
 }
 
@@ -504,17 +537,39 @@ func (s *Store) CountEventsByContainer(ctx context.Context, container string) (i
 	return total, nil
 }
 
+// ListAlerts returns a page of alerts for one container, newest first.
+func (s *Store) ListAlerts(ctx context.Context, container string, beforeID int64, limit int) ([]Alert, error) {
+	return s.ListAllAlerts(ctx, Filter{Containers: []string{container}}, beforeID, limit)
+}
+
+func (s *Store) CountAlertsByContainer(ctx context.Context, container string) (int64, error) {
+	return s.CountAlerts(ctx, Filter{Containers: []string{container}})
+}
+
+// CountAlertsPerContainer returns the number of alerts keyed by container_pk.
+func (s *Store) CountAlertsPerContainer(ctx context.Context) (map[int64]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT container_pk, COUNT(1) FROM alerts GROUP BY container_pk`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var pk, total int64
+		if err := rows.Scan(&pk, &total); err != nil {
+			return nil, err
+		}
+		counts[pk] = total
+	}
+	return counts, rows.Err()
+}
+
 func (s *Store) resolveContainerName(containerPK int64, containerID, fallback string) string {
 	if containerPK > 0 {
-		s.mu.RLock()
-		for _, c := range s.containers {
-			if c.ID == containerPK {
-				name := c.Name
-				s.mu.RUnlock()
-				return name
-			}
+		if c, ok := s.cache.find(func(c Container) bool { return c.ID == containerPK }); ok {
+			return c.Name
 		}
-		s.mu.RUnlock()
 		if c, ok, _ := s.getContainerByPK(context.Background(), containerPK); ok && c.Name != "" {
 			return c.Name
 		}
@@ -532,94 +587,25 @@ func (s *Store) getContainerByPK(ctx context.Context, containerPK int64) (Contai
 		return Container{}, false, nil
 	}
 
-	var c Container
-	var capsJSON string
-	var readOnly int
-	var present int
-	var memoryReservation int64
-	var memoryLimit int64
-	var createdAt string
-	var registeredAt string
-	var startedAt string
-	var updatedAt string
-	var lastEventID sql.NullInt64
-	var healthStatus string
-	var healthFailingStreak int
-	var unhealthySince string
-	var restartLoop int
-	var restartStreak int
-	var restartLoopSince string
-	var healthcheck sql.NullString
-	var noNewPrivileges int
-	var finishedAt sql.NullString
-	var exitCode sql.NullInt64
-
-	err := s.db.QueryRowContext(ctx, `SELECT id, name, container_id, current_container_name, image, image_tag, image_id, created_at_container, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, user, last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck FROM containers WHERE id = ?`, containerPK).Scan(&c.ID, &c.Name, &c.ContainerID, &c.CurrentContainerName, &c.Image, &c.ImageTag, &c.ImageID, &createdAt, &registeredAt, &startedAt, &finishedAt, &exitCode, &c.Status, &c.Role, &capsJSON, &readOnly, &noNewPrivileges, &memoryReservation, &memoryLimit, &c.User, &lastEventID, &updatedAt, &present, &healthStatus, &healthFailingStreak, &unhealthySince, &restartLoop, &restartStreak, &restartLoopSince, &healthcheck)
-	if err == sql.ErrNoRows {
-		return Container{}, false, nil
-	}
-	if err != nil {
-		return Container{}, false, err
-	}
-	if err := json.Unmarshal([]byte(capsJSON), &c.Caps); err != nil {
-		return Container{}, false, err
-	}
-	c.ReadOnly = readOnly == 1
-	c.NoNewPrivileges = noNewPrivileges == 1
-	c.MemoryReservation = memoryReservation
-	c.MemoryLimit = memoryLimit
-	c.CreatedAt = parseTime(createdAt)
-	c.RegisteredAt = parseTime(registeredAt)
-	c.StartedAt = parseTime(startedAt)
-	if finishedAt.Valid {
-		c.FinishedAt = parseTime(finishedAt.String)
-	}
-	if exitCode.Valid {
-		val := int(exitCode.Int64)
-		c.ExitCode = &val
-	}
-	c.UpdatedAt = parseTime(updatedAt)
-	if lastEventID.Valid {
-		c.LastEventID = lastEventID.Int64
-	}
-	c.Present = present == 1
-	c.HealthStatus = healthStatus
-	c.HealthFailingStreak = healthFailingStreak
-	c.UnhealthySince = parseTime(unhealthySince)
-	c.RestartLoop = restartLoop == 1
-	c.RestartStreak = restartStreak
-	c.RestartLoopSince = parseTime(restartLoopSince)
-	if parsed, err := parseHealthcheck(healthcheck); err != nil {
-		return Container{}, false, err
-	} else {
-		c.Healthcheck = parsed
-	}
-	if c.Role == "" {
-		c.Role = "service"
-	}
-
-	s.mu.Lock()
-	s.containers[c.Name] = &c
-	s.mu.Unlock()
-	return c, true, nil
+	return s.loadContainer(ctx, `id = ?`, containerPK)
 }
 
-func (s *Store) ListAllEvents(ctx context.Context, beforeID int64, limit int) ([]Event, error) {
-	if limit <= 0 {
-		limit = 50
-	}
-	if beforeID <= 0 {
-		beforeID = int64(^uint64(0) >> 1)
+// ListAllEvents returns a page of events matching f. cursor is the id to
+// continue from: results are older than it, or newer when f.Ascending is set.
+func (s *Store) ListAllEvents(ctx context.Context, f Filter, cursor int64, limit int) ([]Event, error) {
+	where, args, ok, err := s.filterWhere(ctx, f, "events")
+	if err != nil || !ok {
+		return []Event{}, err
 	}
+	cursorClause, order, args := pageClauses(f, cursor, limit, args)
 
 	rows, err := s.db.QueryContext(ctx, `
-SELECT id, container_name, container_id, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, container_pk, exit_code
-     , parsed_container_name
+SELECT `+eventColumns+`
 FROM events
-WHERE id < ?
-ORDER BY id DESC
+WHERE `+where+` AND `+cursorClause+`
+ORDER BY id `+order+`
 LIMIT ?
-`, beforeID, limit)
+`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -627,41 +613,10 @@ LIMIT ?
 
 	items := []Event{}
 	for rows.Next() {
-		var e Event
-		var ts string
-		var oldImage, newImage, oldImageID, newImageID, reason, details sql.NullString
-		var exitCode sql.NullInt64
-		var parsedContainerName sql.NullString
-		if err := rows.Scan(&e.ID, &e.Container, &e.ContainerID, &e.Type, &e.Severity, &e.Message, &ts, &oldImage, &newImage, &oldImageID, &newImageID, &reason, &details, &e.ContainerPK, &exitCode, &parsedContainerName); err != nil {
+		e, err := s.scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
-		e.Timestamp = parseTime(ts)
-		if oldImage.Valid {
-			e.OldImage = oldImage.String
-		}
-		if newImage.Valid {
-			e.NewImage = newImage.String
-		}
-		if oldImageID.Valid {
-			e.OldImageID = oldImageID.String
-		}
-		if newImageID.Valid {
-			e.NewImageID = newImageID.String
-		}
-		if reason.Valid {
-			e.Reason = reason.String
-		}
-		if details.Valid {
-			e.DetailsJSON = details.String
-		}
-		if exitCode.Valid {
-			val := int(exitCode.Int64)
-			e.ExitCode = &val
-		}
-		if parsedContainerName.Valid {
-			e.ParsedContainerName = parsedContainerName.String
-		}
-		e.Container = s.resolveContainerName(e.ContainerPK, e.ContainerID, e.Container)
 		items = append(items, e)
 	}
 	if err := rows.Err(); err != nil {
@@ -679,36 +634,77 @@ func (s *Store) CountAllEvents(ctx context.Context) (int64, error) {
 }
 
 func (s *Store) AddAlert(ctx context.Context, a Alert) (int64, error) {
-	res, err := s.db.ExecContext(ctx, `
-INSERT INTO alerts (container_pk, container_name, container_id, parsed_container_name, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-`, a.ContainerPK, a.Container, a.ContainerID, nullStr(a.ParsedContainerName), a.Type, a.Severity, a.Message, formatTime(a.Timestamp), nullStr(a.OldImage), nullStr(a.NewImage), nullStr(a.OldImageID), nullStr(a.NewImageID), nullStr(a.Reason), nullStr(a.DetailsJSON), nullIntPtr(a.ExitCode))
-	if err != nil {
+	if err := validateDetails(a.DetailsJSON); err != nil {
 		return 0, err
 	}
-	id, err := res.LastInsertId()
+	var id int64
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		if err := q.QueryRowContext(ctx, `
+INSERT INTO alerts (container_pk, container_name, container_id, parsed_container_name, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, exit_code, incident_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`, a.ContainerPK, a.Container, a.ContainerID, nullStr(a.ParsedContainerName), a.Type, a.Severity, a.Message, formatTime(a.Timestamp), nullStr(a.OldImage), nullStr(a.NewImage), nullStr(a.OldImageID), nullStr(a.NewImageID), nullStr(a.Reason), nullStr(a.DetailsJSON), nullIntPtr(a.ExitCode), nullInt(a.IncidentID)).Scan(&id); err != nil {
+			return err
+		}
+		if a.Logs == "" {
+			return nil
+		}
+		_, err := q.ExecContext(ctx, `INSERT INTO alert_logs (alert_id, logs) VALUES (?, ?)`, id, a.Logs)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
 	return id, nil
 }
 
-func (s *Store) ListAllAlerts(ctx context.Context, beforeID int64, limit int) ([]Alert, error) {
-	if limit <= 0 {
-		limit = 50
+// AcknowledgeAlert marks an alert as seen. It reports false when the alert
+// does not exist; acknowledging twice keeps the first timestamp.
+func (s *Store) AcknowledgeAlert(ctx context.Context, id int64) (bool, error) {
+	if id <= 0 {
+		return false, nil
 	}
-	if beforeID <= 0 {
-		beforeID = int64(^uint64(0) >> 1)
+	var found bool
+	at := formatTime(s.clock.Now())
+	err := s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		res, err := q.ExecContext(ctx, `UPDATE alerts SET acknowledged_at = COALESCE(acknowledged_at, ?) WHERE id = ?`, at, id)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		found = n > 0
+		return err
+	})
+	if err != nil {
+		return false, err
 	}
+	return found, nil
+}
+
+// SetAlertTelegramMessage remembers the Telegram message an alert was sent
+// as, so later alerts about the same problem can reply to it.
+func (s *Store) SetAlertTelegramMessage(ctx context.Context, id, messageID int64) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE alerts SET telegram_message_id = ? WHERE id = ?`, messageID, id)
+		return err
+	})
+}
+
+// ListAllAlerts is ListAllEvents for alerts. f.Query is ignored.
+func (s *Store) ListAllAlerts(ctx context.Context, f Filter, cursor int64, limit int) ([]Alert, error) {
+	where, args, ok, err := s.filterWhere(ctx, f, "alerts")
+	if err != nil || !ok {
+		return []Alert{}, err
+	}
+	cursorClause, order, args := pageClauses(f, cursor, limit, args)
 
 	rows, err := s.db.QueryContext(ctx, `
-SELECT id, container_name, container_id, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, container_pk, exit_code
-     , parsed_container_name
+SELECT `+alertColumns+`
 FROM alerts
-WHERE id < ?
-ORDER BY id DESC
+WHERE `+where+` AND `+cursorClause+`
+ORDER BY id `+order+`
 LIMIT ?
-`, beforeID, limit)
+`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -716,41 +712,10 @@ LIMIT ?
 
 	items := []Alert{}
 	for rows.Next() {
-		var a Alert
-		var ts string
-		var oldImage, newImage, oldImageID, newImageID, reason, details sql.NullString
-		var exitCode sql.NullInt64
-		var parsedContainerName sql.NullString
-		if err := rows.Scan(&a.ID, &a.Container, &a.ContainerID, &a.Type, &a.Severity, &a.Message, &ts, &oldImage, &newImage, &oldImageID, &newImageID, &reason, &details, &a.ContainerPK, &exitCode, &parsedContainerName); err != nil {
-			return nil, err
-		}
-		a.Timestamp = parseTime(ts)
-		if oldImage.Valid {
-			a.OldImage = oldImage.String
-		}
-		if newImage.Valid {
-			a.NewImage = newImage.String
-		}
-		if oldImageID.Valid {
-			a.OldImageID = oldImageID.String
-		}
-		if newImageID.Valid {
-			a.NewImageID = newImageID.String
-		}
-		if reason.Valid {
-			a.Reason = reason.String
-		}
-		if details.Valid {
-			a.DetailsJSON = details.String
-		}
-		if exitCode.Valid {
-			val := int(exitCode.Int64)
-			a.ExitCode = &val
-		}
-		if parsedContainerName.Valid {
-			a.ParsedContainerName = parsedContainerName.String
+		a, err := s.scanAlert(rows)
+		if err != nil {
+			return nil, err
 		}
-		a.Container = s.resolveContainerName(a.ContainerPK, a.ContainerID, a.Container)
 		items = append(items, a)
 	}
 	if err := rows.Err(); err != nil {
@@ -889,65 +854,91 @@ LIMIT 1
 	return parseTime(ts), true, nil
 }
 
+// RestartTimestampsSince returns the timestamps of a container's restart
+// events at or after since, oldest first. Restarts healthmon caused itself are left out.
+func (s *Store) RestartTimestampsSince(ctx context.Context, containerPK int64, since time.Time) ([]time.Time, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT ts
+FROM events
+WHERE container_pk = ? AND event_type = 'restart' AND COALESCE(reason, '') <> 'self_inflicted' AND ts >= ?
+ORDER BY ts ASC, id ASC
+`, containerPK, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []time.Time
+	for rows.Next() {
+		var ts string
+		if err := rows.Scan(&ts); err != nil {
+			return nil, err
+		}
+		items = append(items, parseTime(ts))
+	}
+	return items, rows.Err()
+}
+
 func (s *Store) GetLatestRestartLoopAlertByContainerPK(ctx context.Context, containerPK int64) (Alert, bool, error) {
-	var a Alert
-	var ts string
-	var oldImage, newImage, oldImageID, newImageID, reason, details sql.NullString
-	var exitCode sql.NullInt64
-	var parsedContainerName sql.NullString
-	err := s.db.QueryRowContext(ctx, `
-SELECT id, container_name, container_id, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, container_pk, exit_code
-     , parsed_container_name
+	return s.GetLatestAlertByContainerPK(ctx, containerPK, "restart_loop", "restart_healed")
+}
+
+func (s *Store) GetLatestAlertByContainerPK(ctx context.Context, containerPK int64, alertTypes ...string) (Alert, bool, error) {
+	if containerPK == 0 || len(alertTypes) == 0 {
+		return Alert{}, false, nil
+	}
+	args := make([]interface{}, 0, len(alertTypes)+1)
+	args = append(args, containerPK)
+	for _, alertType := range alertTypes {
+		args = append(args, alertType)
+	}
+	a, err := s.scanAlert(s.db.QueryRowContext(ctx, `
+SELECT `+alertColumns+`
 FROM alerts
-WHERE container_pk = ? AND alert_type IN ('restart_loop', 'restart_healed')
+WHERE container_pk = ? AND alert_type IN (`+placeholders(len(alertTypes))+`)
 ORDER BY id DESC
 LIMIT 1
-`, containerPK).Scan(&a.ID, &a.Container, &a.ContainerID, &a.Type, &a.Severity, &a.Message, &ts, &oldImage, &newImage, &oldImageID, &newImageID, &reason, &details, &a.ContainerPK, &exitCode, &parsedContainerName)
+`, args...))
 	if err == sql.ErrNoRows {
 		return Alert{}, false, nil
 	}
 	if err != nil {
 		return Alert{}, false, err
 	}
-	a.Timestamp = parseTime(ts)
-	if oldImage.Valid {
-		a.OldImage = oldImage.String
-	}
-	if newImage.Valid {
-		a.NewImage = newImage.String
-	}
-	if oldImageID.Valid {
-		a.OldImageID = oldImageID.String
-	}
-	if newImageID.Valid {
-		a.NewImageID = newImageID.String
-	}
-	if reason.Valid {
-		a.Reason = reason.String
+	return a, true, nil
+}
+
+// GetAlert returns the alert with the given id.
+func (s *Store) GetAlert(ctx context.Context, id int64) (Alert, bool, error) {
+	a, err := s.scanAlert(s.db.QueryRowContext(ctx, `SELECT `+alertColumns+` FROM alerts WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return Alert{}, false, nil
 	}
-	if details.Valid {
-		a.DetailsJSON = details.String
+	if err != nil {
+		return Alert{}, false, err
 	}
-	if exitCode.Valid {
-		val := int(exitCode.Int64)
-		a.ExitCode = &val
+	return a, true, nil
+}
+
+// GetAlertLogs returns the log tail stored with an alert; it reports false
+// when there is none.
+func (s *Store) GetAlertLogs(ctx context.Context, id int64) (string, bool, error) {
+	var logs string
+	err := s.db.QueryRowContext(ctx, `SELECT logs FROM alert_logs WHERE alert_id = ?`, id).Scan(&logs)
+	if err == sql.ErrNoRows {
+		return "", false, nil
 	}
-	if parsedContainerName.Valid {
-		a.ParsedContainerName = parsedContainerName.String
+	if err != nil {
+		return "", false, err
 	}
-	a.Container = s.resolveContainerName(a.ContainerPK, a.ContainerID, a.Container)
-	return a, true, nil
+	return logs, true, nil
 }
 
 func (s *Store) ContainerNames() []string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	names := make([]string, 0, len(s.containers))
-	for name := range s.containers {
-		if !s.containers[name].Present {
-			continue
-		}
-		names = append(names, name)
+	present := s.ListContainers()
+	names := make([]string, 0, len(present))
+	for _, c := range present {
+		names = append(names, c.Name)
 	}
 	sort.Strings(names)
 	return names
@@ -957,59 +948,51 @@ func (s *Store) DeleteContainer(ctx context.Context, name string) error {
 	if name == "" {
 		return nil
 	}
-	if _, err := s.db.ExecContext(ctx, `UPDATE containers SET present = 0, updated_at = ? WHERE name = ?`, formatTime(time.Now().UTC()), name); err != nil {
-		return err
-	}
-	s.mu.Lock()
-	if c, ok := s.containers[name]; ok {
-		c.Present = false
-		c.UpdatedAt = time.Now().UTC()
-	}
-	s.mu.Unlock()
-	return nil
+	return s.SetContainerPresent(ctx, name, false)
 }
 
 func (s *Store) SetContainerPresent(ctx context.Context, name string, present bool) error {
 	if name == "" {
 		return nil
 	}
-	value := 0
-	if present {
-		value = 1
-	}
-	if _, err := s.db.ExecContext(ctx, `UPDATE containers SET present = ?, updated_at = ? WHERE name = ?`, value, formatTime(time.Now().UTC()), name); err != nil {
-		return err
-	}
-	s.mu.Lock()
-	if c, ok := s.containers[name]; ok {
+	now := s.clock.Now()
+	s.cache.update(name, func(c *Container) {
 		c.Present = present
-		c.UpdatedAt = time.Now().UTC()
-	}
-	s.mu.Unlock()
+		c.UpdatedAt = now
+	})
+	s.setPresentAsync(name, present, now)
 	return nil
 }
 
 func (s *Store) MarkAbsentExcept(ctx context.Context, presentNames map[string]struct{}) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for name, c := range s.containers {
-		if _, ok := presentNames[name]; ok {
-			if !c.Present {
-				c.Present = true
-				c.UpdatedAt = time.Now().UTC()
-				_, _ = s.db.ExecContext(ctx, `UPDATE containers SET present = 1, updated_at = ? WHERE name = ?`, formatTime(c.UpdatedAt), name)
-			}
-			continue
-		}
-		if c.Present {
-			c.Present = false
-			c.UpdatedAt = time.Now().UTC()
-			_, _ = s.db.ExecContext(ctx, `UPDATE containers SET present = 0, updated_at = ? WHERE name = ?`, formatTime(c.UpdatedAt), name)
+	now := s.clock.Now()
+	var changed []string
+	s.cache.updateAll(func(c *Container) bool {
+		_, present := presentNames[c.Name]
+		if c.Present == present {
+			return false
 		}
+		c.Present = present
+		c.UpdatedAt = now
+		changed = append(changed, c.Name)
+		return true
+	})
+	for _, name := range changed {
+		_, present := presentNames[name]
+		s.setPresentAsync(name, present, now)
 	}
 	return nil
 }
 
+func (s *Store) setPresentAsync(name string, present bool, updatedAt time.Time) {
+	value := boolToInt(present)
+	ts := formatTime(updatedAt)
+	s.writeContainerAsync(name, "presence of "+name, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE containers SET present = ?, updated_at = ? WHERE name = ?`, value, ts, name)
+		return err
+	})
+}
+
 func (s *Store) RenameContainer(ctx context.Context, oldName, newName string, info Container) error {
 	if newName == "" {
 		return nil
@@ -1043,27 +1026,9 @@ func (s *Store) RenameContainer(ctx context.Context, oldName, newName string, in
 	return s.UpsertContainer(ctx, info)
 }
 
-func (s *Store) findContainerByID(id string) (*Container, string) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for name, c := range s.containers {
-		if c.ContainerID == id {
-			return c, name
-		}
-	}
-	return nil, ""
-}
-
 func (s *Store) FindContainerByID(id string) (Container, string, bool) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	for name, c := range s.containers {
-		if c.ContainerID == id {
-			copy := *c
-			return copy, name, true
-		}
-	}
-	return Container{}, "", false
+	c, ok := s.cache.find(func(c Container) bool { return c.ContainerID == id })
+	return c, c.Name, ok
 }
 
 func (s *Store) latestEventID(ctx context.Context, containerPK int64) (int64, error) {
@@ -1077,6 +1042,201 @@ func (s *Store) latestEventID(ctx context.Context, containerPK int64) (int64, er
 	return id.Int64, nil
 }
 
+const eventColumns = `id, container_name, container_id, event_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, container_pk, exit_code
+     , parsed_container_name`
+
+const containerColumns = `id, name, container_id, current_container_name, image, image_tag, image_id, created_at_container, registered_at, started_at, finished_at, exit_code, status, role, caps, read_only, no_new_privileges, memory_reservation, memory_limit, "user", last_event_id, updated_at, present, health_status, health_failing_streak, unhealthy_since, restart_loop, restart_streak, restart_loop_since, healthcheck, docker_restart_count, unhealthy_grace_seconds, last_success_at, task_max_age_seconds, platform, depends_on, networks, labels, last_health_probe, config_fingerprint, image_digest`
+
+// scanContainer reads a row selected with containerColumns.
+func scanContainer(row rowScanner) (Container, error) {
+	var c Container
+	var capsJSON string
+	var readOnly int
+	var noNewPrivileges int
+	var present int
+	var createdAt string
+	var registeredAt string
+	var startedAt string
+	var finishedAt sql.NullString
+	var exitCode sql.NullInt64
+	var updatedAt string
+	var lastEventID sql.NullInt64
+	var unhealthySince string
+	var restartLoop int
+	var restartLoopSince string
+	var healthcheck sql.NullString
+	var unhealthyGrace int64
+	var lastSuccessAt sql.NullString
+	var taskMaxAge int64
+	var dependsOnJSON string
+	var networksJSON string
+	var labelsJSON string
+	var probeJSON string
+	var configJSON string
+
+	if err := row.Scan(&c.ID, &c.Name, &c.ContainerID, &c.CurrentContainerName, &c.Image, &c.ImageTag, &c.ImageID, &createdAt, &registeredAt, &startedAt, &finishedAt, &exitCode, &c.Status, &c.Role, &capsJSON, &readOnly, &noNewPrivileges, &c.MemoryReservation, &c.MemoryLimit, &c.User, &lastEventID, &updatedAt, &present, &c.HealthStatus, &c.HealthFailingStreak, &unhealthySince, &restartLoop, &c.RestartStreak, &restartLoopSince, &healthcheck, &c.DockerRestartCount, &unhealthyGrace, &lastSuccessAt, &taskMaxAge, &c.Platform, &dependsOnJSON, &networksJSON, &labelsJSON, &probeJSON, &configJSON, &c.ImageDigest); err != nil {
+		return Container{}, err
+	}
+	if err := json.Unmarshal([]byte(capsJSON), &c.Caps); err != nil {
+		return Container{}, err
+	}
+	if err := json.Unmarshal([]byte(dependsOnJSON), &c.DependsOn); err != nil {
+		return Container{}, err
+	}
+	if err := json.Unmarshal([]byte(networksJSON), &c.Networks); err != nil {
+		return Container{}, err
+	}
+	if err := json.Unmarshal([]byte(labelsJSON), &c.Labels); err != nil {
+		return Container{}, err
+	}
+	c.ReadOnly = readOnly == 1
+	c.NoNewPrivileges = noNewPrivileges == 1
+	c.CreatedAt = parseTime(createdAt)
+	c.RegisteredAt = parseTime(registeredAt)
+	c.StartedAt = parseTime(startedAt)
+	if finishedAt.Valid {
+		c.FinishedAt = parseTime(finishedAt.String)
+	}
+	if exitCode.Valid {
+		val := int(exitCode.Int64)
+		c.ExitCode = &val
+	}
+	c.UpdatedAt = parseTime(updatedAt)
+	if lastEventID.Valid {
+		c.LastEventID = lastEventID.Int64
+	}
+	c.Present = present == 1
+	c.UnhealthySince = parseTime(unhealthySince)
+	c.RestartLoop = restartLoop == 1
+	c.RestartLoopSince = parseTime(restartLoopSince)
+	c.UnhealthyGrace = time.Duration(unhealthyGrace) * time.Second
+	if lastSuccessAt.Valid {
+		c.LastSuccessAt = parseTime(lastSuccessAt.String)
+	}
+	c.TaskMaxAge = time.Duration(taskMaxAge) * time.Second
+	parsed, err := parseHealthcheck(healthcheck)
+	if err != nil {
+		return Container{}, err
+	}
+	c.Healthcheck = parsed
+	if probeJSON != "" {
+		c.LastHealthProbe = &HealthProbe{}
+		if err := json.Unmarshal([]byte(probeJSON), c.LastHealthProbe); err != nil {
+			return Container{}, err
+		}
+	}
+	if configJSON != "" {
+		c.Config = &ConfigFingerprint{}
+		if err := json.Unmarshal([]byte(configJSON), c.Config); err != nil {
+			return Container{}, err
+		}
+	}
+	if c.Role == "" {
+		c.Role = "service"
+	}
+	return c, nil
+}
+
+const alertColumns = `id, container_name, container_id, alert_type, severity, message, ts, old_image, new_image, old_image_id, new_image_id, reason, details, container_pk, exit_code
+     , parsed_container_name, incident_id, acknowledged_at, telegram_message_id`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *Store) scanEvent(row rowScanner) (Event, error) {
+	var e Event
+	var ts string
+	var oldImage, newImage, oldImageID, newImageID, reason, details sql.NullString
+	var exitCode sql.NullInt64
+	var parsedContainerName sql.NullString
+	if err := row.Scan(&e.ID, &e.Container, &e.ContainerID, &e.Type, &e.Severity, &e.Message, &ts, &oldImage, &newImage, &oldImageID, &newImageID, &reason, &details, &e.ContainerPK, &exitCode, &parsedContainerName); err != nil {
+		return Event{}, err
+	}
+	e.Timestamp = parseTime(ts)
+	if oldImage.Valid {
+		e.OldImage = oldImage.String
+	}
+	if newImage.Valid {
+		e.NewImage = newImage.String
+	}
+	if oldImageID.Valid {
+		e.OldImageID = oldImageID.String
+	}
+	if newImageID.Valid {
+		e.NewImageID = newImageID.String
+	}
+	if reason.Valid {
+		e.Reason = reason.String
+	}
+	if details.Valid {
+		e.DetailsJSON = details.String
+	}
+	if exitCode.Valid {
+		val := int(exitCode.Int64)
+		e.ExitCode = &val
+	}
+	if parsedContainerName.Valid {
+		e.ParsedContainerName = parsedContainerName.String
+	}
+	e.Container = s.resolveContainerName(e.ContainerPK, e.ContainerID, e.Container)
+	return e, nil
+}
+
+func (s *Store) scanAlert(row rowScanner) (Alert, error) {
+	var a Alert
+	var ts string
+	var oldImage, newImage, oldImageID, newImageID, reason, details sql.NullString
+	var exitCode sql.NullInt64
+	var parsedContainerName sql.NullString
+	var incidentID sql.NullInt64
+	var acknowledgedAt sql.NullString
+	if err := row.Scan(&a.ID, &a.Container, &a.ContainerID, &a.Type, &a.Severity, &a.Message, &ts, &oldImage, &newImage, &oldImageID, &newImageID, &reason, &details, &a.ContainerPK, &exitCode, &parsedContainerName, &incidentID, &acknowledgedAt, &a.TelegramMessageID); err != nil {
+		return Alert{}, err
+	}
+	a.Timestamp = parseTime(ts)
+	if oldImage.Valid {
+		a.OldImage = oldImage.String
+	}
+	if newImage.Valid {
+		a.NewImage = newImage.String
+	}
+	if oldImageID.Valid {
+		a.OldImageID = oldImageID.String
+	}
+	if newImageID.Valid {
+		a.NewImageID = newImageID.String
+	}
+	if reason.Valid {
+		a.Reason = reason.String
+	}
+	if details.Valid {
+		a.DetailsJSON = details.String
+	}
+	if exitCode.Valid {
+		val := int(exitCode.Int64)
+		a.ExitCode = &val
+	}
+	if parsedContainerName.Valid {
+		a.ParsedContainerName = parsedContainerName.String
+	}
+	if incidentID.Valid {
+		a.IncidentID = incidentID.Int64
+	}
+	if acknowledgedAt.Valid {
+		a.AcknowledgedAt = parseTime(acknowledgedAt.String)
+	}
+	a.Container = s.resolveContainerName(a.ContainerPK, a.ContainerID, a.Container)
+	return a, nil
+}
+
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
 func boolToInt(val bool) int {
 	if val {
 		return 1
@@ -1131,6 +1291,55 @@ func marshalHealthcheck(val *Healthcheck) (string, error) {
 	return string(raw), nil
 }
 
+// marshalNames stores a list of names as a JSON array, never null.
+func marshalNames(names []string) (string, error) {
+	if names == nil {
+		names = []string{}
+	}
+	raw, err := json.Marshal(names)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// marshalLabels stores labels as a JSON object, never null.
+func marshalLabels(labels map[string]string) (string, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	raw, err := json.Marshal(labels)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// marshalHealthProbe stores a probe as JSON, or an empty string for none.
+func marshalHealthProbe(val *HealthProbe) (string, error) {
+	if val == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// marshalConfigFingerprint stores a fingerprint as JSON, or an empty string
+// for none.
+func marshalConfigFingerprint(val *ConfigFingerprint) (string, error) {
+	if val == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
 func mustHealthcheck(val *Healthcheck) string {
 	raw, err := marshalHealthcheck(val)
 	if err != nil {
@@ -1171,3 +1380,12 @@ func parseTime(val string) time.Time {
 	}
 	return parsed
 }
+
+// CheckWritable runs a write that changes nothing through the writer, so it
+// fails when the database is read-only, locked or unreachable.
+func (s *Store) CheckWritable(ctx context.Context) error {
+	return s.writer.do(ctx, func(ctx context.Context, q db.Querier) error {
+		_, err := q.ExecContext(ctx, `UPDATE containers SET id = id WHERE 1 = 0`)
+		return err
+	})
+}
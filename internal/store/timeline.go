@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+// TimelineCount is the number of events or alerts of one severity within a
+// time bucket.
+type TimelineCount struct {
+	Start    time.Time
+	Severity string
+	Count    int64
+}
+
+// EventTimeline counts events matching f per severity in buckets of the
+// given size, aligned to the Unix epoch. Empty buckets are omitted.
+func (s *Store) EventTimeline(ctx context.Context, f Filter, bucket time.Duration) ([]TimelineCount, error) {
+	return s.timeline(ctx, f, "events", bucket)
+}
+
+// AlertTimeline is EventTimeline for alerts.
+func (s *Store) AlertTimeline(ctx context.Context, f Filter, bucket time.Duration) ([]TimelineCount, error) {
+	return s.timeline(ctx, f, "alerts", bucket)
+}
+
+func (s *Store) timeline(ctx context.Context, f Filter, table string, bucket time.Duration) ([]TimelineCount, error) {
+	where, args, ok, err := s.filterWhere(ctx, f, table)
+	if err != nil || !ok {
+		return []TimelineCount{}, err
+	}
+	size := int64(bucket / time.Second)
+	if size <= 0 {
+		size = 1
+	}
+
+	epoch := `CAST(strftime('%s', ts) AS INTEGER)`
+	if db.DialectOf(s.db) == db.DialectPostgres {
+		epoch = `CAST(FLOOR(EXTRACT(EPOCH FROM ts)) AS BIGINT)`
+	}
+	start := `(` + epoch + ` / ?) * ?`
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+start+` AS bucket_start, severity, COUNT(1)
+FROM `+table+`
+WHERE `+where+`
+GROUP BY bucket_start, severity
+ORDER BY bucket_start ASC, severity ASC
+`, append([]interface{}{size, size}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []TimelineCount{}
+	for rows.Next() {
+		var item TimelineCount
+		var startUnix int64
+		if err := rows.Scan(&startUnix, &item.Severity, &item.Count); err != nil {
+			return nil, err
+		}
+		item.Start = time.Unix(startUnix, 0).UTC()
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestEventTimelineGroupsBySeverityAndBucket(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.Open(filepath.Join(t.TempDir(), "healthmon.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	if err := st.UpsertContainer(ctx, Container{Name: "nginx", ContainerID: "cid-nginx", Status: "running", StartedAt: base}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	nginx, _ := st.GetContainer("nginx")
+	for _, e := range []struct {
+		severity string
+		offset   time.Duration
+	}{
+		{"blue", 5 * time.Minute},
+		{"blue", 50 * time.Minute},
+		{"red", 55 * time.Minute},
+		{"blue", 2*time.Hour + time.Minute},
+		{"blue", 30 * time.Hour},
+	} {
+		if _, err := st.AddEvent(ctx, Event{ContainerPK: nginx.ID, Container: "nginx", ContainerID: "cid-nginx", Type: "test", Severity: e.severity, Message: "test", Timestamp: base.Add(e.offset)}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	got, err := st.EventTimeline(ctx, Filter{Since: base, Until: base.Add(24 * time.Hour)}, time.Hour)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	want := []TimelineCount{
+		{Start: base, Severity: "blue", Count: 2},
+		{Start: base, Severity: "red", Count: 1},
+		{Start: base.Add(2 * time.Hour), Severity: "blue", Count: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d counts, got %+v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || got[i].Severity != want[i].Severity || got[i].Count != want[i].Count {
+			t.Fatalf("count %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
@@ -67,7 +67,7 @@ func TestUpsertKeepsContainerIDAfterEvents(t *testing.T) {
 		Message:     "Restart event: die",
 		Timestamp:   now.Add(time.Second),
 		Reason:      "die",
-		DetailsJSON: string(mustJSON(map[string]string{"test": "1"})),
+		DetailsJSON: EncodeDetails(RestartDetails{RestartCount: 1}),
 	})
 	if err != nil {
 		t.Fatalf("add event 1: %v", err)
@@ -81,7 +81,7 @@ func TestUpsertKeepsContainerIDAfterEvents(t *testing.T) {
 		Message:     "Container started",
 		Timestamp:   now.Add(2 * time.Second),
 		Reason:      "start",
-		DetailsJSON: string(mustJSON(map[string]string{"test": "2"})),
+		DetailsJSON: EncodeDetails(RestartDetails{RestartCount: 2}),
 	})
 	if err != nil {
 		t.Fatalf("add event 2: %v", err)
@@ -181,7 +181,7 @@ func TestHistoryKeepsParsedContainerNamesSeparateFromServiceName(t *testing.T) {
 		t.Fatalf("rename container: %v", err)
 	}
 
-	events, err := st.ListAllEvents(ctx, 0, 10)
+	events, err := st.ListAllEvents(ctx, Filter{}, 0, 10)
 	if err != nil {
 		t.Fatalf("list events: %v", err)
 	}
@@ -195,7 +195,7 @@ func TestHistoryKeepsParsedContainerNamesSeparateFromServiceName(t *testing.T) {
 		t.Fatalf("expected parsed event name elastic_ride, got %q", events[0].ParsedContainerName)
 	}
 
-	alerts, err := st.ListAllAlerts(ctx, 0, 10)
+	alerts, err := st.ListAllAlerts(ctx, Filter{}, 0, 10)
 	if err != nil {
 		t.Fatalf("list alerts: %v", err)
 	}
@@ -217,3 +217,83 @@ func TestHistoryKeepsParsedContainerNamesSeparateFromServiceName(t *testing.T) {
 		t.Fatalf("expected current container name affine, got %q", updated.CurrentContainerName)
 	}
 }
+
+func TestUpsertContainerWithEventIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	cont := Container{
+		Name:         "imapsync",
+		ContainerID:  "container-aaa",
+		Image:        "imapsync",
+		ImageTag:     "latest",
+		ImageID:      "img-imapsync",
+		CreatedAt:    now,
+		RegisteredAt: now,
+		StartedAt:    now,
+		Status:       "running",
+		Role:         "service",
+		Caps:         []string{},
+		User:         "0:0",
+		UpdatedAt:    now,
+		Present:      true,
+	}
+	event := Event{
+		ContainerID: "container-aaa",
+		Type:        "started",
+		Severity:    "blue",
+		Message:     "Container started",
+		Timestamp:   now,
+	}
+
+	stored, eventID, err := st.UpsertContainerWithEvent(ctx, cont, event)
+	if err != nil {
+		t.Fatalf("upsert with event: %v", err)
+	}
+	if stored.ID == 0 || eventID == 0 {
+		t.Fatalf("expected container and event ids, got %d and %d", stored.ID, eventID)
+	}
+	var lastEventID int64
+	if err := dbConn.SQL.QueryRowContext(ctx, `SELECT last_event_id FROM containers WHERE id = ?`, stored.ID).Scan(&lastEventID); err != nil {
+		t.Fatalf("query last_event_id: %v", err)
+	}
+	if lastEventID != eventID {
+		t.Fatalf("expected last_event_id %d, got %d", eventID, lastEventID)
+	}
+
+	// A failing event insert must not leave the container update behind.
+	if _, err := dbConn.SQL.ExecContext(ctx, `DROP TABLE events`); err != nil {
+		t.Fatalf("drop events: %v", err)
+	}
+	other := cont
+	other.Name = "backup"
+	other.ContainerID = "container-bbb"
+	if _, _, err := st.UpsertContainerWithEvent(ctx, other, event); err == nil {
+		t.Fatalf("expected event insert to fail")
+	}
+	var count int
+	if err := dbConn.SQL.QueryRowContext(ctx, `SELECT COUNT(1) FROM containers WHERE name = ?`, "backup").Scan(&count); err != nil {
+		t.Fatalf("count containers: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("expected container insert to be rolled back")
+	}
+	if _, ok := st.GetContainer("backup"); ok {
+		t.Fatalf("expected failed upsert to stay out of the cache")
+	}
+}
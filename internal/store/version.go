@@ -0,0 +1,18 @@
+package store
+
+import "context"
+
+// DataVersion identifies the state of the stored data: the newest event and
+// alert ids and how many writes were committed since startup. Any write
+// changes it, so it can tag responses for conditional requests.
+type DataVersion struct {
+	MaxEventID int64
+	MaxAlertID int64
+	Writes     int64
+}
+
+func (s *Store) DataVersion(ctx context.Context) (DataVersion, error) {
+	v := DataVersion{Writes: s.writer.commits.Load()}
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE((SELECT MAX(id) FROM events), 0), COALESCE((SELECT MAX(id) FROM alerts), 0)`).Scan(&v.MaxEventID, &v.MaxAlertID)
+	return v, err
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"healthmon/internal/db"
+)
+
+const (
+	writeQueueSize = 1024
+	writeBatchSize = 256
+)
+
+type writeFunc func(ctx context.Context, q db.Querier) error
+
+type writeOp struct {
+	run  writeFunc
+	done chan error
+	// failed is called when an async write fails, after it is logged.
+	failed func(error)
+}
+
+// writer serializes every store write through a single goroutine. Writes that
+// queue up while a batch is being committed are applied together in one
+// transaction, so a burst of Docker events costs one commit instead of one per
+// statement. Each write runs inside its own savepoint so a failing statement
+// only rolls back that write and not the rest of the batch.
+type writer struct {
+	conn db.Querier
+	ops  chan writeOp
+	// commits counts the committed batches and direct writes.
+	commits atomic.Int64
+
+	mu      sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+}
+
+func newWriter(conn db.Querier) *writer {
+	w := &writer{
+		conn:    conn,
+		ops:     make(chan writeOp, writeQueueSize),
+		stopped: make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+// do queues fn and waits until the batch it landed in has been committed.
+func (w *writer) do(ctx context.Context, fn writeFunc) error {
+	done := make(chan error, 1)
+	if !w.enqueue(ctx, writeOp{run: fn, done: done}) {
+		defer w.commits.Add(1)
+		return fn(ctx, w.conn)
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// async queues fn without waiting for it. Failures are logged.
+func (w *writer) async(fn writeFunc) {
+	w.asyncOr(fn, nil)
+}
+
+// asyncOr is async, additionally calling failed when fn fails.
+func (w *writer) asyncOr(fn writeFunc, failed func(error)) {
+	op := writeOp{run: fn, failed: failed}
+	if !w.enqueue(context.Background(), op) {
+		op.finish(fn(context.Background(), w.conn))
+		w.commits.Add(1)
+	}
+}
+
+// enqueue reports false once the writer is closed; the caller then runs the
+// write directly.
+func (w *writer) enqueue(ctx context.Context, op writeOp) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false
+	}
+	select {
+	case w.ops <- op:
+		return true
+	case <-ctx.Done():
+		op.finish(ctx.Err())
+		return true
+	}
+}
+
+// close flushes the queued writes and stops the writer goroutine.
+func (w *writer) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.ops)
+	w.mu.Unlock()
+	<-w.stopped
+}
+
+func (w *writer) loop() {
+	defer close(w.stopped)
+	batch := make([]writeOp, 0, writeBatchSize)
+	for op := range w.ops {
+		batch = append(batch[:0], op)
+	drain:
+		for len(batch) < writeBatchSize {
+			select {
+			case next, ok := <-w.ops:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, next)
+			default:
+				break drain
+			}
+		}
+		w.flush(batch)
+	}
+}
+
+func (w *writer) flush(batch []writeOp) {
+	ctx := context.Background()
+	tx, err := db.Begin(ctx, w.conn)
+	if err != nil {
+		for _, op := range batch {
+			op.finish(err)
+		}
+		return
+	}
+
+	errs := make([]error, len(batch))
+	for i, op := range batch {
+		errs[i] = runInSavepoint(ctx, tx, op.run)
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		for _, op := range batch {
+			op.finish(err)
+		}
+		return
+	}
+	w.commits.Add(1)
+	for i, op := range batch {
+		op.finish(errs[i])
+	}
+}
+
+func runInSavepoint(ctx context.Context, tx db.Tx, fn writeFunc) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT store_write`); err != nil {
+		return err
+	}
+	if err := fn(ctx, tx); err != nil {
+		_, _ = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT store_write`)
+		_, _ = tx.ExecContext(ctx, `RELEASE SAVEPOINT store_write`)
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT store_write`)
+	return err
+}
+
+func (op writeOp) finish(err error) {
+	if op.done != nil {
+		op.done <- err
+		return
+	}
+	if err != nil {
+		log.Printf("store write failed: %v", err)
+		if op.failed != nil {
+			op.failed(err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"healthmon/internal/db"
+)
+
+func TestWriterBatchesConcurrentWritesAndFlushesOnClose(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "healthmon.db")
+	dbConn, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer dbConn.Close()
+
+	if err := dbConn.Migrate(ctx); err != nil {
+		t.Fatalf("migrate db: %v", err)
+	}
+
+	st := New(dbConn.SQL)
+	if err := st.Load(ctx); err != nil {
+		t.Fatalf("load store: %v", err)
+	}
+
+	now := time.Now().UTC()
+	cont := Container{
+		Name:         "imapsync",
+		ContainerID:  "container-aaa",
+		Image:        "imapsync",
+		ImageTag:     "latest",
+		ImageID:      "img-imapsync",
+		CreatedAt:    now,
+		RegisteredAt: now,
+		StartedAt:    now,
+		Status:       "running",
+		Role:         "service",
+		Caps:         []string{},
+		User:         "0:0",
+		UpdatedAt:    now,
+		Present:      true,
+	}
+	if err := st.UpsertContainer(ctx, cont); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	created, _ := st.GetContainer("imapsync")
+
+	const writers = 50
+	var wg sync.WaitGroup
+	ids := make(chan int64, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := st.AddEvent(ctx, Event{
+				ContainerPK: created.ID,
+				Container:   "imapsync",
+				ContainerID: "container-aaa",
+				Type:        "restart",
+				Severity:    "info",
+				Message:     "Container restarted",
+				Timestamp:   now,
+			})
+			if err != nil {
+				t.Errorf("add event: %v", err)
+				return
+			}
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int64]struct{})
+	for id := range ids {
+		if id == 0 {
+			t.Fatalf("expected event id to be set")
+		}
+		seen[id] = struct{}{}
+	}
+	if len(seen) != writers {
+		t.Fatalf("expected %d distinct event ids, got %d", writers, len(seen))
+	}
+
+	// Updates of a known container are persisted in the background.
+	cont.Status = "exited"
+	if err := st.UpsertContainer(ctx, cont); err != nil {
+		t.Fatalf("upsert container: %v", err)
+	}
+	st.Close()
+
+	var status string
+	if err := dbConn.SQL.QueryRowContext(ctx, `SELECT status FROM containers WHERE name = ?`, "imapsync").Scan(&status); err != nil {
+		t.Fatalf("query status: %v", err)
+	}
+	if status != "exited" {
+		t.Fatalf("expected pending upsert to be flushed on close, got status %q", status)
+	}
+}
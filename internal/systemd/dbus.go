@@ -0,0 +1,430 @@
+package systemd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements the small part of the D-Bus wire protocol healthmon
+// needs: authenticating on the system bus and making method calls whose
+// arguments are strings and whose replies are basic types or variants of
+// them. Signals and other messages the bus sends are skipped.
+
+const (
+	msgMethodCall   = 1
+	msgMethodReturn = 2
+	msgError        = 3
+
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSignature   = 8
+
+	// callTimeout bounds a method call including the wait for its reply.
+	callTimeout = 5 * time.Second
+
+	// maxMessage bounds the messages read from the bus, like the D-Bus
+	// limit of 128 MiB but far below it; unit properties are tiny.
+	maxMessage = 1 << 20
+)
+
+// Error is an error reply to a method call.
+type Error struct {
+	Name    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// objectPath marks a string argument or value as an object path.
+type objectPath string
+
+// conn is an authenticated connection to a message bus. Calls are
+// serialized: each waits for its reply before the next is sent.
+type conn struct {
+	mu     sync.Mutex
+	c      net.Conn
+	r      *bufio.Reader
+	serial uint32
+}
+
+// dial connects to a bus address like unix:path=/run/dbus/system_bus_socket
+// or a plain socket path, authenticates as the current user and says Hello.
+func dial(address string, timeout time.Duration) (*conn, error) {
+	path, err := socketPath(address)
+	if err != nil {
+		return nil, err
+	}
+	c, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	bus := &conn{c: c, r: bufio.NewReader(c)}
+	_ = c.SetDeadline(time.Now().Add(timeout))
+	err = bus.auth()
+	_ = c.SetDeadline(time.Time{})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if _, err := bus.call("org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello"); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return bus, nil
+}
+
+func socketPath(address string) (string, error) {
+	if strings.HasPrefix(address, "/") {
+		return address, nil
+	}
+	for _, part := range strings.Split(address, ";") {
+		transport, params, ok := strings.Cut(part, ":")
+		if !ok || transport != "unix" {
+			continue
+		}
+		for _, kv := range strings.Split(params, ",") {
+			if path, ok := strings.CutPrefix(kv, "path="); ok {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported bus address %q, expected unix:path=...", address)
+}
+
+// auth runs the EXTERNAL SASL mechanism, which proves the user through the
+// socket's peer credentials.
+func (b *conn) auth() error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := b.c.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n")); err != nil {
+		return err
+	}
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus authentication rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = b.c.Write([]byte("BEGIN\r\n"))
+	return err
+}
+
+func (b *conn) Close() error {
+	return b.c.Close()
+}
+
+// call invokes a method with string and object path arguments and returns
+// the reply's body values.
+func (b *conn) call(dest string, path objectPath, iface, member string, args ...any) ([]any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.serial++
+	serial := b.serial
+	msg, err := encodeCall(serial, dest, path, iface, member, args...)
+	if err != nil {
+		return nil, err
+	}
+	_ = b.c.SetDeadline(time.Now().Add(callTimeout))
+	defer b.c.SetDeadline(time.Time{})
+	if _, err := b.c.Write(msg); err != nil {
+		return nil, err
+	}
+	for {
+		reply, err := readMessage(b.r)
+		if err != nil {
+			return nil, err
+		}
+		if reply.replySerial != serial || (reply.kind != msgMethodReturn && reply.kind != msgError) {
+			continue
+		}
+		if reply.kind == msgError {
+			e := &Error{Name: reply.errorName}
+			if len(reply.body) > 0 {
+				e.Message, _ = reply.body[0].(string)
+			}
+			return nil, e
+		}
+		return reply.body, nil
+	}
+}
+
+// encodeCall builds a little-endian method call message.
+func encodeCall(serial uint32, dest string, path objectPath, iface, member string, args ...any) ([]byte, error) {
+	body := &encoder{}
+	var sig strings.Builder
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			sig.WriteByte('s')
+			body.string(v)
+		case objectPath:
+			sig.WriteByte('o')
+			body.string(string(v))
+		default:
+			return nil, fmt.Errorf("dbus: unsupported argument type %T", arg)
+		}
+	}
+
+	e := &encoder{}
+	e.bytes('l', msgMethodCall, 0, 1)
+	e.uint32(uint32(len(body.buf)))
+	e.uint32(serial)
+	lengthAt := len(e.buf)
+	e.uint32(0)
+	e.align(8)
+	start := len(e.buf)
+	e.field(fieldPath, "o", string(path))
+	e.field(fieldInterface, "s", iface)
+	e.field(fieldMember, "s", member)
+	e.field(fieldDestination, "s", dest)
+	if sig.Len() > 0 {
+		e.field(fieldSignature, "g", sig.String())
+	}
+	binary.LittleEndian.PutUint32(e.buf[lengthAt:], uint32(len(e.buf)-start))
+	e.align(8)
+	return append(e.buf, body.buf...), nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) bytes(b ...byte) {
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+func (e *encoder) signature(s string) {
+	e.buf = append(e.buf, byte(len(s)))
+	e.buf = append(e.buf, s...)
+	e.buf = append(e.buf, 0)
+}
+
+// field appends a header field, a struct of its code and a variant.
+func (e *encoder) field(code byte, sig, value string) {
+	e.align(8)
+	e.bytes(code)
+	e.signature(sig)
+	if sig == "g" {
+		e.signature(value)
+	} else {
+		e.string(value)
+	}
+}
+
+type message struct {
+	kind        byte
+	serial      uint32
+	path        string
+	member      string
+	replySerial uint32
+	errorName   string
+	body        []any
+}
+
+// readMessage reads one message from the bus and decodes its header and,
+// for the types healthmon understands, its body.
+func readMessage(r io.Reader) (message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return message{}, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return message{}, fmt.Errorf("dbus: invalid endianness %q", fixed[0])
+	}
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	headerLen := 16 + int(fieldsLen)
+	padded := (headerLen + 7) &^ 7
+	total := padded + int(bodyLen)
+	if fieldsLen > maxMessage || bodyLen > maxMessage || total > maxMessage {
+		return message{}, errors.New("dbus: message too large")
+	}
+	buf := make([]byte, total)
+	copy(buf, fixed)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return message{}, err
+	}
+
+	msg := message{kind: fixed[1], serial: order.Uint32(fixed[8:])}
+	d := &decoder{buf: buf[:headerLen], pos: 16, order: order}
+	bodySig := ""
+	for d.pos < headerLen {
+		d.align(8)
+		code, err := d.byte()
+		if err != nil {
+			return message{}, err
+		}
+		value, err := d.variant()
+		if err != nil {
+			return message{}, err
+		}
+		switch code {
+		case fieldPath:
+			msg.path, _ = value.(string)
+		case fieldMember:
+			msg.member, _ = value.(string)
+		case fieldErrorName:
+			msg.errorName, _ = value.(string)
+		case fieldReplySerial:
+			msg.replySerial, _ = value.(uint32)
+		case fieldSignature:
+			bodySig, _ = value.(string)
+		}
+	}
+
+	body := &decoder{buf: buf[padded:], order: order}
+	for _, t := range bodySig {
+		value, err := body.value(byte(t))
+		if err != nil {
+			// Bodies of other shapes, such as signals, are not needed.
+			msg.body = nil
+			break
+		}
+		msg.body = append(msg.body, value)
+	}
+	return msg, nil
+}
+
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errShort = errors.New("dbus: message truncated")
+
+func (d *decoder) align(n int) {
+	d.pos = (d.pos + n - 1) / n * n
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) byte() (byte, error) {
+	b, err := d.take(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *decoder) variant() (any, error) {
+	sig, err := d.signature()
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 1 {
+		return nil, fmt.Errorf("dbus: unsupported variant type %q", sig)
+	}
+	return d.value(sig[0])
+}
+
+func (d *decoder) signature() (string, error) {
+	n, err := d.byte()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.take(int(n) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(b[:n]), nil
+}
+
+// value decodes one basic type, or a variant holding one.
+func (d *decoder) value(t byte) (any, error) {
+	switch t {
+	case 'y':
+		return d.byte()
+	case 'b', 'u', 'i':
+		d.align(4)
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		v := d.order.Uint32(b)
+		switch t {
+		case 'b':
+			return v != 0, nil
+		case 'i':
+			return int32(v), nil
+		}
+		return v, nil
+	case 't', 'x':
+		d.align(8)
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		if t == 'x' {
+			return int64(d.order.Uint64(b)), nil
+		}
+		return d.order.Uint64(b), nil
+	case 's', 'o':
+		d.align(4)
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		n := int(d.order.Uint32(b))
+		s, err := d.take(n + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:n]), nil
+	case 'g':
+		return d.signature()
+	case 'v':
+		return d.variant()
+	default:
+		return nil, fmt.Errorf("dbus: unsupported type %q", t)
+	}
+}
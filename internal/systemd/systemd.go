@@ -0,0 +1,195 @@
+// Package systemd reads the state of systemd units from the system bus, so
+// native services can be monitored next to containers.
+package systemd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBus is the address of the system bus on most distributions.
+const DefaultBus = "unix:path=/run/dbus/system_bus_socket"
+
+const (
+	systemdDest   = "org.freedesktop.systemd1"
+	systemdPath   = "/org/freedesktop/systemd1"
+	managerIface  = "org.freedesktop.systemd1.Manager"
+	unitIface     = "org.freedesktop.systemd1.Unit"
+	serviceIface  = "org.freedesktop.systemd1.Service"
+	propsIface    = "org.freedesktop.DBus.Properties"
+	timedateDest  = "org.freedesktop.timedate1"
+	timedatePath  = "/org/freedesktop/timedate1"
+	dialTimeout   = 5 * time.Second
+	serviceSuffix = ".service"
+)
+
+// Unit is the state of a unit. Restarts, the main process' exit status and
+// pid are only known for services.
+type Unit struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	// ActiveSince and InactiveSince are when the unit last entered and left
+	// the active state; zero if it never did.
+	ActiveSince   time.Time
+	InactiveSince time.Time
+	Restarts      int
+	ExitStatus    int
+	MainPID       int
+}
+
+// Client reads units over a bus connection, which it opens on first use and
+// again after it broke.
+type Client struct {
+	address string
+	mu      sync.Mutex
+	bus     *conn
+}
+
+// New returns a client for the bus at address, e.g. DefaultBus.
+func New(address string) *Client {
+	return &Client{address: address}
+}
+
+// Close closes the bus connection, if one is open.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.bus == nil {
+		return nil
+	}
+	err := c.bus.Close()
+	c.bus = nil
+	return err
+}
+
+// Unit loads and returns the state of the unit called name. Units that do
+// not exist come back with LoadState "not-found".
+func (c *Client) Unit(name string) (Unit, error) {
+	bus, err := c.connect()
+	if err != nil {
+		return Unit{}, err
+	}
+	u, err := readUnit(bus, name)
+	if err != nil {
+		if _, ok := err.(*Error); !ok {
+			// The connection is in an unknown state; start over next time.
+			c.Close()
+		}
+		return Unit{}, err
+	}
+	return u, nil
+}
+
+// NTPSynchronized reports whether systemd-timedated considers the system
+// clock synchronized to a time server.
+func (c *Client) NTPSynchronized() (bool, error) {
+	bus, err := c.connect()
+	if err != nil {
+		return false, err
+	}
+	reply, err := bus.call(timedateDest, timedatePath, propsIface, "Get", timedateDest, "NTPSynchronized")
+	if err != nil {
+		if _, ok := err.(*Error); !ok {
+			c.Close()
+		}
+		return false, err
+	}
+	if len(reply) != 1 {
+		return false, fmt.Errorf("NTPSynchronized: unexpected reply")
+	}
+	synced, ok := reply[0].(bool)
+	if !ok {
+		return false, fmt.Errorf("NTPSynchronized: unexpected reply")
+	}
+	return synced, nil
+}
+
+func (c *Client) connect() (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.bus != nil {
+		return c.bus, nil
+	}
+	bus, err := dial(c.address, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c.bus = bus
+	return bus, nil
+}
+
+func readUnit(bus *conn, name string) (Unit, error) {
+	reply, err := bus.call(systemdDest, systemdPath, managerIface, "LoadUnit", name)
+	if err != nil {
+		return Unit{}, err
+	}
+	if len(reply) != 1 {
+		return Unit{}, fmt.Errorf("LoadUnit %s: unexpected reply", name)
+	}
+	path, _ := reply[0].(string)
+
+	u := Unit{Name: name}
+	get := func(iface, prop string) (any, error) {
+		reply, err := bus.call(systemdDest, objectPath(path), propsIface, "Get", iface, prop)
+		if err != nil {
+			return nil, err
+		}
+		if len(reply) != 1 {
+			return nil, fmt.Errorf("%s.%s of %s: unexpected reply", iface, prop, name)
+		}
+		return reply[0], nil
+	}
+	for prop, target := range map[string]*string{
+		"Description": &u.Description,
+		"LoadState":   &u.LoadState,
+		"ActiveState": &u.ActiveState,
+		"SubState":    &u.SubState,
+	} {
+		value, err := get(unitIface, prop)
+		if err != nil {
+			return Unit{}, err
+		}
+		*target, _ = value.(string)
+	}
+	for prop, target := range map[string]*time.Time{
+		"ActiveEnterTimestamp":   &u.ActiveSince,
+		"InactiveEnterTimestamp": &u.InactiveSince,
+	} {
+		value, err := get(unitIface, prop)
+		if err != nil {
+			return Unit{}, err
+		}
+		if usec, _ := value.(uint64); usec > 0 {
+			*target = time.UnixMicro(int64(usec)).UTC()
+		}
+	}
+
+	if u.LoadState != "loaded" || !isService(name) {
+		return u, nil
+	}
+	for prop, target := range map[string]*int{
+		"NRestarts":      &u.Restarts,
+		"ExecMainStatus": &u.ExitStatus,
+		"MainPID":        &u.MainPID,
+	} {
+		value, err := get(serviceIface, prop)
+		if err != nil {
+			return Unit{}, err
+		}
+		switch v := value.(type) {
+		case uint32:
+			*target = int(v)
+		case int32:
+			*target = int(v)
+		}
+	}
+	return u, nil
+}
+
+func isService(name string) bool {
+	return len(name) > len(serviceSuffix) && name[len(name)-len(serviceSuffix):] == serviceSuffix
+}
@@ -0,0 +1,227 @@
+package systemd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBus answers healthmon's calls like the system bus and systemd would,
+// for one unit and timedated.
+type fakeBus struct {
+	t     *testing.T
+	props map[string]any
+}
+
+func (f fakeBus) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	if line, err := r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "\x00AUTH EXTERNAL ") {
+		f.t.Errorf("unexpected auth %q: %v", line, err)
+		return
+	}
+	c.Write([]byte("OK 1234deadbeef\r\n"))
+	if line, _ := r.ReadString('\n'); line != "BEGIN\r\n" {
+		f.t.Errorf("expected BEGIN, got %q", line)
+		return
+	}
+	var serial uint32 = 100
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		serial++
+		switch msg.member {
+		case "Hello":
+			// The bus greets new connections with a signal before the reply.
+			c.Write(encodeReply(4, serial, 0, "s", ":1.42"))
+			c.Write(encodeReply(msgMethodReturn, serial+1, msg.serial, "s", ":1.42"))
+			serial++
+		case "LoadUnit":
+			c.Write(encodeReply(msgMethodReturn, serial, msg.serial, "o", "/org/freedesktop/systemd1/unit/nginx_2eservice"))
+		case "Get":
+			if (msg.path != "/org/freedesktop/systemd1/unit/nginx_2eservice" && msg.path != timedatePath) || len(msg.body) != 2 {
+				f.t.Errorf("unexpected Get on %s with %v", msg.path, msg.body)
+				return
+			}
+			value, ok := f.props[msg.body[1].(string)]
+			if !ok {
+				c.Write(encodeError(serial, msg.serial, "org.freedesktop.DBus.Error.UnknownProperty", "Unknown property"))
+				continue
+			}
+			c.Write(encodeReply(msgMethodReturn, serial, msg.serial, "v", value))
+		}
+	}
+}
+
+func encodeReply(kind byte, serial, replySerial uint32, sig string, value any) []byte {
+	body := &encoder{}
+	encodeValue(body, sig, value)
+	return encodeMessage(kind, serial, replySerial, "", sig, body.buf)
+}
+
+func encodeError(serial, replySerial uint32, name, text string) []byte {
+	body := &encoder{}
+	body.string(text)
+	return encodeMessage(msgError, serial, replySerial, name, "s", body.buf)
+}
+
+func encodeValue(e *encoder, sig string, value any) {
+	switch v := value.(type) {
+	case string:
+		if sig == "v" {
+			e.signature("s")
+		}
+		e.string(v)
+	case uint64:
+		e.signature("t")
+		e.align(8)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+	case uint32:
+		e.signature("u")
+		e.uint32(v)
+	case int32:
+		e.signature("i")
+		e.uint32(uint32(v))
+	case bool:
+		e.signature("b")
+		if v {
+			e.uint32(1)
+		} else {
+			e.uint32(0)
+		}
+	}
+}
+
+func encodeMessage(kind byte, serial, replySerial uint32, errorName, sig string, body []byte) []byte {
+	e := &encoder{}
+	e.bytes('l', kind, 0, 1)
+	e.uint32(uint32(len(body)))
+	e.uint32(serial)
+	lengthAt := len(e.buf)
+	e.uint32(0)
+	e.align(8)
+	start := len(e.buf)
+	if replySerial != 0 {
+		e.align(8)
+		e.bytes(fieldReplySerial)
+		e.signature("u")
+		e.uint32(replySerial)
+	}
+	if errorName != "" {
+		e.field(fieldErrorName, "s", errorName)
+	}
+	e.field(fieldSignature, "g", sig)
+	binary.LittleEndian.PutUint32(e.buf[lengthAt:], uint32(len(e.buf)-start))
+	e.align(8)
+	return append(e.buf, body...)
+}
+
+func TestClientReadsServiceUnit(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bus.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	bus := fakeBus{t: t, props: map[string]any{
+		"Description":            "A high performance web server",
+		"LoadState":              "loaded",
+		"ActiveState":            "failed",
+		"SubState":               "failed",
+		"ActiveEnterTimestamp":   uint64(since.UnixMicro()),
+		"InactiveEnterTimestamp": uint64(0),
+		"NRestarts":              uint32(3),
+		"ExecMainStatus":         int32(1),
+		"MainPID":                uint32(0),
+	}}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go bus.serve(c)
+		}
+	}()
+
+	client := New("unix:path=" + socket)
+	defer client.Close()
+	u, err := client.Unit("nginx.service")
+	if err != nil {
+		t.Fatalf("unit: %v", err)
+	}
+	want := Unit{
+		Name:        "nginx.service",
+		Description: "A high performance web server",
+		LoadState:   "loaded",
+		ActiveState: "failed",
+		SubState:    "failed",
+		ActiveSince: since,
+		Restarts:    3,
+		ExitStatus:  1,
+	}
+	if u != want {
+		t.Fatalf("unexpected unit:\n got %+v\nwant %+v", u, want)
+	}
+
+	delete(bus.props, "MainPID")
+	if _, err := client.Unit("nginx.service"); err == nil || err.Error() != "org.freedesktop.DBus.Error.UnknownProperty: Unknown property" {
+		t.Fatalf("expected the error reply, got %v", err)
+	}
+	// Error replies keep the connection.
+	bus.props["MainPID"] = uint32(42)
+	if u, err := client.Unit("nginx.service"); err != nil || u.MainPID != 42 {
+		t.Fatalf("expected the second read to succeed, got %+v, %v", u, err)
+	}
+}
+
+func TestClientReadsNTPSynchronized(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bus.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	bus := fakeBus{t: t, props: map[string]any{"NTPSynchronized": false}}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go bus.serve(c)
+		}
+	}()
+
+	client := New("unix:path=" + socket)
+	defer client.Close()
+	if synced, err := client.NTPSynchronized(); err != nil || synced {
+		t.Fatalf("expected an unsynchronized clock, got %v, %v", synced, err)
+	}
+	bus.props["NTPSynchronized"] = true
+	if synced, err := client.NTPSynchronized(); err != nil || !synced {
+		t.Fatalf("expected a synchronized clock, got %v, %v", synced, err)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	for address, want := range map[string]string{
+		DefaultBus:                             "/run/dbus/system_bus_socket",
+		"/run/dbus/system_bus_socket":          "/run/dbus/system_bus_socket",
+		"tcp:host=x;unix:path=/tmp/bus,guid=1": "/tmp/bus",
+	} {
+		if got, err := socketPath(address); err != nil || got != want {
+			t.Fatalf("socketPath(%q) = %q, %v; want %q", address, got, err, want)
+		}
+	}
+	if _, err := socketPath("unix:abstract=/tmp/dbus-x"); err == nil {
+		t.Fatalf("expected abstract sockets to be rejected")
+	}
+}
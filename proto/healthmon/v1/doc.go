@@ -0,0 +1,5 @@
+// Package healthmonv1 holds the messages and the gRPC client and server of
+// healthmon's gRPC API, generated from healthmon.proto.
+package healthmonv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative healthmon/v1/healthmon.proto
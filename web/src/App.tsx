@@ -1,4 +1,4 @@
-import type { CSSProperties } from 'react'
+import type { CSSProperties, SyntheticEvent } from 'react'
 import { useCallback, useEffect, useLayoutEffect, useMemo, useRef, useState } from 'react'
 import { List, useDynamicRowHeight } from 'react-window'
 import logoUrl from './assets/logo.svg'
@@ -7,9 +7,13 @@ import './App.css'
 interface Container {
   id: number
   name: string
+  display_name?: string
+  group?: string
+  labels?: Record<string, string>
   container_id: string
   image: string
   image_tag: string
+  image_digest?: string
   image_id: string
   created_at: string
   registered_at: string
@@ -77,6 +81,28 @@ interface AlertItem {
   reason: string
   details: string
   exit_code?: number | null
+  comments?: AlertComment[]
+  hooks?: AlertHookRun[]
+}
+
+interface AlertComment {
+  id: number
+  alert_id: number
+  author: string
+  body: string
+  created_at: string
+}
+
+interface AlertHookRun {
+  id: number
+  alert_id: number
+  target: string
+  command: string
+  exit_code: number
+  output: string
+  error?: string
+  started_at: string
+  duration_ms: number
 }
 
 interface EventListResponse {
@@ -92,7 +118,11 @@ interface AlertListResponse {
 interface EventUpdate {
   container: Container
   event?: EventItem | null
+  // Earlier events coalesced into this update by the server, oldest first.
+  events?: EventItem[]
   alert?: AlertItem | null
+  comment?: AlertComment | null
+  hook_run?: AlertHookRun | null
   container_event_total?: number
   event_total?: number
   alert_total?: number
@@ -329,6 +359,26 @@ const parseAlertRestartCount = (alert: AlertItem) => {
   }
 }
 
+const parseAlertExitCodes = (alert: AlertItem) => {
+  if (!alert.details) return []
+  try {
+    const parsed = JSON.parse(alert.details) as { exit_codes?: number[] }
+    return Array.isArray(parsed.exit_codes) ? parsed.exit_codes : []
+  } catch {
+    return []
+  }
+}
+
+const parseAlertChangelog = (alert: AlertItem) => {
+  if (!alert.details) return null
+  try {
+    const parsed = JSON.parse(alert.details) as { changelog_url?: string }
+    return typeof parsed.changelog_url === 'string' && parsed.changelog_url ? parsed.changelog_url : null
+  } catch {
+    return null
+  }
+}
+
 const deriveDerivedStatus = (container: Container) => {
   if (container.restart_loop) {
     return {
@@ -354,7 +404,8 @@ export default function App() {
   const [events, setEvents] = useState<Record<string, EventItem[] | undefined>>({})
   const [pages, setPages] = useState<Record<string, PageState | undefined>>({})
   const [flash, setFlash] = useState<Record<string, boolean | undefined>>({})
-  const [query, setQuery] = useState('')
+  // Telegram alerts link to /?container=<name>, which opens filtered to it.
+  const [query, setQuery] = useState(() => new URLSearchParams(window.location.search).get('container') ?? '')
   const [view, setView] = useState<ViewMode>('containers')
   const [allEvents, setAllEvents] = useState<EventItem[]>([])
   const [allEventsPage, setAllEventsPage] = useState<PageState>({ loading: false, done: false })
@@ -580,26 +631,31 @@ export default function App() {
         setFlash((prev) => ({ ...prev, [update.container.name]: false }))
       }, 800)
 
-      const eventUpdate = update.event
-      if (eventUpdate && eventUpdate.id > 0) {
+      const eventUpdates = [...(update.events ?? []), ...(update.event ? [update.event] : [])].filter(
+        (item) => item.id > 0,
+      )
+      if (eventUpdates.length > 0) {
+        const newestFirst = [...eventUpdates].reverse()
         setEvents((prev) => {
           if (!(expanded[update.container.name] ?? false)) return prev
           const current = prev[update.container.name] ?? []
-          if (current.some((item) => item.id === eventUpdate.id)) return prev
+          const added = newestFirst.filter((item) => !current.some((existing) => existing.id === item.id))
+          if (added.length === 0) return prev
           return {
             ...prev,
-            [update.container.name]: [eventUpdate, ...current],
+            [update.container.name]: [...added, ...current],
           }
         })
 
         setAllEvents((prev) => {
-          if (prev.some((item) => item.id === eventUpdate.id)) return prev
-          return [eventUpdate, ...prev]
+          const added = newestFirst.filter((item) => !prev.some((existing) => existing.id === item.id))
+          if (added.length === 0) return prev
+          return [...added, ...prev]
         })
         if (typeof update.event_total === 'number') {
           setAllEventsTotal(update.event_total)
         } else {
-          setAllEventsTotal((prev) => prev + 1)
+          setAllEventsTotal((prev) => prev + eventUpdates.length)
         }
         if (typeof update.container_event_total === 'number') {
           setEventTotals((prev) => ({
@@ -609,7 +665,7 @@ export default function App() {
         } else {
           setEventTotals((prev) => ({
             ...prev,
-            [update.container.name]: (prev[update.container.name] ?? 0) + 1,
+            [update.container.name]: (prev[update.container.name] ?? 0) + eventUpdates.length,
           }))
         }
       }
@@ -626,6 +682,28 @@ export default function App() {
           setAlertsTotal((prev) => prev + 1)
         }
       }
+
+      const commentUpdate = update.comment
+      if (commentUpdate) {
+        setAlerts((prev) =>
+          prev.map((item) =>
+            item.id === commentUpdate.alert_id && !(item.comments ?? []).some((c) => c.id === commentUpdate.id)
+              ? { ...item, comments: [...(item.comments ?? []), commentUpdate] }
+              : item,
+          ),
+        )
+      }
+
+      const hookUpdate = update.hook_run
+      if (hookUpdate) {
+        setAlerts((prev) =>
+          prev.map((item) =>
+            item.id === hookUpdate.alert_id && !(item.hooks ?? []).some((h) => h.id === hookUpdate.id)
+              ? { ...item, hooks: [...(item.hooks ?? []), hookUpdate] }
+              : item,
+          ),
+        )
+      }
     }
 
     return () => {
@@ -824,6 +902,7 @@ function ContainerRow({
   const taskStatus = isTask ? deriveTaskStatus(container) : null
   const statusText = taskStatus?.label ?? displayStatus(container)
   const derivedStatus = deriveDerivedStatus(container)
+  const floatingLatest = !container.image_digest && container.image_tag === 'latest'
   const wentBad = container.restart_loop
     ? formatRelativeTime(container.restart_loop_since)
     : container.health_status.toLowerCase() === 'unhealthy'
@@ -869,12 +948,16 @@ function ContainerRow({
         <div className={`status-dot ${statusDotClass}`} />
         <div className="container-info">
           <div className="name-row">
-            <span className="name container-name">{container.name}</span>
+            <span className="name container-name" title={container.display_name ? container.name : undefined}>
+              {container.display_name || container.name}
+            </span>
             <span className="status-pill">{statusText}</span>
           </div>
           <div className="meta">
             <span className="image-name">
-              {container.image}:{container.image_tag}
+              {container.image}
+              {container.image_tag ? `:${container.image_tag}` : ''}
+              {container.image_digest ? `@${container.image_digest.slice(0, 19)}` : ''}
             </span>
           </div>
         </div>
@@ -941,6 +1024,10 @@ function ContainerRow({
                   <span className="warn-badge">!</span>
                 )}
               </p>
+              <p className={floatingLatest ? 'warn-text' : undefined}>
+                Image pinned: {container.image_digest ? 'by digest' : `no, follows ${container.image_tag || 'a tag'}`}
+                {floatingLatest && <span className="warn-badge">!</span>}
+              </p>
               {!container.read_only && (
                 <p className="warn-text">
                   Read-only: no
@@ -1384,6 +1471,32 @@ interface AlertRowProps {
   rowHeight: ReturnType<typeof useDynamicRowHeight>
 }
 
+// AlertLogs loads the log tail of a restart_loop alert when opened. The
+// logs are served to admins only, so they are not part of the alert.
+function AlertLogs({ alertId }: { alertId: number }) {
+  const [logs, setLogs] = useState<string | null>(null)
+  const handleToggle = (event: SyntheticEvent<HTMLDetailsElement>) => {
+    if (!event.currentTarget.open || logs !== null) return
+    fetch(`/api/alerts/${String(alertId)}/logs`)
+      .then(async (res) => {
+        if (res.status === 403) return 'Only admins can see the logs'
+        if (res.status === 404) return 'No logs were captured'
+        if (!res.ok) throw new Error(`HTTP ${String(res.status)}`)
+        const body = (await res.json()) as { logs: string }
+        return body.logs
+      })
+      .then(setLogs, () => {
+        setLogs('Failed to load the logs')
+      })
+  }
+  return (
+    <details className="event-meta alert-hook" onToggle={handleToggle}>
+      <summary>Last logs</summary>
+      <pre>{logs ?? 'Loading…'}</pre>
+    </details>
+  )
+}
+
 function AlertRow({
   index,
   style,
@@ -1431,6 +1544,8 @@ function AlertRow({
   const title = deriveAlertTitle(alert)
   const changeLine = deriveAlertChangeLine(alert)
   let message = alert.type.toLowerCase() === 'failure_no_restart' ? 'Task failed' : alert.message
+  const exitCodes = alert.type === 'restart_loop' ? parseAlertExitCodes(alert) : []
+  const changelog = alert.type === 'image_changed' ? parseAlertChangelog(alert) : null
   if (alert.type === 'restart_loop') {
     const count = parseAlertRestartCount(alert)
     message = count ? `Restart loop detected (${String(count)} restarts)` : 'Restart loop detected'
@@ -1456,6 +1571,30 @@ function AlertRow({
         <div className="event-identity">{deriveAlertContainerLine(alert)}</div>
         {changeLine && <div className="event-change">{changeLine}</div>}
         {alert.exit_code != null && <div className="event-meta">Exit code: {alert.exit_code}</div>}
+        {exitCodes.length > 0 && (
+          <div className="event-meta">Exit codes: {exitCodes.join(', ')}</div>
+        )}
+        {changelog && (
+          <div className="event-meta">
+            <a href={changelog} target="_blank" rel="noreferrer">
+              Changelog
+            </a>
+          </div>
+        )}
+        {alert.type === 'restart_loop' && <AlertLogs alertId={alert.id} />}
+        {alert.comments?.map((comment) => (
+          <div key={comment.id} className="event-meta alert-comment" title={formatDate(comment.created_at)}>
+            {`${comment.author}: ${comment.body}`}
+          </div>
+        ))}
+        {alert.hooks?.map((hook) => (
+          <details key={hook.id} className="event-meta alert-hook">
+            <summary>
+              {`Hook ${hook.command}: ${hook.error ?? 'ok'} (${hook.duration_ms} ms)`}
+            </summary>
+            {hook.output && <pre>{hook.output}</pre>}
+          </details>
+        ))}
       </div>
     </div>
   )